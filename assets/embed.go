@@ -5,7 +5,7 @@ import (
 	"io/fs"
 )
 
-//go:embed components controllers core crd version release
+//go:embed components controllers core crd version release optional/multus/0*.yaml
 var content embed.FS
 
 func Asset(name string) ([]byte, error) {
@@ -0,0 +1,21 @@
+// microshift-kube-controller-manager runs the kube-controller-manager of
+// MicroShift in its own process, when MicroShift is built with the
+// split_components tag.
+package main
+
+import (
+	"os"
+
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/component-base/cli"
+	kubecm "k8s.io/kubernetes/cmd/kube-controller-manager/app"
+)
+
+func main() {
+	command := kubecm.NewControllerManagerCommand()
+	// The kube-controller-manager of MicroShift stops with the context of
+	// the command instead of handling the signals itself.
+	command.SetContext(genericapiserver.SetupSignalContext())
+	code := cli.Run(command)
+	os.Exit(code)
+}
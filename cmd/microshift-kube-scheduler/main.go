@@ -0,0 +1,16 @@
+// microshift-kube-scheduler runs the kube-scheduler of MicroShift in its own
+// process, when MicroShift is built with the split_components tag.
+package main
+
+import (
+	"os"
+
+	"k8s.io/component-base/cli"
+	kubescheduler "k8s.io/kubernetes/cmd/kube-scheduler/app"
+)
+
+func main() {
+	command := kubescheduler.NewSchedulerCommand()
+	code := cli.Run(command)
+	os.Exit(code)
+}
@@ -40,5 +40,13 @@ func newCommand() *cobra.Command {
 	cmd.AddCommand(cmds.NewShowConfigCommand(ioStreams))
 	cmd.AddCommand(cmds.NewBackupCommand())
 	cmd.AddCommand(cmds.NewRestoreCommand())
+	cmd.AddCommand(cmds.NewKubeconfigCommand(ioStreams))
+	cmd.AddCommand(cmds.NewEtcdCommand(ioStreams))
+	cmd.AddCommand(cmds.NewImagesCommand(ioStreams))
+	cmd.AddCommand(cmds.NewCertsCommand(ioStreams))
+	cmd.AddCommand(cmds.NewPreUpgradeCheckCommand(ioStreams))
+	cmd.AddCommand(cmds.NewStatusCommand(ioStreams))
+	cmd.AddCommand(cmds.NewHealthcheckCommand(ioStreams))
+	cmd.AddCommand(cmds.NewJoinTokenCommand(ioStreams))
 	return cmd
 }
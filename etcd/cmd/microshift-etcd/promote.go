@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+
+	"github.com/spf13/cobra"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/client/pkg/v3/types"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+	"go.etcd.io/etcd/server/v3/etcdserver"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/membership"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v2store"
+	"go.etcd.io/etcd/server/v3/etcdserver/cindex"
+	"go.etcd.io/etcd/server/v3/mvcc/backend"
+	"go.etcd.io/etcd/server/v3/wal"
+	"go.etcd.io/etcd/server/v3/wal/walpb"
+	"go.uber.org/zap"
+	"k8s.io/klog/v2"
+)
+
+// NewPromoteCommand is run by 'microshift etcd promote' on a stopped standby
+// node, it is not meant to be run directly.
+func NewPromoteCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "promote",
+		Short:  "Turn the etcd learner of a standby node into a single member cluster",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if os.Geteuid() > 0 {
+				return fmt.Errorf("microshift-etcd must be run privileged")
+			}
+			cfg, err := config.ActiveConfig()
+			if err != nil {
+				return fmt.Errorf("error in reading and validating MicroShift config: %w", err)
+			}
+			backupDir, err := promote(cfg)
+			if err != nil {
+				return err
+			}
+			klog.Infof("etcd data of the learner kept in %s", backupDir)
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// promote replaces the etcd data of the learner with a single member cluster
+// holding the same keys, and returns the directory the data of the learner
+// is moved to.
+//
+// A learner can not be promoted by the cluster without a quorum of voting
+// members, which is lost with the primary, so the membership is rewritten
+// offline, in the same way as 'etcdutl snapshot restore' does.
+func promote(cfg *config.Config) (string, error) {
+	dataDir := filepath.Join(config.DataDir, "etcd")
+	dbPath := filepath.Join(dataDir, "member", "snap", "db")
+	if _, err := os.Stat(dbPath); err != nil {
+		return "", fmt.Errorf("no etcd database to promote: %w", err)
+	}
+
+	newDir := dataDir + ".promote"
+	if err := os.RemoveAll(newDir); err != nil {
+		return "", err
+	}
+	lg, err := zap.NewProduction()
+	if err != nil {
+		return "", err
+	}
+	// The member of the promoted node is the one of a node without standby,
+	// the peer URL is updated if it becomes a primary again.
+	if err := restoreSingleMember(lg, dbPath, newDir, cfg.Node.HostnameOverride, "https://localhost:2380"); err != nil {
+		_ = os.RemoveAll(newDir)
+		return "", fmt.Errorf("failed to create the etcd data of the promoted member: %w", err)
+	}
+
+	backupDir := fmt.Sprintf("%s.learner-%s", dataDir, time.Now().Format("20060102150405"))
+	if err := os.Rename(dataDir, backupDir); err != nil {
+		return "", err
+	}
+	if err := os.Rename(newDir, dataDir); err != nil {
+		return "", err
+	}
+	return backupDir, nil
+}
+
+// restoreSingleMember creates the data directory dataDir of a cluster with a
+// single member, name at peerURL, from the database at dbPath.
+func restoreSingleMember(lg *zap.Logger, dbPath, dataDir, name, peerURL string) error {
+	urlsMap, err := types.NewURLsMap(name + "=" + peerURL)
+	if err != nil {
+		return err
+	}
+	cl, err := membership.NewClusterFromURLsMap(lg, "etcd-cluster", urlsMap)
+	if err != nil {
+		return err
+	}
+
+	memberDir := filepath.Join(dataDir, "member")
+	snapDir := filepath.Join(memberDir, "snap")
+	if err := os.MkdirAll(snapDir, 0700); err != nil {
+		return err
+	}
+	newDBPath := filepath.Join(snapDir, "db")
+	if err := copyFile(dbPath, newDBPath); err != nil {
+		return err
+	}
+
+	be := backend.NewDefaultBackend(newDBPath)
+	defer be.Close()
+	if err := membership.TrimMembershipFromBackend(lg, be); err != nil {
+		return err
+	}
+
+	st := v2store.New(etcdserver.StoreClusterPrefix, etcdserver.StoreKeysPrefix)
+	cl.SetStore(st)
+	cl.SetBackend(be)
+	member := cl.MemberByName(name)
+	cl.AddMember(member, membership.ApplyBoth)
+
+	metadata, err := (&etcdserverpb.Metadata{NodeID: uint64(member.ID), ClusterID: uint64(cl.ID())}).Marshal()
+	if err != nil {
+		return err
+	}
+	w, err := wal.Create(lg, filepath.Join(memberDir, "wal"), metadata)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	// The log starts with the configuration change adding the member, as
+	// it would for a new cluster.
+	memberJSON, err := json.Marshal(member)
+	if err != nil {
+		return err
+	}
+	cc, err := (&raftpb.ConfChange{Type: raftpb.ConfChangeAddNode, NodeID: uint64(member.ID), Context: memberJSON}).Marshal()
+	if err != nil {
+		return err
+	}
+	const index, term = uint64(1), uint64(1)
+	ents := []raftpb.Entry{{Type: raftpb.EntryConfChange, Term: term, Index: index, Data: cc}}
+	if err := w.Save(raftpb.HardState{Term: term, Vote: uint64(member.ID), Commit: index}, ents); err != nil {
+		return err
+	}
+
+	data, err := st.Save()
+	if err != nil {
+		return err
+	}
+	confState := raftpb.ConfState{Voters: []uint64{uint64(member.ID)}}
+	raftSnap := raftpb.Snapshot{
+		Data:     data,
+		Metadata: raftpb.SnapshotMetadata{Index: index, Term: term, ConfState: confState},
+	}
+	if err := snap.New(lg, snapDir).SaveSnap(raftSnap); err != nil {
+		return err
+	}
+	if err := w.SaveSnapshot(walpb.Snapshot{Index: index, Term: term, ConfState: &confState}); err != nil {
+		return err
+	}
+
+	cindex.UpdateConsistentIndex(be.BatchTx(), index, term)
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
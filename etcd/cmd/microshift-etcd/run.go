@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"runtime/debug"
 	"syscall"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/spf13/cobra"
 	etcd "go.etcd.io/etcd/server/v3/embed"
 	"go.etcd.io/etcd/server/v3/mvcc/backend"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
 )
 
@@ -47,11 +49,24 @@ var tlsCipherSuites = []string{
 	"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305",
 }
 
+// goMemoryLimitRatio is the fraction of etcd.memoryMaxMB used as the soft
+// memory limit of the Go runtime.
+const goMemoryLimitRatio = 0.9
+
+// defragCheckJitter is the maximum delay added to the defragmentation checks,
+// as a factor of their frequency.
+const defragCheckJitter = 0.1
+
 type EtcdService struct {
 	etcdCfg                 *etcd.Config
 	minDefragBytes          int64
 	maxFragmentedPercentage float64
 	defragCheckFreq         time.Duration
+	memoryMaxMB             uint64
+	// tmpfs is set when etcd runs from a tmpfs.
+	tmpfs *tmpfsPersister
+	// diskDir is the data directory of etcd on disk.
+	diskDir string
 }
 
 func NewEtcd(cfg *config.Config) *EtcdService {
@@ -66,6 +81,7 @@ func (s *EtcdService) configure(cfg *config.Config) {
 	s.minDefragBytes = cfg.Etcd.MinDefragBytes
 	s.maxFragmentedPercentage = cfg.Etcd.MaxFragmentedPercentage
 	s.defragCheckFreq = cfg.Etcd.DefragCheckFreq
+	s.memoryMaxMB = cfg.Etcd.MemoryMaxMB
 
 	certsDir := cryptomaterial.CertsDirectory(config.DataDir)
 
@@ -80,6 +96,18 @@ func (s *EtcdService) configure(cfg *config.Config) {
 	//s.etcdCfg.ForceNewCluster = true //TODO
 	s.etcdCfg.Logger = "zap"
 	s.etcdCfg.Dir = dataDir
+	s.diskDir = dataDir
+	if cfg.Etcd.Tmpfs.IsEnabled() {
+		s.tmpfs = &tmpfsPersister{
+			diskDir:  dataDir,
+			tmpfsDir: etcdTmpfsDir,
+			name:     cfg.Node.HostnameOverride,
+			// Defragmentation writes a copy of the database.
+			size:     2 * cfg.Etcd.QuotaBackendBytes,
+			interval: time.Duration(*cfg.Etcd.Tmpfs.PersistIntervalSeconds) * time.Second,
+		}
+		s.etcdCfg.Dir = etcdTmpfsDir
+	}
 	s.etcdCfg.QuotaBackendBytes = cfg.Etcd.QuotaBackendBytes
 	url2380 := setURL([]string{"localhost"}, "2380")
 	url2379 := setURL([]string{"localhost"}, "2379")
@@ -92,6 +120,26 @@ func (s *EtcdService) configure(cfg *config.Config) {
 	s.etcdCfg.Name = cfg.Node.HostnameOverride
 	s.etcdCfg.InitialCluster = fmt.Sprintf("%s=https://%s:2380", cfg.Node.HostnameOverride, "localhost")
 
+	// The members of a primary and a standby node reach each other at their
+	// node IP. The standby joins the cluster of the primary, which added it
+	// as a learner.
+	switch cfg.Etcd.Standby.Role {
+	case config.EtcdStandbyRolePrimary:
+		peerURL := setURL([]string{cfg.Node.NodeIP}, "2380")
+		s.etcdCfg.AdvertisePeerUrls = peerURL
+		s.etcdCfg.ListenPeerUrls = peerURL
+		s.etcdCfg.InitialCluster = fmt.Sprintf("%s=%s", cfg.Node.HostnameOverride, config.EtcdPeerURL(cfg.Node.NodeIP))
+	case config.EtcdStandbyRoleStandby:
+		peerURL := setURL([]string{cfg.Node.NodeIP}, "2380")
+		s.etcdCfg.AdvertisePeerUrls = peerURL
+		s.etcdCfg.ListenPeerUrls = peerURL
+		s.etcdCfg.ClusterState = "existing"
+		// etcd matches the members by their peer URLs, the name of the
+		// primary does not matter.
+		s.etcdCfg.InitialCluster = fmt.Sprintf("%s=%s,primary=%s",
+			cfg.Node.HostnameOverride, config.EtcdPeerURL(cfg.Node.NodeIP), config.EtcdPeerURL(cfg.Etcd.Standby.Peer))
+	}
+
 	s.etcdCfg.CipherSuites = tlsCipherSuites
 	s.etcdCfg.ClientTLSInfo.CertFile = cryptomaterial.PeerCertPath(etcdServingCertDir)
 	s.etcdCfg.ClientTLSInfo.KeyFile = cryptomaterial.PeerKeyPath(etcdServingCertDir)
@@ -110,6 +158,22 @@ func (s *EtcdService) Run() error {
 	versionInfo := EtcdVersionInfo
 	klog.InfoS("Version", "microshift-etcd", versionInfo.String(), "etcd-base", versionInfo.EtcdVersion)
 
+	// Make the garbage collector work harder when getting close to the
+	// hard memory limit of the cgroup, before being killed.
+	if s.memoryMaxMB > 0 {
+		limit := int64(float64(s.memoryMaxMB*1024*1024) * goMemoryLimitRatio)
+		debug.SetMemoryLimit(limit)
+		klog.Infof("Go memory limit set to %d bytes", limit)
+	}
+
+	if s.tmpfs != nil {
+		if err := s.tmpfs.prepare(); err != nil {
+			return fmt.Errorf("failed to prepare the etcd tmpfs: %w", err)
+		}
+	} else if err := restoreToDisk(s.diskDir, etcdTmpfsDir, s.etcdCfg.Name); err != nil {
+		return fmt.Errorf("failed to restore the etcd database persisted from the tmpfs: %w", err)
+	}
+
 	e, err := etcd.StartEtcd(s.etcdCfg)
 	if err != nil {
 		return fmt.Errorf("microshift-etcd failed to start: %v", err)
@@ -130,6 +194,9 @@ func (s *EtcdService) Run() error {
 	// Start up the defrag controller.
 	defragCtx, defragShutdown := context.WithCancel(context.Background())
 	go s.defragController(defragCtx, e.Server.Backend())
+	if s.tmpfs != nil {
+		go s.tmpfs.persistController(defragCtx, e)
+	}
 
 	// Wait to be stopped.
 	sigTerm := make(chan os.Signal, 1)
@@ -140,6 +207,15 @@ func (s *EtcdService) Run() error {
 	// Shutdown the defrag controller.
 	defragShutdown()
 
+	// Persist the latest changes before stopping, so nothing is lost
+	// across the restarts of MicroShift and the reboots.
+	if s.tmpfs != nil {
+		if err := s.tmpfs.persist(e); err != nil {
+			klog.Error(err)
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -150,8 +226,10 @@ func (s *EtcdService) defragController(ctx context.Context, be backend.Backend)
 		return
 	}
 
-	// This timer will check the fragmented conditions periodically.
-	timer := time.NewTimer(s.defragCheckFreq)
+	// This timer will check the fragmented conditions periodically, with
+	// a jitter so devices started at the same time do not defragment at the
+	// same time.
+	timer := time.NewTimer(wait.Jitter(s.defragCheckFreq, defragCheckJitter))
 	defer func() {
 		if !timer.Stop() {
 			<-timer.C
@@ -164,13 +242,14 @@ func (s *EtcdService) defragController(ctx context.Context, be backend.Backend)
 		case start := <-timer.C:
 			if isBackendFragmented(be, s.maxFragmentedPercentage, s.minDefragBytes) {
 				klog.Info("attempting to defragment backend")
+				sizeBefore := be.Size()
 				if err := be.Defrag(); err != nil {
 					klog.Errorf("defragmentation failed: %v", err)
 				} else {
-					klog.Infof("defragmentation took %v", time.Since(start))
+					klog.Infof("defragmentation took %v, dbSize: %d -> %d", time.Since(start), sizeBefore, be.Size())
 				}
 			}
-			timer.Reset(s.defragCheckFreq)
+			timer.Reset(wait.Jitter(s.defragCheckFreq, defragCheckJitter))
 		}
 	}
 }
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.etcd.io/etcd/server/v3/embed"
+	"go.uber.org/zap"
+	"k8s.io/klog/v2"
+)
+
+// etcdTmpfsDir is the data directory of etcd when etcd.tmpfs is enabled.
+// It survives the restarts of MicroShift, but not the reboots of the host.
+const etcdTmpfsDir = "/run/microshift-etcd"
+
+const (
+	// tmpfsSnapshotName is the snapshot of the database persisted in the
+	// etcd directory on disk.
+	tmpfsSnapshotName = "tmpfs-snapshot.db"
+	// tmpfsMarkerName records in the tmpfs which snapshot it is newer than.
+	tmpfsMarkerName = "persisted"
+)
+
+// tmpfsPersister keeps etcd in a tmpfs, restoring it from the snapshot on
+// disk and persisting it back periodically.
+//
+// The snapshot is replaced atomically, so it is always a whole database as
+// of some point in time: a crash or a power loss only loses the changes
+// made since it was taken.
+type tmpfsPersister struct {
+	diskDir  string
+	tmpfsDir string
+	name     string
+	size     int64
+	interval time.Duration
+
+	// mu serializes the periodic persistence with the one on stop.
+	mu      sync.Mutex
+	lastRev int64
+}
+
+func (p *tmpfsPersister) snapshotPath() string {
+	return filepath.Join(p.diskDir, tmpfsSnapshotName)
+}
+
+func (p *tmpfsPersister) markerPath() string {
+	return filepath.Join(p.tmpfsDir, tmpfsMarkerName)
+}
+
+// prepare mounts the tmpfs and fills it with the latest data of etcd: the
+// data already in it when MicroShift restarts without a reboot, or else the
+// snapshot on disk. On the first start with etcd.tmpfs enabled, the
+// database on disk becomes the snapshot.
+func (p *tmpfsPersister) prepare() error {
+	if err := mountTmpfs(p.tmpfsDir, p.size); err != nil {
+		return err
+	}
+
+	diskMemberDir := filepath.Join(p.diskDir, "member")
+	diskDB := filepath.Join(diskMemberDir, "snap", "db")
+	if !exists(p.snapshotPath()) && exists(diskDB) {
+		klog.Infof("Moving the etcd database from %s to %s", diskDB, p.snapshotPath())
+		if err := writeFileAtomic(p.snapshotPath(), func(f *os.File) error {
+			in, err := os.Open(diskDB)
+			if err != nil {
+				return err
+			}
+			defer in.Close()
+			_, err = in.WriteTo(f)
+			return err
+		}); err != nil {
+			return fmt.Errorf("failed to move the etcd database to %s: %w", p.snapshotPath(), err)
+		}
+		if err := os.RemoveAll(diskMemberDir); err != nil {
+			return err
+		}
+	}
+
+	if !exists(p.snapshotPath()) {
+		klog.Infof("Starting a new etcd database in %s", p.tmpfsDir)
+		return clearDir(p.tmpfsDir)
+	}
+	if p.isCurrent() {
+		klog.Infof("Reusing the etcd database in %s, newer than %s", p.tmpfsDir, p.snapshotPath())
+		return nil
+	}
+
+	klog.Infof("Restoring the etcd database in %s from %s", p.tmpfsDir, p.snapshotPath())
+	if err := clearDir(p.tmpfsDir); err != nil {
+		return err
+	}
+	lg, err := zap.NewProduction()
+	if err != nil {
+		return err
+	}
+	if err := restoreSingleMember(lg, p.snapshotPath(), p.tmpfsDir, p.name, "https://localhost:2380"); err != nil {
+		return fmt.Errorf("failed to restore the etcd database from %s: %w", p.snapshotPath(), err)
+	}
+	return p.writeMarker()
+}
+
+// isCurrent returns whether the tmpfs holds the data etcd had when the
+// snapshot on disk was taken, or later. A snapshot replaced since, e.g. by
+// restoring a backup, takes precedence.
+func (p *tmpfsPersister) isCurrent() bool {
+	marker, err := os.ReadFile(p.markerPath())
+	if err != nil {
+		return false
+	}
+	want, err := snapshotVersion(p.snapshotPath())
+	return err == nil && exists(filepath.Join(p.tmpfsDir, "member")) && string(marker) == want
+}
+
+func (p *tmpfsPersister) writeMarker() error {
+	version, err := snapshotVersion(p.snapshotPath())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p.markerPath(), []byte(version), 0600)
+}
+
+// snapshotVersion identifies the snapshot at path by its modification time
+// and size.
+func snapshotVersion(path string) (string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(fi.ModTime().UnixNano(), 10) + "-" + strconv.FormatInt(fi.Size(), 10), nil
+}
+
+// persist replaces the snapshot on disk with the database of e, unless it
+// did not change since the last one.
+func (p *tmpfsPersister) persist(e *embed.Etcd) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rev := e.Server.KV().Rev()
+	if rev == p.lastRev {
+		return nil
+	}
+	start := time.Now()
+	snapshot := e.Server.Backend().Snapshot()
+	defer snapshot.Close()
+	if err := writeFileAtomic(p.snapshotPath(), func(f *os.File) error {
+		_, err := snapshot.WriteTo(f)
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to persist the etcd database to %s: %w", p.snapshotPath(), err)
+	}
+	if err := p.writeMarker(); err != nil {
+		return err
+	}
+	p.lastRev = rev
+	klog.Infof("Persisted the etcd database at revision %d to %s in %v", rev, p.snapshotPath(), time.Since(start))
+	return nil
+}
+
+// persistController persists the database every interval until ctx is done.
+func (p *tmpfsPersister) persistController(ctx context.Context, e *embed.Etcd) {
+	// The database restored from the snapshot is already persisted.
+	p.mu.Lock()
+	p.lastRev = e.Server.KV().Rev()
+	p.mu.Unlock()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.persist(e); err != nil {
+				klog.Error(err)
+			}
+		}
+	}
+}
+
+// restoreToDisk moves the etcd database back to disk after etcd.tmpfs is
+// disabled: from the tmpfs when it is current, or else from the snapshot.
+func restoreToDisk(diskDir, tmpfsDir, name string) error {
+	p := &tmpfsPersister{diskDir: diskDir, tmpfsDir: tmpfsDir}
+	if !exists(p.snapshotPath()) {
+		return nil
+	}
+	source := p.snapshotPath()
+	if p.isCurrent() {
+		source = filepath.Join(tmpfsDir, "member", "snap", "db")
+	}
+	klog.Infof("Restoring the etcd database in %s from %s", diskDir, source)
+
+	newDir := diskDir + ".tmpfs-restore"
+	if err := os.RemoveAll(newDir); err != nil {
+		return err
+	}
+	lg, err := zap.NewProduction()
+	if err != nil {
+		return err
+	}
+	if err := restoreSingleMember(lg, source, newDir, name, "https://localhost:2380"); err != nil {
+		_ = os.RemoveAll(newDir)
+		return fmt.Errorf("failed to restore the etcd database from %s: %w", source, err)
+	}
+	if err := os.RemoveAll(filepath.Join(diskDir, "member")); err != nil {
+		return err
+	}
+	if err := os.Rename(filepath.Join(newDir, "member"), filepath.Join(diskDir, "member")); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(newDir); err != nil {
+		return err
+	}
+	if err := os.Remove(p.snapshotPath()); err != nil {
+		return err
+	}
+
+	// The memory of the tmpfs is only released once it is unmounted.
+	if err := syscall.Unmount(tmpfsDir, 0); err != nil && !errors.Is(err, syscall.EINVAL) && !errors.Is(err, syscall.ENOENT) {
+		klog.Warningf("Failed to unmount %s: %v", tmpfsDir, err)
+	}
+	return os.RemoveAll(tmpfsDir)
+}
+
+// mountTmpfs mounts a tmpfs of at most size bytes at dir, unless it is
+// already mounted.
+func mountTmpfs(dir string, size int64) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	mounted, err := isMountPoint(dir)
+	if err != nil || mounted {
+		return err
+	}
+	klog.Infof("Mounting a tmpfs of %d bytes at %s", size, dir)
+	if err := syscall.Mount("tmpfs", dir, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC,
+		fmt.Sprintf("mode=0700,size=%d", size)); err != nil {
+		return fmt.Errorf("failed to mount a tmpfs at %s: %w", dir, err)
+	}
+	return nil
+}
+
+func isMountPoint(dir string) (bool, error) {
+	var st, parent syscall.Stat_t
+	if err := syscall.Stat(dir, &st); err != nil {
+		return false, err
+	}
+	if err := syscall.Stat(filepath.Dir(dir), &parent); err != nil {
+		return false, err
+	}
+	return st.Dev != parent.Dev, nil
+}
+
+// writeFileAtomic writes path with write to a temporary file next to it,
+// and renames it once synced so a partial file is never left at path.
+func writeFileAtomic(path string, write func(f *os.File) error) error {
+	partPath := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".part")
+	f, err := os.OpenFile(partPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(partPath)
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(partPath, path); err != nil {
+		return err
+	}
+	// The rename is only durable once the directory is synced.
+	d, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func clearDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,11 @@
+#!/bin/bash
+#
+# Wait for the workloads listed in healthCheck.workloads of the MicroShift
+# configuration to be ready. The workloads of MicroShift itself are checked by
+# 40_microshift_running_check.sh.
+#
+# The exit codes of 'microshift healthcheck' tell which check failed, see
+# 'microshift healthcheck --help'.
+set -e
+
+exec /usr/bin/microshift healthcheck --greenboot --core=false
@@ -86,6 +86,25 @@ function microshift_health_endpoints_ok() {
     return 0
 }
 
+# Check if MicroShift refused to start on the current boot because the version
+# of the existing data is not compatible with the version of the executable,
+# printing the reason when it did
+#
+# args: None
+# return: 0 if the upgrade was refused on the current boot, or 1 otherwise
+function upgrade_refused_on_current_boot() {
+    local -r decision_file=/var/lib/microshift-backups/upgrade_decision.json
+    [ -f "${decision_file}" ] || return 1
+
+    local -r boot_id=$(tr -d '-' < /proc/sys/kernel/random/boot_id)
+    local -r refused=$(jq -r --arg boot "${boot_id}" \
+        'select(.boot_id == $boot and .allowed == false) | .reason' "${decision_file}" 2>/dev/null || true)
+    [ -z "${refused}" ] && return 1
+
+    echo "Error: MicroShift refused to start on existing data: ${refused}"
+    return 0
+}
+
 # Check if any MicroShift pods are in the 'Running' status
 #
 # args: None
@@ -124,6 +143,11 @@ WAIT_TIMEOUT_SECS=$(get_wait_timeout)
 # Always log potential MicroShift upgrade errors on failure
 LOG_FAILURE_FILES+=("/var/lib/microshift-backups/prerun_failed.log")
 
+# Fail right away if MicroShift refused the upgrade, there is nothing to wait for
+if upgrade_refused_on_current_boot ; then
+    exit 1
+fi
+
 # Wait for MicroShift service to be active (failed status terminates the script)
 echo "Waiting ${WAIT_TIMEOUT_SECS}s for MicroShift service to be active and not failed"
 if ! wait_for "${WAIT_TIMEOUT_SECS}" microshift_service_active ; then
@@ -21,10 +21,12 @@
 %define selinux_policyver 3.14.3-67
 %define microshift_relabel_files() \
    mkdir -p /var/lib/kubelet/pods; \
+   mkdir -p /var/lib/kubelet/device-plugins; \
    mkdir -p /etc/microshift; \
    mkdir -p /usr/lib/microshift; \
    mkdir -p /var/lib/microshift-backups; # Creating folder to avoid GreenBoot race condition so that correct label is applied \
    restorecon -R /var/lib/kubelet/pods; \
+   restorecon -R /var/lib/kubelet/device-plugins; \
    restorecon -R /var/lib/microshift-backups; \
    restorecon -R /etc/microshift; \
    restorecon -R /usr/lib/microshift
@@ -290,6 +292,10 @@ install -d -m755 %{buildroot}/%{_sysconfdir}/microshift
 install -d -m755 %{buildroot}/%{_sysconfdir}/microshift/manifests
 install -d -m755 %{buildroot}/%{_sysconfdir}/microshift/manifests.d
 install -d -m755 %{buildroot}/%{_sysconfdir}/microshift/config.d
+install -d -m755 %{buildroot}/%{_sysconfdir}/microshift/overrides
+install -d -m755 %{buildroot}/%{_sysconfdir}/microshift/scc
+install -d -m755 %{buildroot}/%{_sysconfdir}/microshift/crd
+install -d -m755 %{buildroot}/%{_sysconfdir}/microshift/static-pods
 install -p -m644 packaging/microshift/config.yaml %{buildroot}%{_sysconfdir}/microshift/config.yaml.default
 install -p -m644 packaging/microshift/lvmd.yaml %{buildroot}%{_sysconfdir}/microshift/lvmd.yaml.default
 install -p -m644 packaging/microshift/ovn.yaml %{buildroot}%{_sysconfdir}/microshift/ovn.yaml.default
@@ -331,6 +337,7 @@ mkdir -p -m755 %{buildroot}%{_sysconfdir}/systemd/system/firewalld.service.d
 install -p -m644 packaging/systemd/firewalld-no-iptables.conf %{buildroot}%{_sysconfdir}/systemd/system/firewalld.service.d/firewalld-no-iptables.conf
 
 mkdir -p -m755 %{buildroot}/var/lib/kubelet/pods
+mkdir -p -m755 %{buildroot}/var/lib/kubelet/device-plugins
 
 install -d %{buildroot}%{_datadir}/selinux/packages/%{selinuxtype}
 install -m644 packaging/selinux/microshift.pp.bz2 %{buildroot}%{_datadir}/selinux/packages/%{selinuxtype}
@@ -341,6 +348,7 @@ install -p -m644 packaging/greenboot/functions.sh %{buildroot}%{_datadir}/micros
 
 install -d -m755 %{buildroot}%{_sysconfdir}/greenboot/check/required.d
 install -p -m755 packaging/greenboot/microshift-running-check.sh %{buildroot}%{_sysconfdir}/greenboot/check/required.d/40_microshift_running_check.sh
+install -p -m755 packaging/greenboot/microshift-running-check-workloads.sh %{buildroot}%{_sysconfdir}/greenboot/check/required.d/42_microshift_running_check_workloads.sh
 
 install -d -m755 %{buildroot}%{_sysconfdir}/greenboot/red.d
 install -p -m755 packaging/greenboot/microshift-pre-rollback.sh %{buildroot}%{_sysconfdir}/greenboot/red.d/40_microshift_pre_rollback.sh
@@ -517,6 +525,10 @@ fi
 %dir %{_sysconfdir}/microshift/config.d
 %dir %{_sysconfdir}/microshift/manifests
 %dir %{_sysconfdir}/microshift/manifests.d
+%dir %{_sysconfdir}/microshift/overrides
+%dir %{_sysconfdir}/microshift/scc
+%dir %{_sysconfdir}/microshift/crd
+%dir %{_sysconfdir}/microshift/static-pods
 %config(noreplace) %{_sysconfdir}/microshift/config.yaml.default
 %config(noreplace) %{_sysconfdir}/microshift/lvmd.yaml.default
 %config(noreplace) %{_sysconfdir}/microshift/ovn.yaml.default
@@ -537,6 +549,7 @@ fi
 
 %files selinux
 /var/lib/kubelet/pods
+/var/lib/kubelet/device-plugins
 %{_datadir}/selinux/packages/%{selinuxtype}/microshift.pp.bz2
 
 
@@ -554,6 +567,7 @@ fi
 
 %files greenboot
 %{_sysconfdir}/greenboot/check/required.d/40_microshift_running_check.sh
+%{_sysconfdir}/greenboot/check/required.d/42_microshift_running_check_workloads.sh
 %{_sysconfdir}/greenboot/red.d/40_microshift_pre_rollback.sh
 %{_datadir}/microshift/functions/greenboot.sh
 %dir %{_datadir}/microshift
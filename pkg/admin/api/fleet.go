@@ -0,0 +1,342 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/openshift/microshift/pkg/admin/prerun"
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/node"
+	"github.com/openshift/microshift/pkg/servicemanager"
+	"github.com/openshift/microshift/pkg/util"
+	"github.com/openshift/microshift/pkg/util/cryptomaterial"
+	"github.com/openshift/microshift/pkg/version"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// maxPendingFleetActions bounds the actions waiting to be run, further
+	// requests are refused until the queue drains.
+	maxPendingFleetActions = 16
+	// maxFleetActions is the number of actions kept for their status.
+	maxFleetActions    = 100
+	fleetActionTimeout = 10 * time.Minute
+)
+
+type fleetActionType string
+
+const (
+	// fleetActionBackup saves a snapshot of etcd in the backups directory.
+	fleetActionBackup fleetActionType = "backup"
+	// fleetActionReload restarts MicroShift with the current configuration.
+	fleetActionReload   fleetActionType = "reload"
+	fleetActionCordon   fleetActionType = "cordon"
+	fleetActionUncordon fleetActionType = "uncordon"
+)
+
+type fleetActionState string
+
+const (
+	fleetActionPending   fleetActionState = "Pending"
+	fleetActionRunning   fleetActionState = "Running"
+	fleetActionSucceeded fleetActionState = "Succeeded"
+	fleetActionFailed    fleetActionState = "Failed"
+)
+
+type fleetActionRequest struct {
+	Type fleetActionType `json:"type"`
+	// SnapshotName is the name of the snapshot of a backup action.
+	SnapshotName string `json:"snapshotName,omitempty"`
+}
+
+type fleetAction struct {
+	fleetActionRequest
+	ID string `json:"id"`
+	// Client is the common name of the certificate of the fleet manager
+	// that requested the action.
+	Client   string           `json:"client"`
+	State    fleetActionState `json:"state"`
+	Created  time.Time        `json:"created"`
+	Finished *time.Time       `json:"finished,omitempty"`
+	// Result is the path of the snapshot of a backup action.
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type fleetStatus struct {
+	Node     string                         `json:"node"`
+	Ready    bool                           `json:"ready"`
+	Services []servicemanager.ServiceStatus `json:"services"`
+	Versions fleetVersions                  `json:"versions"`
+	// PendingActions are the actions not finished yet, in the order they
+	// run.
+	PendingActions []fleetAction `json:"pendingActions"`
+}
+
+type fleetVersions struct {
+	MicroShift string `json:"microshift"`
+	// Data is the version of MicroShift that last used the data.
+	Data string `json:"data"`
+}
+
+type clientNameKey struct{}
+
+// FleetServer serves the status of the device and accepts actions from
+// remote fleet managers, authenticated with client certificates. It
+// reuses the operations of the admin API, run one at a time.
+type FleetServer struct {
+	admin  *Server
+	cordon func(ctx context.Context, unschedulable bool) error
+
+	mu      sync.Mutex
+	nextID  int
+	actions []*fleetAction
+	queue   chan *fleetAction
+}
+
+func NewFleetServer(admin *Server) *FleetServer {
+	return &FleetServer{
+		admin: admin,
+		cordon: func(ctx context.Context, unschedulable bool) error {
+			return node.SetUnschedulable(ctx, admin.cfg, unschedulable)
+		},
+		queue: make(chan *fleetAction, maxPendingFleetActions),
+	}
+}
+
+func (f *FleetServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/status", f.getStatus)
+	mux.HandleFunc("GET /v1/actions", f.getActions)
+	mux.HandleFunc("POST /v1/actions", f.createAction)
+	mux.HandleFunc("GET /v1/actions/{id}", f.getAction)
+	return f.authorizeClient(mux)
+}
+
+// Serve serves the fleet API on fleetAPI.port and runs the actions until
+// ctx is done.
+func (f *FleetServer) Serve(ctx context.Context) error {
+	cfg := f.admin.cfg.FleetAPI
+	clientCAs, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return fmt.Errorf("failed to read fleetAPI.clientCAFile: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(clientCAs) {
+		return fmt.Errorf("no certificate found in fleetAPI.clientCAFile %q", cfg.ClientCAFile)
+	}
+	certFile, keyFile := cfg.CertFile, cfg.KeyFile
+	if certFile == "" {
+		servingDir := cryptomaterial.KubeAPIServerExternalServingCertDir(cryptomaterial.CertsDirectory(config.DataDir))
+		certFile, keyFile = cryptomaterial.ServingCertPath(servingDir), cryptomaterial.ServingKeyPath(servingDir)
+	}
+	// Loaded again on every handshake, for the certificate to be rotated.
+	getCertificate := util.ReloadingKeyPair(certFile, keyFile)
+	if _, err := getCertificate(nil); err != nil {
+		return fmt.Errorf("failed to load the serving certificate of the fleet API: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort("", strconv.Itoa(cfg.Port)))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", cfg.Port, err)
+	}
+	server := &http.Server{
+		Handler: f.Handler(),
+		TLSConfig: &tls.Config{
+			GetCertificate: getCertificate,
+			ClientAuth:     tls.RequireAndVerifyClientCert,
+			ClientCAs:      pool,
+			MinVersion:     tls.VersionTLS12,
+		},
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	go f.runActions(ctx)
+
+	klog.Infof("Serving fleet API on port %d", cfg.Port)
+	if err := server.ServeTLS(listener, "", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// authorizeClient only lets the clients with a verified certificate through,
+// with one of fleetAPI.allowedClientNames if set.
+func (f *FleetServer) authorizeClient(next http.Handler) http.Handler {
+	allowed := f.admin.cfg.FleetAPI.AllowedClientNames
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			http.Error(w, "a client certificate is required to use the MicroShift fleet API", http.StatusUnauthorized)
+			return
+		}
+		name := r.TLS.VerifiedChains[0][0].Subject.CommonName
+		if len(allowed) != 0 && !slices.Contains(allowed, name) {
+			klog.Warningf("Refused fleet API request from %q, which is not in fleetAPI.allowedClientNames", name)
+			http.Error(w, fmt.Sprintf("client %q is not allowed to use the MicroShift fleet API", name), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientNameKey{}, name)))
+	})
+}
+
+func (f *FleetServer) getStatus(w http.ResponseWriter, r *http.Request) {
+	status := fleetStatus{
+		Node:     f.admin.cfg.CanonicalNodeName(),
+		Services: f.admin.services.Statuses(),
+		Versions: fleetVersions{
+			MicroShift: version.Get().String(),
+			Data:       prerun.GetVersionStringOfData(),
+		},
+		PendingActions: []fleetAction{},
+	}
+	select {
+	case <-f.admin.ready:
+		status.Ready = true
+	default:
+	}
+
+	f.mu.Lock()
+	for _, a := range f.actions {
+		if a.State == fleetActionPending || a.State == fleetActionRunning {
+			status.PendingActions = append(status.PendingActions, *a)
+		}
+	}
+	f.mu.Unlock()
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (f *FleetServer) getActions(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	actions := make([]fleetAction, 0, len(f.actions))
+	for _, a := range f.actions {
+		actions = append(actions, *a)
+	}
+	f.mu.Unlock()
+	writeJSON(w, http.StatusOK, actions)
+}
+
+func (f *FleetServer) getAction(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, a := range f.actions {
+		if a.ID == id {
+			writeJSON(w, http.StatusOK, *a)
+			return
+		}
+	}
+	http.Error(w, fmt.Sprintf("unknown action %q", id), http.StatusNotFound)
+}
+
+// createAction queues an action and returns it, its state is followed at
+// /v1/actions/{id}.
+func (f *FleetServer) createAction(w http.ResponseWriter, r *http.Request) {
+	req := fleetActionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	switch req.Type {
+	case fleetActionBackup, fleetActionReload, fleetActionCordon, fleetActionUncordon:
+	default:
+		http.Error(w, fmt.Sprintf("unsupported action type %q", req.Type), http.StatusBadRequest)
+		return
+	}
+	if req.SnapshotName != "" && req.Type != fleetActionBackup {
+		http.Error(w, "snapshotName is only valid for a backup action", http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	a := &fleetAction{
+		fleetActionRequest: req,
+		ID:                 strconv.Itoa(f.nextID),
+		Client:             r.Context().Value(clientNameKey{}).(string),
+		State:              fleetActionPending,
+		Created:            time.Now(),
+	}
+	select {
+	case f.queue <- a:
+	default:
+		http.Error(w, "too many pending actions", http.StatusTooManyRequests)
+		return
+	}
+	f.actions = append(f.actions, a)
+	if len(f.actions) > maxFleetActions {
+		f.actions = f.actions[len(f.actions)-maxFleetActions:]
+	}
+	klog.InfoS("Fleet API action queued", "id", a.ID, "type", a.Type, "client", a.Client)
+	writeJSON(w, http.StatusAccepted, *a)
+}
+
+// runActions runs the queued actions one at a time until ctx is done.
+func (f *FleetServer) runActions(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case a := <-f.queue:
+			f.setState(a, fleetActionRunning, "", nil)
+			result, err := f.run(ctx, a)
+			if err != nil {
+				klog.ErrorS(err, "Fleet API action failed", "id", a.ID, "type", a.Type, "client", a.Client)
+				f.setState(a, fleetActionFailed, "", err)
+				continue
+			}
+			klog.InfoS("Fleet API action succeeded", "id", a.ID, "type", a.Type, "client", a.Client)
+			f.setState(a, fleetActionSucceeded, result, nil)
+		}
+	}
+}
+
+func (f *FleetServer) run(ctx context.Context, a *fleetAction) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, fleetActionTimeout)
+	defer cancel()
+
+	switch a.Type {
+	case fleetActionBackup:
+		resp, err := f.admin.saveSnapshot(ctx, a.SnapshotName)
+		return resp.Path, err
+	case fleetActionReload:
+		// MicroShift stops right away, the action is only reported as
+		// succeeded until then.
+		f.admin.actions.Reload()
+		return "", nil
+	case fleetActionCordon:
+		return "", f.cordon(ctx, true)
+	case fleetActionUncordon:
+		return "", f.cordon(ctx, false)
+	}
+	return "", fmt.Errorf("unsupported action type %q", a.Type)
+}
+
+func (f *FleetServer) setState(a *fleetAction, state fleetActionState, result string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	a.State = state
+	a.Result = result
+	if err != nil {
+		a.Error = err.Error()
+	}
+	if state == fleetActionSucceeded || state == fleetActionFailed {
+		now := time.Now()
+		a.Finished = &now
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/servicemanager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFleetHandler(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.FleetAPI.AllowedClientNames = []string{"fleet-manager"}
+	ready := make(chan struct{})
+	close(ready)
+	f := NewFleetServer(NewServer(cfg, servicemanager.NewServiceManager(), nil, ready, Actions{}))
+	cordoned := make(chan bool, 1)
+	f.cordon = func(ctx context.Context, unschedulable bool) error {
+		cordoned <- unschedulable
+		return nil
+	}
+	handler := f.Handler()
+
+	do := func(client, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if client != "" {
+			cert := &x509.Certificate{Subject: pkix.Name{CommonName: client}}
+			req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, do("", http.MethodGet, "/v1/status", "").Code)
+	assert.Equal(t, http.StatusForbidden, do("someone", http.MethodGet, "/v1/status", "").Code)
+
+	assert.Equal(t, http.StatusBadRequest, do("fleet-manager", http.MethodPost, "/v1/actions", `{"type": "reboot"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do("fleet-manager", http.MethodPost, "/v1/actions", `{"type": "cordon", "snapshotName": "x.db"}`).Code)
+	assert.Equal(t, http.StatusNotFound, do("fleet-manager", http.MethodGet, "/v1/actions/1", "").Code)
+
+	rec := do("fleet-manager", http.MethodPost, "/v1/actions", `{"type": "cordon"}`)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	action := fleetAction{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &action))
+	assert.Equal(t, fleetActionPending, action.State)
+	assert.Equal(t, "fleet-manager", action.Client)
+
+	rec = do("fleet-manager", http.MethodGet, "/v1/status", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	status := fleetStatus{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.True(t, status.Ready)
+	assert.Equal(t, cfg.CanonicalNodeName(), status.Node)
+	require.Len(t, status.PendingActions, 1)
+	assert.Equal(t, action.ID, status.PendingActions[0].ID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go f.runActions(ctx)
+	assert.True(t, <-cordoned)
+
+	assert.Eventually(t, func() bool {
+		rec := do("fleet-manager", http.MethodGet, "/v1/actions/"+action.ID, "")
+		got := fleetAction{}
+		return rec.Code == http.StatusOK && json.Unmarshal(rec.Body.Bytes(), &got) == nil && got.State == fleetActionSucceeded
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,337 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/controllers"
+	"github.com/openshift/microshift/pkg/servicemanager"
+	"github.com/openshift/microshift/pkg/util/cryptomaterial/certchains"
+	"golang.org/x/sys/unix"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// SocketPath is where the admin API is served.
+	SocketPath = "/run/microshift/microshift.sock"
+
+	backupTimeout = 5 * time.Minute
+)
+
+type peerUIDKey struct{}
+
+// Actions are the operations on the running MicroShift that the admin API
+// can trigger.
+type Actions struct {
+	// Reload stops MicroShift without draining the node, so systemd starts
+	// it again with the current configuration.
+	Reload func()
+}
+
+// Server is a small HTTP API on a unix socket for host agents and the CLI.
+// Only root clients are allowed, which is verified with the credentials of
+// the peer of every connection.
+type Server struct {
+	cfg        *config.Config
+	services   *servicemanager.ServiceManager
+	certChains *certchains.CertificateChains
+	ready      <-chan struct{}
+	actions    Actions
+}
+
+func NewServer(cfg *config.Config, services *servicemanager.ServiceManager, certChains *certchains.CertificateChains,
+	ready <-chan struct{}, actions Actions) *Server {
+	return &Server{
+		cfg:        cfg,
+		services:   services,
+		certChains: certChains,
+		ready:      ready,
+		actions:    actions,
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/services", s.getServices)
+	mux.HandleFunc("POST /v1/services/{name}/enable", s.enableService)
+	mux.HandleFunc("POST /v1/services/{name}/disable", s.disableService)
+	mux.HandleFunc("GET /v1/config", s.getConfig)
+	mux.HandleFunc("GET /v1/certificates", s.getCertificates)
+	mux.HandleFunc("GET /v1/readyz", s.getReadyz)
+	mux.HandleFunc("POST /v1/actions/reload", s.reload)
+	mux.HandleFunc("POST /v1/actions/backup", s.backup)
+	mux.HandleFunc("PUT /v1/log-level", s.setLogLevel)
+	if s.cfg.Debugging.Profiling == config.ProfilingStatusEnabled {
+		// pprof.Index serves the named profiles, e.g. goroutine?debug=2 for
+		// a dump of the stacks of all of the goroutines.
+		mux.HandleFunc("GET /debug/pprof/", pprof.Index)
+		mux.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+		mux.HandleFunc("GET /v1/debug/memstats", s.getMemStats)
+	}
+	return authorizeRoot(mux)
+}
+
+// Serve serves the API on SocketPath until ctx is done.
+func (s *Server) Serve(ctx context.Context) error {
+	if err := os.MkdirAll(filepath.Dir(SocketPath), 0700); err != nil {
+		return fmt.Errorf("failed to create dir %q: %w", filepath.Dir(SocketPath), err)
+	}
+	// A stale socket is left behind when MicroShift is killed.
+	if err := os.Remove(SocketPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale socket %q: %w", SocketPath, err)
+	}
+	listener, err := net.Listen("unix", SocketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %q: %w", SocketPath, err)
+	}
+	if err := os.Chmod(SocketPath, 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to restrict permissions of %q: %w", SocketPath, err)
+	}
+
+	server := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+		ConnContext:       withPeerUID,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+
+	klog.Infof("Serving admin API on %s", SocketPath)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// withPeerUID stores the uid of the process on the other end of a unix
+// socket connection in the request context.
+func withPeerUID(ctx context.Context, c net.Conn) context.Context {
+	uc, ok := c.(*net.UnixConn)
+	if !ok {
+		return ctx
+	}
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return ctx
+	}
+	var cred *unix.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil || credErr != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, peerUIDKey{}, cred.Uid)
+}
+
+func authorizeRoot(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if uid, ok := r.Context().Value(peerUIDKey{}).(uint32); !ok || uid != 0 {
+			http.Error(w, "only root is allowed to use the MicroShift admin API", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		klog.Warningf("Failed to write admin API response: %v", err)
+	}
+}
+
+func (s *Server) getServices(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.services.Statuses())
+}
+
+func (s *Server) enableService(w http.ResponseWriter, r *http.Request) {
+	s.toggleService(w, r.PathValue("name"), s.services.EnableService)
+}
+
+func (s *Server) disableService(w http.ResponseWriter, r *http.Request) {
+	s.toggleService(w, r.PathValue("name"), s.services.DisableService)
+}
+
+// toggleService enables or disables an optional service until MicroShift
+// restarts, and returns the status of the service.
+func (s *Server) toggleService(w http.ResponseWriter, name string, toggle func(string) error) {
+	if err := toggle(name); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, servicemanager.ErrUnknownService):
+			status = http.StatusNotFound
+		case errors.Is(err, servicemanager.ErrNotOptional), errors.Is(err, servicemanager.ErrNotStarted):
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	for _, status := range s.services.Statuses() {
+		if status.Name == name {
+			writeJSON(w, http.StatusOK, status)
+			return
+		}
+	}
+}
+
+func (s *Server) getConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.cfg)
+}
+
+func (s *Server) getCertificates(w http.ResponseWriter, r *http.Request) {
+	certs, err := certchains.ListCertificates(s.certChains)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, certs)
+}
+
+func (s *Server) getReadyz(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-s.ready:
+		fmt.Fprintln(w, "ok")
+	default:
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+	}
+}
+
+type memStatsResponse struct {
+	Goroutines int              `json:"goroutines"`
+	MemStats   runtime.MemStats `json:"memStats"`
+	GCStats    debug.GCStats    `json:"gcStats"`
+}
+
+// getMemStats returns the memory and garbage collector statistics of the
+// MicroShift process, to follow its memory growth without taking profiles.
+func (s *Server) getMemStats(w http.ResponseWriter, r *http.Request) {
+	resp := memStatsResponse{Goroutines: runtime.NumGoroutine()}
+	runtime.ReadMemStats(&resp.MemStats)
+	debug.ReadGCStats(&resp.GCStats)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) reload(w http.ResponseWriter, r *http.Request) {
+	klog.Info("Reload requested through the admin API")
+	w.WriteHeader(http.StatusAccepted)
+	s.actions.Reload()
+}
+
+type backupRequest struct {
+	// Name of the snapshot file, defaults to a name based on the current time.
+	Name string `json:"name"`
+}
+
+type backupResponse struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+var (
+	errInvalidSnapshotName = errors.New("invalid snapshot name")
+	errSnapshotExists      = errors.New("already exists")
+)
+
+// backup stores a snapshot of etcd in the backups directory, as a file so
+// it is never mistaken for a data backup by `microshift restore` or pruned
+// like automated backups.
+func (s *Server) backup(w http.ResponseWriter, r *http.Request) {
+	req := backupRequest{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	resp, err := s.saveSnapshot(r.Context(), req.Name)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, errInvalidSnapshotName):
+			status = http.StatusBadRequest
+		case errors.Is(err, errSnapshotExists):
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	klog.Infof("Saved etcd snapshot %s through the admin API", resp.Path)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// saveSnapshot stores a snapshot of etcd named name in the backups
+// directory, with a name based on the current time when empty.
+func (s *Server) saveSnapshot(ctx context.Context, name string) (backupResponse, error) {
+	if name == "" {
+		name = "etcd-snapshot-" + time.Now().UTC().Format("20060102T150405Z") + ".db"
+	}
+	if name != filepath.Base(name) || name == "." || name == ".." {
+		return backupResponse{}, fmt.Errorf("%w %q", errInvalidSnapshotName, name)
+	}
+	path := filepath.Join(config.BackupsDir, name)
+	if _, err := os.Stat(path); err == nil {
+		return backupResponse{}, fmt.Errorf("%q %w", path, errSnapshotExists)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, backupTimeout)
+	defer cancel()
+	client, err := controllers.GetEtcdClient(ctx, s.cfg)
+	if err != nil {
+		return backupResponse{}, fmt.Errorf("failed to connect to etcd: %w", err)
+	}
+	defer client.Close()
+
+	size, err := controllers.SaveEtcdSnapshot(ctx, client, path)
+	if err != nil {
+		return backupResponse{}, err
+	}
+	return backupResponse{Path: path, Size: size}, nil
+}
+
+type logLevelRequest struct {
+	// LogLevel is one of the values of debugging.logLevel in the config.
+	LogLevel string `json:"logLevel"`
+}
+
+// setLogLevel changes the verbosity of MicroShift until it is restarted.
+func (s *Server) setLogLevel(w http.ResponseWriter, r *http.Request) {
+	req := logLevelRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	verbosity, err := config.LogLevelVerbosity(req.LogLevel)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var level klog.Level
+	if err := level.Set(strconv.Itoa(verbosity)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	klog.Infof("Log level changed to %s through the admin API", req.LogLevel)
+	writeJSON(w, http.StatusOK, req)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/servicemanager"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	ready := make(chan struct{})
+	reloaded := false
+	s := NewServer(config.NewDefault(), servicemanager.NewServiceManager(), nil, ready, Actions{
+		Reload: func() { reloaded = true },
+	})
+	handler := s.Handler()
+
+	do := func(uid *uint32, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if uid != nil {
+			req = req.WithContext(context.WithValue(req.Context(), peerUIDKey{}, *uid))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	root, user := uint32(0), uint32(1000)
+
+	assert.Equal(t, http.StatusForbidden, do(nil, http.MethodGet, "/v1/readyz", "").Code)
+	assert.Equal(t, http.StatusForbidden, do(&user, http.MethodGet, "/v1/readyz", "").Code)
+
+	assert.Equal(t, http.StatusServiceUnavailable, do(&root, http.MethodGet, "/v1/readyz", "").Code)
+	close(ready)
+	assert.Equal(t, http.StatusOK, do(&root, http.MethodGet, "/v1/readyz", "").Code)
+
+	assert.Equal(t, http.StatusOK, do(&root, http.MethodGet, "/v1/services", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(&root, http.MethodPost, "/v1/services/unknown/disable", "").Code)
+	assert.Equal(t, http.StatusOK, do(&root, http.MethodGet, "/v1/config", "").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, do(&root, http.MethodPost, "/v1/config", "").Code)
+
+	assert.Equal(t, http.StatusOK, do(&root, http.MethodPut, "/v1/log-level", `{"logLevel": "Normal"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(&root, http.MethodPut, "/v1/log-level", `{"logLevel": "Verbose"}`).Code)
+
+	assert.Equal(t, http.StatusBadRequest, do(&root, http.MethodPost, "/v1/actions/backup", `{"name": "../data"}`).Code)
+
+	assert.Equal(t, http.StatusAccepted, do(&root, http.MethodPost, "/v1/actions/reload", "").Code)
+	assert.True(t, reloaded)
+}
+
+func TestHandlerProfiling(t *testing.T) {
+	root := uint32(0)
+	get := func(handler http.Handler, uid *uint32, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if uid != nil {
+			req = req.WithContext(context.WithValue(req.Context(), peerUIDKey{}, *uid))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	cfg := config.NewDefault()
+	disabled := NewServer(cfg, servicemanager.NewServiceManager(), nil, nil, Actions{}).Handler()
+	assert.Equal(t, http.StatusNotFound, get(disabled, &root, "/debug/pprof/").Code)
+	assert.Equal(t, http.StatusNotFound, get(disabled, &root, "/v1/debug/memstats").Code)
+
+	cfg = config.NewDefault()
+	cfg.Debugging.Profiling = config.ProfilingStatusEnabled
+	enabled := NewServer(cfg, servicemanager.NewServiceManager(), nil, nil, Actions{}).Handler()
+	assert.Equal(t, http.StatusForbidden, get(enabled, nil, "/debug/pprof/").Code)
+	assert.Equal(t, http.StatusOK, get(enabled, &root, "/debug/pprof/").Code)
+
+	rec := get(enabled, &root, "/debug/pprof/goroutine?debug=2")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "goroutine ")
+
+	rec = get(enabled, &root, "/v1/debug/memstats")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	stats := map[string]any{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+	assert.Contains(t, stats, "goroutines")
+	assert.Contains(t, stats, "memStats")
+	assert.Contains(t, stats, "gcStats")
+}
@@ -91,7 +91,7 @@ func (bs Backups) getDangling(deploymentIDs []string) Backups {
 	ds := sets.New(deploymentIDs...)
 
 	for _, b := range bs {
-		deploy := getDeploymentIDForTheBackup(b)
+		deploy := GetDeploymentIDForTheBackup(b)
 
 		if deploy != "" {
 			if !ds.Has(deploy) {
@@ -114,9 +114,9 @@ func isAutomatedBackup(name data.BackupName) bool {
 	return backupNameRegexp.MatchString(string(name))
 }
 
-// getDeploymentIDForTheBackup returns a deployment ID from backup's name
+// GetDeploymentIDForTheBackup returns a deployment ID from backup's name
 // according to the schema: deploy-id_boot-id
-func getDeploymentIDForTheBackup(backup data.BackupName) string {
+func GetDeploymentIDForTheBackup(backup data.BackupName) string {
 	if !isAutomatedBackup(backup) {
 		return ""
 	}
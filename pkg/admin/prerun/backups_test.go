@@ -67,7 +67,7 @@ func Test_isAutomatedBackup(t *testing.T) {
 	}
 }
 
-func Test_getDeploymentIDForTheBackup(t *testing.T) {
+func TestGetDeploymentIDForTheBackup(t *testing.T) {
 	testData := []struct {
 		backupName     data.BackupName
 		expectedResult string
@@ -103,6 +103,6 @@ func Test_getDeploymentIDForTheBackup(t *testing.T) {
 	}
 
 	for _, td := range testData {
-		assert.Equal(t, td.expectedResult, getDeploymentIDForTheBackup(td.backupName))
+		assert.Equal(t, td.expectedResult, GetDeploymentIDForTheBackup(td.backupName))
 	}
 }
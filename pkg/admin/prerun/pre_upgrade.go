@@ -0,0 +1,73 @@
+package prerun
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	"k8s.io/klog/v2"
+)
+
+var (
+	// preUpgradeBackupFilepath describes the backup of the data taken when
+	// the system booted into a new deployment, before MicroShift of the new
+	// deployment touched the data.
+	preUpgradeBackupFilepath = filepath.Join(config.BackupsDir, "pre_upgrade.json")
+)
+
+// PreUpgradeBackup is the backup of the data of the previous deployment,
+// taken on the first start of MicroShift in a new deployment. A rollback to
+// FromDeploymentID returns to this data.
+type PreUpgradeBackup struct {
+	Backup           string `json:"backup"`
+	FromDeploymentID string `json:"from_deployment_id"`
+	ToDeploymentID   string `json:"to_deployment_id"`
+	// DataVersion is the version of MicroShift which last used the data
+	// that was backed up.
+	DataVersion string    `json:"data_version"`
+	BootID      string    `json:"boot_id"`
+	Time        time.Time `json:"time"`
+}
+
+// GetPreUpgradeBackup returns the last pre-upgrade backup, or nil if none
+// was taken.
+func GetPreUpgradeBackup() (*PreUpgradeBackup, error) {
+	data, err := os.ReadFile(preUpgradeBackupFilepath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", preUpgradeBackupFilepath, err)
+	}
+	b := &PreUpgradeBackup{}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", preUpgradeBackupFilepath, err)
+	}
+	return b, nil
+}
+
+// recordPreUpgradeBackup writes b, unless it describes the last pre-upgrade
+// backup already, e.g. when MicroShift restarts within the boot.
+func recordPreUpgradeBackup(b PreUpgradeBackup) error {
+	if last, err := GetPreUpgradeBackup(); err == nil && last != nil &&
+		last.Backup == b.Backup && last.ToDeploymentID == b.ToDeploymentID {
+		return nil
+	}
+
+	klog.InfoS("Recording pre-upgrade backup", "contents", b)
+	data, err := json.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %v: %w", b, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(preUpgradeBackupFilepath), 0700); err != nil {
+		return fmt.Errorf("failed to create dir %q: %w", filepath.Dir(preUpgradeBackupFilepath), err)
+	}
+	if err := os.WriteFile(preUpgradeBackupFilepath, data, 0600); err != nil {
+		return fmt.Errorf("writing %q to %q failed: %w", string(data), preUpgradeBackupFilepath, err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package prerun
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreUpgradeBackup(t *testing.T) {
+	path := preUpgradeBackupFilepath
+	preUpgradeBackupFilepath = filepath.Join(t.TempDir(), "pre_upgrade.json")
+	defer func() { preUpgradeBackupFilepath = path }()
+
+	b, err := GetPreUpgradeBackup()
+	assert.NoError(t, err)
+	assert.Nil(t, b, "no pre-upgrade backup was taken yet")
+
+	first := PreUpgradeBackup{
+		Backup:           "rhel-a.0_boot1",
+		FromDeploymentID: "rhel-a.0",
+		ToDeploymentID:   "rhel-b.0",
+		DataVersion:      "4.16.2",
+		BootID:           "boot2",
+		Time:             time.Date(2024, 10, 16, 10, 0, 0, 0, time.UTC),
+	}
+	assert.NoError(t, recordPreUpgradeBackup(first))
+	b, err = GetPreUpgradeBackup()
+	assert.NoError(t, err)
+	assert.Equal(t, first, *b)
+
+	// A restart within the boot does not replace the record.
+	again := first
+	again.Time = first.Time.Add(time.Minute)
+	assert.NoError(t, recordPreUpgradeBackup(again))
+	b, err = GetPreUpgradeBackup()
+	assert.NoError(t, err)
+	assert.Equal(t, first.Time, b.Time)
+
+	assert.NoError(t, os.WriteFile(preUpgradeBackupFilepath, []byte("{"), 0600))
+	_, err = GetPreUpgradeBackup()
+	assert.Error(t, err)
+}
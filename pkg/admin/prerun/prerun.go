@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	datadir "github.com/openshift/microshift/pkg/admin/data"
 	"github.com/openshift/microshift/pkg/config"
@@ -16,11 +17,15 @@ var (
 	restoreFilepath = filepath.Join(config.BackupsDir, "restore")
 )
 
-func DataManagement(dataManager datadir.Manager) error {
+// DataManagement backs up the data on ostree systems, and restores a backup
+// when instructed to, or when the system rolled back to an older MicroShift
+// and restoreOnRollback is set.
+func DataManagement(dataManager datadir.Manager, restoreOnRollback bool) error {
 	klog.InfoS("START pre-run data management")
 
 	dm := dataManagement{
-		dataManager: dataManager,
+		dataManager:       dataManager,
+		restoreOnRollback: restoreOnRollback,
 	}
 
 	if err := dm.perform(); err != nil {
@@ -33,7 +38,8 @@ func DataManagement(dataManager datadir.Manager) error {
 }
 
 type dataManagement struct {
-	dataManager datadir.Manager
+	dataManager       datadir.Manager
+	restoreOnRollback bool
 }
 
 func (dm *dataManagement) perform() error {
@@ -58,6 +64,13 @@ func (dm *dataManagement) perform() error {
 	}
 	klog.InfoS("END optional restore")
 
+	klog.InfoS("START rollback restore")
+	if err := dm.rollbackRestore(); err != nil {
+		klog.ErrorS(err, "FAIL rollback restore")
+		return err
+	}
+	klog.InfoS("END rollback restore")
+
 	return nil
 }
 
@@ -71,7 +84,7 @@ func (dm *dataManagement) backup() error {
 		return nil
 	}
 
-	versionFileExists, err := util.PathExistsAndIsNotEmpty(versionFilePath)
+	versionFileExists, err := util.PathExistsAndIsNotEmpty(versionFilePath())
 	if err != nil {
 		return fmt.Errorf("checking if version metadata exists failed: %w", err)
 	}
@@ -100,20 +113,49 @@ func (dm *dataManagement) backup() error {
 		return nil
 	}
 
+	// The data was last used in another deployment: this is the first
+	// start after an upgrade (or a rollback), and the backup below is the
+	// one to return to if the new deployment is rolled back.
+	currentDeploymentID, err := GetCurrentDeploymentID()
+	if err != nil {
+		return err
+	}
+	deploymentChanged := versionFile.DeploymentID != currentDeploymentID
+	if deploymentChanged {
+		klog.InfoS("System booted into a new deployment - backing up the data of the previous deployment before starting",
+			"previous", versionFile.DeploymentID, "current", currentDeploymentID)
+	}
+
 	existingBackups, err := getBackups(dm.dataManager)
 	if err != nil {
 		return err
 	}
 
 	newBackupName := versionFile.BackupName()
+	preUpgrade := PreUpgradeBackup{
+		Backup:           string(newBackupName),
+		FromDeploymentID: versionFile.DeploymentID,
+		ToDeploymentID:   currentDeploymentID,
+		DataVersion:      versionFile.Version.String(),
+		BootID:           currentBootID,
+		Time:             time.Now(),
+	}
 	if existingBackups.has(newBackupName) {
 		klog.InfoS("Backup already exists", "name", newBackupName)
+		if deploymentChanged {
+			return recordPreUpgradeBackup(preUpgrade)
+		}
 		return nil
 	}
 
 	if _, err := dm.dataManager.Backup(newBackupName); err != nil {
 		return fmt.Errorf("failed to create backup %q: %w", newBackupName, err)
 	}
+	if deploymentChanged {
+		if err := recordPreUpgradeBackup(preUpgrade); err != nil {
+			return err
+		}
+	}
 
 	// after making a new backup, remove all old backups for the deployment
 	existingBackups.getForDeployment(versionFile.DeploymentID).removeAll(dm.dataManager)
@@ -177,7 +219,7 @@ func (dm *dataManagement) optionalRestore() error {
 		return nil
 	}
 
-	versionFileExists, err := util.PathExistsAndIsNotEmpty(versionFilePath)
+	versionFileExists, err := util.PathExistsAndIsNotEmpty(versionFilePath())
 	if err != nil {
 		return fmt.Errorf("checking if version metadata exists failed: %w", err)
 	}
@@ -302,7 +344,7 @@ func (dm *dataManagement) backup413() error {
 
 func (dm *dataManagement) removeBackupsWithoutExistingDeployments(backups Backups) error {
 	klog.InfoS("Attempting to remove backups for no longer existing deployments")
-	deployments, err := getAllDeploymentIDs()
+	deployments, err := GetAllDeploymentIDs()
 	if err != nil {
 		return err
 	}
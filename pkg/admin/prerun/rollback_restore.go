@@ -0,0 +1,110 @@
+package prerun
+
+import (
+	"fmt"
+
+	datadir "github.com/openshift/microshift/pkg/admin/data"
+	"k8s.io/klog/v2"
+)
+
+// rollbackRestore restores the backup of the current deployment when the
+// system rolled back to it from a deployment whose newer MicroShift already
+// used the data: the MicroShift of the current deployment cannot use that
+// data and would refuse to start.
+func (dm *dataManagement) rollbackRestore() error {
+	ver, err := getVersions()
+	if err != nil {
+		return err
+	}
+	if ver.data == nil {
+		klog.InfoS("MicroShift data does not exist - skipping rollback restore")
+		return nil
+	}
+	if !dataNewerThanExecutable(ver) {
+		klog.InfoS("MicroShift data was not left by a newer MicroShift - skipping rollback restore",
+			"exec", ver.exec, "data", ver.data)
+		return nil
+	}
+
+	versionFile, err := getVersionFile()
+	if err != nil {
+		return fmt.Errorf("loading version metadata failed: %w", err)
+	}
+	currentDeploymentID, err := GetCurrentDeploymentID()
+	if err != nil {
+		return err
+	}
+	if versionFile.DeploymentID == currentDeploymentID {
+		klog.InfoS("MicroShift data is newer than the executable, but it was last used by the current deployment - not restoring",
+			"deploymentID", currentDeploymentID)
+		return nil
+	}
+
+	if !dm.restoreOnRollback {
+		klog.InfoS("WARNING: System rolled back to a deployment with an older MicroShift than the one which last used the data, "+
+			"but restoring on rollback is disabled with upgrade.restoreOnRollback - continuing startup with current data",
+			"exec", ver.exec, "data", ver.data, "deploymentID", currentDeploymentID)
+		return nil
+	}
+
+	backup, err := dm.backupForRollback(currentDeploymentID)
+	if err != nil {
+		return err
+	}
+	if backup == "" {
+		klog.InfoS("WARNING: System rolled back to a deployment with an older MicroShift than the one which last used the data, "+
+			"but there is no backup for the deployment - continuing startup with current data",
+			"exec", ver.exec, "data", ver.data, "deploymentID", currentDeploymentID)
+		return nil
+	}
+
+	klog.InfoS("System rolled back to a deployment with an older MicroShift than the one which last used the data - "+
+		"restoring the backup taken before the upgrade",
+		"exec", ver.exec, "data", ver.data, "deploymentID", currentDeploymentID, "backup", backup,
+		"backupOfCurrentData", versionFile.BackupName())
+	if err := dm.restore(backup, &versionFile); err != nil {
+		return err
+	}
+	klog.InfoS("Restored the backup taken before the upgrade", "backup", backup)
+	return nil
+}
+
+// backupForRollback returns the backup to restore on a rollback to
+// deploymentID: the one taken when the system upgraded from it, or else the
+// one of its last boot. It returns an empty name if there is none.
+func (dm *dataManagement) backupForRollback(deploymentID string) (datadir.BackupName, error) {
+	preUpgrade, err := GetPreUpgradeBackup()
+	if err != nil {
+		klog.ErrorS(err, "Failed to read the pre-upgrade backup record - ignoring")
+	} else if preUpgrade != nil && preUpgrade.FromDeploymentID == deploymentID {
+		name := datadir.BackupName(preUpgrade.Backup)
+		exists, err := dm.dataManager.BackupExists(name)
+		if err != nil {
+			return "", fmt.Errorf("failed to check if backup %q exists: %w", name, err)
+		}
+		if exists {
+			return name, nil
+		}
+		klog.InfoS("Pre-upgrade backup no longer exists - looking for another backup of the deployment",
+			"backup", name, "deploymentID", deploymentID)
+	}
+
+	existingBackups, err := getBackups(dm.dataManager)
+	if err != nil {
+		return "", err
+	}
+	return existingBackups.getForDeployment(deploymentID).getOneOrNone(), nil
+}
+
+// dataNewerThanExecutable returns whether the data was last used by a newer
+// MicroShift, or a newer etcd, than the executable.
+func dataNewerThanExecutable(ver versions) bool {
+	if ver.data == nil {
+		return false
+	}
+	if ver.exec.Major < ver.data.Major ||
+		(ver.exec.Major == ver.data.Major && ver.exec.Minor < ver.data.Minor) {
+		return true
+	}
+	return checkEtcdVersionCompatibility(ver.execEtcd, ver.dataEtcd) != nil
+}
@@ -0,0 +1,64 @@
+package prerun
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataNewerThanExecutable(t *testing.T) {
+	testData := []struct {
+		name     string
+		ver      versions
+		expected bool
+	}{
+		{
+			name:     "no data",
+			ver:      versions{exec: versionMetadata{4, 16, 0}},
+			expected: false,
+		},
+		{
+			name:     "same version",
+			ver:      versions{exec: versionMetadata{4, 16, 0}, data: &versionMetadata{4, 16, 0}},
+			expected: false,
+		},
+		{
+			name:     "upgrade",
+			ver:      versions{exec: versionMetadata{4, 17, 0}, data: &versionMetadata{4, 16, 3}},
+			expected: false,
+		},
+		{
+			name:     "older patch",
+			ver:      versions{exec: versionMetadata{4, 16, 1}, data: &versionMetadata{4, 16, 3}},
+			expected: false,
+		},
+		{
+			name:     "older minor",
+			ver:      versions{exec: versionMetadata{4, 16, 3}, data: &versionMetadata{4, 17, 0}},
+			expected: true,
+		},
+		{
+			name:     "older major",
+			ver:      versions{exec: versionMetadata{4, 16, 3}, data: &versionMetadata{5, 0, 0}},
+			expected: true,
+		},
+		{
+			name: "older etcd",
+			ver: versions{exec: versionMetadata{4, 16, 3}, data: &versionMetadata{4, 16, 3},
+				execEtcd: "3.5", dataEtcd: "3.6"},
+			expected: true,
+		},
+		{
+			name: "newer etcd",
+			ver: versions{exec: versionMetadata{4, 17, 0}, data: &versionMetadata{4, 16, 3},
+				execEtcd: "3.6", dataEtcd: "3.5"},
+			expected: false,
+		},
+	}
+
+	for _, td := range testData {
+		t.Run(td.name, func(t *testing.T) {
+			assert.Equal(t, td.expected, dataNewerThanExecutable(td.ver))
+		})
+	}
+}
@@ -75,7 +75,7 @@ func GetCurrentDeploymentID() (string, error) {
 	return "", fmt.Errorf("could not find booted deployment in %#v", deployments)
 }
 
-func getAllDeploymentIDs() ([]string, error) {
+func GetAllDeploymentIDs() ([]string, error) {
 	deployments, err := getDeploymentsFromOSTree()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get deployments: %w", err)
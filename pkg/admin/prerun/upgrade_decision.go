@@ -0,0 +1,129 @@
+package prerun
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+
+	"k8s.io/klog/v2"
+)
+
+var (
+	// upgradeDecisionFilepath holds the outcome of the version checks of the
+	// last start, for greenboot to fail fast (and roll back) on a refused upgrade.
+	upgradeDecisionFilepath = filepath.Join(config.BackupsDir, "upgrade_decision.json")
+)
+
+// UpgradeDecision describes whether MicroShift of TargetVersion may run
+// using the data of DataVersion.
+type UpgradeDecision struct {
+	// DataVersion is empty when there is no MicroShift data yet.
+	DataVersion   string `json:"data_version,omitempty"`
+	TargetVersion string `json:"target_version"`
+	Allowed       bool   `json:"allowed"`
+	Reason        string `json:"reason,omitempty"`
+	// Forced is set when the checks failed but MicroShift was started with
+	// --force.
+	Forced bool      `json:"forced,omitempty"`
+	BootID string    `json:"boot_id,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// CheckUpgrade decides whether MicroShift of targetVersion ("major.minor.patch")
+// may run using the existing data, applying the same version skew and blocked
+// upgrade rules as on start. If targetVersion is empty, the version of the
+// executable is used. Nothing is written to disk.
+func CheckUpgrade(targetVersion string) (UpgradeDecision, error) {
+	ver, err := getVersions()
+	if err != nil {
+		return UpgradeDecision{}, err
+	}
+	if targetVersion != "" {
+		ver.exec, err = versionMetadataFromString(targetVersion)
+		if err != nil {
+			return UpgradeDecision{}, err
+		}
+		// The etcd version of another release is not known.
+		ver.execEtcd = ""
+	}
+	return decideUpgrade(ver), nil
+}
+
+func decideUpgrade(ver versions) UpgradeDecision {
+	d := UpgradeDecision{
+		TargetVersion: ver.exec.String(),
+		Allowed:       true,
+		Time:          time.Now(),
+	}
+	if ver.data == nil {
+		d.Reason = "no existing data"
+		return d
+	}
+
+	d.DataVersion = ver.data.String()
+	if err := checkVersionCompatibility(ver.exec, *ver.data); err != nil {
+		d.Allowed, d.Reason = false, err.Error()
+		return d
+	}
+	if err := checkEtcdVersionCompatibility(ver.execEtcd, ver.dataEtcd); err != nil {
+		d.Allowed, d.Reason = false, err.Error()
+		return d
+	}
+	if err := isUpgradeBlocked(ver.exec, *ver.data); err != nil {
+		d.Allowed, d.Reason = false, err.Error()
+	}
+	return d
+}
+
+// recordUpgradeDecision writes the decision taken on start, so greenboot can
+// act on it.
+func recordUpgradeDecision(d UpgradeDecision) error {
+	bootID, err := getCurrentBootID()
+	if err != nil {
+		return fmt.Errorf("failed to get current boot ID: %w", err)
+	}
+	d.BootID = bootID
+
+	klog.InfoS("Recording upgrade decision", "contents", d)
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %v: %w", d, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(upgradeDecisionFilepath), 0700); err != nil {
+		return fmt.Errorf("failed to create dir %q: %w", filepath.Dir(upgradeDecisionFilepath), err)
+	}
+	if err := os.WriteFile(upgradeDecisionFilepath, data, 0600); err != nil {
+		return fmt.Errorf("writing %q to %q failed: %w", string(data), upgradeDecisionFilepath, err)
+	}
+	return nil
+}
+
+// UpgradeRefusedOnCurrentBoot returns the reason MicroShift refused to start
+// using the existing data on the current boot, or an empty string if it did
+// not.
+func UpgradeRefusedOnCurrentBoot() (string, error) {
+	data, err := os.ReadFile(upgradeDecisionFilepath)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %q: %w", upgradeDecisionFilepath, err)
+	}
+	d := UpgradeDecision{}
+	if err := json.Unmarshal(data, &d); err != nil {
+		return "", fmt.Errorf("failed to parse %q: %w", upgradeDecisionFilepath, err)
+	}
+	bootID, err := getCurrentBootID()
+	if err != nil {
+		return "", err
+	}
+	if d.BootID != bootID || d.Allowed {
+		return "", nil
+	}
+	return d.Reason, nil
+}
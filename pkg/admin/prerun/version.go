@@ -16,11 +16,9 @@ import (
 	"k8s.io/klog/v2"
 )
 
-var (
-	versionFilePath = filepath.Join(config.DataDir, "version")
+var errDataVersionDoesNotExist = errors.New("version file for MicroShift data does not exist")
 
-	errDataVersionDoesNotExist = errors.New("version file for MicroShift data does not exist")
-)
+func versionFilePath() string { return filepath.Join(config.DataDir, "version") }
 
 type versionFile struct {
 	Version      versionMetadata `json:"version"`
@@ -34,9 +32,12 @@ func (hi *versionFile) BackupName() data.BackupName {
 	return data.BackupName(fmt.Sprintf("%s_%s", hi.DeploymentID, hi.BootID))
 }
 
-func VersionMetadataManagement() error {
+// VersionMetadataManagement checks that the executable can use the existing
+// data and records its version. When force is set, MicroShift starts even if
+// the checks fail.
+func VersionMetadataManagement(force bool) error {
 	klog.InfoS("START version metadata management")
-	if err := versionMetadataManagement(); err != nil {
+	if err := versionMetadataManagement(force); err != nil {
 		klog.ErrorS(err, "FAIL version metadata management")
 		return err
 	}
@@ -44,7 +45,7 @@ func VersionMetadataManagement() error {
 	return nil
 }
 
-func versionMetadataManagement() error {
+func versionMetadataManagement(force bool) error {
 	klog.InfoS("START getting versions")
 	ver, err := getVersions()
 	if err != nil {
@@ -53,23 +54,23 @@ func versionMetadataManagement() error {
 	}
 	klog.InfoS("END getting versions", "exec", ver.exec, "data", ver.data)
 
-	if ver.data == nil {
-		klog.InfoS("SKIP version compatibility checks - data does not exist")
-	} else {
-		klog.InfoS("START version compatibility checks")
-		if err := checkVersionCompatibility(ver.exec, *ver.data); err != nil {
-			klog.ErrorS(err, "FAIL version compatibility checks")
-			return err
-		}
-		klog.InfoS("END version compatibility checks")
-
-		klog.InfoS("START checking if version upgrade is blocked")
-		if err := isUpgradeBlocked(ver.exec, *ver.data); err != nil {
-			klog.ErrorS(err, "FAIL checking if version upgrade is blocked")
-			return err
-		}
-		klog.InfoS("END checking if version upgrade is blocked")
+	klog.InfoS("START version compatibility checks")
+	decision := decideUpgrade(ver)
+	if !decision.Allowed && force {
+		klog.Warningf("Version compatibility checks failed, starting anyway because of --force: %s", decision.Reason)
+		decision.Allowed, decision.Forced = true, true
+	}
+	// The decision is recorded even on first start, so greenboot never
+	// acts on a stale one.
+	if err := recordUpgradeDecision(decision); err != nil {
+		klog.ErrorS(err, "Failed to record upgrade decision - ignoring")
 	}
+	if !decision.Allowed {
+		err := fmt.Errorf("%s\n%s", decision.Reason, versionRemediation(ver))
+		klog.ErrorS(err, "FAIL version compatibility checks")
+		return err
+	}
+	klog.InfoS("END version compatibility checks", "reason", decision.Reason)
 
 	klog.InfoS("START updating version file")
 	if err := updateVersionFile(ver.exec); err != nil {
@@ -78,12 +79,35 @@ func versionMetadataManagement() error {
 	}
 	klog.InfoS("END updating version file")
 
+	klog.InfoS("START updating version history")
+	if err := updateVersionHistory(ver.exec, ver.execEtcd, decision.Forced); err != nil {
+		klog.ErrorS(err, "FAIL updating version history")
+		return err
+	}
+	klog.InfoS("END updating version history")
+
 	return nil
 }
 
+// versionRemediation explains how to recover from failed version
+// compatibility checks.
+func versionRemediation(ver versions) string {
+	return fmt.Sprintf("The data in %s was last used by MicroShift %s and cannot be used by MicroShift %s. To recover, either:\n"+
+		"- install a MicroShift version compatible with the data,\n"+
+		"- restore a backup of the data made by a compatible version with 'microshift restore',\n"+
+		"- remove the data with 'microshift-cleanup-data --all' to start from scratch, or\n"+
+		"- start MicroShift regardless with 'microshift run --force', at the risk of corrupting the data.",
+		config.DataDir, ver.data.String(), ver.exec.String())
+}
+
 type versions struct {
 	exec versionMetadata
 	data *versionMetadata
+
+	// execEtcd and dataEtcd are the etcd versions of the executable and of
+	// the last start, empty when unknown.
+	execEtcd string
+	dataEtcd string
 }
 
 // getVersions obtains and returns versions of executable and data dir.
@@ -94,14 +118,24 @@ func getVersions() (versions, error) {
 		return versions{}, fmt.Errorf("failed to get version of MicroShift executable: %w", err)
 	}
 
+	execEtcdVer, err := getExecEtcdVersion()
+	if err != nil {
+		return versions{}, fmt.Errorf("failed to get etcd version of MicroShift executable: %w", err)
+	}
+
 	vs := versions{
-		exec: execVer,
-		data: nil,
+		exec:     execVer,
+		data:     nil,
+		execEtcd: execEtcdVer,
 	}
 
 	dataVer, err := getVersionOfData()
 	if err == nil {
 		vs.data = &dataVer
+		vs.dataEtcd, err = lastEtcdVersion()
+		if err != nil {
+			return versions{}, fmt.Errorf("failed to get etcd version of existing MicroShift data: %w", err)
+		}
 		return vs, nil
 	}
 
@@ -160,8 +194,8 @@ func updateVersionFile(ver versionMetadata) error {
 		return fmt.Errorf("failed to marshal %v: %w", v, err)
 	}
 
-	if err := os.WriteFile(versionFilePath, data, 0600); err != nil {
-		return fmt.Errorf("writing %q to %q failed: %w", string(data), versionFilePath, err)
+	if err := os.WriteFile(versionFilePath(), data, 0600); err != nil {
+		return fmt.Errorf("writing %q to %q failed: %w", string(data), versionFilePath(), err)
 	}
 
 	if isOstree {
@@ -248,7 +282,7 @@ func getVersionOfData() (versionMetadata, error) {
 }
 
 func getVersionFile() (versionFile, error) {
-	exists, err := util.PathExistsAndIsNotEmpty(versionFilePath)
+	exists, err := util.PathExistsAndIsNotEmpty(versionFilePath())
 	if err != nil {
 		return versionFile{}, fmt.Errorf("checking if path exists failed: %w", err)
 	}
@@ -257,9 +291,9 @@ func getVersionFile() (versionFile, error) {
 		return versionFile{}, errDataVersionDoesNotExist
 	}
 
-	versionFileContents, err := os.ReadFile(versionFilePath)
+	versionFileContents, err := os.ReadFile(versionFilePath())
 	if err != nil {
-		return versionFile{}, fmt.Errorf("reading %q failed: %w", versionFilePath, err)
+		return versionFile{}, fmt.Errorf("reading %q failed: %w", versionFilePath(), err)
 	}
 	return parseVersionFile(versionFileContents)
 }
@@ -0,0 +1,193 @@
+package prerun
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/util"
+)
+
+// versionHistoryFilePath records the versions of MicroShift and etcd which
+// used the data, so a later start can detect a downgrade of either.
+func versionHistoryFilePath() string { return filepath.Join(config.DataDir, "version-history.json") }
+
+var (
+	// etcdExecutable returns the path of microshift-etcd, which is
+	// installed next to the MicroShift executable.
+	etcdExecutable = func() (string, error) {
+		microshiftExecPath, err := os.Executable()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(filepath.Dir(microshiftExecPath), "microshift-etcd"), nil
+	}
+)
+
+// maxVersionHistoryEntries bounds the history, which only gets a new entry
+// when the versions or the boot change.
+const maxVersionHistoryEntries = 50
+
+type versionHistoryEntry struct {
+	Version versionMetadata `json:"version"`
+	// EtcdVersion is the major.minor version of etcd, which determines the
+	// schema of the etcd database.
+	EtcdVersion string    `json:"etcd_version"`
+	BootID      string    `json:"boot_id"`
+	Time        time.Time `json:"time"`
+	// Forced is set when MicroShift was started with --force despite a
+	// failed version compatibility check.
+	Forced bool `json:"forced,omitempty"`
+}
+
+// getExecEtcdVersion returns the etcd version MicroShift runs, e.g. "3.5",
+// as reported by microshift-etcd: it is built separately from MicroShift, so
+// the etcd version MicroShift is built with may be another one.
+func getExecEtcdVersion() (string, error) {
+	etcdPath, err := etcdExecutable()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the path of microshift-etcd: %w", err)
+	}
+	out, err := exec.Command(etcdPath, "version", "-o", "json").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s version: %w", etcdPath, err)
+	}
+	info := struct {
+		EtcdVersion string `json:"etcdVersion"`
+	}{}
+	if err := json.Unmarshal(out, &info); err != nil {
+		return "", fmt.Errorf("failed to parse the output of %s version: %w", etcdPath, err)
+	}
+	// Only the major and minor versions determine the schema of the database.
+	split := strings.Split(info.EtcdVersion, ".")
+	if len(split) < 2 {
+		return "", fmt.Errorf("invalid etcd version %q reported by %s", info.EtcdVersion, etcdPath)
+	}
+	majorMinor := split[0] + "." + split[1]
+	if _, _, err := parseEtcdVersion(majorMinor); err != nil {
+		return "", err
+	}
+	return majorMinor, nil
+}
+
+// getVersionHistory returns the version history of the data, oldest first,
+// or nil if it was never recorded.
+func getVersionHistory() ([]versionHistoryEntry, error) {
+	exists, err := util.PathExistsAndIsNotEmpty(versionHistoryFilePath())
+	if err != nil {
+		return nil, fmt.Errorf("checking if path exists failed: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	contents, err := os.ReadFile(versionHistoryFilePath())
+	if err != nil {
+		return nil, fmt.Errorf("reading %q failed: %w", versionHistoryFilePath(), err)
+	}
+	history := []versionHistoryEntry{}
+	if err := json.Unmarshal(contents, &history); err != nil {
+		return nil, fmt.Errorf("parsing %q failed: %w", versionHistoryFilePath(), err)
+	}
+	return history, nil
+}
+
+// updateVersionHistory adds an entry for the current start with etcdVer, the
+// etcd version of the executable, unless the last entry already describes it.
+func updateVersionHistory(ver versionMetadata, etcdVer string, forced bool) error {
+	history, err := getVersionHistory()
+	if err != nil {
+		return err
+	}
+
+	bootID, err := getCurrentBootID()
+	if err != nil {
+		return fmt.Errorf("failed to get current boot ID: %w", err)
+	}
+	entry := versionHistoryEntry{
+		Version:     ver,
+		EtcdVersion: etcdVer,
+		BootID:      bootID,
+		Time:        time.Now(),
+		Forced:      forced,
+	}
+
+	if n := len(history); n > 0 {
+		last := history[n-1]
+		if last.Version == entry.Version && last.EtcdVersion == entry.EtcdVersion && last.BootID == entry.BootID && !forced {
+			return nil
+		}
+	}
+	history = append(history, entry)
+	if len(history) > maxVersionHistoryEntries {
+		history = history[len(history)-maxVersionHistoryEntries:]
+	}
+
+	klog.InfoS("Adding version history entry", "entry", entry)
+	data, err := json.Marshal(history)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %v: %w", history, err)
+	}
+	if err := os.WriteFile(versionHistoryFilePath(), data, 0600); err != nil {
+		return fmt.Errorf("writing %q failed: %w", versionHistoryFilePath(), err)
+	}
+	return nil
+}
+
+// lastEtcdVersion returns the etcd version which last used the data, or an
+// empty string if it is unknown.
+func lastEtcdVersion() (string, error) {
+	history, err := getVersionHistory()
+	if err != nil {
+		return "", err
+	}
+	if len(history) == 0 {
+		return "", nil
+	}
+	return history[len(history)-1].EtcdVersion, nil
+}
+
+// checkEtcdVersionCompatibility refuses running an etcd older than the one
+// which last wrote the database, as etcd does not support downgrading the
+// schema of its database.
+func checkEtcdVersionCompatibility(execEtcdVer, dataEtcdVer string) error {
+	if dataEtcdVer == "" || execEtcdVer == "" || execEtcdVer == dataEtcdVer {
+		return nil
+	}
+	execMajor, execMinor, err := parseEtcdVersion(execEtcdVer)
+	if err != nil {
+		return err
+	}
+	dataMajor, dataMinor, err := parseEtcdVersion(dataEtcdVer)
+	if err != nil {
+		return err
+	}
+	if execMajor < dataMajor || (execMajor == dataMajor && execMinor < dataMinor) {
+		return fmt.Errorf("etcd (%s) is older than the one which last used the existing data (%s): migrating etcd data to older version is not supported", execEtcdVer, dataEtcdVer)
+	}
+	return nil
+}
+
+func parseEtcdVersion(majorMinor string) (int, int, error) {
+	split := strings.Split(majorMinor, ".")
+	if len(split) != 2 {
+		return 0, 0, fmt.Errorf("invalid etcd version string (%s): expected Major.Minor", majorMinor)
+	}
+	major, err := strconv.Atoi(split[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("converting %q to an int failed: %w", split[0], err)
+	}
+	minor, err := strconv.Atoi(split[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("converting %q to an int failed: %w", split[1], err)
+	}
+	return major, minor, nil
+}
@@ -2,8 +2,11 @@ package prerun
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/openshift/microshift/pkg/config"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -137,3 +140,118 @@ func TestParseVersionFile(t *testing.T) {
 		}
 	}
 }
+
+func TestDecideUpgrade(t *testing.T) {
+	testData := []struct {
+		name    string
+		ver     versions
+		allowed bool
+	}{
+		{
+			name:    "first start",
+			ver:     versions{exec: versionMetadata{Major: 4, Minor: 15}},
+			allowed: true,
+		},
+		{
+			name:    "supported upgrade",
+			ver:     versions{exec: versionMetadata{Major: 4, Minor: 15}, data: &versionMetadata{Major: 4, Minor: 14}},
+			allowed: true,
+		},
+		{
+			name:    "unsupported skip",
+			ver:     versions{exec: versionMetadata{Major: 4, Minor: 15}, data: &versionMetadata{Major: 4, Minor: 12}},
+			allowed: false,
+		},
+		{
+			name:    "downgrade",
+			ver:     versions{exec: versionMetadata{Major: 4, Minor: 14}, data: &versionMetadata{Major: 4, Minor: 15}},
+			allowed: false,
+		},
+		{
+			name: "etcd upgrade",
+			ver: versions{exec: versionMetadata{Major: 4, Minor: 15}, data: &versionMetadata{Major: 4, Minor: 14},
+				execEtcd: "3.6", dataEtcd: "3.5"},
+			allowed: true,
+		},
+		{
+			name: "etcd downgrade",
+			ver: versions{exec: versionMetadata{Major: 4, Minor: 15, Patch: 1}, data: &versionMetadata{Major: 4, Minor: 15, Patch: 2},
+				execEtcd: "3.5", dataEtcd: "3.6"},
+			allowed: false,
+		},
+		{
+			name: "unknown etcd version",
+			ver: versions{exec: versionMetadata{Major: 4, Minor: 15}, data: &versionMetadata{Major: 4, Minor: 15},
+				execEtcd: "3.5"},
+			allowed: true,
+		},
+	}
+
+	for _, td := range testData {
+		t.Run(td.name, func(t *testing.T) {
+			d := decideUpgrade(td.ver)
+			assert.Equal(t, td.allowed, d.Allowed)
+			assert.Equal(t, td.ver.exec.String(), d.TargetVersion)
+			if !td.allowed {
+				assert.NotEmpty(t, d.Reason)
+			}
+		})
+	}
+}
+
+func TestUpdateVersionHistory(t *testing.T) {
+	dataDir := config.DataDir
+	config.DataDir = t.TempDir()
+	defer func() { config.DataDir = dataDir }()
+
+	etcdVer, err := lastEtcdVersion()
+	assert.NoError(t, err)
+	assert.Empty(t, etcdVer)
+
+	v1 := versionMetadata{Major: 4, Minor: 15, Patch: 0}
+	v2 := versionMetadata{Major: 4, Minor: 15, Patch: 1}
+	assert.NoError(t, updateVersionHistory(v1, "3.5", false))
+	// Restarting during the same boot does not add entries.
+	assert.NoError(t, updateVersionHistory(v1, "3.5", false))
+	assert.NoError(t, updateVersionHistory(v2, "3.5", false))
+	assert.NoError(t, updateVersionHistory(v1, "3.6", true))
+
+	history, err := getVersionHistory()
+	assert.NoError(t, err)
+	assert.Len(t, history, 3)
+	assert.Equal(t, v1, history[0].Version)
+	assert.Equal(t, v2, history[1].Version)
+	assert.True(t, history[2].Forced)
+	assert.Equal(t, "3.6", history[2].EtcdVersion)
+	assert.NotEmpty(t, history[2].BootID)
+
+	etcdVer, err = lastEtcdVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, "3.6", etcdVer)
+
+	for i := 0; i < maxVersionHistoryEntries; i++ {
+		assert.NoError(t, updateVersionHistory(v2, "3.6", true))
+	}
+	history, err = getVersionHistory()
+	assert.NoError(t, err)
+	assert.Len(t, history, maxVersionHistoryEntries)
+}
+
+func TestGetExecEtcdVersion(t *testing.T) {
+	etcdPath := filepath.Join(t.TempDir(), "microshift-etcd")
+	executable := etcdExecutable
+	etcdExecutable = func() (string, error) { return etcdPath, nil }
+	defer func() { etcdExecutable = executable }()
+
+	_, err := getExecEtcdVersion()
+	assert.Error(t, err)
+
+	script := `#!/bin/sh
+[ "$*" = "version -o json" ] || exit 1
+echo '{"major": "4", "minor": "19", "gitVersion": "4.19.0", "patch": "0", "etcdVersion": "3.6.1"}'
+`
+	assert.NoError(t, os.WriteFile(etcdPath, []byte(script), 0700))
+	etcdVer, err := getExecEtcdVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, "3.6", etcdVer)
+}
@@ -44,6 +44,10 @@ func (d *dpApplier) Read(objBytes []byte, render RenderFunc, params RenderParams
 			panic(err)
 		}
 	}
+	objBytes, err = applyOverrides(objBytes, &appsv1.Deployment{})
+	if err != nil {
+		panic(err)
+	}
 	obj, err := runtime.Decode(appsCodecs.UniversalDecoder(appsv1.SchemeGroupVersion), objBytes)
 	if err != nil {
 		panic(err)
@@ -69,6 +73,10 @@ func (d *dsApplier) Read(objBytes []byte, render RenderFunc, params RenderParams
 			panic(err)
 		}
 	}
+	objBytes, err = applyOverrides(objBytes, &appsv1.DaemonSet{})
+	if err != nil {
+		panic(err)
+	}
 	obj, err := runtime.Decode(appsCodecs.UniversalDecoder(appsv1.SchemeGroupVersion), objBytes)
 	if err != nil {
 		panic(err)
@@ -3,6 +3,9 @@ package assets
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"time"
 
 	embedded "github.com/openshift/microshift/assets"
@@ -12,6 +15,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apiruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -44,6 +48,7 @@ var (
 		"components/csi-snapshot-controller/volumesnapshotclasses.yaml",
 		"components/csi-snapshot-controller/volumesnapshotcontents.yaml",
 		"components/csi-snapshot-controller/volumesnapshots.yaml",
+		"optional/multus/01-crd-networkattachmentdefinition.yaml",
 	}
 	// for apis that belong to a group served by openshift-apiserver but are themselves served
 	// as a CR, the crd registration controller will not automatically create local apiservices
@@ -181,3 +186,86 @@ func ApplyCRDs(ctx context.Context, cfg *config.Config) error {
 
 	return nil
 }
+
+// LoadUserCRDs reads the CustomResourceDefinitions of the *.yaml files of
+// dir, in lexical order. A missing dir is not an error.
+func LoadUserCRDs(dir string) ([]*apiextv1.CustomResourceDefinition, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var userCRDs []*apiextv1.CustomResourceDefinition
+	names := sets.New[string]()
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		docs, err := readYAMLDocuments(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CRDs from %q: %w", file, err)
+		}
+		for _, doc := range docs {
+			obj, gvk, err := apiExtensionsCodecs.UniversalDeserializer().Decode(doc, nil, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CRDs from %q: %w", file, err)
+			}
+			crd, ok := obj.(*apiextv1.CustomResourceDefinition)
+			if !ok {
+				return nil, fmt.Errorf("failed to read CRDs from %q: unsupported kind %q, must be CustomResourceDefinition", file, gvk.Kind)
+			}
+			if names.Has(crd.Name) {
+				return nil, fmt.Errorf("CustomResourceDefinition %q is defined more than once", crd.Name)
+			}
+			names.Insert(crd.Name)
+			userCRDs = append(userCRDs, crd)
+		}
+	}
+	return userCRDs, nil
+}
+
+// ApplyUserCRDs applies the CRDs of the user and waits for all of them to be
+// established, so the manifests using them can be applied.
+func ApplyUserCRDs(ctx context.Context, cfg *config.Config, userCRDs []*apiextv1.CustomResourceDefinition) error {
+	if len(userCRDs) == 0 {
+		return nil
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", cfg.KubeConfigPath(config.KubeAdmin))
+	if err != nil {
+		return err
+	}
+	client := apiextclientv1.NewForConfigOrDie(rest.AddUserAgent(restConfig, "crd-agent"))
+
+	for _, crd := range userCRDs {
+		klog.Infof("Applying user CRD %s", crd.Name)
+		if err := wait.PollUntilContextTimeout(ctx, customResourceReadyInterval, customResourceReadyTimeout, true, func(ctx context.Context) (done bool, err error) {
+			if err := applyCRD(ctx, client, crd); err != nil {
+				klog.Warningf("failed to apply user CRD %s: %v", crd.Name, err)
+				return false, nil
+			}
+			return true, nil
+		}); err != nil {
+			return fmt.Errorf("failed to apply user CRD %s: %w", crd.Name, err)
+		}
+	}
+
+	for _, crd := range userCRDs {
+		klog.Infof("Waiting for user crd %s condition.type: established", crd.Name)
+		if err := wait.PollUntilContextTimeout(ctx, customResourceReadyInterval, customResourceReadyTimeout, true, func(ctx context.Context) (done bool, err error) {
+			done, e := isEstablished(ctx, client, crd)
+			if e != nil {
+				klog.Errorf("polling for crd condition status \"established\"=\"true\": %v", e)
+			}
+			return done, nil
+		}); err != nil {
+			return fmt.Errorf("waiting for user CRD %s: %w", crd.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package assets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const userCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        x-kubernetes-preserve-unknown-fields: true
+`
+
+func TestLoadUserCRDs(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "10-widgets.yaml"), []byte(userCRD), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "20-gadgets.yaml"), []byte("---\n"+
+		`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gadgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Gadget
+    plural: gadgets
+  scope: Cluster
+  versions:
+  - name: v1
+    served: true
+    storage: true
+`), 0600))
+
+	crds, err := LoadUserCRDs(dir)
+	assert.NoError(t, err)
+	if assert.Len(t, crds, 2) {
+		assert.Equal(t, "widgets.example.com", crds[0].Name)
+		assert.Equal(t, "Widget", crds[0].Spec.Names.Kind)
+		assert.Equal(t, "gadgets.example.com", crds[1].Name)
+	}
+
+	crds, err = LoadUserCRDs(filepath.Join(t.TempDir(), "missing"))
+	assert.NoError(t, err)
+	assert.Empty(t, crds)
+}
+
+func TestLoadUserCRDsInvalid(t *testing.T) {
+	for name, files := range map[string][]string{
+		"duplicate": {userCRD, userCRD},
+		"kind":      {"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n"},
+		"malformed": {"apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\n  metadata: [\n"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			for i, f := range files {
+				assert.NoError(t, os.WriteFile(filepath.Join(dir, string(rune('a'+i))+".yaml"), []byte(f), 0600))
+			}
+			_, err := LoadUserCRDs(dir)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package assets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+type overrideKey struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+func (k overrideKey) String() string {
+	return fmt.Sprintf("%s %s/%s", k.kind, k.namespace, k.name)
+}
+
+type overrideObject struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+}
+
+// overridableKinds are the kinds of the embedded manifests which can be
+// patched by the user, and their types.
+var overridableKinds = map[string]func() any{
+	"Deployment": func() any { return &appsv1.Deployment{} },
+	"DaemonSet":  func() any { return &appsv1.DaemonSet{} },
+}
+
+var (
+	// overrides holds the strategic merge patches of the user, in the order
+	// they are applied, by object.
+	overrides = map[overrideKey][][]byte{}
+	// overridesLock guards overrides, which are read while lock is held by
+	// the appliers.
+	overridesLock sync.RWMutex
+)
+
+// LoadOverrides reads the strategic merge patches of the embedded manifests
+// from the *.yaml files of dir, in lexical order. Every document must set the
+// kind, namespace and name of the object it patches. A missing dir is not
+// an error.
+func LoadOverrides(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	loaded := map[overrideKey][][]byte{}
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		err = readOverrides(f, loaded)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read overrides from %q: %w", file, err)
+		}
+	}
+
+	overridesLock.Lock()
+	defer overridesLock.Unlock()
+	overrides = loaded
+	for key := range overrides {
+		klog.Infof("Loaded overrides for %v", key)
+	}
+	return nil
+}
+
+func readOverrides(r io.Reader, loaded map[overrideKey][][]byte) error {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		patch := map[string]any{}
+		if err := decoder.Decode(&patch); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if len(patch) == 0 {
+			continue
+		}
+
+		patchBytes, err := json.Marshal(patch)
+		if err != nil {
+			return err
+		}
+		var obj overrideObject
+		if err := json.Unmarshal(patchBytes, &obj); err != nil {
+			return err
+		}
+		newObj, ok := overridableKinds[obj.Kind]
+		if !ok {
+			return fmt.Errorf("unsupported kind %q, must be Deployment or DaemonSet", obj.Kind)
+		}
+		if obj.Metadata.Name == "" || obj.Metadata.Namespace == "" {
+			return fmt.Errorf("%s override must set metadata.name and metadata.namespace", obj.Kind)
+		}
+		key := overrideKey{kind: obj.Kind, namespace: obj.Metadata.Namespace, name: obj.Metadata.Name}
+		// Report invalid fields now rather than when the manifest is applied.
+		patched, err := strategicpatch.StrategicMergePatch([]byte("{}"), patchBytes, newObj())
+		if err != nil {
+			return fmt.Errorf("invalid override for %v: %w", key, err)
+		}
+		if err := json.Unmarshal(patched, newObj()); err != nil {
+			return fmt.Errorf("invalid override for %v: %w", key, err)
+		}
+		loaded[key] = append(loaded[key], patchBytes)
+	}
+}
+
+// applyOverrides patches the rendered manifest objBytes with the overrides
+// of the user for the object. dataStruct is the type of the object, which
+// carries the strategic merge patch directives.
+func applyOverrides(objBytes []byte, dataStruct any) ([]byte, error) {
+	overridesLock.RLock()
+	defer overridesLock.RUnlock()
+	if len(overrides) == 0 {
+		return objBytes, nil
+	}
+
+	objJSON, err := yaml.YAMLToJSON(objBytes)
+	if err != nil {
+		return nil, err
+	}
+	var obj overrideObject
+	if err := json.Unmarshal(objJSON, &obj); err != nil {
+		return nil, err
+	}
+	key := overrideKey{kind: obj.Kind, namespace: obj.Metadata.Namespace, name: obj.Metadata.Name}
+	patches := overrides[key]
+	if len(patches) == 0 {
+		return objBytes, nil
+	}
+
+	for _, patch := range patches {
+		objJSON, err = strategicpatch.StrategicMergePatch(objJSON, patch, dataStruct)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply overrides to %v: %w", key, err)
+		}
+	}
+	klog.Infof("Applied %d override(s) to %v", len(patches), key)
+	return objJSON, nil
+}
@@ -0,0 +1,140 @@
+package assets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const routerDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: router-default
+  namespace: openshift-ingress
+spec:
+  template:
+    spec:
+      nodeSelector:
+        kubernetes.io/os: linux
+      containers:
+      - name: router
+        image: router
+        resources:
+          requests:
+            cpu: 100m
+            memory: 256Mi
+`
+
+func TestOverrides(t *testing.T) {
+	defer func() { overrides = map[overrideKey][][]byte{} }()
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "10-router.yaml"), []byte(`
+kind: Deployment
+metadata:
+  name: router-default
+  namespace: openshift-ingress
+spec:
+  template:
+    spec:
+      nodeSelector:
+        node-role.kubernetes.io/edge: ""
+      containers:
+      - name: router
+        resources:
+          requests:
+            cpu: 50m
+---
+kind: DaemonSet
+metadata:
+  name: dns-default
+  namespace: openshift-dns
+spec:
+  template:
+    spec:
+      priorityClassName: system-node-critical
+`), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "20-router.yaml"), []byte(`
+kind: Deployment
+metadata:
+  name: router-default
+  namespace: openshift-ingress
+spec:
+  template:
+    spec:
+      containers:
+      - name: router
+        resources:
+          requests:
+            memory: 128Mi
+`), 0600))
+	assert.NoError(t, LoadOverrides(dir))
+	assert.Len(t, overrides, 2)
+
+	patched, err := applyOverrides([]byte(routerDeployment), &appsv1.Deployment{})
+	assert.NoError(t, err)
+	dp := &appsv1.Deployment{}
+	assert.NoError(t, yaml.Unmarshal(patched, dp))
+	assert.Equal(t, map[string]string{"kubernetes.io/os": "linux", "node-role.kubernetes.io/edge": ""}, dp.Spec.Template.Spec.NodeSelector)
+	container := dp.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "router", container.Image)
+	assert.Equal(t, "50m", container.Resources.Requests.Cpu().String())
+	assert.Equal(t, "128Mi", container.Resources.Requests.Memory().String())
+
+	// Manifests without overrides are left untouched.
+	other := []byte("kind: Deployment\nmetadata:\n  name: service-ca\n  namespace: openshift-service-ca\n")
+	unpatched, err := applyOverrides(other, &appsv1.Deployment{})
+	assert.NoError(t, err)
+	assert.Equal(t, other, unpatched)
+}
+
+func TestLoadOverridesInvalid(t *testing.T) {
+	defer func() { overrides = map[overrideKey][][]byte{} }()
+
+	for name, override := range map[string]string{
+		"kind":      "kind: ConfigMap\nmetadata:\n  name: a\n  namespace: b\n",
+		"name":      "kind: Deployment\nmetadata:\n  namespace: b\n",
+		"field":     "kind: Deployment\nmetadata:\n  name: a\n  namespace: b\nspec:\n  replicas: many\n",
+		"malformed": "kind: Deployment\n  metadata: [\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, "override.yaml"), []byte(override), 0600))
+			assert.Error(t, LoadOverrides(dir))
+		})
+	}
+
+	assert.NoError(t, LoadOverrides(filepath.Join(t.TempDir(), "missing")))
+	assert.Empty(t, overrides)
+}
+
+func TestOverridesConcurrentReload(t *testing.T) {
+	defer func() { overrides = map[overrideKey][][]byte{} }()
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "10-router.yaml"), []byte(`
+kind: Deployment
+metadata:
+  name: router-default
+  namespace: openshift-ingress
+spec:
+  replicas: 2
+`), 0600))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 50 {
+			assert.NoError(t, LoadOverrides(dir))
+		}
+	}()
+	for range 50 {
+		_, err := applyOverrides([]byte(routerDeployment), &appsv1.Deployment{})
+		assert.NoError(t, err)
+	}
+	<-done
+}
@@ -6,6 +6,7 @@ import (
 
 	embedded "github.com/openshift/microshift/assets"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -27,6 +28,9 @@ var (
 type sccApplier struct {
 	Client *sccclientv1.SecurityV1Client
 	scc    *sccv1.SecurityContextConstraints
+	// reconcile also reverts the changes made to the existing SCC, rather
+	// than only ensuring its metadata.
+	reconcile bool
 }
 
 func sccClient(kubeconfigPath string) *sccclientv1.SecurityV1Client {
@@ -65,6 +69,15 @@ func (s *sccApplier) Handle(ctx context.Context) error {
 
 	var modified bool
 	resourcemerge.EnsureObjectMeta(&modified, &existing.ObjectMeta, s.scc.ObjectMeta)
+	if s.reconcile {
+		required := s.scc.DeepCopy()
+		required.TypeMeta = existing.TypeMeta
+		required.ObjectMeta = existing.ObjectMeta
+		if !equality.Semantic.DeepEqual(existing, required) {
+			existing = required
+			modified = true
+		}
+	}
 	if !modified {
 		return nil
 	}
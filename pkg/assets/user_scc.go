@@ -0,0 +1,144 @@
+package assets
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/klog/v2"
+
+	sccv1 "github.com/openshift/api/security/v1"
+)
+
+// UserSCCs are the SecurityContextConstraints shipped by the user, with the
+// ClusterRoles and ClusterRoleBindings granting their use.
+type UserSCCs struct {
+	SCCs                []*sccv1.SecurityContextConstraints
+	ClusterRoles        []*rbacv1.ClusterRole
+	ClusterRoleBindings []*rbacv1.ClusterRoleBinding
+}
+
+// LoadUserSCCs reads the SecurityContextConstraints, ClusterRoles and
+// ClusterRoleBindings of the *.yaml files of dir, in lexical order. SCCs
+// named after one of reserved are refused. A missing dir is not an error.
+func LoadUserSCCs(dir string, reserved []string) (*UserSCCs, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	u := &UserSCCs{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := u.read(data); err != nil {
+			return nil, fmt.Errorf("failed to read SCCs from %q: %w", file, err)
+		}
+	}
+
+	reservedNames := sets.New(reserved...)
+	names := sets.New[string]()
+	for _, scc := range u.SCCs {
+		if reservedNames.Has(scc.Name) {
+			return nil, fmt.Errorf("SecurityContextConstraints %q is managed by MicroShift and cannot be replaced", scc.Name)
+		}
+		if names.Has(scc.Name) {
+			return nil, fmt.Errorf("SecurityContextConstraints %q is defined more than once", scc.Name)
+		}
+		names.Insert(scc.Name)
+	}
+	return u, nil
+}
+
+func (u *UserSCCs) read(data []byte) error {
+	docs, err := readYAMLDocuments(data)
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		obj, gvk, err := sccCodecs.UniversalDeserializer().Decode(doc, nil, nil)
+		if err != nil {
+			return err
+		}
+		switch o := obj.(type) {
+		case *sccv1.SecurityContextConstraints:
+			u.SCCs = append(u.SCCs, o)
+		case *rbacv1.ClusterRole:
+			u.ClusterRoles = append(u.ClusterRoles, o)
+		case *rbacv1.ClusterRoleBinding:
+			u.ClusterRoleBindings = append(u.ClusterRoleBindings, o)
+		default:
+			return fmt.Errorf("unsupported kind %q, must be SecurityContextConstraints, ClusterRole or ClusterRoleBinding", gvk.Kind)
+		}
+	}
+	return nil
+}
+
+// readYAMLDocuments splits data into its non-empty YAML documents.
+func readYAMLDocuments(data []byte) ([][]byte, error) {
+	var docs [][]byte
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for {
+		doc, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) != 0 {
+			docs = append(docs, doc)
+		}
+	}
+}
+
+// Apply creates the SCCs and their RBAC, and reverts the changes made to
+// them since they were last applied.
+func (u *UserSCCs) Apply(ctx context.Context, kubeconfigPath string) error {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if len(u.SCCs) != 0 {
+		scc := &sccApplier{Client: sccClient(kubeconfigPath), reconcile: true}
+		for _, s := range u.SCCs {
+			klog.Infof("Applying user scc %s", s.Name)
+			scc.scc = s
+			if err := scc.Handle(ctx); err != nil {
+				return fmt.Errorf("failed to apply scc %s: %w", s.Name, err)
+			}
+		}
+	}
+	if len(u.ClusterRoles) != 0 {
+		cr := &clusterRoleApplier{}
+		cr.New(kubeconfigPath)
+		for _, r := range u.ClusterRoles {
+			cr.cr = r
+			if err := cr.Handle(ctx); err != nil {
+				return fmt.Errorf("failed to apply clusterrole %s: %w", r.Name, err)
+			}
+		}
+	}
+	if len(u.ClusterRoleBindings) != 0 {
+		crb := &clusterRoleBindingApplier{}
+		crb.New(kubeconfigPath)
+		for _, b := range u.ClusterRoleBindings {
+			crb.crb = b
+			if err := crb.Handle(ctx); err != nil {
+				return fmt.Errorf("failed to apply clusterrolebinding %s: %w", b.Name, err)
+			}
+		}
+	}
+	return nil
+}
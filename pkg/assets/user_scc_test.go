@@ -0,0 +1,84 @@
+package assets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const userSCC = `apiVersion: security.openshift.io/v1
+kind: SecurityContextConstraints
+metadata:
+  name: my-app
+allowHostNetwork: true
+runAsUser:
+  type: RunAsAny
+seLinuxContext:
+  type: MustRunAs
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: system:openshift:scc:my-app
+rules:
+- apiGroups: ["security.openshift.io"]
+  resources: ["securitycontextconstraints"]
+  resourceNames: ["my-app"]
+  verbs: ["use"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: my-app
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: system:openshift:scc:my-app
+subjects:
+- kind: ServiceAccount
+  name: my-app
+  namespace: my-app
+`
+
+func TestLoadUserSCCs(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "my-app.yaml"), []byte(userSCC), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ignored.json"), []byte("{"), 0600))
+
+	u, err := LoadUserSCCs(dir, []string{"restricted-v2"})
+	assert.NoError(t, err)
+	if assert.Len(t, u.SCCs, 1) {
+		assert.Equal(t, "my-app", u.SCCs[0].Name)
+		assert.True(t, u.SCCs[0].AllowHostNetwork)
+	}
+	if assert.Len(t, u.ClusterRoles, 1) {
+		assert.Equal(t, []string{"my-app"}, u.ClusterRoles[0].Rules[0].ResourceNames)
+	}
+	if assert.Len(t, u.ClusterRoleBindings, 1) {
+		assert.Equal(t, "system:openshift:scc:my-app", u.ClusterRoleBindings[0].RoleRef.Name)
+	}
+
+	u, err = LoadUserSCCs(filepath.Join(t.TempDir(), "missing"), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, u.SCCs)
+}
+
+func TestLoadUserSCCsInvalid(t *testing.T) {
+	for name, files := range map[string][]string{
+		"reserved":  {userSCC},
+		"duplicate": {"apiVersion: security.openshift.io/v1\nkind: SecurityContextConstraints\nmetadata:\n  name: a\n", "apiVersion: security.openshift.io/v1\nkind: SecurityContextConstraints\nmetadata:\n  name: a\n"},
+		"kind":      {"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n"},
+		"malformed": {"apiVersion: security.openshift.io/v1\nkind: SecurityContextConstraints\n  metadata: [\n"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			for i, f := range files {
+				assert.NoError(t, os.WriteFile(filepath.Join(dir, string(rune('a'+i))+".yaml"), []byte(f), 0600))
+			}
+			_, err := LoadUserSCCs(dir, []string{"my-app"})
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package boottimings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	"k8s.io/klog/v2"
+)
+
+// Milestones of the start of MicroShift, recorded by SetMilestone.
+const (
+	// MilestoneReady is when all of the services are ready.
+	MilestoneReady = "ready"
+	// MilestoneManifestsApplied is when the manifests were applied for the
+	// first time, which may be after MilestoneReady.
+	MilestoneManifestsApplied = "manifests-applied"
+)
+
+// ReportFile holds the timings of the last start of MicroShift.
+func ReportFile() string { return filepath.Join(config.DataDir, "boot-timings.json") }
+
+// Report is the timings of a start of MicroShift. The durations are in
+// seconds, the offsets are the seconds since the start.
+type Report struct {
+	Start time.Time `json:"start"`
+	// Phases are the consecutive steps of the start, until the services
+	// are all ready.
+	Phases []Timing `json:"phases"`
+	// Milestones are the offsets of the events of the start.
+	Milestones []Timing `json:"milestones"`
+	// Services are the timings of the start of each service, in the order
+	// they became ready.
+	Services []ServiceTiming `json:"services"`
+}
+
+type Timing struct {
+	Name    string  `json:"name"`
+	Seconds float64 `json:"seconds"`
+}
+
+type ServiceTiming struct {
+	Name string `json:"name"`
+	// Started is the offset of the start of the service, once its
+	// dependencies were ready.
+	Started float64 `json:"started"`
+	// Ready is the offset of the readiness of the service.
+	Ready float64 `json:"ready"`
+}
+
+var (
+	mu     sync.Mutex
+	report *Report
+	// saved is whether the report is written on every change, once
+	// MicroShift is ready.
+	saved bool
+)
+
+// Start begins recording the timings of a start of MicroShift.
+func Start(start time.Time) {
+	mu.Lock()
+	defer mu.Unlock()
+	report = &Report{Start: start, Phases: []Timing{}, Milestones: []Timing{}, Services: []ServiceTiming{}}
+	saved = false
+}
+
+// SetPhase records the duration of a phase of the start.
+func SetPhase(name string, d time.Duration) {
+	update(func(r *Report) {
+		r.Phases = append(r.Phases, Timing{Name: name, Seconds: d.Seconds()})
+	})
+}
+
+// SetMilestone records the offset of an event of the start, the first time
+// it happens. The report is written from MilestoneReady on.
+func SetMilestone(name string, at time.Time) {
+	update(func(r *Report) {
+		for _, m := range r.Milestones {
+			if m.Name == name {
+				return
+			}
+		}
+		r.Milestones = append(r.Milestones, Timing{Name: name, Seconds: at.Sub(r.Start).Seconds()})
+		if name == MilestoneReady {
+			saved = true
+		}
+	})
+}
+
+// SetServiceReady records the start of a service the first time it becomes
+// ready. Restarts of the service are ignored.
+func SetServiceReady(name string, started, ready time.Time) {
+	update(func(r *Report) {
+		for _, s := range r.Services {
+			if s.Name == name {
+				return
+			}
+		}
+		r.Services = append(r.Services, ServiceTiming{
+			Name:    name,
+			Started: started.Sub(r.Start).Seconds(),
+			Ready:   ready.Sub(r.Start).Seconds(),
+		})
+	})
+}
+
+func update(f func(r *Report)) {
+	mu.Lock()
+	defer mu.Unlock()
+	if report == nil {
+		return
+	}
+	f(report)
+	if saved {
+		if err := save(report); err != nil {
+			// The report is informational, it must not hold the start.
+			klog.Warningf("Failed to save the boot timings: %v", err)
+		}
+	}
+}
+
+func save(r *Report) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := ReportFile() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %q: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, ReportFile()); err != nil {
+		return fmt.Errorf("failed to rename %q to %q: %w", tmp, ReportFile(), err)
+	}
+	return nil
+}
+
+// Load reads the report of the last start of MicroShift.
+func Load() (*Report, error) {
+	data, err := os.ReadFile(ReportFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no boot timings in %q, MicroShift was not ready yet", ReportFile())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", ReportFile(), err)
+	}
+	r := &Report{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", ReportFile(), err)
+	}
+	return r, nil
+}
@@ -0,0 +1,44 @@
+package boottimings
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport(t *testing.T) {
+	dataDir := config.DataDir
+	config.DataDir = t.TempDir()
+	defer func() { config.DataDir = dataDir }()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	Start(start)
+	SetPhase("certificates", 2*time.Second)
+	SetServiceReady("etcd", start.Add(3*time.Second), start.Add(5*time.Second))
+	_, err := os.Stat(ReportFile())
+	assert.ErrorIs(t, err, os.ErrNotExist, "the report is saved once MicroShift is ready")
+
+	SetMilestone(MilestoneReady, start.Add(10*time.Second))
+	// Restarts of services after the start are not recorded.
+	SetServiceReady("etcd", start.Add(20*time.Second), start.Add(21*time.Second))
+	SetMilestone(MilestoneManifestsApplied, start.Add(12*time.Second))
+
+	report, err := Load()
+	require.NoError(t, err)
+	assert.True(t, report.Start.Equal(start))
+	assert.Equal(t, []Timing{{Name: "certificates", Seconds: 2}}, report.Phases)
+	assert.Equal(t, []Timing{{Name: MilestoneReady, Seconds: 10}, {Name: MilestoneManifestsApplied, Seconds: 12}}, report.Milestones)
+	assert.Equal(t, []ServiceTiming{{Name: "etcd", Started: 3, Ready: 5}}, report.Services)
+}
+
+func TestLoadMissing(t *testing.T) {
+	dataDir := config.DataDir
+	config.DataDir = t.TempDir()
+	defer func() { config.DataDir = dataDir }()
+	_, err := Load()
+	assert.Error(t, err)
+}
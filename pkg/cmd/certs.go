@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/util/cryptomaterial"
+	"github.com/openshift/microshift/pkg/util/cryptomaterial/certchains"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func NewCertsCommand(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "certs",
+		Short: "Inspect, rotate and revoke MicroShift managed certificates",
+	}
+
+	cmd.AddCommand(newCertsListCommand(ioStreams))
+	cmd.AddCommand(newCertsRotateCommand(ioStreams))
+	cmd.AddCommand(newCertsRevokeCommand(ioStreams))
+
+	return cmd
+}
+
+// loadCertChains returns the certificate chains of the active configuration.
+// Certificates that are missing are generated, exactly like on MicroShift start.
+func loadCertChains() (*certchains.CertificateChains, error) {
+	if err := shouldRunPrivileged(); err != nil {
+		return nil, err
+	}
+	cfg, err := config.ActiveConfig()
+	if err != nil {
+		return nil, err
+	}
+	return certSetup(cfg, externalCA)
+}
+
+// listCertificates returns the certificates of the active configuration found
+// on disk. Unlike loadCertChains, it only reads them.
+func listCertificates() ([]certchains.CertificateInfo, error) {
+	if err := shouldRunPrivileged(); err != nil {
+		return nil, err
+	}
+	cfg, err := config.ActiveConfig()
+	if err != nil {
+		return nil, err
+	}
+	chains, err := newCertChains(cfg, cryptomaterial.CertsDirectory(config.DataDir))
+	if err != nil {
+		return nil, err
+	}
+	return certchains.ListCertificateFiles(chains)
+}
+
+func newCertsListCommand(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	output := ""
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List MicroShift managed certificates with their expiry and SANs",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			certs, err := listCertificates()
+			cmdutil.CheckErr(err)
+			cmdutil.CheckErr(printCertificates(ioStreams, output, certs))
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", output, "One of 'yaml' or 'json'.")
+
+	return cmd
+}
+
+func printCertificates(ioStreams genericclioptions.IOStreams, output string, certs []certchains.CertificateInfo) error {
+	switch output {
+	case "":
+		w := tabwriter.NewWriter(ioStreams.Out, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tCA\tNOT AFTER\tREMAINING\tSANS")
+		for _, c := range certs {
+			remaining := time.Until(c.NotAfter).Round(time.Hour)
+			fmt.Fprintf(w, "%s\t%t\t%s\t%v\t%s\n",
+				c.Name, c.IsCA, c.NotAfter.Format(time.RFC3339), remaining, strings.Join(c.SANs, ","))
+		}
+		return w.Flush()
+	case "yaml":
+		marshalled, err := yaml.Marshal(certs)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ioStreams.Out, string(marshalled))
+	case "json":
+		marshalled, err := json.MarshalIndent(certs, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ioStreams.Out, string(marshalled))
+	default:
+		return fmt.Errorf("unsupported --output=%q, must be one of 'yaml' or 'json'", output)
+	}
+	return nil
+}
+
+func newCertsRotateCommand(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	restart := true
+	cmd := &cobra.Command{
+		Use:   "rotate NAME",
+		Short: "Force regeneration of a certificate or a whole certificate chain",
+		Long: `Force regeneration of a certificate or a whole certificate chain.
+
+NAME is a certificate name as printed by "microshift certs list". When NAME
+refers to a signer, the signer and all of the certificates it signed are
+regenerated. MicroShift is restarted afterwards so all of the components
+pick up the new certificates, unless --restart=false is given.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			chains, err := loadCertChains()
+			cmdutil.CheckErr(err)
+			cmdutil.CheckErr(rotateCertificate(chains, args[0]))
+			fmt.Fprintf(ioStreams.Out, "Regenerated %s\n", args[0])
+
+			if restart {
+				cmdutil.CheckErr(restartMicroShiftIfActive(ioStreams))
+			} else {
+				fmt.Fprintln(ioStreams.Out, "MicroShift must be restarted for the new certificates to take effect")
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&restart, "restart", restart, "Restart MicroShift after regenerating the certificates, if it is running.")
+
+	return cmd
+}
+
+func rotateCertificate(chains *certchains.CertificateChains, name string) error {
+	certPath := strings.Split(strings.Trim(name, "/"), "/")
+	found := false
+	err := chains.WalkChains(nil, func(p []string, _ x509.Certificate) error {
+		if strings.Join(p, "/") == strings.Join(certPath, "/") {
+			found = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("unknown certificate %q, see 'microshift certs list'", name)
+	}
+	return chains.Regenerate(certPath...)
+}
+
+func newCertsRevokeCommand(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	rotateSigner := false
+	cmd := &cobra.Command{
+		Use:   "revoke NAME --rotate-signer",
+		Short: "Revoke admin client certificates, like the one of a lost kubeconfig",
+		Long: `Revoke admin client certificates, like the one of a lost kubeconfig.
+
+NAME is either the user of the certificates minted by "microshift kubeconfig
+--user", the serial number in hexadecimal of a certificate minted by
+"microshift kubeconfig", or admin-kubeconfig-client for the certificate of the
+kubeconfigs in /var/lib/microshift/resources/kubeadmin.
+
+kube-apiserver does not check certificate revocation lists, the certificates
+are revoked by regenerating admin-kubeconfig-signer and restarting MicroShift,
+which invalidates all of the certificates it issued. --rotate-signer confirms
+it. The kubeconfigs in /var/lib/microshift/resources/kubeadmin are
+regenerated, the other ones must be minted again with "microshift kubeconfig".`,
+		Args: cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !rotateSigner {
+				return fmt.Errorf("revoking certificates requires rotating admin-kubeconfig-signer, confirm it with --rotate-signer")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			chains, err := loadCertChains()
+			cmdutil.CheckErr(err)
+			revoked, err := findAdminCertificates(cryptomaterial.CertsDirectory(config.DataDir), args[0])
+			cmdutil.CheckErr(err)
+
+			// The issued certificates go with the directory of the previous
+			// signer.
+			cmdutil.CheckErr(rotateCertificate(chains, "admin-kubeconfig-signer"))
+			fmt.Fprintln(ioStreams.Out, "Regenerated admin-kubeconfig-signer")
+			for _, c := range revoked {
+				fmt.Fprintf(ioStreams.Out, "Revoked certificate %s of %s\n", c.SerialNumber.Text(16), c.Subject.CommonName)
+			}
+			cmdutil.CheckErr(restartMicroShiftIfActive(ioStreams))
+		},
+	}
+
+	cmd.Flags().BoolVar(&rotateSigner, "rotate-signer", rotateSigner, "Regenerate admin-kubeconfig-signer and restart MicroShift, invalidating all of the admin client certificates.")
+	cmdutil.CheckErr(cmd.MarkFlagRequired("rotate-signer"))
+
+	return cmd
+}
+
+// adminKubeconfigClient is the client certificate of the kubeconfigs that
+// MicroShift generates.
+const adminKubeconfigClient = "admin-kubeconfig-client"
+
+// findAdminCertificates returns the admin client certificates matching name,
+// to check that there is a certificate to revoke before rotating the signer.
+func findAdminCertificates(certsDir, name string) ([]*x509.Certificate, error) {
+	if name == adminKubeconfigClient {
+		cert, err := readCertificate(cryptomaterial.ClientCertPath(cryptomaterial.AdminKubeconfigClientCertDir(certsDir)))
+		if err != nil {
+			return nil, err
+		}
+		return []*x509.Certificate{cert}, nil
+	}
+
+	issued, err := filepath.Glob(filepath.Join(cryptomaterial.AdminKubeconfigIssuedDir(certsDir), "*.crt"))
+	if err != nil {
+		return nil, err
+	}
+	serial := strings.ToLower(strings.ReplaceAll(name, ":", ""))
+	var found []*x509.Certificate
+	for _, path := range issued {
+		cert, err := readCertificate(path)
+		if err != nil {
+			return nil, err
+		}
+		if cert.Subject.CommonName == name || cert.SerialNumber.Text(16) == serial {
+			found = append(found, cert)
+		}
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no certificate of %q minted by 'microshift kubeconfig'", name)
+	}
+	return found, nil
+}
+
+func readCertificate(path string) (*x509.Certificate, error) {
+	certPEM, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := crypto.CertsFromPEM(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return certs[0], nil
+}
+
+func restartMicroShiftIfActive(ioStreams genericclioptions.IOStreams) error {
+	out, _ := exec.Command("systemctl", "is-active", "microshift.service").Output()
+	if state := strings.TrimSpace(string(out)); state != "active" && state != "activating" {
+		fmt.Fprintf(ioStreams.Out, "microshift.service is %q, the new certificates will be used on next start\n", state)
+		return nil
+	}
+
+	fmt.Fprintln(ioStreams.Out, "Restarting microshift.service")
+	if out, err := exec.Command("systemctl", "restart", "microshift.service").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to restart microshift.service: %w: %s", err, string(out))
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/microshift/pkg/util/cryptomaterial"
+	"github.com/openshift/microshift/pkg/util/cryptomaterial/certchains"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+func testCertChains(t *testing.T) *certchains.CertificateChains {
+	dir := t.TempDir()
+	chains, err := certchains.NewCertificateChains(
+		certchains.NewCertificateSigner("test-signer", filepath.Join(dir, "test-signer"), 10).
+			WithClientCertificates(&certchains.ClientCertificateSigningRequestInfo{
+				CSRMeta:  certchains.CSRMeta{Name: "test-client", ValidityDays: 1},
+				UserInfo: &user.DefaultInfo{Name: "test-user"},
+			}).
+			WithServingCertificates(&certchains.ServingCertificateSigningRequestInfo{
+				CSRMeta:   certchains.CSRMeta{Name: "test-server", ValidityDays: 1},
+				Hostnames: []string{"test.example.com", "10.0.0.1"},
+			}),
+	).Complete()
+	require.NoError(t, err)
+	return chains
+}
+
+func TestRotateCertificate(t *testing.T) {
+	chains := testCertChains(t)
+	before, err := certchains.ListCertificates(chains)
+	require.NoError(t, err)
+
+	assert.Error(t, rotateCertificate(chains, "test-signer/unknown"))
+	assert.Error(t, rotateCertificate(chains, "unknown-signer"))
+	require.NoError(t, rotateCertificate(chains, "test-signer/test-client"))
+
+	after, err := certchains.ListCertificates(chains)
+	require.NoError(t, err)
+	// The signer and the serving certificate are untouched.
+	assert.Equal(t, before[0], after[0])
+	assert.Equal(t, before[2], after[2])
+}
+
+func TestFindAdminCertificates(t *testing.T) {
+	certsDir := t.TempDir()
+	_, err := certchains.NewCertificateChains(
+		certchains.NewCertificateSigner("admin-kubeconfig-signer", cryptomaterial.AdminKubeconfigSignerDir(certsDir), 10).
+			WithClientCertificates(&certchains.ClientCertificateSigningRequestInfo{
+				CSRMeta:  certchains.CSRMeta{Name: "admin-kubeconfig-client", ValidityDays: 1},
+				UserInfo: &user.DefaultInfo{Name: "system:admin", Groups: []string{"system:masters"}},
+			}),
+	).Complete()
+	require.NoError(t, err)
+
+	o := &KubeconfigOptions{User: "alice"}
+	for range 2 {
+		_, _, err := o.clientCertKey(certsDir)
+		require.NoError(t, err)
+	}
+	o.User = "bob"
+	bobPEM, _, err := o.clientCertKey(certsDir)
+	require.NoError(t, err)
+	bob, err := crypto.CertsFromPEM(bobPEM)
+	require.NoError(t, err)
+
+	_, err = findAdminCertificates(certsDir, "carol")
+	assert.ErrorContains(t, err, "no certificate")
+
+	found, err := findAdminCertificates(certsDir, "alice")
+	require.NoError(t, err)
+	assert.Len(t, found, 2)
+
+	found, err = findAdminCertificates(certsDir, bob[0].SerialNumber.Text(16))
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "bob", found[0].Subject.CommonName)
+
+	found, err = findAdminCertificates(certsDir, "admin-kubeconfig-client")
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "system:admin", found[0].Subject.CommonName)
+}
+
+func TestCertsRevokeRequiresRotateSigner(t *testing.T) {
+	for _, args := range [][]string{
+		{"alice"},
+		{"alice", "--rotate-signer=false"},
+	} {
+		cmd := newCertsRevokeCommand(genericclioptions.NewTestIOStreamsDiscard())
+		cmd.SetArgs(args)
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		assert.Error(t, cmd.Execute(), args)
+	}
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/microshift/pkg/assets"
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/controllers"
+	"github.com/openshift/microshift/pkg/kustomize"
+	"github.com/openshift/microshift/pkg/node"
+	"github.com/openshift/microshift/pkg/servicemanager"
+	"github.com/openshift/microshift/pkg/util"
+	"github.com/openshift/microshift/pkg/util/cryptomaterial"
+	"github.com/openshift/microshift/pkg/util/cryptomaterial/certchains"
+
+	"k8s.io/klog/v2"
+)
+
+// RunMicroshiftDryRun resolves the configuration and generates certificates,
+// kubeconfigs and component configuration files into dir (a temporary
+// directory removed afterwards when dir is empty), and renders the manifests
+// of the configured kustomize paths. No service is started, nothing is
+// written to the MicroShift data directory and root privileges are not needed.
+func RunMicroshiftDryRun(cfg *config.Config, dir string) error {
+	klog.InfoS("MICROSHIFT DRY RUN STARTING")
+	logConfig(cfg)
+
+	if dir == "" {
+		tmpDir, err := os.MkdirTemp("", "microshift-dry-run-")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		defer os.RemoveAll(tmpDir)
+		dir = tmpDir
+	} else if err := util.MakeDir(dir); err != nil {
+		return fmt.Errorf("failed to create dir %q: %w", dir, err)
+	}
+	klog.InfoS("Generating files", "dir", dir)
+
+	// Every path derived from the data directory, including the ones stored
+	// in cfg by the kubeconfig helpers, follows config.DataDir.
+	config.DataDir = dir
+
+	certChains, err := initCerts(context.Background(), cfg, dryRunIssuer)
+	if err != nil {
+		return fmt.Errorf("failed to generate the certificates: %w", err)
+	}
+	if err := initKubeconfigs(cfg, certChains); err != nil {
+		return fmt.Errorf("failed to generate the kubeconfigs: %w", err)
+	}
+
+	// Creating the services writes their configuration files. The context is
+	// only used once the services run, which never happens here.
+	services := []servicemanager.Service{
+		controllers.NewKubeAPIServer(cfg),
+		controllers.NewKubeScheduler(cfg),
+		controllers.NewKubeControllerManager(context.Background(), cfg),
+		controllers.NewRouteControllerManager(cfg),
+		controllers.NewClusterPolicyController(cfg),
+		node.NewKubeletServer(cfg),
+	}
+	errs := []error{}
+	for _, s := range services {
+		if r, ok := s.(servicemanager.ConfigurationReporter); ok {
+			if err := r.ConfigurationError(); err != nil {
+				errs = append(errs, fmt.Errorf("%s configuration failed: %w", s.Name(), err))
+			}
+		}
+	}
+
+	if err := assets.LoadOverrides(config.OverridesDir); err != nil {
+		errs = append(errs, fmt.Errorf("failed to load the overrides of the component manifests: %w", err))
+	}
+
+	paths, err := cfg.Manifests.GetKustomizationPaths()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to find kustomization paths: %w", err))
+	}
+	for _, path := range paths {
+		resources, err := kustomize.Render(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		klog.InfoS("Rendered kustomization", "path", path, "resources", resources.Size())
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	klog.InfoS("MICROSHIFT DRY RUN SUCCEEDED")
+	return nil
+}
+
+// dryRunIssuer returns the issuer of the signers of a dry run. Using the
+// certificates.pkcs11 token would create its key on the host, a CA generated
+// in certsDir stands in for it.
+func dryRunIssuer(cfg *config.Config, certsDir string) (*crypto.CA, error) {
+	if !cfg.Certificates.PKCS11.IsEnabled() {
+		return externalCA(cfg, certsDir)
+	}
+	klog.InfoS("Not using the PKCS#11 token, generating a CA standing in for it", "module", cfg.Certificates.PKCS11.Module)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return certchains.EnsureKeyCA(
+		cryptomaterial.CACertPath(cryptomaterial.TokenRootCADir(certsDir)),
+		key,
+		"microshift-root-ca",
+		*cfg.Certificates.LongLivedValidityDays,
+		0,
+		cfg.Certificates.Backdate(),
+	)
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/util/cryptomaterial"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunMicroshiftDryRun(t *testing.T) {
+	// Stands in for the data directory of the host, which the dry run must
+	// leave untouched.
+	hostDataDir := t.TempDir()
+	dataDir := config.DataDir
+	config.DataDir = hostDataDir
+	defer func() { config.DataDir = dataDir }()
+	_, staticPodsErr := os.Stat(config.StaticPodsDir)
+
+	cfg := config.NewDefault()
+	// The token must not be used, the module does not even exist.
+	cfg.Certificates.PKCS11.Module = filepath.Join(t.TempDir(), "missing-pkcs11.so")
+	cfg.Certificates.PKCS11.TokenLabel = "microshift"
+	cfg.Certificates.PKCS11.KeyLabel = "microshift-root-ca"
+
+	dir := filepath.Join(t.TempDir(), "dry-run")
+	require.NoError(t, RunMicroshiftDryRun(cfg, dir))
+
+	certsDir := cryptomaterial.CertsDirectory(dir)
+	assert.FileExists(t, cryptomaterial.CACertPath(cryptomaterial.TokenRootCADir(certsDir)))
+	assert.FileExists(t, cryptomaterial.CACertPath(cryptomaterial.AdminKubeconfigSignerDir(certsDir)))
+	assert.FileExists(t, cfg.KubeConfigPath(config.KubeAdmin))
+
+	entries, err := os.ReadDir(hostDataDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	if os.IsNotExist(staticPodsErr) {
+		assert.NoDirExists(t, config.StaticPodsDir)
+	}
+}
@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/controllers"
+	"github.com/spf13/cobra"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	etcdCommandTimeout = 30 * time.Second
+	// Defragmentation blocks the etcd backend and may take a while on big databases.
+	etcdDefragTimeout = 5 * time.Minute
+)
+
+// withEtcdClient runs fn with a client connected to the etcd used by MicroShift.
+func withEtcdClient(timeout time.Duration, fn func(context.Context, *clientv3.Client) error) error {
+	if err := shouldRunPrivileged(); err != nil {
+		return err
+	}
+
+	cfg, err := config.ActiveConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	client, err := controllers.GetEtcdClient(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to etcd: %w", err)
+	}
+	defer client.Close()
+
+	return fn(ctx, client)
+}
+
+func NewEtcdCommand(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "etcd",
+		Short: "Interact with the etcd used by MicroShift",
+		Long: `Interact with the MicroShift managed etcd using the client certificates
+from the MicroShift data directory, or with the external etcd configured in
+etcd.external. MicroShift must be running.`,
+	}
+
+	cmd.AddCommand(newEtcdStatusCommand(ioStreams))
+	cmd.AddCommand(newEtcdDefragCommand(ioStreams))
+	cmd.AddCommand(newEtcdSnapshotCommand(ioStreams))
+	cmd.AddCommand(newEtcdMemberCommand(ioStreams))
+	cmd.AddCommand(newEtcdPromoteCommand(ioStreams))
+
+	return cmd
+}
+
+func newEtcdStatusCommand(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Print the status of etcd",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(withEtcdClient(etcdCommandTimeout, func(ctx context.Context, client *clientv3.Client) error {
+				for _, endpoint := range client.Endpoints() {
+					status, err := client.Status(ctx, endpoint)
+					if err != nil {
+						return fmt.Errorf("failed to get etcd status of %s: %w", endpoint, err)
+					}
+					if err := printEtcdStatus(ioStreams.Out, endpoint, status); err != nil {
+						return err
+					}
+				}
+				return nil
+			}))
+		},
+	}
+}
+
+func printEtcdStatus(out io.Writer, endpoint string, status *clientv3.StatusResponse) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "Endpoint:\t%s\n", endpoint)
+	fmt.Fprintf(w, "ID:\t%x\n", status.Header.MemberId)
+	fmt.Fprintf(w, "Version:\t%s\n", status.Version)
+	fmt.Fprintf(w, "DB Size:\t%d\n", status.DbSize)
+	fmt.Fprintf(w, "DB Size In Use:\t%d\n", status.DbSizeInUse)
+	if status.DbSize > 0 {
+		fmt.Fprintf(w, "Fragmented:\t%.2f%%\n", float64(status.DbSize-status.DbSizeInUse)/float64(status.DbSize)*100)
+	}
+	fmt.Fprintf(w, "Is Leader:\t%t\n", status.Header.MemberId == status.Leader)
+	fmt.Fprintf(w, "Is Learner:\t%t\n", status.IsLearner)
+	fmt.Fprintf(w, "Raft Term:\t%d\n", status.RaftTerm)
+	fmt.Fprintf(w, "Raft Index:\t%d\n", status.RaftIndex)
+	fmt.Fprintf(w, "Raft Applied Index:\t%d\n", status.RaftAppliedIndex)
+	if len(status.Errors) != 0 {
+		fmt.Fprintf(w, "Errors:\t%s\n", strings.Join(status.Errors, ", "))
+	}
+	return w.Flush()
+}
+
+func newEtcdDefragCommand(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "defrag",
+		Short: "Defragment the etcd database",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(withEtcdClient(etcdDefragTimeout, func(ctx context.Context, client *clientv3.Client) error {
+				for _, endpoint := range client.Endpoints() {
+					start := time.Now()
+					if _, err := client.Defragment(ctx, endpoint); err != nil {
+						return fmt.Errorf("failed to defragment etcd at %s: %w", endpoint, err)
+					}
+					fmt.Fprintf(ioStreams.Out, "Finished defragmenting etcd at %s in %v\n", endpoint, time.Since(start).Round(time.Millisecond))
+				}
+				return nil
+			}))
+		},
+	}
+}
+
+func newEtcdSnapshotCommand(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Manage etcd snapshots",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "save PATH",
+		Short: "Store a snapshot of the etcd database to PATH",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(checkPathExistence(args[0], false))
+			cmdutil.CheckErr(withEtcdClient(etcdDefragTimeout, func(ctx context.Context, client *clientv3.Client) error {
+				size, err := controllers.SaveEtcdSnapshot(ctx, client, args[0])
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(ioStreams.Out, "Snapshot saved at %s (%d bytes)\n", args[0], size)
+				return nil
+			}))
+		},
+	})
+
+	return cmd
+}
+
+func newEtcdMemberCommand(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "member",
+		Short: "Inspect etcd cluster members",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List etcd cluster members",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(withEtcdClient(etcdCommandTimeout, func(ctx context.Context, client *clientv3.Client) error {
+				resp, err := client.MemberList(ctx)
+				if err != nil {
+					return fmt.Errorf("failed to list etcd members: %w", err)
+				}
+
+				w := tabwriter.NewWriter(ioStreams.Out, 0, 8, 2, ' ', 0)
+				fmt.Fprintln(w, "ID\tNAME\tPEER ADDRS\tCLIENT ADDRS\tIS LEARNER")
+				for _, m := range resp.Members {
+					fmt.Fprintf(w, "%x\t%s\t%s\t%s\t%t\n",
+						m.ID, m.Name, strings.Join(m.PeerURLs, ","), strings.Join(m.ClientURLs, ","), m.IsLearner)
+				}
+				return w.Flush()
+			}))
+		},
+	})
+
+	return cmd
+}
+
+// etcdStandbyPromotedDropIn is the configuration drop-in clearing the standby
+// role of a promoted node, which then runs the whole control plane.
+var etcdStandbyPromotedDropIn = filepath.Join(config.ConfigDropInDir, "99-etcd-standby-promoted.yaml")
+
+func newEtcdPromoteCommand(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "promote",
+		Short: "Promote the etcd learner of a standby node after the failure of the primary",
+		Long: `Turn the etcd learner of a stopped standby node into a single member
+cluster with the data replicated from the primary, and clear the standby role
+of the node. MicroShift runs the whole control plane on its next start.
+
+The former primary must not be started again with its data: set it up as the
+standby of the promoted node instead.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(shouldRunPrivileged())
+
+			cfg, err := config.ActiveConfig()
+			cmdutil.CheckErr(err)
+			if !cfg.Etcd.IsStandby() {
+				cmdutil.CheckErr(fmt.Errorf("etcd.standby.role is %q, only a standby node can be promoted", cfg.Etcd.Standby.Role))
+			}
+			if err := exec.Command("systemctl", "is-active", "--quiet", "microshift.service").Run(); err == nil {
+				cmdutil.CheckErr(fmt.Errorf("microshift.service is active: stop MicroShift before promoting the standby"))
+			}
+
+			microshiftExecPath, err := os.Executable()
+			cmdutil.CheckErr(err)
+			promote := exec.Command(filepath.Join(filepath.Dir(microshiftExecPath), "microshift-etcd"), "promote")
+			promote.Stdout, promote.Stderr = ioStreams.Out, ioStreams.ErrOut
+			if err := promote.Run(); err != nil {
+				cmdutil.CheckErr(fmt.Errorf("failed to promote the etcd learner: %w", err))
+			}
+
+			cmdutil.CheckErr(os.MkdirAll(config.ConfigDropInDir, 0755))
+			dropIn := fmt.Sprintf("etcd:\n  standby:\n    role: %s\n", config.EtcdStandbyRoleNone)
+			cmdutil.CheckErr(os.WriteFile(etcdStandbyPromotedDropIn, []byte(dropIn), 0600))
+
+			fmt.Fprintf(ioStreams.Out, "Promoted the etcd learner, the standby role is cleared in %s.\n", etcdStandbyPromotedDropIn)
+			fmt.Fprintln(ioStreams.Out, "Start MicroShift with 'systemctl start microshift' to run the control plane on this node.")
+		},
+	}
+}
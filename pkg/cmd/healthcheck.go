@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/healthcheck"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func NewHealthcheckCommand(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	greenboot := false
+	timeout := 300 * time.Second
+	opts := healthcheck.Options{Core: true, User: true}
+
+	cmd := &cobra.Command{
+		Use:   "healthcheck",
+		Short: "Wait for MicroShift and the workloads to be healthy",
+		Long: `Wait for MicroShift and the workloads to be healthy.
+
+Checks, in order, that MicroShift did not refuse to start on the existing
+data, that microshift.service is active, that the readyz and livez endpoints
+of the API server pass, and that the workloads of MicroShift and the ones
+listed in healthCheck.workloads of the configuration are rolled out and
+available. Each failure exits with its own code:
+
+  1  the health check could not run
+  2  microshift.service failed or is not active
+  3  MicroShift refused to start on the existing data
+  4  the API server is not healthy
+  5  the workloads of MicroShift are not ready
+  6  the workloads of healthCheck.workloads are not ready
+
+With --greenboot, the timeout grows with each boot attempt as set in
+/etc/greenboot/greenboot.conf with MICROSHIFT_WAIT_TIMEOUT_SEC, and the check
+passes when microshift.service is not enabled.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(shouldRunPrivileged())
+
+			if greenboot {
+				fmt.Fprintln(ioStreams.Out, "STARTED")
+				if !healthcheck.ServiceEnabled() {
+					fmt.Fprintln(ioStreams.Out, "MicroShift service is not enabled. Exiting...")
+					fmt.Fprintln(ioStreams.Out, "FINISHED")
+					return
+				}
+				if !cmd.Flags().Changed("timeout") {
+					timeout = healthcheck.GreenbootWaitTimeout()
+				}
+			}
+			opts.Timeout = timeout
+
+			cfg, err := config.ActiveConfig()
+			cmdutil.CheckErr(err)
+
+			fmt.Fprintf(ioStreams.Out, "Waiting %s for MicroShift to be healthy\n", timeout)
+			result := healthcheck.Run(cmd.Context(), cfg, opts)
+			if result.Code != healthcheck.ExitHealthy {
+				fmt.Fprintf(ioStreams.ErrOut, "Error: %s\n", result.Message)
+				if greenboot {
+					fmt.Fprintln(ioStreams.Out, "FAILURE")
+				}
+				os.Exit(int(result.Code))
+			}
+			fmt.Fprintln(ioStreams.Out, result.Message)
+			if greenboot {
+				fmt.Fprintln(ioStreams.Out, "FINISHED")
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&greenboot, "greenboot", greenboot, "Run as a greenboot health check script.")
+	cmd.Flags().DurationVar(&timeout, "timeout", timeout, "Time all of the checks may take. Defaults to the greenboot timeout of the boot attempt with --greenboot.")
+	cmd.Flags().BoolVar(&opts.Core, "core", opts.Core, "Check the workloads of MicroShift.")
+	cmd.Flags().BoolVar(&opts.User, "user", opts.User, "Check the workloads listed in healthCheck.workloads.")
+
+	return cmd
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/crio"
+	"github.com/openshift/microshift/pkg/kustomize"
+	"github.com/openshift/microshift/pkg/release"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+	remote "k8s.io/cri-client/pkg"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	imagePullTimeout = 30 * time.Minute
+
+	// imageSourceRelease marks images of the embedded components.
+	imageSourceRelease = "release"
+)
+
+// ImageReference describes a container image required by MicroShift.
+type ImageReference struct {
+	// Name of the image in the release, or empty for manifest images.
+	Name string `json:"name,omitempty"`
+	// Image is the pull specification of the image.
+	Image string `json:"image"`
+	// Source is "release" for images of the embedded components, or the
+	// path of the kustomization referencing the image.
+	Source string `json:"source"`
+}
+
+type ImagesOptions struct {
+	Output string
+	Pull   bool
+
+	genericclioptions.IOStreams
+}
+
+func NewImagesOptions(ioStreams genericclioptions.IOStreams) *ImagesOptions {
+	return &ImagesOptions{
+		IOStreams: ioStreams,
+	}
+}
+
+func NewImagesCommand(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	o := NewImagesOptions(ioStreams)
+	cmd := &cobra.Command{
+		Use:   "images",
+		Short: "List the container images required by MicroShift",
+		Long: `List the container images required by the embedded components and by the
+manifests found in the configured kustomize paths.
+
+Use --pull to pre-pull all of the images through CRI-O, for example when
+preparing an image for an air-gapped environment.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Validate())
+			cfg, err := config.ActiveConfig()
+			cmdutil.CheckErr(err)
+			cmdutil.CheckErr(o.Run(cfg))
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.Output, "output", "o", o.Output, "One of 'yaml' or 'json'.")
+	cmd.Flags().BoolVar(&o.Pull, "pull", o.Pull, "Pull all of the images using CRI-O. Requires root privileges.")
+
+	return cmd
+}
+
+func (o *ImagesOptions) Validate() error {
+	switch o.Output {
+	case "", "yaml", "json":
+	default:
+		return fmt.Errorf("unsupported --output=%q, must be one of 'yaml' or 'json'", o.Output)
+	}
+	if o.Pull {
+		return shouldRunPrivileged()
+	}
+	return nil
+}
+
+func (o *ImagesOptions) Run(cfg *config.Config) error {
+	images, err := requiredImages(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := o.print(images); err != nil {
+		return err
+	}
+
+	if o.Pull {
+		return o.pull(images)
+	}
+	return nil
+}
+
+// requiredImages returns the images of the embedded components followed by
+// the images of each kustomization, in the order they are applied.
+func requiredImages(cfg *config.Config) ([]ImageReference, error) {
+	images := []ImageReference{}
+
+	names := make([]string, 0, len(release.Image))
+	for name := range release.Image {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		images = append(images, ImageReference{Name: name, Image: release.Image[name], Source: imageSourceRelease})
+	}
+
+	paths, err := cfg.Manifests.GetKustomizationPaths()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find kustomization paths: %w", err)
+	}
+	for _, path := range paths {
+		pathImages, err := kustomize.ContainerImages(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, image := range pathImages {
+			images = append(images, ImageReference{Image: image, Source: path})
+		}
+	}
+
+	return images, nil
+}
+
+func (o *ImagesOptions) print(images []ImageReference) error {
+	switch o.Output {
+	case "yaml":
+		marshalled, err := yaml.Marshal(images)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(o.Out, string(marshalled))
+	case "json":
+		marshalled, err := json.MarshalIndent(images, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(o.Out, string(marshalled))
+	default:
+		w := tabwriter.NewWriter(o.Out, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "IMAGE\tSOURCE")
+		for _, i := range images {
+			fmt.Fprintf(w, "%s\t%s\n", i.Image, i.Source)
+		}
+		return w.Flush()
+	}
+	return nil
+}
+
+func (o *ImagesOptions) pull(images []ImageReference) error {
+	imageService, err := remote.NewRemoteImageService(crio.Endpoint, crio.ConnectionTimeout, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to CRI-O: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), imagePullTimeout)
+	defer cancel()
+
+	pulled := map[string]struct{}{}
+	for _, i := range images {
+		if _, ok := pulled[i.Image]; ok {
+			continue
+		}
+		// Credentials are taken from the CRI-O configured pull secret.
+		fmt.Fprintf(o.ErrOut, "Pulling %s\n", i.Image)
+		if _, err := imageService.PullImage(ctx, &runtimeapi.ImageSpec{Image: i.Image}, nil, nil); err != nil {
+			return fmt.Errorf("failed to pull %q: %w", i.Image, err)
+		}
+		pulled[i.Image] = struct{}{}
+	}
+	return nil
+}
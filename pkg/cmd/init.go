@@ -16,6 +16,8 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
+	"crypto/rsa"
 	"crypto/x509"
 	"fmt"
 	"net"
@@ -23,26 +25,47 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/authentication/serviceaccount"
 	"k8s.io/apiserver/pkg/authentication/user"
 	apiserveroptions "k8s.io/kubernetes/pkg/controlplane/apiserver/options"
 
+	"github.com/openshift/library-go/pkg/crypto"
 	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/sysconfwatch"
+	"github.com/openshift/microshift/pkg/tracing"
 	"github.com/openshift/microshift/pkg/util"
 	"github.com/openshift/microshift/pkg/util/cryptomaterial"
 	"github.com/openshift/microshift/pkg/util/cryptomaterial/certchains"
+	"github.com/openshift/microshift/pkg/util/cryptomaterial/pkcs11"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"k8s.io/klog/v2"
 )
 
-func initCerts(cfg *config.Config) (*certchains.CertificateChains, error) {
-	certChains, err := certSetup(cfg)
+// issuerFunc returns the CA issuing the signers of MicroShift, or nil for
+// self-signed signers.
+type issuerFunc func(cfg *config.Config, certsDir string) (*crypto.CA, error)
+
+func initCerts(ctx context.Context, cfg *config.Config, issuerOf issuerFunc) (_ *certchains.CertificateChains, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "certificates")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	_, setupSpan := tracing.Tracer().Start(ctx, "certificates setup")
+	certChains, err := certSetup(cfg, issuerOf)
+	tracing.EndSpan(setupSpan, err)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := checkClock(certChains, cfg.Certificates.ClockSkewPolicy); err != nil {
+		return nil, err
+	}
+
 	// we cannot just remove the certs dir and regenerate all the certificates
 	// because there are some long-lived certs and CAs that shouldn't be swapped
 	// - for example system:admin client certs, KAS serving CAs
@@ -51,31 +74,80 @@ func initCerts(cfg *config.Config) (*certchains.CertificateChains, error) {
 		return nil, err
 	}
 
+	_, regenSpan := tracing.Tracer().Start(ctx, "certificates regeneration",
+		trace.WithAttributes(attribute.Int("microshift.certificates.regenerated", len(regenCerts))))
 	for _, c := range regenCerts {
 		if err := certChains.Regenerate(c...); err != nil {
+			tracing.EndSpan(regenSpan, err)
 			return nil, err
 		}
 	}
+	regenSpan.End()
 
 	return certChains, err
 }
 
-func certSetup(cfg *config.Config) (*certchains.CertificateChains, error) {
-	_, svcNet, err := net.ParseCIDR(cfg.Network.ServiceNetwork[0])
+func certSetup(cfg *config.Config, issuerOf issuerFunc) (*certchains.CertificateChains, error) {
+	certsDir := cryptomaterial.CertsDirectory(config.DataDir)
+
+	// Only the external serving certificate holds the configured names, the
+	// signers and the other certificates are kept when they change.
+	externalServingCert := cryptomaterial.ServingCertPath(cryptomaterial.KubeAPIServerExternalServingCertDir(certsDir))
+	if added, removed := changedNames(externalServingCert, externalCertNames(cfg)); len(added) != 0 || len(removed) != 0 {
+		klog.Infof("API server names changed since the last start, added %v, removed %v: regenerating kube-external-serving", added, removed)
+	}
+
+	issuer, err := issuerOf(cfg, certsDir)
+	if err != nil {
+		return nil, err
+	}
+	// The bundle of the pods is rebuilt from the current signers, for the
+	// chain of an external CA no longer configured to be removed from it.
+	if err := os.Remove(cryptomaterial.ServiceAccountTokenCABundlePath(certsDir)); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	chains, err := newCertChains(cfg, certsDir)
+	if err != nil {
+		return nil, err
+	}
+	certChains, err := chains.WithBackdate(cfg.Certificates.Backdate()).WithIssuer(issuer).Complete()
 	if err != nil {
 		return nil, err
 	}
 
-	_, apiServerServiceIP, err := apiserveroptions.ServiceIPRange(*svcNet)
+	// The pods trust the PKI of the organization, kube-controller-manager
+	// publishing the bundle in the kube-root-ca.crt config maps.
+	if issuer != nil {
+		if err := certchains.AddToBundle(cryptomaterial.ServiceAccountTokenCABundlePath(certsDir), issuer.Config.Certs...); err != nil {
+			return nil, err
+		}
+	}
+
+	saKeyDir := filepath.Join(config.DataDir, "/resources/kube-apiserver/secrets/service-account-key")
+	if err := util.EnsureKeyPair(
+		filepath.Join(saKeyDir, "service-account.pub"),
+		filepath.Join(saKeyDir, "service-account.key"),
+	); err != nil {
+		return nil, err
+	}
+
+	cfg.Ingress.ServingCertificate, cfg.Ingress.ServingKey, err = certChains.GetCertKey("ingress-ca", "router-default-serving")
 	if err != nil {
 		return nil, err
 	}
 
-	externalCertNames := []string{
+	return certChains, nil
+}
+
+// externalCertNames returns the names of the external serving certificate of
+// the API server.
+func externalCertNames(cfg *config.Config) []string {
+	names := []string{
 		cfg.Node.HostnameOverride,
 		"api." + cfg.DNS.BaseDomain,
 	}
-	externalCertNames = append(externalCertNames, cfg.ApiServer.SubjectAltNames...)
+	names = append(names, cfg.ApiServer.SubjectAltNames...)
 	// When Kube apiserver advertise address matches the node IP we can not add
 	// it to the certificates or else the internal pod access to apiserver is
 	// broken. Because of client-go not using SNI and the way apiserver handles
@@ -83,12 +155,36 @@ func certSetup(cfg *config.Config) (*certchains.CertificateChains, error) {
 	// getting the external certificate, which is signed by a different CA and
 	// does not match the hostname.
 	if cfg.ApiServer.AdvertiseAddress != cfg.Node.NodeIP {
-		externalCertNames = append(externalCertNames, cfg.Node.NodeIP)
+		names = append(names, cfg.Node.NodeIP)
 	}
+	return names
+}
 
-	certsDir := cryptomaterial.CertsDirectory(config.DataDir)
+// newCertChains returns the builder of the certificate chains of MicroShift
+// stored in certsDir. It does not touch the files, which are only generated
+// when the chains are completed.
+//
+//nolint:ireturn
+func newCertChains(cfg *config.Config, certsDir string) (certchains.CertificateChainsBuilder, error) {
+	_, svcNet, err := net.ParseCIDR(cfg.Network.ServiceNetwork[0])
+	if err != nil {
+		return nil, err
+	}
+
+	_, apiServerServiceIP, err := apiserveroptions.ServiceIPRange(*svcNet)
+	if err != nil {
+		return nil, err
+	}
+
+	externalCertNames := externalCertNames(cfg)
+	shortLived, longLived := *cfg.Certificates.ShortLivedValidityDays, *cfg.Certificates.LongLivedValidityDays
 
-	certChains, err := certchains.NewCertificateChains(
+	serviceCAValidity := longLived
+	if cfg.Components.ServiceCA.ValidityDays != 0 {
+		serviceCAValidity = cfg.Components.ServiceCA.ValidityDays
+	}
+
+	return certchains.NewCertificateChains(
 		// ------------------------------
 		// CLIENT CERTIFICATE SIGNERS
 		// ------------------------------
@@ -97,33 +193,33 @@ func certSetup(cfg *config.Config) (*certchains.CertificateChains, error) {
 		certchains.NewCertificateSigner(
 			"kube-control-plane-signer",
 			cryptomaterial.KubeControlPlaneSignerCertDir(certsDir),
-			cryptomaterial.ShortLivedCertificateValidityDays,
+			shortLived,
 		).WithClientCertificates(
 			&certchains.ClientCertificateSigningRequestInfo{
 				CSRMeta: certchains.CSRMeta{
 					Name:         "kube-controller-manager",
-					ValidityDays: cryptomaterial.ShortLivedCertificateValidityDays,
+					ValidityDays: shortLived,
 				},
 				UserInfo: &user.DefaultInfo{Name: "system:kube-controller-manager"},
 			},
 			&certchains.ClientCertificateSigningRequestInfo{
 				CSRMeta: certchains.CSRMeta{
 					Name:         "kube-scheduler",
-					ValidityDays: cryptomaterial.ShortLivedCertificateValidityDays,
+					ValidityDays: shortLived,
 				},
 				UserInfo: &user.DefaultInfo{Name: "system:kube-scheduler"},
 			},
 			&certchains.ClientCertificateSigningRequestInfo{
 				CSRMeta: certchains.CSRMeta{
 					Name:         "cluster-policy-controller",
-					ValidityDays: cryptomaterial.ShortLivedCertificateValidityDays,
+					ValidityDays: shortLived,
 				},
 				UserInfo: &user.DefaultInfo{Name: "system:kube-controller-manager"},
 			},
 			&certchains.ClientCertificateSigningRequestInfo{
 				CSRMeta: certchains.CSRMeta{
 					Name:         "route-controller-manager",
-					ValidityDays: cryptomaterial.ShortLivedCertificateValidityDays,
+					ValidityDays: shortLived,
 				},
 				UserInfo: serviceaccount.UserInfo("openshift-route-controller-manager", "route-controller-manager-sa", ""),
 			}),
@@ -132,12 +228,12 @@ func certSetup(cfg *config.Config) (*certchains.CertificateChains, error) {
 		certchains.NewCertificateSigner(
 			"kube-apiserver-to-kubelet-signer",
 			cryptomaterial.KubeAPIServerToKubeletSignerCertDir(certsDir),
-			cryptomaterial.ShortLivedCertificateValidityDays,
+			shortLived,
 		).WithClientCertificates(
 			&certchains.ClientCertificateSigningRequestInfo{
 				CSRMeta: certchains.CSRMeta{
 					Name:         "kube-apiserver-to-kubelet-client",
-					ValidityDays: cryptomaterial.ShortLivedCertificateValidityDays,
+					ValidityDays: shortLived,
 				},
 				UserInfo: &user.DefaultInfo{Name: "system:kube-apiserver", Groups: []string{"kube-master"}},
 			}),
@@ -146,12 +242,12 @@ func certSetup(cfg *config.Config) (*certchains.CertificateChains, error) {
 		certchains.NewCertificateSigner(
 			"admin-kubeconfig-signer",
 			cryptomaterial.AdminKubeconfigSignerDir(certsDir),
-			cryptomaterial.LongLivedCertificateValidityDays,
+			longLived,
 		).WithClientCertificates(
 			&certchains.ClientCertificateSigningRequestInfo{
 				CSRMeta: certchains.CSRMeta{
 					Name:         "admin-kubeconfig-client",
-					ValidityDays: cryptomaterial.LongLivedCertificateValidityDays,
+					ValidityDays: longLived,
 				},
 				UserInfo: &user.DefaultInfo{Name: "system:admin", Groups: []string{"system:masters"}},
 			}),
@@ -160,17 +256,17 @@ func certSetup(cfg *config.Config) (*certchains.CertificateChains, error) {
 		certchains.NewCertificateSigner(
 			"kubelet-signer",
 			cryptomaterial.KubeletCSRSignerSignerCertDir(certsDir),
-			cryptomaterial.ShortLivedCertificateValidityDays,
+			shortLived,
 		).WithSubCAs(
 			certchains.NewCertificateSigner(
 				"kube-csr-signer",
 				cryptomaterial.CSRSignerCertDir(certsDir),
-				cryptomaterial.ShortLivedCertificateValidityDays,
+				shortLived,
 			).WithClientCertificates(
 				&certchains.ClientCertificateSigningRequestInfo{
 					CSRMeta: certchains.CSRMeta{
 						Name:         "kubelet-client",
-						ValidityDays: cryptomaterial.ShortLivedCertificateValidityDays,
+						ValidityDays: shortLived,
 					},
 					// userinfo per https://kubernetes.io/docs/reference/access-authn-authz/node/#overview
 					UserInfo: &user.DefaultInfo{Name: "system:node:" + cfg.CanonicalNodeName(), Groups: []string{"system:nodes"}},
@@ -179,7 +275,7 @@ func certSetup(cfg *config.Config) (*certchains.CertificateChains, error) {
 				&certchains.ServingCertificateSigningRequestInfo{
 					CSRMeta: certchains.CSRMeta{
 						Name:         "kubelet-server",
-						ValidityDays: cryptomaterial.ShortLivedCertificateValidityDays,
+						ValidityDays: shortLived,
 					},
 					Hostnames: []string{cfg.Node.HostnameOverride, cfg.Node.NodeIP},
 				},
@@ -188,12 +284,12 @@ func certSetup(cfg *config.Config) (*certchains.CertificateChains, error) {
 		certchains.NewCertificateSigner(
 			"aggregator-signer",
 			cryptomaterial.AggregatorSignerDir(certsDir),
-			cryptomaterial.ShortLivedCertificateValidityDays,
+			shortLived,
 		).WithClientCertificates(
 			&certchains.ClientCertificateSigningRequestInfo{
 				CSRMeta: certchains.CSRMeta{
 					Name:         "aggregator-client",
-					ValidityDays: cryptomaterial.ShortLivedCertificateValidityDays,
+					ValidityDays: shortLived,
 				},
 				UserInfo: &user.DefaultInfo{Name: "system:openshift-aggregator"},
 			},
@@ -205,12 +301,12 @@ func certSetup(cfg *config.Config) (*certchains.CertificateChains, error) {
 		certchains.NewCertificateSigner(
 			"service-ca",
 			cryptomaterial.ServiceCADir(certsDir),
-			cryptomaterial.LongLivedCertificateValidityDays,
+			serviceCAValidity,
 		).WithServingCertificates(
 			&certchains.ServingCertificateSigningRequestInfo{
 				CSRMeta: certchains.CSRMeta{
 					Name:         "route-controller-manager-serving",
-					ValidityDays: cryptomaterial.ShortLivedCertificateValidityDays,
+					ValidityDays: shortLived,
 				},
 				Hostnames: []string{
 					"route-controller-manager.openshift-route-controller-manager.svc",
@@ -222,15 +318,15 @@ func certSetup(cfg *config.Config) (*certchains.CertificateChains, error) {
 		certchains.NewCertificateSigner(
 			"ingress-ca",
 			cryptomaterial.IngressCADir(certsDir),
-			cryptomaterial.LongLivedCertificateValidityDays,
+			longLived,
 		).WithServingCertificates(
 			&certchains.ServingCertificateSigningRequestInfo{
 				CSRMeta: certchains.CSRMeta{
 					Name:         "router-default-serving",
-					ValidityDays: cryptomaterial.ShortLivedCertificateValidityDays,
+					ValidityDays: shortLived,
 				},
 				Hostnames: []string{
-					"*.apps." + cfg.DNS.BaseDomain, // wildcard for any additional auto-generated domains
+					"*." + cfg.Ingress.Domain, // wildcard for any additional auto-generated domains
 				},
 			},
 		),
@@ -240,12 +336,12 @@ func certSetup(cfg *config.Config) (*certchains.CertificateChains, error) {
 		certchains.NewCertificateSigner(
 			"kube-apiserver-external-signer",
 			cryptomaterial.KubeAPIServerExternalSigner(certsDir),
-			cryptomaterial.LongLivedCertificateValidityDays,
+			longLived,
 		).WithServingCertificates(
 			&certchains.ServingCertificateSigningRequestInfo{
 				CSRMeta: certchains.CSRMeta{
 					Name:         "kube-external-serving",
-					ValidityDays: cryptomaterial.ShortLivedCertificateValidityDays,
+					ValidityDays: shortLived,
 				},
 				Hostnames: externalCertNames,
 			},
@@ -254,12 +350,12 @@ func certSetup(cfg *config.Config) (*certchains.CertificateChains, error) {
 		certchains.NewCertificateSigner(
 			"kube-apiserver-localhost-signer",
 			cryptomaterial.KubeAPIServerLocalhostSigner(certsDir),
-			cryptomaterial.LongLivedCertificateValidityDays,
+			longLived,
 		).WithServingCertificates(
 			&certchains.ServingCertificateSigningRequestInfo{
 				CSRMeta: certchains.CSRMeta{
 					Name:         "kube-apiserver-localhost-serving",
-					ValidityDays: cryptomaterial.ShortLivedCertificateValidityDays,
+					ValidityDays: shortLived,
 				},
 				Hostnames: []string{
 					"localhost",
@@ -270,12 +366,12 @@ func certSetup(cfg *config.Config) (*certchains.CertificateChains, error) {
 		certchains.NewCertificateSigner(
 			"kube-apiserver-service-network-signer",
 			cryptomaterial.KubeAPIServerServiceNetworkSigner(certsDir),
-			cryptomaterial.LongLivedCertificateValidityDays,
+			longLived,
 		).WithServingCertificates(
 			&certchains.ServingCertificateSigningRequestInfo{
 				CSRMeta: certchains.CSRMeta{
 					Name:         "kube-apiserver-service-network-serving",
-					ValidityDays: cryptomaterial.ShortLivedCertificateValidityDays,
+					ValidityDays: shortLived,
 				},
 				Hostnames: []string{
 					"kubernetes",
@@ -300,12 +396,12 @@ func certSetup(cfg *config.Config) (*certchains.CertificateChains, error) {
 		certchains.NewCertificateSigner(
 			"etcd-signer",
 			cryptomaterial.EtcdSignerDir(certsDir),
-			cryptomaterial.LongLivedCertificateValidityDays,
+			longLived,
 		).WithClientCertificates(
 			&certchains.ClientCertificateSigningRequestInfo{
 				CSRMeta: certchains.CSRMeta{
 					Name:         "apiserver-etcd-client",
-					ValidityDays: cryptomaterial.LongLivedCertificateValidityDays,
+					ValidityDays: longLived,
 				},
 				UserInfo: &user.DefaultInfo{Name: "etcd", Groups: []string{"etcd"}},
 			},
@@ -313,15 +409,15 @@ func certSetup(cfg *config.Config) (*certchains.CertificateChains, error) {
 			&certchains.PeerCertificateSigningRequestInfo{
 				CSRMeta: certchains.CSRMeta{
 					Name:         "etcd-peer",
-					ValidityDays: cryptomaterial.LongLivedCertificateValidityDays,
+					ValidityDays: longLived,
 				},
 				UserInfo:  &user.DefaultInfo{Name: "system:etcd-peer:etcd-client", Groups: []string{"system:etcd-peers"}},
-				Hostnames: []string{"localhost", cfg.Node.HostnameOverride},
+				Hostnames: etcdPeerHostnames(cfg),
 			},
 			&certchains.PeerCertificateSigningRequestInfo{
 				CSRMeta: certchains.CSRMeta{
 					Name:         "etcd-serving",
-					ValidityDays: cryptomaterial.LongLivedCertificateValidityDays,
+					ValidityDays: longLived,
 				},
 				UserInfo:  &user.DefaultInfo{Name: "system:etcd-server:etcd-client", Groups: []string{"system:etcd-servers"}},
 				Hostnames: []string{"localhost", cfg.Node.HostnameOverride},
@@ -345,26 +441,82 @@ func certSetup(cfg *config.Config) (*certchains.CertificateChains, error) {
 		cryptomaterial.ServiceAccountTokenCABundlePath(certsDir),
 		[]string{"kube-apiserver-localhost-signer"},
 		[]string{"kube-apiserver-service-network-signer"},
-	).Complete()
+	), nil
+}
 
+// externalCA returns the external CA issuing the signers, or nil when they
+// are self-signed.
+func externalCA(cfg *config.Config, certsDir string) (*crypto.CA, error) {
+	if cfg.Certificates.PKCS11.IsEnabled() {
+		return tokenCA(cfg, certsDir)
+	}
+	if !cfg.Certificates.ExternalCA.IsEnabled() {
+		return nil, nil
+	}
+	// The serials of the certificates it issues are random, not to collide
+	// with the ones of its other users.
+	ca, err := crypto.GetCA(cfg.Certificates.ExternalCA.CertFile, cfg.Certificates.ExternalCA.KeyFile, "")
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to load certificates.externalCA: %w", err)
+	}
+	if cert := ca.Config.Certs[0]; !cert.IsCA || cert.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return nil, fmt.Errorf("certificates.externalCA.certFile %s is not a CA certificate", cfg.Certificates.ExternalCA.CertFile)
 	}
+	return ca, nil
+}
 
-	saKeyDir := filepath.Join(config.DataDir, "/resources/kube-apiserver/secrets/service-account-key")
-	if err := util.EnsureKeyPair(
-		filepath.Join(saKeyDir, "service-account.pub"),
-		filepath.Join(saKeyDir, "service-account.key"),
-	); err != nil {
-		return nil, err
+// tokenCA returns the CA whose key is in the certificates.pkcs11 token:
+// the external CA, or a root CA generated by MicroShift otherwise, whose
+// certificate is renewed with the same key like the long-lived signers.
+func tokenCA(cfg *config.Config, certsDir string) (*crypto.CA, error) {
+	p := cfg.Certificates.PKCS11
+	token := pkcs11.Token{Module: p.Module, Label: p.TokenLabel, PINFile: p.PINFile}
+	certFile := cfg.Certificates.ExternalCA.CertFile
+	key, err := token.EnsureKey(p.KeyLabel, certFile == "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the key of the CA from certificates.pkcs11: %w", err)
 	}
 
-	cfg.Ingress.ServingCertificate, cfg.Ingress.ServingKey, err = certChains.GetCertKey("ingress-ca", "router-default-serving")
+	if certFile == "" {
+		const month = 30 * time.Hour * 24
+		return certchains.EnsureKeyCA(
+			cryptomaterial.CACertPath(cryptomaterial.TokenRootCADir(certsDir)),
+			key,
+			"microshift-root-ca",
+			*cfg.Certificates.LongLivedValidityDays,
+			18*month,
+			cfg.Certificates.Backdate(),
+		)
+	}
+
+	certPEM, err := os.ReadFile(certFile)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to read certificates.externalCA.certFile: %w", err)
+	}
+	certs, err := crypto.CertsFromPEM(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificates.externalCA.certFile: %w", err)
+	}
+	if cert := certs[0]; !cert.IsCA || cert.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return nil, fmt.Errorf("certificates.externalCA.certFile %s is not a CA certificate", certFile)
 	}
+	if pub, ok := certs[0].PublicKey.(*rsa.PublicKey); !ok || !pub.Equal(key.Public()) {
+		return nil, fmt.Errorf("certificates.externalCA.certFile %s is not the certificate of key %q of the token", certFile, p.KeyLabel)
+	}
+	return &crypto.CA{
+		Config:          &crypto.TLSCertificateConfig{Certs: certs, Key: key},
+		SerialGenerator: &crypto.RandomSerialGenerator{},
+	}, nil
+}
 
-	return certChains, nil
+// etcdPeerHostnames returns the names in the etcd peer certificate, with
+// the node IP the member of the other node connects to with a standby.
+func etcdPeerHostnames(cfg *config.Config) []string {
+	hostnames := []string{"localhost", cfg.Node.HostnameOverride}
+	if cfg.Etcd.Standby.Role != config.EtcdStandbyRoleNone {
+		hostnames = append(hostnames, cfg.Node.NodeIP)
+	}
+	return hostnames
 }
 
 func initKubeconfigs(
@@ -539,12 +691,66 @@ func initKubeconfigs(
 	return nil
 }
 
+// checkClock handles the clock being behind the stored certificates, which
+// are then not yet valid, as set by certificates.clockSkewPolicy. This
+// happens on the devices without a real-time clock booting before NTP sets
+// the clock, and the certificates would be regenerated from the wrong
+// clock.
+func checkClock(cs *certchains.CertificateChains, policy config.ClockSkewPolicyEnum) error {
+	var latest x509.Certificate
+	var latestPath []string
+	if err := cs.WalkChains(nil, func(certPath []string, c x509.Certificate) error {
+		if c.NotBefore.After(latest.NotBefore) {
+			latest, latestPath = c, certPath
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if !now.Before(latest.NotBefore) {
+		return nil
+	}
+	err := fmt.Errorf("the clock (%s) is behind the certificate %s, valid from %s",
+		now.Format(time.RFC3339), strings.Join(latestPath, "/"), latest.NotBefore.Format(time.RFC3339))
+	if policy == config.ClockSkewPolicyRefuse {
+		return fmt.Errorf("%w, refusing to start until the clock is set", err)
+	}
+	klog.Warningf("%v, regenerating the certificates that are not yet valid", err)
+	return nil
+}
+
+// changedNames returns the names added to and removed from the ones of the
+// server certificate in certFile, which is regenerated when they differ. There
+// is no change when the certificate does not exist yet.
+func changedNames(certFile string, names []string) (added, removed []string) {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, nil
+	}
+	certs, err := crypto.CertsFromPEM(certPEM)
+	if err != nil {
+		klog.Warningf("Failed to parse certificate %s: %v", certFile, err)
+		return nil, nil
+	}
+
+	certNames := sets.New[string](certs[0].DNSNames...)
+	for _, ip := range certs[0].IPAddresses {
+		certNames.Insert(ip.String())
+	}
+	wanted := sets.New[string](names...)
+	return sets.List(wanted.Difference(certNames)), sets.List(certNames.Difference(wanted))
+}
+
 // certsToRegenerate returns paths to certificates in the given certificate chains
 // bundle that need to be regenerated
 func certsToRegenerate(cs *certchains.CertificateChains) ([][]string, error) {
 	regenCerts := [][]string{}
 	err := cs.WalkChains(nil, func(certPath []string, c x509.Certificate) error {
-		if now := time.Now(); now.Before(c.NotBefore) || now.After(c.NotAfter) {
+		// Certificates valid from before EarliestSaneTime were generated
+		// before the clock was set.
+		if now := time.Now(); now.Before(c.NotBefore) || now.After(c.NotAfter) || c.NotBefore.Before(sysconfwatch.EarliestSaneTime) {
 			regenCerts = append(regenCerts, certPath)
 		}
 
@@ -593,6 +799,7 @@ func cleanupStaleKubeconfigs(cfg *config.Config, path string) error {
 	for _, deletePath := range deleteDirs {
 		if err := os.RemoveAll(deletePath); err != nil {
 			klog.Warningf("Unable to remove %s: %v", deletePath, err)
+			continue
 		}
 		klog.Infof("Removed stale kubeconfig %s", deletePath)
 	}
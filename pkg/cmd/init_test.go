@@ -20,8 +20,10 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/util/cryptomaterial"
 	"github.com/openshift/microshift/pkg/util/cryptomaterial/certchains"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -173,3 +175,63 @@ func mustComplete(t *testing.T, cs certchains.CertificateChainsBuilder) *certcha
 	require.NoError(t, err)
 	return ret
 }
+
+func Test_checkClock(t *testing.T) {
+	// A negative backdate makes the certificates valid from the future, as
+	// when the clock was set back since they were generated.
+	chains := func(backdate time.Duration) *certchains.CertificateChains {
+		return mustComplete(t,
+			certchains.NewCertificateChains(certchains.NewCertificateSigner("signer", t.TempDir(), 365).
+				WithClientCertificates(&certchains.ClientCertificateSigningRequestInfo{
+					CSRMeta:  certchains.CSRMeta{Name: "somename", ValidityDays: 280},
+					UserInfo: &user.DefaultInfo{Name: "someclient"},
+				}),
+			).WithBackdate(backdate))
+	}
+
+	assert.NoError(t, checkClock(chains(0), config.ClockSkewPolicyRefuse))
+	assert.NoError(t, checkClock(chains(-time.Hour), config.ClockSkewPolicyWarn))
+	err := checkClock(chains(-time.Hour), config.ClockSkewPolicyRefuse)
+	assert.ErrorContains(t, err, "is behind the certificate signer")
+}
+
+func Test_changedNames(t *testing.T) {
+	tmpDir := t.TempDir()
+	chains := func(names ...string) *certchains.CertificateChains {
+		return mustComplete(t, certchains.NewCertificateChains(
+			certchains.NewCertificateSigner("kube-apiserver-external-signer", tmpDir, 365).
+				WithServingCertificates(&certchains.ServingCertificateSigningRequestInfo{
+					CSRMeta:   certchains.CSRMeta{Name: "kube-external-serving", ValidityDays: 365},
+					Hostnames: names,
+				}),
+		))
+	}
+	certFile := cryptomaterial.ServingCertPath(filepath.Join(tmpDir, "kube-external-serving"))
+
+	added, removed := changedNames(certFile, []string{"hostname"})
+	assert.Empty(t, added, "no certificate yet")
+	assert.Empty(t, removed)
+
+	chains("hostname", "api.example.com", "10.0.0.1")
+	signerBefore, err := os.ReadFile(cryptomaterial.CACertPath(tmpDir))
+	require.NoError(t, err)
+
+	added, removed = changedNames(certFile, []string{"hostname", "api.example.com", "10.0.0.1"})
+	assert.Empty(t, added)
+	assert.Empty(t, removed)
+
+	names := []string{"hostname", "api.example.com", "microshift.example.com", "10.0.0.2"}
+	added, removed = changedNames(certFile, names)
+	assert.Equal(t, []string{"10.0.0.2", "microshift.example.com"}, added)
+	assert.Equal(t, []string{"10.0.0.1"}, removed)
+
+	// The serving certificate is regenerated for the new names, with the
+	// same signer.
+	chains(names...)
+	signerAfter, err := os.ReadFile(cryptomaterial.CACertPath(tmpDir))
+	require.NoError(t, err)
+	assert.Equal(t, signerBefore, signerAfter)
+	added, removed = changedNames(certFile, names)
+	assert.Empty(t, added)
+	assert.Empty(t, removed)
+}
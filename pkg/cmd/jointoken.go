@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/join"
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func NewJoinTokenCommand(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "join-token",
+		Short: "Manage the tokens of the join API for nodes to join the control plane",
+	}
+
+	cmd.AddCommand(newJoinTokenCreateCommand(ioStreams))
+	cmd.AddCommand(newJoinTokenListCommand(ioStreams))
+	cmd.AddCommand(newJoinTokenDeleteCommand(ioStreams))
+
+	return cmd
+}
+
+func newJoinTokenCreateCommand(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	ttl := 24 * time.Hour
+	description := ""
+	printJoinCommand := true
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a join token and print the command joining a node with it",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(shouldRunPrivileged())
+
+			token, t, err := join.NewTokenStore().Create(ttl, description)
+			cmdutil.CheckErr(err)
+			if !printJoinCommand {
+				fmt.Fprintln(ioStreams.Out, token)
+				return
+			}
+
+			cfg, err := config.ActiveConfig()
+			cmdutil.CheckErr(err)
+			hash, err := join.ServerCACertHash()
+			cmdutil.CheckErr(err)
+			joinURL := "https://" + net.JoinHostPort(cfg.Node.NodeIP, strconv.Itoa(join.ServerPort))
+			if t.Expires.IsZero() {
+				fmt.Fprintf(ioStreams.Out, "Created join token %s, it never expires.\n", t.ID)
+			} else {
+				fmt.Fprintf(ioStreams.Out, "Created join token %s, it expires at %s.\n", t.ID, t.Expires.Format(time.RFC3339))
+			}
+			fmt.Fprintf(ioStreams.Out, "Join a node with:\n\n  microshift run --roles=%s --join-url=%s --join-token=%s --join-ca-cert-hash=%s\n",
+				config.RoleNode, joinURL, token, hash)
+		},
+	}
+
+	cmd.Flags().DurationVar(&ttl, "ttl", ttl, "Time the token is valid for, 0 for a token that never expires.")
+	cmd.Flags().StringVar(&description, "description", description, "Description of what the token is used for.")
+	cmd.Flags().BoolVar(&printJoinCommand, "print-join-command", printJoinCommand, "Print the command joining a node, instead of only the token.")
+
+	return cmd
+}
+
+func newJoinTokenListCommand(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the join tokens with their expiry",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(shouldRunPrivileged())
+
+			tokens, err := join.NewTokenStore().List()
+			cmdutil.CheckErr(err)
+
+			now := time.Now()
+			w := tabwriter.NewWriter(ioStreams.Out, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tEXPIRES\tSTATUS\tNODE\tDESCRIPTION")
+			for _, t := range tokens {
+				expires, status := "never", "valid"
+				if !t.Expires.IsZero() {
+					expires = t.Expires.Format(time.RFC3339)
+				}
+				if t.Expired(now) {
+					status = "expired"
+				}
+				node := t.NodeName
+				if node == "" {
+					node = "<none>"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", t.ID, expires, status, node, t.Description)
+			}
+			cmdutil.CheckErr(w.Flush())
+		},
+	}
+
+	return cmd
+}
+
+func newJoinTokenDeleteCommand(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete ID...",
+		Short: "Delete join tokens, the nodes that joined with them are not affected",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(shouldRunPrivileged())
+
+			store := join.NewTokenStore()
+			for _, id := range args {
+				cmdutil.CheckErr(store.Delete(id))
+				fmt.Fprintf(ioStreams.Out, "Deleted join token %s\n", id)
+			}
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/util"
+	"github.com/openshift/microshift/pkg/util/cryptomaterial"
+	"github.com/spf13/cobra"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+type KubeconfigOptions struct {
+	// Hostname is the API server name written into the kubeconfig. When
+	// empty, the internal cluster URL is used.
+	Hostname string
+	// User is the name of the user in the client certificate. When empty,
+	// the existing system:admin certificate is reused.
+	User string
+	// Groups are the groups of the user in the client certificate.
+	Groups []string
+	// Expiration is the lifetime of a newly minted client certificate.
+	Expiration time.Duration
+	// Output is the path of the kubeconfig file. Printed to stdout if empty.
+	Output string
+
+	genericclioptions.IOStreams
+}
+
+func NewKubeconfigOptions(ioStreams genericclioptions.IOStreams) *KubeconfigOptions {
+	return &KubeconfigOptions{
+		IOStreams: ioStreams,
+	}
+}
+
+func NewKubeconfigCommand(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	o := NewKubeconfigOptions(ioStreams)
+	cmd := &cobra.Command{
+		Use:   "kubeconfig",
+		Short: "Generate an additional kubeconfig for MicroShift",
+		Long: `Generate an additional kubeconfig for MicroShift.
+
+Without flags, the kubeconfig is equivalent to the one in
+/var/lib/microshift/resources/kubeadmin/kubeconfig. Use --hostname to point
+the kubeconfig at one of the API server's external names, --user and --group
+to mint a dedicated client certificate with limited privileges, and
+--expiration to mint a short-lived client certificate.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(shouldRunPrivileged())
+			cmdutil.CheckErr(o.Validate())
+			cfg, err := config.ActiveConfig()
+			cmdutil.CheckErr(err)
+			cmdutil.CheckErr(o.Run(cfg))
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&o.Hostname, "hostname", o.Hostname, "External hostname or IP of the API server to use in the kubeconfig.")
+	flags.StringVar(&o.User, "user", o.User, "User name of a newly minted client certificate. Defaults to system:admin.")
+	flags.StringSliceVar(&o.Groups, "group", o.Groups, "Group of the user in a newly minted client certificate. May be repeated.")
+	flags.DurationVar(&o.Expiration, "expiration", o.Expiration, "Lifetime of a newly minted client certificate, e.g. 24h. Defaults to 1 year when --user is set.")
+	flags.StringVarP(&o.Output, "output", "o", o.Output, "Path to write the kubeconfig to. Printed to stdout if not set.")
+
+	return cmd
+}
+
+func (o *KubeconfigOptions) Validate() error {
+	if o.Expiration < 0 {
+		return fmt.Errorf("--expiration must not be negative")
+	}
+	if o.User == "" && len(o.Groups) != 0 {
+		return fmt.Errorf("--group requires --user")
+	}
+	return nil
+}
+
+// mintsCertificate returns true when the kubeconfig needs a dedicated
+// client certificate rather than the boot-time admin one.
+func (o *KubeconfigOptions) mintsCertificate() bool {
+	return o.User != "" || o.Expiration != 0
+}
+
+func (o *KubeconfigOptions) Run(cfg *config.Config) error {
+	certsDir := cryptomaterial.CertsDirectory(config.DataDir)
+
+	serverURL := cfg.ApiServer.URL
+	trustSigner := cryptomaterial.KubeAPIServerLocalhostSigner(certsDir)
+	if o.Hostname != "" {
+		u, err := url.Parse(cfg.ApiServer.URL)
+		if err != nil {
+			return fmt.Errorf("failed to parse cluster URL: %w", err)
+		}
+		u.Host = net.JoinHostPort(o.Hostname, strconv.Itoa(cfg.ApiServer.Port))
+		serverURL = u.String()
+		trustSigner = cryptomaterial.KubeAPIServerExternalSigner(certsDir)
+
+		if !slices.Contains(slices.Concat(cfg.ApiServer.SubjectAltNames, []string{cfg.Node.HostnameOverride, cfg.Node.NodeIP}), o.Hostname) {
+			fmt.Fprintf(o.ErrOut, "WARNING: %q is not a name of the API server certificate, add it to apiServer.subjectAltNames and restart MicroShift\n", o.Hostname)
+		}
+	}
+
+	trustPEM, err := os.ReadFile(cryptomaterial.CACertPath(trustSigner))
+	if err != nil {
+		return fmt.Errorf("failed to load the trust signer: %w", err)
+	}
+
+	certPEM, keyPEM, err := o.clientCertKey(certsDir)
+	if err != nil {
+		return err
+	}
+
+	kubeconfig := util.NewKubeConfigWithClientCerts(serverURL, trustPEM, certPEM, keyPEM)
+	if o.Output != "" {
+		return clientcmd.WriteToFile(kubeconfig, o.Output)
+	}
+	marshalled, err := clientcmd.Write(kubeconfig)
+	if err != nil {
+		return err
+	}
+	_, err = o.Out.Write(marshalled)
+	return err
+}
+
+func (o *KubeconfigOptions) clientCertKey(certsDir string) ([]byte, []byte, error) {
+	signerDir := cryptomaterial.AdminKubeconfigSignerDir(certsDir)
+
+	if !o.mintsCertificate() {
+		clientDir := cryptomaterial.AdminKubeconfigClientCertDir(certsDir)
+		certPEM, err := os.ReadFile(cryptomaterial.ClientCertPath(clientDir))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read admin client certificate: %w", err)
+		}
+		keyPEM, err := os.ReadFile(cryptomaterial.ClientKeyPath(clientDir))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read admin client key: %w", err)
+		}
+		return certPEM, keyPEM, nil
+	}
+
+	ca, err := crypto.GetCA(
+		cryptomaterial.CACertPath(signerDir),
+		cryptomaterial.CAKeyPath(signerDir),
+		cryptomaterial.CASerialsPath(signerDir),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load admin-kubeconfig-signer: %w", err)
+	}
+
+	u := &user.DefaultInfo{Name: o.User, Groups: o.Groups}
+	if u.Name == "" {
+		u = &user.DefaultInfo{Name: "system:admin", Groups: []string{"system:masters"}}
+	}
+	lifetime := o.Expiration
+	if lifetime == 0 {
+		lifetime = cryptomaterial.ShortLivedCertificateValidityDays * 24 * time.Hour
+	}
+
+	tlsConfig, err := ca.MakeClientCertificateForDuration(u, lifetime)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign client certificate for %q: %w", u.Name, err)
+	}
+	certPEM, keyPEM, err := tlsConfig.GetPEMBytes()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The certificate, without its key, is kept for "microshift certs revoke".
+	issuedDir := cryptomaterial.AdminKubeconfigIssuedDir(certsDir)
+	if err := os.MkdirAll(issuedDir, 0700); err != nil {
+		return nil, nil, err
+	}
+	issuedPath := filepath.Join(issuedDir, tlsConfig.Certs[0].SerialNumber.Text(16)+".crt")
+	if err := os.WriteFile(issuedPath, certPEM, 0600); err != nil {
+		return nil, nil, fmt.Errorf("failed to record client certificate for %q: %w", u.Name, err)
+	}
+	return certPEM, keyPEM, nil
+}
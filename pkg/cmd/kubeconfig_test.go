@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKubeconfigOptionsValidate(t *testing.T) {
+	testData := []struct {
+		name        string
+		opts        KubeconfigOptions
+		errExpected bool
+		mints       bool
+	}{
+		{
+			name: "Defaults reuse the admin certificate",
+			opts: KubeconfigOptions{},
+		},
+		{
+			name: "Hostname only reuses the admin certificate",
+			opts: KubeconfigOptions{Hostname: "microshift.example.com"},
+		},
+		{
+			name:  "User with group",
+			opts:  KubeconfigOptions{User: "viewer", Groups: []string{"viewers"}},
+			mints: true,
+		},
+		{
+			name:  "Short-lived admin",
+			opts:  KubeconfigOptions{Expiration: time.Hour},
+			mints: true,
+		},
+		{
+			name:        "Group without user",
+			opts:        KubeconfigOptions{Groups: []string{"viewers"}},
+			errExpected: true,
+		},
+		{
+			name:        "Negative expiration",
+			opts:        KubeconfigOptions{User: "viewer", Expiration: -time.Hour},
+			errExpected: true,
+			mints:       true,
+		},
+	}
+
+	for _, td := range testData {
+		t.Run(td.name, func(t *testing.T) {
+			err := td.opts.Validate()
+			assert.Equal(t, td.errExpected, err != nil, "unexpected error: %v", err)
+			assert.Equal(t, td.mints, td.opts.mintsCertificate())
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/openshift/microshift/pkg/admin/prerun"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func NewPreUpgradeCheckCommand(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	targetVersion := ""
+	output := ""
+
+	cmd := &cobra.Command{
+		Use:   "pre-upgrade-check",
+		Short: "Check if the existing MicroShift data can be used by a MicroShift version",
+		Long: `Check if the existing MicroShift data can be used by a MicroShift version,
+applying the same version skew and blocked upgrade rules as on start.
+
+By default the version of this executable is checked, which is useful to run
+the new executable before restarting MicroShift on it. Use --target-version to
+check another version, for example before staging an upgrade. Exits with a
+non-zero code if the upgrade would be refused.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(shouldRunPrivileged())
+
+			decision, err := prerun.CheckUpgrade(targetVersion)
+			cmdutil.CheckErr(err)
+			cmdutil.CheckErr(printUpgradeDecision(ioStreams, output, decision))
+			if !decision.Allowed {
+				cmdutil.CheckErr(cmdutil.ErrExit)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&targetVersion, "target-version", targetVersion, "MicroShift version to check, in the major.minor.patch format. Defaults to the version of this executable.")
+	cmd.Flags().StringVarP(&output, "output", "o", output, "One of 'yaml' or 'json'.")
+
+	return cmd
+}
+
+func printUpgradeDecision(ioStreams genericclioptions.IOStreams, output string, d prerun.UpgradeDecision) error {
+	switch output {
+	case "":
+		dataVersion := d.DataVersion
+		if dataVersion == "" {
+			dataVersion = "none"
+		}
+		if d.Allowed {
+			fmt.Fprintf(ioStreams.Out, "Upgrade from %s to %s is allowed\n", dataVersion, d.TargetVersion)
+		} else {
+			fmt.Fprintf(ioStreams.Out, "Upgrade from %s to %s is not allowed: %s\n", dataVersion, d.TargetVersion, d.Reason)
+		}
+	case "yaml":
+		marshalled, err := yaml.Marshal(d)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ioStreams.Out, string(marshalled))
+	case "json":
+		marshalled, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ioStreams.Out, string(marshalled))
+	default:
+		return fmt.Errorf("unsupported --output=%q, must be one of 'yaml' or 'json'", output)
+	}
+	return nil
+}
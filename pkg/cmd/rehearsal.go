@@ -0,0 +1,319 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/openshift/microshift/pkg/admin/data"
+	"github.com/openshift/microshift/pkg/admin/prerun"
+	"github.com/openshift/microshift/pkg/assets"
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/controllers"
+	"github.com/openshift/microshift/pkg/healthcheck"
+	"github.com/openshift/microshift/pkg/node"
+	"github.com/openshift/microshift/pkg/servicemanager"
+	"github.com/openshift/microshift/pkg/util"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+	migrationv1alpha1 "sigs.k8s.io/kube-storage-version-migrator/pkg/apis/migration/v1alpha1"
+	migrationclient "sigs.k8s.io/kube-storage-version-migrator/pkg/clients/clientset"
+)
+
+const (
+	// defaultUpgradeRehearsalDir is on the file system of the data, for the
+	// clone to share the blocks of the data where reflinks are supported.
+	defaultUpgradeRehearsalDir = "/var/lib/microshift-upgrade-rehearsal"
+	// upgradeRehearsalTimeout bounds the start of the services, the
+	// storage migrations and the health checks of the rehearsal.
+	upgradeRehearsalTimeout = 10 * time.Minute
+	// upgradeRehearsalDataFlag is the hidden flag running the rehearsal in
+	// the private mount namespace, on the clone in the rehearsal directory.
+	upgradeRehearsalDataFlag = "upgrade-rehearsal-data"
+
+	storageMigrationPollInterval = 2 * time.Second
+)
+
+// RunMicroshiftUpgradeRehearsal starts the control plane of this MicroShift
+// executable on a copy-on-write clone of the data, made in dir, and checks
+// that the storage of every resource can be migrated and that the API server
+// is healthy. The rehearsal runs in a private mount namespace where the clone
+// is mounted over the data directory, so the data itself is never touched.
+// MicroShift must be stopped, as the rehearsal uses the same ports.
+func RunMicroshiftUpgradeRehearsal(cfg *config.Config, dir string) error {
+	klog.InfoS("MICROSHIFT UPGRADE REHEARSAL STARTING")
+	if err := shouldRunPrivileged(); err != nil {
+		return err
+	}
+	if cfg.Etcd.IsExternal() {
+		return fmt.Errorf("the upgrade of an external etcd cannot be rehearsed on a clone of the data")
+	}
+	if err := exec.Command("systemctl", "is-active", "--quiet", "microshift.service").Run(); err == nil {
+		return fmt.Errorf("microshift.service is active: stop MicroShift before rehearsing the upgrade")
+	}
+
+	decision, err := prerun.CheckUpgrade("")
+	if err != nil {
+		return err
+	}
+	if !decision.Allowed {
+		return fmt.Errorf("upgrade from %s to %s is not allowed: %s", decision.DataVersion, decision.TargetVersion, decision.Reason)
+	}
+	if decision.DataVersion == "" {
+		return fmt.Errorf("there is no MicroShift data in %s to rehearse the upgrade of", config.DataDir)
+	}
+	klog.InfoS("Upgrade is allowed by the version checks", "data", decision.DataVersion, "exec", decision.TargetVersion)
+
+	keep := dir != ""
+	if !keep {
+		dir = defaultUpgradeRehearsalDir
+	}
+	if err := util.MakeDir(dir); err != nil {
+		return fmt.Errorf("failed to create dir %q: %w", dir, err)
+	}
+
+	// The clone is made by the data manager, which copies with reflinks
+	// when the file system supports them.
+	dataManager, err := data.NewManager(data.StoragePath(dir))
+	if err != nil {
+		return err
+	}
+	if exists, err := dataManager.BackupExists("data"); err != nil {
+		return err
+	} else if exists {
+		if err := dataManager.RemoveBackup("data"); err != nil {
+			return fmt.Errorf("failed to remove the clone of the previous rehearsal: %w", err)
+		}
+	}
+	if _, err := dataManager.Backup("data"); err != nil {
+		return fmt.Errorf("failed to clone the data: %w", err)
+	}
+	if !keep {
+		defer func() {
+			if err := os.RemoveAll(dir); err != nil {
+				klog.ErrorS(err, "Failed to remove the upgrade rehearsal directory", "dir", dir)
+			}
+		}()
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get exec path: %w", err)
+	}
+	cmd := exec.Command(exe, "run", "--"+upgradeRehearsalDataFlag, dir) //nolint:gosec
+	cmd.SysProcAttr = &syscall.SysProcAttr{Unshareflags: syscall.CLONE_NEWNS}
+	// Without INVOCATION_ID, etcd runs as a child process in the mount
+	// namespace rather than in a transient unit outside of it.
+	cmd.Env = []string{}
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, "INVOCATION_ID=") && !strings.HasPrefix(env, "NOTIFY_SOCKET=") {
+			cmd.Env = append(cmd.Env, env)
+		}
+	}
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		klog.ErrorS(err, "MICROSHIFT UPGRADE REHEARSAL FAILED", "dir", dir)
+		return fmt.Errorf("upgrade rehearsal of %s to %s failed: %w", decision.DataVersion, decision.TargetVersion, err)
+	}
+	klog.InfoS("MICROSHIFT UPGRADE REHEARSAL PASSED", "data", decision.DataVersion, "exec", decision.TargetVersion)
+	return nil
+}
+
+// runUpgradeRehearsalInNamespace is the part of the rehearsal running in the
+// private mount namespace.
+func runUpgradeRehearsalInNamespace(cfg *config.Config, dir string) error {
+	// Besides the data, the audit log of the rehearsal is kept away from
+	// the one of MicroShift.
+	mounts := map[string]string{
+		filepath.Join(dir, "data"): config.DataDir,
+		filepath.Join(dir, "log"):  "/var/log/kube-apiserver",
+	}
+	for src, target := range mounts {
+		if err := util.MakeDir(src); err != nil {
+			return fmt.Errorf("failed to create dir %q: %w", src, err)
+		}
+		if err := util.MakeDir(target); err != nil {
+			return fmt.Errorf("failed to create dir %q: %w", target, err)
+		}
+		if err := syscall.Mount(src, target, "", syscall.MS_BIND, ""); err != nil {
+			return fmt.Errorf("failed to mount %q on %q: %w", src, target, err)
+		}
+		klog.InfoS("Mounted for the upgrade rehearsal", "source", src, "target", target)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), upgradeRehearsalTimeout)
+	defer cancel()
+
+	certChains, err := initCerts(ctx, cfg, externalCA)
+	if err != nil {
+		return fmt.Errorf("failed to generate the certificates: %w", err)
+	}
+	if err := initKubeconfigs(cfg, certChains); err != nil {
+		return fmt.Errorf("failed to generate the kubeconfigs: %w", err)
+	}
+	if err := assets.LoadOverrides(config.OverridesDir); err != nil {
+		return fmt.Errorf("failed to load the overrides of the component manifests: %w", err)
+	}
+
+	// Only the control plane runs: the kubelet would start the workloads
+	// of the clone on the host.
+	migrator := controllers.NewKubeStorageVersionMigrator(cfg)
+	m := servicemanager.NewServiceManager()
+	util.Must(m.AddService(node.NewNetworkConfiguration(cfg)))
+	util.Must(m.AddService(controllers.NewEtcd(cfg)))
+	util.Must(m.AddService(controllers.NewKubeAPIServer(cfg)))
+	util.Must(m.AddService(controllers.NewOpenShiftCRDManager(cfg)))
+	util.Must(m.AddService(servicemanager.NewGenericService(migrator.Name(), []string{"kube-apiserver"}, migrator.Run)))
+
+	runCtx, runCancel := context.WithCancel(ctx)
+	ready, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		if err := m.Run(runCtx, ready, stopped); err != nil {
+			klog.Errorf("Stopped %s: %v", m.Name(), err)
+		}
+	}()
+	defer func() {
+		runCancel()
+		<-stopped
+	}()
+
+	select {
+	case <-ready:
+		klog.InfoS("Upgrade rehearsal services are ready")
+	case <-stopped:
+		return fmt.Errorf("upgrade rehearsal services stopped before becoming ready")
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for the upgrade rehearsal services to be ready")
+	}
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", cfg.KubeConfigPath(config.KubeAdmin))
+	if err != nil {
+		return fmt.Errorf("failed to load the kubeconfig: %w", err)
+	}
+	restConfig = rest.AddUserAgent(restConfig, "microshift-upgrade-rehearsal")
+
+	if err := migrateAllResources(ctx, restConfig); err != nil {
+		return err
+	}
+
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	if err := healthcheck.WaitForAPI(ctx, client); err != nil {
+		return err
+	}
+	klog.InfoS("API server is healthy on the upgraded data")
+	return nil
+}
+
+// migrateAllResources rewrites every stored resource in its storage version
+// with kube-storage-version-migrator, and fails listing the resources which
+// could not be migrated.
+func migrateAllResources(ctx context.Context, restConfig *rest.Config) error {
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	migrations, err := migrationclient.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+
+	resourceLists, err := client.Discovery().ServerPreferredResources()
+	if err != nil {
+		return fmt.Errorf("failed to discover the resources: %w", err)
+	}
+	resources := []migrationv1alpha1.GroupVersionResource{}
+	for _, list := range resourceLists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return err
+		}
+		for _, r := range list.APIResources {
+			// Only the resources which can be listed and updated are
+			// stored, the other ones are virtual or subresources.
+			verbs := sets.New[string](r.Verbs...)
+			if strings.Contains(r.Name, "/") || !verbs.HasAll("list", "update") {
+				continue
+			}
+			resources = append(resources, migrationv1alpha1.GroupVersionResource{Group: gv.Group, Version: gv.Version, Resource: r.Name})
+		}
+	}
+
+	klog.InfoS("Migrating the storage of the resources", "count", len(resources))
+	failed := []string{}
+	for _, r := range resources {
+		if err := migrateResource(ctx, migrations, r); err != nil {
+			klog.ErrorS(err, "Failed to migrate the storage", "resource", r)
+			failed = append(failed, fmt.Sprintf("%s: %v", storageMigrationName(r), err))
+			continue
+		}
+		klog.InfoS("Migrated the storage", "resource", r)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to migrate the storage of %d resources: %s", len(failed), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+func migrateResource(ctx context.Context, client migrationclient.Interface, r migrationv1alpha1.GroupVersionResource) error {
+	migrations := client.MigrationV1alpha1().StorageVersionMigrations()
+	name := storageMigrationName(r)
+	_, err := migrations.Create(ctx, &migrationv1alpha1.StorageVersionMigration{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       migrationv1alpha1.StorageVersionMigrationSpec{Resource: r},
+	}, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create storage version migration %q: %w", name, err)
+	}
+
+	var failure error
+	err = wait.PollUntilContextCancel(ctx, storageMigrationPollInterval, true, func(ctx context.Context) (bool, error) {
+		m, err := migrations.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			klog.Warningf("Failed to get storage version migration %q: %v", name, err)
+			return false, nil
+		}
+		for _, c := range m.Status.Conditions {
+			if c.Status != corev1.ConditionTrue {
+				continue
+			}
+			switch c.Type {
+			case migrationv1alpha1.MigrationSucceeded:
+				return true, nil
+			case migrationv1alpha1.MigrationFailed:
+				failure = errors.New(c.Message)
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("timed out waiting for storage version migration %q", name)
+	}
+	return failure
+}
+
+// storageMigrationName returns a valid object name for the migration of r.
+func storageMigrationName(r migrationv1alpha1.GroupVersionResource) string {
+	if r.Group == "" {
+		return "upgrade-rehearsal-" + r.Resource
+	}
+	return "upgrade-rehearsal-" + r.Resource + "." + r.Group
+}
@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	migrationv1alpha1 "sigs.k8s.io/kube-storage-version-migrator/pkg/apis/migration/v1alpha1"
+)
+
+func TestStorageMigrationName(t *testing.T) {
+	assert.Equal(t, "upgrade-rehearsal-configmaps",
+		storageMigrationName(migrationv1alpha1.GroupVersionResource{Version: "v1", Resource: "configmaps"}))
+	assert.Equal(t, "upgrade-rehearsal-routes.route.openshift.io",
+		storageMigrationName(migrationv1alpha1.GroupVersionResource{Group: "route.openshift.io", Version: "v1", Resource: "routes"}))
+}
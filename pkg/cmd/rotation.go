@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/openshift/microshift/pkg/util/cryptomaterial/certchains"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+)
+
+// hotReloadedCerts maps the certificates whose consumers load them again
+// from disk when they change to these consumers. They are rotated while
+// MicroShift runs. The other certificates are embedded in kubeconfigs,
+// trusted through CA bundles, or loaded once by the components running in
+// the MicroShift process, which cannot be restarted on their own, so
+// MicroShift restarts to rotate them.
+var hotReloadedCerts = map[string]string{
+	"kube-apiserver-external-signer/kube-external-serving":                         "kube-apiserver, fleet API",
+	"kube-apiserver-localhost-signer/kube-apiserver-localhost-serving":             "kube-apiserver",
+	"kube-apiserver-service-network-signer/kube-apiserver-service-network-serving": "kube-apiserver, join server",
+	"kube-apiserver-to-kubelet-signer/kube-apiserver-to-kubelet-client":            "kube-apiserver",
+	"aggregator-signer/aggregator-client":                                          "kube-apiserver",
+	"kubelet-signer/kube-csr-signer/kubelet-server":                                "kubelet",
+	"service-ca/route-controller-manager-serving":                                  "route-controller-manager",
+	"etcd-signer/apiserver-etcd-client":                                            "kube-apiserver, etcd health check",
+	"etcd-signer/etcd-peer":                                                        "etcd",
+	"etcd-signer/etcd-serving":                                                     "etcd",
+}
+
+// rotateCertificates rotates the certificates when they are due, until ctx
+// is done. The certificates that are hot-reloaded are regenerated in place,
+// restart is called for MicroShift to regenerate the others on start.
+func rotateCertificates(ctx context.Context, cs *certchains.CertificateChains, restart func()) {
+	// The certificates still due for rotation once regenerated, on start or
+	// here, cannot be renewed, like the signers of an external CA expiring
+	// soon. They are reported by the CertificatesExpiringSoon node condition
+	// instead of restarting over and over.
+	unrenewable := sets.New[string]()
+	for {
+		due, err := certchains.CertsToRotate(cs, time.Now(), unrenewable)
+		if err != nil {
+			klog.Fatalf("failed to determine when to rotate certificates: %v", err)
+		}
+		for _, certPath := range due {
+			klog.Warningf("Certificate %s cannot be renewed before its rotation date", strings.Join(certPath, "/"))
+			unrenewable.Insert(strings.Join(certPath, "/"))
+		}
+
+		certPath, rotationDate, err := nextRotation(cs, unrenewable)
+		if err != nil {
+			klog.Fatalf("failed to determine when to rotate certificates: %v", err)
+		}
+		if certPath == nil {
+			<-ctx.Done()
+			klog.Info("Certificate watcher exiting")
+			return
+		}
+		klog.Infof("Rotating certificate %s at %s", strings.Join(certPath, "/"), rotationDate.Format(time.RFC3339))
+
+		select {
+		case <-time.After(time.Until(rotationDate)):
+		case <-ctx.Done():
+			klog.Info("Certificate watcher exiting")
+			return
+		}
+
+		needsRestart, err := rotateDueCerts(cs, time.Now(), unrenewable)
+		if err != nil {
+			klog.Errorf("Failed to rotate certificates: %v", err)
+			restart()
+			return
+		}
+		if needsRestart {
+			restart()
+			return
+		}
+	}
+}
+
+// nextRotation returns the certificate rotated first and when, leaving out
+// the certificates that cannot be renewed.
+func nextRotation(cs *certchains.CertificateChains, unrenewable sets.Set[string]) ([]string, time.Time, error) {
+	var (
+		certPath     []string
+		rotationDate time.Time
+	)
+	err := cs.WalkChains(nil, func(currentPath []string, c x509.Certificate) error {
+		if unrenewable.Has(strings.Join(currentPath, "/")) {
+			return nil
+		}
+		if rotateAt := certchains.RotationDate(&c); certPath == nil || rotateAt.Before(rotationDate) {
+			certPath, rotationDate = slices.Clone(currentPath), rotateAt
+		}
+		return nil
+	})
+	return certPath, rotationDate, err
+}
+
+// rotateDueCerts regenerates the certificates due for rotation at the given
+// time when all of them are hot-reloaded, and returns whether MicroShift
+// must restart to rotate them otherwise.
+func rotateDueCerts(cs *certchains.CertificateChains, at time.Time, unrenewable sets.Set[string]) (bool, error) {
+	due, err := certchains.CertsToRotate(cs, at, unrenewable)
+	if err != nil {
+		return false, err
+	}
+	for _, certPath := range due {
+		if _, ok := hotReloadedCerts[strings.Join(certPath, "/")]; !ok {
+			klog.Infof("Certificate %s is not reloaded by its consumers, restarting to rotate it", strings.Join(certPath, "/"))
+			return true, nil
+		}
+	}
+
+	for _, certPath := range due {
+		name := strings.Join(certPath, "/")
+		if err := cs.Regenerate(certPath...); err != nil {
+			return false, fmt.Errorf("failed to regenerate %s: %w", name, err)
+		}
+		klog.Infof("Rotated certificate %s, reloaded by %s", name, hotReloadedCerts[name])
+	}
+	return false, nil
+}
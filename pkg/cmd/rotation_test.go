@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/microshift/pkg/util/cryptomaterial/certchains"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+func Test_rotateDueCerts(t *testing.T) {
+	// The serving certificate is due for rotation right away, being valid for
+	// less than 4 months.
+	mkChains := func(t *testing.T, clientValidityDays int) *certchains.CertificateChains {
+		tmpDir := t.TempDir()
+		cs, err := certchains.NewCertificateChains(
+			certchains.NewCertificateSigner("kube-apiserver-localhost-signer", filepath.Join(tmpDir, "localhost"), 3650).
+				WithServingCertificates(&certchains.ServingCertificateSigningRequestInfo{
+					CSRMeta:   certchains.CSRMeta{Name: "kube-apiserver-localhost-serving", ValidityDays: 10},
+					Hostnames: []string{"localhost"},
+				}),
+			certchains.NewCertificateSigner("kube-control-plane-signer", filepath.Join(tmpDir, "control-plane"), 3650).
+				WithClientCertificates(&certchains.ClientCertificateSigningRequestInfo{
+					CSRMeta:  certchains.CSRMeta{Name: "kube-scheduler", ValidityDays: clientValidityDays},
+					UserInfo: &user.DefaultInfo{Name: "system:kube-scheduler"},
+				}),
+		).Complete()
+		require.NoError(t, err)
+		return cs
+	}
+	serial := func(t *testing.T, cs *certchains.CertificateChains, certPath ...string) string {
+		certPEM, _, err := cs.GetCertKey(certPath...)
+		require.NoError(t, err)
+		certs, err := crypto.CertsFromPEM(certPEM)
+		require.NoError(t, err)
+		return certs[0].SerialNumber.String()
+	}
+
+	t.Run("hot-reloaded certificates are regenerated", func(t *testing.T) {
+		cs := mkChains(t, 3650)
+		before := serial(t, cs, "kube-apiserver-localhost-signer", "kube-apiserver-localhost-serving")
+
+		restart, err := rotateDueCerts(cs, time.Now(), nil)
+		require.NoError(t, err)
+		assert.False(t, restart)
+		assert.NotEqual(t, before, serial(t, cs, "kube-apiserver-localhost-signer", "kube-apiserver-localhost-serving"))
+	})
+
+	t.Run("other certificates need a restart", func(t *testing.T) {
+		cs := mkChains(t, 10)
+		before := serial(t, cs, "kube-apiserver-localhost-signer", "kube-apiserver-localhost-serving")
+
+		restart, err := rotateDueCerts(cs, time.Now(), nil)
+		require.NoError(t, err)
+		assert.True(t, restart)
+		// They are all regenerated on start instead.
+		assert.Equal(t, before, serial(t, cs, "kube-apiserver-localhost-signer", "kube-apiserver-localhost-serving"))
+	})
+}
+
+func Test_nextRotation(t *testing.T) {
+	tmpDir := t.TempDir()
+	cs, err := certchains.NewCertificateChains(
+		certchains.NewCertificateSigner("kube-apiserver-localhost-signer", filepath.Join(tmpDir, "localhost"), 3650).
+			WithServingCertificates(&certchains.ServingCertificateSigningRequestInfo{
+				CSRMeta:   certchains.CSRMeta{Name: "kube-apiserver-localhost-serving", ValidityDays: 10},
+				Hostnames: []string{"localhost"},
+			}),
+	).Complete()
+	require.NoError(t, err)
+
+	certPath, _, err := nextRotation(cs, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kube-apiserver-localhost-signer", "kube-apiserver-localhost-serving"}, certPath)
+
+	// The certificates that cannot be renewed are not waited for.
+	certPath, rotationDate, err := nextRotation(cs, sets.New("kube-apiserver-localhost-signer/kube-apiserver-localhost-serving"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kube-apiserver-localhost-signer"}, certPath)
+	assert.True(t, rotationDate.After(time.Now().Add(8*365*24*time.Hour)))
+}
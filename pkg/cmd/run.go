@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -12,21 +13,35 @@ import (
 	"time"
 
 	"github.com/coreos/go-systemd/daemon"
+	"github.com/openshift/microshift/pkg/admin/api"
 	"github.com/openshift/microshift/pkg/admin/data"
 	"github.com/openshift/microshift/pkg/admin/prerun"
+	"github.com/openshift/microshift/pkg/assets"
+	"github.com/openshift/microshift/pkg/boottimings"
 	"github.com/openshift/microshift/pkg/config"
 	"github.com/openshift/microshift/pkg/controllers"
+	"github.com/openshift/microshift/pkg/crio"
+	"github.com/openshift/microshift/pkg/diskpressure"
+	"github.com/openshift/microshift/pkg/egress"
+	"github.com/openshift/microshift/pkg/firewall"
+	"github.com/openshift/microshift/pkg/join"
 	"github.com/openshift/microshift/pkg/kustomize"
 	"github.com/openshift/microshift/pkg/loadbalancerservice"
+	"github.com/openshift/microshift/pkg/logforwarding"
+	"github.com/openshift/microshift/pkg/logging"
 	"github.com/openshift/microshift/pkg/mdns"
+	"github.com/openshift/microshift/pkg/metrics"
 	"github.com/openshift/microshift/pkg/node"
+	"github.com/openshift/microshift/pkg/registry"
 	"github.com/openshift/microshift/pkg/release"
 	"github.com/openshift/microshift/pkg/servicemanager"
 	"github.com/openshift/microshift/pkg/sysconfwatch"
+	"github.com/openshift/microshift/pkg/tracing"
 	"github.com/openshift/microshift/pkg/util"
-	"github.com/openshift/microshift/pkg/util/cryptomaterial/certchains"
 	"github.com/openshift/microshift/pkg/version"
+	"github.com/openshift/microshift/pkg/virtualip"
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/trace"
 
 	logsAPIV1 "k8s.io/component-base/logs/api/v1"
 	"k8s.io/klog/v2"
@@ -35,6 +50,10 @@ import (
 
 const (
 	gracefulShutdownTimeout = 15
+	// tracingShutdownTimeout bounds the export of the pending traces on stop.
+	tracingShutdownTimeout = 5 * time.Second
+	// saneClockTimeout bounds the wait for NTP to set the clock on start.
+	saneClockTimeout = 2 * time.Minute
 )
 
 var (
@@ -51,11 +70,30 @@ func NewRunMicroshiftCommand() *cobra.Command {
 	}
 
 	var multinode bool
+	var roles []string
+	var joinURL string
+	var joinToken string
+	var joinCACertHash string
+	var dryRun bool
+	var dryRunDir string
+	var force bool
+	var upgradeRehearsal bool
+	var upgradeRehearsalDir string
+	var upgradeRehearsalData string
 
 	flags := cmd.Flags()
-	flags.BoolVar(&multinode, "multinode", false, "enable multinode mode")
-	err := flags.MarkHidden("multinode")
-	if err != nil {
+	flags.BoolVar(&multinode, "multinode", false, "Serve the join API for nodes to join this control plane with --roles="+config.RoleNode)
+	flags.StringSliceVar(&roles, "roles", []string{config.RoleControlPlane, config.RoleNode}, "Roles of the node. With only the "+config.RoleNode+" role, the kubelet joins the control plane at --join-url")
+	flags.StringVar(&joinURL, "join-url", "", "URL of the join API of the control plane, e.g. https://192.168.1.10:7443")
+	flags.StringVar(&joinToken, "join-token", "", "Token of the join API, created with microshift join-token create on the control plane")
+	flags.StringVar(&joinCACertHash, "join-ca-cert-hash", "", "Hash of the CA of the join API to pin, in the format sha256:<hex>, printed by microshift join-token create")
+	flags.BoolVar(&dryRun, "dry-run", false, "Generate certificates, kubeconfigs and component configuration files and render the manifests without starting any service")
+	flags.StringVar(&dryRunDir, "dry-run-dir", "", "Directory to keep the files generated by --dry-run in. A temporary directory is used and removed if not set")
+	flags.BoolVar(&force, "force", false, "Start even if the existing data was last used by a newer or unsupported version of MicroShift. This may corrupt the data")
+	flags.BoolVar(&upgradeRehearsal, "upgrade-rehearsal", false, "Start the control plane of this executable on a copy-on-write clone of the data, migrate the storage of every resource and check the health of the API server, without changing the data. MicroShift must be stopped")
+	flags.StringVar(&upgradeRehearsalDir, "upgrade-rehearsal-dir", "", "Directory to keep the clone of the data and the logs of --upgrade-rehearsal in. "+defaultUpgradeRehearsalDir+" is used and removed if not set")
+	flags.StringVar(&upgradeRehearsalData, upgradeRehearsalDataFlag, "", "")
+	if err := flags.MarkHidden(upgradeRehearsalDataFlag); err != nil {
 		panic(err)
 	}
 
@@ -77,18 +115,51 @@ func NewRunMicroshiftCommand() *cobra.Command {
 		}
 
 		cfg = config.ConfigMultiNode(cfg, multinode)
+		cfg, err = config.ConfigWorkerNode(cfg, roles, joinURL, joinToken, joinCACertHash)
+		if err != nil {
+			return err
+		}
+		if multinode && cfg.MultiNode.Worker {
+			return fmt.Errorf("--multinode requires the %q role", config.RoleControlPlane)
+		}
 
 		for _, w := range cfg.Warnings {
 			klog.Warningf("Configuration warning: %s", w)
 		}
 
+		if dryRun {
+			return RunMicroshiftDryRun(cfg, dryRunDir)
+		}
+		if dryRunDir != "" {
+			return fmt.Errorf("--dry-run-dir requires --dry-run")
+		}
+		if upgradeRehearsalData != "" {
+			return runUpgradeRehearsalInNamespace(cfg, upgradeRehearsalData)
+		}
+		if upgradeRehearsal {
+			return RunMicroshiftUpgradeRehearsal(cfg, upgradeRehearsalDir)
+		}
+		if upgradeRehearsalDir != "" {
+			return fmt.Errorf("--upgrade-rehearsal-dir requires --upgrade-rehearsal")
+		}
+
+		if cfg.MultiNode.Worker {
+			return RunMicroshiftWorker(cfg)
+		}
+		if cfg.Etcd.IsStandby() {
+			if cfg.MultiNode.Enabled {
+				return fmt.Errorf("--multinode can not be used on an etcd standby node")
+			}
+			return RunMicroshiftStandby(cfg)
+		}
+
 		// Things to very badly if the node's name has changed
 		// since the last time the server started.
 		err = cfg.EnsureNodeNameHasNotChanged()
 		if err != nil {
 			return err
 		}
-		return RunMicroshift(cfg)
+		return RunMicroshift(cfg, force)
 	}
 
 	return cmd
@@ -119,16 +190,16 @@ func logConfig(cfg *config.Config) {
 	}
 }
 
-func prerunDataManagement() error {
+func prerunDataManagement(cfg *config.Config) error {
 	dataManager, err := data.NewManager(config.BackupsDir)
 	if err != nil {
 		return fmt.Errorf("failed to create data manager: %w", err)
 	}
 
-	return prerun.DataManagement(dataManager)
+	return prerun.DataManagement(dataManager, cfg.Upgrade.RestoreOnRollback == config.RestoreOnRollbackStatusEnabled)
 }
 
-func RunMicroshift(cfg *config.Config) error {
+func RunMicroshift(cfg *config.Config, force bool) error {
 	// fail early if we don't have enough privileges
 	if os.Geteuid() > 0 {
 		klog.Fatalf("MicroShift must be run privileged")
@@ -136,6 +207,7 @@ func RunMicroshift(cfg *config.Config) error {
 
 	klog.InfoS("MICROSHIFT STARTING")
 	microshiftStart := time.Now()
+	boottimings.Start(microshiftStart)
 
 	// Tell the logging code that it's OK to receive reconfiguration
 	// instructions unless those instructions are different. This
@@ -146,16 +218,32 @@ func RunMicroshift(cfg *config.Config) error {
 	// the same process, as they are in MicroShift. See comments in
 	// k8s.io/component-base/logs/api/v1/options.go for details.
 	logsAPIV1.ReapplyHandling = logsAPIV1.ReapplyHandlingIgnoreUnchanged
+	if err := logging.Apply(cfg); err != nil {
+		return err
+	}
 
 	cleanUpPreviousLogFiles()
 
-	if err := prerunDataManagement(); err != nil {
+	if err := prerunDataManagement(cfg); err != nil {
 		writeLogFileError(preRunFailedLogPath, err)
 		return err
 	}
 
 	logConfig(cfg)
 
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		shutdownTracing(ctx)
+	}()
+	// The span of the start of MicroShift is the parent of the spans of the
+	// certificates and of the start of each service.
+	startCtx, startSpan := tracing.Tracer().Start(context.Background(), "startup", trace.WithTimestamp(microshiftStart))
+
 	// TO-DO: When multi-node is ready, we need to add the controller host-name/mDNS hostname
 	//        or VIP to this list on start
 	//        see https://github.com/openshift/microshift/pull/471
@@ -174,14 +262,27 @@ func RunMicroshift(cfg *config.Config) error {
 	if err := util.MakeDir(config.DataDir); err != nil {
 		return fmt.Errorf("failed to create dir %q: %w", config.DataDir, err)
 	}
+	if err := metrics.RestoreConfigReloads(); err != nil {
+		klog.Warningf("Failed to restore the number of config reloads: %v", err)
+	}
 
-	if err := prerun.VersionMetadataManagement(); err != nil {
+	if err := prerun.VersionMetadataManagement(force); err != nil {
 		writeLogFileError(preRunFailedLogPath, err)
 		return err
 	}
 
+	// Devices without a real-time clock boot at the epoch until NTP sets the
+	// clock, and the certificates generated meanwhile would be unusable.
+	if err := sysconfwatch.WaitForSaneClock(context.Background(), sysconfwatch.MinimumSaneTime(), saneClockTimeout); err != nil {
+		klog.Warningf("Generating certificates with an unsynchronized clock, they are regenerated once it is set: %v", err)
+	}
+
+	metrics.SetStartupPhaseDuration(metrics.PhaseInitialization, time.Since(microshiftStart))
+	boottimings.SetPhase(metrics.PhaseInitialization, time.Since(microshiftStart))
+	certsStart := time.Now()
+
 	// TODO: change to only initialize what is strictly necessary for the selected role(s)
-	certChains, err := initCerts(cfg)
+	certChains, err := initCerts(startCtx, cfg, externalCA)
 	if err != nil {
 		klog.Fatalf("failed to retrieve the necessary certificates: %v", err)
 	}
@@ -191,10 +292,29 @@ func RunMicroshift(cfg *config.Config) error {
 		klog.Fatalf("failed to create the necessary kubeconfigs for internal components: %v", err)
 	}
 
+	if err := assets.LoadOverrides(config.OverridesDir); err != nil {
+		return fmt.Errorf("failed to load the overrides of the component manifests: %w", err)
+	}
+	if err := crio.ReconcileImagesConfig(startCtx, cfg); err != nil {
+		return err
+	}
+	if err := crio.ReconcileRuntimeConfig(startCtx, cfg); err != nil {
+		return err
+	}
+	if err := crio.ReconcileCNIConfig(startCtx, cfg); err != nil {
+		return err
+	}
+	metrics.SetStartupPhaseDuration(metrics.PhaseCertificates, time.Since(certsStart))
+	boottimings.SetPhase(metrics.PhaseCertificates, time.Since(certsStart))
+
 	// Establish the context we will use to control execution
 	runCtx, runCancel := context.WithCancel(context.Background())
 
 	m := servicemanager.NewServiceManager()
+	util.Must(m.AddService(crio.NewService()))
+	if cfg.Firewall.Status == config.FirewallStatusEnabled {
+		util.Must(m.AddService(firewall.NewReconciler(cfg)))
+	}
 	util.Must(m.AddService(node.NewNetworkConfiguration(cfg)))
 	util.Must(m.AddService(controllers.NewEtcd(cfg)))
 	util.Must(m.AddService(sysconfwatch.NewSysConfWatchController(cfg)))
@@ -204,63 +324,144 @@ func RunMicroshift(cfg *config.Config) error {
 	util.Must(m.AddService(controllers.NewOpenShiftCRDManager(cfg)))
 	util.Must(m.AddService(controllers.NewRouteControllerManager(cfg)))
 	util.Must(m.AddService(controllers.NewOpenShiftDefaultSCCManager(cfg)))
-	util.Must(m.AddService(mdns.NewMicroShiftmDNSController(cfg)))
+	if cfg.VirtualIP.Status == config.VirtualIPStatusEnabled {
+		util.Must(m.AddService(virtualip.NewManager(cfg)))
+	}
 	util.Must(m.AddService(controllers.NewInfrastructureServices(cfg)))
 	util.Must(m.AddService(controllers.NewClusterPolicyController(cfg)))
-	util.Must(m.AddService(controllers.NewVersionManager(cfg)))
-	util.Must(m.AddService(kustomize.NewKustomizer(cfg)))
+	if cfg.Images.Preload != "" {
+		util.Must(m.AddService(crio.NewImagePreloader(cfg)))
+	}
+	if len(cfg.Images.GarbageCollection.ProtectedLabels) != 0 {
+		util.Must(m.AddService(crio.NewImageProtector(cfg)))
+	}
+	util.Must(m.AddService(crio.NewRuntimeClassManager(cfg)))
+	if cfg.Images.LocalRegistry.Status == config.LocalRegistryStatusEnabled {
+		util.Must(m.AddService(registry.NewServer(cfg)))
+	}
 	util.Must(m.AddService(node.NewKubeletServer(cfg)))
-	util.Must(m.AddService(loadbalancerservice.NewLoadbalancerServiceController(cfg)))
+	util.Must(m.AddService(node.NewCertificatesCondition(cfg, certChains)))
+	if len(cfg.Certificates.CSRApproval.Rules) != 0 {
+		util.Must(m.AddService(controllers.NewCSRApprover(cfg)))
+	}
+	if cfg.MultiNode.Enabled {
+		util.Must(m.AddService(join.NewServer(cfg)))
+	}
+	if len(cfg.Network.Egress.Addresses) != 0 {
+		util.Must(m.AddService(egress.NewController(cfg)))
+	}
 	util.Must(m.AddService(controllers.NewKubeStorageVersionMigrator(cfg)))
+	util.Must(m.AddService(controllers.NewEncryptionMigrator(cfg)))
+	util.Must(m.AddService(controllers.NewServiceAccountIssuerPublisher(cfg)))
 	util.Must(m.AddService(controllers.NewClusterID(cfg)))
+	if cfg.Logging.Events.Status == config.EventsStatusEnabled {
+		util.Must(m.AddService(controllers.NewEventJournal(cfg)))
+	}
+	if cfg.Logging.Forwarding.Status == config.ForwardingStatusEnabled {
+		util.Must(m.AddService(logforwarding.NewLogForwarder(cfg)))
+	}
+	// The deferred services are only started once the other ones are ready,
+	// MicroShift being ready without them.
+	if cfg.MDNS.Status == config.MDNSStatusEnabled {
+		util.Must(m.AddService(mdns.NewMicroShiftmDNSController(cfg)))
+	}
+	util.Must(m.AddService(controllers.NewVersionManager(cfg)))
+	if cfg.DiskMaintenance.IsEnabled() {
+		util.Must(m.AddService(diskpressure.NewMaintainer(cfg)))
+	}
+	util.Must(m.AddService(kustomize.NewKustomizer(cfg)))
+	util.Must(m.AddService(loadbalancerservice.NewLoadbalancerServiceController(cfg)))
+	// Services compiled in by downstream distributions, see servicemanager.Register.
+	if err := servicemanager.DefaultRegistry.AddServices(runCtx, cfg, m); err != nil {
+		klog.Fatalf("failed to add registered services: %v", err)
+	}
+
+	serviceTimeouts := make(map[string]time.Duration, len(cfg.Startup.ServiceTimeoutSeconds))
+	for name, timeout := range cfg.Startup.ServiceTimeoutSeconds {
+		serviceTimeouts[name] = time.Duration(timeout) * time.Second
+	}
+	m.SetReadinessTimeouts(serviceTimeouts)
+
+	go func() {
+		if err := m.ServeHealth(runCtx, servicemanager.HealthEndpointAddress); err != nil {
+			klog.Errorf("Failed to serve services health: %v", err)
+		}
+	}()
 
 	// Storing and clearing the env, so other components don't send the READY=1 until MicroShift is fully ready
 	notifySocket := os.Getenv("NOTIFY_SOCKET")
 	os.Unsetenv("NOTIFY_SOCKET")
 
-	klog.InfoS("MICROSHIFT STARTING SERVICES", "since-start", time.Since(microshiftStart))
-
-	_, rotationDate, err := certchains.WhenToRotateAtEarliest(certChains)
-	if err != nil {
-		klog.Fatalf("failed to determine when to rotate certificates: %v", err)
+	if cfg.Metrics.ListenAddress != "" {
+		go func() {
+			if err := metrics.NewServer(cfg, m, certChains).Serve(runCtx); err != nil {
+				klog.Errorf("Failed to serve metrics: %v", err)
+			}
+		}()
 	}
 
-	// Establish a deadline for restarting to rotate the certificates.
-	certCtx, certCancel := context.WithDeadline(context.Background(), rotationDate)
+	klog.InfoS("MICROSHIFT STARTING SERVICES", "since-start", time.Since(microshiftStart))
+	servicesStart := time.Now()
 
-	// Watch for the certificate deadline context to be done, log a
-	// message, and cancel the run context to propagate the shutdown.
-	go func() {
-		select {
-		case <-certCtx.Done():
-			klog.Info("Stopping services for certificate rotation")
-			runCancel()
-			return
-		case <-runCtx.Done():
-			klog.Info("Certificate watcher exiting")
-			certCancel()
-			return
-		}
-	}()
+	// Rotate the certificates before they expire, stopping the services for
+	// MicroShift to be restarted when they are not reloaded by their consumers.
+	go rotateCertificates(runCtx, certChains, func() {
+		klog.Info("Stopping services for certificate rotation")
+		runCancel()
+	})
 
 	// Start everything up
 	ready, stopped := make(chan struct{}), make(chan struct{})
 	go func() {
 		klog.Infof("Started %s", m.Name())
-		if err := m.Run(runCtx, ready, stopped); err != nil {
+		if err := m.Run(trace.ContextWithSpan(runCtx, startSpan), ready, stopped); err != nil {
 			klog.Errorf("Stopped %s: %v", m.Name(), err)
 		} else {
 			klog.Infof("%s completed", m.Name())
 		}
 	}()
 
+	adminAPI := api.NewServer(cfg, m, certChains, ready, api.Actions{
+		Reload: func() {
+			klog.Info("Stopping services for reload")
+			if err := metrics.RecordConfigReload(); err != nil {
+				klog.Warningf("Failed to count the config reload: %v", err)
+			}
+			runCancel()
+		},
+	})
+	go func() {
+		if err := adminAPI.Serve(runCtx); err != nil {
+			klog.Errorf("Failed to serve admin API: %v", err)
+		}
+	}()
+	if cfg.FleetAPI.Status == config.FleetAPIStatusEnabled {
+		fleetAPI := api.NewFleetServer(adminAPI)
+		go func() {
+			if err := fleetAPI.Serve(runCtx); err != nil {
+				klog.Errorf("Failed to serve fleet API: %v", err)
+			}
+		}()
+	}
+
 	// Connect signal handler
 	sigTerm := make(chan os.Signal, 1)
 	signal.Notify(sigTerm, os.Interrupt, syscall.SIGTERM)
 
+	// A nil channel never fires when the startup timeout is disabled.
+	var startupTimeout <-chan time.Time
+	if timeout := *cfg.Startup.TimeoutSeconds; timeout > 0 {
+		startupTimeout = time.After(time.Duration(timeout) * time.Second)
+	}
+	var startupErr error
+
 	select {
 	case <-ready:
 		klog.InfoS("MICROSHIFT READY", "since-start", time.Since(microshiftStart))
+		metrics.SetStartupPhaseDuration(metrics.PhaseServices, time.Since(servicesStart))
+		boottimings.SetPhase(metrics.PhaseServices, time.Since(servicesStart))
+		boottimings.SetMilestone(boottimings.MilestoneReady, time.Now())
+		startSpan.End()
 		os.Setenv("NOTIFY_SOCKET", notifySocket)
 		if supported, err := daemon.SdNotify(false, daemon.SdNotifyReady); err != nil {
 			klog.Warningf("error sending sd_notify readiness message: %v", err)
@@ -270,15 +471,79 @@ func RunMicroshift(cfg *config.Config) error {
 			klog.Info("service does not support sd_notify readiness messages")
 		}
 
-		// Watch for SIGTERM to exit, now that we are ready.
-		<-sigTerm
-		klog.Info("Interrupt received")
+		// Make the node schedulable again if it was drained on the last shutdown.
+		go func() {
+			if err := node.UncordonNode(runCtx, cfg); err != nil && !errors.Is(err, context.Canceled) {
+				klog.Errorf("Failed to uncordon node: %v", err)
+			}
+		}()
+
+		// Remove the nodes registered under the previous host names.
+		go func() {
+			if err := node.RemovePreviousNodes(runCtx, cfg); err != nil && !errors.Is(err, context.Canceled) {
+				klog.Errorf("Failed to remove previous nodes: %v", err)
+			}
+		}()
+
+		// Delay host shutdowns until the workloads are stopped. A nil
+		// channel never fires when graceful shutdown is unavailable.
+		var hostShutdown <-chan struct{}
+		var inhibitor *node.ShutdownInhibitor
+		if *cfg.Node.GracefulShutdown.GracePeriodSeconds > 0 {
+			inhibitor, err = node.InhibitShutdown()
+			if err != nil {
+				klog.Warningf("Host shutdowns will not wait for workloads to stop: %v", err)
+			} else {
+				defer inhibitor.Release()
+				hostShutdown = inhibitor.ShutdownRequested()
+			}
+		}
+
+		// Watch for SIGTERM to exit, now that we are ready, or for
+		// stopping on our own for certificate rotation or a reload.
+		select {
+		case <-hostShutdown:
+			klog.Info("Host shutdown requested")
+
+			// The kubelet terminates the pods of the node by priority
+			// meanwhile. Systemd stops MicroShift once the lock is released.
+			gracePeriod := time.Duration(*cfg.Node.GracefulShutdown.GracePeriodSeconds) * time.Second
+			drainCtx, drainCancel := context.WithTimeout(context.Background(), gracePeriod)
+			if err := node.DrainNode(drainCtx, cfg); err != nil {
+				klog.Errorf("Failed to drain node, continuing shutdown: %v", err)
+			}
+			drainCancel()
+			inhibitor.Release()
+
+			select {
+			case <-sigTerm:
+				klog.Info("Interrupt received")
+			case <-runCtx.Done():
+			}
+		case <-sigTerm:
+			klog.Info("Interrupt received")
+
+			// Drain the node while the kubelet and the control plane are still
+			// running, so workloads are stopped gracefully before MicroShift is.
+			if err := node.DrainNode(context.Background(), cfg); err != nil {
+				klog.Errorf("Failed to drain node, continuing shutdown: %v", err)
+			}
+		case <-runCtx.Done():
+		}
 	case <-sigTerm:
 		// A signal that comes in before we are ready is handled here.
 		klog.Info("Interrupt received")
+		m.ReportNotReady()
+		tracing.EndSpan(startSpan, fmt.Errorf("interrupted before being ready"))
+	case <-startupTimeout:
+		startupErr = fmt.Errorf("MicroShift was not ready after %d seconds", *cfg.Startup.TimeoutSeconds)
+		klog.ErrorS(startupErr, "MICROSHIFT READINESS TIMEOUT")
+		m.ReportNotReady()
+		tracing.EndSpan(startSpan, startupErr)
 	case <-runCtx.Done():
 		// We might end up here if the certificate rotation is
 		// triggered and we exit on our own, instead of via a signal.
+		tracing.EndSpan(startSpan, fmt.Errorf("stopped before being ready"))
 	}
 	klog.Info("MICROSHIFT STOPPING")
 	microshiftStop := time.Now()
@@ -290,5 +555,5 @@ func RunMicroshift(cfg *config.Config) error {
 		klog.InfoS("MICROSHIFT STOP TIMED OUT", "since-stop", time.Since(microshiftStop))
 	}
 	klog.InfoS("MICROSHIFT STOPPED", "since-stop", time.Since(microshiftStop))
-	return nil
+	return startupErr
 }
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openshift/microshift/pkg/admin/prerun"
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/controllers"
+	"github.com/openshift/microshift/pkg/firewall"
+	"github.com/openshift/microshift/pkg/logging"
+	"github.com/openshift/microshift/pkg/servicemanager"
+	"github.com/openshift/microshift/pkg/util"
+
+	logsAPIV1 "k8s.io/component-base/logs/api/v1"
+	"k8s.io/klog/v2"
+)
+
+// RunMicroshiftStandby runs only the etcd learner of a standby node, which
+// replicates the database of the primary until promoted with
+// 'microshift etcd promote'.
+func RunMicroshiftStandby(cfg *config.Config) error {
+	if err := shouldRunPrivileged(); err != nil {
+		return err
+	}
+
+	klog.InfoS("MICROSHIFT STANDBY STARTING", "primary", cfg.Etcd.Standby.Peer)
+	microshiftStart := time.Now()
+
+	logsAPIV1.ReapplyHandling = logsAPIV1.ReapplyHandlingIgnoreUnchanged
+	if err := logging.Apply(cfg); err != nil {
+		return err
+	}
+
+	if err := util.MakeDir(config.DataDir); err != nil {
+		return fmt.Errorf("failed to create dir %q: %w", config.DataDir, err)
+	}
+	// The version of the data is recorded for the checks on the start of
+	// the promoted node.
+	if err := prerun.VersionMetadataManagement(false); err != nil {
+		return err
+	}
+	// The peer certificates of etcd are signed by the etcd signer copied
+	// from the primary, the other certificates are ready for a promotion.
+	if _, err := initCerts(context.Background(), cfg, externalCA); err != nil {
+		return err
+	}
+	logConfig(cfg)
+
+	m := servicemanager.NewServiceManager()
+	if cfg.Firewall.Status == config.FirewallStatusEnabled {
+		util.Must(m.AddService(firewall.NewReconciler(cfg)))
+	}
+	util.Must(m.AddService(controllers.NewEtcd(cfg)))
+	return runNodeServices("STANDBY", microshiftStart, m)
+}
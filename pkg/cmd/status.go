@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openshift/microshift/pkg/admin/api"
+	"github.com/openshift/microshift/pkg/boottimings"
+	"github.com/openshift/microshift/pkg/servicemanager"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/yaml"
+)
+
+const statusTimeout = 10 * time.Second
+
+func NewStatusCommand(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	bootTimings := false
+	output := ""
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Print the state of the MicroShift services",
+		Long: `Print the state of the MicroShift services.
+
+With --boot-timings, print how long the last start of MicroShift took
+instead: the duration of its phases, when it became ready and applied the
+manifests, and when each service started and became ready, in seconds since
+the start. The timings are kept in the data directory, they can be printed
+while MicroShift is stopped.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(shouldRunPrivileged())
+			if bootTimings {
+				report, err := boottimings.Load()
+				cmdutil.CheckErr(err)
+				cmdutil.CheckErr(printStatus(ioStreams, output, report, func() error {
+					return printBootTimings(ioStreams, report)
+				}))
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), statusTimeout)
+			defer cancel()
+			var statuses []servicemanager.ServiceStatus
+			cmdutil.CheckErr(api.Get(ctx, "/v1/services", &statuses))
+			cmdutil.CheckErr(printStatus(ioStreams, output, statuses, func() error {
+				return printServices(ioStreams, statuses)
+			}))
+		},
+	}
+
+	cmd.Flags().BoolVar(&bootTimings, "boot-timings", bootTimings, "Print the timings of the last start of MicroShift.")
+	cmd.Flags().StringVarP(&output, "output", "o", output, "One of 'yaml' or 'json'.")
+
+	return cmd
+}
+
+// printStatus prints v as YAML or JSON, or as a table with printTable.
+func printStatus(ioStreams genericclioptions.IOStreams, output string, v any, printTable func() error) error {
+	switch output {
+	case "":
+		return printTable()
+	case "yaml":
+		marshalled, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ioStreams.Out, string(marshalled))
+	case "json":
+		marshalled, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ioStreams.Out, string(marshalled))
+	default:
+		return fmt.Errorf("unsupported --output=%q, must be one of 'yaml' or 'json'", output)
+	}
+	return nil
+}
+
+func printServices(ioStreams genericclioptions.IOStreams, statuses []servicemanager.ServiceStatus) error {
+	w := tabwriter.NewWriter(ioStreams.Out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tSTATE\tSINCE\tERROR")
+	for _, s := range statuses {
+		since := ""
+		if !s.Since.IsZero() {
+			since = time.Since(s.Since).Round(time.Second).String()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Name, s.State, since, s.Error)
+	}
+	return w.Flush()
+}
+
+func printBootTimings(ioStreams genericclioptions.IOStreams, report *boottimings.Report) error {
+	fmt.Fprintf(ioStreams.Out, "Started at %s\n", report.Start.Format(time.RFC3339))
+
+	tables := []struct {
+		header string
+		rows   []string
+	}{
+		{header: "PHASE\tDURATION"},
+		{header: "MILESTONE\tAFTER"},
+		{header: "SERVICE\tSTARTED\tREADY\tDURATION"},
+	}
+	for _, p := range report.Phases {
+		tables[0].rows = append(tables[0].rows, fmt.Sprintf("%s\t%.3fs", p.Name, p.Seconds))
+	}
+	for _, m := range report.Milestones {
+		tables[1].rows = append(tables[1].rows, fmt.Sprintf("%s\t%.3fs", m.Name, m.Seconds))
+	}
+	for _, s := range report.Services {
+		tables[2].rows = append(tables[2].rows, fmt.Sprintf("%s\t%.3fs\t%.3fs\t%.3fs", s.Name, s.Started, s.Ready, s.Ready-s.Started))
+	}
+
+	for _, table := range tables {
+		fmt.Fprintln(ioStreams.Out)
+		w := tabwriter.NewWriter(ioStreams.Out, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, table.header)
+		for _, row := range table.rows {
+			fmt.Fprintln(w, row)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,18 +3,41 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
 
 	"github.com/openshift/microshift/pkg/release"
 	"github.com/openshift/microshift/pkg/version"
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/yaml"
 
+	apimachineryversion "k8s.io/apimachinery/pkg/version"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	kubeversion "k8s.io/component-base/version"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
+// VersionReport is the inventory of MicroShift and of the components it
+// embeds or deploys, intended for compliance tracking by fleet managers.
+type VersionReport struct {
+	MicroShift version.Info             `json:"microshift"`
+	BaseOCP    string                   `json:"baseOCP"`
+	Kubernetes apimachineryversion.Info `json:"kubernetes"`
+	// Etcd is the version of the etcd embedded in microshift-etcd, or
+	// empty if the microshift-etcd binary could not be queried.
+	Etcd string `json:"etcd,omitempty"`
+	// OVNKubernetes is the image of the OVN-Kubernetes CNI.
+	OVNKubernetes string `json:"ovnKubernetes,omitempty"`
+	// Images are the images of all the components deployed by MicroShift,
+	// keyed by component name.
+	Images map[string]string `json:"images"`
+}
+
 type VersionOptions struct {
-	Output string
+	Output     string
+	Components bool
 
 	genericclioptions.IOStreams
 }
@@ -38,11 +61,16 @@ func NewVersionCommand(ioStreams genericclioptions.IOStreams) *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&o.Output, "output", "o", o.Output, "One of 'yaml' or 'json'.")
+	cmd.Flags().BoolVar(&o.Components, "components", o.Components, "Include the versions of the embedded components and the images of the deployed components.")
 
 	return cmd
 }
 
 func (o *VersionOptions) Run() error {
+	if o.Components {
+		return o.runComponents()
+	}
+
 	versionInfo := version.Get()
 
 	switch o.Output {
@@ -69,3 +97,75 @@ func (o *VersionOptions) Run() error {
 
 	return nil
 }
+
+func (o *VersionOptions) runComponents() error {
+	report := NewVersionReport()
+
+	switch o.Output {
+	case "":
+		fmt.Fprintf(o.Out, "MicroShift Version: %s\n", report.MicroShift.String())
+		fmt.Fprintf(o.Out, "MicroShift Git Commit: %s\n", report.MicroShift.GitCommit)
+		fmt.Fprintf(o.Out, "MicroShift Build Date: %s\n", report.MicroShift.BuildDate)
+		fmt.Fprintf(o.Out, "Base OCP Version: %s\n", report.BaseOCP)
+		fmt.Fprintf(o.Out, "Kubernetes Version: %s\n", report.Kubernetes.String())
+		fmt.Fprintf(o.Out, "Etcd Version: %s\n", report.Etcd)
+		fmt.Fprintf(o.Out, "OVN-Kubernetes Image: %s\n", report.OVNKubernetes)
+		fmt.Fprintln(o.Out, "Images:")
+		names := make([]string, 0, len(report.Images))
+		for name := range report.Images {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(o.Out, "  %s: %s\n", name, report.Images[name])
+		}
+	case "yaml":
+		marshalled, err := yaml.Marshal(&report)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(o.Out, string(marshalled))
+	case "json":
+		marshalled, err := json.MarshalIndent(&report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(o.Out, string(marshalled))
+	default:
+		return fmt.Errorf("VersionOptions were not validated: --output=%q should have been rejected", o.Output)
+	}
+
+	return nil
+}
+
+// NewVersionReport collects the versions of MicroShift and its components.
+func NewVersionReport() VersionReport {
+	return VersionReport{
+		MicroShift:    version.Get(),
+		BaseOCP:       release.Base,
+		Kubernetes:    kubeversion.Get(),
+		Etcd:          etcdVersion(),
+		OVNKubernetes: release.Image["ovn_kubernetes_microshift"],
+		Images:        release.Image,
+	}
+}
+
+// etcdVersion asks the microshift-etcd binary installed next to the
+// microshift binary for the version of etcd it embeds.
+func etcdVersion() string {
+	microshiftExecPath, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	out, err := exec.Command(filepath.Join(filepath.Dir(microshiftExecPath), "microshift-etcd"), "version", "-o", "json").Output()
+	if err != nil {
+		return ""
+	}
+	info := struct {
+		EtcdVersion string `json:"etcdVersion"`
+	}{}
+	if err := json.Unmarshal(out, &info); err != nil {
+		return ""
+	}
+	return info.EtcdVersion
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/coreos/go-systemd/daemon"
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/crio"
+	"github.com/openshift/microshift/pkg/firewall"
+	"github.com/openshift/microshift/pkg/join"
+	"github.com/openshift/microshift/pkg/logging"
+	"github.com/openshift/microshift/pkg/node"
+	"github.com/openshift/microshift/pkg/registry"
+	"github.com/openshift/microshift/pkg/servicemanager"
+	"github.com/openshift/microshift/pkg/util"
+
+	logsAPIV1 "k8s.io/component-base/logs/api/v1"
+	"k8s.io/klog/v2"
+)
+
+// RunMicroshiftWorker runs the kubelet of a node with the node role only,
+// joining the control plane first when the node did not join it yet.
+func RunMicroshiftWorker(cfg *config.Config) error {
+	if err := shouldRunPrivileged(); err != nil {
+		return err
+	}
+
+	klog.InfoS("MICROSHIFT WORKER STARTING")
+	microshiftStart := time.Now()
+
+	logsAPIV1.ReapplyHandling = logsAPIV1.ReapplyHandlingIgnoreUnchanged
+	if err := logging.Apply(cfg); err != nil {
+		return err
+	}
+
+	if err := util.MakeDir(config.DataDir); err != nil {
+		return fmt.Errorf("failed to create dir %q: %w", config.DataDir, err)
+	}
+
+	joined, err := join.Joined()
+	if err != nil {
+		return err
+	}
+	if !joined {
+		if cfg.MultiNode.JoinURL == "" || cfg.MultiNode.JoinToken == "" || cfg.MultiNode.JoinCACertHash == "" {
+			return fmt.Errorf("--join-url, --join-token and --join-ca-cert-hash are required to join the control plane")
+		}
+		if err := join.Join(context.Background(), cfg, cfg.MultiNode.JoinURL, cfg.MultiNode.JoinToken, cfg.MultiNode.JoinCACertHash); err != nil {
+			return err
+		}
+	}
+	cluster, err := join.LoadCluster()
+	if err != nil {
+		return err
+	}
+	cluster.Apply(cfg)
+	logConfig(cfg)
+	// The nodes of the bridge CNI would allocate the pod IPs from the same
+	// cluster network, and do not route to the pods of the other nodes.
+	if cfg.Network.CNIPlugin == config.CniPluginBridge {
+		return fmt.Errorf("the bridge CNI plugin does not support worker nodes")
+	}
+	if err := crio.ReconcileImagesConfig(context.Background(), cfg); err != nil {
+		return err
+	}
+	if err := crio.ReconcileRuntimeConfig(context.Background(), cfg); err != nil {
+		return err
+	}
+	if err := crio.ReconcileCNIConfig(context.Background(), cfg); err != nil {
+		return err
+	}
+
+	m := servicemanager.NewServiceManager()
+	util.Must(m.AddService(crio.NewService()))
+	if cfg.Firewall.Status == config.FirewallStatusEnabled {
+		util.Must(m.AddService(firewall.NewReconciler(cfg)))
+	}
+	if cfg.Images.Preload != "" {
+		util.Must(m.AddService(crio.NewImagePreloader(cfg)))
+	}
+	if len(cfg.Images.GarbageCollection.ProtectedLabels) != 0 {
+		util.Must(m.AddService(crio.NewImageProtector(cfg)))
+	}
+	if cfg.Images.LocalRegistry.Status == config.LocalRegistryStatusEnabled {
+		util.Must(m.AddService(registry.NewServer(cfg)))
+	}
+	util.Must(m.AddService(node.NewKubeletServer(cfg)))
+	return runNodeServices("WORKER", microshiftStart, m)
+}
+
+// runNodeServices runs the services of m, for the nodes that do not run the
+// whole control plane, until MicroShift is stopped.
+func runNodeServices(role string, microshiftStart time.Time, m *servicemanager.ServiceManager) error {
+	runCtx, runCancel := context.WithCancel(context.Background())
+
+	// Storing and clearing the env, so the kubelet does not send the READY=1
+	// until MicroShift is ready.
+	notifySocket := os.Getenv("NOTIFY_SOCKET")
+	os.Unsetenv("NOTIFY_SOCKET")
+
+	ready, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		klog.Infof("Started %s", m.Name())
+		if err := m.Run(runCtx, ready, stopped); err != nil {
+			klog.Errorf("Stopped %s: %v", m.Name(), err)
+		} else {
+			klog.Infof("%s completed", m.Name())
+		}
+	}()
+
+	sigTerm := make(chan os.Signal, 1)
+	signal.Notify(sigTerm, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case <-ready:
+		klog.InfoS("MICROSHIFT "+role+" READY", "since-start", time.Since(microshiftStart))
+		os.Setenv("NOTIFY_SOCKET", notifySocket)
+		if supported, err := daemon.SdNotify(false, daemon.SdNotifyReady); err != nil {
+			klog.Warningf("error sending sd_notify readiness message: %v", err)
+		} else if supported {
+			klog.Info("sent sd_notify readiness message")
+		}
+
+		select {
+		case <-sigTerm:
+			klog.Info("Interrupt received")
+		case <-runCtx.Done():
+		}
+	case <-sigTerm:
+		klog.Info("Interrupt received")
+		m.ReportNotReady()
+	case <-runCtx.Done():
+	}
+	klog.Info("MICROSHIFT " + role + " STOPPING")
+	microshiftStop := time.Now()
+	runCancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Duration(gracefulShutdownTimeout) * time.Second):
+		klog.InfoS("MICROSHIFT "+role+" STOP TIMED OUT", "since-stop", time.Since(microshiftStop))
+	}
+	klog.InfoS("MICROSHIFT "+role+" STOPPED", "since-stop", time.Since(microshiftStop))
+	return nil
+}
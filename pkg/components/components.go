@@ -37,5 +37,20 @@ func StartComponents(cfg *config.Config, ctx context.Context) error {
 		klog.Warningf("Failed to start CNI plugin: %v", err)
 		return err
 	}
+
+	if err := startKubeProxy(ctx, cfg, kubeAdminConfig); err != nil {
+		klog.Warningf("Failed to start kube-proxy: %v", err)
+		return err
+	}
+
+	if err := startMultus(ctx, cfg, kubeAdminConfig); err != nil {
+		klog.Warningf("Failed to start Multus: %v", err)
+		return err
+	}
+
+	if err := startKubeStateMetrics(ctx, cfg, kubeAdminConfig); err != nil {
+		klog.Warningf("Failed to start kube-state-metrics: %v", err)
+		return err
+	}
 	return nil
 }
@@ -2,6 +2,8 @@ package components
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
 
 	"github.com/openshift/microshift/pkg/assets"
@@ -40,6 +42,22 @@ func startServiceCAController(ctx context.Context, cfg *config.Config, kubeconfi
 		cmName     = "signing-cabundle"
 	)
 
+	if cfg.Components.ServiceCA.Status == config.ServiceCAStatusRemoved {
+		if err := assets.DeleteClusterRoleBindings(ctx, clusterRoleBinding, kubeconfigPath); err != nil {
+			klog.Warningf("Failed to delete clusterRolebinding %v: %v", clusterRoleBinding, err)
+			return err
+		}
+		if err := assets.DeleteClusterRoles(ctx, clusterRole, kubeconfigPath); err != nil {
+			klog.Warningf("Failed to delete clusterRole %v: %v", clusterRole, err)
+			return err
+		}
+		if err := assets.DeleteNamespaces(ctx, ns, kubeconfigPath); err != nil {
+			klog.Warningf("Failed to delete ns %v: %v", ns, err)
+			return err
+		}
+		return nil
+	}
+
 	serviceCADir := cryptomaterial.ServiceCADir(cryptomaterial.CertsDirectory(config.DataDir))
 	caCertPath := cryptomaterial.CACertPath(serviceCADir)
 	caKeyPath := cryptomaterial.CAKeyPath(serviceCADir)
@@ -91,9 +109,13 @@ func startServiceCAController(ctx context.Context, cfg *config.Config, kubeconfi
 		klog.Warningf("Failed to apply configMap %v: %v", cm, err)
 		return err
 	}
+	// The controller reads the CA on start, it is restarted when the CA is
+	// rotated to issue the certificates and inject the bundles of the new one.
+	caHash := sha256.Sum256(caCertPEM)
 	extraParams := assets.RenderParams{
 		"CAConfigMap": cmName,
 		"TLSSecret":   secretName,
+		"CAHash":      hex.EncodeToString(caHash[:]),
 	}
 	if err := assets.ApplyDeployments(ctx, apps, renderTemplate, renderParamsFromConfig(cfg, extraParams), kubeconfigPath); err != nil {
 		klog.Warningf("Failed to apply apps %v: %v", apps, err)
@@ -187,6 +209,8 @@ func startIngressController(ctx context.Context, cfg *config.Config, kubeconfigP
 
 	extraParams := assets.RenderParams{
 		"RouterNamespaceOwnership": cfg.Ingress.AdmissionPolicy.NamespaceOwnership == config.NamespaceOwnershipAllowed,
+		"RouterAllowWildcards":     cfg.Ingress.AdmissionPolicy.WildcardPolicy == config.WildcardPolicyAllowed,
+		"RouterDomain":             cfg.Ingress.Domain,
 		"RouterHttpPort":           *cfg.Ingress.Ports.Http,
 		"RouterHttpsPort":          *cfg.Ingress.Ports.Https,
 		"RouterMode":               routerMode,
@@ -0,0 +1,62 @@
+package components
+
+import (
+	"context"
+
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/microshift/pkg/assets"
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/util"
+)
+
+// kubeProxyManifestsDir holds the manifests of the kube-proxy of the
+// microshift-flannel package.
+var kubeProxyManifestsDir = "/usr/lib/microshift/manifests.d/000-microshift-kube-proxy"
+
+// startKubeProxy applies the configuration of the kube-proxy of the
+// microshift-flannel package, which implements the services with the CNI
+// plugins other than OVN-Kubernetes. kube-proxy restarts when its
+// configuration changes.
+func startKubeProxy(ctx context.Context, cfg *config.Config, kubeconfigPath string) error {
+	var (
+		ns = []string{"components/kube-proxy/namespace.yaml"}
+		cm = []string{"components/kube-proxy/configmap.yaml"}
+	)
+
+	if !kubeProxyDeployed(cfg) {
+		return nil
+	}
+
+	if err := assets.ApplyNamespaces(ctx, ns, kubeconfigPath); err != nil {
+		klog.Warningf("Failed to apply namespaces %v: %v", ns, err)
+		return err
+	}
+	extraParams := assets.RenderParams{
+		"KubeProxy": cfg.Network.KubeProxy,
+	}
+	if err := assets.ApplyConfigMaps(ctx, cm, renderTemplate, renderParamsFromConfig(cfg, extraParams), kubeconfigPath); err != nil {
+		klog.Warningf("Failed to apply configMap %v: %v", cm, err)
+		return err
+	}
+	return nil
+}
+
+// kubeProxyDeployed returns whether the kube-proxy of the microshift-flannel
+// package implements the services. The hosts upgraded from the releases
+// before the flannel CNI plugin may still set cniPlugin to none in the
+// drop-in configuration of the package, which is kept when it was changed,
+// so kube-proxy is also deployed with none when its manifests are installed.
+func kubeProxyDeployed(cfg *config.Config) bool {
+	switch cfg.Network.CNIPlugin {
+	case config.CniPluginFlannel, config.CniPluginBridge:
+		return true
+	case config.CniPluginNone:
+		installed, err := util.PathExists(kubeProxyManifestsDir)
+		if err != nil {
+			klog.Warningf("Failed to check for the kube-proxy manifests in %s: %v", kubeProxyManifestsDir, err)
+		}
+		return installed
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package components
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	embedded "github.com/openshift/microshift/assets"
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+)
+
+func TestKubeProxyConfigMap(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.Network.ClusterNetwork = []string{"10.42.0.0/16", "fd01::/48"}
+	cfg.Network.ServiceNetwork = []string{"10.43.0.0/16", "fd02::/112"}
+	cfg.Network.KubeProxy.Mode = config.KubeProxyModeIPVS
+	cfg.Network.KubeProxy.IPVSScheduler = "lc"
+	cfg.Network.KubeProxy.Conntrack.MaxPerCore = ptr.To[int](65536)
+	cfg.Network.KubeProxy.Conntrack.UDPTimeoutSeconds = ptr.To[int](30)
+	data, err := renderTemplate(embedded.MustAsset("components/kube-proxy/configmap.yaml"), renderParamsFromConfig(cfg, map[string]any{"KubeProxy": cfg.Network.KubeProxy}))
+	require.NoError(t, err)
+
+	var configMap corev1.ConfigMap
+	require.NoError(t, yaml.Unmarshal(data, &configMap))
+	var proxyConfig struct {
+		ClusterCIDR string `json:"clusterCIDR"`
+		Mode        string `json:"mode"`
+		IPVS        struct {
+			Scheduler string `json:"scheduler"`
+		} `json:"ipvs"`
+		Conntrack map[string]any `json:"conntrack"`
+	}
+	require.NoError(t, yaml.Unmarshal([]byte(configMap.Data["config.conf"]), &proxyConfig))
+	assert.Equal(t, "10.42.0.0/16,fd01::/48", proxyConfig.ClusterCIDR)
+	assert.Equal(t, "ipvs", proxyConfig.Mode)
+	assert.Equal(t, "lc", proxyConfig.IPVS.Scheduler)
+	assert.Equal(t, map[string]any{
+		"maxPerCore":            float64(65536),
+		"min":                   float64(131072),
+		"tcpEstablishedTimeout": "86400s",
+		"tcpCloseWaitTimeout":   "3600s",
+		"udpTimeout":            "30s",
+		"udpStreamTimeout":      "0s",
+	}, proxyConfig.Conntrack)
+}
+
+func TestKubeProxyDeployed(t *testing.T) {
+	dir := kubeProxyManifestsDir
+	defer func() { kubeProxyManifestsDir = dir }()
+	kubeProxyManifestsDir = filepath.Join(t.TempDir(), "000-microshift-kube-proxy")
+
+	cfg := config.NewDefault()
+	for _, cni := range []config.CNIPlugin{config.CniPluginUnset, config.CniPluginOVNK, config.CniPluginNone} {
+		cfg.Network.CNIPlugin = cni
+		assert.False(t, kubeProxyDeployed(cfg), cni)
+	}
+	for _, cni := range []config.CNIPlugin{config.CniPluginFlannel, config.CniPluginBridge} {
+		cfg.Network.CNIPlugin = cni
+		assert.True(t, kubeProxyDeployed(cfg), cni)
+	}
+
+	// Upgraded hosts whose drop-in configuration of microshift-flannel
+	// still sets cniPlugin to none.
+	require.NoError(t, os.Mkdir(kubeProxyManifestsDir, 0755))
+	cfg.Network.CNIPlugin = config.CniPluginNone
+	assert.True(t, kubeProxyDeployed(cfg))
+	cfg.Network.CNIPlugin = config.CniPluginOVNK
+	assert.False(t, kubeProxyDeployed(cfg))
+}
@@ -0,0 +1,63 @@
+package components
+
+import (
+	"context"
+
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/microshift/pkg/assets"
+	"github.com/openshift/microshift/pkg/config"
+)
+
+func startKubeStateMetrics(ctx context.Context, cfg *config.Config, kubeconfigPath string) error {
+	var (
+		ns   = []string{"components/kube-state-metrics/namespace.yaml"}
+		sa   = []string{"components/kube-state-metrics/service-account.yaml"}
+		cr   = []string{"components/kube-state-metrics/cluster-role.yaml"}
+		crb  = []string{"components/kube-state-metrics/cluster-role-binding.yaml"}
+		svc  = []string{"components/kube-state-metrics/service.yaml"}
+		apps = []string{"components/kube-state-metrics/deployment.yaml"}
+	)
+
+	if cfg.Metrics.KubeStateMetrics.Status == config.KubeStateMetricsStatusRemoved {
+		if err := assets.DeleteClusterRoleBindings(ctx, crb, kubeconfigPath); err != nil {
+			klog.Warningf("Failed to delete cluster role bindings %v: %v", crb, err)
+			return err
+		}
+		if err := assets.DeleteClusterRoles(ctx, cr, kubeconfigPath); err != nil {
+			klog.Warningf("Failed to delete cluster roles %v: %v", cr, err)
+			return err
+		}
+		if err := assets.DeleteNamespaces(ctx, ns, kubeconfigPath); err != nil {
+			klog.Warningf("Failed to delete namespaces %v: %v", ns, err)
+			return err
+		}
+		return nil
+	}
+
+	if err := assets.ApplyNamespaces(ctx, ns, kubeconfigPath); err != nil {
+		klog.Warningf("Failed to apply namespaces %v: %v", ns, err)
+		return err
+	}
+	if err := assets.ApplyClusterRoles(ctx, cr, kubeconfigPath); err != nil {
+		klog.Warningf("Failed to apply clusterRole %v: %v", cr, err)
+		return err
+	}
+	if err := assets.ApplyClusterRoleBindings(ctx, crb, kubeconfigPath); err != nil {
+		klog.Warningf("Failed to apply clusterRolebinding %v: %v", crb, err)
+		return err
+	}
+	if err := assets.ApplyServiceAccounts(ctx, sa, kubeconfigPath); err != nil {
+		klog.Warningf("Failed to apply serviceAccount %v: %v", sa, err)
+		return err
+	}
+	if err := assets.ApplyServices(ctx, svc, renderTemplate, renderParamsFromConfig(cfg, nil), kubeconfigPath); err != nil {
+		klog.Warningf("Failed to apply service %v: %v", svc, err)
+		return err
+	}
+	if err := assets.ApplyDeployments(ctx, apps, renderTemplate, renderParamsFromConfig(cfg, nil), kubeconfigPath); err != nil {
+		klog.Warningf("Failed to apply apps %v: %v", apps, err)
+		return err
+	}
+	return nil
+}
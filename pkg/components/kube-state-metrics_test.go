@@ -0,0 +1,30 @@
+package components
+
+import (
+	"testing"
+
+	embedded "github.com/openshift/microshift/assets"
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/release"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestKubeStateMetricsDeployment(t *testing.T) {
+	cfg := &config.Config{Network: config.Network{
+		ClusterNetwork: []string{"10.42.0.0/16"},
+		ServiceNetwork: []string{"10.43.0.0/16"},
+	}}
+	data, err := renderTemplate(embedded.MustAsset("components/kube-state-metrics/deployment.yaml"), renderParamsFromConfig(cfg, nil))
+	require.NoError(t, err)
+
+	var deployment appsv1.Deployment
+	require.NoError(t, yaml.Unmarshal(data, &deployment))
+	container := deployment.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, release.Image["kube_state_metrics"], container.Image)
+	assert.NotEmpty(t, container.Image)
+	assert.Contains(t, container.Args, "--resources=daemonsets,deployments,jobs,nodes,persistentvolumeclaims,pods,statefulsets")
+}
@@ -0,0 +1,100 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/microshift/pkg/assets"
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/crio"
+	"github.com/openshift/microshift/pkg/release"
+)
+
+// multusDefaultNetworkFile is the network the manifests of the
+// microshift-multus RPM wait for before starting Multus.
+const multusDefaultNetworkFile = "/etc/cni/net.d/10-ovn-kubernetes.conf"
+
+// renderMultus overrides in the manifests of the microshift-multus RPM what
+// its kustomization and the OVN-Kubernetes network would set otherwise: the
+// images of the release and the network file of the CNI plugin.
+func renderMultus(data []byte, params assets.RenderParams) ([]byte, error) {
+	replacements := []string{multusDefaultNetworkFile, params["DefaultNetworkFile"].(string)}
+	for name, key := range map[string]string{
+		"multus-cni-microshift":                  "multus_cni_microshift",
+		"containernetworking-plugins-microshift": "containernetworking_plugins_microshift",
+	} {
+		image, ok := release.Image[key]
+		if !ok {
+			return nil, fmt.Errorf("release has no image %q", key)
+		}
+		replacements = append(replacements, "image: "+name, "image: "+image)
+	}
+	return []byte(strings.NewReplacer(replacements...).Replace(string(data))), nil
+}
+
+func startMultus(ctx context.Context, cfg *config.Config, kubeconfigPath string) error {
+	var (
+		ns   = []string{"optional/multus/00-namespace.yaml"}
+		sa   = []string{"optional/multus/02-service-account.yaml"}
+		cr   = []string{"optional/multus/03-cluster-role.yaml"}
+		crb  = []string{"optional/multus/04-cluster-role-binding.yaml"}
+		cm   = []string{"optional/multus/05-configmap.yaml"}
+		apps = []string{
+			"optional/multus/06-daemonset.yaml",
+			"optional/multus/07-daemonset-dhcp.yaml",
+		}
+	)
+
+	switch cfg.Network.Multus.Status {
+	case config.MultusStatusUnmanaged:
+		return nil
+	case config.MultusStatusRemoved:
+		// The NetworkAttachmentDefinitions of the workloads are kept with
+		// their CRD.
+		if err := assets.DeleteClusterRoleBindings(ctx, crb, kubeconfigPath); err != nil {
+			klog.Warningf("Failed to delete cluster role bindings %v: %v", crb, err)
+			return err
+		}
+		if err := assets.DeleteClusterRoles(ctx, cr, kubeconfigPath); err != nil {
+			klog.Warningf("Failed to delete cluster roles %v: %v", cr, err)
+			return err
+		}
+		if err := assets.DeleteNamespaces(ctx, ns, kubeconfigPath); err != nil {
+			klog.Warningf("Failed to delete namespaces %v: %v", ns, err)
+			return err
+		}
+		return nil
+	}
+
+	if err := assets.ApplyNamespaces(ctx, ns, kubeconfigPath); err != nil {
+		klog.Warningf("Failed to apply namespaces %v: %v", ns, err)
+		return err
+	}
+	if err := assets.ApplyClusterRoles(ctx, cr, kubeconfigPath); err != nil {
+		klog.Warningf("Failed to apply clusterRole %v: %v", cr, err)
+		return err
+	}
+	if err := assets.ApplyClusterRoleBindings(ctx, crb, kubeconfigPath); err != nil {
+		klog.Warningf("Failed to apply clusterRolebinding %v: %v", crb, err)
+		return err
+	}
+	if err := assets.ApplyServiceAccounts(ctx, sa, kubeconfigPath); err != nil {
+		klog.Warningf("Failed to apply serviceAccount %v: %v", sa, err)
+		return err
+	}
+	if err := assets.ApplyConfigMaps(ctx, cm, nil, nil, kubeconfigPath); err != nil {
+		klog.Warningf("Failed to apply configMap %v: %v", cm, err)
+		return err
+	}
+	extraParams := assets.RenderParams{
+		"DefaultNetworkFile": crio.DefaultNetworkFile(cfg.Network.CNIPlugin),
+	}
+	if err := assets.ApplyDaemonSets(ctx, apps, renderMultus, extraParams, kubeconfigPath); err != nil {
+		klog.Warningf("Failed to apply apps %v: %v", apps, err)
+		return err
+	}
+	return nil
+}
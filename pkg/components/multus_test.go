@@ -0,0 +1,35 @@
+package components
+
+import (
+	"testing"
+
+	embedded "github.com/openshift/microshift/assets"
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/crio"
+	"github.com/openshift/microshift/pkg/release"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestMultusDaemonSet(t *testing.T) {
+	params := map[string]any{"DefaultNetworkFile": crio.DefaultNetworkFile(config.CniPluginBridge)}
+	data, err := renderMultus(embedded.MustAsset("optional/multus/06-daemonset.yaml"), params)
+	require.NoError(t, err)
+
+	var daemonSet appsv1.DaemonSet
+	require.NoError(t, yaml.Unmarshal(data, &daemonSet))
+	spec := daemonSet.Spec.Template.Spec
+	assert.Equal(t, release.Image["containernetworking_plugins_microshift"], spec.InitContainers[0].Image)
+	assert.Equal(t, release.Image["multus_cni_microshift"], spec.Containers[0].Image)
+	assert.NotEmpty(t, spec.Containers[0].Image)
+	assert.Contains(t, spec.Containers[0].Args[0], "--readiness-indicator-file=/etc/cni/net.d/05-microshift-bridge.conflist")
+	assert.NotContains(t, spec.Containers[0].Args[0], multusDefaultNetworkFile)
+
+	data, err = renderMultus(embedded.MustAsset("optional/multus/07-daemonset-dhcp.yaml"), params)
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(data, &daemonSet))
+	assert.Equal(t, release.Image["containernetworking_plugins_microshift"], daemonSet.Spec.Template.Spec.Containers[0].Image)
+}
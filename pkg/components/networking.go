@@ -12,8 +12,8 @@ import (
 )
 
 func startCNIPlugin(ctx context.Context, cfg *config.Config, kubeconfigPath string) error {
-	if !cfg.Network.IsEnabled() {
-		klog.Warningf("CNI deployment disabled, OVN-K will not be available")
+	if !cfg.Network.IsOVNK() {
+		klog.Warningf("CNI plugin is %q, OVN-K will not be available", cfg.Network.CNIPlugin)
 		return nil
 	}
 	var (
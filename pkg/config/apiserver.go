@@ -1,5 +1,14 @@
 package config
 
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
 type ApiServer struct {
 	// SubjectAltNames added to API server certs
 	SubjectAltNames []string `json:"subjectAltNames"`
@@ -17,6 +26,16 @@ type ApiServer struct {
 
 	AuditLog AuditLog `json:"auditLog"`
 
+	Encryption Encryption `json:"encryption"`
+
+	Authorization Authorization `json:"authorization"`
+
+	ServiceAccount ServiceAccount `json:"serviceAccount"`
+
+	Tuning ApiServerTuning `json:"tuning"`
+
+	PodSecurity PodSecurity `json:"podSecurity"`
+
 	// The URL and Port of the API server cannot be changed by the user.
 	URL  string `json:"-"`
 	Port int    `json:"-"`
@@ -55,3 +74,303 @@ type AuditLog struct {
 	// +kubebuilder:default=Default
 	Profile string `json:"profile"`
 }
+
+const (
+	EncryptionProviderIdentity EncryptionProviderEnum = "identity"
+	EncryptionProviderAESCBC   EncryptionProviderEnum = "aescbc"
+	EncryptionProviderAESGCM   EncryptionProviderEnum = "aesgcm"
+	EncryptionProviderKMS      EncryptionProviderEnum = "kms"
+)
+
+type EncryptionProviderEnum string
+
+type Encryption struct {
+	// provider used to encrypt secrets stored in etcd. identity stores them
+	// unencrypted. Secrets written with a previous provider or key are
+	// re-encrypted in the background after MicroShift starts.
+	// +kubebuilder:default=identity
+	// +kubebuilder:validation:Enum=identity;aescbc;aesgcm;kms
+	Provider EncryptionProviderEnum `json:"provider"`
+	// keyRotationDays is the age in days after which a new encryption key is
+	// generated when MicroShift starts. 0 disables the rotation. Does not
+	// apply to the kms provider, whose keys are rotated by the KMS plugin.
+	// +kubebuilder:default=90
+	KeyRotationDays *int `json:"keyRotationDays"`
+	// kms configures the KMS v2 plugin used by the kms provider.
+	KMS EncryptionKMS `json:"kms"`
+}
+
+type EncryptionKMS struct {
+	// name of the KMS plugin. Changing it is handled like changing the
+	// provider: the secrets are re-encrypted with the new plugin while the
+	// previous one is still used to read them.
+	// +kubebuilder:default=microshift-kms
+	Name string `json:"name"`
+	// endpoint is the unix socket the KMS plugin listens on, for example
+	// unix:///var/run/kmsplugin/socket.sock.
+	Endpoint string `json:"endpoint"`
+	// timeoutSeconds is the timeout of the calls to the KMS plugin.
+	// +kubebuilder:default=3
+	TimeoutSeconds *int `json:"timeoutSeconds"`
+}
+
+// IsEnabled returns whether secrets are encrypted at rest.
+func (e Encryption) IsEnabled() bool {
+	return e.Provider != EncryptionProviderIdentity
+}
+
+func (e Encryption) validate() error {
+	switch e.Provider {
+	case EncryptionProviderIdentity, EncryptionProviderAESCBC, EncryptionProviderAESGCM:
+	case EncryptionProviderKMS:
+		if err := e.KMS.validate(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported apiServer.encryption.provider value %v", e.Provider)
+	}
+	if e.KeyRotationDays != nil && *e.KeyRotationDays < 0 {
+		return fmt.Errorf("apiServer.encryption.keyRotationDays must not be negative, got %d", *e.KeyRotationDays)
+	}
+	return nil
+}
+
+func (k EncryptionKMS) validate() error {
+	if k.Name == "" || strings.Contains(k.Name, ":") {
+		return fmt.Errorf("apiServer.encryption.kms.name must not be empty or contain ':', got %q", k.Name)
+	}
+	path, ok := strings.CutPrefix(k.Endpoint, "unix://")
+	if !ok || !filepath.IsAbs(path) {
+		return fmt.Errorf("apiServer.encryption.kms.endpoint must be a unix socket like unix:///path/to/socket, got %q", k.Endpoint)
+	}
+	if k.TimeoutSeconds != nil && *k.TimeoutSeconds <= 0 {
+		return fmt.Errorf("apiServer.encryption.kms.timeoutSeconds must be positive, got %d", *k.TimeoutSeconds)
+	}
+	return nil
+}
+
+type Authorization struct {
+	// webhook configures an authorizer consulted for the requests of all of
+	// the users except system:masters, before RBAC.
+	Webhook AuthorizationWebhook `json:"webhook"`
+}
+
+type AuthorizationWebhook struct {
+	// kubeconfigPath is the absolute path of the kubeconfig file describing
+	// how to reach the remote authorization service. Empty disables the webhook.
+	KubeconfigPath string `json:"kubeconfigPath"`
+	// cacheAuthorizedTTLSeconds is the duration to cache the 'authorized'
+	// responses from the webhook.
+	// +kubebuilder:default=300
+	CacheAuthorizedTTLSeconds *int `json:"cacheAuthorizedTTLSeconds"`
+	// cacheUnauthorizedTTLSeconds is the duration to cache the 'unauthorized'
+	// responses from the webhook.
+	// +kubebuilder:default=30
+	CacheUnauthorizedTTLSeconds *int `json:"cacheUnauthorizedTTLSeconds"`
+}
+
+// IsEnabled returns whether the authorization webhook is configured.
+func (w AuthorizationWebhook) IsEnabled() bool {
+	return w.KubeconfigPath != ""
+}
+
+func (w AuthorizationWebhook) validate() error {
+	if w.KubeconfigPath != "" && !filepath.IsAbs(w.KubeconfigPath) {
+		return fmt.Errorf("apiServer.authorization.webhook.kubeconfigPath must be an absolute path, got %q", w.KubeconfigPath)
+	}
+	if w.CacheAuthorizedTTLSeconds != nil && *w.CacheAuthorizedTTLSeconds < 0 {
+		return fmt.Errorf("apiServer.authorization.webhook.cacheAuthorizedTTLSeconds must not be negative, got %d", *w.CacheAuthorizedTTLSeconds)
+	}
+	if w.CacheUnauthorizedTTLSeconds != nil && *w.CacheUnauthorizedTTLSeconds < 0 {
+		return fmt.Errorf("apiServer.authorization.webhook.cacheUnauthorizedTTLSeconds must not be negative, got %d", *w.CacheUnauthorizedTTLSeconds)
+	}
+	return nil
+}
+
+// DefaultServiceAccountIssuer is the issuer of the service account tokens
+// unless configured otherwise. Tokens it issued are always accepted.
+const DefaultServiceAccountIssuer = "https://kubernetes.default.svc"
+
+type ServiceAccount struct {
+	// issuer is the identifier of the service account token issuer, set in
+	// the iss claim of the tokens. External systems federating the tokens
+	// fetch the OIDC discovery document from this URL. Tokens issued with the
+	// default issuer remain valid after changing it.
+	// +kubebuilder:default="https://kubernetes.default.svc"
+	Issuer string `json:"issuer"`
+	// jwksURI overrides the URL of the JSON Web Key Set in the OIDC discovery
+	// document, for when the documents are published to another location
+	// than the issuer URL.
+	JWKSURI string `json:"jwksURI"`
+	// publish copies the OIDC discovery documents to a location reachable by
+	// the external systems.
+	Publish ServiceAccountPublish `json:"publish"`
+}
+
+type ServiceAccountPublish struct {
+	// directory the documents are written to, at the
+	// .well-known/openid-configuration and openid/v1/jwks paths, to be
+	// served by a web server.
+	Directory string `json:"directory"`
+	// url the documents are uploaded to with HTTP PUT requests, at the same
+	// paths as in directory.
+	URL string `json:"url"`
+	// caFile is the CA bundle verifying the server of url. Defaults to the
+	// system CAs.
+	CAFile string `json:"caFile"`
+	// bearerTokenFile is the file holding the token sent in the
+	// Authorization header of the uploads.
+	BearerTokenFile string `json:"bearerTokenFile"`
+}
+
+// IsEnabled returns whether the OIDC discovery documents are published.
+func (p ServiceAccountPublish) IsEnabled() bool {
+	return p.Directory != "" || p.URL != ""
+}
+
+func validateHTTPSURL(field, value string) error {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("%s must be an https URL without query or fragment, got %q", field, value)
+	}
+	return nil
+}
+
+func (s ServiceAccount) validate() error {
+	if err := validateHTTPSURL("apiServer.serviceAccount.issuer", s.Issuer); err != nil {
+		return err
+	}
+	if s.JWKSURI != "" {
+		if err := validateHTTPSURL("apiServer.serviceAccount.jwksURI", s.JWKSURI); err != nil {
+			return err
+		}
+	}
+	if s.Publish.URL != "" {
+		if err := validateHTTPSURL("apiServer.serviceAccount.publish.url", s.Publish.URL); err != nil {
+			return err
+		}
+	}
+	for field, path := range map[string]string{
+		"directory":       s.Publish.Directory,
+		"caFile":          s.Publish.CAFile,
+		"bearerTokenFile": s.Publish.BearerTokenFile,
+	} {
+		if path != "" && !filepath.IsAbs(path) {
+			return fmt.Errorf("apiServer.serviceAccount.publish.%s must be an absolute path, got %q", field, path)
+		}
+	}
+	return nil
+}
+
+type ApiServerTuning struct {
+	// maxRequestsInflight is the maximum number of non-mutating requests
+	// served concurrently. Together with maxMutatingRequestsInflight, it
+	// bounds the memory used by the API server under load.
+	// +kubebuilder:default=400
+	MaxRequestsInflight *int `json:"maxRequestsInflight"`
+	// maxMutatingRequestsInflight is the maximum number of mutating requests
+	// served concurrently.
+	// +kubebuilder:default=200
+	MaxMutatingRequestsInflight *int `json:"maxMutatingRequestsInflight"`
+	// watchCacheSizes overrides the size of the watch cache of resources, in
+	// the resource[.group]#size format, e.g. secrets#100 or
+	// deployments.apps#50. A size of 0 disables the watch cache of the
+	// resource, serving its lists and watches from etcd.
+	WatchCacheSizes []string `json:"watchCacheSizes"`
+	// goAwayChance is the probability, between 0 and 0.02, to close an HTTP/2
+	// connection so the client reconnects, spreading the clients over the
+	// API servers behind a load balancer. It does not help with a single API
+	// server.
+	// +kubebuilder:default=0
+	GoAwayChance *float64 `json:"goAwayChance"`
+	// disabledAPIs lists the API group versions, in the group/version format,
+	// or resources, in the group/version/resource format, not served by the
+	// API server, sparing their handlers and watch caches, e.g.
+	// batch/v1/cronjobs or autoscaling/v2. Only the APIs MicroShift does not
+	// depend on can be disabled: batch/v1, autoscaling/v1, autoscaling/v2,
+	// flowcontrol.apiserver.k8s.io/v1 and flowcontrol.apiserver.k8s.io/v1beta3,
+	// or their resources.
+	DisabledAPIs []string `json:"disabledAPIs"`
+}
+
+// disableableAPIs are the API group versions, and their resources, which
+// can be disabled. The other ones are used by MicroShift or its components.
+var disableableAPIs = map[string][]string{
+	"batch/v1":                             {"jobs", "cronjobs"},
+	"autoscaling/v1":                       {"horizontalpodautoscalers"},
+	"autoscaling/v2":                       {"horizontalpodautoscalers"},
+	"flowcontrol.apiserver.k8s.io/v1":      {"flowschemas", "prioritylevelconfigurations"},
+	"flowcontrol.apiserver.k8s.io/v1beta3": {"flowschemas", "prioritylevelconfigurations"},
+}
+
+// IsAPIDisabled returns whether the resource of groupVersion, or the whole
+// group version when resource is empty, is in disabledAPIs.
+func (t ApiServerTuning) IsAPIDisabled(groupVersion, resource string) bool {
+	for _, api := range t.DisabledAPIs {
+		if api == groupVersion || (resource != "" && api == groupVersion+"/"+resource) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t ApiServerTuning) validate() error {
+	if t.MaxRequestsInflight != nil && *t.MaxRequestsInflight <= 0 {
+		return fmt.Errorf("apiServer.tuning.maxRequestsInflight must be positive, got %d", *t.MaxRequestsInflight)
+	}
+	if t.MaxMutatingRequestsInflight != nil && *t.MaxMutatingRequestsInflight <= 0 {
+		return fmt.Errorf("apiServer.tuning.maxMutatingRequestsInflight must be positive, got %d", *t.MaxMutatingRequestsInflight)
+	}
+	for _, size := range t.WatchCacheSizes {
+		resource, n, ok := strings.Cut(size, "#")
+		if v, err := strconv.Atoi(n); !ok || resource == "" || err != nil || v < 0 {
+			return fmt.Errorf("apiServer.tuning.watchCacheSizes entry %q must be in the resource[.group]#size format", size)
+		}
+	}
+	if t.GoAwayChance != nil && (*t.GoAwayChance < 0 || *t.GoAwayChance > 0.02) {
+		return fmt.Errorf("apiServer.tuning.goAwayChance must be between 0 and 0.02, got %v", *t.GoAwayChance)
+	}
+	for _, api := range t.DisabledAPIs {
+		group, rest, _ := strings.Cut(api, "/")
+		version, resource, _ := strings.Cut(rest, "/")
+		resources, ok := disableableAPIs[group+"/"+version]
+		if !ok || (resource != "" && !slices.Contains(resources, resource)) {
+			return fmt.Errorf("apiServer.tuning.disabledAPIs entry %q is not one of the APIs which can be disabled", api)
+		}
+	}
+	return nil
+}
+
+const (
+	PodSecurityModeEnforce PodSecurityModeEnum = "Enforce"
+	PodSecurityModeLogOnly PodSecurityModeEnum = "LogOnly"
+)
+
+type PodSecurityModeEnum string
+
+type PodSecurity struct {
+	// Describes whether the pod security admission decisions are enforced.
+	//
+	// Value must be one of:
+	//
+	// - Enforce: Pods violating the pod security level of their namespace are
+	//   rejected.
+	//
+	// - LogOnly: Violations are only recorded in the audit log, warnings and
+	//   metrics, to help migrating workloads which do not meet the restricted
+	//   level yet. Namespaces are labeled for audit and warn only. The SCC
+	//   admission is not affected and still rejects the pods no SCC allows.
+	//
+	// If empty, the default is Enforce.
+	// +kubebuilder:default="Enforce"
+	Mode PodSecurityModeEnum `json:"mode"`
+}
+
+func (p PodSecurity) validate() error {
+	switch p.Mode {
+	case PodSecurityModeEnforce, PodSecurityModeLogOnly:
+		return nil
+	default:
+		return fmt.Errorf("unsupported apiServer.podSecurity.mode value %v", p.Mode)
+	}
+}
@@ -0,0 +1,240 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+type ClockSkewPolicyEnum string
+
+const (
+	ClockSkewPolicyWarn   ClockSkewPolicyEnum = "Warn"
+	ClockSkewPolicyRefuse ClockSkewPolicyEnum = "Refuse"
+)
+
+const (
+	// Certificates within 7 months of their expiry are rotated on start,
+	// so shorter validities would rotate them on every start.
+	minShortLivedValidityDays = 240
+	// Certificates valid for 5 years or more are considered long-lived,
+	// and rotated within 18 months of their expiry.
+	minLongLivedValidityDays = 5 * 365
+)
+
+// Certificates configures the validity of the certificates MicroShift
+// generates, and how it handles a clock behind them.
+type Certificates struct {
+	// Minutes the certificates are valid from before they are generated,
+	// for them to be usable on the hosts whose clock is set behind by up
+	// to as much, like boards without a real-time clock booting before
+	// NTP synchronizes.
+	// +kubebuilder:default=60
+	BackdateMinutes *int `json:"backdateMinutes"`
+
+	// Validity in days of the short-lived certificates and signers, like
+	// the serving and client certificates of the control plane. They are
+	// rotated on start within 7 months of their expiry, so it must be at
+	// least 240 and below 1825.
+	// +kubebuilder:default=365
+	ShortLivedValidityDays *int `json:"shortLivedValidityDays"`
+
+	// Validity in days of the long-lived signers and certificates, like
+	// the signers of the API server and of the admin kubeconfig. They are
+	// rotated on start within 18 months of their expiry, so it must be at
+	// least 1825.
+	// +kubebuilder:default=3650
+	LongLivedValidityDays *int `json:"longLivedValidityDays"`
+
+	// What MicroShift does on start when the clock is behind the
+	// certificates it stored, which are then not yet valid: Warn, and
+	// regenerate them from the wrong clock, or Refuse to start until the
+	// clock is set.
+	// +kubebuilder:validation:Enum:=Warn;Refuse
+	// +kubebuilder:default="Warn"
+	ClockSkewPolicy ClockSkewPolicyEnum `json:"clockSkewPolicy"`
+
+	// External CA issuing the signers of MicroShift instead of them being
+	// self-signed, for all of its certificates to chain to the PKI of the
+	// organization. Changing it regenerates all of the certificates.
+	ExternalCA CertificatesExternalCA `json:"externalCA"`
+
+	// PKCS#11 token, like a TPM 2.0 through tpm2-pkcs11, keeping the private
+	// key of the CA issuing the signers of MicroShift, which never leaves
+	// it. The CA is certificates.externalCA.certFile when set, a root CA
+	// generated by MicroShift otherwise.
+	PKCS11 CertificatesPKCS11 `json:"pkcs11"`
+
+	// Policy of the approver of the certificate signing requests of the
+	// cluster, like the ones of the kubelets for their serving certificates.
+	CSRApproval CertificatesCSRApproval `json:"csrApproval"`
+}
+
+// CertificatesExternalCA is an intermediate CA of the PKI of the
+// organization.
+type CertificatesExternalCA struct {
+	// Absolute path of the PEM certificate of the CA, followed by the
+	// certificates of its chain up to the root CA, which are added to the
+	// CA bundle of the pods.
+	CertFile string `json:"certFile"`
+
+	// Absolute path of the PEM private key of the CA. It must not be set
+	// when the key is in the certificates.pkcs11 token.
+	KeyFile string `json:"keyFile"`
+}
+
+// CertificatesPKCS11 is a PKCS#11 token used through the pkcs11-tool
+// command of OpenSC.
+type CertificatesPKCS11 struct {
+	// Absolute path of the PKCS#11 module of the token, like
+	// /usr/lib64/pkcs11/libtpm2_pkcs11.so for a TPM 2.0.
+	Module string `json:"module"`
+
+	// Label of the token.
+	TokenLabel string `json:"tokenLabel"`
+
+	// Absolute path of the file holding the user PIN of the token.
+	PINFile string `json:"pinFile"`
+
+	// Label of the RSA key of the CA in the token, which is generated when
+	// missing without certificates.externalCA.
+	// +kubebuilder:default="microshift-root-ca"
+	KeyLabel string `json:"keyLabel"`
+}
+
+// CertificatesCSRApproval approves the certificate signing requests
+// matching its rules, instead of them being approved manually.
+type CertificatesCSRApproval struct {
+	// Rules approving the pending certificate signing requests matching
+	// any of them. The requests to the signers of the rules matching none
+	// are denied, and the ones to other signers are left to be approved
+	// manually. The approver does not run without rules.
+	Rules []CSRApprovalRule `json:"rules"`
+}
+
+// +kubebuilder:validation:Enum:=RSA;ECDSA;Ed25519
+type CSRApprovalKeyType string
+
+const (
+	CSRApprovalKeyTypeRSA     CSRApprovalKeyType = "RSA"
+	CSRApprovalKeyTypeECDSA   CSRApprovalKeyType = "ECDSA"
+	CSRApprovalKeyTypeEd25519 CSRApprovalKeyType = "Ed25519"
+)
+
+// CSRApprovalRule matches the certificate signing requests of a signer.
+type CSRApprovalRule struct {
+	// Name of the signer of the requests, like
+	// kubernetes.io/kubelet-serving.
+	SignerName string `json:"signerName"`
+
+	// Patterns of the names of the users allowed to request the
+	// certificates, like system:node:*. Any user is allowed when empty.
+	Usernames []string `json:"usernames"`
+
+	// Groups allowed to request the certificates, like system:nodes. Any
+	// group is allowed when empty, but usernames or groups must be set.
+	Groups []string `json:"groups"`
+
+	// Patterns all of the DNS names requested must match, like
+	// *.example.com. No DNS name may be requested when empty.
+	DNSNames []string `json:"dnsNames"`
+
+	// Networks all of the IP addresses requested must belong to, like
+	// 192.168.1.0/24. No IP address may be requested when empty.
+	IPAddresses []string `json:"ipAddresses"`
+
+	// Types of the keys of the requests, among RSA, ECDSA and Ed25519.
+	// Any type is allowed when empty.
+	KeyTypes []CSRApprovalKeyType `json:"keyTypes"`
+}
+
+func (r CSRApprovalRule) validate(i int) error {
+	if r.SignerName == "" {
+		return fmt.Errorf("certificates.csrApproval.rules[%d].signerName must be set", i)
+	}
+	if len(r.Usernames) == 0 && len(r.Groups) == 0 {
+		return fmt.Errorf("certificates.csrApproval.rules[%d] must set usernames or groups", i)
+	}
+	for _, pattern := range append(r.Usernames, r.DNSNames...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q in certificates.csrApproval.rules[%d]: %w", pattern, i, err)
+		}
+	}
+	for _, cidr := range r.IPAddresses {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("invalid network %q in certificates.csrApproval.rules[%d].ipAddresses: %w", cidr, i, err)
+		}
+	}
+	for _, keyType := range r.KeyTypes {
+		switch keyType {
+		case CSRApprovalKeyTypeRSA, CSRApprovalKeyTypeECDSA, CSRApprovalKeyTypeEd25519:
+		default:
+			return fmt.Errorf("unsupported key type %q in certificates.csrApproval.rules[%d].keyTypes", keyType, i)
+		}
+	}
+	return nil
+}
+
+func (c Certificates) validate() error {
+	if c.BackdateMinutes != nil && *c.BackdateMinutes < 0 {
+		return fmt.Errorf("certificates.backdateMinutes must not be negative, got %d", *c.BackdateMinutes)
+	}
+	if c.ShortLivedValidityDays != nil && (*c.ShortLivedValidityDays < minShortLivedValidityDays || *c.ShortLivedValidityDays >= minLongLivedValidityDays) {
+		return fmt.Errorf("certificates.shortLivedValidityDays must be at least %d and below %d, got %d",
+			minShortLivedValidityDays, minLongLivedValidityDays, *c.ShortLivedValidityDays)
+	}
+	if c.LongLivedValidityDays != nil && *c.LongLivedValidityDays < minLongLivedValidityDays {
+		return fmt.Errorf("certificates.longLivedValidityDays must be at least %d, got %d",
+			minLongLivedValidityDays, *c.LongLivedValidityDays)
+	}
+	if c.PKCS11.IsEnabled() {
+		if c.ExternalCA.KeyFile != "" {
+			return fmt.Errorf("certificates.externalCA.keyFile must not be set with certificates.pkcs11, the key is in the token")
+		}
+		if c.PKCS11.TokenLabel == "" || c.PKCS11.PINFile == "" || c.PKCS11.KeyLabel == "" {
+			return fmt.Errorf("certificates.pkcs11.tokenLabel, certificates.pkcs11.pinFile and certificates.pkcs11.keyLabel must be set with certificates.pkcs11.module")
+		}
+	} else if (c.ExternalCA.CertFile == "") != (c.ExternalCA.KeyFile == "") {
+		return fmt.Errorf("certificates.externalCA.certFile and certificates.externalCA.keyFile must be set together")
+	}
+	for name, path := range map[string]string{
+		"externalCA.certFile": c.ExternalCA.CertFile,
+		"externalCA.keyFile":  c.ExternalCA.KeyFile,
+		"pkcs11.module":       c.PKCS11.Module,
+		"pkcs11.pinFile":      c.PKCS11.PINFile,
+	} {
+		if path != "" && !filepath.IsAbs(path) {
+			return fmt.Errorf("certificates.%s must be an absolute path, got %q", name, path)
+		}
+	}
+	for i, rule := range c.CSRApproval.Rules {
+		if err := rule.validate(i); err != nil {
+			return err
+		}
+	}
+	switch c.ClockSkewPolicy {
+	case ClockSkewPolicyWarn, ClockSkewPolicyRefuse:
+	default:
+		return fmt.Errorf("unsupported certificates.clockSkewPolicy value %v", c.ClockSkewPolicy)
+	}
+	return nil
+}
+
+// Backdate returns how long the certificates are valid from before they are
+// generated.
+func (c Certificates) Backdate() time.Duration {
+	return time.Duration(*c.BackdateMinutes) * time.Minute
+}
+
+// IsEnabled returns whether the signers are issued by an external CA.
+func (e CertificatesExternalCA) IsEnabled() bool {
+	return e.CertFile != ""
+}
+
+// IsEnabled returns whether the key of the CA issuing the signers is in a
+// PKCS#11 token.
+func (p CertificatesPKCS11) IsEnabled() bool {
+	return p.Module != ""
+}
@@ -0,0 +1,48 @@
+package config
+
+import "fmt"
+
+type ServiceCAStatusEnum string
+
+const (
+	ServiceCAStatusManaged ServiceCAStatusEnum = "Managed"
+	ServiceCAStatusRemoved ServiceCAStatusEnum = "Removed"
+)
+
+// Components configures the optional components of the control plane.
+type Components struct {
+	ServiceCA ServiceCA `json:"serviceCA"`
+}
+
+// ServiceCA configures the service CA controller, which issues the serving
+// certificates of the services annotated with
+// service.beta.openshift.io/serving-cert-secret-name and injects its CA
+// bundle in the ConfigMaps, webhooks, API services and CRDs annotated with
+// service.beta.openshift.io/inject-cabundle.
+type ServiceCA struct {
+	// Whether the service CA controller is deployed, Managed or Removed.
+	// Removing it deletes its namespace, the certificates it issued and the
+	// CA bundles it injected are kept but no longer renewed.
+	// +kubebuilder:default="Managed"
+	Status ServiceCAStatusEnum `json:"status"`
+
+	// Validity in days of the service CA, which is rotated on start within
+	// 7 months of its expiry when shorter than 1825, within 18 months
+	// otherwise. The controller is restarted with the new CA, which it
+	// injects in the CA bundles. It must be at least 240, and defaults to
+	// certificates.longLivedValidityDays when 0.
+	ValidityDays int `json:"validityDays"`
+}
+
+func (c Components) validate() error {
+	switch c.ServiceCA.Status {
+	case ServiceCAStatusManaged, ServiceCAStatusRemoved:
+	default:
+		return fmt.Errorf("unsupported components.serviceCA.status value %v", c.ServiceCA.Status)
+	}
+	if c.ServiceCA.ValidityDays != 0 && c.ServiceCA.ValidityDays < minShortLivedValidityDays {
+		return fmt.Errorf("components.serviceCA.validityDays must be at least %d, got %d",
+			minShortLivedValidityDays, c.ServiceCA.ValidityDays)
+	}
+	return nil
+}
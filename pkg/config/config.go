@@ -18,6 +18,7 @@ import (
 	"github.com/openshift/microshift/pkg/config/apiserver"
 
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/klog/v2"
 	netutils "k8s.io/utils/net"
 	"k8s.io/utils/ptr"
@@ -50,8 +51,27 @@ type Config struct {
 	Etcd      EtcdConfig    `json:"etcd"`
 	Debugging Debugging     `json:"debugging"`
 	Manifests Manifests     `json:"manifests"`
+	MDNS      MDNS          `json:"mdns"`
 	Ingress   IngressConfig `json:"ingress"`
 	Storage   Storage       `json:"storage"`
+	Startup   Startup       `json:"startup"`
+	Scheduler Scheduler     `json:"scheduler"`
+
+	ControllerManager ControllerManager `json:"controllerManager"`
+	LoadBalancer      LoadBalancer      `json:"loadBalancer"`
+	Metrics           Metrics           `json:"metrics"`
+	Tracing           Tracing           `json:"tracing"`
+	Logging           Logging           `json:"logging"`
+	HealthCheck       HealthCheck       `json:"healthCheck"`
+	Upgrade           Upgrade           `json:"upgrade"`
+	VirtualIP         VirtualIP         `json:"virtualIP"`
+	FleetAPI          FleetAPI          `json:"fleetAPI"`
+	Firewall          Firewall          `json:"firewall"`
+	Certificates      Certificates      `json:"certificates"`
+	Components        Components        `json:"components"`
+	Images            Images            `json:"images"`
+	DiskMaintenance   DiskMaintenance   `json:"diskMaintenance"`
+	CRIO              CRIO              `json:"crio"`
 
 	// Settings specified in this section are transferred as-is into the Kubelet config.
 	// +kubebuilder:validation:Schemaless
@@ -94,13 +114,14 @@ func (c *Config) fillDefaults() error {
 	if err != nil {
 		return fmt.Errorf("failed to get hostname %v", err)
 	}
-	nodeIP, err := util.GetHostIP("")
+	nodeIP, err := util.GetHostIP("", util.NodeIPPolicy{})
 	if err != nil {
 		return fmt.Errorf("failed to get host IP: %v", err)
 	}
 
 	c.Debugging = Debugging{
-		LogLevel: "Normal",
+		LogLevel:  "Normal",
+		Profiling: ProfilingStatusDisabled,
 	}
 	c.ApiServer = ApiServer{
 		SubjectAltNames: subjectAltNames,
@@ -113,15 +134,167 @@ func (c *Config) fillDefaults() error {
 		MaxFileSize: 200,
 		Profile:     "Default",
 	}
+	c.ApiServer.Encryption = Encryption{
+		Provider:        EncryptionProviderIdentity,
+		KeyRotationDays: ptr.To[int](90),
+		KMS: EncryptionKMS{
+			Name:           "microshift-kms",
+			TimeoutSeconds: ptr.To[int](3),
+		},
+	}
+	c.ApiServer.Authorization.Webhook = AuthorizationWebhook{
+		CacheAuthorizedTTLSeconds:   ptr.To[int](300),
+		CacheUnauthorizedTTLSeconds: ptr.To[int](30),
+	}
+	c.ApiServer.ServiceAccount = ServiceAccount{
+		Issuer: DefaultServiceAccountIssuer,
+	}
+	c.ApiServer.Tuning = ApiServerTuning{
+		MaxRequestsInflight:         ptr.To[int](400),
+		MaxMutatingRequestsInflight: ptr.To[int](200),
+		GoAwayChance:                ptr.To[float64](0),
+	}
+	c.ApiServer.PodSecurity = PodSecurity{
+		Mode: PodSecurityModeEnforce,
+	}
 	c.Node = Node{
 		HostnameOverride: hostname,
 		NodeIP:           nodeIP,
+		NodeIPSelection: NodeIPSelection{
+			PreferredIPFamily: IPFamilyIPv4,
+		},
+		Drain: NodeDrain{
+			TimeoutSeconds: ptr.To[int](0),
+		},
+		GracefulShutdown: NodeGracefulShutdown{
+			GracePeriodSeconds:             ptr.To[int](30),
+			CriticalPodsGracePeriodSeconds: ptr.To[int](10),
+		},
+		Eviction: NodeEviction{
+			PressureTransitionPeriodSeconds: ptr.To[int](300),
+			MaxPodGracePeriodSeconds:        ptr.To[int](0),
+		},
+		TopologyManager: NodeTopologyManager{
+			Policy: TopologyManagerPolicyNone,
+			Scope:  TopologyManagerScopeContainer,
+		},
+		CPUManager: NodeCPUManager{
+			Policy: CPUManagerPolicyNone,
+		},
+		MemoryManager: NodeMemoryManager{
+			Policy: MemoryManagerPolicyNone,
+		},
+		ResourceReservation: NodeResourceReservation{
+			AutoSizing: AutoSizingDisabled,
+		},
+		Swap: NodeSwap{
+			Behavior: SwapBehaviorNoSwap,
+		},
+	}
+	c.ControllerManager = ControllerManager{
+		TerminatedPodGCThreshold:      ptr.To[int](12500),
+		NodeMonitorGracePeriodSeconds: ptr.To[int](40),
 	}
 	c.DNS = DNS{
 		BaseDomain: "example.com",
 	}
+	c.Startup = Startup{
+		TimeoutSeconds: ptr.To[int](0),
+	}
+	c.Upgrade = Upgrade{
+		RestoreOnRollback: RestoreOnRollbackStatusEnabled,
+	}
+	c.VirtualIP = VirtualIP{
+		Status:   VirtualIPStatusDisabled,
+		Priority: 100,
+		Port:     7444,
+	}
+	c.FleetAPI = FleetAPI{
+		Status: FleetAPIStatusDisabled,
+		Port:   7445,
+	}
+	c.Firewall = Firewall{
+		Status:      FirewallStatusDisabled,
+		Backend:     FirewallBackendFirewalld,
+		Zone:        "public",
+		TrustedZone: "trusted",
+	}
+	c.Certificates = Certificates{
+		BackdateMinutes:        ptr.To[int](60),
+		ShortLivedValidityDays: ptr.To[int](365),
+		LongLivedValidityDays:  ptr.To[int](3650),
+		ClockSkewPolicy:        ClockSkewPolicyWarn,
+		PKCS11: CertificatesPKCS11{
+			KeyLabel: "microshift-root-ca",
+		},
+	}
+	c.Images = Images{
+		PullSecretFile: DefaultPullSecretFile,
+		GarbageCollection: ImagesGarbageCollection{
+			HighThresholdPercent: ptr.To[int](85),
+			LowThresholdPercent:  ptr.To[int](80),
+			MinimumAgeSeconds:    ptr.To[int](120),
+			MaximumAgeSeconds:    ptr.To[int](0),
+		},
+		LocalRegistry: ImagesLocalRegistry{
+			Status: LocalRegistryStatusDisabled,
+			Port:   5000,
+		},
+	}
+	c.DiskMaintenance = DiskMaintenance{
+		Status:               DiskMaintenanceStatusDisabled,
+		MarginPercent:        ptr.To[int](5),
+		CheckIntervalSeconds: ptr.To[int](60),
+		KeepBackups:          ptr.To[int](1),
+	}
+	c.Metrics = Metrics{
+		KubeStateMetrics: KubeStateMetrics{
+			Status: KubeStateMetricsStatusRemoved,
+		},
+	}
+	c.Components = Components{
+		ServiceCA: ServiceCA{
+			Status: ServiceCAStatusManaged,
+		},
+	}
+	c.Tracing = Tracing{
+		SamplingRatePerMillion: ptr.To[int](1000000),
+	}
+	c.Logging = Logging{
+		Format: LogFormatText,
+		Events: LoggingEvents{
+			Status:       EventsStatusEnabled,
+			MaxPerMinute: ptr.To[int](60),
+		},
+		Forwarding: LoggingForwarding{
+			Status:       ForwardingStatusDisabled,
+			Sources:      []LogSourceEnum{LogSourceAudit, LogSourceKubelet},
+			BufferSizeMB: ptr.To[int](100),
+		},
+	}
+	c.LoadBalancer = LoadBalancer{
+		L2Announcement: L2AnnouncementDisabled,
+	}
+	c.MDNS = MDNS{
+		Status: MDNSStatusEnabled,
+		Domain: "local",
+	}
 	c.Network = Network{
 		ServiceNodePortRange: "30000-32767",
+		Multus: NetworkMultus{
+			Status: MultusStatusUnmanaged,
+		},
+		KubeProxy: NetworkKubeProxy{
+			Mode: KubeProxyModeIPTables,
+			Conntrack: KubeProxyConntrack{
+				MaxPerCore:                   ptr.To[int](0),
+				Min:                          ptr.To[int](131072),
+				TCPEstablishedTimeoutSeconds: ptr.To[int](86400),
+				TCPCloseWaitTimeoutSeconds:   ptr.To[int](3600),
+				UDPTimeoutSeconds:            ptr.To[int](0),
+				UDPStreamTimeoutSeconds:      ptr.To[int](0),
+			},
+		},
 	}
 	c.Etcd = EtcdConfig{
 		MemoryLimitMB:           0,
@@ -129,19 +302,36 @@ func (c *Config) fillDefaults() error {
 		MinDefragBytes:          100 * 1024 * 1024,
 		MaxFragmentedPercentage: 45,
 		DefragCheckFreq:         5 * time.Minute,
+		Defragmentation: EtcdDefragmentation{
+			CheckIntervalSeconds:    ptr.To[int](300),
+			MaxFragmentedPercentage: ptr.To[int](45),
+			MinDatabaseSizeMB:       ptr.To[int](100),
+		},
+		Standby: EtcdStandby{
+			Role: EtcdStandbyRoleNone,
+		},
+		Tmpfs: EtcdTmpfs{
+			Status:                 EtcdTmpfsStatusDisabled,
+			PersistIntervalSeconds: ptr.To[int](900),
+		},
 	}
 	c.Manifests = Manifests{
+		ConflictPolicy: ManifestsConflictPolicyForce,
 		KustomizePaths: []string{
 			defaultManifestDirLib,
 			defaultManifestDirLibGlob,
 			defaultManifestDirEtc,
 			defaultManifestDirEtcGlob,
 		},
+		Reconcile: ManifestsReconcile{
+			IntervalSeconds: ptr.To[int](0),
+		},
 	}
 	c.Ingress = IngressConfig{
 		Status: StatusManaged,
 		AdmissionPolicy: RouteAdmissionPolicy{
 			NamespaceOwnership: NamespaceOwnershipAllowed,
+			WildcardPolicy:     WildcardPolicyDisallowed,
 		},
 		Ports: IngressPortsConfig{
 			Http:  ptr.To[int](80),
@@ -189,10 +379,82 @@ func (c *Config) incorporateUserSettings(u *Config) {
 	if u.Network.DNS != "" {
 		c.Network.DNS = u.Network.DNS
 	}
+	if u.Network.Multus.Status != "" {
+		c.Network.Multus.Status = u.Network.Multus.Status
+	}
+	if len(u.Network.NodePortAddresses) != 0 {
+		c.Network.NodePortAddresses = u.Network.NodePortAddresses
+	}
+	if len(u.Network.Egress.Addresses) != 0 {
+		c.Network.Egress.Addresses = u.Network.Egress.Addresses
+	}
+	if u.Network.KubeProxy.Mode != "" {
+		c.Network.KubeProxy.Mode = u.Network.KubeProxy.Mode
+	}
+	if u.Network.KubeProxy.IPVSScheduler != "" {
+		c.Network.KubeProxy.IPVSScheduler = u.Network.KubeProxy.IPVSScheduler
+	}
+	if u.Network.KubeProxy.Conntrack.MaxPerCore != nil {
+		c.Network.KubeProxy.Conntrack.MaxPerCore = u.Network.KubeProxy.Conntrack.MaxPerCore
+	}
+	if u.Network.KubeProxy.Conntrack.Min != nil {
+		c.Network.KubeProxy.Conntrack.Min = u.Network.KubeProxy.Conntrack.Min
+	}
+	if u.Network.KubeProxy.Conntrack.TCPEstablishedTimeoutSeconds != nil {
+		c.Network.KubeProxy.Conntrack.TCPEstablishedTimeoutSeconds = u.Network.KubeProxy.Conntrack.TCPEstablishedTimeoutSeconds
+	}
+	if u.Network.KubeProxy.Conntrack.TCPCloseWaitTimeoutSeconds != nil {
+		c.Network.KubeProxy.Conntrack.TCPCloseWaitTimeoutSeconds = u.Network.KubeProxy.Conntrack.TCPCloseWaitTimeoutSeconds
+	}
+	if u.Network.KubeProxy.Conntrack.UDPTimeoutSeconds != nil {
+		c.Network.KubeProxy.Conntrack.UDPTimeoutSeconds = u.Network.KubeProxy.Conntrack.UDPTimeoutSeconds
+	}
+	if u.Network.KubeProxy.Conntrack.UDPStreamTimeoutSeconds != nil {
+		c.Network.KubeProxy.Conntrack.UDPStreamTimeoutSeconds = u.Network.KubeProxy.Conntrack.UDPStreamTimeoutSeconds
+	}
 
 	if u.Etcd.MemoryLimitMB != 0 {
 		c.Etcd.MemoryLimitMB = u.Etcd.MemoryLimitMB
 	}
+	if u.Etcd.MemoryMaxMB != 0 {
+		c.Etcd.MemoryMaxMB = u.Etcd.MemoryMaxMB
+	}
+	if len(u.Etcd.External.Endpoints) != 0 {
+		c.Etcd.External.Endpoints = u.Etcd.External.Endpoints
+	}
+	if u.Etcd.External.CAFile != "" {
+		c.Etcd.External.CAFile = u.Etcd.External.CAFile
+	}
+	if u.Etcd.External.CertFile != "" {
+		c.Etcd.External.CertFile = u.Etcd.External.CertFile
+	}
+	if u.Etcd.External.KeyFile != "" {
+		c.Etcd.External.KeyFile = u.Etcd.External.KeyFile
+	}
+	if u.Etcd.Standby.Role != "" {
+		c.Etcd.Standby.Role = u.Etcd.Standby.Role
+	}
+	if u.Etcd.Standby.Peer != "" {
+		c.Etcd.Standby.Peer = u.Etcd.Standby.Peer
+	}
+	if u.Etcd.Tmpfs.Status != "" {
+		c.Etcd.Tmpfs.Status = u.Etcd.Tmpfs.Status
+	}
+	if u.Etcd.Tmpfs.PersistIntervalSeconds != nil {
+		c.Etcd.Tmpfs.PersistIntervalSeconds = ptr.To[int](*u.Etcd.Tmpfs.PersistIntervalSeconds)
+	}
+	if u.Etcd.Defragmentation.CheckIntervalSeconds != nil {
+		c.Etcd.Defragmentation.CheckIntervalSeconds = ptr.To[int](*u.Etcd.Defragmentation.CheckIntervalSeconds)
+		c.Etcd.DefragCheckFreq = time.Duration(*u.Etcd.Defragmentation.CheckIntervalSeconds) * time.Second
+	}
+	if u.Etcd.Defragmentation.MaxFragmentedPercentage != nil {
+		c.Etcd.Defragmentation.MaxFragmentedPercentage = ptr.To[int](*u.Etcd.Defragmentation.MaxFragmentedPercentage)
+		c.Etcd.MaxFragmentedPercentage = float64(*u.Etcd.Defragmentation.MaxFragmentedPercentage)
+	}
+	if u.Etcd.Defragmentation.MinDatabaseSizeMB != nil {
+		c.Etcd.Defragmentation.MinDatabaseSizeMB = ptr.To[int](*u.Etcd.Defragmentation.MinDatabaseSizeMB)
+		c.Etcd.MinDefragBytes = int64(*u.Etcd.Defragmentation.MinDatabaseSizeMB) * 1024 * 1024
+	}
 
 	if u.Node.HostnameOverride != "" {
 		c.Node.HostnameOverride = u.Node.HostnameOverride
@@ -203,6 +465,81 @@ func (c *Config) incorporateUserSettings(u *Config) {
 	if u.Node.NodeIPV6 != "" {
 		c.Node.NodeIPV6 = u.Node.NodeIPV6
 	}
+	if len(u.Node.NodeIPSelection.Interfaces) != 0 {
+		c.Node.NodeIPSelection.Interfaces = u.Node.NodeIPSelection.Interfaces
+	}
+	if len(u.Node.NodeIPSelection.ExcludeInterfaces) != 0 {
+		c.Node.NodeIPSelection.ExcludeInterfaces = u.Node.NodeIPSelection.ExcludeInterfaces
+	}
+	if len(u.Node.NodeIPSelection.PreferredSubnets) != 0 {
+		c.Node.NodeIPSelection.PreferredSubnets = u.Node.NodeIPSelection.PreferredSubnets
+	}
+	if u.Node.NodeIPSelection.PreferredIPFamily != "" {
+		c.Node.NodeIPSelection.PreferredIPFamily = u.Node.NodeIPSelection.PreferredIPFamily
+	}
+	if u.Node.Drain.TimeoutSeconds != nil {
+		c.Node.Drain.TimeoutSeconds = ptr.To[int](*u.Node.Drain.TimeoutSeconds)
+	}
+	if u.Node.GracefulShutdown.GracePeriodSeconds != nil {
+		c.Node.GracefulShutdown.GracePeriodSeconds = ptr.To[int](*u.Node.GracefulShutdown.GracePeriodSeconds)
+	}
+	if u.Node.GracefulShutdown.CriticalPodsGracePeriodSeconds != nil {
+		c.Node.GracefulShutdown.CriticalPodsGracePeriodSeconds = ptr.To[int](*u.Node.GracefulShutdown.CriticalPodsGracePeriodSeconds)
+	}
+	if len(u.Node.Eviction.Hard) != 0 {
+		c.Node.Eviction.Hard = u.Node.Eviction.Hard
+	}
+	if len(u.Node.Eviction.Soft) != 0 {
+		c.Node.Eviction.Soft = u.Node.Eviction.Soft
+	}
+	if len(u.Node.Eviction.SoftGracePeriod) != 0 {
+		c.Node.Eviction.SoftGracePeriod = u.Node.Eviction.SoftGracePeriod
+	}
+	if u.Node.Eviction.PressureTransitionPeriodSeconds != nil {
+		c.Node.Eviction.PressureTransitionPeriodSeconds = ptr.To[int](*u.Node.Eviction.PressureTransitionPeriodSeconds)
+	}
+	if u.Node.Eviction.MaxPodGracePeriodSeconds != nil {
+		c.Node.Eviction.MaxPodGracePeriodSeconds = ptr.To[int](*u.Node.Eviction.MaxPodGracePeriodSeconds)
+	}
+	if u.Node.TopologyManager.Policy != "" {
+		c.Node.TopologyManager.Policy = u.Node.TopologyManager.Policy
+	}
+	if u.Node.TopologyManager.Scope != "" {
+		c.Node.TopologyManager.Scope = u.Node.TopologyManager.Scope
+	}
+	if u.Node.CPUManager.Policy != "" {
+		c.Node.CPUManager.Policy = u.Node.CPUManager.Policy
+	}
+	if len(u.Node.CPUManager.PolicyOptions) != 0 {
+		c.Node.CPUManager.PolicyOptions = u.Node.CPUManager.PolicyOptions
+	}
+	if u.Node.CPUManager.ReservedSystemCPUs != "" {
+		c.Node.CPUManager.ReservedSystemCPUs = u.Node.CPUManager.ReservedSystemCPUs
+	}
+	if u.Node.MemoryManager.Policy != "" {
+		c.Node.MemoryManager.Policy = u.Node.MemoryManager.Policy
+	}
+	if len(u.Node.MemoryManager.ReservedMemory) != 0 {
+		c.Node.MemoryManager.ReservedMemory = u.Node.MemoryManager.ReservedMemory
+	}
+	if u.Node.ResourceReservation.AutoSizing != "" {
+		c.Node.ResourceReservation.AutoSizing = u.Node.ResourceReservation.AutoSizing
+	}
+	if len(u.Node.ResourceReservation.SystemReserved) != 0 {
+		c.Node.ResourceReservation.SystemReserved = u.Node.ResourceReservation.SystemReserved
+	}
+	if len(u.Node.ResourceReservation.KubeReserved) != 0 {
+		c.Node.ResourceReservation.KubeReserved = u.Node.ResourceReservation.KubeReserved
+	}
+	if u.Node.Swap.Behavior != "" {
+		c.Node.Swap.Behavior = u.Node.Swap.Behavior
+	}
+	if u.Startup.TimeoutSeconds != nil {
+		c.Startup.TimeoutSeconds = ptr.To[int](*u.Startup.TimeoutSeconds)
+	}
+	if len(u.Startup.ServiceTimeoutSeconds) != 0 {
+		c.Startup.ServiceTimeoutSeconds = u.Startup.ServiceTimeoutSeconds
+	}
 	if len(u.ApiServer.SubjectAltNames) != 0 {
 		c.ApiServer.SubjectAltNames = u.ApiServer.SubjectAltNames
 	}
@@ -228,13 +565,22 @@ func (c *Config) incorporateUserSettings(u *Config) {
 	if u.Debugging.LogLevel != "" {
 		c.Debugging.LogLevel = u.Debugging.LogLevel
 	}
+	if u.Debugging.Profiling != "" {
+		c.Debugging.Profiling = u.Debugging.Profiling
+	}
 
 	// Check for nil instead of an empty list because if a user
 	// provides a list but it is empty we want to treat that as
 	// disabling the manifest loader.
+	if u.Manifests.ConflictPolicy != "" {
+		c.Manifests.ConflictPolicy = u.Manifests.ConflictPolicy
+	}
 	if u.Manifests.KustomizePaths != nil {
 		c.Manifests.KustomizePaths = u.Manifests.KustomizePaths
 	}
+	if u.Manifests.Reconcile.IntervalSeconds != nil {
+		c.Manifests.Reconcile.IntervalSeconds = ptr.To[int](*u.Manifests.Reconcile.IntervalSeconds)
+	}
 
 	if len(u.Ingress.Status) != 0 {
 		c.Ingress.Status = u.Ingress.Status
@@ -244,6 +590,14 @@ func (c *Config) incorporateUserSettings(u *Config) {
 		c.Ingress.AdmissionPolicy.NamespaceOwnership = u.Ingress.AdmissionPolicy.NamespaceOwnership
 	}
 
+	if len(u.Ingress.AdmissionPolicy.WildcardPolicy) != 0 {
+		c.Ingress.AdmissionPolicy.WildcardPolicy = u.Ingress.AdmissionPolicy.WildcardPolicy
+	}
+
+	if u.Ingress.Domain != "" {
+		c.Ingress.Domain = u.Ingress.Domain
+	}
+
 	if u.Ingress.Ports.Http != nil {
 		c.Ingress.Ports.Http = ptr.To[int](*u.Ingress.Ports.Http)
 	}
@@ -258,6 +612,66 @@ func (c *Config) incorporateUserSettings(u *Config) {
 		})
 	}
 
+	if u.ApiServer.Encryption.Provider != "" {
+		c.ApiServer.Encryption.Provider = u.ApiServer.Encryption.Provider
+	}
+	if u.ApiServer.Encryption.KeyRotationDays != nil {
+		c.ApiServer.Encryption.KeyRotationDays = ptr.To[int](*u.ApiServer.Encryption.KeyRotationDays)
+	}
+	if u.ApiServer.Encryption.KMS.Name != "" {
+		c.ApiServer.Encryption.KMS.Name = u.ApiServer.Encryption.KMS.Name
+	}
+	if u.ApiServer.Encryption.KMS.Endpoint != "" {
+		c.ApiServer.Encryption.KMS.Endpoint = u.ApiServer.Encryption.KMS.Endpoint
+	}
+	if u.ApiServer.Encryption.KMS.TimeoutSeconds != nil {
+		c.ApiServer.Encryption.KMS.TimeoutSeconds = ptr.To[int](*u.ApiServer.Encryption.KMS.TimeoutSeconds)
+	}
+	if u.ApiServer.Authorization.Webhook.KubeconfigPath != "" {
+		c.ApiServer.Authorization.Webhook.KubeconfigPath = u.ApiServer.Authorization.Webhook.KubeconfigPath
+	}
+	if u.ApiServer.Authorization.Webhook.CacheAuthorizedTTLSeconds != nil {
+		c.ApiServer.Authorization.Webhook.CacheAuthorizedTTLSeconds = ptr.To[int](*u.ApiServer.Authorization.Webhook.CacheAuthorizedTTLSeconds)
+	}
+	if u.ApiServer.Authorization.Webhook.CacheUnauthorizedTTLSeconds != nil {
+		c.ApiServer.Authorization.Webhook.CacheUnauthorizedTTLSeconds = ptr.To[int](*u.ApiServer.Authorization.Webhook.CacheUnauthorizedTTLSeconds)
+	}
+	if u.ApiServer.ServiceAccount.Issuer != "" {
+		c.ApiServer.ServiceAccount.Issuer = u.ApiServer.ServiceAccount.Issuer
+	}
+	if u.ApiServer.ServiceAccount.JWKSURI != "" {
+		c.ApiServer.ServiceAccount.JWKSURI = u.ApiServer.ServiceAccount.JWKSURI
+	}
+	if u.ApiServer.ServiceAccount.Publish.Directory != "" {
+		c.ApiServer.ServiceAccount.Publish.Directory = u.ApiServer.ServiceAccount.Publish.Directory
+	}
+	if u.ApiServer.ServiceAccount.Publish.URL != "" {
+		c.ApiServer.ServiceAccount.Publish.URL = u.ApiServer.ServiceAccount.Publish.URL
+	}
+	if u.ApiServer.ServiceAccount.Publish.CAFile != "" {
+		c.ApiServer.ServiceAccount.Publish.CAFile = u.ApiServer.ServiceAccount.Publish.CAFile
+	}
+	if u.ApiServer.ServiceAccount.Publish.BearerTokenFile != "" {
+		c.ApiServer.ServiceAccount.Publish.BearerTokenFile = u.ApiServer.ServiceAccount.Publish.BearerTokenFile
+	}
+	if u.ApiServer.Tuning.MaxRequestsInflight != nil {
+		c.ApiServer.Tuning.MaxRequestsInflight = ptr.To[int](*u.ApiServer.Tuning.MaxRequestsInflight)
+	}
+	if u.ApiServer.Tuning.MaxMutatingRequestsInflight != nil {
+		c.ApiServer.Tuning.MaxMutatingRequestsInflight = ptr.To[int](*u.ApiServer.Tuning.MaxMutatingRequestsInflight)
+	}
+	if len(u.ApiServer.Tuning.WatchCacheSizes) != 0 {
+		c.ApiServer.Tuning.WatchCacheSizes = u.ApiServer.Tuning.WatchCacheSizes
+	}
+	if u.ApiServer.Tuning.GoAwayChance != nil {
+		c.ApiServer.Tuning.GoAwayChance = ptr.To[float64](*u.ApiServer.Tuning.GoAwayChance)
+	}
+	if len(u.ApiServer.Tuning.DisabledAPIs) != 0 {
+		c.ApiServer.Tuning.DisabledAPIs = u.ApiServer.Tuning.DisabledAPIs
+	}
+	if u.ApiServer.PodSecurity.Mode != "" {
+		c.ApiServer.PodSecurity.Mode = u.ApiServer.PodSecurity.Mode
+	}
 	if len(u.ApiServer.NamedCertificates) != 0 {
 		c.ApiServer.NamedCertificates = u.ApiServer.NamedCertificates
 	}
@@ -271,12 +685,246 @@ func (c *Config) incorporateUserSettings(u *Config) {
 	if u.Kubelet != nil {
 		c.Kubelet = u.Kubelet
 	}
+	if len(u.LoadBalancer.AddressPools) != 0 {
+		c.LoadBalancer.AddressPools = u.LoadBalancer.AddressPools
+	}
+	if u.LoadBalancer.FirewalldZone != "" {
+		c.LoadBalancer.FirewalldZone = u.LoadBalancer.FirewalldZone
+	}
+	if u.LoadBalancer.L2Announcement != "" {
+		c.LoadBalancer.L2Announcement = u.LoadBalancer.L2Announcement
+	}
+	if u.Metrics.ListenAddress != "" {
+		c.Metrics.ListenAddress = u.Metrics.ListenAddress
+	}
+	if u.Metrics.KubeStateMetrics.Status != "" {
+		c.Metrics.KubeStateMetrics.Status = u.Metrics.KubeStateMetrics.Status
+	}
+	if u.Components.ServiceCA.Status != "" {
+		c.Components.ServiceCA.Status = u.Components.ServiceCA.Status
+	}
+	if u.Components.ServiceCA.ValidityDays != 0 {
+		c.Components.ServiceCA.ValidityDays = u.Components.ServiceCA.ValidityDays
+	}
+	if u.Tracing.Endpoint != "" {
+		c.Tracing.Endpoint = u.Tracing.Endpoint
+	}
+	if u.Tracing.SamplingRatePerMillion != nil {
+		c.Tracing.SamplingRatePerMillion = ptr.To[int](*u.Tracing.SamplingRatePerMillion)
+	}
+	if u.Logging.Format != "" {
+		c.Logging.Format = u.Logging.Format
+	}
+	if u.Logging.Events.Status != "" {
+		c.Logging.Events.Status = u.Logging.Events.Status
+	}
+	if u.Logging.Events.MaxPerMinute != nil {
+		c.Logging.Events.MaxPerMinute = ptr.To[int](*u.Logging.Events.MaxPerMinute)
+	}
+	if u.Logging.Forwarding.Status != "" {
+		c.Logging.Forwarding.Status = u.Logging.Forwarding.Status
+	}
+	if u.Logging.Forwarding.Endpoint != "" {
+		c.Logging.Forwarding.Endpoint = u.Logging.Forwarding.Endpoint
+	}
+	if u.Logging.Forwarding.Sources != nil {
+		c.Logging.Forwarding.Sources = u.Logging.Forwarding.Sources
+	}
+	if u.Logging.Forwarding.CAFile != "" {
+		c.Logging.Forwarding.CAFile = u.Logging.Forwarding.CAFile
+	}
+	if u.Logging.Forwarding.BufferSizeMB != nil {
+		c.Logging.Forwarding.BufferSizeMB = ptr.To[int](*u.Logging.Forwarding.BufferSizeMB)
+	}
+
+	if u.HealthCheck.Workloads != nil {
+		c.HealthCheck.Workloads = u.HealthCheck.Workloads
+	}
+	if u.Upgrade.RestoreOnRollback != "" {
+		c.Upgrade.RestoreOnRollback = u.Upgrade.RestoreOnRollback
+	}
+	if u.VirtualIP.Status != "" {
+		c.VirtualIP.Status = u.VirtualIP.Status
+	}
+	if u.VirtualIP.Address != "" {
+		c.VirtualIP.Address = u.VirtualIP.Address
+	}
+	if u.VirtualIP.Peer != "" {
+		c.VirtualIP.Peer = u.VirtualIP.Peer
+	}
+	if u.VirtualIP.Interface != "" {
+		c.VirtualIP.Interface = u.VirtualIP.Interface
+	}
+	if u.VirtualIP.Priority != 0 {
+		c.VirtualIP.Priority = u.VirtualIP.Priority
+	}
+	if u.VirtualIP.Port != 0 {
+		c.VirtualIP.Port = u.VirtualIP.Port
+	}
+	if u.FleetAPI.Status != "" {
+		c.FleetAPI.Status = u.FleetAPI.Status
+	}
+	if u.FleetAPI.Port != 0 {
+		c.FleetAPI.Port = u.FleetAPI.Port
+	}
+	if u.FleetAPI.ClientCAFile != "" {
+		c.FleetAPI.ClientCAFile = u.FleetAPI.ClientCAFile
+	}
+	if len(u.FleetAPI.AllowedClientNames) != 0 {
+		c.FleetAPI.AllowedClientNames = u.FleetAPI.AllowedClientNames
+	}
+	if u.FleetAPI.CertFile != "" {
+		c.FleetAPI.CertFile = u.FleetAPI.CertFile
+	}
+	if u.FleetAPI.KeyFile != "" {
+		c.FleetAPI.KeyFile = u.FleetAPI.KeyFile
+	}
+	if u.Firewall.Status != "" {
+		c.Firewall.Status = u.Firewall.Status
+	}
+	if u.Firewall.Backend != "" {
+		c.Firewall.Backend = u.Firewall.Backend
+	}
+	if u.Firewall.Zone != "" {
+		c.Firewall.Zone = u.Firewall.Zone
+	}
+	if u.Firewall.TrustedZone != "" {
+		c.Firewall.TrustedZone = u.Firewall.TrustedZone
+	}
+	if len(u.Firewall.AllowedPorts) != 0 {
+		c.Firewall.AllowedPorts = u.Firewall.AllowedPorts
+	}
+	if u.Certificates.BackdateMinutes != nil {
+		c.Certificates.BackdateMinutes = ptr.To[int](*u.Certificates.BackdateMinutes)
+	}
+	if u.Certificates.ShortLivedValidityDays != nil {
+		c.Certificates.ShortLivedValidityDays = ptr.To[int](*u.Certificates.ShortLivedValidityDays)
+	}
+	if u.Certificates.LongLivedValidityDays != nil {
+		c.Certificates.LongLivedValidityDays = ptr.To[int](*u.Certificates.LongLivedValidityDays)
+	}
+	if u.Certificates.ClockSkewPolicy != "" {
+		c.Certificates.ClockSkewPolicy = u.Certificates.ClockSkewPolicy
+	}
+	if u.Certificates.ExternalCA.CertFile != "" {
+		c.Certificates.ExternalCA.CertFile = u.Certificates.ExternalCA.CertFile
+	}
+	if u.Certificates.ExternalCA.KeyFile != "" {
+		c.Certificates.ExternalCA.KeyFile = u.Certificates.ExternalCA.KeyFile
+	}
+	if u.Certificates.PKCS11.Module != "" {
+		c.Certificates.PKCS11.Module = u.Certificates.PKCS11.Module
+	}
+	if u.Certificates.PKCS11.TokenLabel != "" {
+		c.Certificates.PKCS11.TokenLabel = u.Certificates.PKCS11.TokenLabel
+	}
+	if u.Certificates.PKCS11.PINFile != "" {
+		c.Certificates.PKCS11.PINFile = u.Certificates.PKCS11.PINFile
+	}
+	if u.Certificates.PKCS11.KeyLabel != "" {
+		c.Certificates.PKCS11.KeyLabel = u.Certificates.PKCS11.KeyLabel
+	}
+	if len(u.Certificates.CSRApproval.Rules) != 0 {
+		c.Certificates.CSRApproval.Rules = u.Certificates.CSRApproval.Rules
+	}
+	if u.Images.PullSecretFile != "" {
+		c.Images.PullSecretFile = u.Images.PullSecretFile
+	}
+	if len(u.Images.Mirrors) != 0 {
+		c.Images.Mirrors = u.Images.Mirrors
+	}
+	if u.Images.Preload != "" {
+		c.Images.Preload = u.Images.Preload
+	}
+	if u.Images.GarbageCollection.HighThresholdPercent != nil {
+		c.Images.GarbageCollection.HighThresholdPercent = ptr.To[int](*u.Images.GarbageCollection.HighThresholdPercent)
+	}
+	if u.Images.GarbageCollection.LowThresholdPercent != nil {
+		c.Images.GarbageCollection.LowThresholdPercent = ptr.To[int](*u.Images.GarbageCollection.LowThresholdPercent)
+	}
+	if u.Images.GarbageCollection.MinimumAgeSeconds != nil {
+		c.Images.GarbageCollection.MinimumAgeSeconds = ptr.To[int](*u.Images.GarbageCollection.MinimumAgeSeconds)
+	}
+	if u.Images.GarbageCollection.MaximumAgeSeconds != nil {
+		c.Images.GarbageCollection.MaximumAgeSeconds = ptr.To[int](*u.Images.GarbageCollection.MaximumAgeSeconds)
+	}
+	if len(u.Images.GarbageCollection.ProtectedImages) != 0 {
+		c.Images.GarbageCollection.ProtectedImages = u.Images.GarbageCollection.ProtectedImages
+	}
+	if len(u.Images.GarbageCollection.ProtectedLabels) != 0 {
+		c.Images.GarbageCollection.ProtectedLabels = u.Images.GarbageCollection.ProtectedLabels
+	}
+	if u.Images.LocalRegistry.Status != "" {
+		c.Images.LocalRegistry.Status = u.Images.LocalRegistry.Status
+	}
+	if u.Images.LocalRegistry.Port != 0 {
+		c.Images.LocalRegistry.Port = u.Images.LocalRegistry.Port
+	}
+	if u.DiskMaintenance.Status != "" {
+		c.DiskMaintenance.Status = u.DiskMaintenance.Status
+	}
+	if u.DiskMaintenance.MarginPercent != nil {
+		c.DiskMaintenance.MarginPercent = ptr.To[int](*u.DiskMaintenance.MarginPercent)
+	}
+	if u.DiskMaintenance.CheckIntervalSeconds != nil {
+		c.DiskMaintenance.CheckIntervalSeconds = ptr.To[int](*u.DiskMaintenance.CheckIntervalSeconds)
+	}
+	if u.DiskMaintenance.KeepBackups != nil {
+		c.DiskMaintenance.KeepBackups = ptr.To[int](*u.DiskMaintenance.KeepBackups)
+	}
+	if u.CRIO.PauseImage != "" {
+		c.CRIO.PauseImage = u.CRIO.PauseImage
+	}
+	if u.CRIO.PidsLimit != 0 {
+		c.CRIO.PidsLimit = u.CRIO.PidsLimit
+	}
+	if u.CRIO.LogSizeMax != "" {
+		c.CRIO.LogSizeMax = u.CRIO.LogSizeMax
+	}
+	if len(u.CRIO.RuntimeClasses) != 0 {
+		c.CRIO.RuntimeClasses = u.CRIO.RuntimeClasses
+	}
+	if u.MDNS.Status != "" {
+		c.MDNS.Status = u.MDNS.Status
+	}
+	if u.MDNS.Domain != "" {
+		c.MDNS.Domain = u.MDNS.Domain
+	}
+	if len(u.MDNS.Hostnames) != 0 {
+		c.MDNS.Hostnames = u.MDNS.Hostnames
+	}
+	if len(u.MDNS.Interfaces) != 0 {
+		c.MDNS.Interfaces = u.MDNS.Interfaces
+	}
+	if len(u.MDNS.ExcludeInterfaces) != 0 {
+		c.MDNS.ExcludeInterfaces = u.MDNS.ExcludeInterfaces
+	}
+	if u.Scheduler.Profiles != nil {
+		c.Scheduler.Profiles = u.Scheduler.Profiles
+	}
+	if len(u.ControllerManager.Controllers) != 0 {
+		c.ControllerManager.Controllers = u.ControllerManager.Controllers
+	}
+	if u.ControllerManager.TerminatedPodGCThreshold != nil {
+		c.ControllerManager.TerminatedPodGCThreshold = ptr.To[int](*u.ControllerManager.TerminatedPodGCThreshold)
+	}
+	if u.ControllerManager.NodeMonitorGracePeriodSeconds != nil {
+		c.ControllerManager.NodeMonitorGracePeriodSeconds = ptr.To[int](*u.ControllerManager.NodeMonitorGracePeriodSeconds)
+	}
 }
 
 // updateComputedValues examins the existing settings and converts any
 // inputs to more easily consumable units or fills in any defaults
 // computed based on the values of other settings.
 func (c *Config) updateComputedValues() error {
+	if policy := c.Node.NodeIPSelection.Policy(); c.UserNodeIP() == "" && !policy.IsZero() {
+		nodeIP, err := util.GetHostIP("", policy)
+		if err != nil {
+			return fmt.Errorf("failed to select host IP: %w", err)
+		}
+		c.Node.NodeIP = nodeIP
+	}
+
 	if len(c.Network.ClusterNetwork) == 0 {
 		defaultClusterNetwork := "10.42.0.0/16"
 		ip := net.ParseIP(c.Node.NodeIP)
@@ -294,6 +942,16 @@ func (c *Config) updateComputedValues() error {
 		c.Network.ServiceNetwork = []string{defaultServiceNetwork}
 	}
 
+	if c.IsIPv6Only() && c.UserNodeIP() == "" && net.ParseIP(c.Node.NodeIP).To4() != nil {
+		// The default node IP is an IPv4 address as soon as the host has
+		// one, which is not reachable in an IPv6 single-stack cluster.
+		ip, err := util.GetHostIPv6("")
+		if err != nil {
+			return fmt.Errorf("unable to determine ipv6 host address: %v", err)
+		}
+		c.Node.NodeIP = ip
+	}
+
 	if c.IsIPv4() && c.IsIPv6() && len(c.Node.NodeIPV6) == 0 {
 		// NodeIPv6 is a dual-stack only parameter that needs to be configured.
 		// When the user does not provide a value, MicroShift needs to take
@@ -308,6 +966,10 @@ func (c *Config) updateComputedValues() error {
 		c.Node.NodeIPV6 = ip
 	}
 
+	if c.Ingress.Domain == "" {
+		c.Ingress.Domain = "apps." + c.DNS.BaseDomain
+	}
+
 	clusterDNS, err := c.computeClusterDNS()
 	if err != nil {
 		return err
@@ -348,6 +1010,12 @@ func (c *Config) updateComputedValues() error {
 		c.ApiServer.AdvertiseAddresses = append(c.ApiServer.AdvertiseAddresses, ip)
 	}
 
+	// The API server is reached at the virtual IP on either node of the
+	// pair, whichever holds it.
+	if c.VirtualIP.Status == VirtualIPStatusEnabled && !stringSliceContains(c.ApiServer.SubjectAltNames, c.VirtualIP.Address) {
+		c.ApiServer.SubjectAltNames = append(c.ApiServer.SubjectAltNames, c.VirtualIP.Address)
+	}
+
 	c.computeLoggingSetting()
 
 	return nil
@@ -375,6 +1043,18 @@ func (c *Config) validate() error {
 	if !c.Network.validCNIPlugin() {
 		return fmt.Errorf("invalid cni plugin for network configuration  %q", c.Network.CNIPlugin)
 	}
+	if err := c.Network.validateMultus(); err != nil {
+		return err
+	}
+	if err := c.Network.KubeProxy.validate(); err != nil {
+		return err
+	}
+	if err := c.Network.validateEgress(); err != nil {
+		return err
+	}
+	if err := c.Network.validateNodePortAddresses(); err != nil {
+		return err
+	}
 
 	//nolint:nestif // extracting the nested ifs will just increase the complexity of the if expressions as validation expands
 	if len(c.ApiServer.SubjectAltNames) > 0 {
@@ -394,9 +1074,9 @@ func (c *Config) validate() error {
 		if err != nil {
 			return fmt.Errorf("failed to parse cluster URL: %v", err)
 		}
-		if u.Hostname() == "localhost" || u.Hostname() == "127.0.0.1" {
-			if stringSliceContains(c.ApiServer.SubjectAltNames, "localhost", "127.0.0.1") {
-				return fmt.Errorf("subjectAltNames must not contain localhost, 127.0.0.1")
+		if u.Hostname() == "localhost" || u.Hostname() == "127.0.0.1" || u.Hostname() == "::1" {
+			if stringSliceContains(c.ApiServer.SubjectAltNames, "localhost", "127.0.0.1", "::1") {
+				return fmt.Errorf("subjectAltNames must not contain localhost, 127.0.0.1, ::1")
 			}
 		} else {
 			if stringSliceContains(c.ApiServer.SubjectAltNames, c.Node.NodeIP) {
@@ -432,6 +1112,33 @@ func (c *Config) validate() error {
 			c.Etcd.MemoryLimitMB, EtcdMinimumMemoryLimit,
 		)
 	}
+	if c.Etcd.MemoryMaxMB > 0 && c.Etcd.MemoryMaxMB < EtcdMinimumMemoryLimit {
+		return fmt.Errorf("etcd.memoryMaxMB value %d is below the minimum allowed %d",
+			c.Etcd.MemoryMaxMB, EtcdMinimumMemoryLimit,
+		)
+	}
+	if c.Etcd.MemoryMaxMB > 0 && c.Etcd.MemoryMaxMB < c.Etcd.MemoryLimitMB {
+		return fmt.Errorf("etcd.memoryMaxMB value %d is below etcd.memoryLimitMB value %d",
+			c.Etcd.MemoryMaxMB, c.Etcd.MemoryLimitMB,
+		)
+	}
+
+	if err := c.Etcd.Defragmentation.validate(); err != nil {
+		return err
+	}
+	if err := c.Etcd.External.validate(); err != nil {
+		return err
+	}
+	if err := c.Etcd.validateStandby(c.Node.NodeIP); err != nil {
+		return err
+	}
+	if err := c.Etcd.validateTmpfs(); err != nil {
+		return err
+	}
+	// The pages of the tmpfs are charged to the cgroup of etcd.
+	if c.Etcd.Tmpfs.IsEnabled() && c.Etcd.MemoryMaxMB > 0 {
+		c.AddWarning("etcd.memoryMaxMB also limits the etcd database in etcd.tmpfs, which etcd is killed for exceeding")
+	}
 
 	if c.ApiServer.SkipInterface {
 		err := checkAdvertiseAddressConfigured(c.ApiServer.AdvertiseAddresses[0])
@@ -452,6 +1159,102 @@ func (c *Config) validate() error {
 		return fmt.Errorf("unsupported namespaceOwnership value %v", c.Ingress.AdmissionPolicy.NamespaceOwnership)
 	}
 
+	switch c.Ingress.AdmissionPolicy.WildcardPolicy {
+	case WildcardPolicyDisallowed, WildcardPolicyAllowed:
+	default:
+		return fmt.Errorf("unsupported wildcardPolicy value %v", c.Ingress.AdmissionPolicy.WildcardPolicy)
+	}
+
+	if errs := validation.IsDNS1123Subdomain(c.Ingress.Domain); len(errs) != 0 {
+		return fmt.Errorf("ingress.domain %q is not a valid DNS subdomain: %s", c.Ingress.Domain, strings.Join(errs, ", "))
+	}
+
+	if err := c.Node.NodeIPSelection.validate(); err != nil {
+		return err
+	}
+	if c.Node.Drain.TimeoutSeconds != nil && *c.Node.Drain.TimeoutSeconds < 0 {
+		return fmt.Errorf("node.drain.timeoutSeconds must not be negative, got %d", *c.Node.Drain.TimeoutSeconds)
+	}
+	if err := c.Node.GracefulShutdown.validate(); err != nil {
+		return err
+	}
+	if err := c.Node.Eviction.validate(); err != nil {
+		return err
+	}
+	if err := c.Node.TopologyManager.validate(); err != nil {
+		return err
+	}
+	if err := c.Node.CPUManager.validate(); err != nil {
+		return err
+	}
+	if err := c.Node.MemoryManager.validate(); err != nil {
+		return err
+	}
+	if err := c.Node.ResourceReservation.validate(); err != nil {
+		return err
+	}
+	if err := c.Node.Swap.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Manifests.validate(); err != nil {
+		return err
+	}
+	if err := c.LoadBalancer.validate(); err != nil {
+		return err
+	}
+	if err := c.Metrics.validate(); err != nil {
+		return err
+	}
+	if err := c.Components.validate(); err != nil {
+		return err
+	}
+	if err := c.Tracing.validate(); err != nil {
+		return err
+	}
+	if err := c.Logging.validate(); err != nil {
+		return err
+	}
+	if err := c.Debugging.validate(); err != nil {
+		return err
+	}
+	if err := c.HealthCheck.validate(); err != nil {
+		return err
+	}
+	if err := c.Upgrade.validate(); err != nil {
+		return err
+	}
+	if err := c.VirtualIP.validate(c.Node.NodeIP); err != nil {
+		return err
+	}
+	if err := c.FleetAPI.validate(); err != nil {
+		return err
+	}
+	if err := c.Firewall.validate(); err != nil {
+		return err
+	}
+	if err := c.Certificates.validate(); err != nil {
+		return err
+	}
+	if err := c.Images.validate(); err != nil {
+		return err
+	}
+	if err := c.DiskMaintenance.validate(); err != nil {
+		return err
+	}
+	if err := c.CRIO.validate(); err != nil {
+		return err
+	}
+	if err := c.MDNS.validate(); err != nil {
+		return err
+	}
+	if err := c.Startup.validate(); err != nil {
+		return err
+	}
+	if err := c.ControllerManager.validate(); err != nil {
+		return err
+	}
+
 	if c.Ingress.Ports.Http != nil && (*c.Ingress.Ports.Http < 1 || *c.Ingress.Ports.Http > math.MaxUint16) {
 		return fmt.Errorf("unsupported value %v for ingress.ports.http", *c.Ingress.Ports.Http)
 	}
@@ -467,6 +1270,25 @@ func (c *Config) validate() error {
 	if err := validateAuditLogConfig(c.ApiServer.AuditLog); err != nil {
 		return fmt.Errorf("error validating apiserver.auditLog:\n%w", err)
 	}
+	if err := c.ApiServer.Encryption.validate(); err != nil {
+		return err
+	}
+	if err := c.ApiServer.Authorization.Webhook.validate(); err != nil {
+		return err
+	}
+	if err := c.ApiServer.ServiceAccount.validate(); err != nil {
+		return err
+	}
+	if err := c.ApiServer.Tuning.validate(); err != nil {
+		return err
+	}
+	// kube-state-metrics fails to start without the jobs it reports on.
+	if c.Metrics.KubeStateMetrics.Status == KubeStateMetricsStatusManaged && c.ApiServer.Tuning.IsAPIDisabled("batch/v1", "jobs") {
+		return fmt.Errorf("apiServer.tuning.disabledAPIs must not disable batch/v1 jobs when metrics.kubeStateMetrics.status is %s", KubeStateMetricsStatusManaged)
+	}
+	if err := c.ApiServer.PodSecurity.validate(); err != nil {
+		return err
+	}
 
 	if err := validateNodeIPv6Address(c.Node.NodeIPV6, c.IsIPv4() && c.IsIPv6()); err != nil {
 		return fmt.Errorf("error validating node.nodeIPv6: %w", err)
@@ -519,6 +1341,20 @@ func (c Config) IsIPv6() bool {
 	return false
 }
 
+// IsIPv6Only returns whether the cluster is IPv6 single-stack.
+func (c Config) IsIPv6Only() bool {
+	return c.IsIPv6() && !c.IsIPv4()
+}
+
+// UnspecifiedAddress returns the address the servers listen on to accept
+// the connections to all the addresses of the host.
+func (c Config) UnspecifiedAddress() string {
+	if c.IsIPv6Only() {
+		return "::"
+	}
+	return "0.0.0.0"
+}
+
 var allHostnames []string
 
 func getAllHostnames() ([]string, error) {
@@ -728,6 +1564,9 @@ func validateNetworkStack(cfg *Config) error {
 	if netutils.IPFamilyOfString(cfg.ApiServer.AdvertiseAddress) != netutils.IPFamilyOfCIDRString(cfg.Network.ServiceNetwork[0]) {
 		return fmt.Errorf("invalid IP family in apiServer.AdvertiseAddress: does not match first network.ServiceNetwork IP family")
 	}
+	if cfg.IsIPv6Only() && netutils.IPFamilyOfString(cfg.Node.NodeIP) != netutils.IPv6 {
+		return fmt.Errorf("invalid IP family in node.nodeIP: must be an IPv6 address in an IPv6 single-stack cluster")
+	}
 	return nil
 }
 
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"k8s.io/utils/ptr"
@@ -76,6 +77,7 @@ func TestGetActiveConfigFromYAML(t *testing.T) {
 			expected: func() *Config {
 				c := mkDefaultConfig()
 				c.DNS.BaseDomain = "test-example.com"
+				c.Ingress.Domain = "apps.test-example.com"
 				return c
 			}(),
 		},
@@ -114,6 +116,312 @@ func TestGetActiveConfigFromYAML(t *testing.T) {
 				return c
 			}(),
 		},
+		{
+			name: "node-drain",
+			config: dedent(`
+            node:
+              drain:
+                timeoutSeconds: 60
+            `),
+			expected: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.Drain.TimeoutSeconds = ptr.To[int](60)
+				return c
+			}(),
+		},
+		{
+			name: "node-resource-reservation",
+			config: dedent(`
+            node:
+              resourceReservation:
+                autoSizing: Enabled
+                systemReserved:
+                  ephemeral-storage: 1Gi
+                kubeReserved:
+                  memory: 500Mi
+            `),
+			expected: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.ResourceReservation.AutoSizing = AutoSizingEnabled
+				c.Node.ResourceReservation.SystemReserved = map[string]string{"ephemeral-storage": "1Gi"}
+				c.Node.ResourceReservation.KubeReserved = map[string]string{"memory": "500Mi"}
+				return c
+			}(),
+		},
+		{
+			name: "node-graceful-shutdown",
+			config: dedent(`
+            node:
+              gracefulShutdown:
+                gracePeriodSeconds: 120
+                criticalPodsGracePeriodSeconds: 30
+            `),
+			expected: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.GracefulShutdown.GracePeriodSeconds = ptr.To[int](120)
+				c.Node.GracefulShutdown.CriticalPodsGracePeriodSeconds = ptr.To[int](30)
+				return c
+			}(),
+		},
+		{
+			name: "startup",
+			config: dedent(`
+            startup:
+              timeoutSeconds: 600
+              serviceTimeoutSeconds:
+                etcd: 300
+            `),
+			expected: func() *Config {
+				c := mkDefaultConfig()
+				c.Startup.TimeoutSeconds = ptr.To[int](600)
+				c.Startup.ServiceTimeoutSeconds = map[string]int{"etcd": 300}
+				return c
+			}(),
+		},
+		{
+			name: "api-server-encryption",
+			config: dedent(`
+            apiServer:
+              encryption:
+                provider: aesgcm
+                keyRotationDays: 30
+            `),
+			expected: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.Encryption.Provider = EncryptionProviderAESGCM
+				c.ApiServer.Encryption.KeyRotationDays = ptr.To[int](30)
+				return c
+			}(),
+		},
+		{
+			name: "api-server-encryption-kms",
+			config: dedent(`
+            apiServer:
+              encryption:
+                provider: kms
+                kms:
+                  name: tpm
+                  endpoint: unix:///run/kmsplugin/socket.sock
+                  timeoutSeconds: 10
+            `),
+			expected: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.Encryption.Provider = EncryptionProviderKMS
+				c.ApiServer.Encryption.KMS.Name = "tpm"
+				c.ApiServer.Encryption.KMS.Endpoint = "unix:///run/kmsplugin/socket.sock"
+				c.ApiServer.Encryption.KMS.TimeoutSeconds = ptr.To[int](10)
+				return c
+			}(),
+		},
+		{
+			name: "api-server-authorization-webhook",
+			config: dedent(`
+            apiServer:
+              authorization:
+                webhook:
+                  kubeconfigPath: /etc/microshift/authz-webhook.kubeconfig
+                  cacheAuthorizedTTLSeconds: 60
+                  cacheUnauthorizedTTLSeconds: 0
+            `),
+			expected: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.Authorization.Webhook.KubeconfigPath = "/etc/microshift/authz-webhook.kubeconfig"
+				c.ApiServer.Authorization.Webhook.CacheAuthorizedTTLSeconds = ptr.To[int](60)
+				c.ApiServer.Authorization.Webhook.CacheUnauthorizedTTLSeconds = ptr.To[int](0)
+				return c
+			}(),
+		},
+		{
+			name: "api-server-service-account",
+			config: dedent(`
+            apiServer:
+              serviceAccount:
+                issuer: https://oidc.example.com/edge-1
+                jwksURI: https://oidc.example.com/edge-1/openid/v1/jwks
+                publish:
+                  directory: /var/www/oidc
+                  url: https://upload.example.com/edge-1
+                  caFile: /etc/pki/upload-ca.crt
+                  bearerTokenFile: /etc/microshift/upload-token
+            `),
+			expected: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.ServiceAccount.Issuer = "https://oidc.example.com/edge-1"
+				c.ApiServer.ServiceAccount.JWKSURI = "https://oidc.example.com/edge-1/openid/v1/jwks"
+				c.ApiServer.ServiceAccount.Publish = ServiceAccountPublish{
+					Directory:       "/var/www/oidc",
+					URL:             "https://upload.example.com/edge-1",
+					CAFile:          "/etc/pki/upload-ca.crt",
+					BearerTokenFile: "/etc/microshift/upload-token",
+				}
+				return c
+			}(),
+		},
+		{
+			name: "api-server-tuning",
+			config: dedent(`
+            apiServer:
+              tuning:
+                maxRequestsInflight: 100
+                maxMutatingRequestsInflight: 50
+                watchCacheSizes:
+                  - secrets#0
+                  - deployments.apps#50
+                goAwayChance: 0.001
+                disabledAPIs:
+                  - batch/v1/cronjobs
+                  - autoscaling/v2
+            `),
+			expected: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.Tuning.MaxRequestsInflight = ptr.To[int](100)
+				c.ApiServer.Tuning.MaxMutatingRequestsInflight = ptr.To[int](50)
+				c.ApiServer.Tuning.WatchCacheSizes = []string{"secrets#0", "deployments.apps#50"}
+				c.ApiServer.Tuning.GoAwayChance = ptr.To[float64](0.001)
+				c.ApiServer.Tuning.DisabledAPIs = []string{"batch/v1/cronjobs", "autoscaling/v2"}
+				return c
+			}(),
+		},
+		{
+			name: "api-server-pod-security",
+			config: dedent(`
+            apiServer:
+              podSecurity:
+                mode: LogOnly
+            `),
+			expected: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.PodSecurity.Mode = PodSecurityModeLogOnly
+				return c
+			}(),
+		},
+		{
+			name: "scheduler-profiles",
+			config: dedent(`
+            scheduler:
+              profiles:
+                - schedulerName: default-scheduler
+                  pluginConfig:
+                    - name: NodeResourcesFit
+                      args:
+                        scoringStrategy:
+                          type: MostAllocated
+            `),
+			expected: func() *Config {
+				c := mkDefaultConfig()
+				c.Scheduler.Profiles = []map[string]any{{
+					"schedulerName": "default-scheduler",
+					"pluginConfig": []any{
+						map[string]any{
+							"name": "NodeResourcesFit",
+							"args": map[string]any{
+								"scoringStrategy": map[string]any{"type": "MostAllocated"},
+							},
+						},
+					},
+				}}
+				return c
+			}(),
+		},
+		{
+			name: "controller-manager-controllers",
+			config: dedent(`
+            controllerManager:
+              controllers:
+                - -ttl-after-finished-controller
+                - -cronjob-controller
+            `),
+			expected: func() *Config {
+				c := mkDefaultConfig()
+				c.ControllerManager.Controllers = []string{"-ttl-after-finished-controller", "-cronjob-controller"}
+				return c
+			}(),
+		},
+		{
+			name: "pod-gc-and-eviction",
+			config: dedent(`
+            controllerManager:
+              terminatedPodGCThreshold: 100
+              nodeMonitorGracePeriodSeconds: 60
+            node:
+              eviction:
+                hard:
+                  memory.available: 100Mi
+                soft:
+                  nodefs.available: 10%
+                softGracePeriod:
+                  nodefs.available: 2m
+                maxPodGracePeriodSeconds: 30
+            `),
+			expected: func() *Config {
+				c := mkDefaultConfig()
+				c.ControllerManager.TerminatedPodGCThreshold = ptr.To[int](100)
+				c.ControllerManager.NodeMonitorGracePeriodSeconds = ptr.To[int](60)
+				c.Node.Eviction.Hard = map[string]string{"memory.available": "100Mi"}
+				c.Node.Eviction.Soft = map[string]string{"nodefs.available": "10%"}
+				c.Node.Eviction.SoftGracePeriod = map[string]string{"nodefs.available": "2m"}
+				c.Node.Eviction.MaxPodGracePeriodSeconds = ptr.To[int](30)
+				return c
+			}(),
+		},
+		{
+			name: "node-topology-manager",
+			config: dedent(`
+            node:
+              topologyManager:
+                policy: single-numa-node
+                scope: pod
+            `),
+			expected: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.TopologyManager.Policy = TopologyManagerPolicySingleNUMANode
+				c.Node.TopologyManager.Scope = TopologyManagerScopePod
+				return c
+			}(),
+		},
+		{
+			name: "node-resource-managers",
+			config: dedent(`
+            node:
+              cpuManager:
+                policy: static
+                policyOptions:
+                  full-pcpus-only: "true"
+                reservedSystemCPUs: 0-1
+              memoryManager:
+                policy: Static
+                reservedMemory:
+                  - numaNode: 0
+                    memory: 1100Mi
+            `),
+			expected: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.CPUManager.Policy = CPUManagerPolicyStatic
+				c.Node.CPUManager.PolicyOptions = map[string]string{"full-pcpus-only": "true"}
+				c.Node.CPUManager.ReservedSystemCPUs = "0-1"
+				c.Node.MemoryManager.Policy = MemoryManagerPolicyStatic
+				c.Node.MemoryManager.ReservedMemory = []NodeReservedMemory{{NUMANode: 0, Memory: "1100Mi"}}
+				return c
+			}(),
+		},
+		{
+			name: "ingress-route-admission",
+			config: dedent(`
+            dns:
+              baseDomain: test-example.com
+            ingress:
+              domain: edge.example.com
+              routeAdmissionPolicy:
+                wildcardPolicy: WildcardsAllowed
+            `),
+			expected: func() *Config {
+				c := mkDefaultConfig()
+				c.DNS.BaseDomain = "test-example.com"
+				c.Ingress.Domain = "edge.example.com"
+				c.Ingress.AdmissionPolicy.WildcardPolicy = WildcardPolicyAllowed
+				return c
+			}(),
+		},
 		{
 			name: "api-server-subject-alt-names",
 			config: dedent(`
@@ -206,14 +514,58 @@ func TestGetActiveConfigFromYAML(t *testing.T) {
 			config: dedent(`
             etcd:
               memoryLimitMB: 129
+              memoryMaxMB: 256
             `),
 			expected: func() *Config {
 				c := mkDefaultConfig()
 				c.Etcd.MemoryLimitMB = 129
+				c.Etcd.MemoryMaxMB = 256
 				assert.NoError(t, c.updateComputedValues())
 				return c
 			}(),
 		},
+		{
+			name: "etcd-defragmentation",
+			config: dedent(`
+            etcd:
+              defragmentation:
+                checkIntervalSeconds: 3600
+                maxFragmentedPercentage: 30
+                minDatabaseSizeMB: 200
+            `),
+			expected: func() *Config {
+				c := mkDefaultConfig()
+				c.Etcd.Defragmentation.CheckIntervalSeconds = ptr.To[int](3600)
+				c.Etcd.Defragmentation.MaxFragmentedPercentage = ptr.To[int](30)
+				c.Etcd.Defragmentation.MinDatabaseSizeMB = ptr.To[int](200)
+				c.Etcd.DefragCheckFreq = time.Hour
+				c.Etcd.MaxFragmentedPercentage = 30
+				c.Etcd.MinDefragBytes = 200 * 1024 * 1024
+				return c
+			}(),
+		},
+		{
+			name: "etcd-external",
+			config: dedent(`
+            etcd:
+              external:
+                endpoints:
+                - https://etcd.example.com:2379
+                caFile: /etc/etcd/ca.crt
+                certFile: /etc/etcd/client.crt
+                keyFile: /etc/etcd/client.key
+            `),
+			expected: func() *Config {
+				c := mkDefaultConfig()
+				c.Etcd.External = EtcdExternal{
+					Endpoints: []string{"https://etcd.example.com:2379"},
+					CAFile:    "/etc/etcd/ca.crt",
+					CertFile:  "/etc/etcd/client.crt",
+					KeyFile:   "/etc/etcd/client.key",
+				}
+				return c
+			}(),
+		},
 		{
 			name: "manifests-default",
 			config: dedent(`
@@ -529,226 +881,496 @@ func TestValidate(t *testing.T) {
 			expectErr: true,
 		},
 		{
-			name: "subject-alt-names-with-kubernetes",
+			name: "subject-alt-names-with-loopback-ipv6",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.ApiServer.SubjectAltNames = []string{"kubernetes"}
+				c.ApiServer.SubjectAltNames = []string{"::1"}
 				return c
 			}(),
 			expectErr: true,
 		},
 		{
-			name: "etcd-memory-limit-low",
+			name: "subject-alt-names-with-kubernetes",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Etcd.MemoryLimitMB = 1
+				c.ApiServer.SubjectAltNames = []string{"kubernetes"}
 				return c
 			}(),
 			expectErr: true,
 		},
 		{
-			name: "etcd-memory-zero",
+			name: "multus-managed",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Etcd.MemoryLimitMB = 0
+				c.Network.Multus.Status = MultusStatusManaged
 				return c
 			}(),
 			expectErr: false,
 		},
 		{
-			name: "advertise-address-not-present",
+			name: "multus-managed-without-cni",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.ApiServer.AdvertiseAddress = "8.8.8.8"
-				c.ApiServer.SkipInterface = true
+				c.Network.CNIPlugin = CniPluginNone
+				c.Network.Multus.Status = MultusStatusManaged
 				return c
 			}(),
 			expectErr: true,
 		},
 		{
-			name: "router-status-invalid",
+			name: "multus-bad-status",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Ingress.Status = "invalid"
+				c.Network.Multus.Status = "Enabled"
 				return c
 			}(),
 			expectErr: true,
 		},
 		{
-			name: "ingress-ports-http-invalid-value-1",
+			name: "node-port-addresses",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Ingress.Ports.Http = ptr.To[int](0)
+				c.Network.NodePortAddresses = []string{"192.168.10.5", "10.10.0.0/24", "enp2s0"}
 				return c
 			}(),
-			expectErr: true,
+			expectErr: false,
 		},
 		{
-			name: "ingress-listen-address-ip-forbidden",
+			name: "node-port-addresses-bad-entry",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Ingress.ListenAddress = []string{"127.0.0.1", "169.255.169.254"}
+				c.Network.NodePortAddresses = []string{"10.10.0.0/33"}
 				return c
 			}(),
 			expectErr: true,
 		},
 		{
-			name: "ingress-ports-http-invalid-value-2",
+			name: "egress-addresses",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Ingress.Ports.Http = ptr.To[int](65536)
+				c.Network.Egress.Addresses = []string{"192.168.1.50", "2001:db8::50"}
 				return c
 			}(),
-			expectErr: true,
+			expectErr: false,
 		},
 		{
-			name: "ingress-listen-address-ip-not-present",
+			name: "egress-bad-address",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Ingress.ListenAddress = []string{"1.2.3.4"}
+				c.Network.Egress.Addresses = []string{"192.168.1.0/24"}
 				return c
 			}(),
 			expectErr: true,
 		},
 		{
-			name: "ingress-ports-https-invalid-value-1",
+			name: "kube-proxy-ipvs",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Ingress.Ports.Https = ptr.To[int](0)
+				c.Network.KubeProxy.Mode = KubeProxyModeIPVS
+				c.Network.KubeProxy.IPVSScheduler = "lc"
+				c.Network.KubeProxy.Conntrack.MaxPerCore = ptr.To[int](65536)
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "kube-proxy-scheduler-without-ipvs",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Network.KubeProxy.Mode = KubeProxyModeNFTables
+				c.Network.KubeProxy.IPVSScheduler = "lc"
 				return c
 			}(),
 			expectErr: true,
 		},
 		{
-			name: "ingress-ports-https-invalid-value-2",
+			name: "kube-proxy-bad-mode",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Ingress.Ports.Https = ptr.To[int](65536)
+				c.Network.KubeProxy.Mode = "userspace"
 				return c
 			}(),
 			expectErr: true,
 		},
 		{
-			name: "ingress-listen-address-nic-not-present",
+			name: "kube-proxy-negative-timeout",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Ingress.ListenAddress = []string{"dummyinterface"}
+				c.Network.KubeProxy.Conntrack.UDPTimeoutSeconds = ptr.To[int](-1)
 				return c
 			}(),
 			expectErr: true,
 		},
 		{
-			name: "ingress-listen-address-bad-ip-family-1",
+			name: "etcd-memory-limit-low",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Ingress.ListenAddress = []string{"1.2.3.4"}
-				c.Network.ClusterNetwork = []string{"fd01::/48"}
-				c.Network.ServiceNetwork = []string{"fd02::/112"}
+				c.Etcd.MemoryLimitMB = 1
 				return c
 			}(),
 			expectErr: true,
 		},
 		{
-			name: "ingress-listen-address-bad-ip-family-2",
+			name: "etcd-memory-max-low",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Ingress.ListenAddress = []string{"fe80::1"}
-				c.Network.ClusterNetwork = []string{"10.42.0.0/16"}
-				c.Network.ServiceNetwork = []string{"10.43.0.0/16"}
+				c.Etcd.MemoryMaxMB = 1
 				return c
 			}(),
 			expectErr: true,
 		},
 		{
-			name: "audit-log-flag-values-unexpected-values",
+			name: "etcd-memory-max-below-limit",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.ApiServer.AuditLog.MaxFiles = -1
-				c.ApiServer.AuditLog.MaxFileAge = -1
-				c.ApiServer.AuditLog.MaxFileSize = -1
+				c.Etcd.MemoryLimitMB = 512
+				c.Etcd.MemoryMaxMB = 256
 				return c
 			}(),
 			expectErr: true,
 		},
 		{
-			name: "audit-log-flag-expected-values",
+			name: "etcd-memory-max",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.ApiServer.AuditLog.MaxFiles = 0
-				c.ApiServer.AuditLog.MaxFileAge = 0
-				c.ApiServer.AuditLog.MaxFileSize = 0
+				c.Etcd.MemoryLimitMB = 256
+				c.Etcd.MemoryMaxMB = 512
 				return c
 			}(),
 			expectErr: false,
 		},
 		{
-			name: "network-too-many-entries",
+			name: "etcd-memory-zero",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Network.ServiceNetwork = []string{"1.2.3.4/24", "::1/128", "5.6.7.8/24"}
-				c.Network.ClusterNetwork = []string{"9.10.11.12/24", "::2/128", "13.14.15.16/24"}
-				c.ApiServer.AdvertiseAddress = "17.18.19.20"
+				c.Etcd.MemoryLimitMB = 0
 				return c
 			}(),
-			expectErr: true,
+			expectErr: false,
 		},
 		{
-			name: "network-same-ip-family-ipv4",
+			name: "etcd-defragmentation-percentage-out-of-range",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Network.ServiceNetwork = []string{"21.22.23.24/24", "25.26.27.28/24"}
-				c.Network.ClusterNetwork = []string{"29.30.31.32/24", "33.34.35.36/24"}
-				c.ApiServer.AdvertiseAddress = "37.38.39.40"
+				c.Etcd.Defragmentation.MaxFragmentedPercentage = ptr.To[int](101)
 				return c
 			}(),
 			expectErr: true,
 		},
 		{
-			name: "network-same-ip-family-ipv6",
+			name: "etcd-defragmentation-disabled",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Network.ServiceNetwork = []string{"fd01::/64", "fd02::/64"}
-				c.Network.ClusterNetwork = []string{"fd03::/64", "fd04::/64"}
-				c.ApiServer.AdvertiseAddress = "fd01::1"
+				c.Etcd.Defragmentation.CheckIntervalSeconds = ptr.To[int](0)
 				return c
 			}(),
-			expectErr: true,
+			expectErr: false,
 		},
 		{
-			name: "network-bad-format-ipv4",
+			name: "etcd-external-http-endpoint",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Network.ServiceNetwork = []string{"1.2.3.300/24"}
-				c.Network.ClusterNetwork = []string{"300.1.2.3/24"}
-				c.ApiServer.AdvertiseAddress = "8.8.8.8"
+				c.Etcd.External = EtcdExternal{
+					Endpoints: []string{"http://etcd.example.com:2379"},
+					CAFile:    "/etc/etcd/ca.crt",
+					CertFile:  "/etc/etcd/client.crt",
+					KeyFile:   "/etc/etcd/client.key",
+				}
 				return c
 			}(),
 			expectErr: true,
 		},
 		{
-			name: "network-bad-format-ipv6",
+			name: "etcd-external-missing-certificates",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Network.ServiceNetwork = []string{"fd01:::/64"}
-				c.Network.ClusterNetwork = []string{"fd05::/64"}
-				c.ApiServer.AdvertiseAddress = "fd01::2"
+				c.Etcd.External.Endpoints = []string{"https://etcd.example.com:2379"}
 				return c
 			}(),
 			expectErr: true,
 		},
 		{
-			name: "network-different-ip-family",
+			name: "etcd-external-certificates-without-endpoints",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Network.ServiceNetwork = []string{"fd05::/64"}
-				c.Network.ClusterNetwork = []string{"4.3.2.1/24"}
-				c.ApiServer.AdvertiseAddress = "fd01::3"
+				c.Etcd.External.CAFile = "/etc/etcd/ca.crt"
 				return c
 			}(),
 			expectErr: true,
 		},
 		{
-			name: "network-different-ip-family-advertise-address",
+			name: "etcd-standby",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Etcd.Standby = EtcdStandby{Role: EtcdStandbyRoleStandby, Peer: "192.0.2.10"}
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "etcd-standby-missing-peer",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Etcd.Standby.Role = EtcdStandbyRolePrimary
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "etcd-standby-peer-is-node-ip",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Etcd.Standby = EtcdStandby{Role: EtcdStandbyRolePrimary, Peer: c.Node.NodeIP}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "etcd-tmpfs",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Etcd.Tmpfs = EtcdTmpfs{Status: EtcdTmpfsStatusEnabled, PersistIntervalSeconds: ptr.To[int](3600)}
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "etcd-tmpfs-persist-interval-too-short",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Etcd.Tmpfs = EtcdTmpfs{Status: EtcdTmpfsStatusEnabled, PersistIntervalSeconds: ptr.To[int](10)}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "etcd-tmpfs-standby",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Etcd.Tmpfs.Status = EtcdTmpfsStatusEnabled
+				c.Etcd.Standby = EtcdStandby{Role: EtcdStandbyRolePrimary, Peer: "192.0.2.10"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "etcd-tmpfs-invalid-status",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Etcd.Tmpfs.Status = "Auto"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "etcd-standby-external",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Etcd.Standby = EtcdStandby{Role: EtcdStandbyRoleStandby, Peer: "192.0.2.10"}
+				c.Etcd.External = EtcdExternal{
+					Endpoints: []string{"https://etcd.example.com:2379"},
+					CAFile:    "/etc/etcd/ca.crt",
+					CertFile:  "/etc/etcd/client.crt",
+					KeyFile:   "/etc/etcd/client.key",
+				}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "advertise-address-not-present",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.AdvertiseAddress = "8.8.8.8"
+				c.ApiServer.SkipInterface = true
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "router-status-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Ingress.Status = "invalid"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "ingress-ports-http-invalid-value-1",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Ingress.Ports.Http = ptr.To[int](0)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "ingress-listen-address-ip-forbidden",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Ingress.ListenAddress = []string{"127.0.0.1", "169.255.169.254"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "ingress-ports-http-invalid-value-2",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Ingress.Ports.Http = ptr.To[int](65536)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "ingress-listen-address-ip-not-present",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Ingress.ListenAddress = []string{"1.2.3.4"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "ingress-ports-https-invalid-value-1",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Ingress.Ports.Https = ptr.To[int](0)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "ingress-ports-https-invalid-value-2",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Ingress.Ports.Https = ptr.To[int](65536)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "ingress-listen-address-nic-not-present",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Ingress.ListenAddress = []string{"dummyinterface"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "ingress-listen-address-bad-ip-family-1",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Ingress.ListenAddress = []string{"1.2.3.4"}
+				c.Network.ClusterNetwork = []string{"fd01::/48"}
+				c.Network.ServiceNetwork = []string{"fd02::/112"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "ingress-listen-address-bad-ip-family-2",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Ingress.ListenAddress = []string{"fe80::1"}
+				c.Network.ClusterNetwork = []string{"10.42.0.0/16"}
+				c.Network.ServiceNetwork = []string{"10.43.0.0/16"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "audit-log-flag-values-unexpected-values",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.AuditLog.MaxFiles = -1
+				c.ApiServer.AuditLog.MaxFileAge = -1
+				c.ApiServer.AuditLog.MaxFileSize = -1
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "audit-log-flag-expected-values",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.AuditLog.MaxFiles = 0
+				c.ApiServer.AuditLog.MaxFileAge = 0
+				c.ApiServer.AuditLog.MaxFileSize = 0
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "network-too-many-entries",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Network.ServiceNetwork = []string{"1.2.3.4/24", "::1/128", "5.6.7.8/24"}
+				c.Network.ClusterNetwork = []string{"9.10.11.12/24", "::2/128", "13.14.15.16/24"}
+				c.ApiServer.AdvertiseAddress = "17.18.19.20"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "network-same-ip-family-ipv4",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Network.ServiceNetwork = []string{"21.22.23.24/24", "25.26.27.28/24"}
+				c.Network.ClusterNetwork = []string{"29.30.31.32/24", "33.34.35.36/24"}
+				c.ApiServer.AdvertiseAddress = "37.38.39.40"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "network-same-ip-family-ipv6",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Network.ServiceNetwork = []string{"fd01::/64", "fd02::/64"}
+				c.Network.ClusterNetwork = []string{"fd03::/64", "fd04::/64"}
+				c.ApiServer.AdvertiseAddress = "fd01::1"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "network-bad-format-ipv4",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Network.ServiceNetwork = []string{"1.2.3.300/24"}
+				c.Network.ClusterNetwork = []string{"300.1.2.3/24"}
+				c.ApiServer.AdvertiseAddress = "8.8.8.8"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "network-bad-format-ipv6",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Network.ServiceNetwork = []string{"fd01:::/64"}
+				c.Network.ClusterNetwork = []string{"fd05::/64"}
+				c.ApiServer.AdvertiseAddress = "fd01::2"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "network-different-ip-family",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Network.ServiceNetwork = []string{"fd05::/64"}
+				c.Network.ClusterNetwork = []string{"4.3.2.1/24"}
+				c.ApiServer.AdvertiseAddress = "fd01::3"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "network-different-ip-family-advertise-address",
 			config: func() *Config {
 				c := mkDefaultConfig()
 				c.Network.ServiceNetwork = []string{"fd06::/64"}
@@ -759,44 +1381,1266 @@ func TestValidate(t *testing.T) {
 			expectErr: true,
 		},
 		{
-			name: "node-ipv6-must-be-configured",
+			name: "node-ipv6-must-be-configured",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Network.ServiceNetwork = []string{"90.80.70.60/16", "fd08::/64"}
+				c.Network.ClusterNetwork = []string{"50.40.30.20/16", "fd09::/64"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "node-ipv6-must-not-be-configured",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Network.ServiceNetwork = []string{"91.81.71.61/16"}
+				c.Network.ClusterNetwork = []string{"51.41.31.21/16"}
+				c.Node.NodeIPV6 = "2001:db0:ff::1"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "node-ipv6-bad-format",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Network.ServiceNetwork = []string{"92.82.72.62/16", "fd0a::/64"}
+				c.Network.ClusterNetwork = []string{"52.42.32.22/16", "fd0b::/64"}
+				c.Node.NodeIPV6 = "2001:db0::ff:::1"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "node-ipv6-must-be-ipv6",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Network.ServiceNetwork = []string{"93.83.73.63/16", "fd0c::/64"}
+				c.Network.ClusterNetwork = []string{"53.43.33.23/16", "fd0d::/64"}
+				c.Node.NodeIPV6 = "11.22.33.44"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "node-drain-timeout-negative",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.Drain.TimeoutSeconds = ptr.To[int](-1)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "encryption-provider-unsupported",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.Encryption.Provider = "secretbox"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "encryption-key-rotation-negative",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.Encryption.KeyRotationDays = ptr.To[int](-1)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "encryption-kms-without-endpoint",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.Encryption.Provider = EncryptionProviderKMS
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "encryption-kms-tcp-endpoint",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.Encryption.Provider = EncryptionProviderKMS
+				c.ApiServer.Encryption.KMS.Endpoint = "tcp://127.0.0.1:9000"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "encryption-kms",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.Encryption.Provider = EncryptionProviderKMS
+				c.ApiServer.Encryption.KMS.Endpoint = "unix:///run/kmsplugin/socket.sock"
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "authorization-webhook-relative-kubeconfig",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.Authorization.Webhook.KubeconfigPath = "authz.kubeconfig"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "authorization-webhook-ttl-negative",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.Authorization.Webhook.CacheAuthorizedTTLSeconds = ptr.To[int](-1)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "service-account-issuer-http",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.ServiceAccount.Issuer = "http://oidc.example.com"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "service-account-publish-relative-directory",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.ServiceAccount.Publish.Directory = "oidc"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "api-server-max-requests-inflight-zero",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.Tuning.MaxRequestsInflight = ptr.To[int](0)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "api-server-watch-cache-size-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.Tuning.WatchCacheSizes = []string{"secrets=100"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "api-server-goaway-chance-too-high",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.Tuning.GoAwayChance = ptr.To[float64](0.1)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "api-server-disabled-apis",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.Tuning.DisabledAPIs = []string{"batch/v1", "autoscaling/v1/horizontalpodautoscalers", "flowcontrol.apiserver.k8s.io/v1"}
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "api-server-disabled-apis-required",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.Tuning.DisabledAPIs = []string{"policy/v1"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "api-server-disabled-apis-unknown-resource",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.Tuning.DisabledAPIs = []string{"batch/v1/pods"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "api-server-disabled-jobs-with-kube-state-metrics",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Metrics.KubeStateMetrics.Status = KubeStateMetricsStatusManaged
+				c.ApiServer.Tuning.DisabledAPIs = []string{"batch/v1/jobs"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "api-server-pod-security-mode-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.ApiServer.PodSecurity.Mode = "Audit"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "controller-manager-controllers-list",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.ControllerManager.Controllers = []string{"-cronjob-controller,-job-controller"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "terminated-pod-gc-threshold-negative",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.ControllerManager.TerminatedPodGCThreshold = ptr.To[int](-1)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "eviction-soft-without-grace-period",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.Eviction.Soft = map[string]string{"memory.available": "200Mi"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "topology-manager-policy-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.TopologyManager.Policy = "static"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "topology-manager-scope-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.TopologyManager.Scope = "node"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "cpu-manager-static-without-reserved-cpus",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.CPUManager.Policy = CPUManagerPolicyStatic
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "cpu-manager-reserved-cpus-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.CPUManager.Policy = CPUManagerPolicyStatic
+				c.Node.CPUManager.ReservedSystemCPUs = "3-1"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "cpu-manager-policy-options-without-static",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.CPUManager.PolicyOptions = map[string]string{"full-pcpus-only": "true"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "memory-manager-static-without-reserved-memory",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.MemoryManager.Policy = MemoryManagerPolicyStatic
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "memory-manager-reserved-memory-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.MemoryManager.Policy = MemoryManagerPolicyStatic
+				c.Node.MemoryManager.ReservedMemory = []NodeReservedMemory{{NUMANode: 0, Memory: "lots"}}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "node-ip-selection-interface-pattern-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.NodeIPSelection.Interfaces = []string{"eth["}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "node-ip-selection-subnet-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.NodeIPSelection.PreferredSubnets = []string{"10.0.0.0"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "node-ip-selection-ip-family-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.NodeIPSelection.PreferredIPFamily = "IPv5"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "graceful-shutdown-negative",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.GracefulShutdown.GracePeriodSeconds = ptr.To[int](-1)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "graceful-shutdown-critical-pods-exceeds-grace-period",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.GracefulShutdown.GracePeriodSeconds = ptr.To[int](10)
+				c.Node.GracefulShutdown.CriticalPodsGracePeriodSeconds = ptr.To[int](20)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "resource-reservation-auto-sizing-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.ResourceReservation.AutoSizing = "Auto"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "resource-reservation-quantity-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.ResourceReservation.KubeReserved = map[string]string{"memory": "lots"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "swap-behavior-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.Swap.Behavior = "UnlimitedSwap"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "manifests-conflict-policy-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Manifests.ConflictPolicy = "Ignore"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "manifests-kustomize-path-oci-empty",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Manifests.KustomizePaths = []string{"oci://"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "manifests-reconcile-interval-negative",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Manifests.Reconcile.IntervalSeconds = ptr.To[int](-1)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "mdns-status-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.MDNS.Status = "Off"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "mdns-hostname-outside-domain",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.MDNS.Domain = "lan"
+				c.MDNS.Hostnames = []string{"dashboard.local"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "mdns-interface-pattern-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.MDNS.ExcludeInterfaces = []string{"wwan["}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "loadbalancer-pool-address-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.LoadBalancer.AddressPools = []LoadBalancerAddressPool{{Name: "lan", Addresses: []string{"192.168.1.210-192.168.1.200"}}}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "loadbalancer-pool-name-duplicate",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.LoadBalancer.AddressPools = []LoadBalancerAddressPool{
+					{Name: "lan", Addresses: []string{"192.168.1.200"}},
+					{Name: "lan", Addresses: []string{"192.168.1.201"}},
+				}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "loadbalancer-pools-overlap",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.LoadBalancer.AddressPools = []LoadBalancerAddressPool{
+					{Name: "lan", Addresses: []string{"192.168.1.200-192.168.1.210"}},
+					{Name: "dmz", Addresses: []string{"192.168.1.208/30"}},
+				}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "loadbalancer-l2-announcement-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.LoadBalancer.L2Announcement = "enabled"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "metrics-listen-address-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Metrics.ListenAddress = "localhost"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "metrics-kube-state-metrics-status-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Metrics.KubeStateMetrics.Status = "Enabled"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "metrics-listen-address-relative-socket",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Metrics.ListenAddress = "unix://metrics.sock"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "tracing-endpoint-without-scheme",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Tracing.Endpoint = "localhost:4317"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "tracing-sampling-rate-too-high",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Tracing.SamplingRatePerMillion = ptr.To[int](2000000)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "logging-format-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Logging.Format = "logfmt"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "logging-events-status-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Logging.Events.Status = "Managed"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "logging-events-max-per-minute-zero",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Logging.Events.MaxPerMinute = ptr.To[int](0)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "ingress-wildcard-policy-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Ingress.AdmissionPolicy.WildcardPolicy = "Subdomain"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "ingress-domain-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Ingress.Domain = "*.apps.example.com"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "startup-timeout-negative",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Startup.TimeoutSeconds = ptr.To[int](-1)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "startup-service-timeout-negative",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Startup.ServiceTimeoutSeconds = map[string]int{"etcd": -1}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "graceful-shutdown-disabled",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.GracefulShutdown.GracePeriodSeconds = ptr.To[int](0)
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "mdns-custom-domain",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.MDNS.Domain = "lan"
+				c.MDNS.Hostnames = []string{"dashboard.lan"}
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "loadbalancer-pools",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.LoadBalancer.AddressPools = []LoadBalancerAddressPool{
+					{Name: "lan", Addresses: []string{"192.168.1.200-192.168.1.210", "192.168.1.220/30"}},
+					{Name: "v6", Addresses: []string{"fd00::100"}},
+				}
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "metrics-listen-address",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Metrics.ListenAddress = "localhost:10262"
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "metrics-listen-address-socket",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Metrics.ListenAddress = "unix:///run/microshift/metrics.sock"
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "tracing-endpoint",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Tracing.Endpoint = "http://localhost:4317"
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "debugging-profiling-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Debugging.Profiling = "On"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "debugging-profiling-enabled",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Debugging.Profiling = ProfilingStatusEnabled
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "logging-forwarding-without-endpoint",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Logging.Forwarding.Status = ForwardingStatusEnabled
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "logging-forwarding-endpoint-without-port",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Logging.Forwarding.Status = ForwardingStatusEnabled
+				c.Logging.Forwarding.Endpoint = "tcp://logs.example.com"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "logging-forwarding-invalid-source",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Logging.Forwarding.Sources = []LogSourceEnum{"crio"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "logging-forwarding-https",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Logging.Forwarding.Status = ForwardingStatusEnabled
+				c.Logging.Forwarding.Endpoint = "https://logs.example.com/ingest"
+				c.Logging.Forwarding.CAFile = "/etc/pki/logs-ca.crt"
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "healthcheck-workloads",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.HealthCheck.Workloads = []HealthCheckWorkload{
+					{Namespace: "my-app", Deployments: []string{"frontend"}},
+					{Namespace: "monitoring"},
+				}
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "healthcheck-workloads-invalid-namespace",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.HealthCheck.Workloads = []HealthCheckWorkload{{Namespace: ""}}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "healthcheck-workloads-invalid-name",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.HealthCheck.Workloads = []HealthCheckWorkload{{Namespace: "my-app", DaemonSets: []string{"Agent"}}}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "upgrade-restore-on-rollback-invalid",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Upgrade.RestoreOnRollback = "Auto"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "upgrade-restore-on-rollback-disabled",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Upgrade.RestoreOnRollback = RestoreOnRollbackStatusDisabled
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "fleet-api-enabled",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.FleetAPI.Status = FleetAPIStatusEnabled
+				c.FleetAPI.ClientCAFile = "/etc/microshift/fleet/ca.crt"
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "fleet-api-missing-client-ca",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.FleetAPI.Status = FleetAPIStatusEnabled
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "fleet-api-cert-without-key",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.FleetAPI.Status = FleetAPIStatusEnabled
+				c.FleetAPI.ClientCAFile = "/etc/microshift/fleet/ca.crt"
+				c.FleetAPI.CertFile = "/etc/microshift/fleet/tls.crt"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "firewall-enabled",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Firewall.Status = FirewallStatusEnabled
+				c.Firewall.AllowedPorts = []string{"30080/tcp", "30053/udp"}
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "firewall-nftables-without-zones",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Firewall.Status = FirewallStatusEnabled
+				c.Firewall.Backend = FirewallBackendNftables
+				c.Firewall.Zone = ""
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "firewall-bad-backend",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Firewall.Status = FirewallStatusEnabled
+				c.Firewall.Backend = "iptables"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "firewall-bad-allowed-port",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Firewall.Status = FirewallStatusEnabled
+				c.Firewall.AllowedPorts = []string{"30080/icmp"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "images-mirrors",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Images.Mirrors = []ImageMirror{
+					{Source: "quay.io/openshift-release-dev", Mirrors: []string{"mirror.example.com:5000/ocp"}},
+					{Source: "*.example.org", Mirrors: []string{"mirror.example.com:5000"}, MirrorTags: true, BlockSource: true},
+				}
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "images-mirror-with-scheme",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Images.Mirrors = []ImageMirror{{Source: "quay.io", Mirrors: []string{"https://mirror.example.com"}}}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "images-mirror-without-mirrors",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Images.Mirrors = []ImageMirror{{Source: "quay.io"}}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "images-relative-pull-secret",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Images.PullSecretFile = "pull-secret.json"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "images-garbage-collection",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Images.GarbageCollection.MaximumAgeSeconds = ptr.To(604800)
+				c.Images.GarbageCollection.ProtectedImages = []string{"registry.example.com/app:1.0", "quay.io/example/*", "*pause*"}
+				c.Images.GarbageCollection.ProtectedLabels = map[string]string{"com.example.keep": "true"}
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "images-garbage-collection-low-above-high",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Images.GarbageCollection.LowThresholdPercent = ptr.To(90)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "images-garbage-collection-maximum-below-minimum",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Images.GarbageCollection.MaximumAgeSeconds = ptr.To(60)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "images-garbage-collection-invalid-protected-image",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Images.GarbageCollection.ProtectedImages = []string{"*example"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "images-local-registry-invalid-port",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Images.LocalRegistry.Status = LocalRegistryStatusEnabled
+				c.Images.LocalRegistry.Port = 70000
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "disk-maintenance-enabled",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.DiskMaintenance.Status = DiskMaintenanceStatusEnabled
+				c.DiskMaintenance.KeepBackups = ptr.To(0)
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "disk-maintenance-invalid-status",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.DiskMaintenance.Status = "On"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "disk-maintenance-margin-too-large",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Network.ServiceNetwork = []string{"90.80.70.60/16", "fd08::/64"}
-				c.Network.ClusterNetwork = []string{"50.40.30.20/16", "fd09::/64"}
+				c.DiskMaintenance.Status = DiskMaintenanceStatusEnabled
+				c.DiskMaintenance.MarginPercent = ptr.To(60)
 				return c
 			}(),
 			expectErr: true,
 		},
 		{
-			name: "node-ipv6-must-not-be-configured",
+			name: "disk-maintenance-interval-too-short",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Network.ServiceNetwork = []string{"91.81.71.61/16"}
-				c.Network.ClusterNetwork = []string{"51.41.31.21/16"}
-				c.Node.NodeIPV6 = "2001:db0:ff::1"
+				c.DiskMaintenance.Status = DiskMaintenanceStatusEnabled
+				c.DiskMaintenance.CheckIntervalSeconds = ptr.To(1)
 				return c
 			}(),
 			expectErr: true,
 		},
 		{
-			name: "node-ipv6-bad-format",
+			name: "crio-settings",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Network.ServiceNetwork = []string{"92.82.72.62/16", "fd0a::/64"}
-				c.Network.ClusterNetwork = []string{"52.42.32.22/16", "fd0b::/64"}
-				c.Node.NodeIPV6 = "2001:db0::ff:::1"
+				c.CRIO.PauseImage = "registry.example.com/pause:3.9"
+				c.CRIO.PidsLimit = 4096
+				c.CRIO.LogSizeMax = "50Mi"
+				c.CRIO.RuntimeClasses = []string{RuntimeClassCrun, RuntimeClassKata}
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "crio-log-size-too-small",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.CRIO.LogSizeMax = "1Ki"
 				return c
 			}(),
 			expectErr: true,
 		},
 		{
-			name: "node-ipv6-must-be-ipv6",
+			name: "crio-invalid-pids-limit",
 			config: func() *Config {
 				c := mkDefaultConfig()
-				c.Network.ServiceNetwork = []string{"93.83.73.63/16", "fd0c::/64"}
-				c.Network.ClusterNetwork = []string{"53.43.33.23/16", "fd0d::/64"}
-				c.Node.NodeIPV6 = "11.22.33.44"
+				c.CRIO.PidsLimit = -2
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "crio-unsupported-runtime-class",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.CRIO.RuntimeClasses = []string{"runc"}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "crio-duplicate-runtime-class",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.CRIO.RuntimeClasses = []string{RuntimeClassKata, RuntimeClassKata}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "images-relative-preload",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Images.Preload = "images"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "virtual-ip-enabled",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.VirtualIP.Status = VirtualIPStatusEnabled
+				c.VirtualIP.Address = "192.0.2.100"
+				c.VirtualIP.Peer = "192.0.2.11"
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "virtual-ip-missing-peer",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.VirtualIP.Status = VirtualIPStatusEnabled
+				c.VirtualIP.Address = "192.0.2.100"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "virtual-ip-node-ip",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.VirtualIP.Status = VirtualIPStatusEnabled
+				c.VirtualIP.Address = c.Node.NodeIP
+				c.VirtualIP.Peer = "192.0.2.11"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "virtual-ip-invalid-priority",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.VirtualIP.Status = VirtualIPStatusEnabled
+				c.VirtualIP.Address = "192.0.2.100"
+				c.VirtualIP.Peer = "192.0.2.11"
+				c.VirtualIP.Priority = 255
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "logging-format-json",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Logging.Format = LogFormatJSON
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "node-drain-enabled",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Node.Drain.TimeoutSeconds = ptr.To[int](60)
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "certificates-short-lived-validity-too-short",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Certificates.ShortLivedValidityDays = ptr.To[int](90)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "certificates-short-lived-validity-too-long",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Certificates.ShortLivedValidityDays = ptr.To[int](1825)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "certificates-long-lived-validity-too-short",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Certificates.LongLivedValidityDays = ptr.To[int](730)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "certificates-negative-backdate",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Certificates.BackdateMinutes = ptr.To[int](-1)
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "certificates-clock-skew-policy-refuse",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Certificates.ClockSkewPolicy = ClockSkewPolicyRefuse
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "certificates-external-ca",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Certificates.ExternalCA.CertFile = "/etc/pki/microshift/ca.crt"
+				c.Certificates.ExternalCA.KeyFile = "/etc/pki/microshift/ca.key"
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "certificates-external-ca-missing-key",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Certificates.ExternalCA.CertFile = "/etc/pki/microshift/ca.crt"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "certificates-external-ca-relative-path",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Certificates.ExternalCA.CertFile = "ca.crt"
+				c.Certificates.ExternalCA.KeyFile = "/etc/pki/microshift/ca.key"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "certificates-pkcs11",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Certificates.PKCS11.Module = "/usr/lib64/pkcs11/libtpm2_pkcs11.so"
+				c.Certificates.PKCS11.TokenLabel = "microshift"
+				c.Certificates.PKCS11.PINFile = "/etc/microshift/pkcs11-pin"
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "certificates-pkcs11-external-ca",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Certificates.ExternalCA.CertFile = "/etc/pki/microshift/ca.crt"
+				c.Certificates.PKCS11.Module = "/usr/lib64/pkcs11/libtpm2_pkcs11.so"
+				c.Certificates.PKCS11.TokenLabel = "microshift"
+				c.Certificates.PKCS11.PINFile = "/etc/microshift/pkcs11-pin"
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "certificates-pkcs11-external-ca-key-file",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Certificates.ExternalCA.CertFile = "/etc/pki/microshift/ca.crt"
+				c.Certificates.ExternalCA.KeyFile = "/etc/pki/microshift/ca.key"
+				c.Certificates.PKCS11.Module = "/usr/lib64/pkcs11/libtpm2_pkcs11.so"
+				c.Certificates.PKCS11.TokenLabel = "microshift"
+				c.Certificates.PKCS11.PINFile = "/etc/microshift/pkcs11-pin"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "certificates-pkcs11-missing-pin-file",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Certificates.PKCS11.Module = "/usr/lib64/pkcs11/libtpm2_pkcs11.so"
+				c.Certificates.PKCS11.TokenLabel = "microshift"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "certificates-csr-approval",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Certificates.CSRApproval.Rules = []CSRApprovalRule{{
+					SignerName:  "kubernetes.io/kubelet-serving",
+					Usernames:   []string{"system:node:*"},
+					Groups:      []string{"system:nodes"},
+					DNSNames:    []string{"*.example.com"},
+					IPAddresses: []string{"192.168.1.0/24"},
+					KeyTypes:    []CSRApprovalKeyType{CSRApprovalKeyTypeECDSA},
+				}}
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "certificates-csr-approval-any-user",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Certificates.CSRApproval.Rules = []CSRApprovalRule{{SignerName: "kubernetes.io/kubelet-serving"}}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "certificates-csr-approval-invalid-network",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Certificates.CSRApproval.Rules = []CSRApprovalRule{{
+					SignerName:  "kubernetes.io/kubelet-serving",
+					Groups:      []string{"system:nodes"},
+					IPAddresses: []string{"192.168.1.1"},
+				}}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "certificates-csr-approval-invalid-key-type",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Certificates.CSRApproval.Rules = []CSRApprovalRule{{
+					SignerName: "kubernetes.io/kubelet-serving",
+					Groups:     []string{"system:nodes"},
+					KeyTypes:   []CSRApprovalKeyType{"DSA"},
+				}}
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "components-service-ca-removed",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Components.ServiceCA.Status = ServiceCAStatusRemoved
+				c.Components.ServiceCA.ValidityDays = 730
+				return c
+			}(),
+			expectErr: false,
+		},
+		{
+			name: "components-service-ca-invalid-status",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Components.ServiceCA.Status = "Unmanaged"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "components-service-ca-validity-too-short",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Components.ServiceCA.ValidityDays = 90
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name: "certificates-invalid-clock-skew-policy",
+			config: func() *Config {
+				c := mkDefaultConfig()
+				c.Certificates.ClockSkewPolicy = "Ignore"
 				return c
 			}(),
 			expectErr: true,
@@ -815,6 +2659,27 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+func TestNodeSwapLimitedSwapRequiresCgroupV2(t *testing.T) {
+	orig := cgroupControllersPath
+	t.Cleanup(func() { cgroupControllersPath = orig })
+
+	c := NewDefault()
+	c.ApiServer.SkipInterface = false
+	c.Node.Swap.Behavior = SwapBehaviorLimitedSwap
+
+	cgroupControllersPath = filepath.Join(t.TempDir(), "cgroup.controllers")
+	if err := c.validate(); err == nil {
+		t.Fatal("Expecting error on cgroup v1 and received nothing")
+	}
+
+	if err := os.WriteFile(cgroupControllersPath, []byte("cpu memory\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.validate(); err != nil {
+		t.Fatalf("Not expecting error on cgroup v2 and received: %v", err)
+	}
+}
+
 func TestMicroshiftConfigIsDefaultNodeName(t *testing.T) {
 	c := NewDefault()
 	if !c.isDefaultNodeName() {
@@ -914,6 +2779,32 @@ func TestMicroshiftConfigNodeNameValidationFromDefault(t *testing.T) {
 	}
 }
 
+func TestMicroshiftConfigNodeNameValidationHostRenamed(t *testing.T) {
+	dataDir, cleanup := setupSuiteDataDir(t)
+	defer cleanup()
+
+	c := NewDefault()
+	c.Node.HostnameOverride = "node1"
+	if err := c.validateNodeName(IS_DEFAULT_NODENAME, dataDir); err != nil {
+		t.Fatalf("failed to validate node name on first call: %v", err)
+	}
+
+	for _, name := range []string{"node2", "node3"} {
+		c.Node.HostnameOverride = name
+		if err := c.validateNodeName(IS_DEFAULT_NODENAME, dataDir); err != nil {
+			t.Fatalf("failed to validate node name after host rename: %v", err)
+		}
+		assert.Equal(t, name, c.CanonicalNodeName())
+		data, err := os.ReadFile(filepath.Join(dataDir, ".nodename"))
+		assert.NoError(t, err)
+		assert.Equal(t, name, string(data))
+	}
+
+	previousNames, err := PreviousNodeNames(dataDir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"node1", "node2"}, previousNames)
+}
+
 func TestMicroshiftConfigNodeNameValidationBadName(t *testing.T) {
 	dataDir, cleanup := setupSuiteDataDir(t)
 	defer cleanup()
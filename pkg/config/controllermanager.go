@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ControllerManager struct {
+	// controllers enables, or disables when prefixed with '-', individual
+	// controllers of kube-controller-manager. The entries are added to the
+	// defaults, which enable all of the controllers enabled upstream except
+	// ttl-controller, bootstrap-signer-controller and token-cleaner-controller.
+	Controllers []string `json:"controllers"`
+
+	// terminatedPodGCThreshold is the number of terminated pods that can
+	// exist before the terminated pod garbage collector starts deleting
+	// the oldest ones. 0 disables the garbage collection of terminated pods.
+	// +kubebuilder:default=12500
+	TerminatedPodGCThreshold *int `json:"terminatedPodGCThreshold"`
+
+	// nodeMonitorGracePeriodSeconds is the time, in seconds, the node is
+	// allowed to stop reporting its status before being marked unhealthy
+	// and its pods evicted.
+	// +kubebuilder:default=40
+	NodeMonitorGracePeriodSeconds *int `json:"nodeMonitorGracePeriodSeconds"`
+}
+
+func (c ControllerManager) validate() error {
+	for _, controller := range c.Controllers {
+		if name := strings.TrimPrefix(controller, "-"); name == "" || name == "*" || strings.ContainsAny(name, ", ") {
+			return fmt.Errorf("controllerManager.controllers entry %q must be a controller name, optionally prefixed with '-'", controller)
+		}
+	}
+	if c.TerminatedPodGCThreshold != nil && *c.TerminatedPodGCThreshold < 0 {
+		return fmt.Errorf("controllerManager.terminatedPodGCThreshold must not be negative, got %d", *c.TerminatedPodGCThreshold)
+	}
+	if c.NodeMonitorGracePeriodSeconds != nil && *c.NodeMonitorGracePeriodSeconds <= 0 {
+		return fmt.Errorf("controllerManager.nodeMonitorGracePeriodSeconds must be positive, got %d", *c.NodeMonitorGracePeriodSeconds)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// RuntimeClassCrun is the default runtime of the pods.
+	RuntimeClassCrun = "crun"
+	// RuntimeClassKata runs the pods in virtual machines with Kata
+	// Containers.
+	RuntimeClassKata = "kata"
+)
+
+// CRIOMinimumLogSize is the smallest log size CRI-O accepts.
+const CRIOMinimumLogSize = 8192
+
+// CRIO configures the CRI-O container runtime, which MicroShift manages
+// with its drop-in configuration and starts when it is not running.
+type CRIO struct {
+	// Image of the infrastructure container of the pods, e.g. a copy in a
+	// local registry. Empty uses the image of the MicroShift release.
+	PauseImage string `json:"pauseImage"`
+
+	// Maximum number of processes in a container, -1 for unlimited. 0
+	// keeps the default of CRI-O.
+	PidsLimit int64 `json:"pidsLimit"`
+
+	// Maximum size of the log of a container, e.g. 50Mi, after which it
+	// is truncated. Empty keeps the default of CRI-O, unlimited.
+	LogSizeMax string `json:"logSizeMax"`
+
+	// Runtimes offered to the pods, each with a RuntimeClass of the same
+	// name: crun, the default runtime, or kata, for Kata Containers which
+	// must be installed on the host.
+	RuntimeClasses []string `json:"runtimeClasses"`
+}
+
+func (c CRIO) validate() error {
+	if strings.ContainsAny(c.PauseImage, " \t\n\"") {
+		return fmt.Errorf("crio.pauseImage %q is not a valid image reference", c.PauseImage)
+	}
+	if c.PidsLimit < -1 {
+		return fmt.Errorf("crio.pidsLimit must be -1 or more, got %d", c.PidsLimit)
+	}
+	if c.LogSizeMax != "" {
+		q, err := resource.ParseQuantity(c.LogSizeMax)
+		if err != nil {
+			return fmt.Errorf("crio.logSizeMax %q is not a valid quantity: %w", c.LogSizeMax, err)
+		}
+		if q.Value() < CRIOMinimumLogSize {
+			return fmt.Errorf("crio.logSizeMax must be at least %d bytes, got %s", CRIOMinimumLogSize, c.LogSizeMax)
+		}
+	}
+	for i, name := range c.RuntimeClasses {
+		switch name {
+		case RuntimeClassCrun, RuntimeClassKata:
+		default:
+			return fmt.Errorf("unsupported crio.runtimeClasses value %q, must be %s or %s", name, RuntimeClassCrun, RuntimeClassKata)
+		}
+		if slices.Contains(c.RuntimeClasses[:i], name) {
+			return fmt.Errorf("crio.runtimeClasses contains %q more than once", name)
+		}
+	}
+	return nil
+}
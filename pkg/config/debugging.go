@@ -9,11 +9,33 @@ import (
 // default.
 const defaultLogLevel = "Normal"
 
+type ProfilingStatusEnum string
+
+const (
+	ProfilingStatusEnabled  ProfilingStatusEnum = "Enabled"
+	ProfilingStatusDisabled ProfilingStatusEnum = "Disabled"
+)
+
 type Debugging struct {
 	// Valid values are: "Normal", "Debug", "Trace", "TraceAll".
 	// Defaults to "Normal".
 	// +kubebuilder:default="Normal"
 	LogLevel string `json:"logLevel"`
+
+	// Whether the pprof profiles, goroutine dumps and memory statistics of
+	// the MicroShift process are served on the local admin socket, Enabled
+	// or Disabled.
+	// +kubebuilder:default="Disabled"
+	Profiling ProfilingStatusEnum `json:"profiling"`
+}
+
+func (d *Debugging) validate() error {
+	switch d.Profiling {
+	case ProfilingStatusEnabled, ProfilingStatusDisabled:
+	default:
+		return fmt.Errorf("unsupported debugging.profiling value %v", d.Profiling)
+	}
+	return nil
 }
 
 var logLevelNames = map[string]int{
@@ -38,6 +60,15 @@ func (c *Config) computeLoggingSetting() {
 	}
 }
 
+// LogLevelVerbosity returns the numerical value of a LogLevel name.
+func LogLevelVerbosity(level string) (int, error) {
+	verbosity, ok := logLevelNames[strings.ToLower(level)]
+	if !ok {
+		return 0, fmt.Errorf("unrecognized log level %q, must be one of Normal, Debug, Trace or TraceAll", level)
+	}
+	return verbosity, nil
+}
+
 // GetVerbosity returns the numerical value for LogLevel which is an
 // enum.
 func (c *Config) GetVerbosity() int {
@@ -0,0 +1,62 @@
+package config
+
+import "fmt"
+
+type DiskMaintenanceStatusEnum string
+
+const (
+	DiskMaintenanceStatusEnabled  DiskMaintenanceStatusEnum = "Enabled"
+	DiskMaintenanceStatusDisabled DiskMaintenanceStatusEnum = "Disabled"
+)
+
+// DiskMaintenance configures the reclaiming of disk space by MicroShift
+// before the kubelet starts evicting pods because of disk pressure.
+type DiskMaintenance struct {
+	// Whether MicroShift reclaims disk space when the free space of the
+	// data directory or of the image store gets close to the eviction
+	// thresholds of the kubelet: by compacting and defragmenting etcd,
+	// pruning the oldest backups and removing the unused images.
+	// Value must be one of:
+	// - Enabled
+	// - Disabled
+	// +kubebuilder:default="Disabled"
+	Status DiskMaintenanceStatusEnum `json:"status"`
+
+	// Margin, in percent of the filesystem size, above the eviction
+	// thresholds of the kubelet at which disk space is reclaimed.
+	// +kubebuilder:default=5
+	MarginPercent *int `json:"marginPercent"`
+
+	// How often, in seconds, the free disk space is checked.
+	// +kubebuilder:default=60
+	CheckIntervalSeconds *int `json:"checkIntervalSeconds"`
+
+	// Number of the newest backups which are never pruned.
+	// +kubebuilder:default=1
+	KeepBackups *int `json:"keepBackups"`
+}
+
+// IsEnabled returns whether disk space is reclaimed under disk pressure.
+func (d DiskMaintenance) IsEnabled() bool {
+	return d.Status == DiskMaintenanceStatusEnabled
+}
+
+func (d DiskMaintenance) validate() error {
+	switch d.Status {
+	case DiskMaintenanceStatusEnabled:
+	case DiskMaintenanceStatusDisabled:
+		return nil
+	default:
+		return fmt.Errorf("unsupported diskMaintenance.status value %v", d.Status)
+	}
+	if m := d.MarginPercent; m != nil && (*m < 0 || *m > 50) {
+		return fmt.Errorf("diskMaintenance.marginPercent must be between 0 and 50, got %d", *m)
+	}
+	if i := d.CheckIntervalSeconds; i != nil && *i < 10 {
+		return fmt.Errorf("diskMaintenance.checkIntervalSeconds must be at least 10, got %d", *i)
+	}
+	if k := d.KeepBackups; k != nil && *k < 0 {
+		return fmt.Errorf("diskMaintenance.keepBackups must not be negative, got %d", *k)
+	}
+	return nil
+}
@@ -1,6 +1,12 @@
 package config
 
-import "time"
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"time"
+)
 
 const (
 	// Etcd performance degrades significantly if the memory available
@@ -13,6 +19,30 @@ type EtcdConfig struct {
 	// memory when it gets to this value. 0 means no limit.
 	MemoryLimitMB uint64 `json:"memoryLimitMB"`
 
+	// Set a hard memory limit on the etcd process; etcd is killed and
+	// restarted when it gets to this value, instead of making the whole
+	// system run out of memory. It must not be lower than memoryLimitMB.
+	// 0 means no limit.
+	MemoryMaxMB uint64 `json:"memoryMaxMB"`
+
+	// Defragmentation configures the online defragmentation of the etcd
+	// database, which returns the space freed by compactions to the
+	// filesystem.
+	Defragmentation EtcdDefragmentation `json:"defragmentation"`
+
+	// External points MicroShift at an etcd cluster it does not manage,
+	// instead of running its own.
+	External EtcdExternal `json:"external"`
+
+	// Standby replicates the etcd database of a primary MicroShift node to
+	// a standby node, which can be promoted when the primary fails.
+	Standby EtcdStandby `json:"standby"`
+
+	// Tmpfs keeps the etcd database in memory, persisting it to disk
+	// periodically, to spare the flash storage of devices running
+	// read-mostly workloads.
+	Tmpfs EtcdTmpfs `json:"tmpfs"`
+
 	// The limit on the size of the etcd database; etcd will start
 	// failing writes if its size on disk reaches this value
 	QuotaBackendBytes int64 `json:"-"`
@@ -27,3 +57,185 @@ type EtcdConfig struct {
 	// defrags, except for a single on startup).
 	DefragCheckFreq time.Duration `json:"-"`
 }
+
+type EtcdExternal struct {
+	// List of https URLs of the members of the external etcd cluster.
+	// MicroShift runs its own etcd when the list is empty.
+	// example:
+	//   - https://etcd.example.com:2379
+	Endpoints []string `json:"endpoints"`
+
+	// Path of the CA bundle to verify the certificates of the etcd members.
+	CAFile string `json:"caFile"`
+
+	// Paths of the client certificate and key MicroShift authenticates
+	// to etcd with.
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+}
+
+// IsExternal returns whether MicroShift uses an etcd cluster it does not manage.
+func (e *EtcdConfig) IsExternal() bool {
+	return len(e.External.Endpoints) != 0
+}
+
+func (e *EtcdExternal) validate() error {
+	if len(e.Endpoints) == 0 {
+		if e.CAFile != "" || e.CertFile != "" || e.KeyFile != "" {
+			return fmt.Errorf("etcd.external.endpoints must be set when configuring etcd.external certificates")
+		}
+		return nil
+	}
+	for _, endpoint := range e.Endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return fmt.Errorf("invalid etcd.external.endpoints value %q: %w", endpoint, err)
+		}
+		if u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("etcd.external.endpoints value %q must be an https URL", endpoint)
+		}
+	}
+	for name, path := range map[string]string{"caFile": e.CAFile, "certFile": e.CertFile, "keyFile": e.KeyFile} {
+		if !filepath.IsAbs(path) {
+			return fmt.Errorf("etcd.external.%s must be an absolute path when using an external etcd, got %q", name, path)
+		}
+	}
+	return nil
+}
+
+type EtcdStandbyRoleEnum string
+
+const (
+	EtcdStandbyRoleNone    EtcdStandbyRoleEnum = "None"
+	EtcdStandbyRolePrimary EtcdStandbyRoleEnum = "Primary"
+	EtcdStandbyRoleStandby EtcdStandbyRoleEnum = "Standby"
+)
+
+type EtcdStandby struct {
+	// Role of the node: None, Primary to add the peer as an etcd learner
+	// replicating the database, or Standby to run only the etcd learner,
+	// until promoted with 'microshift etcd promote'.
+	// +kubebuilder:default="None"
+	Role EtcdStandbyRoleEnum `json:"role"`
+
+	// IP address of the other node, the standby on the primary and the
+	// primary on the standby. The etcd members of both nodes connect to
+	// each other on port 2380.
+	Peer string `json:"peer"`
+}
+
+// EtcdPeerURL returns the URL of the etcd member of the node at ip.
+func EtcdPeerURL(ip string) string {
+	return "https://" + net.JoinHostPort(ip, "2380")
+}
+
+// IsStandby returns whether the node only runs the etcd learner.
+func (e *EtcdConfig) IsStandby() bool {
+	return e.Standby.Role == EtcdStandbyRoleStandby
+}
+
+func (e *EtcdConfig) validateStandby(nodeIP string) error {
+	switch e.Standby.Role {
+	case EtcdStandbyRolePrimary, EtcdStandbyRoleStandby:
+	case EtcdStandbyRoleNone:
+		return nil
+	default:
+		return fmt.Errorf("unsupported etcd.standby.role value %v", e.Standby.Role)
+	}
+	if e.IsExternal() {
+		return fmt.Errorf("etcd.standby can not be used with etcd.external")
+	}
+	peer := net.ParseIP(e.Standby.Peer)
+	if peer == nil {
+		return fmt.Errorf("etcd.standby.peer %q is not a valid IP address", e.Standby.Peer)
+	}
+	if peer.Equal(net.ParseIP(nodeIP)) {
+		return fmt.Errorf("etcd.standby.peer must differ from the node IP %s", nodeIP)
+	}
+	return nil
+}
+
+type EtcdDefragmentation struct {
+	// How often, in seconds, to check whether the database needs to be
+	// defragmented. A random delay of up to 10% is added to every check
+	// so devices started at the same time do not defragment in lockstep.
+	// 0 disables defragmentation, except for a single one on startup.
+	// +kubebuilder:default=300
+	CheckIntervalSeconds *int `json:"checkIntervalSeconds"`
+
+	// Percentage of the database file not in use above which the
+	// database is defragmented.
+	// +kubebuilder:default=45
+	MaxFragmentedPercentage *int `json:"maxFragmentedPercentage"`
+
+	// Size of the database file, in MB, below which the database is never
+	// defragmented.
+	// +kubebuilder:default=100
+	MinDatabaseSizeMB *int `json:"minDatabaseSizeMB"`
+}
+
+func (d *EtcdDefragmentation) validate() error {
+	if d.CheckIntervalSeconds != nil && *d.CheckIntervalSeconds < 0 {
+		return fmt.Errorf("etcd.defragmentation.checkIntervalSeconds must not be negative, got %d", *d.CheckIntervalSeconds)
+	}
+	if d.MaxFragmentedPercentage != nil && (*d.MaxFragmentedPercentage < 1 || *d.MaxFragmentedPercentage > 100) {
+		return fmt.Errorf("etcd.defragmentation.maxFragmentedPercentage must be between 1 and 100, got %d", *d.MaxFragmentedPercentage)
+	}
+	if d.MinDatabaseSizeMB != nil && *d.MinDatabaseSizeMB < 0 {
+		return fmt.Errorf("etcd.defragmentation.minDatabaseSizeMB must not be negative, got %d", *d.MinDatabaseSizeMB)
+	}
+	return nil
+}
+
+type EtcdTmpfsStatusEnum string
+
+const (
+	EtcdTmpfsStatusEnabled  EtcdTmpfsStatusEnum = "Enabled"
+	EtcdTmpfsStatusDisabled EtcdTmpfsStatusEnum = "Disabled"
+)
+
+// EtcdTmpfsMinimumPersistInterval is the minimum interval between the
+// snapshots of an etcd database in memory, which would otherwise wear out
+// the storage as much as keeping the database on it.
+const EtcdTmpfsMinimumPersistInterval = 60
+
+type EtcdTmpfs struct {
+	// Whether etcd runs from a tmpfs, with its database persisted to a
+	// snapshot in the data directory every persistIntervalSeconds and
+	// when MicroShift stops. The changes made since the last snapshot are
+	// lost when the host crashes or loses power.
+	// Value must be one of:
+	// - Enabled
+	// - Disabled
+	// +kubebuilder:default="Disabled"
+	Status EtcdTmpfsStatusEnum `json:"status"`
+
+	// How often, in seconds, to persist the database when it changed.
+	// +kubebuilder:default=900
+	PersistIntervalSeconds *int `json:"persistIntervalSeconds"`
+}
+
+// IsEnabled returns whether etcd runs from a tmpfs.
+func (t *EtcdTmpfs) IsEnabled() bool {
+	return t.Status == EtcdTmpfsStatusEnabled
+}
+
+func (e *EtcdConfig) validateTmpfs() error {
+	switch e.Tmpfs.Status {
+	case EtcdTmpfsStatusEnabled:
+	case EtcdTmpfsStatusDisabled:
+		return nil
+	default:
+		return fmt.Errorf("unsupported etcd.tmpfs.status value %v", e.Tmpfs.Status)
+	}
+	if e.IsExternal() {
+		return fmt.Errorf("etcd.tmpfs can not be used with etcd.external")
+	}
+	if e.Standby.Role != EtcdStandbyRoleNone {
+		return fmt.Errorf("etcd.tmpfs can not be used with etcd.standby")
+	}
+	if i := e.Tmpfs.PersistIntervalSeconds; i != nil && *i < EtcdTmpfsMinimumPersistInterval {
+		return fmt.Errorf("etcd.tmpfs.persistIntervalSeconds must be at least %d, got %d", EtcdTmpfsMinimumPersistInterval, *i)
+	}
+	return nil
+}
@@ -15,9 +15,19 @@ import (
 
 const (
 	ConfigFile      = "/etc/microshift/config.yaml"
-	DataDir         = "/var/lib/microshift"
 	BackupsDir      = "/var/lib/microshift-backups"
 	ConfigDropInDir = "/etc/microshift/config.d"
+	OverridesDir    = "/etc/microshift/overrides"
+	SCCDir          = "/etc/microshift/scc"
+	CRDDir          = "/etc/microshift/crd"
+	StaticPodsDir   = "/etc/microshift/static-pods"
+)
+
+var (
+	// DataDir is where MicroShift keeps its certificates, kubeconfigs and
+	// component configuration files. It is only ever changed by
+	// `microshift run --dry-run` to render those files to another location.
+	DataDir = "/var/lib/microshift"
 )
 
 func getActiveConfigFromYAMLDropins(yamlDropins [][]byte) (*Config, error) {
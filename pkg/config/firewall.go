@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type FirewallStatusEnum string
+
+const (
+	FirewallStatusEnabled  FirewallStatusEnum = "Enabled"
+	FirewallStatusDisabled FirewallStatusEnum = "Disabled"
+)
+
+type FirewallBackendEnum string
+
+const (
+	FirewallBackendFirewalld FirewallBackendEnum = "firewalld"
+	FirewallBackendNftables  FirewallBackendEnum = "nftables"
+)
+
+// Firewall configures the host firewall rules MicroShift opens the ports
+// it serves with, and trusts the traffic of the pods with.
+type Firewall struct {
+	// Whether MicroShift opens the ports it serves in the host firewall
+	// when starting, and closes them when stopping, Enabled or Disabled.
+	// +kubebuilder:default="Disabled"
+	Status FirewallStatusEnum `json:"status"`
+
+	// How the ports are opened: firewalld, in the runtime configuration of
+	// its zones, or nftables, in a dedicated inet microshift table
+	// dropping the other incoming traffic. Use nftables on hosts without
+	// firewalld only.
+	// +kubebuilder:validation:Enum:=firewalld;nftables
+	// +kubebuilder:default="firewalld"
+	Backend FirewallBackendEnum `json:"backend"`
+
+	// Zone of firewalld the ports are opened in.
+	// +kubebuilder:default="public"
+	Zone string `json:"zone"`
+
+	// Zone of firewalld the pod networks and the OVN-Kubernetes host
+	// masquerade address are added to as sources.
+	// +kubebuilder:default="trusted"
+	TrustedZone string `json:"trustedZone"`
+
+	// Additional ports opened, as port/protocol with the tcp, udp or sctp
+	// protocol, e.g. 30080/tcp for a NodePort service.
+	AllowedPorts []string `json:"allowedPorts"`
+}
+
+func (f Firewall) validate() error {
+	switch f.Status {
+	case FirewallStatusEnabled:
+	case FirewallStatusDisabled:
+		return nil
+	default:
+		return fmt.Errorf("unsupported firewall.status value %v", f.Status)
+	}
+	switch f.Backend {
+	case FirewallBackendFirewalld:
+		if f.Zone == "" || f.TrustedZone == "" {
+			return fmt.Errorf("firewall.zone and firewall.trustedZone must be set with the firewalld backend")
+		}
+	case FirewallBackendNftables:
+	default:
+		return fmt.Errorf("unsupported firewall.backend value %v", f.Backend)
+	}
+	for _, port := range f.AllowedPorts {
+		number, protocol, _ := strings.Cut(port, "/")
+		if n, err := strconv.Atoi(number); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("firewall.allowedPorts %q is not a valid port", port)
+		}
+		switch protocol {
+		case "tcp", "udp", "sctp":
+		default:
+			return fmt.Errorf("firewall.allowedPorts %q must have the tcp, udp or sctp protocol", port)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+type FleetAPIStatusEnum string
+
+const (
+	FleetAPIStatusEnabled  FleetAPIStatusEnum = "Enabled"
+	FleetAPIStatusDisabled FleetAPIStatusEnum = "Disabled"
+)
+
+// FleetAPI configures the API remote fleet managers use to follow the
+// health of the device and to trigger actions on it, authenticated with
+// client certificates.
+type FleetAPI struct {
+	// Whether the fleet API is served, Enabled or Disabled.
+	// +kubebuilder:default="Disabled"
+	Status FleetAPIStatusEnum `json:"status"`
+
+	// TCP port the fleet API is served on, on all addresses.
+	// +kubebuilder:default=7445
+	Port int `json:"port"`
+
+	// Path of the CA bundle verifying the client certificates of the
+	// fleet managers. Required when the fleet API is enabled.
+	ClientCAFile string `json:"clientCAFile"`
+
+	// Common names of the client certificates allowed to use the fleet
+	// API. Every certificate signed by clientCAFile is allowed when empty.
+	AllowedClientNames []string `json:"allowedClientNames"`
+
+	// Paths of the serving certificate and key of the fleet API. The
+	// serving certificate of the API server for external clients is used
+	// when empty.
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+}
+
+func (f *FleetAPI) validate() error {
+	switch f.Status {
+	case FleetAPIStatusEnabled:
+	case FleetAPIStatusDisabled:
+		return nil
+	default:
+		return fmt.Errorf("unsupported fleetAPI.status value %v", f.Status)
+	}
+	if f.Port < 1 || f.Port > 65535 {
+		return fmt.Errorf("fleetAPI.port %d is not a valid port", f.Port)
+	}
+	if !filepath.IsAbs(f.ClientCAFile) {
+		return fmt.Errorf("fleetAPI.clientCAFile must be an absolute path when the fleet API is enabled, got %q", f.ClientCAFile)
+	}
+	if (f.CertFile == "") != (f.KeyFile == "") {
+		return fmt.Errorf("fleetAPI.certFile and fleetAPI.keyFile must be set together")
+	}
+	for name, path := range map[string]string{"certFile": f.CertFile, "keyFile": f.KeyFile} {
+		if path != "" && !filepath.IsAbs(path) {
+			return fmt.Errorf("fleetAPI.%s must be an absolute path, got %q", name, path)
+		}
+	}
+	return nil
+}
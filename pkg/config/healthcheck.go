@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// HealthCheck configures `microshift healthcheck`, which greenboot runs on
+// boot to decide whether to roll back the system.
+type HealthCheck struct {
+	// Workloads of the user that must be ready for the system to be
+	// healthy, in addition to the ones of MicroShift.
+	Workloads []HealthCheckWorkload `json:"workloads"`
+}
+
+// HealthCheckWorkload selects the workloads of a namespace to check.
+type HealthCheckWorkload struct {
+	// Namespace of the workloads.
+	// +kubebuilder:example=my-app
+	Namespace string `json:"namespace"`
+
+	// Names of the Deployments, DaemonSets and StatefulSets to check. All
+	// of the workloads of the namespace are checked when the three are
+	// empty, and at least one must exist.
+	Deployments  []string `json:"deployments"`
+	DaemonSets   []string `json:"daemonSets"`
+	StatefulSets []string `json:"statefulSets"`
+}
+
+func (h *HealthCheck) validate() error {
+	for i, w := range h.Workloads {
+		if errs := validation.IsDNS1123Label(w.Namespace); len(errs) > 0 {
+			return fmt.Errorf("invalid healthCheck.workloads[%d].namespace %q: %v", i, w.Namespace, errs)
+		}
+		for _, names := range [][]string{w.Deployments, w.DaemonSets, w.StatefulSets} {
+			for _, name := range names {
+				if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+					return fmt.Errorf("invalid workload name %q in healthCheck.workloads[%d]: %v", name, i, errs)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+type LocalRegistryStatusEnum string
+
+const (
+	LocalRegistryStatusEnabled  LocalRegistryStatusEnum = "Enabled"
+	LocalRegistryStatusDisabled LocalRegistryStatusEnum = "Disabled"
+)
+
+// DefaultPullSecretFile is the pull secret CRI-O is configured with by the
+// MicroShift packages.
+const DefaultPullSecretFile = "/etc/crio/openshift-pull-secret"
+
+// Images configures how the container images are pulled. MicroShift renders
+// it into drop-in configurations of CRI-O and of the containers registries.
+type Images struct {
+	// Path of the pull secret authenticating the pulls of the images and of
+	// the OCI artifacts of the manifests.
+	// +kubebuilder:default="/etc/crio/openshift-pull-secret"
+	PullSecretFile string `json:"pullSecretFile"`
+
+	// Mirrors the images are pulled from instead of their source
+	// registries, like an ImageContentSourcePolicy.
+	Mirrors []ImageMirror `json:"mirrors"`
+
+	// Directory of OCI layouts, OCI archives and docker archives (.tar)
+	// whose images are copied to the storage of CRI-O before MicroShift is
+	// ready, e.g. baked in the ostree commit for offline first boots. The
+	// OCI images are named by their org.opencontainers.image.ref.name
+	// annotation, which must be a full reference. No image is preloaded
+	// when empty.
+	Preload string `json:"preload"`
+
+	// Garbage collection of the unused images by the kubelet.
+	GarbageCollection ImagesGarbageCollection `json:"garbageCollection"`
+
+	// Read-only registry serving the images of the OCI layouts sideloaded
+	// in the data directory.
+	LocalRegistry ImagesLocalRegistry `json:"localRegistry"`
+}
+
+// ImagesLocalRegistry configures the local registry, which serves the OCI
+// layouts of /var/lib/microshift/registry, e.g. the images of the
+// /var/lib/microshift/registry/example/app layout as localhost:5000/example/app.
+type ImagesLocalRegistry struct {
+	// Whether the local registry is served, Enabled or Disabled.
+	// +kubebuilder:default="Disabled"
+	Status LocalRegistryStatusEnum `json:"status"`
+
+	// TCP port the local registry is served on, on the loopback addresses.
+	// +kubebuilder:default=5000
+	Port int `json:"port"`
+}
+
+// ImagesGarbageCollection configures when the kubelet removes the unused
+// images and which images it never removes.
+type ImagesGarbageCollection struct {
+	// Disk usage, in percent, of the filesystem of the images above which
+	// the unused images are removed. 100 disables the garbage collection.
+	// +kubebuilder:default=85
+	HighThresholdPercent *int `json:"highThresholdPercent"`
+
+	// Disk usage, in percent, the garbage collection brings the filesystem
+	// of the images down to.
+	// +kubebuilder:default=80
+	LowThresholdPercent *int `json:"lowThresholdPercent"`
+
+	// Time, in seconds, an image stays unused before it may be removed.
+	// +kubebuilder:default=120
+	MinimumAgeSeconds *int `json:"minimumAgeSeconds"`
+
+	// Time, in seconds, after which an unused image is removed regardless
+	// of the disk usage. 0 never removes the images because of their age.
+	// +kubebuilder:default=0
+	MaximumAgeSeconds *int `json:"maximumAgeSeconds"`
+
+	// References of the images never removed. A reference ending with *
+	// matches the images starting with it, and one starting and ending
+	// with * the images containing it.
+	ProtectedImages []string `json:"protectedImages"`
+
+	// Labels of the images never removed, e.g. "com.example.keep: true".
+	// The images with all of the labels are protected.
+	ProtectedLabels map[string]string `json:"protectedLabels"`
+}
+
+// ImageMirror redirects the pulls of the images of a source to mirrors.
+type ImageMirror struct {
+	// Registry, repository or image whose pulls are redirected, e.g.
+	// quay.io/openshift-release-dev/ocp-release. A registry may start with
+	// a wildcard, e.g. *.example.com.
+	Source string `json:"source"`
+
+	// Registries or repositories tried in order before the source.
+	Mirrors []string `json:"mirrors"`
+
+	// Whether the images pulled by tag are also pulled from the mirrors.
+	// Only the images pulled by digest are by default, since a tag may
+	// refer to another image on a mirror.
+	MirrorTags bool `json:"mirrorTags"`
+
+	// Whether the images are only pulled from the mirrors, never from the
+	// source.
+	BlockSource bool `json:"blockSource"`
+}
+
+func (i *Images) validate() error {
+	if !filepath.IsAbs(i.PullSecretFile) {
+		return fmt.Errorf("images.pullSecretFile must be an absolute path, got %q", i.PullSecretFile)
+	}
+	if i.Preload != "" && !filepath.IsAbs(i.Preload) {
+		return fmt.Errorf("images.preload must be an absolute path, got %q", i.Preload)
+	}
+	if err := i.GarbageCollection.validate(); err != nil {
+		return err
+	}
+	switch i.LocalRegistry.Status {
+	case LocalRegistryStatusEnabled, LocalRegistryStatusDisabled:
+	default:
+		return fmt.Errorf("unsupported images.localRegistry.status value %v", i.LocalRegistry.Status)
+	}
+	if i.LocalRegistry.Port < 1 || i.LocalRegistry.Port > 65535 {
+		return fmt.Errorf("images.localRegistry.port %d is not a valid port", i.LocalRegistry.Port)
+	}
+	sources := make(map[string]bool, len(i.Mirrors))
+	for _, m := range i.Mirrors {
+		if err := validateImageLocation(m.Source); err != nil {
+			return fmt.Errorf("invalid images.mirrors source %q: %w", m.Source, err)
+		}
+		if sources[m.Source] {
+			return fmt.Errorf("images.mirrors source %q is configured more than once", m.Source)
+		}
+		sources[m.Source] = true
+		if len(m.Mirrors) == 0 {
+			return fmt.Errorf("images.mirrors source %q has no mirrors", m.Source)
+		}
+		for _, mirror := range m.Mirrors {
+			if strings.HasPrefix(mirror, "*.") {
+				return fmt.Errorf("invalid mirror %q of %q: wildcards are only supported in sources", mirror, m.Source)
+			}
+			if err := validateImageLocation(mirror); err != nil {
+				return fmt.Errorf("invalid mirror %q of %q: %w", mirror, m.Source, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateImageLocation checks a registry or repository is written the way
+// the containers registries configuration expects it.
+func validateImageLocation(location string) error {
+	switch {
+	case location == "":
+		return fmt.Errorf("must not be empty")
+	case strings.Contains(location, "://"):
+		return fmt.Errorf("must not have a scheme")
+	case strings.ContainsAny(location, "@ \t\"'\\"):
+		return fmt.Errorf("must be a registry or repository, without digest, spaces or quotes")
+	case strings.HasSuffix(location, "/"):
+		return fmt.Errorf("must not end with /")
+	}
+	return nil
+}
+
+func (g *ImagesGarbageCollection) validate() error {
+	high, low := *g.HighThresholdPercent, *g.LowThresholdPercent
+	if high < 0 || high > 100 {
+		return fmt.Errorf("images.garbageCollection.highThresholdPercent must be between 0 and 100, got %d", high)
+	}
+	if low < 0 || low >= high {
+		return fmt.Errorf("images.garbageCollection.lowThresholdPercent must be between 0 and highThresholdPercent, got %d", low)
+	}
+	if *g.MinimumAgeSeconds < 0 {
+		return fmt.Errorf("images.garbageCollection.minimumAgeSeconds must not be negative, got %d", *g.MinimumAgeSeconds)
+	}
+	if maximum := *g.MaximumAgeSeconds; maximum != 0 && maximum <= *g.MinimumAgeSeconds {
+		return fmt.Errorf("images.garbageCollection.maximumAgeSeconds must be 0 or greater than minimumAgeSeconds, got %d", maximum)
+	}
+	for _, ref := range g.ProtectedImages {
+		if !validProtectedImage(ref) {
+			return fmt.Errorf("invalid images.garbageCollection.protectedImages reference %q", ref)
+		}
+	}
+	for key := range g.ProtectedLabels {
+		if key == "" {
+			return fmt.Errorf("images.garbageCollection.protectedLabels must not have an empty label")
+		}
+	}
+	return nil
+}
+
+// validProtectedImage reports whether ref is an exact reference, a prefix
+// ending with * or a keyword between *, as CRI-O pins the images.
+func validProtectedImage(ref string) bool {
+	name := strings.TrimSuffix(ref, "*")
+	if strings.HasPrefix(ref, "*") {
+		if len(ref) < 2 || !strings.HasSuffix(ref, "*") {
+			return false
+		}
+		name = strings.TrimPrefix(name, "*")
+	}
+	return name != "" && !strings.ContainsAny(name, "* \t\"'\\")
+}
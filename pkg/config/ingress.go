@@ -5,15 +5,21 @@ const (
 	NamespaceOwnershipAllowed NamespaceOwnershipEnum = "InterNamespaceAllowed"
 	StatusManaged             IngressStatusEnum      = "Managed"
 	StatusRemoved             IngressStatusEnum      = "Removed"
+	WildcardPolicyDisallowed  WildcardPolicyEnum     = "WildcardsDisallowed"
+	WildcardPolicyAllowed     WildcardPolicyEnum     = "WildcardsAllowed"
 )
 
 type NamespaceOwnershipEnum string
 type IngressStatusEnum string
+type WildcardPolicyEnum string
 
 type IngressConfig struct {
 	// Default router status, can be Managed or Removed.
 	// +kubebuilder:default=Managed
-	Status          IngressStatusEnum    `json:"status"`
+	Status IngressStatusEnum `json:"status"`
+	// Domain used to generate the host of the routes which do not specify
+	// one, and of the router certificate. Defaults to apps.<dns.baseDomain>.
+	Domain          string               `json:"domain"`
 	AdmissionPolicy RouteAdmissionPolicy `json:"routeAdmissionPolicy"`
 	Ports           IngressPortsConfig   `json:"ports"`
 	// List of IP addresses and NIC names where the router will be listening. The NIC
@@ -37,6 +43,21 @@ type RouteAdmissionPolicy struct {
 	// If empty, the default is InterNamespaceAllowed.
 	// +kubebuilder:default="InterNamespaceAllowed"
 	NamespaceOwnership NamespaceOwnershipEnum `json:"namespaceOwnership"`
+
+	// Describes whether routes with a wildcard host, e.g. *.example.com,
+	// are admitted by the router.
+	//
+	// Value must be one of:
+	//
+	// - WildcardsDisallowed: Only routes with a wildcard policy of None are
+	//   admitted.
+	//
+	// - WildcardsAllowed: Routes with a wildcard policy of Subdomain are
+	//   admitted too.
+	//
+	// If empty, the default is WildcardsDisallowed.
+	// +kubebuilder:default="WildcardsDisallowed"
+	WildcardPolicy WildcardPolicyEnum `json:"wildcardPolicy"`
 }
 
 type IngressPortsConfig struct {
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+)
+
+type KubeProxyModeEnum string
+
+const (
+	KubeProxyModeIPTables KubeProxyModeEnum = "iptables"
+	KubeProxyModeIPVS     KubeProxyModeEnum = "ipvs"
+	KubeProxyModeNFTables KubeProxyModeEnum = "nftables"
+)
+
+// NetworkKubeProxy configures the kube-proxy of the microshift-flannel
+// package, which implements the services with the flannel and bridge CNI
+// plugins. OVN-Kubernetes implements them itself.
+type NetworkKubeProxy struct {
+	// How kube-proxy implements the services: iptables, ipvs, or nftables
+	// for hosts without the iptables compatibility layer.
+	// +kubebuilder:validation:Enum:=iptables;ipvs;nftables
+	// +kubebuilder:default="iptables"
+	Mode KubeProxyModeEnum `json:"mode"`
+
+	// Scheduling algorithm of the ipvs mode, e.g. rr, lc or sh. Round
+	// robin when empty.
+	IPVSScheduler string `json:"ipvsScheduler"`
+
+	Conntrack KubeProxyConntrack `json:"conntrack"`
+}
+
+// KubeProxyConntrack tunes the connection tracking table of the host for
+// the services, 0 leaving the value of the host unchanged.
+type KubeProxyConntrack struct {
+	// Maximum number of connections tracked per CPU core.
+	// +kubebuilder:default=0
+	MaxPerCore *int `json:"maxPerCore"`
+
+	// Minimum number of connections tracked, regardless of maxPerCore.
+	// +kubebuilder:default=131072
+	Min *int `json:"min"`
+
+	// Time, in seconds, established TCP connections are tracked without
+	// traffic.
+	// +kubebuilder:default=86400
+	TCPEstablishedTimeoutSeconds *int `json:"tcpEstablishedTimeoutSeconds"`
+
+	// Time, in seconds, TCP connections are tracked in the CLOSE_WAIT
+	// state.
+	// +kubebuilder:default=3600
+	TCPCloseWaitTimeoutSeconds *int `json:"tcpCloseWaitTimeoutSeconds"`
+
+	// Time, in seconds, UDP flows are tracked after a single packet, and
+	// after packets in both directions, such as DNS and QUIC flows.
+	// +kubebuilder:default=0
+	UDPTimeoutSeconds *int `json:"udpTimeoutSeconds"`
+	// +kubebuilder:default=0
+	UDPStreamTimeoutSeconds *int `json:"udpStreamTimeoutSeconds"`
+}
+
+func (k NetworkKubeProxy) validate() error {
+	switch k.Mode {
+	case KubeProxyModeIPTables, KubeProxyModeNFTables:
+		if k.IPVSScheduler != "" {
+			return fmt.Errorf("network.kubeProxy.ipvsScheduler requires the ipvs mode")
+		}
+	case KubeProxyModeIPVS:
+	default:
+		return fmt.Errorf("unsupported network.kubeProxy.mode value %v", k.Mode)
+	}
+	for name, value := range map[string]*int{
+		"maxPerCore":                   k.Conntrack.MaxPerCore,
+		"min":                          k.Conntrack.Min,
+		"tcpEstablishedTimeoutSeconds": k.Conntrack.TCPEstablishedTimeoutSeconds,
+		"tcpCloseWaitTimeoutSeconds":   k.Conntrack.TCPCloseWaitTimeoutSeconds,
+		"udpTimeoutSeconds":            k.Conntrack.UDPTimeoutSeconds,
+		"udpStreamTimeoutSeconds":      k.Conntrack.UDPStreamTimeoutSeconds,
+	} {
+		if value != nil && *value < 0 {
+			return fmt.Errorf("network.kubeProxy.conntrack.%s must not be negative, got %d", name, *value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+type L2AnnouncementEnum string
+
+const (
+	L2AnnouncementEnabled  L2AnnouncementEnum = "Enabled"
+	L2AnnouncementDisabled L2AnnouncementEnum = "Disabled"
+)
+
+// LoadBalancer configures the addresses of the LoadBalancer services.
+type LoadBalancer struct {
+	// Pools of addresses assigned to the LoadBalancer services selecting
+	// them with the microshift.io/loadbalancer-pool annotation. The
+	// services without it are assigned the node IP.
+	AddressPools []LoadBalancerAddressPool `json:"addressPools"`
+
+	// Whether the pool addresses assigned to services, which are not
+	// configured on the host, are announced on the LAN with ARP and IPv6
+	// neighbor discovery, Enabled or Disabled.
+	// +kubebuilder:default="Disabled"
+	L2Announcement L2AnnouncementEnum `json:"l2Announcement"`
+
+	// Zone of firewalld in which the ports of the LoadBalancer services,
+	// with their TCP, UDP or SCTP protocol, are opened at runtime. The
+	// ports are not opened when empty.
+	FirewalldZone string `json:"firewalldZone"`
+}
+
+// LoadBalancerAddressPool is a named set of addresses assigned to the
+// LoadBalancer services.
+type LoadBalancerAddressPool struct {
+	// Name of the pool, as set in the microshift.io/loadbalancer-pool
+	// annotation of the services.
+	Name string `json:"name"`
+
+	// Addresses of the pool, as IP addresses, CIDRs or ranges such as
+	// 192.168.1.200-192.168.1.210. They must be routed to the host, e.g.
+	// as secondary addresses of its interfaces.
+	Addresses []string `json:"addresses"`
+}
+
+// AddressRange is an inclusive range of IP addresses of a single family.
+type AddressRange struct {
+	First netip.Addr
+	Last  netip.Addr
+}
+
+// Contains reports whether the address is in the range.
+func (r AddressRange) Contains(addr netip.Addr) bool {
+	return addr.Is4() == r.First.Is4() && r.First.Compare(addr) <= 0 && addr.Compare(r.Last) <= 0
+}
+
+// ParseAddressRange parses an IP address, a CIDR or a range of addresses
+// such as 192.168.1.200-192.168.1.210.
+func ParseAddressRange(s string) (AddressRange, error) {
+	if first, last, found := strings.Cut(s, "-"); found {
+		r := AddressRange{}
+		var err error
+		if r.First, err = netip.ParseAddr(strings.TrimSpace(first)); err != nil {
+			return AddressRange{}, err
+		}
+		if r.Last, err = netip.ParseAddr(strings.TrimSpace(last)); err != nil {
+			return AddressRange{}, err
+		}
+		if r.First.Is4() != r.Last.Is4() || r.First.Compare(r.Last) > 0 {
+			return AddressRange{}, fmt.Errorf("invalid address range %q", s)
+		}
+		return r, nil
+	}
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return AddressRange{}, err
+		}
+		prefix = prefix.Masked()
+		last := prefix.Addr().AsSlice()
+		for bit := prefix.Bits(); bit < len(last)*8; bit++ {
+			last[bit/8] |= 1 << (7 - bit%8)
+		}
+		lastAddr, _ := netip.AddrFromSlice(last)
+		return AddressRange{First: prefix.Addr(), Last: lastAddr}, nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return AddressRange{}, err
+	}
+	return AddressRange{First: addr, Last: addr}, nil
+}
+
+// Ranges returns the parsed addresses of the pool.
+func (p LoadBalancerAddressPool) Ranges() ([]AddressRange, error) {
+	ranges := make([]AddressRange, 0, len(p.Addresses))
+	for _, address := range p.Addresses {
+		r, err := ParseAddressRange(address)
+		if err != nil {
+			return nil, fmt.Errorf("loadBalancer.addressPools %q has invalid addresses %q: %w", p.Name, address, err)
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+func (lb LoadBalancer) validate() error {
+	switch lb.L2Announcement {
+	case L2AnnouncementEnabled, L2AnnouncementDisabled:
+	default:
+		return fmt.Errorf("unsupported loadBalancer.l2Announcement value %v", lb.L2Announcement)
+	}
+	var all []AddressRange
+	names := map[string]bool{}
+	for _, pool := range lb.AddressPools {
+		if errs := validation.IsDNS1123Label(pool.Name); len(errs) != 0 {
+			return fmt.Errorf("loadBalancer.addressPools name %q is invalid: %s", pool.Name, strings.Join(errs, ", "))
+		}
+		if names[pool.Name] {
+			return fmt.Errorf("loadBalancer.addressPools name %q is not unique", pool.Name)
+		}
+		names[pool.Name] = true
+		if len(pool.Addresses) == 0 {
+			return fmt.Errorf("loadBalancer.addressPools %q has no addresses", pool.Name)
+		}
+		ranges, err := pool.Ranges()
+		if err != nil {
+			return err
+		}
+		for _, r := range ranges {
+			for _, other := range all {
+				if other.Contains(r.First) || other.Contains(r.Last) || r.Contains(other.First) {
+					return fmt.Errorf("loadBalancer.addressPools %q addresses %v-%v overlap with another pool", pool.Name, r.First, r.Last)
+				}
+			}
+			all = append(all, r)
+		}
+	}
+	return nil
+}
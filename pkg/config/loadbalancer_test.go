@@ -0,0 +1,27 @@
+package config
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAddressRange(t *testing.T) {
+	for s, want := range map[string][2]string{
+		"192.168.1.200":               {"192.168.1.200", "192.168.1.200"},
+		"192.168.1.200-192.168.1.210": {"192.168.1.200", "192.168.1.210"},
+		"192.168.1.221/30":            {"192.168.1.220", "192.168.1.223"},
+		"fd00::/120":                  {"fd00::", "fd00::ff"},
+	} {
+		r, err := ParseAddressRange(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, netip.MustParseAddr(want[0]), r.First, s)
+		assert.Equal(t, netip.MustParseAddr(want[1]), r.Last, s)
+	}
+	for _, s := range []string{"", "eth0", "192.168.1.10-fd00::1", "192.168.1.10-192.168.1.1", "192.168.1.0/33"} {
+		_, err := ParseAddressRange(s)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+)
+
+type LogFormatEnum string
+
+const (
+	LogFormatText LogFormatEnum = "text"
+	LogFormatJSON LogFormatEnum = "json"
+)
+
+type EventsStatusEnum string
+
+const (
+	EventsStatusEnabled  EventsStatusEnum = "Enabled"
+	EventsStatusDisabled EventsStatusEnum = "Disabled"
+)
+
+type ForwardingStatusEnum string
+
+const (
+	ForwardingStatusEnabled  ForwardingStatusEnum = "Enabled"
+	ForwardingStatusDisabled ForwardingStatusEnum = "Disabled"
+)
+
+type LogSourceEnum string
+
+const (
+	// LogSourceAudit is the audit log of the kube-apiserver.
+	LogSourceAudit LogSourceEnum = "audit"
+	// LogSourceKubelet is the log of the kubelet in the journal of
+	// MicroShift.
+	LogSourceKubelet LogSourceEnum = "kubelet"
+)
+
+// Logging configures the output of the logs of MicroShift and of its
+// embedded components.
+type Logging struct {
+	// Format of the logs, text or json. With json, each line is an object
+	// with the ts, caller, component and msg fields, v for the verbosity
+	// of informational messages and err for errors, followed by the
+	// key/value pairs of the message.
+	// +kubebuilder:default="text"
+	Format LogFormatEnum `json:"format"`
+
+	Events LoggingEvents `json:"events"`
+
+	Forwarding LoggingForwarding `json:"forwarding"`
+}
+
+// LoggingEvents configures the mirroring of the Warning events of the
+// cluster to journald, where they can be read with
+// journalctl -t microshift-events.
+type LoggingEvents struct {
+	// Whether the Warning events are mirrored to journald, Enabled or
+	// Disabled.
+	// +kubebuilder:default="Enabled"
+	Status EventsStatusEnum `json:"status"`
+
+	// Maximum number of events mirrored per minute, the events over it are
+	// dropped and counted.
+	// +kubebuilder:default=60
+	MaxPerMinute *int `json:"maxPerMinute"`
+}
+
+// LoggingForwarding configures the shipping of the logs of the node to a
+// central collector.
+type LoggingForwarding struct {
+	// Whether the logs are forwarded, Enabled or Disabled.
+	// +kubebuilder:default="Disabled"
+	Status ForwardingStatusEnum `json:"status"`
+
+	// URL of the collector. With tcp://host:port or udp://host:port, each
+	// line is sent as a syslog message. With https://, the lines are POSTed
+	// in batches as JSON objects, one per line, with the source, time, host
+	// and message fields.
+	// +kubebuilder:example=tcp://logs.example.com:514
+	Endpoint string `json:"endpoint"`
+
+	// Logs to forward, audit for the audit log of the kube-apiserver and
+	// kubelet for the messages of the kubelet.
+	// +kubebuilder:default={"audit","kubelet"}
+	Sources []LogSourceEnum `json:"sources"`
+
+	// Path of the bundle of CA certificates used to verify an https
+	// endpoint, the system trust store is used when empty.
+	CAFile string `json:"caFile"`
+
+	// Disk space, in megabytes, used to keep the logs while the collector
+	// is unreachable. The oldest logs are dropped when it is full.
+	// +kubebuilder:default=100
+	BufferSizeMB *int `json:"bufferSizeMB"`
+}
+
+func (l *Logging) validate() error {
+	switch l.Format {
+	case LogFormatText, LogFormatJSON:
+	default:
+		return fmt.Errorf("unsupported logging.format value %v", l.Format)
+	}
+	switch l.Events.Status {
+	case EventsStatusEnabled, EventsStatusDisabled:
+	default:
+		return fmt.Errorf("unsupported logging.events.status value %v", l.Events.Status)
+	}
+	if l.Events.MaxPerMinute != nil && *l.Events.MaxPerMinute < 1 {
+		return fmt.Errorf("logging.events.maxPerMinute must be positive, got %d", *l.Events.MaxPerMinute)
+	}
+	return l.Forwarding.validate()
+}
+
+func (f *LoggingForwarding) validate() error {
+	switch f.Status {
+	case ForwardingStatusEnabled, ForwardingStatusDisabled:
+	default:
+		return fmt.Errorf("unsupported logging.forwarding.status value %v", f.Status)
+	}
+	for _, source := range f.Sources {
+		if !slices.Contains([]LogSourceEnum{LogSourceAudit, LogSourceKubelet}, source) {
+			return fmt.Errorf("unsupported logging.forwarding.sources value %v", source)
+		}
+	}
+	if f.BufferSizeMB != nil && *f.BufferSizeMB < 1 {
+		return fmt.Errorf("logging.forwarding.bufferSizeMB must be positive, got %d", *f.BufferSizeMB)
+	}
+	if f.Status == ForwardingStatusDisabled {
+		return nil
+	}
+
+	if f.Endpoint == "" {
+		return fmt.Errorf("logging.forwarding.endpoint is required when the forwarding is enabled")
+	}
+	u, err := url.Parse(f.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid logging.forwarding.endpoint %q: %w", f.Endpoint, err)
+	}
+	switch u.Scheme {
+	case "tcp", "udp":
+		if u.Port() == "" {
+			return fmt.Errorf("logging.forwarding.endpoint %q must include a port", f.Endpoint)
+		}
+	case "https":
+	default:
+		return fmt.Errorf("unsupported scheme %q of logging.forwarding.endpoint, must be one of tcp, udp or https", u.Scheme)
+	}
+	if f.CAFile != "" && u.Scheme != "https" {
+		return fmt.Errorf("logging.forwarding.caFile is only used with an https endpoint")
+	}
+	if len(f.Sources) == 0 {
+		return fmt.Errorf("logging.forwarding.sources must not be empty when the forwarding is enabled")
+	}
+	return nil
+}
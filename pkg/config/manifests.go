@@ -3,7 +3,9 @@ package config
 import (
 	"fmt"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
 
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/kustomize/api/konfig"
@@ -16,17 +18,78 @@ const (
 	// for files embedded in ostree. i.e. cni/other component customizations
 	defaultManifestDirLib     = "/usr/lib/microshift/manifests"
 	defaultManifestDirLibGlob = "/usr/lib/microshift/manifests.d/*"
+
+	// OCIScheme prefixes the kustomizePaths which reference an OCI
+	// artifact holding a kustomization, e.g. oci://quay.io/org/app:1.0.
+	OCIScheme = "oci://"
+
+	// ChartArchivePattern matches the Helm chart archives of the manifests
+	// directories without a kustomization, which are applied rendered.
+	ChartArchivePattern = "*.tgz"
+)
+
+// ociManifestsDir holds the kustomizations pulled from OCI artifacts.
+func ociManifestsDir() string { return filepath.Join(DataDir, "manifests-oci") }
+
+type ManifestsConflictPolicyEnum string
+
+const (
+	ManifestsConflictPolicyForce ManifestsConflictPolicyEnum = "Force"
+	ManifestsConflictPolicyFail  ManifestsConflictPolicyEnum = "Fail"
 )
 
 type Manifests struct {
+	// How the manifests are applied when the fields they define were
+	// changed by another field manager, e.g. with kubectl edit. Force
+	// reverts the changes and takes ownership of the fields, Fail does
+	// not apply the kustomization.
+	// +kubebuilder:default="Force"
+	ConflictPolicy ManifestsConflictPolicyEnum `json:"conflictPolicy"`
+
 	// The locations on the filesystem to scan for kustomization
 	// files to use to load manifests. Set to a list of paths to scan
 	// only those paths. Set to an empty list to disable loading
 	// manifests. The entries in the list can be glob patterns to
-	// match multiple subdirectories.
+	// match multiple subdirectories, or references to OCI artifacts
+	// holding a kustomization, prefixed with oci://.
 	//
 	// +kubebuilder:default={"/usr/lib/microshift/manifests","/usr/lib/microshift/manifests.d/*","/etc/microshift/manifests","/etc/microshift/manifests.d/*"}
 	KustomizePaths []string `json:"kustomizePaths"`
+
+	// Reconcile configures applying the manifests again after they are
+	// applied on start.
+	Reconcile ManifestsReconcile `json:"reconcile"`
+}
+
+type ManifestsReconcile struct {
+	// Interval, in seconds, at which the manifests are applied again,
+	// reverting the changes made to the resources they define. The
+	// manifests are also applied again as soon as their files change.
+	// 0 applies the manifests only once, on start.
+	// +kubebuilder:default=0
+	IntervalSeconds *int `json:"intervalSeconds"`
+}
+
+func (m Manifests) validate() error {
+	switch m.ConflictPolicy {
+	case ManifestsConflictPolicyForce, ManifestsConflictPolicyFail:
+	default:
+		return fmt.Errorf("unsupported manifests.conflictPolicy value %v", m.ConflictPolicy)
+	}
+	for _, path := range m.KustomizePaths {
+		ref, isOCI := strings.CutPrefix(path, OCIScheme)
+		if isOCI && (ref == "" || strings.ContainsAny(ref, " \t\n")) {
+			return fmt.Errorf("manifests.kustomizePaths %q is not a valid OCI reference", path)
+		}
+	}
+	return m.Reconcile.validate()
+}
+
+func (r ManifestsReconcile) validate() error {
+	if r.IntervalSeconds != nil && *r.IntervalSeconds < 0 {
+		return fmt.Errorf("manifests.reconcile.intervalSeconds must not be negative, got %d", *r.IntervalSeconds)
+	}
+	return nil
 }
 
 // GetKustomizationPaths returns the list of configured paths for
@@ -40,6 +103,10 @@ func (m *Manifests) GetKustomizationPaths() ([]string, error) {
 func (m *Manifests) GetKustomizationDeletePaths() ([]string, error) {
 	deletePaths := make([]string, 0, len(m.KustomizePaths))
 	for _, path := range m.KustomizePaths {
+		// OCI artifacts are deleted by removing them from the list.
+		if strings.HasPrefix(path, OCIScheme) {
+			continue
+		}
 		// If kustomize path ends with "*": add "delete" dir before it.
 		// Otherwise, just add it at the end.
 		dir, file := filepath.Split(path)
@@ -52,10 +119,20 @@ func (m *Manifests) GetKustomizationDeletePaths() ([]string, error) {
 	return getKustomizationPaths(deletePaths)
 }
 
+// OCIManifestDir returns the directory the kustomization of the OCI
+// reference is pulled to.
+func OCIManifestDir(ref string) string {
+	name := strings.NewReplacer("/", "_", ":", "_", "@", "_").Replace(strings.TrimPrefix(ref, OCIScheme))
+	return filepath.Join(ociManifestsDir(), name)
+}
+
 func getKustomizationPaths(kustomizePaths []string) ([]string, error) {
 	kustomizationFileNames := konfig.RecognizedKustomizationFileNames()
 	results := []string{}
 	for _, path := range kustomizePaths {
+		if strings.HasPrefix(path, OCIScheme) {
+			path = OCIManifestDir(path)
+		}
 		for _, filename := range kustomizationFileNames {
 			pattern := filepath.Join(path, filename)
 			matches, err := filepath.Glob(pattern)
@@ -79,6 +156,21 @@ func getKustomizationPaths(kustomizePaths []string) ([]string, error) {
 				results = append(results, filepath.Dir(match))
 			}
 		}
+
+		// Directories holding a chart archive instead of a kustomization.
+		charts, err := filepath.Glob(filepath.Join(path, ChartArchivePattern))
+		if err != nil {
+			return nil, fmt.Errorf("could not understand kustomizePath value %v: %w", path, err)
+		}
+		sort.Strings(charts)
+		for _, chart := range charts {
+			dir := filepath.Dir(chart)
+			if slices.Contains(results, dir) {
+				continue
+			}
+			klog.Infof("Adding chart path %v", dir)
+			results = append(results, dir)
+		}
 	}
 	return results, nil
 }
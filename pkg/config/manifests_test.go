@@ -43,6 +43,10 @@ func TestGetKustomizationPaths(t *testing.T) {
 	assert.NoError(t, makeTestKustomizeDir("no-ext"))
 	assert.NoError(t, makeTestKustomize("no-ext"))
 
+	assert.NoError(t, makeTestKustomizeDir("chart"))
+	assert.NoError(t, os.WriteFile(filepath.Join(kustomizeDirName("chart"), "app-1.0.0.tgz"), []byte{}, 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(kustomizeDirName("yaml"), "app-1.0.0.tgz"), []byte{}, 0600))
+
 	assert.NoError(t, makeTestKustomizeDir("parent"))
 	assert.NoError(t, makeTestKustomizeDir("parent/a"))
 	assert.NoError(t, makeTestKustomizeYaml("parent/a"))
@@ -78,6 +82,20 @@ func TestGetKustomizationPaths(t *testing.T) {
 				kustomizeDirName("yml"),
 			},
 		},
+		{
+			// Directories without a kustomization may hold a chart.
+			name: "chart",
+			manifests: &Manifests{
+				KustomizePaths: []string{
+					kustomizeDirName("chart"),
+					kustomizeDirName("yaml"),
+				},
+			},
+			results: []string{
+				kustomizeDirName("chart"),
+				kustomizeDirName("yaml"),
+			},
+		},
 		{
 			name: "o*",
 			manifests: &Manifests{
@@ -152,3 +170,8 @@ func TestGetKustomizationPaths(t *testing.T) {
 		})
 	}
 }
+
+func TestOCIManifestDir(t *testing.T) {
+	assert.Equal(t, "/var/lib/microshift/manifests-oci/quay.io_org_app_1.0", OCIManifestDir("oci://quay.io/org/app:1.0"))
+	assert.Equal(t, "/var/lib/microshift/manifests-oci/registry_5000_app_sha256_abc", OCIManifestDir("oci://registry:5000/app@sha256:abc"))
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+type MDNSStatusEnum string
+
+const (
+	MDNSStatusEnabled  MDNSStatusEnum = "Enabled"
+	MDNSStatusDisabled MDNSStatusEnum = "Disabled"
+)
+
+// MDNS configures the mDNS responder announcing the node name and the
+// hosts of the routes in the mDNS domain.
+type MDNS struct {
+	// Whether the mDNS responder runs, Enabled or Disabled. Disable it on
+	// hosts running another responder, such as Avahi.
+	// +kubebuilder:default="Enabled"
+	Status MDNSStatusEnum `json:"status"`
+
+	// Domain of the names announced. Only the node name, the route hosts
+	// and the hostnames under it are announced.
+	// +kubebuilder:default="local"
+	Domain string `json:"domain"`
+
+	// Additional host names announced with the node IPs, under the domain.
+	Hostnames []string `json:"hostnames"`
+
+	// Names of the interfaces the mDNS responder answers on, as shell
+	// patterns, e.g. "eth*". All interfaces but the OVN-Kubernetes ones
+	// are used when empty.
+	Interfaces []string `json:"interfaces"`
+
+	// Names of the interfaces the mDNS responder never answers on, as
+	// shell patterns, e.g. "wwan*".
+	ExcludeInterfaces []string `json:"excludeInterfaces"`
+}
+
+func (m MDNS) validate() error {
+	switch m.Status {
+	case MDNSStatusEnabled, MDNSStatusDisabled:
+	default:
+		return fmt.Errorf("unsupported mdns.status value %v", m.Status)
+	}
+	if errs := validation.IsDNS1123Subdomain(m.Domain); len(errs) != 0 {
+		return fmt.Errorf("mdns.domain %q is not a valid DNS subdomain: %s", m.Domain, strings.Join(errs, ", "))
+	}
+	for _, hostname := range m.Hostnames {
+		if errs := validation.IsDNS1123Subdomain(hostname); len(errs) != 0 {
+			return fmt.Errorf("mdns.hostnames %q is not a valid DNS subdomain: %s", hostname, strings.Join(errs, ", "))
+		}
+		if !strings.HasSuffix(hostname, "."+m.Domain) {
+			return fmt.Errorf("mdns.hostnames %q must be under mdns.domain %q", hostname, m.Domain)
+		}
+	}
+	for _, patterns := range [][]string{m.Interfaces, m.ExcludeInterfaces} {
+		for _, pattern := range patterns {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("mdns interface pattern %q is invalid: %w", pattern, err)
+			}
+		}
+	}
+	return nil
+}
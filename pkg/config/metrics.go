@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// MetricsUnixScheme prefixes metrics listen addresses that are unix sockets.
+	MetricsUnixScheme = "unix://"
+)
+
+type KubeStateMetricsStatusEnum string
+
+const (
+	KubeStateMetricsStatusManaged KubeStateMetricsStatusEnum = "Managed"
+	KubeStateMetricsStatusRemoved KubeStateMetricsStatusEnum = "Removed"
+)
+
+// Metrics configures the endpoint serving the metrics of MicroShift itself
+// and the bundled kube-state-metrics.
+type Metrics struct {
+	// Address to serve the MicroShift metrics on, for scraping by
+	// monitoring agents: a host:port, e.g. localhost:10262, or a unix
+	// socket, e.g. unix:///run/microshift/metrics.sock. The endpoint is not
+	// authenticated, prefer localhost or a unix socket.
+	// Metrics are not served when empty.
+	ListenAddress string `json:"listenAddress"`
+
+	KubeStateMetrics KubeStateMetrics `json:"kubeStateMetrics"`
+}
+
+// KubeStateMetrics configures the bundled kube-state-metrics, reporting the
+// state of the workloads and of the node without a monitoring stack.
+type KubeStateMetrics struct {
+	// Whether kube-state-metrics is deployed, Managed or Removed. It is
+	// limited to the pods, workloads, jobs, volume claims and node, and
+	// uses less than 30MB of memory. Removing it deletes its namespace.
+	// +kubebuilder:default="Removed"
+	Status KubeStateMetricsStatusEnum `json:"status"`
+}
+
+func (m *Metrics) validate() error {
+	switch m.KubeStateMetrics.Status {
+	case KubeStateMetricsStatusManaged, KubeStateMetricsStatusRemoved:
+	default:
+		return fmt.Errorf("unsupported metrics.kubeStateMetrics.status value %v", m.KubeStateMetrics.Status)
+	}
+	if m.ListenAddress == "" {
+		return nil
+	}
+	if path, ok := strings.CutPrefix(m.ListenAddress, MetricsUnixScheme); ok {
+		if !filepath.IsAbs(path) {
+			return fmt.Errorf("metrics.listenAddress must be an absolute unix socket path like unix:///path/to/socket, got %q", m.ListenAddress)
+		}
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(m.ListenAddress); err != nil {
+		return fmt.Errorf("metrics.listenAddress must be a host:port or a unix socket, got %q: %w", m.ListenAddress, err)
+	}
+	return nil
+}
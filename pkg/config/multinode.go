@@ -1,10 +1,30 @@
 package config
 
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// RoleControlPlane runs the control plane: etcd, the API server and
+	// the controllers.
+	RoleControlPlane = "controlplane"
+	// RoleNode runs the kubelet.
+	RoleNode = "node"
+)
+
 type MultiNodeConfig struct {
 	Enabled bool `json:"enabled"`
 	// only one controlplane node is supported
 	// IP address of control plane node
 	Controlplane string `json:"controlplane"`
+
+	// Worker is set when the node only has the node role, and joins the
+	// control plane at JoinURL.
+	Worker         bool   `json:"worker"`
+	JoinURL        string `json:"joinURL"`
+	JoinToken      string `json:"-"`
+	JoinCACertHash string `json:"joinCACertHash"`
 }
 
 // ConfigMultiNode populates multinode configurations to Config.MultiNode
@@ -23,3 +43,36 @@ func ConfigMultiNode(c *Config, enabled bool) *Config {
 
 	return c
 }
+
+// ConfigWorkerNode populates the worker configurations to Config.MultiNode
+// from the roles of the node and the join settings. A node with both roles
+// is not a worker, and the join settings are only valid for workers.
+func ConfigWorkerNode(c *Config, roles []string, joinURL, joinToken, joinCACertHash string) (*Config, error) {
+	controlPlane, node := false, false
+	for _, r := range roles {
+		switch strings.TrimSpace(r) {
+		case RoleControlPlane:
+			controlPlane = true
+		case RoleNode:
+			node = true
+		default:
+			return nil, fmt.Errorf("unsupported role %q, must be %q or %q", r, RoleControlPlane, RoleNode)
+		}
+	}
+	if !node {
+		return nil, fmt.Errorf("the %q role is required", RoleNode)
+	}
+	if controlPlane {
+		if joinURL != "" || joinToken != "" || joinCACertHash != "" {
+			return nil, fmt.Errorf("--join-url, --join-token and --join-ca-cert-hash require --roles=%s", RoleNode)
+		}
+		return c, nil
+	}
+
+	c.MultiNode.Enabled = true
+	c.MultiNode.Worker = true
+	c.MultiNode.JoinURL = joinURL
+	c.MultiNode.JoinToken = joinToken
+	c.MultiNode.JoinCACertHash = joinCACertHash
+	return c, nil
+}
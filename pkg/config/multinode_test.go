@@ -0,0 +1,58 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigWorkerNode(t *testing.T) {
+	tests := []struct {
+		name       string
+		roles      []string
+		joinURL    string
+		wantWorker bool
+		wantErr    bool
+	}{
+		{
+			name:       "both roles is not a worker",
+			roles:      []string{RoleControlPlane, RoleNode},
+			wantWorker: false,
+		},
+		{
+			name:       "node role only is a worker",
+			roles:      []string{RoleNode},
+			joinURL:    "https://192.168.1.10:7443",
+			wantWorker: true,
+		},
+		{
+			name:    "control plane without node role is unsupported",
+			roles:   []string{RoleControlPlane},
+			wantErr: true,
+		},
+		{
+			name:    "unknown role",
+			roles:   []string{RoleNode, "etcd"},
+			wantErr: true,
+		},
+		{
+			name:    "join settings require the node role only",
+			roles:   []string{RoleControlPlane, RoleNode},
+			joinURL: "https://192.168.1.10:7443",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ConfigWorkerNode(&Config{}, tt.roles, tt.joinURL, "", "")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantWorker, c.MultiNode.Worker)
+			assert.Equal(t, tt.wantWorker, c.MultiNode.Enabled)
+			assert.Equal(t, tt.joinURL, c.MultiNode.JoinURL)
+		})
+	}
+}
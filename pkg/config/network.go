@@ -3,13 +3,14 @@ package config
 import (
 	"fmt"
 	"net"
+	"strings"
 
 	"github.com/apparentlymart/go-cidr/cidr"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
-// CNIPlugin is an enum value that determines whether MicroShift deploys OVNK.
-// +kubebuilder:validation:Enum:="";none;ovnk
+// CNIPlugin is an enum value that determines the CNI MicroShift deploys.
+// +kubebuilder:validation:Enum:="";none;ovnk;flannel;bridge
 type CNIPlugin string
 
 const (
@@ -24,14 +25,29 @@ const (
 	// CniPluginOVNK is equivalent to CniPluginUnset, and explicitly tells MicroShift to deploy OVNK. This option exists to
 	// provide a differentiation between OVNK and potential future CNI options.
 	CniPluginOVNK CNIPlugin = "ovnk"
+	// CniPluginFlannel tells MicroShift to not deploy OVNK, the pod network being provided by the flannel and
+	// kube-proxy manifests of the microshift-flannel package.
+	CniPluginFlannel CNIPlugin = "flannel"
+	// CniPluginBridge tells MicroShift to connect the pods to a bridge of the host with the bridge CNI plugin,
+	// instead of deploying OVNK. It is only supported on a single node.
+	CniPluginBridge CNIPlugin = "bridge"
+)
+
+type MultusStatusEnum string
+
+const (
+	MultusStatusManaged   MultusStatusEnum = "Managed"
+	MultusStatusRemoved   MultusStatusEnum = "Removed"
+	MultusStatusUnmanaged MultusStatusEnum = "Unmanaged"
 )
 
 type Network struct {
 	// CNIPlugin is a user defined string value matching one of the above CNI values. MicroShift uses this
-	// value to decide whether to deploy the OVN-K as default CNI. An unset field defaults to "" during yaml parsing, and thus
+	// value to decide which CNI to deploy. An unset field defaults to "" during yaml parsing, and thus
 	// could mean that the cluster has been upgraded. In order to support the existing out-of-box behavior, MicroShift
-	// assumes an empty string to mean the OVN-K should be deployed.
-	// Allowed values are: unset or one of ["", "ovnk", "none"]
+	// assumes an empty string to mean the OVN-K should be deployed. "flannel" and "none" leave the pod network
+	// to the CNI of the manifests, and "bridge" connects the pods to a bridge of the host.
+	// Allowed values are: unset or one of ["", "ovnk", "flannel", "bridge", "none"]
 	// +kubebuilder:validation:Optional
 	CNIPlugin CNIPlugin `json:"cniPlugin,omitempty"`
 
@@ -56,10 +72,75 @@ type Network struct {
 	// +kubebuilder:default="30000-32767"
 	ServiceNodePortRange string `json:"serviceNodePortRange"`
 
+	// IP addresses, CIDRs or interface names of the host the NodePort
+	// services are exposed on, e.g. only those of the OT network and not
+	// of the management network. The LoadBalancer services without an
+	// address pool are assigned the first of these addresses instead of
+	// the node IP. The services are exposed on all the addresses when
+	// empty.
+	NodePortAddresses []string `json:"nodePortAddresses"`
+
+	Multus NetworkMultus `json:"multus"`
+
+	KubeProxy NetworkKubeProxy `json:"kubeProxy"`
+
+	Egress NetworkEgress `json:"egress"`
+
 	// The DNS server to use
 	DNS string `json:"-"`
 }
 
+// NetworkMultus configures the bundled Multus CNI, attaching the pods to
+// secondary networks with NetworkAttachmentDefinitions.
+type NetworkMultus struct {
+	// Whether Multus and the bridge, ipvlan, macvlan, static, dhcp and
+	// host-local CNI plugins are deployed, Managed, Removed or Unmanaged.
+	// Removing them deletes their namespace but keeps the
+	// NetworkAttachmentDefinitions. Unmanaged leaves them to the
+	// microshift-multus package.
+	// +kubebuilder:default="Unmanaged"
+	Status MultusStatusEnum `json:"status"`
+}
+
+// NetworkEgress configures the source address of the traffic the pods send
+// out of the cluster.
+type NetworkEgress struct {
+	// Addresses of the host the namespaces may select with the
+	// microshift.io/egress-ip annotation, for the traffic of their pods
+	// leaving the cluster to be translated to them instead of the node IP.
+	// They must be configured on the interfaces of the host.
+	Addresses []string `json:"addresses"`
+}
+
+func (n Network) validateEgress() error {
+	for _, address := range n.Egress.Addresses {
+		if net.ParseIP(address) == nil {
+			return fmt.Errorf("network.egress.addresses %q is not a valid IP address", address)
+		}
+	}
+	if len(n.Egress.Addresses) != 0 && !n.IsEnabled() {
+		return fmt.Errorf("network.egress.addresses requires a network.cniPlugin")
+	}
+	return nil
+}
+
+func (n Network) validateNodePortAddresses() error {
+	for _, entry := range n.NodePortAddresses {
+		if net.ParseIP(entry) != nil {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(entry); err == nil {
+			continue
+		}
+		// Interface names are at most 15 characters, without slashes nor
+		// whitespace.
+		if entry == "" || len(entry) > 15 || strings.ContainsAny(entry, "/ \t\n") {
+			return fmt.Errorf("network.nodePortAddresses %q is neither an IP address, a CIDR nor an interface name", entry)
+		}
+	}
+	return nil
+}
+
 func (c *Config) computeClusterDNS() (string, error) {
 	if len(c.Network.ServiceNetwork) == 0 {
 		return "", fmt.Errorf("network.serviceNetwork not filled in")
@@ -92,7 +173,26 @@ func isValidIPAddress(ipAddress string) bool {
 }
 
 func (n Network) validCNIPlugin() (isSupported bool) {
-	return sets.New[CNIPlugin](CniPluginUnset, CniPluginOVNK, CniPluginNone).Has(n.CNIPlugin)
+	return sets.New[CNIPlugin](CniPluginUnset, CniPluginOVNK, CniPluginFlannel, CniPluginBridge, CniPluginNone).Has(n.CNIPlugin)
+}
+
+func (n Network) validateMultus() error {
+	switch n.Multus.Status {
+	case MultusStatusManaged:
+		if n.CNIPlugin == CniPluginNone {
+			return fmt.Errorf("network.multus.status %v requires a network.cniPlugin for the default network", n.Multus.Status)
+		}
+	case MultusStatusRemoved, MultusStatusUnmanaged:
+	default:
+		return fmt.Errorf("unsupported network.multus.status value %v", n.Multus.Status)
+	}
+	return nil
+}
+
+// IsOVNK returns whether MicroShift deploys OVN-K, which is also the case when
+// .network.cniPlugin is unset.
+func (n Network) IsOVNK() bool {
+	return n.CNIPlugin == CniPluginUnset || n.CNIPlugin == CniPluginOVNK
 }
 
 // IsEnabled returns false only when .network.cniPlugin: "none". An empty value is considered "enabled"
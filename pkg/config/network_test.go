@@ -39,6 +39,18 @@ func TestCNIPlugin_IsEnabled(t *testing.T) {
 	}
 }
 
+func TestNetwork_IsOVNK(t *testing.T) {
+	for plugin, want := range map[CNIPlugin]bool{
+		CniPluginUnset:   true,
+		CniPluginOVNK:    true,
+		CniPluginFlannel: false,
+		CniPluginBridge:  false,
+		CniPluginNone:    false,
+	} {
+		assert.Equalf(t, want, Network{CNIPlugin: plugin}.IsOVNK(), "IsOVNK() with %q", plugin)
+	}
+}
+
 func TestNetwork_cniPluginIsValid(t *testing.T) {
 	type fields struct {
 		CNIPlugin CNIPlugin
@@ -62,6 +74,20 @@ func TestNetwork_cniPluginIsValid(t *testing.T) {
 			},
 			wantIsSupported: true,
 		},
+		{
+			name: "is valid when value is flannel",
+			fields: fields{
+				CNIPlugin: CniPluginFlannel,
+			},
+			wantIsSupported: true,
+		},
+		{
+			name: "is valid when value is bridge",
+			fields: fields{
+				CNIPlugin: CniPluginBridge,
+			},
+			wantIsSupported: true,
+		},
 		{
 			name: "is invalid when value does not match one of predefined drivers",
 			fields: fields{
@@ -79,3 +105,23 @@ func TestNetwork_cniPluginIsValid(t *testing.T) {
 		})
 	}
 }
+
+func TestIPv6SingleStack(t *testing.T) {
+	c := NewDefault()
+	c.Node.NodeIP = "2001:db8::10"
+	c.Network.ClusterNetwork = nil
+	c.Network.ServiceNetwork = nil
+	c.ApiServer.AdvertiseAddress = ""
+	assert.NoError(t, c.updateComputedValues())
+
+	assert.True(t, c.IsIPv6Only())
+	assert.Equal(t, []string{"fd01::/48"}, c.Network.ClusterNetwork)
+	assert.Equal(t, []string{"fd02::/112"}, c.Network.ServiceNetwork)
+	assert.Equal(t, "fd02::a", c.Network.DNS)
+	assert.Equal(t, "fd02::1:0", c.ApiServer.AdvertiseAddress)
+	assert.Equal(t, "::", c.UnspecifiedAddress())
+	assert.NoError(t, validateNetworkStack(c))
+
+	c.Node.NodeIP = "192.0.2.10"
+	assert.Error(t, validateNetworkStack(c))
+}
@@ -5,9 +5,14 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/klog/v2"
+
+	"github.com/openshift/microshift/pkg/util"
 )
 
 type Node struct {
@@ -21,6 +26,451 @@ type Node struct {
 	// IPv6 address of the node, passed to the kubelet. This parameter
 	// is only allowed when dual stack deployment is configured.
 	NodeIPV6 string `json:"nodeIPv6"`
+
+	// NodeIPSelection configures how the node IP is chosen among the
+	// addresses of the host when nodeIP is not set. The address of the
+	// interface of the default route is used when empty.
+	NodeIPSelection NodeIPSelection `json:"nodeIPSelection"`
+
+	// Drain configures how workloads are stopped when MicroShift stops.
+	Drain NodeDrain `json:"drain"`
+
+	// GracefulShutdown configures how workloads are stopped when the host
+	// shuts down or reboots.
+	GracefulShutdown NodeGracefulShutdown `json:"gracefulShutdown"`
+
+	// Eviction configures when the kubelet evicts pods to reclaim the
+	// resources of the node.
+	Eviction NodeEviction `json:"eviction"`
+
+	// TopologyManager configures how the kubelet aligns the CPUs and the
+	// devices of device plugins, such as GPUs, allocated to a pod on the
+	// same NUMA node.
+	TopologyManager NodeTopologyManager `json:"topologyManager"`
+
+	// CPUManager configures how the kubelet assigns CPUs to containers.
+	CPUManager NodeCPUManager `json:"cpuManager"`
+
+	// MemoryManager configures how the kubelet assigns the memory of NUMA
+	// nodes to containers.
+	MemoryManager NodeMemoryManager `json:"memoryManager"`
+
+	// ResourceReservation configures the resources of the host reserved for
+	// the operating system and MicroShift, which pods cannot use.
+	ResourceReservation NodeResourceReservation `json:"resourceReservation"`
+
+	// Swap configures whether workloads may use the swap of the host,
+	// e.g. zram on memory-constrained devices.
+	Swap NodeSwap `json:"swap"`
+}
+
+const (
+	IPFamilyIPv4 IPFamilyEnum = "IPv4"
+	IPFamilyIPv6 IPFamilyEnum = "IPv6"
+)
+
+type IPFamilyEnum string
+
+type NodeIPSelection struct {
+	// Names of the interfaces the node IP may be taken from, as shell
+	// patterns, e.g. "eth*". All interfaces are allowed when empty.
+	Interfaces []string `json:"interfaces"`
+
+	// Names of the interfaces the node IP is never taken from, as shell
+	// patterns, e.g. "wwan*".
+	ExcludeInterfaces []string `json:"excludeInterfaces"`
+
+	// Subnets, in CIDR notation, whose addresses are preferred, in order
+	// of preference.
+	PreferredSubnets []string `json:"preferredSubnets"`
+
+	// IP family preferred when the host has addresses of both families,
+	// IPv4 or IPv6.
+	// +kubebuilder:default="IPv4"
+	PreferredIPFamily IPFamilyEnum `json:"preferredIPFamily"`
+}
+
+func (s NodeIPSelection) validate() error {
+	for _, patterns := range [][]string{s.Interfaces, s.ExcludeInterfaces} {
+		for _, pattern := range patterns {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("node.nodeIPSelection interface pattern %q is invalid: %w", pattern, err)
+			}
+		}
+	}
+	for _, subnet := range s.PreferredSubnets {
+		if _, _, err := net.ParseCIDR(subnet); err != nil {
+			return fmt.Errorf("node.nodeIPSelection.preferredSubnets %q is not a valid CIDR: %w", subnet, err)
+		}
+	}
+	switch s.PreferredIPFamily {
+	case IPFamilyIPv4, IPFamilyIPv6:
+	default:
+		return fmt.Errorf("unsupported node.nodeIPSelection.preferredIPFamily value %v", s.PreferredIPFamily)
+	}
+	return nil
+}
+
+// Policy returns the node IP selection policy applied to the addresses of
+// the host.
+func (s NodeIPSelection) Policy() util.NodeIPPolicy {
+	return util.NodeIPPolicy{
+		Interfaces:        s.Interfaces,
+		ExcludeInterfaces: s.ExcludeInterfaces,
+		PreferredSubnets:  s.PreferredSubnets,
+		PreferIPv6:        s.PreferredIPFamily == IPFamilyIPv6,
+	}
+}
+
+type NodeDrain struct {
+	// Maximum time, in seconds, to wait for the pods of the node to be
+	// evicted when MicroShift stops. The node is cordoned and its pods
+	// are evicted before the kubelet is stopped, so workloads can shut
+	// down cleanly. DaemonSet and static pods are not evicted.
+	// 0, the default, disables draining.
+	// +kubebuilder:default=0
+	TimeoutSeconds *int `json:"timeoutSeconds"`
+}
+
+type NodeGracefulShutdown struct {
+	// Time, in seconds, the host shutdown is delayed by to stop the pods of
+	// the node. The kubelet terminates the regular pods first and then the
+	// critical ones, while MicroShift drains the node. The delay is capped by
+	// the InhibitDelayMaxSec setting of systemd-logind, which the kubelet
+	// raises to this value. 0 disables graceful shutdown.
+	// +kubebuilder:default=30
+	GracePeriodSeconds *int `json:"gracePeriodSeconds"`
+
+	// Time, in seconds, out of gracePeriodSeconds reserved to terminate
+	// critical pods, i.e. the pods of the system-node-critical and
+	// system-cluster-critical priority classes.
+	// +kubebuilder:default=10
+	CriticalPodsGracePeriodSeconds *int `json:"criticalPodsGracePeriodSeconds"`
+}
+
+func (g NodeGracefulShutdown) validate() error {
+	if *g.GracePeriodSeconds < 0 {
+		return fmt.Errorf("node.gracefulShutdown.gracePeriodSeconds must not be negative, got %d", *g.GracePeriodSeconds)
+	}
+	if *g.CriticalPodsGracePeriodSeconds < 0 {
+		return fmt.Errorf("node.gracefulShutdown.criticalPodsGracePeriodSeconds must not be negative, got %d", *g.CriticalPodsGracePeriodSeconds)
+	}
+	if *g.GracePeriodSeconds > 0 && *g.CriticalPodsGracePeriodSeconds > *g.GracePeriodSeconds {
+		return fmt.Errorf("node.gracefulShutdown.criticalPodsGracePeriodSeconds must not exceed gracePeriodSeconds")
+	}
+	return nil
+}
+
+type NodeEviction struct {
+	// Thresholds, by eviction signal, which trigger the immediate eviction
+	// of pods, e.g. "memory.available: 100Mi" or "nodefs.available: 10%".
+	// The kubelet defaults are used when empty.
+	Hard map[string]string `json:"hard"`
+
+	// Thresholds, by eviction signal, which trigger the eviction of pods
+	// once they are exceeded for the grace period of the signal.
+	Soft map[string]string `json:"soft"`
+
+	// Grace periods, by eviction signal, of the soft thresholds,
+	// e.g. "memory.available: 1m30s".
+	SoftGracePeriod map[string]string `json:"softGracePeriod"`
+
+	// Time, in seconds, the kubelet waits before leaving a node pressure
+	// condition, so pods are not scheduled back while the node is
+	// still under pressure.
+	// +kubebuilder:default=300
+	PressureTransitionPeriodSeconds *int `json:"pressureTransitionPeriodSeconds"`
+
+	// Maximum grace period, in seconds, given to pods evicted because a
+	// soft threshold was met. 0 uses the grace period of the pods.
+	// +kubebuilder:default=0
+	MaxPodGracePeriodSeconds *int `json:"maxPodGracePeriodSeconds"`
+}
+
+func (e NodeEviction) validate() error {
+	for signal := range e.Soft {
+		if _, ok := e.SoftGracePeriod[signal]; !ok {
+			return fmt.Errorf("node.eviction.softGracePeriod.%s must be set for the soft threshold", signal)
+		}
+	}
+	for signal, period := range e.SoftGracePeriod {
+		if _, ok := e.Soft[signal]; !ok {
+			return fmt.Errorf("node.eviction.softGracePeriod.%s has no soft threshold", signal)
+		}
+		if d, err := time.ParseDuration(period); err != nil || d < 0 {
+			return fmt.Errorf("node.eviction.softGracePeriod.%s must be a non-negative duration, got %q", signal, period)
+		}
+	}
+	if e.PressureTransitionPeriodSeconds != nil && *e.PressureTransitionPeriodSeconds < 0 {
+		return fmt.Errorf("node.eviction.pressureTransitionPeriodSeconds must not be negative, got %d", *e.PressureTransitionPeriodSeconds)
+	}
+	if e.MaxPodGracePeriodSeconds != nil && *e.MaxPodGracePeriodSeconds < 0 {
+		return fmt.Errorf("node.eviction.maxPodGracePeriodSeconds must not be negative, got %d", *e.MaxPodGracePeriodSeconds)
+	}
+	return nil
+}
+
+const (
+	TopologyManagerPolicyNone           TopologyManagerPolicyEnum = "none"
+	TopologyManagerPolicyBestEffort     TopologyManagerPolicyEnum = "best-effort"
+	TopologyManagerPolicyRestricted     TopologyManagerPolicyEnum = "restricted"
+	TopologyManagerPolicySingleNUMANode TopologyManagerPolicyEnum = "single-numa-node"
+	TopologyManagerScopeContainer       TopologyManagerScopeEnum  = "container"
+	TopologyManagerScopePod             TopologyManagerScopeEnum  = "pod"
+)
+
+type TopologyManagerPolicyEnum string
+type TopologyManagerScopeEnum string
+
+type NodeTopologyManager struct {
+	// Describes how the topology hints of the device plugins and of the
+	// CPU manager are used when admitting a pod.
+	//
+	// Value must be one of:
+	//
+	// - none: Hints are ignored.
+	//
+	// - best-effort: Resources are aligned when possible.
+	//
+	// - restricted: Pods whose resources cannot be aligned are rejected.
+	//
+	// - single-numa-node: Pods whose resources cannot be allocated on a
+	//   single NUMA node are rejected.
+	//
+	// If empty, the default is none.
+	// +kubebuilder:default="none"
+	Policy TopologyManagerPolicyEnum `json:"policy"`
+
+	// Describes whether the resources are aligned per container or for the
+	// whole pod. Value must be container or pod.
+	// +kubebuilder:default="container"
+	Scope TopologyManagerScopeEnum `json:"scope"`
+}
+
+func (t NodeTopologyManager) validate() error {
+	switch t.Policy {
+	case TopologyManagerPolicyNone, TopologyManagerPolicyBestEffort, TopologyManagerPolicyRestricted, TopologyManagerPolicySingleNUMANode:
+	default:
+		return fmt.Errorf("unsupported node.topologyManager.policy value %v", t.Policy)
+	}
+	switch t.Scope {
+	case TopologyManagerScopeContainer, TopologyManagerScopePod:
+	default:
+		return fmt.Errorf("unsupported node.topologyManager.scope value %v", t.Scope)
+	}
+	return nil
+}
+
+const (
+	CPUManagerPolicyNone      CPUManagerPolicyEnum    = "none"
+	CPUManagerPolicyStatic    CPUManagerPolicyEnum    = "static"
+	MemoryManagerPolicyNone   MemoryManagerPolicyEnum = "None"
+	MemoryManagerPolicyStatic MemoryManagerPolicyEnum = "Static"
+)
+
+type CPUManagerPolicyEnum string
+type MemoryManagerPolicyEnum string
+
+type NodeCPUManager struct {
+	// Describes how CPUs are assigned to containers.
+	//
+	// Value must be one of:
+	//
+	// - none: Containers share the CPUs not reserved for the system.
+	//
+	// - static: Containers of Guaranteed pods requesting whole CPUs get
+	//   exclusive CPUs. reservedSystemCPUs must be set.
+	//
+	// If empty, the default is none.
+	// +kubebuilder:default="none"
+	Policy CPUManagerPolicyEnum `json:"policy"`
+
+	// Options of the static policy, e.g. "full-pcpus-only: true".
+	PolicyOptions map[string]string `json:"policyOptions"`
+
+	// CPUs, in the cpuset format, e.g. "0-1" or "0,4", reserved for the
+	// system and MicroShift, which are never assigned exclusively to
+	// containers.
+	ReservedSystemCPUs string `json:"reservedSystemCPUs"`
+}
+
+func (c NodeCPUManager) validate() error {
+	switch c.Policy {
+	case CPUManagerPolicyNone:
+		if len(c.PolicyOptions) != 0 {
+			return fmt.Errorf("node.cpuManager.policyOptions requires the static policy")
+		}
+	case CPUManagerPolicyStatic:
+		if c.ReservedSystemCPUs == "" {
+			return fmt.Errorf("node.cpuManager.reservedSystemCPUs must be set with the static policy")
+		}
+	default:
+		return fmt.Errorf("unsupported node.cpuManager.policy value %v", c.Policy)
+	}
+	if c.ReservedSystemCPUs != "" {
+		if err := validateCPUSet(c.ReservedSystemCPUs); err != nil {
+			return fmt.Errorf("node.cpuManager.reservedSystemCPUs %q is not a valid cpuset: %w", c.ReservedSystemCPUs, err)
+		}
+	}
+	return nil
+}
+
+// validateCPUSet checks the cpuset format of the kernel, e.g. "0-3,8".
+func validateCPUSet(cpus string) error {
+	for _, r := range strings.Split(cpus, ",") {
+		first, last, isRange := strings.Cut(r, "-")
+		start, err := strconv.Atoi(first)
+		if err != nil || start < 0 {
+			return fmt.Errorf("invalid CPU %q", first)
+		}
+		if !isRange {
+			continue
+		}
+		end, err := strconv.Atoi(last)
+		if err != nil || end < start {
+			return fmt.Errorf("invalid CPU range %q", r)
+		}
+	}
+	return nil
+}
+
+type NodeMemoryManager struct {
+	// Describes how the memory of NUMA nodes is assigned to containers.
+	//
+	// Value must be one of:
+	//
+	// - None: Memory is not assigned.
+	//
+	// - Static: Containers of Guaranteed pods get memory from the NUMA
+	//   nodes the topology manager aligns them to. reservedMemory must be
+	//   set.
+	//
+	// If empty, the default is None.
+	// +kubebuilder:default="None"
+	Policy MemoryManagerPolicyEnum `json:"policy"`
+
+	// Memory reserved for the system on each NUMA node. The total must
+	// match the memory reserved by the kubeReserved, systemReserved and
+	// evictionHard settings of the kubelet.
+	ReservedMemory []NodeReservedMemory `json:"reservedMemory"`
+}
+
+type NodeReservedMemory struct {
+	NUMANode int `json:"numaNode"`
+	// Quantity of memory, e.g. "1100Mi".
+	Memory string `json:"memory"`
+}
+
+func (m NodeMemoryManager) validate() error {
+	switch m.Policy {
+	case MemoryManagerPolicyNone:
+	case MemoryManagerPolicyStatic:
+		if len(m.ReservedMemory) == 0 {
+			return fmt.Errorf("node.memoryManager.reservedMemory must be set with the Static policy")
+		}
+	default:
+		return fmt.Errorf("unsupported node.memoryManager.policy value %v", m.Policy)
+	}
+	numaNodes := map[int]bool{}
+	for _, r := range m.ReservedMemory {
+		if r.NUMANode < 0 {
+			return fmt.Errorf("node.memoryManager.reservedMemory numaNode must not be negative, got %d", r.NUMANode)
+		}
+		if numaNodes[r.NUMANode] {
+			return fmt.Errorf("node.memoryManager.reservedMemory numaNode %d is listed more than once", r.NUMANode)
+		}
+		numaNodes[r.NUMANode] = true
+		if _, err := resource.ParseQuantity(r.Memory); err != nil {
+			return fmt.Errorf("node.memoryManager.reservedMemory memory %q of NUMA node %d is invalid: %w", r.Memory, r.NUMANode, err)
+		}
+	}
+	return nil
+}
+
+const (
+	AutoSizingEnabled  AutoSizingEnum = "Enabled"
+	AutoSizingDisabled AutoSizingEnum = "Disabled"
+)
+
+type AutoSizingEnum string
+
+type NodeResourceReservation struct {
+	// Describes whether the memory and CPU reserved for the system are
+	// computed from the memory and CPUs of the host, using the same tiers
+	// as OpenShift, e.g. 512Mi of the memory of a 2Gi host and 3645Mi of
+	// the memory of a 32Gi host.
+	//
+	// Value must be Enabled or Disabled.
+	// +kubebuilder:default="Disabled"
+	AutoSizing AutoSizingEnum `json:"autoSizing"`
+
+	// Resources reserved for the operating system, by resource name, e.g.
+	// "memory: 1Gi" or "cpu: 500m". They take precedence over the auto-sized
+	// ones.
+	SystemReserved map[string]string `json:"systemReserved"`
+
+	// Resources reserved for MicroShift, by resource name, e.g.
+	// "memory: 1Gi" or "cpu: 500m".
+	KubeReserved map[string]string `json:"kubeReserved"`
+}
+
+func (r NodeResourceReservation) validate() error {
+	switch r.AutoSizing {
+	case AutoSizingEnabled, AutoSizingDisabled:
+	default:
+		return fmt.Errorf("unsupported node.resourceReservation.autoSizing value %v", r.AutoSizing)
+	}
+	for name, reserved := range map[string]map[string]string{
+		"systemReserved": r.SystemReserved,
+		"kubeReserved":   r.KubeReserved,
+	} {
+		for resourceName, quantity := range reserved {
+			if _, err := resource.ParseQuantity(quantity); err != nil {
+				return fmt.Errorf("node.resourceReservation.%s %s quantity %q is invalid: %w", name, resourceName, quantity, err)
+			}
+		}
+	}
+	return nil
+}
+
+const (
+	SwapBehaviorNoSwap      SwapBehaviorEnum = "NoSwap"
+	SwapBehaviorLimitedSwap SwapBehaviorEnum = "LimitedSwap"
+)
+
+type SwapBehaviorEnum string
+
+// cgroupControllersPath only exists on hosts using the unified cgroup v2
+// hierarchy.
+var cgroupControllersPath = "/sys/fs/cgroup/cgroup.controllers"
+
+type NodeSwap struct {
+	// Describes how workloads use the swap of the host. The kubelet never
+	// refuses to start when swap is enabled.
+	//
+	// Value must be one of:
+	//
+	// - NoSwap: Workloads do not use swap.
+	//
+	// - LimitedSwap: Containers of Burstable pods may use swap, in
+	//   proportion to their memory request. Requires cgroup v2.
+	//
+	// If empty, the default is NoSwap.
+	// +kubebuilder:default="NoSwap"
+	Behavior SwapBehaviorEnum `json:"behavior"`
+}
+
+func (s NodeSwap) validate() error {
+	switch s.Behavior {
+	case SwapBehaviorNoSwap:
+	case SwapBehaviorLimitedSwap:
+		if _, err := os.Stat(cgroupControllersPath); err != nil {
+			return fmt.Errorf("node.swap.behavior %v requires cgroup v2: %w", s.Behavior, err)
+		}
+	default:
+		return fmt.Errorf("unsupported node.swap.behavior value %v", s.Behavior)
+	}
+	return nil
 }
 
 // Determine if the config file specified a NodeName (by default it's assigned the hostname)
@@ -75,16 +525,57 @@ func (c *Config) validateNodeName(isDefaultNodeName bool, dataDir string) error
 		if !isDefaultNodeName {
 			return fmt.Errorf("configured NodeName %q does not match previous NodeName %q , NodeName cannot be changed for a device once established",
 				currentNodeName, establishedNodeName)
-		} else {
-			c.Node.HostnameOverride = establishedNodeName
-			klog.Warningf("NodeName has changed due to a host name change, using previously established NodeName %q."+
-				"Please consider using a static NodeName in configuration", establishedNodeName)
 		}
+		klog.Warningf("NodeName has changed from %q to %q due to a host name change, the node is registered again under the new name."+
+			"Please consider using a static NodeName in configuration", establishedNodeName, currentNodeName)
+		if err := renameNode(dataDir, establishedNodeName, currentNodeName); err != nil {
+			return fmt.Errorf("failed to change NodeName: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// renameNode establishes the new NodeName, and records the previous one so
+// its Node object is removed once MicroShift is running.
+func renameNode(dataDir, previousName, name string) error {
+	previousNames, err := PreviousNodeNames(dataDir)
+	if err != nil {
+		return err
+	}
+	previousNames = append(previousNames, previousName)
+	previousNamesPath := filepath.Join(dataDir, PreviousNodeNamesFile)
+	if err := os.WriteFile(previousNamesPath, []byte(strings.Join(previousNames, "\n")+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to write previous NodeNames file %q: %w", previousNamesPath, err)
 	}
 
+	// The file is read-only, replace it rather than writing to it.
+	filePath := filepath.Join(dataDir, ".nodename")
+	if err := os.Remove(filePath); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filePath, []byte(name), 0400); err != nil {
+		return fmt.Errorf("failed to write nodename file %q: %w", filePath, err)
+	}
 	return nil
 }
 
+// PreviousNodeNamesFile lists the NodeNames used before host name changes,
+// whose Node objects are yet to be removed.
+const PreviousNodeNamesFile = ".nodename.previous"
+
+// PreviousNodeNames returns the NodeNames used before host name changes,
+// whose Node objects are yet to be removed.
+func PreviousNodeNames(dataDir string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(dataDir, PreviousNodeNamesFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(data)), nil
+}
+
 func (c *Config) EnsureNodeNameHasNotChanged() error {
 	// Validate NodeName in config file, node-name should not be changed for an already
 	// initialized MicroShift instance. This can lead to Pods being re-scheduled, storage
@@ -0,0 +1,10 @@
+package config
+
+type Scheduler struct {
+	// Profiles of the KubeSchedulerConfiguration, transferred as-is. They
+	// replace the default profile, e.g. to score nodes with the
+	// MostAllocated strategy of the NodeResourcesFit plugin or to change the
+	// bindTimeoutSeconds of the VolumeBinding plugin.
+	// +kubebuilder:validation:Schemaless
+	Profiles []map[string]any `json:"profiles"`
+}
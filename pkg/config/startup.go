@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+)
+
+// Startup configures how long MicroShift waits for its services to become ready.
+type Startup struct {
+	// Maximum time, in seconds, for all of the MicroShift services to
+	// become ready. MicroShift stops and reports the services that are not
+	// ready when it is exceeded. It should be lower than the
+	// TimeoutStartSec of the microshift systemd unit, which stops
+	// MicroShift without reporting anything.
+	// 0 disables the timeout.
+	// +kubebuilder:default=0
+	TimeoutSeconds *int `json:"timeoutSeconds"`
+
+	// Maximum time, in seconds, for individual services to become ready
+	// once they are started, by service name. Overrides the default
+	// timeout of the service. 0 disables the timeout of the service.
+	ServiceTimeoutSeconds map[string]int `json:"serviceTimeoutSeconds"`
+}
+
+func (s *Startup) validate() error {
+	if s.TimeoutSeconds != nil && *s.TimeoutSeconds < 0 {
+		return fmt.Errorf("startup.timeoutSeconds must not be negative, got %d", *s.TimeoutSeconds)
+	}
+	for name, timeout := range s.ServiceTimeoutSeconds {
+		if timeout < 0 {
+			return fmt.Errorf("startup.serviceTimeoutSeconds.%s must not be negative, got %d", name, timeout)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Tracing configures the export of the OpenTelemetry traces of the start of
+// MicroShift, of its services and of the manifests it applies.
+type Tracing struct {
+	// URL of the OTLP gRPC endpoint of the collector to export the traces
+	// to, e.g. http://localhost:4317 for a collector on the host, or
+	// https://collector.example.com:4317 to connect with TLS.
+	// Traces are not exported when empty.
+	Endpoint string `json:"endpoint"`
+
+	// Number of traces sampled per million, from 0 to 1000000.
+	// +kubebuilder:default=1000000
+	SamplingRatePerMillion *int `json:"samplingRatePerMillion"`
+}
+
+func (t *Tracing) validate() error {
+	if t.SamplingRatePerMillion != nil && (*t.SamplingRatePerMillion < 0 || *t.SamplingRatePerMillion > 1000000) {
+		return fmt.Errorf("tracing.samplingRatePerMillion must be between 0 and 1000000, got %d", *t.SamplingRatePerMillion)
+	}
+	if t.Endpoint == "" {
+		return nil
+	}
+	u, err := url.Parse(t.Endpoint)
+	if err != nil {
+		return fmt.Errorf("tracing.endpoint %q is not a valid URL: %w", t.Endpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("tracing.endpoint must be an http:// or https:// URL, got %q", t.Endpoint)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("tracing.endpoint must have a host, got %q", t.Endpoint)
+	}
+	return nil
+}
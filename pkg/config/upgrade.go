@@ -0,0 +1,30 @@
+package config
+
+import "fmt"
+
+type RestoreOnRollbackStatusEnum string
+
+const (
+	RestoreOnRollbackStatusEnabled  RestoreOnRollbackStatusEnum = "Enabled"
+	RestoreOnRollbackStatusDisabled RestoreOnRollbackStatusEnum = "Disabled"
+)
+
+// Upgrade configures how MicroShift handles the data across the upgrades
+// and rollbacks of the ostree deployment.
+type Upgrade struct {
+	// Whether the backup taken before an upgrade is restored when the
+	// system rolls back to the deployment of an older MicroShift that
+	// cannot use the data, Enabled or Disabled. When Disabled, MicroShift
+	// refuses to start on the data until it is restored or removed.
+	// +kubebuilder:default="Enabled"
+	RestoreOnRollback RestoreOnRollbackStatusEnum `json:"restoreOnRollback"`
+}
+
+func (u *Upgrade) validate() error {
+	switch u.RestoreOnRollback {
+	case RestoreOnRollbackStatusEnabled, RestoreOnRollbackStatusDisabled:
+	default:
+		return fmt.Errorf("unsupported upgrade.restoreOnRollback value %v", u.RestoreOnRollback)
+	}
+	return nil
+}
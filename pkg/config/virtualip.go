@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"net"
+)
+
+type VirtualIPStatusEnum string
+
+const (
+	VirtualIPStatusEnabled  VirtualIPStatusEnum = "Enabled"
+	VirtualIPStatusDisabled VirtualIPStatusEnum = "Disabled"
+)
+
+// VirtualIP configures the virtual IP address held by the healthy node of an
+// active/passive pair of MicroShift nodes, exposing the API server and the
+// router at a stable address.
+type VirtualIP struct {
+	// Whether the node takes part in the election of the holder of the
+	// virtual IP, Enabled or Disabled.
+	// +kubebuilder:default="Disabled"
+	Status VirtualIPStatusEnum `json:"status"`
+
+	// The virtual IP address, added to the certificates of the API server.
+	Address string `json:"address"`
+
+	// IP address of the other node of the pair, both nodes exchange
+	// advertisements over UDP.
+	Peer string `json:"peer"`
+
+	// Name of the interface the virtual IP is added to. The interface of
+	// the node IP is used when empty.
+	Interface string `json:"interface"`
+
+	// Priority of the node, from 1 to 254. The healthy node with the
+	// highest priority holds the virtual IP, the one with the highest IP
+	// address on a tie.
+	// +kubebuilder:default=100
+	Priority int `json:"priority"`
+
+	// UDP port of the advertisements, the same on both nodes.
+	// +kubebuilder:default=7444
+	Port int `json:"port"`
+}
+
+func (v VirtualIP) validate(nodeIP string) error {
+	switch v.Status {
+	case VirtualIPStatusEnabled:
+	case VirtualIPStatusDisabled:
+		return nil
+	default:
+		return fmt.Errorf("unsupported virtualIP.status value %v", v.Status)
+	}
+	address := net.ParseIP(v.Address)
+	if address == nil {
+		return fmt.Errorf("virtualIP.address %q is not a valid IP address", v.Address)
+	}
+	peer := net.ParseIP(v.Peer)
+	if peer == nil {
+		return fmt.Errorf("virtualIP.peer %q is not a valid IP address", v.Peer)
+	}
+	if (address.To4() == nil) != (peer.To4() == nil) {
+		return fmt.Errorf("virtualIP.address and virtualIP.peer must be of the same IP family")
+	}
+	for _, ip := range []net.IP{address, peer} {
+		if ip.Equal(net.ParseIP(nodeIP)) {
+			return fmt.Errorf("virtualIP.address and virtualIP.peer must differ from the node IP %s", nodeIP)
+		}
+	}
+	if address.Equal(peer) {
+		return fmt.Errorf("virtualIP.address and virtualIP.peer must differ")
+	}
+	if v.Priority < 1 || v.Priority > 254 {
+		return fmt.Errorf("virtualIP.priority %d must be between 1 and 254", v.Priority)
+	}
+	if v.Port < 1 || v.Port > 65535 {
+		return fmt.Errorf("virtualIP.port %d is not a valid port", v.Port)
+	}
+	return nil
+}
@@ -27,11 +27,15 @@ import (
 	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
 type ClusterPolicyController struct {
 	run        func(context.Context) error
 	kubeconfig string
+	logOnly    bool
 
 	configErr error
 }
@@ -46,9 +50,11 @@ func (s *ClusterPolicyController) Name() string { return "cluster-policy-control
 func (s *ClusterPolicyController) Dependencies() []string {
 	return []string{"kube-apiserver", "infrastructure-services-manager"}
 }
+func (s *ClusterPolicyController) ConfigurationError() error { return s.configErr }
 
 func (s *ClusterPolicyController) configure(cfg *config.Config) error {
 	s.kubeconfig = cfg.KubeConfigPath(config.ClusterPolicyController)
+	s.logOnly = cfg.ApiServer.PodSecurity.Mode == config.PodSecurityModeLogOnly
 
 	scheme := runtime.NewScheme()
 	if err := openshiftcontrolplanev1.AddToScheme(scheme); err != nil {
@@ -57,14 +63,20 @@ func (s *ClusterPolicyController) configure(cfg *config.Config) error {
 
 	codec := serializer.NewCodecFactory(scheme).LegacyCodec(openshiftcontrolplanev1.GroupVersion)
 
-	encodedConfig, err := runtime.Encode(codec,
-		&openshiftcontrolplanev1.OpenShiftControllerManagerConfig{
-			Controllers: []string{
-				"*",
-				"-openshift.io/resourcequota",
-				"-openshift.io/cluster-quota-reconciliation",
-			},
-		})
+	controllerConfig := &openshiftcontrolplanev1.OpenShiftControllerManagerConfig{
+		Controllers: []string{
+			"*",
+			"-openshift.io/resourcequota",
+			"-openshift.io/cluster-quota-reconciliation",
+		},
+	}
+	if s.logOnly {
+		// The pod security label syncer then only sets the audit and warn
+		// labels of the namespaces, not the enforce one. The SCC admission
+		// of the API server is not affected.
+		controllerConfig.FeatureGates = []string{"OpenShiftPodSecurityAdmission=false"}
+	}
+	encodedConfig, err := runtime.Encode(codec, controllerConfig)
 	if err != nil {
 		return err
 	}
@@ -100,6 +112,16 @@ func (s *ClusterPolicyController) Run(ctx context.Context, ready chan<- struct{}
 		return fmt.Errorf("configuration failed: %w", s.configErr)
 	}
 
+	restConfig, err := clientcmd.BuildConfigFromFlags("", s.kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to build kubeconfig %s: %w", s.kubeconfig, err)
+	}
+	client, err := kubernetes.NewForConfig(rest.AddUserAgent(restConfig, s.Name()))
+	if err != nil {
+		return err
+	}
+	go reportPodSecurityLevels(ctx, client, s.logOnly)
+
 	close(ready) // todo
 	return s.run(ctx)
 }
@@ -0,0 +1,43 @@
+/*
+Copyright © 2021 MicroShift Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/openshift/microshift/pkg/servicemanager"
+	"github.com/openshift/microshift/pkg/util"
+)
+
+// newComponentProcess returns the service running the component name from
+// the microshift-<name> binary installed next to the microshift one, ready
+// once healthzURL returns 200.
+func newComponentProcess(name string, args []string, healthzURL string) *servicemanager.ProcessService {
+	path := "microshift-" + name
+	if microshiftExecPath, err := os.Executable(); err == nil {
+		path = filepath.Join(filepath.Dir(microshiftExecPath), path)
+	}
+	return servicemanager.NewProcessService(name, nil, path, args, func(ctx context.Context) error {
+		// This endpoint uses a self-signed certificate on purpose, we need to skip verification.
+		if status := util.RetryInsecureGet(ctx, healthzURL); status != 200 {
+			return fmt.Errorf("%s returned %d", healthzURL, status)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,135 @@
+//go:build !split_components
+
+/*
+Copyright © 2021 MicroShift Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/microshift/pkg/util"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	klog "k8s.io/klog/v2"
+	kubecm "k8s.io/kubernetes/cmd/kube-controller-manager/app"
+	kubescheduler "k8s.io/kubernetes/cmd/kube-scheduler/app"
+	schedulerOptions "k8s.io/kubernetes/cmd/kube-scheduler/app/options"
+	schedulervalidation "k8s.io/kubernetes/pkg/scheduler/apis/config/validation"
+)
+
+// embeddedComponents is true when kube-scheduler and kube-controller-manager
+// are compiled into MicroShift and run in its process. Building with the
+// split_components tag leaves them out, see components_split.go.
+const embeddedComponents = true
+
+// validateSchedulerConfig loads the configuration the way kube-scheduler does
+// to report errors in the profiles before starting it.
+func validateSchedulerConfig(path string) error {
+	schedulerConfig, err := schedulerOptions.LoadConfigFromFile(klog.Background(), path)
+	if err != nil {
+		return err
+	}
+	if errs := schedulervalidation.ValidateKubeSchedulerConfiguration(schedulerConfig); errs != nil {
+		return errs
+	}
+	return nil
+}
+
+func (s *KubeScheduler) runEmbedded(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	defer close(stopped)
+	errorChannel := make(chan error, 1)
+
+	// run readiness check
+	go func() {
+		// This endpoint uses a self-signed certificate on purpose, we need to skip verification.
+		healthcheckStatus := util.RetryInsecureGet(ctx, kubeSchedulerHealthzURL)
+		if healthcheckStatus != 200 {
+			klog.Errorf("%s healthcheck failed due to kube-scheduler failure to start", s.Name())
+			errorChannel <- errors.New("kube-scheduler healthcheck failed")
+		}
+
+		klog.Infof("%s is ready", s.Name())
+		close(ready)
+	}()
+
+	options := schedulerOptions.NewOptions()
+	options.ConfigFile = s.configPath()
+	options.Authentication.RemoteKubeConfigFile = s.kubeconfig
+	options.Authorization.RemoteKubeConfigFile = s.kubeconfig
+	options.SecureServing.MinTLSVersion = string(fixedTLSProfile.MinTLSVersion)
+	options.SecureServing.CipherSuites = crypto.OpenSSLToIANACipherSuites(fixedTLSProfile.Ciphers)
+	cc, sched, err := kubescheduler.Setup(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		errorChannel <- kubescheduler.Run(ctx, cc, sched)
+	}()
+
+	return <-errorChannel
+}
+
+// validateKCMControllers reports the controllers unknown to
+// kube-controller-manager, which would otherwise fail to start.
+func validateKCMControllers(controllers []string) error {
+	known := sets.New[string](kubecm.KnownControllers()...)
+	for alias := range kubecm.ControllerAliases() {
+		known.Insert(alias)
+	}
+	for _, controller := range controllers {
+		if name := strings.TrimPrefix(controller, "-"); !known.Has(name) {
+			return fmt.Errorf("unknown controller %q in controllerManager.controllers", name)
+		}
+	}
+	return nil
+}
+
+func (s *KubeControllerManager) runEmbedded(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	defer close(stopped)
+	errorChannel := make(chan error, 1)
+
+	// run readiness check
+	go func() {
+		// This endpoint uses a self-signed certificate on purpose, we need to skip verification.
+		healthcheckStatus := util.RetryInsecureGet(ctx, kubeControllerManagerHealthzURL)
+		if healthcheckStatus != 200 {
+			klog.Errorf("kube-controller-manager failed to start")
+			errorChannel <- errors.New("kube-controller-manager failed to start")
+		}
+
+		klog.Infof("%s is ready", s.Name())
+		close(ready)
+	}()
+
+	// Carrying a patch for NewControllerManagerCommand to use cmd.Context().Done()
+	// as the stop channel instead of the channel returned by SetupSignalHandler,
+	// which expects to be called at most once in a process.
+	cmd := kubecm.NewControllerManagerCommand()
+	cmd.SetArgs(s.args)
+	go func() {
+		errorChannel <- cmd.ExecuteContext(ctx)
+	}()
+
+	if err := s.applyFn(); err != nil {
+		return fmt.Errorf("failed to apply openshift namespaces: %w", err)
+	}
+	return <-errorChannel
+}
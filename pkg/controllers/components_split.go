@@ -0,0 +1,50 @@
+//go:build split_components
+
+/*
+Copyright © 2021 MicroShift Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"context"
+	"errors"
+)
+
+// embeddedComponents is false when MicroShift is built with the
+// split_components tag, leaving out kube-scheduler and
+// kube-controller-manager to reduce its size and memory. They run from the
+// microshift-kube-scheduler and microshift-kube-controller-manager binaries
+// installed next to the microshift one instead.
+const embeddedComponents = false
+
+var errNotEmbedded = errors.New("not compiled into MicroShift, built with the split_components tag")
+
+// validateSchedulerConfig is left to microshift-kube-scheduler, which fails
+// to start with an invalid configuration.
+func validateSchedulerConfig(path string) error { return nil }
+
+// validateKCMControllers is left to microshift-kube-controller-manager, which
+// fails to start with unknown controllers.
+func validateKCMControllers(controllers []string) error { return nil }
+
+func (s *KubeScheduler) runEmbedded(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	close(stopped)
+	return errNotEmbedded
+}
+
+func (s *KubeControllerManager) runEmbedded(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	close(stopped)
+	return errNotEmbedded
+}
@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"path"
+	"slices"
+
+	"github.com/openshift/microshift/pkg/config"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+const csrApprovalReason = "MicroShiftCSRApprovalPolicy"
+
+// CSRApprover approves the certificate signing requests matching the rules
+// of certificates.csrApproval, and denies the other ones to the signers of
+// the rules. Its decisions are logged for auditing.
+type CSRApprover struct {
+	kubeconfig string
+	rules      []config.CSRApprovalRule
+}
+
+func NewCSRApprover(cfg *config.Config) *CSRApprover {
+	return &CSRApprover{
+		kubeconfig: cfg.KubeConfigPath(config.KubeAdmin),
+		rules:      cfg.Certificates.CSRApproval.Rules,
+	}
+}
+
+func (s *CSRApprover) Name() string           { return "csr-approver" }
+func (s *CSRApprover) Dependencies() []string { return []string{"kube-apiserver"} }
+
+func (s *CSRApprover) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	defer close(stopped)
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", s.kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to build kubeconfig admin path: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(rest.AddUserAgent(restConfig, s.Name()))
+	if err != nil {
+		return fmt.Errorf("failed to create clientset for %s: %w", s.Name(), err)
+	}
+
+	queue := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[string]())
+	defer queue.ShutDown()
+	enqueue := func(obj any) {
+		if csr, ok := obj.(*certificatesv1.CertificateSigningRequest); ok && isPending(csr) {
+			queue.Add(csr.Name)
+		}
+	}
+
+	factory := informers.NewSharedInformerFactory(client, 0)
+	informer := factory.Certificates().V1().CertificateSigningRequests().Informer()
+	_, err = informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    enqueue,
+		UpdateFunc: func(_, obj any) { enqueue(obj) },
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize certificate signing request informer handlers: %w", err)
+	}
+	lister := factory.Certificates().V1().CertificateSigningRequests().Lister()
+
+	factory.Start(ctx.Done())
+	defer factory.Shutdown()
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return fmt.Errorf("timed out waiting for caches to sync")
+	}
+	klog.Infof("Approving the certificate signing requests with %d rules", len(s.rules))
+	close(ready)
+
+	go func() {
+		<-ctx.Done()
+		queue.ShutDown()
+	}()
+	for {
+		name, shutdown := queue.Get()
+		if shutdown {
+			return ctx.Err()
+		}
+		csr, err := lister.Get(name)
+		if err == nil {
+			err = s.handle(ctx, client, csr)
+		}
+		if err != nil {
+			klog.Warningf("Failed to handle certificate signing request %s: %v", name, err)
+			queue.AddRateLimited(name)
+		} else {
+			queue.Forget(name)
+		}
+		queue.Done(name)
+	}
+}
+
+// handle approves or denies the pending csr, or leaves it to be approved
+// manually when its signer is not in the rules.
+func (s *CSRApprover) handle(ctx context.Context, client kubernetes.Interface, csr *certificatesv1.CertificateSigningRequest) error {
+	if !isPending(csr) {
+		return nil
+	}
+	approved, message, decided := decideCSR(s.rules, csr)
+	if !decided {
+		klog.V(2).Infof("Leaving certificate signing request %s of %s to signer %s to be approved manually", csr.Name, csr.Spec.Username, csr.Spec.SignerName)
+		return nil
+	}
+
+	condition := certificatesv1.CertificateSigningRequestCondition{
+		Type:           certificatesv1.CertificateDenied,
+		Status:         corev1.ConditionTrue,
+		Reason:         csrApprovalReason,
+		Message:        message,
+		LastUpdateTime: metav1.Now(),
+	}
+	if approved {
+		condition.Type = certificatesv1.CertificateApproved
+	}
+	csr = csr.DeepCopy()
+	csr.Status.Conditions = append(csr.Status.Conditions, condition)
+	if _, err := client.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, csr.Name, csr, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	if approved {
+		klog.Infof("Approved certificate signing request %s of %s to signer %s: %s", csr.Name, csr.Spec.Username, csr.Spec.SignerName, message)
+	} else {
+		klog.Warningf("Denied certificate signing request %s of %s to signer %s: %s", csr.Name, csr.Spec.Username, csr.Spec.SignerName, message)
+	}
+	return nil
+}
+
+func isPending(csr *certificatesv1.CertificateSigningRequest) bool {
+	for _, c := range csr.Status.Conditions {
+		switch c.Type {
+		case certificatesv1.CertificateApproved, certificatesv1.CertificateDenied, certificatesv1.CertificateFailed:
+			return false
+		}
+	}
+	return true
+}
+
+// decideCSR returns whether csr is approved by one of the rules, with the
+// reason why. decided is false when no rule is of its signer.
+func decideCSR(rules []config.CSRApprovalRule, csr *certificatesv1.CertificateSigningRequest) (approved bool, message string, decided bool) {
+	var mismatches []string
+	for i, rule := range rules {
+		if rule.SignerName != csr.Spec.SignerName {
+			continue
+		}
+		decided = true
+		if err := matchCSR(rule, csr); err != nil {
+			mismatches = append(mismatches, fmt.Sprintf("rule %d: %v", i, err))
+			continue
+		}
+		return true, fmt.Sprintf("matches rule %d", i), true
+	}
+	if !decided {
+		return false, "", false
+	}
+	return false, fmt.Sprintf("matches no rule: %v", mismatches), true
+}
+
+// matchCSR returns why csr does not match rule, if it does not.
+func matchCSR(rule config.CSRApprovalRule, csr *certificatesv1.CertificateSigningRequest) error {
+	if len(rule.Usernames) != 0 && !slices.ContainsFunc(rule.Usernames, func(pattern string) bool {
+		matched, _ := path.Match(pattern, csr.Spec.Username)
+		return matched
+	}) {
+		return fmt.Errorf("user %q is not allowed", csr.Spec.Username)
+	}
+	if len(rule.Groups) != 0 && !slices.ContainsFunc(rule.Groups, func(group string) bool {
+		return slices.Contains(csr.Spec.Groups, group)
+	}) {
+		return fmt.Errorf("groups %v are not allowed", csr.Spec.Groups)
+	}
+
+	block, _ := pem.Decode(csr.Spec.Request)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return fmt.Errorf("no PEM certificate request")
+	}
+	request, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return err
+	}
+	if err := request.CheckSignature(); err != nil {
+		return err
+	}
+
+	if len(request.EmailAddresses) != 0 || len(request.URIs) != 0 {
+		return fmt.Errorf("email and URI names are not allowed")
+	}
+	for _, name := range request.DNSNames {
+		if !slices.ContainsFunc(rule.DNSNames, func(pattern string) bool {
+			matched, _ := path.Match(pattern, name)
+			return matched
+		}) {
+			return fmt.Errorf("DNS name %q is not allowed", name)
+		}
+	}
+	for _, ip := range request.IPAddresses {
+		if !slices.ContainsFunc(rule.IPAddresses, func(cidr string) bool {
+			_, network, err := net.ParseCIDR(cidr)
+			return err == nil && network.Contains(ip)
+		}) {
+			return fmt.Errorf("IP address %s is not allowed", ip)
+		}
+	}
+
+	var keyType config.CSRApprovalKeyType
+	switch request.PublicKey.(type) {
+	case *rsa.PublicKey:
+		keyType = config.CSRApprovalKeyTypeRSA
+	case *ecdsa.PublicKey:
+		keyType = config.CSRApprovalKeyTypeECDSA
+	case ed25519.PublicKey:
+		keyType = config.CSRApprovalKeyTypeEd25519
+	}
+	if len(rule.KeyTypes) != 0 && !slices.Contains(rule.KeyTypes, keyType) {
+		return fmt.Errorf("key type %T is not allowed", request.PublicKey)
+	}
+	return nil
+}
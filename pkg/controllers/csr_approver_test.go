@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net"
+	"testing"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestCSR(t *testing.T, signerName, username string, key any, dnsNames []string, ips []net.IP) *certificatesv1.CertificateSigningRequest {
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:     pkix.Name{CommonName: username, Organization: []string{"system:nodes"}},
+		DNSNames:    dnsNames,
+		IPAddresses: ips,
+	}, key)
+	require.NoError(t, err)
+	return &certificatesv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "csr-" + username},
+		Spec: certificatesv1.CertificateSigningRequestSpec{
+			Request:    pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}),
+			SignerName: signerName,
+			Username:   username,
+			Groups:     []string{"system:nodes", "system:authenticated"},
+		},
+	}
+}
+
+func Test_decideCSR(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	rules := []config.CSRApprovalRule{{
+		SignerName:  "kubernetes.io/kubelet-serving",
+		Usernames:   []string{"system:node:*"},
+		Groups:      []string{"system:nodes"},
+		DNSNames:    []string{"*.example.com"},
+		IPAddresses: []string{"192.168.1.0/24"},
+		KeyTypes:    []config.CSRApprovalKeyType{config.CSRApprovalKeyTypeECDSA},
+	}}
+	serving := "kubernetes.io/kubelet-serving"
+	ips := []net.IP{net.ParseIP("192.168.1.10")}
+
+	tests := []struct {
+		name     string
+		csr      *certificatesv1.CertificateSigningRequest
+		approved bool
+		decided  bool
+		message  string
+	}{
+		{
+			name:     "matching",
+			csr:      newTestCSR(t, serving, "system:node:node1", ecKey, []string{"node1.example.com"}, ips),
+			approved: true,
+			decided:  true,
+		},
+		{
+			name:    "other signer",
+			csr:     newTestCSR(t, "example.com/signer", "system:node:node1", ecKey, nil, nil),
+			decided: false,
+		},
+		{
+			name:    "user",
+			csr:     newTestCSR(t, serving, "alice", ecKey, nil, nil),
+			decided: true,
+			message: `user "alice" is not allowed`,
+		},
+		{
+			name:    "DNS name",
+			csr:     newTestCSR(t, serving, "system:node:node1", ecKey, []string{"node1.example.org"}, nil),
+			decided: true,
+			message: `DNS name "node1.example.org" is not allowed`,
+		},
+		{
+			name:    "IP address",
+			csr:     newTestCSR(t, serving, "system:node:node1", ecKey, nil, []net.IP{net.ParseIP("10.0.0.1")}),
+			decided: true,
+			message: "IP address 10.0.0.1 is not allowed",
+		},
+		{
+			name:    "key type",
+			csr:     newTestCSR(t, serving, "system:node:node1", rsaKey, nil, nil),
+			decided: true,
+			message: "key type *rsa.PublicKey is not allowed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			approved, message, decided := decideCSR(rules, tt.csr)
+			assert.Equal(t, tt.approved, approved)
+			assert.Equal(t, tt.decided, decided)
+			assert.Contains(t, message, tt.message)
+		})
+	}
+}
+
+func TestCSRApprover_handle(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	approvedCSR := newTestCSR(t, "kubernetes.io/kubelet-serving", "system:node:node1", key, nil, nil)
+	deniedCSR := newTestCSR(t, "kubernetes.io/kubelet-serving", "alice", key, nil, nil)
+	client := fake.NewSimpleClientset(approvedCSR, deniedCSR)
+	s := &CSRApprover{rules: []config.CSRApprovalRule{{
+		SignerName: "kubernetes.io/kubelet-serving",
+		Groups:     []string{"system:nodes"},
+		Usernames:  []string{"system:node:*"},
+	}}}
+
+	for _, csr := range []*certificatesv1.CertificateSigningRequest{approvedCSR, deniedCSR} {
+		require.NoError(t, s.handle(context.TODO(), client, csr))
+	}
+
+	csr, err := client.CertificatesV1().CertificateSigningRequests().Get(context.TODO(), approvedCSR.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, csr.Status.Conditions, 1)
+	assert.Equal(t, certificatesv1.CertificateApproved, csr.Status.Conditions[0].Type)
+	assert.False(t, isPending(csr))
+
+	csr, err = client.CertificatesV1().CertificateSigningRequests().Get(context.TODO(), deniedCSR.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, csr.Status.Conditions, 1)
+	assert.Equal(t, certificatesv1.CertificateDenied, csr.Status.Conditions[0].Type)
+	assert.Equal(t, csrApprovalReason, csr.Status.Conditions[0].Reason)
+}
@@ -0,0 +1,428 @@
+/*
+Copyright © 2024 MicroShift Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	apiserverv1 "k8s.io/apiserver/pkg/apis/apiserver/v1"
+	"k8s.io/apiserver/pkg/storage/value/encrypt/envelope/kmsv2"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	migrationv1alpha1 "sigs.k8s.io/kube-storage-version-migrator/pkg/apis/migration/v1alpha1"
+	migrationclient "sigs.k8s.io/kube-storage-version-migrator/pkg/clients/clientset"
+
+	"github.com/openshift/microshift/pkg/config"
+)
+
+const (
+	encryptionKeyPrefix = "key-"
+	// encryptionKeySize is the size in bytes of the generated keys, for AES-256.
+	encryptionKeySize = 32
+
+	encryptionMigrationPollInterval = 10 * time.Second
+)
+
+var encryptedResources = []string{"secrets"}
+
+func encryptionConfigDir() string {
+	return filepath.Join(config.DataDir, "resources", "kube-apiserver-encryption")
+}
+
+// encryptionConfigPath is the path of the EncryptionConfiguration of kube-apiserver.
+func encryptionConfigPath() string {
+	return filepath.Join(encryptionConfigDir(), "config.yaml")
+}
+
+// encryptionMigratedPath is the path of the file holding the ID of the last
+// provider all of the encrypted resources were re-written with.
+func encryptionMigratedPath() string {
+	return filepath.Join(encryptionConfigDir(), "migrated")
+}
+
+// providerKeyID identifies the provider and key a provider configuration
+// encrypts with, e.g. aescbc/key-1700000000.
+func providerKeyID(p apiserverv1.ProviderConfiguration) string {
+	switch {
+	case p.AESCBC != nil && len(p.AESCBC.Keys) > 0:
+		return string(config.EncryptionProviderAESCBC) + "/" + p.AESCBC.Keys[0].Name
+	case p.AESGCM != nil && len(p.AESGCM.Keys) > 0:
+		return string(config.EncryptionProviderAESGCM) + "/" + p.AESGCM.Keys[0].Name
+	case p.KMS != nil:
+		return string(config.EncryptionProviderKMS) + "/" + p.KMS.Name
+	case p.Identity != nil:
+		return string(config.EncryptionProviderIdentity)
+	}
+	return ""
+}
+
+func providerKey(p apiserverv1.ProviderConfiguration) *apiserverv1.Key {
+	switch {
+	case p.AESCBC != nil && len(p.AESCBC.Keys) > 0:
+		return &p.AESCBC.Keys[0]
+	case p.AESGCM != nil && len(p.AESGCM.Keys) > 0:
+		return &p.AESGCM.Keys[0]
+	}
+	return nil
+}
+
+func kmsTimeout(kms config.EncryptionKMS) time.Duration {
+	if kms.TimeoutSeconds == nil {
+		return 0
+	}
+	return time.Duration(*kms.TimeoutSeconds) * time.Second
+}
+
+func newEncryptionProvider(enc config.Encryption, now time.Time) (apiserverv1.ProviderConfiguration, error) {
+	switch enc.Provider {
+	case config.EncryptionProviderIdentity:
+		return apiserverv1.ProviderConfiguration{Identity: &apiserverv1.IdentityConfiguration{}}, nil
+	case config.EncryptionProviderKMS:
+		kms := &apiserverv1.KMSConfiguration{
+			APIVersion: "v2",
+			Name:       enc.KMS.Name,
+			Endpoint:   enc.KMS.Endpoint,
+		}
+		if timeout := kmsTimeout(enc.KMS); timeout > 0 {
+			kms.Timeout = &metav1.Duration{Duration: timeout}
+		}
+		return apiserverv1.ProviderConfiguration{KMS: kms}, nil
+	}
+
+	secret := make([]byte, encryptionKeySize)
+	if _, err := rand.Read(secret); err != nil {
+		return apiserverv1.ProviderConfiguration{}, fmt.Errorf("failed to generate encryption key: %w", err)
+	}
+	aes := &apiserverv1.AESConfiguration{
+		Keys: []apiserverv1.Key{{
+			Name:   encryptionKeyPrefix + strconv.FormatInt(now.Unix(), 10),
+			Secret: base64.StdEncoding.EncodeToString(secret),
+		}},
+	}
+
+	switch enc.Provider {
+	case config.EncryptionProviderAESCBC:
+		return apiserverv1.ProviderConfiguration{AESCBC: aes}, nil
+	case config.EncryptionProviderAESGCM:
+		return apiserverv1.ProviderConfiguration{AESGCM: aes}, nil
+	}
+	return apiserverv1.ProviderConfiguration{}, fmt.Errorf("unsupported encryption provider %q", enc.Provider)
+}
+
+// needsNewProvider returns whether a new provider must be written with,
+// because the configured provider changed or its key is due for rotation.
+func needsNewProvider(write apiserverv1.ProviderConfiguration, enc config.Encryption, now time.Time) bool {
+	id := providerKeyID(write)
+	if id != string(enc.Provider) && !strings.HasPrefix(id, string(enc.Provider)+"/") {
+		return true
+	}
+	if write.KMS != nil {
+		return write.KMS.Name != enc.KMS.Name
+	}
+
+	key := providerKey(write)
+	if key == nil || enc.KeyRotationDays == nil || *enc.KeyRotationDays == 0 {
+		return false
+	}
+	created, err := strconv.ParseInt(strings.TrimPrefix(key.Name, encryptionKeyPrefix), 10, 64)
+	if err != nil {
+		klog.Warningf("Cannot tell the age of encryption key %q, rotating it: %v", key.Name, err)
+		return true
+	}
+	return now.Sub(time.Unix(created, 0)) >= time.Duration(*enc.KeyRotationDays)*24*time.Hour
+}
+
+// desiredEncryptionProviders returns the providers of the encryption
+// configuration from the current ones. The first provider encrypts the
+// resources being written, the others decrypt the resources that were not
+// re-written yet. Providers other than the first one are only dropped once
+// all of the resources were migrated to the first one, and identity is kept
+// last to read the resources stored before encryption was enabled.
+func desiredEncryptionProviders(current []apiserverv1.ProviderConfiguration, enc config.Encryption, migrated string, now time.Time) ([]apiserverv1.ProviderConfiguration, error) {
+	if len(current) == 0 {
+		current = []apiserverv1.ProviderConfiguration{{Identity: &apiserverv1.IdentityConfiguration{}}}
+	}
+	if providerKeyID(current[0]) == migrated {
+		current = current[:1]
+	}
+
+	write, previous := current[0], current[1:]
+	if needsNewProvider(write, enc, now) {
+		p, err := newEncryptionProvider(enc, now)
+		if err != nil {
+			return nil, err
+		}
+		klog.Infof("Encrypting %v with new encryption provider %s", encryptedResources, providerKeyID(p))
+		write, previous = p, current
+	} else if write.KMS != nil {
+		// The endpoint and timeout of the plugin can change without
+		// re-encrypting the resources.
+		p, err := newEncryptionProvider(enc, now)
+		if err != nil {
+			return nil, err
+		}
+		write = p
+	}
+
+	providers := []apiserverv1.ProviderConfiguration{write}
+	for _, p := range previous {
+		if p.Identity == nil {
+			providers = append(providers, p)
+		}
+	}
+	if write.Identity == nil {
+		providers = append(providers, apiserverv1.ProviderConfiguration{Identity: &apiserverv1.IdentityConfiguration{}})
+	}
+	return providers, nil
+}
+
+// checkKMSProvider returns an error unless the KMS plugin reports it is healthy.
+func checkKMSProvider(ctx context.Context, kms config.EncryptionKMS) error {
+	// Cancelling the context closes the connection to the plugin.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	service, err := kmsv2.NewGRPCService(ctx, kms.Endpoint, kms.Name, kmsTimeout(kms))
+	if err != nil {
+		return err
+	}
+	status, err := service.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get status of KMS plugin %q at %s: %w", kms.Name, kms.Endpoint, err)
+	}
+	if status.Healthz != "ok" {
+		return fmt.Errorf("KMS plugin %q at %s is not healthy: %s", kms.Name, kms.Endpoint, status.Healthz)
+	}
+	if status.KeyID == "" {
+		return fmt.Errorf("KMS plugin %q at %s returned an empty key ID", kms.Name, kms.Endpoint)
+	}
+	return nil
+}
+
+func readEncryptionConfig(path string) (*apiserverv1.EncryptionConfiguration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	encryptionConfig := &apiserverv1.EncryptionConfiguration{}
+	if err := yaml.Unmarshal(data, encryptionConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", path, err)
+	}
+	return encryptionConfig, nil
+}
+
+func readMigratedProvider() (string, error) {
+	data, err := os.ReadFile(encryptionMigratedPath())
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ensureEncryptionConfig generates the encryption configuration of
+// kube-apiserver, rotating the key when needed, and returns its path. It
+// returns an empty path when encryption was never enabled.
+func ensureEncryptionConfig(enc config.Encryption, now time.Time) (string, error) {
+	path := encryptionConfigPath()
+	current, err := readEncryptionConfig(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if current == nil && !enc.IsEnabled() {
+		return "", nil
+	}
+
+	var currentProviders []apiserverv1.ProviderConfiguration
+	if current != nil {
+		for _, r := range current.Resources {
+			if reflect.DeepEqual(r.Resources, encryptedResources) {
+				currentProviders = r.Providers
+			}
+		}
+	}
+
+	migrated, err := readMigratedProvider()
+	if err != nil {
+		return "", err
+	}
+	providers, err := desiredEncryptionProviders(currentProviders, enc, migrated, now)
+	if err != nil {
+		return "", err
+	}
+	if current != nil && reflect.DeepEqual(providers, currentProviders) {
+		return path, nil
+	}
+
+	encryptionConfig := &apiserverv1.EncryptionConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apiserverv1.SchemeGroupVersion.String(),
+			Kind:       "EncryptionConfiguration",
+		},
+		Resources: []apiserverv1.ResourceConfiguration{{
+			Resources: encryptedResources,
+			Providers: providers,
+		}},
+	}
+	data, err := yaml.Marshal(encryptionConfig)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	// The file holds the keys, so it is replaced atomically to never leave
+	// kube-apiserver without the keys of the stored resources.
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return "", fmt.Errorf("failed to rename %q to %q: %w", tmpPath, path, err)
+	}
+	return path, nil
+}
+
+// EncryptionMigrator re-writes the encrypted resources after the provider
+// or key used to encrypt them changed, so the previous keys can be dropped
+// from the encryption configuration on the next start of MicroShift. It
+// relies on kube-storage-version-migrator to re-write the resources.
+type EncryptionMigrator struct {
+	kubeconfig string
+}
+
+func NewEncryptionMigrator(cfg *config.Config) *EncryptionMigrator {
+	return &EncryptionMigrator{
+		kubeconfig: cfg.KubeConfigPath(config.KubeAdmin),
+	}
+}
+
+func (s *EncryptionMigrator) Name() string { return "encryption-migrator" }
+func (s *EncryptionMigrator) Dependencies() []string {
+	return []string{"kube-apiserver", kubeStorageVersionMigrator}
+}
+
+func (s *EncryptionMigrator) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	defer close(stopped)
+	// Re-writing the resources runs in the background and does not delay
+	// the readiness of MicroShift.
+	close(ready)
+
+	encryptionConfig, err := readEncryptionConfig(encryptionConfigPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	migrated, err := readMigratedProvider()
+	if err != nil {
+		return err
+	}
+
+	for _, r := range encryptionConfig.Resources {
+		if len(r.Providers) == 0 {
+			continue
+		}
+		id := providerKeyID(r.Providers[0])
+		if id == migrated {
+			continue
+		}
+		klog.Infof("Re-writing %v with encryption provider %s", r.Resources, id)
+		if err := s.migrate(ctx, id, r.Resources); err != nil {
+			// The previous keys are kept until a later run succeeds.
+			klog.ErrorS(err, "Failed to re-write resources, retrying on the next start", "resources", r.Resources, "provider", id)
+			return nil
+		}
+		if err := os.WriteFile(encryptionMigratedPath(), []byte(id), 0600); err != nil {
+			return err
+		}
+		klog.Infof("Re-wrote %v with encryption provider %s, previous keys are removed on the next start", r.Resources, id)
+	}
+	return nil
+}
+
+// migrate creates a StorageVersionMigration for every resource and waits for
+// all of them to succeed.
+func (s *EncryptionMigrator) migrate(ctx context.Context, id string, resources []string) error {
+	restConfig, err := clientcmd.BuildConfigFromFlags("", s.kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to build kubeconfig admin path: %w", err)
+	}
+	client, err := migrationclient.NewForConfig(rest.AddUserAgent(restConfig, s.Name()))
+	if err != nil {
+		return err
+	}
+	migrations := client.MigrationV1alpha1().StorageVersionMigrations()
+
+	for _, resource := range resources {
+		name := "encryption-" + resource + "-" + strings.ReplaceAll(id, "/", "-")
+		_, err := migrations.Create(ctx, &migrationv1alpha1.StorageVersionMigration{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: migrationv1alpha1.StorageVersionMigrationSpec{
+				Resource: migrationv1alpha1.GroupVersionResource{Version: "v1", Resource: resource},
+			},
+		}, metav1.CreateOptions{})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create storage version migration %q: %w", name, err)
+		}
+
+		err = wait.PollUntilContextCancel(ctx, encryptionMigrationPollInterval, true, func(ctx context.Context) (bool, error) {
+			m, err := migrations.Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				klog.Warningf("Failed to get storage version migration %q: %v", name, err)
+				return false, nil
+			}
+			for _, c := range m.Status.Conditions {
+				if c.Status != corev1.ConditionTrue {
+					continue
+				}
+				switch c.Type {
+				case migrationv1alpha1.MigrationSucceeded:
+					return true, nil
+				case migrationv1alpha1.MigrationFailed:
+					return false, fmt.Errorf("storage version migration %q failed: %s", name, c.Message)
+				}
+			}
+			return false, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// The migration is deleted so that migrating to the same provider
+		// again, e.g. after disabling encryption twice, is not considered done.
+		if err := migrations.Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			klog.Warningf("Failed to delete storage version migration %q: %v", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	kmsservice "k8s.io/kms/pkg/service"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/microshift/pkg/config"
+)
+
+func TestEnsureEncryptionConfig(t *testing.T) {
+	dataDir := config.DataDir
+	config.DataDir = t.TempDir()
+	defer func() { config.DataDir = dataDir }()
+
+	providerIDs := func() []string {
+		c, err := readEncryptionConfig(encryptionConfigPath())
+		assert.NoError(t, err)
+		ids := []string{}
+		for _, p := range c.Resources[0].Providers {
+			ids = append(ids, providerKeyID(p))
+		}
+		return ids
+	}
+	setMigrated := func(id string) {
+		assert.NoError(t, os.WriteFile(encryptionMigratedPath(), []byte(id), 0600))
+	}
+
+	start := time.Unix(1700000000, 0)
+	identity := config.Encryption{Provider: config.EncryptionProviderIdentity, KeyRotationDays: ptr.To[int](90)}
+	aescbc := config.Encryption{Provider: config.EncryptionProviderAESCBC, KeyRotationDays: ptr.To[int](90)}
+	aesgcm := config.Encryption{Provider: config.EncryptionProviderAESGCM, KeyRotationDays: ptr.To[int](0)}
+
+	// Nothing is generated until encryption is enabled.
+	path, err := ensureEncryptionConfig(identity, start)
+	assert.NoError(t, err)
+	assert.Empty(t, path)
+
+	path, err = ensureEncryptionConfig(aescbc, start)
+	assert.NoError(t, err)
+	assert.Equal(t, encryptionConfigPath(), path)
+	assert.Equal(t, []string{"aescbc/key-1700000000", "identity"}, providerIDs())
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// The key is only rotated once it is old enough.
+	_, err = ensureEncryptionConfig(aescbc, start.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"aescbc/key-1700000000", "identity"}, providerIDs())
+
+	rotation := start.Add(90 * 24 * time.Hour)
+	_, err = ensureEncryptionConfig(aescbc, rotation)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"aescbc/key-1707776000", "aescbc/key-1700000000", "identity"}, providerIDs())
+
+	// The previous keys are kept until the secrets were re-written.
+	_, err = ensureEncryptionConfig(aesgcm, rotation.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"aesgcm/key-1707779600", "aescbc/key-1707776000", "aescbc/key-1700000000", "identity"}, providerIDs())
+
+	setMigrated("aesgcm/key-1707779600")
+	_, err = ensureEncryptionConfig(aesgcm, rotation.Add(1000*24*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"aesgcm/key-1707779600", "identity"}, providerIDs())
+
+	// Disabling encryption keeps the keys to read the encrypted secrets.
+	_, err = ensureEncryptionConfig(identity, rotation.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"identity", "aesgcm/key-1707779600"}, providerIDs())
+
+	setMigrated("identity")
+	path, err = ensureEncryptionConfig(identity, rotation.Add(3*time.Hour))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, path)
+	assert.Equal(t, []string{"identity"}, providerIDs())
+}
+
+func TestEnsureEncryptionConfigKMS(t *testing.T) {
+	dataDir := config.DataDir
+	config.DataDir = t.TempDir()
+	defer func() { config.DataDir = dataDir }()
+
+	now := time.Unix(1700000000, 0)
+	aescbc := config.Encryption{Provider: config.EncryptionProviderAESCBC, KeyRotationDays: ptr.To[int](1)}
+	kms := config.Encryption{
+		Provider:        config.EncryptionProviderKMS,
+		KeyRotationDays: ptr.To[int](1),
+		KMS:             config.EncryptionKMS{Name: "tpm", Endpoint: "unix:///run/kms/tpm.sock", TimeoutSeconds: ptr.To[int](3)},
+	}
+	providers := func() []string {
+		c, err := readEncryptionConfig(encryptionConfigPath())
+		assert.NoError(t, err)
+		ids := []string{}
+		for _, p := range c.Resources[0].Providers {
+			ids = append(ids, providerKeyID(p))
+		}
+		return ids
+	}
+
+	_, err := ensureEncryptionConfig(aescbc, now)
+	assert.NoError(t, err)
+	_, err = ensureEncryptionConfig(kms, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"kms/tpm", "aescbc/key-1700000000", "identity"}, providers())
+
+	// KMS keys are rotated by the plugin, and the endpoint is updated in place.
+	kms.KMS.Endpoint = "unix:///run/kms/other.sock"
+	_, err = ensureEncryptionConfig(kms, now.Add(48*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"kms/tpm", "aescbc/key-1700000000", "identity"}, providers())
+	c, err := readEncryptionConfig(encryptionConfigPath())
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", c.Resources[0].Providers[0].KMS.APIVersion)
+	assert.Equal(t, "unix:///run/kms/other.sock", c.Resources[0].Providers[0].KMS.Endpoint)
+	assert.Equal(t, 3*time.Second, c.Resources[0].Providers[0].KMS.Timeout.Duration)
+
+	// A new plugin re-encrypts the secrets.
+	kms.KMS.Name = "cloud"
+	_, err = ensureEncryptionConfig(kms, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"kms/cloud", "kms/tpm", "aescbc/key-1700000000", "identity"}, providers())
+}
+
+type fakeKMSService struct {
+	healthz string
+}
+
+func (f *fakeKMSService) Decrypt(ctx context.Context, uid string, req *kmsservice.DecryptRequest) ([]byte, error) {
+	return req.Ciphertext, nil
+}
+
+func (f *fakeKMSService) Encrypt(ctx context.Context, uid string, data []byte) (*kmsservice.EncryptResponse, error) {
+	return &kmsservice.EncryptResponse{Ciphertext: data, KeyID: "1"}, nil
+}
+
+func (f *fakeKMSService) Status(ctx context.Context) (*kmsservice.StatusResponse, error) {
+	return &kmsservice.StatusResponse{Version: "v2", Healthz: f.healthz, KeyID: "1"}, nil
+}
+
+func TestCheckKMSProvider(t *testing.T) {
+	for _, healthz := range []string{"ok", "unavailable"} {
+		t.Run(healthz, func(t *testing.T) {
+			socket := filepath.Join(t.TempDir(), "kms.sock")
+			kms := config.EncryptionKMS{Name: "fake", Endpoint: "unix://" + socket, TimeoutSeconds: ptr.To[int](1)}
+
+			assert.Error(t, checkKMSProvider(context.Background(), kms))
+
+			server := kmsservice.NewGRPCService(socket, time.Second, &fakeKMSService{healthz: healthz})
+			serving := make(chan error, 1)
+			go func() { serving <- server.ListenAndServe() }()
+			assert.Eventually(t, func() bool {
+				_, err := os.Stat(socket)
+				return err == nil
+			}, 5*time.Second, 10*time.Millisecond)
+
+			err := checkKMSProvider(context.Background(), kms)
+			if healthz == "ok" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, "not healthy")
+			}
+			server.Close()
+			<-serving
+		})
+	}
+}
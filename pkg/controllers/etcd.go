@@ -19,12 +19,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"time"
 
 	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/servicemanager"
 	"github.com/openshift/microshift/pkg/util/cryptomaterial"
 	klog "k8s.io/klog/v2"
 
@@ -32,6 +34,10 @@ import (
 	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
+const (
+	EtcdEndpoint = "https://localhost:2379"
+)
+
 var (
 	HealthCheckRetries = 10
 	HealthCheckWait    = 3 * time.Second
@@ -39,20 +45,51 @@ var (
 
 type EtcdService struct {
 	memoryLimit uint64
+	memoryMax   uint64
+	cfg         *config.Config
 }
 
 func NewEtcd(cfg *config.Config) *EtcdService {
 	return &EtcdService{
 		memoryLimit: cfg.Etcd.MemoryLimitMB,
+		memoryMax:   cfg.Etcd.MemoryMaxMB,
+		cfg:         cfg,
 	}
 }
 
 func (s *EtcdService) Name() string           { return "etcd" }
 func (s *EtcdService) Dependencies() []string { return []string{} }
 
+// RestartPolicy restarts etcd a few times before restarting MicroShift,
+// so a slow or failed etcd start is retried without restarting every service.
+func (s *EtcdService) RestartPolicy() servicemanager.RestartPolicy {
+	return servicemanager.RestartPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 5 * time.Second,
+		MaxBackoff:     time.Minute,
+		GiveUp:         servicemanager.GiveUpStopMicroShift,
+	}
+}
+
+// ReadinessTimeout leaves enough time for all of the restarts of the
+// RestartPolicy.
+func (s *EtcdService) ReadinessTimeout() time.Duration { return 3 * time.Minute }
+func (s *EtcdService) ReadinessHint() string {
+	if s.cfg.Etcd.IsExternal() {
+		return fmt.Sprintf("check that the external etcd at %v is healthy and reachable, and that it trusts the client certificate %s",
+			s.cfg.Etcd.External.Endpoints, s.cfg.Etcd.External.CertFile)
+	}
+	return "the etcd database in " + filepath.Join(config.DataDir, "etcd") + " may be corrupt or the disk too slow; " +
+		"check the output of 'journalctl -u microshift-etcd.scope' and consider restoring a backup with 'microshift restore'"
+}
+
 func (s *EtcdService) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
 	defer close(stopped)
 
+	if s.cfg.Etcd.IsExternal() {
+		return s.runExternal(ctx, ready)
+	}
+
 	// Check to see if we should run as a systemd run or directly as a binary.
 	runningAsSvc := os.Getenv("INVOCATION_ID") != ""
 
@@ -87,11 +124,18 @@ func (s *EtcdService) Run(ctx context.Context, ready chan<- struct{}, stopped ch
 		if s.memoryLimit > 0 {
 			args = append(args, "--property", fmt.Sprintf("MemoryHigh=%vM", s.memoryLimit))
 		}
+		if s.memoryMax > 0 {
+			args = append(args, "--property", fmt.Sprintf("MemoryMax=%vM", s.memoryMax))
+		}
+		etcdMemoryMax.Set(float64(s.memoryMax * 1024 * 1024))
 
 		args = append(args, etcdPath)
 
 		exe = "systemd-run"
 	} else {
+		if s.memoryLimit > 0 || s.memoryMax > 0 {
+			klog.Warning("etcd memory limits are only enforced when MicroShift runs as a systemd service")
+		}
 		exe = etcdPath
 	}
 	args = append(args, "run")
@@ -111,24 +155,27 @@ func (s *EtcdService) Run(ctx context.Context, ready chan<- struct{}, stopped ch
 	}
 
 	// Handle microshift-etcd termination before microshift process exits
+	exited := make(chan struct{})
 	go func() {
+		defer close(exited)
 		if err := cmd.Wait(); err != nil {
 			klog.Warningf("%v failed waiting on process to finish: %+v", s.Name(), err)
 		}
 		klog.Infof("%v process quit: %v", s.Name(), cmd.ProcessState.String())
 
-		if !errors.Is(ctx.Err(), context.Canceled) {
-			// Exit microshift to trigger microshift-etcd restart
-			klog.Warning("microshift-etcd process terminated prematurely, restarting MicroShift")
-			os.Exit(0)
-		} else {
+		if errors.Is(ctx.Err(), context.Canceled) {
 			klog.Info("MicroShift is mid shutdown - ignoring etcd termination")
 		}
 	}()
 
-	// Ensures microshift-etcd unit stopped after microshift
+	// Ensures microshift-etcd unit stopped after microshift, or before
+	// etcd is restarted.
 	defer func() {
 		klog.Info("stopping microshift-etcd")
+		if !runningAsSvc {
+			_ = cmd.Process.Kill()
+			return
+		}
 		cmd := exec.Command("systemctl", "stop", "microshift-etcd.scope", "--no-block")
 
 		if out, err := cmd.CombinedOutput(); err != nil {
@@ -137,17 +184,74 @@ func (s *EtcdService) Run(ctx context.Context, ready chan<- struct{}, stopped ch
 		}
 	}()
 
-	if err := checkIfEtcdIsReady(ctx); err != nil {
-		return err
+	readyCtx, readyCancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-exited:
+			readyCancel()
+		case <-readyCtx.Done():
+		}
+	}()
+	err = checkIfEtcdIsReady(readyCtx, s.cfg)
+	readyCancel()
+	if err != nil {
+		select {
+		case <-exited:
+			return fmt.Errorf("microshift-etcd process terminated before becoming ready: %v", err)
+		default:
+			return err
+		}
 	}
 	klog.Info("etcd is ready!")
 	close(ready)
 
-	// Wait for MicroShift to be done
+	if s.cfg.Etcd.Standby.Role == config.EtcdStandbyRolePrimary {
+		go addStandbyLearner(ctx, s.cfg)
+	}
+
+	// systemd-run executes etcd in the scope, which has its own cgroup
+	// once etcd is running.
+	if runningAsSvc {
+		go reportEtcdMemoryUsage(cmd.Process.Pid, exited)
+	}
+
+	// Wait for MicroShift to be done, or for etcd to terminate prematurely
+	// in which case the service manager restarts it.
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-exited:
+		return fmt.Errorf("microshift-etcd process terminated prematurely")
+	}
+}
+
+// runExternal only waits for the external etcd to be reachable, its
+// lifecycle is managed by other tools.
+func (s *EtcdService) runExternal(ctx context.Context, ready chan<- struct{}) error {
+	klog.Infof("using external etcd at %v", s.cfg.Etcd.External.Endpoints)
+	if err := checkIfEtcdIsReady(ctx, s.cfg); err != nil {
+		return err
+	}
+	klog.Info("external etcd is ready!")
+	close(ready)
+
 	<-ctx.Done()
 	return ctx.Err()
 }
 
+func (s *EtcdService) HealthCheck(ctx context.Context) error {
+	client, err := GetEtcdClient(ctx, s.cfg)
+	if err != nil {
+		return fmt.Errorf("failed to obtain etcd client: %w", err)
+	}
+	defer client.Close()
+
+	if _, err := client.Get(ctx, "health", healthCheckOptions(s.cfg)...); err != nil {
+		return fmt.Errorf("etcd is not healthy: %w", err)
+	}
+	return nil
+}
+
 func stopMicroshiftEtcdScopeIfExists() error {
 	// There are several codes that systemctl can return like
 	// 0 - unit is active, 3 - unit is not active, 4 - no such unit.
@@ -168,8 +272,8 @@ func stopMicroshiftEtcdScopeIfExists() error {
 	return nil
 }
 
-func checkIfEtcdIsReady(ctx context.Context) error {
-	client, err := getEtcdClient(ctx)
+func checkIfEtcdIsReady(ctx context.Context, cfg *config.Config) error {
+	client, err := GetEtcdClient(ctx, cfg)
 	if err != nil {
 		return fmt.Errorf("failed to obtain etcd client: %v", err)
 	}
@@ -177,7 +281,7 @@ func checkIfEtcdIsReady(ctx context.Context) error {
 
 	for i := 0; i < HealthCheckRetries; i++ {
 		time.Sleep(HealthCheckWait)
-		if _, err = client.Get(ctx, "health"); err == nil {
+		if _, err = client.Get(ctx, "health", healthCheckOptions(cfg)...); err == nil {
 			return nil
 		} else {
 			klog.Infof("etcd not ready yet: %v", err)
@@ -189,22 +293,47 @@ func checkIfEtcdIsReady(ctx context.Context) error {
 	return fmt.Errorf("etcd still not healthy after checking %d times", HealthCheckRetries)
 }
 
-func getEtcdClient(ctx context.Context) (*clientv3.Client, error) {
+// healthCheckOptions returns the options of the read checking the health of
+// etcd. The learner of a standby node only serves serializable reads.
+func healthCheckOptions(cfg *config.Config) []clientv3.OpOption {
+	if cfg.Etcd.IsStandby() {
+		return []clientv3.OpOption{clientv3.WithSerializable()}
+	}
+	return nil
+}
+
+// etcdClientInfo returns the endpoints of the etcd used by MicroShift and
+// the client certificates to connect to it: the ones of the external etcd
+// if configured, or the apiserver client certificates from the data
+// directory for the MicroShift managed etcd.
+func etcdClientInfo(cfg *config.Config) ([]string, transport.TLSInfo) {
+	if cfg.Etcd.IsExternal() {
+		return cfg.Etcd.External.Endpoints, transport.TLSInfo{
+			CertFile:      cfg.Etcd.External.CertFile,
+			KeyFile:       cfg.Etcd.External.KeyFile,
+			TrustedCAFile: cfg.Etcd.External.CAFile,
+		}
+	}
+
 	certsDir := cryptomaterial.CertsDirectory(config.DataDir)
 	etcdAPIServerClientCertDir := cryptomaterial.EtcdAPIServerClientCertDir(certsDir)
-
-	tlsInfo := transport.TLSInfo{
+	return []string{EtcdEndpoint}, transport.TLSInfo{
 		CertFile:      cryptomaterial.ClientCertPath(etcdAPIServerClientCertDir),
 		KeyFile:       cryptomaterial.ClientKeyPath(etcdAPIServerClientCertDir),
 		TrustedCAFile: cryptomaterial.CACertPath(cryptomaterial.EtcdSignerDir(certsDir)),
 	}
+}
+
+// GetEtcdClient returns a client for the etcd used by MicroShift.
+func GetEtcdClient(ctx context.Context, cfg *config.Config) (*clientv3.Client, error) {
+	endpoints, tlsInfo := etcdClientInfo(cfg)
 	tlsConfig, err := tlsInfo.ClientConfig()
 	if err != nil {
 		return nil, err
 	}
 
 	cli, err := clientv3.New(clientv3.Config{
-		Endpoints:   []string{"https://localhost:2379"},
+		Endpoints:   endpoints,
 		DialTimeout: 5 * time.Second,
 		TLS:         tlsConfig,
 		Context:     ctx,
@@ -214,3 +343,38 @@ func getEtcdClient(ctx context.Context) (*clientv3.Client, error) {
 	}
 	return cli, nil
 }
+
+// SaveEtcdSnapshot streams the snapshot to a temporary file next to path,
+// and renames it once complete so a partial snapshot is never left at path.
+func SaveEtcdSnapshot(ctx context.Context, client *clientv3.Client, path string) (int64, error) {
+	rc, err := client.Snapshot(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to request etcd snapshot: %w", err)
+	}
+	defer rc.Close()
+
+	partPath := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".part")
+	f, err := os.OpenFile(partPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %q: %w", partPath, err)
+	}
+	defer os.Remove(partPath)
+
+	size, err := io.Copy(f, rc)
+	if err != nil {
+		f.Close()
+		return 0, fmt.Errorf("failed to receive etcd snapshot: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return 0, fmt.Errorf("failed to sync %q: %w", partPath, err)
+	}
+	if err := f.Close(); err != nil {
+		return 0, fmt.Errorf("failed to close %q: %w", partPath, err)
+	}
+
+	if err := os.Rename(partPath, path); err != nil {
+		return 0, fmt.Errorf("failed to rename %q to %q: %w", partPath, path, err)
+	}
+	return size, nil
+}
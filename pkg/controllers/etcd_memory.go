@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+const etcdMemoryReportInterval = 30 * time.Second
+
+var (
+	etcdMemoryUsage = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Name:           "microshift_etcd_memory_usage_bytes",
+			Help:           "Memory used by the cgroup of the MicroShift managed etcd.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+	etcdMemoryMax = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Name:           "microshift_etcd_memory_max_bytes",
+			Help:           "Hard memory limit of the MicroShift managed etcd, 0 if unlimited.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+)
+
+func init() {
+	legacyregistry.MustRegister(etcdMemoryUsage, etcdMemoryMax)
+}
+
+// reportEtcdMemoryUsage periodically reports the memory used by the cgroup
+// of the etcd process until done is closed.
+func reportEtcdMemoryUsage(pid int, done <-chan struct{}) {
+	dir, err := processCgroupDir(pid)
+	if err != nil {
+		klog.Warningf("Not reporting etcd memory usage: %v", err)
+		return
+	}
+
+	ticker := time.NewTicker(etcdMemoryReportInterval)
+	defer ticker.Stop()
+	for {
+		if usage, err := readCgroupMemoryCurrent(dir); err != nil {
+			klog.V(2).Infof("Failed to read etcd memory usage: %v", err)
+		} else {
+			etcdMemoryUsage.Set(float64(usage))
+		}
+
+		select {
+		case <-ticker.C:
+		case <-done:
+			return
+		}
+	}
+}
+
+func readCgroupMemoryCurrent(dir string) (uint64, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "memory.current"))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// processCgroupDir returns the cgroup v2 directory of the process.
+func processCgroupDir(pid int) (string, error) {
+	f, err := os.Open(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	path, err := parseCgroupV2Path(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to find cgroup of process %d: %w", pid, err)
+	}
+	return filepath.Join("/sys/fs/cgroup", path), nil
+}
+
+func parseCgroupV2Path(r io.Reader) (string, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if path, ok := strings.CutPrefix(scanner.Text(), "0::"); ok {
+			return path, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no cgroup v2 hierarchy")
+}
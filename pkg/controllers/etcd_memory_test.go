@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCgroupV2Path(t *testing.T) {
+	path, err := parseCgroupV2Path(strings.NewReader("0::/system.slice/microshift-etcd.scope\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "/system.slice/microshift-etcd.scope", path)
+
+	_, err = parseCgroupV2Path(strings.NewReader("12:memory:/system.slice/microshift.service\n1:name=systemd:/system.slice/microshift.service\n"))
+	assert.Error(t, err)
+}
+
+func TestReadCgroupMemoryCurrent(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "memory.current"), []byte("123456\n"), 0600))
+	usage, err := readCgroupMemoryCurrent(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(123456), usage)
+}
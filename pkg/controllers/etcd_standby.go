@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"k8s.io/apimachinery/pkg/util/wait"
+	klog "k8s.io/klog/v2"
+)
+
+const standbyRetryInterval = 30 * time.Second
+
+// addStandbyLearner makes the etcd member of the primary reachable at the
+// node IP and adds the member of the standby as a learner, retrying until
+// the membership is right or ctx is done. The standby replicates the
+// database without taking part in the quorum, so the primary keeps working
+// when the standby is down.
+func addStandbyLearner(ctx context.Context, cfg *config.Config) {
+	_ = wait.PollUntilContextCancel(ctx, standbyRetryInterval, true, func(ctx context.Context) (bool, error) {
+		if err := reconcileStandbyMembers(ctx, cfg); err != nil {
+			klog.Warningf("Failed to add the etcd standby %s as a learner: %v", cfg.Etcd.Standby.Peer, err)
+			return false, nil
+		}
+		return true, nil
+	})
+}
+
+func reconcileStandbyMembers(ctx context.Context, cfg *config.Config) error {
+	client, err := GetEtcdClient(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to obtain etcd client: %w", err)
+	}
+	defer client.Close()
+
+	resp, err := client.MemberList(ctx)
+	if err != nil {
+		return err
+	}
+	primaryURL := config.EtcdPeerURL(cfg.Node.NodeIP)
+	standbyURL := config.EtcdPeerURL(cfg.Etcd.Standby.Peer)
+	updateSelf, addLearner := standbyMembershipChanges(resp.Members, resp.Header.MemberId, primaryURL, standbyURL)
+
+	// The standby reaches the primary at the peer URL of its member,
+	// localhost when the node ran without standby before.
+	if updateSelf {
+		if _, err := client.MemberUpdate(ctx, resp.Header.MemberId, []string{primaryURL}); err != nil {
+			return fmt.Errorf("failed to update the peer URL of the etcd member to %s: %w", primaryURL, err)
+		}
+		klog.Infof("Updated the peer URL of the etcd member to %s", primaryURL)
+	}
+	if addLearner {
+		if _, err := client.MemberAddAsLearner(ctx, []string{standbyURL}); err != nil {
+			return err
+		}
+		klog.Infof("Added the etcd standby %s as a learner", standbyURL)
+	}
+	return nil
+}
+
+// standbyMembershipChanges returns whether the peer URL of the member self
+// must be updated to primaryURL, and whether a learner must be added at
+// standbyURL.
+func standbyMembershipChanges(members []*etcdserverpb.Member, self uint64, primaryURL, standbyURL string) (bool, bool) {
+	updateSelf, addLearner := false, true
+	for _, m := range members {
+		if m.ID == self {
+			updateSelf = !slices.Equal(m.PeerURLs, []string{primaryURL})
+		}
+		if slices.Contains(m.PeerURLs, standbyURL) {
+			addLearner = false
+			if !m.IsLearner {
+				klog.Warningf("etcd member %s of the standby %s is a voting member, the primary needs it for the quorum", m.Name, standbyURL)
+			}
+		}
+	}
+	return updateSelf, addLearner
+}
@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+func TestStandbyMembershipChanges(t *testing.T) {
+	const primaryURL, standbyURL = "https://192.0.2.10:2380", "https://192.0.2.11:2380"
+
+	tests := []struct {
+		name           string
+		members        []*etcdserverpb.Member
+		wantUpdateSelf bool
+		wantAddLearner bool
+	}{
+		{
+			name:           "single member on localhost",
+			members:        []*etcdserverpb.Member{{ID: 1, PeerURLs: []string{"https://localhost:2380"}}},
+			wantUpdateSelf: true,
+			wantAddLearner: true,
+		},
+		{
+			name:           "learner missing",
+			members:        []*etcdserverpb.Member{{ID: 1, PeerURLs: []string{primaryURL}}},
+			wantAddLearner: true,
+		},
+		{
+			name: "learner added",
+			members: []*etcdserverpb.Member{
+				{ID: 1, PeerURLs: []string{primaryURL}},
+				{ID: 2, PeerURLs: []string{standbyURL}, IsLearner: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updateSelf, addLearner := standbyMembershipChanges(tt.members, 1, primaryURL, standbyURL)
+			assert.Equal(t, tt.wantUpdateSelf, updateSelf)
+			assert.Equal(t, tt.wantAddLearner, addLearner)
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/journal"
+	"github.com/openshift/microshift/pkg/config"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// eventJournalIdentifier is the syslog identifier of the events in the
+	// journal, for journalctl -t.
+	eventJournalIdentifier = "microshift-events"
+	// eventJournalDropReportInterval is how often the number of events
+	// dropped by the rate limit is written to the journal.
+	eventJournalDropReportInterval = time.Minute
+)
+
+// EventJournal mirrors the Warning events of the cluster to journald, so
+// that the failures of the workloads show up next to the logs of the host.
+type EventJournal struct {
+	kubeconfig   string
+	maxPerMinute int
+	// send writes an entry to the journal, it is replaced by the tests.
+	send func(message string, priority journal.Priority, vars map[string]string) error
+	// since is the time the controller started, older events are not
+	// mirrored again.
+	since   time.Time
+	limiter *rate.Limiter
+	dropped atomic.Int64
+}
+
+func NewEventJournal(cfg *config.Config) *EventJournal {
+	return &EventJournal{
+		kubeconfig:   cfg.KubeConfigPath(config.KubeAdmin),
+		maxPerMinute: *cfg.Logging.Events.MaxPerMinute,
+		send:         journal.Send,
+	}
+}
+
+func (s *EventJournal) Name() string           { return "event-journal" }
+func (s *EventJournal) Dependencies() []string { return []string{"kube-apiserver"} }
+
+// Optional allows disabling the mirroring at runtime.
+func (s *EventJournal) Optional() bool { return true }
+
+func (s *EventJournal) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	defer close(stopped)
+
+	if !journal.Enabled() {
+		klog.Warningf("journald is not available, not mirroring the events")
+		close(ready)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", s.kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to build kubeconfig admin path: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(rest.AddUserAgent(restConfig, s.Name()))
+	if err != nil {
+		return fmt.Errorf("failed to create clientset for %s: %w", s.Name(), err)
+	}
+
+	s.since = time.Now()
+	s.limiter = rate.NewLimiter(rate.Every(time.Minute/time.Duration(s.maxPerMinute)), s.maxPerMinute)
+
+	factory := informers.NewSharedInformerFactoryWithOptions(client, 0,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("type", corev1.EventTypeWarning).String()
+		}))
+	informer := factory.Core().V1().Events().Informer()
+	_, err = informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if event, ok := obj.(*corev1.Event); ok {
+				s.mirror(event)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldEvent, ok := oldObj.(*corev1.Event)
+			if !ok {
+				return
+			}
+			// Repeated events are updated with a higher count.
+			if event, ok := newObj.(*corev1.Event); ok && eventCount(event) > eventCount(oldEvent) {
+				s.mirror(event)
+			}
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize event informer handlers: %w", err)
+	}
+
+	factory.Start(ctx.Done())
+	defer factory.Shutdown()
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return fmt.Errorf("timed out waiting for caches to sync")
+	}
+	klog.Infof("Mirroring Warning events to journald as %s", eventJournalIdentifier)
+	close(ready)
+
+	ticker := time.NewTicker(eventJournalDropReportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.reportDropped()
+			return ctx.Err()
+		case <-ticker.C:
+			s.reportDropped()
+		}
+	}
+}
+
+// mirror writes the event to the journal unless it happened before the
+// controller started or the rate limit is reached.
+func (s *EventJournal) mirror(event *corev1.Event) {
+	if eventTime(event).Before(s.since) {
+		return
+	}
+	if !s.limiter.Allow() {
+		s.dropped.Add(1)
+		return
+	}
+	message, vars := eventJournalEntry(event)
+	if err := s.send(message, journal.PriWarning, vars); err != nil {
+		klog.V(2).Infof("Failed to write event %s/%s to the journal: %v", event.Namespace, event.Name, err)
+	}
+}
+
+// reportDropped writes the number of events dropped by the rate limit since
+// the last report to the journal.
+func (s *EventJournal) reportDropped() {
+	dropped := s.dropped.Swap(0)
+	if dropped == 0 {
+		return
+	}
+	message := fmt.Sprintf("%d Warning events were not mirrored, over the limit of %d per minute", dropped, s.maxPerMinute)
+	if err := s.send(message, journal.PriWarning, map[string]string{
+		"SYSLOG_IDENTIFIER": eventJournalIdentifier,
+		"K8S_DROPPED":       strconv.FormatInt(dropped, 10),
+	}); err != nil {
+		klog.V(2).Infof("Failed to write the number of dropped events to the journal: %v", err)
+	}
+}
+
+// eventJournalEntry returns the message of the event in the journal and its
+// structured fields, to filter with e.g. journalctl K8S_NAMESPACE=default.
+func eventJournalEntry(event *corev1.Event) (string, map[string]string) {
+	object := event.InvolvedObject
+	name := object.Name
+	if object.Namespace != "" {
+		name = object.Namespace + "/" + object.Name
+	}
+	source := event.ReportingController
+	if source == "" {
+		source = event.Source.Component
+	}
+	message := fmt.Sprintf("%s %s %s: %s", object.Kind, name, event.Reason, event.Message)
+	return message, map[string]string{
+		"SYSLOG_IDENTIFIER": eventJournalIdentifier,
+		"K8S_NAMESPACE":     object.Namespace,
+		"K8S_KIND":          object.Kind,
+		"K8S_NAME":          object.Name,
+		"K8S_REASON":        event.Reason,
+		"K8S_COUNT":         strconv.Itoa(int(eventCount(event))),
+		"K8S_SOURCE":        source,
+	}
+}
+
+// eventCount returns the number of occurrences of the event.
+func eventCount(event *corev1.Event) int32 {
+	if event.Series != nil {
+		return event.Series.Count
+	}
+	if event.Count == 0 {
+		return 1
+	}
+	return event.Count
+}
+
+// eventTime returns the time of the last occurrence of the event.
+func eventTime(event *corev1.Event) time.Time {
+	switch {
+	case event.Series != nil:
+		return event.Series.LastObservedTime.Time
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
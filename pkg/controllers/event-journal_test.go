@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/journal"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEventJournalMirror(t *testing.T) {
+	type entry struct {
+		message string
+		vars    map[string]string
+	}
+	var entries []entry
+	now := time.Now()
+	s := &EventJournal{
+		maxPerMinute: 2,
+		since:        now,
+		limiter:      rate.NewLimiter(rate.Every(time.Minute/2), 2),
+		send: func(message string, _ journal.Priority, vars map[string]string) error {
+			entries = append(entries, entry{message, vars})
+			return nil
+		},
+	}
+	event := func(name string, at time.Time) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			InvolvedObject: corev1.ObjectReference{
+				Kind:      "Pod",
+				Namespace: "default",
+				Name:      "nginx",
+			},
+			Reason:        "BackOff",
+			Message:       "Back-off restarting failed container",
+			Source:        corev1.EventSource{Component: "kubelet"},
+			Count:         3,
+			LastTimestamp: metav1.NewTime(at),
+			Type:          corev1.EventTypeWarning,
+		}
+	}
+
+	s.mirror(event("before-start", now.Add(-time.Minute)))
+	s.mirror(event("first", now.Add(time.Second)))
+	s.mirror(event("second", now.Add(time.Second)))
+	s.mirror(event("over-limit", now.Add(time.Second)))
+
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "Pod default/nginx BackOff: Back-off restarting failed container", entries[0].message)
+	assert.Equal(t, map[string]string{
+		"SYSLOG_IDENTIFIER": "microshift-events",
+		"K8S_NAMESPACE":     "default",
+		"K8S_KIND":          "Pod",
+		"K8S_NAME":          "nginx",
+		"K8S_REASON":        "BackOff",
+		"K8S_COUNT":         "3",
+		"K8S_SOURCE":        "kubelet",
+	}, entries[0].vars)
+
+	s.reportDropped()
+	assert.Len(t, entries, 3)
+	assert.Equal(t, "1", entries[2].vars["K8S_DROPPED"])
+
+	s.reportDropped()
+	assert.Len(t, entries, 3, "nothing dropped since the last report")
+}
+
+func TestEventCountAndTime(t *testing.T) {
+	observed := time.Now()
+	event := &corev1.Event{
+		Series: &corev1.EventSeries{Count: 5, LastObservedTime: metav1.NewMicroTime(observed)},
+	}
+	assert.Equal(t, int32(5), eventCount(event))
+	assert.True(t, eventTime(event).Equal(observed))
+
+	event = &corev1.Event{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(observed)}}
+	assert.Equal(t, int32(1), eventCount(event))
+	assert.True(t, eventTime(event).Equal(observed))
+}
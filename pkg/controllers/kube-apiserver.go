@@ -76,11 +76,14 @@ func init() {
 type KubeAPIServer struct {
 	kasConfigBytes []byte
 	verbosity      int
+	logFormat      string
 	configureErr   error // todo: report configuration errors immediately
 
 	masterURL        string
 	servingCAPath    string
 	advertiseAddress string
+	// kms is the KMS plugin that must be healthy for kube-apiserver to be ready.
+	kms *config.EncryptionKMS
 }
 
 func NewKubeAPIServer(cfg *config.Config) *KubeAPIServer {
@@ -91,11 +94,13 @@ func NewKubeAPIServer(cfg *config.Config) *KubeAPIServer {
 	return s
 }
 
-func (s *KubeAPIServer) Name() string           { return "kube-apiserver" }
-func (s *KubeAPIServer) Dependencies() []string { return []string{"etcd", "network-configuration"} }
+func (s *KubeAPIServer) Name() string              { return "kube-apiserver" }
+func (s *KubeAPIServer) Dependencies() []string    { return []string{"etcd", "network-configuration"} }
+func (s *KubeAPIServer) ConfigurationError() error { return s.configureErr }
 
 func (s *KubeAPIServer) configure(cfg *config.Config) error {
 	s.verbosity = cfg.GetVerbosity()
+	s.logFormat = string(cfg.Logging.Format)
 
 	certsDir := cryptomaterial.CertsDirectory(config.DataDir)
 	kubeCSRSignerDir := cryptomaterial.CSRSignerCertDir(certsDir)
@@ -103,7 +108,7 @@ func (s *KubeAPIServer) configure(cfg *config.Config) error {
 	clientCABundlePath := cryptomaterial.TotalClientCABundlePath(certsDir)
 	aggregatorCAPath := cryptomaterial.CACertPath(cryptomaterial.AggregatorSignerDir(certsDir))
 	aggregatorClientCertDir := cryptomaterial.AggregatorClientCertDir(certsDir)
-	etcdClientCertDir := cryptomaterial.EtcdAPIServerClientCertDir(certsDir)
+	etcdEndpoints, etcdTLSInfo := etcdClientInfo(cfg)
 	serviceNetworkServingCertDir := cryptomaterial.KubeAPIServerServiceNetworkServingCertDir(certsDir)
 	servingCert := cryptomaterial.ServingCertPath(serviceNetworkServingCertDir)
 	servingKey := cryptomaterial.ServingKeyPath(serviceNetworkServingCertDir)
@@ -111,6 +116,10 @@ func (s *KubeAPIServer) configure(cfg *config.Config) error {
 	if err := s.configureAuditPolicy(cfg); err != nil {
 		return fmt.Errorf("failed to configure kube-apiserver audit policy: %w", err)
 	}
+	encryptionConfig, err := ensureEncryptionConfig(cfg.ApiServer.Encryption, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to configure kube-apiserver encryption: %w", err)
+	}
 
 	s.masterURL = cfg.ApiServer.URL
 	s.servingCAPath = cryptomaterial.ServiceAccountTokenCABundlePath(certsDir)
@@ -166,18 +175,16 @@ func (s *KubeAPIServer) configure(cfg *config.Config) error {
 
 	overrides := &kubecontrolplanev1.KubeAPIServerConfig{
 		APIServerArguments: map[string]kubecontrolplanev1.Arguments{
-			"advertise-address":   {s.advertiseAddress},
-			"audit-policy-file":   {filepath.Join(config.DataDir, "/resources/kube-apiserver-audit-policies/default.yaml")},
-			"audit-log-maxage":    {strconv.Itoa(cfg.ApiServer.AuditLog.MaxFileAge)},
-			"audit-log-maxbackup": {strconv.Itoa(cfg.ApiServer.AuditLog.MaxFiles)},
-			"audit-log-maxsize":   {strconv.Itoa(cfg.ApiServer.AuditLog.MaxFileSize)},
-			"client-ca-file":      {clientCABundlePath},
-			"etcd-cafile":         {cryptomaterial.CACertPath(cryptomaterial.EtcdSignerDir(certsDir))},
-			"etcd-certfile":       {cryptomaterial.ClientCertPath(etcdClientCertDir)},
-			"etcd-keyfile":        {cryptomaterial.ClientKeyPath(etcdClientCertDir)},
-			"etcd-servers": {
-				"https://localhost:2379",
-			},
+			"advertise-address":             {s.advertiseAddress},
+			"audit-policy-file":             {filepath.Join(config.DataDir, "/resources/kube-apiserver-audit-policies/default.yaml")},
+			"audit-log-maxage":              {strconv.Itoa(cfg.ApiServer.AuditLog.MaxFileAge)},
+			"audit-log-maxbackup":           {strconv.Itoa(cfg.ApiServer.AuditLog.MaxFiles)},
+			"audit-log-maxsize":             {strconv.Itoa(cfg.ApiServer.AuditLog.MaxFileSize)},
+			"client-ca-file":                {clientCABundlePath},
+			"etcd-cafile":                   {etcdTLSInfo.TrustedCAFile},
+			"etcd-certfile":                 {etcdTLSInfo.CertFile},
+			"etcd-keyfile":                  {etcdTLSInfo.KeyFile},
+			"etcd-servers":                  etcdEndpoints,
 			"kubelet-certificate-authority": {cryptomaterial.CABundlePath(kubeCSRSignerDir)},
 			"kubelet-client-certificate":    {cryptomaterial.ClientCertPath(kubeletClientDir)},
 			"kubelet-client-key":            {cryptomaterial.ClientKeyPath(kubeletClientDir)},
@@ -229,7 +236,7 @@ func (s *KubeAPIServer) configure(cfg *config.Config) error {
 									APIVersion: "route.openshift.io/v1",
 									Kind:       "HostAssignmentAdmissionConfig",
 								},
-								Domain: "apps." + cfg.DNS.BaseDomain,
+								Domain: cfg.Ingress.Domain,
 							},
 						},
 					},
@@ -239,10 +246,11 @@ func (s *KubeAPIServer) configure(cfg *config.Config) error {
 			CORSAllowedOrigins: []string{
 				`//127\.0\.0\.1(:|$)`,
 				`//localhost(:|$)`,
+				`//\[::1\](:|$)`,
 			},
 			ServingInfo: configv1.HTTPServingInfo{
 				ServingInfo: configv1.ServingInfo{
-					BindAddress:       net.JoinHostPort("0.0.0.0", strconv.Itoa(cfg.ApiServer.Port)),
+					BindAddress:       net.JoinHostPort(cfg.UnspecifiedAddress(), strconv.Itoa(cfg.ApiServer.Port)),
 					MinTLSVersion:     string(fixedTLSProfile.MinTLSVersion),
 					CipherSuites:      crypto.OpenSSLToIANACipherSuites(fixedTLSProfile.Ciphers),
 					NamedCertificates: namedCerts,
@@ -254,6 +262,81 @@ func (s *KubeAPIServer) configure(cfg *config.Config) error {
 		},
 		ServicesNodePortRange: cfg.Network.ServiceNodePortRange,
 	}
+	if cfg.ApiServer.Encryption.Provider == config.EncryptionProviderKMS {
+		s.kms = &cfg.ApiServer.Encryption.KMS
+	}
+	tuning := cfg.ApiServer.Tuning
+	if tuning.MaxRequestsInflight != nil {
+		overrides.APIServerArguments["max-requests-inflight"] = kubecontrolplanev1.Arguments{strconv.Itoa(*tuning.MaxRequestsInflight)}
+	}
+	if tuning.MaxMutatingRequestsInflight != nil {
+		overrides.APIServerArguments["max-mutating-requests-inflight"] = kubecontrolplanev1.Arguments{strconv.Itoa(*tuning.MaxMutatingRequestsInflight)}
+	}
+	if len(tuning.WatchCacheSizes) > 0 {
+		overrides.APIServerArguments["watch-cache-sizes"] = kubecontrolplanev1.Arguments{strings.Join(tuning.WatchCacheSizes, ",")}
+	}
+	if tuning.GoAwayChance != nil {
+		overrides.APIServerArguments["goaway-chance"] = kubecontrolplanev1.Arguments{strconv.FormatFloat(*tuning.GoAwayChance, 'f', -1, 64)}
+	}
+	if len(tuning.DisabledAPIs) > 0 {
+		runtimeConfig := make([]string, 0, len(tuning.DisabledAPIs))
+		for _, api := range tuning.DisabledAPIs {
+			runtimeConfig = append(runtimeConfig, api+"=false")
+		}
+		overrides.APIServerArguments["runtime-config"] = kubecontrolplanev1.Arguments{strings.Join(runtimeConfig, ",")}
+		// Priority and fairness cannot be configured without its API, the
+		// API server falls back to the max requests inflight limits.
+		if tuning.IsAPIDisabled("flowcontrol.apiserver.k8s.io/v1", "flowschemas") ||
+			tuning.IsAPIDisabled("flowcontrol.apiserver.k8s.io/v1", "prioritylevelconfigurations") {
+			overrides.APIServerArguments["enable-priority-and-fairness"] = kubecontrolplanev1.Arguments{"false"}
+		}
+	}
+
+	// The default issuer is kept as a secondary one so that the tokens it
+	// issued remain valid after configuring another issuer.
+	issuers := kubecontrolplanev1.Arguments{cfg.ApiServer.ServiceAccount.Issuer}
+	if issuers[0] != config.DefaultServiceAccountIssuer {
+		issuers = append(issuers, config.DefaultServiceAccountIssuer)
+	}
+	overrides.APIServerArguments["service-account-issuer"] = issuers
+	overrides.APIServerArguments["api-audiences"] = issuers
+	if cfg.ApiServer.ServiceAccount.JWKSURI != "" {
+		overrides.APIServerArguments["service-account-jwks-uri"] = kubecontrolplanev1.Arguments{cfg.ApiServer.ServiceAccount.JWKSURI}
+	}
+	if webhook := cfg.ApiServer.Authorization.Webhook; webhook.IsEnabled() {
+		if _, err := os.Stat(webhook.KubeconfigPath); err != nil {
+			return fmt.Errorf("failed to read authorization webhook kubeconfig: %w", err)
+		}
+		// The webhook comes after SystemMasters so MicroShift and its admin
+		// keep access when the webhook is unavailable, and before RBAC, which
+		// never denies, so it is consulted for all of the other requests.
+		overrides.APIServerArguments["authorization-mode"] = kubecontrolplanev1.Arguments{"Scope", "SystemMasters", "Webhook", "RBAC", "Node"}
+		overrides.APIServerArguments["authorization-webhook-config-file"] = kubecontrolplanev1.Arguments{webhook.KubeconfigPath}
+		overrides.APIServerArguments["authorization-webhook-version"] = kubecontrolplanev1.Arguments{"v1"}
+		if webhook.CacheAuthorizedTTLSeconds != nil {
+			overrides.APIServerArguments["authorization-webhook-cache-authorized-ttl"] = kubecontrolplanev1.Arguments{
+				(time.Duration(*webhook.CacheAuthorizedTTLSeconds) * time.Second).String(),
+			}
+		}
+		if webhook.CacheUnauthorizedTTLSeconds != nil {
+			overrides.APIServerArguments["authorization-webhook-cache-unauthorized-ttl"] = kubecontrolplanev1.Arguments{
+				(time.Duration(*webhook.CacheUnauthorizedTTLSeconds) * time.Second).String(),
+			}
+		}
+	}
+	if cfg.ApiServer.PodSecurity.Mode == config.PodSecurityModeLogOnly {
+		// Only the enforce level changes, violations of the restricted level
+		// keep being audited, warned about and counted in the
+		// pod_security_evaluations_total metric.
+		overrides.AdmissionConfig.PluginConfig["PodSecurity"] = configv1.AdmissionPluginConfig{
+			Configuration: runtime.RawExtension{
+				Raw: []byte(`{"kind":"PodSecurityConfiguration","apiVersion":"pod-security.admission.config.k8s.io/v1","defaults":{"enforce":"privileged"}}`),
+			},
+		}
+	}
+	if encryptionConfig != "" {
+		overrides.APIServerArguments["encryption-provider-config"] = kubecontrolplanev1.Arguments{encryptionConfig}
+	}
 
 	overridesBytes, err := json.Marshal(overrides)
 	if err != nil {
@@ -308,6 +391,18 @@ func (s *KubeAPIServer) configureAuditPolicy(cfg *config.Config) error {
 	return os.WriteFile(path, data, 0400)
 }
 
+// ReadinessTimeout is 0 because Run already fails when kube-apiserver is not
+// ready after kubeAPIStartupTimeout.
+func (s *KubeAPIServer) ReadinessTimeout() time.Duration { return 0 }
+func (s *KubeAPIServer) ReadinessHint() string {
+	hint := "kube-apiserver cannot start without a healthy etcd; check the output of 'journalctl -u microshift-etcd.scope' " +
+		"and the certificates with 'microshift certs list'"
+	if s.kms != nil {
+		hint += fmt.Sprintf("; secrets cannot be read or written without the KMS plugin listening on %s", s.kms.Endpoint)
+	}
+	return hint
+}
+
 func (s *KubeAPIServer) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
 	if s.configureErr != nil {
 		return fmt.Errorf("configuration failed: %w", s.configureErr)
@@ -345,6 +440,12 @@ func (s *KubeAPIServer) Run(ctx context.Context, ready chan<- struct{}, stopped
 				klog.Infof("%q not yet ready: received http status %d", s.Name(), status)
 				return false, nil
 			}
+			if s.kms != nil {
+				if err := checkKMSProvider(ctx, *s.kms); err != nil {
+					klog.Infof("%q not yet ready: %v", s.Name(), err)
+					return false, nil
+				}
+			}
 			return true, nil
 		})
 		if err != nil {
@@ -388,6 +489,7 @@ func (s *KubeAPIServer) Run(ctx context.Context, ready chan<- struct{}, stopped
 	cmd.SetArgs([]string{
 		"--openshift-config", fd.Name(),
 		"-v", strconv.Itoa(s.verbosity),
+		"--logging-format", s.logFormat,
 	})
 	go func() {
 		errorChannel <- cmd.ExecuteContext(ctx)
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+
+	kubecontrolplanev1 "github.com/openshift/api/kubecontrolplane/v1"
+
+	"github.com/openshift/microshift/pkg/config"
+)
+
+func TestKubeAPIServerAuthorizationWebhook(t *testing.T) {
+	dataDir := config.DataDir
+	config.DataDir = t.TempDir()
+	defer func() { config.DataDir = dataDir }()
+
+	args := func(cfg *config.Config) map[string]kubecontrolplanev1.Arguments {
+		s := NewKubeAPIServer(cfg)
+		assert.NoError(t, s.configureErr)
+		kasConfig := &kubecontrolplanev1.KubeAPIServerConfig{}
+		assert.NoError(t, yaml.Unmarshal(s.kasConfigBytes, kasConfig))
+		return kasConfig.APIServerArguments
+	}
+
+	cfg := config.NewDefault()
+	cfg.ApiServer.AdvertiseAddresses = []string{cfg.ApiServer.AdvertiseAddress}
+	got := args(cfg)
+	assert.Equal(t, kubecontrolplanev1.Arguments{"Scope", "SystemMasters", "RBAC", "Node"}, got["authorization-mode"])
+	assert.NotContains(t, got, "authorization-webhook-config-file")
+	assert.Equal(t, kubecontrolplanev1.Arguments{config.DefaultServiceAccountIssuer}, got["service-account-issuer"])
+
+	kubeconfig := filepath.Join(t.TempDir(), "webhook.kubeconfig")
+	cfg.ApiServer.Authorization.Webhook.KubeconfigPath = kubeconfig
+	cfg.ApiServer.Authorization.Webhook.CacheUnauthorizedTTLSeconds = ptr.To[int](0)
+	s := NewKubeAPIServer(cfg)
+	assert.ErrorContains(t, s.configureErr, "authorization webhook kubeconfig")
+
+	assert.NoError(t, os.WriteFile(kubeconfig, []byte{}, 0600))
+	got = args(cfg)
+	assert.Equal(t, kubecontrolplanev1.Arguments{"Scope", "SystemMasters", "Webhook", "RBAC", "Node"}, got["authorization-mode"])
+	assert.Equal(t, kubecontrolplanev1.Arguments{kubeconfig}, got["authorization-webhook-config-file"])
+	assert.Equal(t, kubecontrolplanev1.Arguments{"5m0s"}, got["authorization-webhook-cache-authorized-ttl"])
+	assert.Equal(t, kubecontrolplanev1.Arguments{"0s"}, got["authorization-webhook-cache-unauthorized-ttl"])
+}
+
+func TestKubeAPIServerServiceAccountIssuer(t *testing.T) {
+	dataDir := config.DataDir
+	config.DataDir = t.TempDir()
+	defer func() { config.DataDir = dataDir }()
+
+	cfg := config.NewDefault()
+	cfg.ApiServer.AdvertiseAddresses = []string{cfg.ApiServer.AdvertiseAddress}
+	cfg.ApiServer.ServiceAccount.Issuer = "https://oidc.example.com/edge-1"
+	cfg.ApiServer.ServiceAccount.JWKSURI = "https://oidc.example.com/edge-1/openid/v1/jwks"
+	s := NewKubeAPIServer(cfg)
+	assert.NoError(t, s.configureErr)
+	kasConfig := &kubecontrolplanev1.KubeAPIServerConfig{}
+	assert.NoError(t, yaml.Unmarshal(s.kasConfigBytes, kasConfig))
+
+	issuers := kubecontrolplanev1.Arguments{"https://oidc.example.com/edge-1", config.DefaultServiceAccountIssuer}
+	assert.Equal(t, issuers, kasConfig.APIServerArguments["service-account-issuer"])
+	assert.Equal(t, issuers, kasConfig.APIServerArguments["api-audiences"])
+	assert.Equal(t, kubecontrolplanev1.Arguments{cfg.ApiServer.ServiceAccount.JWKSURI}, kasConfig.APIServerArguments["service-account-jwks-uri"])
+}
+
+func TestKubeAPIServerTuning(t *testing.T) {
+	dataDir := config.DataDir
+	config.DataDir = t.TempDir()
+	defer func() { config.DataDir = dataDir }()
+
+	cfg := config.NewDefault()
+	cfg.ApiServer.AdvertiseAddresses = []string{cfg.ApiServer.AdvertiseAddress}
+	cfg.ApiServer.Tuning.WatchCacheSizes = []string{"secrets#0", "deployments.apps#50"}
+	cfg.ApiServer.Tuning.GoAwayChance = ptr.To[float64](0.001)
+	cfg.ApiServer.Tuning.DisabledAPIs = []string{"batch/v1/cronjobs", "flowcontrol.apiserver.k8s.io/v1"}
+	s := NewKubeAPIServer(cfg)
+	assert.NoError(t, s.configureErr)
+	kasConfig := &kubecontrolplanev1.KubeAPIServerConfig{}
+	assert.NoError(t, yaml.Unmarshal(s.kasConfigBytes, kasConfig))
+
+	assert.Equal(t, kubecontrolplanev1.Arguments{"400"}, kasConfig.APIServerArguments["max-requests-inflight"])
+	assert.Equal(t, kubecontrolplanev1.Arguments{"200"}, kasConfig.APIServerArguments["max-mutating-requests-inflight"])
+	assert.Equal(t, kubecontrolplanev1.Arguments{"secrets#0,deployments.apps#50"}, kasConfig.APIServerArguments["watch-cache-sizes"])
+	assert.Equal(t, kubecontrolplanev1.Arguments{"0.001"}, kasConfig.APIServerArguments["goaway-chance"])
+	assert.Equal(t, kubecontrolplanev1.Arguments{"batch/v1/cronjobs=false,flowcontrol.apiserver.k8s.io/v1=false"}, kasConfig.APIServerArguments["runtime-config"])
+	assert.Equal(t, kubecontrolplanev1.Arguments{"false"}, kasConfig.APIServerArguments["enable-priority-and-fairness"])
+}
+
+func TestKubeAPIServerPodSecurityLogOnly(t *testing.T) {
+	dataDir := config.DataDir
+	config.DataDir = t.TempDir()
+	defer func() { config.DataDir = dataDir }()
+
+	podSecurityDefaults := func(cfg *config.Config) map[string]any {
+		s := NewKubeAPIServer(cfg)
+		assert.NoError(t, s.configureErr)
+		kasConfig := map[string]any{}
+		assert.NoError(t, yaml.Unmarshal(s.kasConfigBytes, &kasConfig))
+		pluginConfig := kasConfig["admission"].(map[string]any)["pluginConfig"].(map[string]any)
+		return pluginConfig["PodSecurity"].(map[string]any)["configuration"].(map[string]any)["defaults"].(map[string]any)
+	}
+
+	cfg := config.NewDefault()
+	cfg.ApiServer.AdvertiseAddresses = []string{cfg.ApiServer.AdvertiseAddress}
+	assert.Equal(t, "restricted", podSecurityDefaults(cfg)["enforce"])
+
+	cfg.ApiServer.PodSecurity.Mode = config.PodSecurityModeLogOnly
+	defaults := podSecurityDefaults(cfg)
+	assert.Equal(t, "privileged", defaults["enforce"])
+	assert.Equal(t, "restricted", defaults["audit"])
+	assert.Equal(t, "restricted", defaults["warn"])
+	assert.Equal(t, "latest", defaults["enforce-version"])
+}
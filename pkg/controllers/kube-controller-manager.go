@@ -18,7 +18,6 @@ package controllers
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"path/filepath"
 	"sort"
@@ -29,19 +28,19 @@ import (
 	embedded "github.com/openshift/microshift/assets"
 	"github.com/openshift/microshift/pkg/assets"
 	"github.com/openshift/microshift/pkg/config"
-	"github.com/openshift/microshift/pkg/util"
 	"github.com/openshift/microshift/pkg/util/cryptomaterial"
 
 	kubecontrolplanev1 "github.com/openshift/api/kubecontrolplane/v1"
 	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
-	kubecm "k8s.io/kubernetes/cmd/kube-controller-manager/app"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/kubernetes/cmd/kube-controller-manager/names"
 
-	klog "k8s.io/klog/v2"
 	"sigs.k8s.io/yaml"
 )
 
 const (
-	kcmDefaultConfigAsset = "controllers/kube-controller-manager/defaultconfig.yaml"
+	kcmDefaultConfigAsset           = "controllers/kube-controller-manager/defaultconfig.yaml"
+	kubeControllerManagerHealthzURL = "https://localhost:10257/healthz"
 )
 
 type KubeControllerManager struct {
@@ -59,8 +58,9 @@ func NewKubeControllerManager(ctx context.Context, cfg *config.Config) *KubeCont
 	return s
 }
 
-func (s *KubeControllerManager) Name() string           { return "kube-controller-manager" }
-func (s *KubeControllerManager) Dependencies() []string { return []string{"kube-apiserver"} }
+func (s *KubeControllerManager) Name() string              { return "kube-controller-manager" }
+func (s *KubeControllerManager) Dependencies() []string    { return []string{"kube-apiserver"} }
+func (s *KubeControllerManager) ConfigurationError() error { return s.configureErr }
 
 func kcmRootCAFile() string {
 	certsDir := cryptomaterial.CertsDirectory(config.DataDir)
@@ -97,11 +97,24 @@ func configure(ctx context.Context, cfg *config.Config) (args []string, applyFn
 			"cluster-signing-cert-file":        {clusterSigningCert},
 			"cluster-signing-key-file":         {clusterSigningKey},
 			"v":                                {strconv.Itoa(cfg.GetVerbosity())},
+			"logging-format":                   {string(cfg.Logging.Format)},
 			"tls-cipher-suites":                {strings.Join(crypto.OpenSSLToIANACipherSuites(fixedTLSProfile.Ciphers), ",")},
 			"tls-min-version":                  {string(fixedTLSProfile.MinTLSVersion)},
+			"terminated-pod-gc-threshold":      {strconv.Itoa(*cfg.ControllerManager.TerminatedPodGCThreshold)},
+			"node-monitor-grace-period":        {fmt.Sprintf("%ds", *cfg.ControllerManager.NodeMonitorGracePeriodSeconds)},
 		},
 	}
 
+	if len(cfg.ControllerManager.Controllers) > 0 {
+		if err := validateKCMControllers(cfg.ControllerManager.Controllers); err != nil {
+			return nil, nil, err
+		}
+		overrides.ExtendedArguments["controllers"] = cfg.ControllerManager.Controllers
+	}
+	if disabled := controllersOfDisabledAPIs(cfg.ApiServer.Tuning); len(disabled) > 0 {
+		overrides.ExtendedArguments["controllers"] = append(overrides.ExtendedArguments["controllers"], disabled...)
+	}
+
 	args, err = mergeAndConvertToArgs(overrides)
 	applyFn = func() error {
 		return assets.ApplyNamespaces(ctx, []string{
@@ -112,40 +125,35 @@ func configure(ctx context.Context, cfg *config.Config) (args []string, applyFn
 	return args, applyFn, err
 }
 
+// controllersOfDisabledAPIs returns the exclusions of the controllers of the
+// APIs disabled in the API server, which would retry listing them forever.
+func controllersOfDisabledAPIs(tuning config.ApiServerTuning) []string {
+	var disabled []string
+	if tuning.IsAPIDisabled("batch/v1", "jobs") {
+		// The cron jobs and the TTL of the finished jobs are implemented
+		// with jobs.
+		disabled = append(disabled, "-"+names.JobController, "-"+names.CronJobController, "-"+names.TTLAfterFinishedController)
+	} else if tuning.IsAPIDisabled("batch/v1", "cronjobs") {
+		disabled = append(disabled, "-"+names.CronJobController)
+	}
+	if tuning.IsAPIDisabled("autoscaling/v2", "horizontalpodautoscalers") {
+		disabled = append(disabled, "-"+names.HorizontalPodAutoscalerController)
+	}
+	return disabled
+}
+
 func (s *KubeControllerManager) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
 	if s.configureErr != nil {
 		return fmt.Errorf("configuration failed: %w", s.configureErr)
 	}
-
-	defer close(stopped)
-	errorChannel := make(chan error, 1)
-
-	// run readiness check
-	go func() {
-		// This endpoint uses a self-signed certificate on purpose, we need to skip verification.
-		healthcheckStatus := util.RetryInsecureGet(ctx, "https://localhost:10257/healthz")
-		if healthcheckStatus != 200 {
-			klog.Errorf("kube-controller-manager failed to start")
-			errorChannel <- errors.New("kube-controller-manager failed to start")
+	if !embeddedComponents {
+		if err := s.applyFn(); err != nil {
+			close(stopped)
+			return fmt.Errorf("failed to apply openshift namespaces: %w", err)
 		}
-
-		klog.Infof("%s is ready", s.Name())
-		close(ready)
-	}()
-
-	// Carrying a patch for NewControllerManagerCommand to use cmd.Context().Done()
-	// as the stop channel instead of the channel returned by SetupSignalHandler,
-	// which expects to be called at most once in a process.
-	cmd := kubecm.NewControllerManagerCommand()
-	cmd.SetArgs(s.args)
-	go func() {
-		errorChannel <- cmd.ExecuteContext(ctx)
-	}()
-
-	if err := s.applyFn(); err != nil {
-		return fmt.Errorf("failed to apply openshift namespaces: %w", err)
+		return newComponentProcess(s.Name(), s.args, kubeControllerManagerHealthzURL).Run(ctx, ready, stopped)
 	}
-	return <-errorChannel
+	return s.runEmbedded(ctx, ready, stopped)
 }
 
 func mergeAndConvertToArgs(overrides *kubecontrolplanev1.KubeControllerManagerConfig) ([]string, error) {
@@ -158,7 +166,14 @@ func mergeAndConvertToArgs(overrides *kubecontrolplanev1.KubeControllerManagerCo
 		return nil, fmt.Errorf("failed to marshal KubeControllerManagerConfig, error: %w", err)
 	}
 	mergedBytes, err := resourcemerge.MergePrunedProcessConfig(
-		&kubecontrolplanev1.KubeControllerManagerConfig{}, nil, defaultConfigBytes, overridesBytes)
+		&kubecontrolplanev1.KubeControllerManagerConfig{},
+		map[string]resourcemerge.MergeFunc{
+			// The controllers configured by the user are added to the defaults.
+			".extendedArguments.controllers": func(dst interface{}, src interface{}, currentPath string) (interface{}, error) {
+				return mergeControllers(dst.([]interface{}), src.([]interface{})), nil
+			},
+		},
+		defaultConfigBytes, overridesBytes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to merge kube-controller-manager configuration: error: %w", err)
 	}
@@ -170,6 +185,31 @@ func mergeAndConvertToArgs(overrides *kubecontrolplanev1.KubeControllerManagerCo
 	return GetKubeControllerManagerArgs(kubeControllerManagerConfig), nil
 }
 
+// mergeControllers adds the controllers of the user to the defaults,
+// dropping the defaults for the same controllers: kube-controller-manager
+// applies the first entry matching a controller, so a default disabling a
+// controller would win over the user enabling it.
+func mergeControllers(defaults, user []interface{}) []interface{} {
+	named := sets.New[string]()
+	for _, c := range user {
+		named.Insert(controllerKey(c.(string)))
+	}
+	merged := []interface{}{}
+	for _, c := range defaults {
+		if c.(string) == "*" || !named.Has(controllerKey(c.(string))) {
+			merged = append(merged, c)
+		}
+	}
+	return append(merged, user...)
+}
+
+// controllerKey identifies the controller of an entry of --controllers by
+// either its name or its alias, e.g. ttl-controller and ttl.
+func controllerKey(entry string) string {
+	name := strings.TrimPrefix(entry, "-")
+	return strings.ReplaceAll(strings.TrimSuffix(name, "-controller"), "-", "")
+}
+
 // This is a straight copy from KCM operator repo
 func GetKubeControllerManagerArgs(config map[string]interface{}) []string {
 	extendedArguments, ok := config["extendedArguments"]
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
@@ -67,10 +68,13 @@ func TestConfigure(t *testing.T) {
 		"--leader-elect-resource-lock=leases",
 		"--leader-elect-retry-period=3s",
 		"--leader-elect=false",
+		"--logging-format=text",
+		"--node-monitor-grace-period=40s",
 		fmt.Sprintf("--root-ca-file=%s", kcmRootCAFile()),
 		"--secure-port=10257",
 		fmt.Sprintf("--service-account-private-key-file=%s", kcmServiceAccountPrivateKeyFile()),
 		fmt.Sprintf("--service-cluster-ip-range=%s", cfg.Network.ServiceNetwork[0]),
+		"--terminated-pod-gc-threshold=12500",
 		fmt.Sprintf("--tls-cipher-suites=%s", strings.Join(crypto.OpenSSLToIANACipherSuites(fixedTLSProfile.Ciphers), ",")),
 		fmt.Sprintf("--tls-min-version=%s", string(fixedTLSProfile.MinTLSVersion)),
 		"--use-service-account-credentials=true",
@@ -82,3 +86,71 @@ func TestConfigure(t *testing.T) {
 		t.Errorf("expected args to match - diff: %s", cmp.Diff(argsWant, argsGot))
 	}
 }
+
+func TestConfigureControllers(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.ControllerManager.Controllers = []string{"-ttl-after-finished-controller", "-cronjob"}
+	kcm := NewKubeControllerManager(context.TODO(), cfg)
+	if err := kcm.ConfigurationError(); err != nil {
+		t.Fatalf("unexpected configuration error: %v", err)
+	}
+
+	controllersWant := []string{
+		"--controllers=*",
+		"--controllers=-bootstrapsigner",
+		"--controllers=-cronjob",
+		"--controllers=-tokencleaner",
+		"--controllers=-ttl",
+		"--controllers=-ttl-after-finished-controller",
+	}
+	controllersGot := []string{}
+	for _, arg := range kcm.args {
+		if strings.HasPrefix(arg, "--controllers=") {
+			controllersGot = append(controllersGot, arg)
+		}
+	}
+	if !reflect.DeepEqual(controllersWant, controllersGot) {
+		t.Errorf("expected controllers to match - diff: %s", cmp.Diff(controllersWant, controllersGot))
+	}
+
+	cfg.ControllerManager.Controllers = nil
+	cfg.ApiServer.Tuning.DisabledAPIs = []string{"batch/v1/cronjobs", "autoscaling/v2"}
+	kcm = NewKubeControllerManager(context.TODO(), cfg)
+	if err := kcm.ConfigurationError(); err != nil {
+		t.Fatalf("unexpected configuration error: %v", err)
+	}
+	for _, want := range []string{"--controllers=-cronjob-controller", "--controllers=-horizontal-pod-autoscaler-controller"} {
+		if !slices.Contains(kcm.args, want) {
+			t.Errorf("expected %s in %v", want, kcm.args)
+		}
+	}
+	cfg.ApiServer.Tuning.DisabledAPIs = nil
+
+	// Enabling a controller disabled by default drops its default entry,
+	// which kube-controller-manager would otherwise apply first.
+	cfg.ControllerManager.Controllers = []string{"ttl-controller", "bootstrapsigner"}
+	kcm = NewKubeControllerManager(context.TODO(), cfg)
+	if err := kcm.ConfigurationError(); err != nil {
+		t.Fatalf("unexpected configuration error: %v", err)
+	}
+	controllersWant = []string{
+		"--controllers=*",
+		"--controllers=-tokencleaner",
+		"--controllers=bootstrapsigner",
+		"--controllers=ttl-controller",
+	}
+	controllersGot = []string{}
+	for _, arg := range kcm.args {
+		if strings.HasPrefix(arg, "--controllers=") {
+			controllersGot = append(controllersGot, arg)
+		}
+	}
+	if !reflect.DeepEqual(controllersWant, controllersGot) {
+		t.Errorf("expected controllers to match - diff: %s", cmp.Diff(controllersWant, controllersGot))
+	}
+
+	cfg.ControllerManager.Controllers = []string{"-cloud-magic-controller"}
+	if err := NewKubeControllerManager(context.TODO(), cfg).ConfigurationError(); err == nil {
+		t.Errorf("expected an error for an unknown controller")
+	}
+}
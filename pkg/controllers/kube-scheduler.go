@@ -17,23 +17,25 @@ package controllers
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/openshift/library-go/pkg/crypto"
 	"github.com/openshift/microshift/pkg/config"
-	"github.com/openshift/microshift/pkg/util"
 
 	klog "k8s.io/klog/v2"
-	kubescheduler "k8s.io/kubernetes/cmd/kube-scheduler/app"
-	schedulerOptions "k8s.io/kubernetes/cmd/kube-scheduler/app/options"
+	"sigs.k8s.io/yaml"
 )
 
+const kubeSchedulerHealthzURL = "https://localhost:10259/healthz"
+
 type KubeScheduler struct {
-	options    *schedulerOptions.Options
-	kubeconfig string
+	kubeconfig   string
+	verbosity    int
+	configureErr error
 }
 
 func NewKubeScheduler(cfg *config.Config) *KubeScheduler {
@@ -42,32 +44,45 @@ func NewKubeScheduler(cfg *config.Config) *KubeScheduler {
 	return s
 }
 
-func (s *KubeScheduler) Name() string           { return "kube-scheduler" }
-func (s *KubeScheduler) Dependencies() []string { return []string{"kube-apiserver"} }
+func (s *KubeScheduler) Name() string              { return "kube-scheduler" }
+func (s *KubeScheduler) Dependencies() []string    { return []string{"kube-apiserver"} }
+func (s *KubeScheduler) ConfigurationError() error { return s.configureErr }
 
 func (s *KubeScheduler) configure(cfg *config.Config) {
 	if err := s.writeConfig(cfg); err != nil {
 		klog.Fatalf("failed to write kube-scheduler config: %v", err)
 	}
-
-	s.options = schedulerOptions.NewOptions()
-	s.options.ConfigFile = filepath.Join(config.DataDir, "/resources/kube-scheduler/config/config.yaml")
-	s.options.Authentication.RemoteKubeConfigFile = cfg.KubeConfigPath(config.KubeScheduler)
-	s.options.Authorization.RemoteKubeConfigFile = cfg.KubeConfigPath(config.KubeScheduler)
-	s.options.SecureServing.MinTLSVersion = string(fixedTLSProfile.MinTLSVersion)
-	s.options.SecureServing.CipherSuites = crypto.OpenSSLToIANACipherSuites(fixedTLSProfile.Ciphers)
+	if err := validateSchedulerConfig(s.configPath()); err != nil {
+		s.configureErr = fmt.Errorf("invalid scheduler.profiles: %w", err)
+	}
 	s.kubeconfig = cfg.KubeConfigPath(config.KubeScheduler)
+	s.verbosity = cfg.GetVerbosity()
+}
+
+func (s *KubeScheduler) configPath() string {
+	return filepath.Join(config.DataDir, "resources", "kube-scheduler", "config", "config.yaml")
 }
 
 func (s *KubeScheduler) writeConfig(cfg *config.Config) error {
-	data := []byte(`apiVersion: kubescheduler.config.k8s.io/v1
-kind: KubeSchedulerConfiguration
-clientConnection:
-  kubeconfig: ` + cfg.KubeConfigPath(config.KubeScheduler) + `
-leaderElection:
-  leaderElect: false`)
-
-	path := filepath.Join(config.DataDir, "resources", "kube-scheduler", "config", "config.yaml")
+	schedulerConfig := map[string]any{
+		"apiVersion": "kubescheduler.config.k8s.io/v1",
+		"kind":       "KubeSchedulerConfiguration",
+		"clientConnection": map[string]any{
+			"kubeconfig": cfg.KubeConfigPath(config.KubeScheduler),
+		},
+		"leaderElection": map[string]any{
+			"leaderElect": false,
+		},
+	}
+	if len(cfg.Scheduler.Profiles) > 0 {
+		schedulerConfig["profiles"] = cfg.Scheduler.Profiles
+	}
+	data, err := yaml.Marshal(schedulerConfig)
+	if err != nil {
+		return err
+	}
+
+	path := s.configPath()
 	if err := os.MkdirAll(filepath.Dir(path), os.FileMode(0700)); err != nil {
 		return fmt.Errorf("creating directory path %s: %w", path, err)
 	}
@@ -75,30 +90,24 @@ leaderElection:
 }
 
 func (s *KubeScheduler) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
-	defer close(stopped)
-	errorChannel := make(chan error, 1)
-
-	// run readiness check
-	go func() {
-		// This endpoint uses a self-signed certificate on purpose, we need to skip verification.
-		healthcheckStatus := util.RetryInsecureGet(ctx, "https://localhost:10259/healthz")
-		if healthcheckStatus != 200 {
-			klog.Errorf("%s healthcheck failed due to kube-scheduler failure to start", s.Name())
-			errorChannel <- errors.New("kube-scheduler healthcheck failed")
-		}
-
-		klog.Infof("%s is ready", s.Name())
-		close(ready)
-	}()
-
-	cc, sched, err := kubescheduler.Setup(ctx, s.options)
-	if err != nil {
-		return err
+	if s.configureErr != nil {
+		return fmt.Errorf("configuration failed: %w", s.configureErr)
 	}
+	if !embeddedComponents {
+		return newComponentProcess(s.Name(), s.processArgs(), kubeSchedulerHealthzURL).Run(ctx, ready, stopped)
+	}
+	return s.runEmbedded(ctx, ready, stopped)
+}
 
-	go func() {
-		errorChannel <- kubescheduler.Run(ctx, cc, sched)
-	}()
-
-	return <-errorChannel
+// processArgs are the flags of microshift-kube-scheduler matching the
+// options of the embedded kube-scheduler.
+func (s *KubeScheduler) processArgs() []string {
+	return []string{
+		"--config=" + s.configPath(),
+		"--authentication-kubeconfig=" + s.kubeconfig,
+		"--authorization-kubeconfig=" + s.kubeconfig,
+		"--tls-min-version=" + string(fixedTLSProfile.MinTLSVersion),
+		"--tls-cipher-suites=" + strings.Join(crypto.OpenSSLToIANACipherSuites(fixedTLSProfile.Ciphers), ","),
+		"--v=" + strconv.Itoa(s.verbosity),
+	}
 }
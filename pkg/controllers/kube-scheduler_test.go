@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/klog/v2"
+	kubescheduler "k8s.io/kubernetes/cmd/kube-scheduler/app"
+	schedulerOptions "k8s.io/kubernetes/cmd/kube-scheduler/app/options"
+
+	"github.com/openshift/microshift/pkg/config"
+)
+
+func TestKubeSchedulerProfiles(t *testing.T) {
+	dataDir := config.DataDir
+	config.DataDir = t.TempDir()
+	defer func() { config.DataDir = dataDir }()
+
+	cfg := config.NewDefault()
+	assert.NoError(t, NewKubeScheduler(cfg).ConfigurationError())
+
+	cfg.Scheduler.Profiles = []map[string]any{{
+		"schedulerName": "default-scheduler",
+		"pluginConfig": []any{
+			map[string]any{
+				"name": "NodeResourcesFit",
+				"args": map[string]any{
+					"scoringStrategy": map[string]any{"type": "MostAllocated"},
+				},
+			},
+			map[string]any{
+				"name": "VolumeBinding",
+				"args": map[string]any{"bindTimeoutSeconds": 30},
+			},
+		},
+	}}
+	assert.NoError(t, NewKubeScheduler(cfg).ConfigurationError())
+	schedulerConfig, err := schedulerOptions.LoadConfigFromFile(klog.Background(), filepath.Join(config.DataDir, "resources", "kube-scheduler", "config", "config.yaml"))
+	assert.NoError(t, err)
+	assert.Len(t, schedulerConfig.Profiles, 1)
+
+	cfg.Scheduler.Profiles = []map[string]any{{
+		"schedulerName": "default-scheduler",
+		"pluginConfig": []any{
+			map[string]any{
+				"name": "VolumeBinding",
+				"args": map[string]any{"bindTimeoutSeconds": -1},
+			},
+		},
+	}}
+	assert.ErrorContains(t, NewKubeScheduler(cfg).ConfigurationError(), "bindTimeoutSeconds")
+}
+
+func TestKubeSchedulerProcessArgs(t *testing.T) {
+	dataDir := config.DataDir
+	config.DataDir = t.TempDir()
+	defer func() { config.DataDir = dataDir }()
+
+	s := NewKubeScheduler(config.NewDefault())
+	assert.NoError(t, s.ConfigurationError())
+	// microshift-kube-scheduler knows all of the flags.
+	assert.NoError(t, kubescheduler.NewSchedulerCommand().ParseFlags(s.processArgs()))
+	assert.Contains(t, s.processArgs(), "--config="+s.configPath())
+}
@@ -17,27 +17,37 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/openshift/microshift/pkg/assets"
 	"github.com/openshift/microshift/pkg/config"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/klog/v2"
 )
 
 type OpenShiftCRDManager struct {
-	cfg *config.Config
+	cfg      *config.Config
+	userCRDs []*apiextv1.CustomResourceDefinition
+
+	configErr error
 }
 
 func NewOpenShiftCRDManager(cfg *config.Config) *OpenShiftCRDManager {
 	s := &OpenShiftCRDManager{}
 	s.cfg = cfg
+	s.userCRDs, s.configErr = assets.LoadUserCRDs(config.CRDDir)
 	return s
 }
 
-func (s *OpenShiftCRDManager) Name() string           { return "openshift-crd-manager" }
-func (s *OpenShiftCRDManager) Dependencies() []string { return []string{"kube-apiserver"} }
+func (s *OpenShiftCRDManager) Name() string              { return "openshift-crd-manager" }
+func (s *OpenShiftCRDManager) Dependencies() []string    { return []string{"kube-apiserver"} }
+func (s *OpenShiftCRDManager) ConfigurationError() error { return s.configErr }
 
 func (s *OpenShiftCRDManager) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
 	defer close(stopped)
+	if s.configErr != nil {
+		return fmt.Errorf("configuration failed: %w", s.configErr)
+	}
 
 	if err := assets.ApplyCRDs(ctx, s.cfg); err != nil {
 		klog.Errorf("%s unable to apply default CRDs: %v", s.Name(), err)
@@ -50,6 +60,10 @@ func (s *OpenShiftCRDManager) Run(ctx context.Context, ready chan<- struct{}, st
 		klog.Errorf("%s unable to confirm all CRDs are ready: %v", s.Name(), err)
 		return ctx.Err()
 	}
+	if err := assets.ApplyUserCRDs(ctx, s.cfg, s.userCRDs); err != nil {
+		klog.Errorf("%s unable to apply user CRDs from %s: %v", s.Name(), config.CRDDir, err)
+		return err
+	}
 	klog.Infof("%s all CRDs are ready", s.Name())
 	close(ready)
 
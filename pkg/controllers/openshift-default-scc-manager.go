@@ -17,19 +17,39 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/openshift/microshift/pkg/assets"
 	"github.com/openshift/microshift/pkg/config"
 	"k8s.io/klog/v2"
 )
 
+// defaultSCCNames are the SCCs applied by MicroShift, which the SCCs of the
+// user cannot replace.
+var defaultSCCNames = []string{
+	"anyuid",
+	"hostaccess",
+	"hostmount-anyuid",
+	"hostnetwork",
+	"hostnetwork-v2",
+	"nonroot",
+	"nonroot-v2",
+	"privileged",
+	"restricted",
+	"restricted-v2",
+}
+
 type OpenShiftDefaultSCCManager struct {
-	cfg *config.Config
+	cfg      *config.Config
+	userSCCs *assets.UserSCCs
+
+	configErr error
 }
 
 func NewOpenShiftDefaultSCCManager(cfg *config.Config) *OpenShiftDefaultSCCManager {
 	s := &OpenShiftDefaultSCCManager{}
 	s.cfg = cfg
+	s.userSCCs, s.configErr = assets.LoadUserSCCs(config.SCCDir, defaultSCCNames)
 	return s
 }
 
@@ -39,16 +59,30 @@ func (s *OpenShiftDefaultSCCManager) Name() string {
 func (s *OpenShiftDefaultSCCManager) Dependencies() []string {
 	return []string{"kube-apiserver", "openshift-crd-manager"}
 }
+func (s *OpenShiftDefaultSCCManager) ConfigurationError() error { return s.configErr }
 
 func (s *OpenShiftDefaultSCCManager) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
 	defer close(stopped)
 	defer close(ready)
+	if s.configErr != nil {
+		return fmt.Errorf("configuration failed: %w", s.configErr)
+	}
 	// TO-DO add readiness check
 	if err := ApplyDefaultSCCs(ctx, s.cfg); err != nil {
 		klog.Errorf("%s unable to apply default SCCs: %v", s.Name(), err)
 		return err
 	}
 	klog.Infof("%s applied default SCCs", s.Name())
+
+	// The user SCCs are applied by MicroShift rather than as manifests, as
+	// the SCC CRD might not exist yet when the manifests are applied.
+	if err := s.userSCCs.Apply(ctx, s.cfg.KubeConfigPath(config.KubeAdmin)); err != nil {
+		klog.Errorf("%s unable to apply user SCCs from %s: %v", s.Name(), config.SCCDir, err)
+		return err
+	}
+	if len(s.userSCCs.SCCs) != 0 {
+		klog.Infof("%s applied %d user SCCs", s.Name(), len(s.userSCCs.SCCs))
+	}
 	return ctx.Err()
 }
 
@@ -18,6 +18,7 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"net"
 
 	corev1 "k8s.io/api/core/v1"
 	unstructuredv1 "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -60,6 +61,7 @@ func (s *OCPRouteControllerManager) Name() string { return componentRCM }
 func (s *OCPRouteControllerManager) Dependencies() []string {
 	return []string{"kube-apiserver", "openshift-crd-manager"}
 }
+func (s *OCPRouteControllerManager) ConfigurationError() error { return s.configErr }
 
 func (s *OCPRouteControllerManager) configure(cfg *config.Config) error {
 	s.kubeconfig = cfg.KubeConfigPath(config.RouteControllerManager)
@@ -69,7 +71,7 @@ func (s *OCPRouteControllerManager) configure(cfg *config.Config) error {
 	rcmConfig := &openshiftcontrolplanev1.OpenShiftControllerManagerConfig{
 		ServingInfo: &configv1.HTTPServingInfo{
 			ServingInfo: configv1.ServingInfo{
-				BindAddress: "0.0.0.0:8445",
+				BindAddress: net.JoinHostPort(cfg.UnspecifiedAddress(), "8445"),
 				BindNetwork: "tcp",
 				CertInfo: configv1.CertInfo{
 					CertFile: cryptomaterial.ServingCertPath(servingCertDir),
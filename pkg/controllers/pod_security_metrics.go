@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+	psapi "k8s.io/pod-security-admission/api"
+)
+
+const podSecurityReportInterval = time.Minute
+
+var (
+	podSecurityNamespaces = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Name:           "microshift_pod_security_namespaces",
+			Help:           "Number of namespaces by pod security admission mode (enforce, audit or warn) and level, as labeled by the cluster policy controller or the user.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"mode", "level"},
+	)
+	podSecurityLogOnly = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Name:           "microshift_pod_security_log_only",
+			Help:           "Whether the pod security admission only logs (1) or enforces (0) the violations of the pod security levels. The SCC admission is always enforced.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+)
+
+func init() {
+	legacyregistry.MustRegister(podSecurityNamespaces, podSecurityLogOnly)
+}
+
+// podSecurityModeLabels are the namespace labels of the pod security
+// admission modes.
+var podSecurityModeLabels = map[string]string{
+	"enforce": psapi.EnforceLevelLabel,
+	"audit":   psapi.AuditLevelLabel,
+	"warn":    psapi.WarnLevelLabel,
+}
+
+type podSecurityKey struct {
+	mode  string
+	level string
+}
+
+// countPodSecurityLevels counts the namespaces by pod security admission
+// mode and level. Namespaces without the label of a mode are counted with an
+// empty level, as the defaults of the admission apply to them.
+func countPodSecurityLevels(namespaces []corev1.Namespace) map[podSecurityKey]int {
+	counts := map[podSecurityKey]int{}
+	for _, ns := range namespaces {
+		for mode, label := range podSecurityModeLabels {
+			counts[podSecurityKey{mode: mode, level: ns.Labels[label]}]++
+		}
+	}
+	return counts
+}
+
+// reportPodSecurityLevels periodically updates the pod security metrics
+// until ctx is done.
+func reportPodSecurityLevels(ctx context.Context, client kubernetes.Interface, logOnly bool) {
+	if logOnly {
+		podSecurityLogOnly.Set(1)
+	} else {
+		podSecurityLogOnly.Set(0)
+	}
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		namespaces, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			klog.V(2).Infof("Failed to list namespaces for pod security metrics: %v", err)
+			return
+		}
+		podSecurityNamespaces.Reset()
+		for key, n := range countPodSecurityLevels(namespaces.Items) {
+			podSecurityNamespaces.WithLabelValues(key.mode, key.level).Set(float64(n))
+		}
+	}, podSecurityReportInterval)
+}
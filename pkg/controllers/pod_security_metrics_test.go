@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCountPodSecurityLevels(t *testing.T) {
+	namespaces := []corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "app", Labels: map[string]string{
+			"pod-security.kubernetes.io/enforce": "restricted",
+			"pod-security.kubernetes.io/audit":   "restricted",
+			"pod-security.kubernetes.io/warn":    "restricted",
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "legacy", Labels: map[string]string{
+			"pod-security.kubernetes.io/audit": "baseline",
+			"pod-security.kubernetes.io/warn":  "baseline",
+		}}},
+	}
+
+	assert.Equal(t, map[podSecurityKey]int{
+		{mode: "enforce", level: ""}:           2,
+		{mode: "enforce", level: "restricted"}: 1,
+		{mode: "audit", level: ""}:             1,
+		{mode: "audit", level: "restricted"}:   1,
+		{mode: "audit", level: "baseline"}:     1,
+		{mode: "warn", level: ""}:              1,
+		{mode: "warn", level: "restricted"}:    1,
+		{mode: "warn", level: "baseline"}:      1,
+	}, countPodSecurityLevels(namespaces))
+}
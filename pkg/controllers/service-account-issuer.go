@@ -0,0 +1,196 @@
+/*
+Copyright © 2024 MicroShift Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/microshift/pkg/config"
+)
+
+const (
+	serviceAccountIssuerPublishInterval = time.Minute
+	serviceAccountIssuerUploadTimeout   = 30 * time.Second
+)
+
+// serviceAccountIssuerDocuments are the paths of the OIDC discovery
+// documents served by kube-apiserver. They are published at the same paths.
+var serviceAccountIssuerDocuments = []string{
+	".well-known/openid-configuration",
+	"openid/v1/jwks",
+}
+
+// ServiceAccountIssuerPublisher publishes the OIDC discovery documents of the
+// service account token issuer, so external systems can verify the tokens
+// without reaching kube-apiserver. The documents are published again when
+// they change, e.g. after the signing key was regenerated.
+type ServiceAccountIssuerPublisher struct {
+	kubeconfig string
+	publish    config.ServiceAccountPublish
+}
+
+func NewServiceAccountIssuerPublisher(cfg *config.Config) *ServiceAccountIssuerPublisher {
+	return &ServiceAccountIssuerPublisher{
+		kubeconfig: cfg.KubeConfigPath(config.KubeAdmin),
+		publish:    cfg.ApiServer.ServiceAccount.Publish,
+	}
+}
+
+func (s *ServiceAccountIssuerPublisher) Name() string           { return "service-account-issuer-publisher" }
+func (s *ServiceAccountIssuerPublisher) Dependencies() []string { return []string{"kube-apiserver"} }
+
+func (s *ServiceAccountIssuerPublisher) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	defer close(stopped)
+	// Publishing runs in the background and does not delay the readiness of
+	// MicroShift.
+	close(ready)
+
+	if !s.publish.IsEnabled() {
+		return nil
+	}
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", s.kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to build kubeconfig admin path: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(rest.AddUserAgent(restConfig, s.Name()))
+	if err != nil {
+		return err
+	}
+
+	var published map[string][]byte
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		documents := make(map[string][]byte, len(serviceAccountIssuerDocuments))
+		for _, path := range serviceAccountIssuerDocuments {
+			data, err := client.CoreV1().RESTClient().Get().AbsPath(path).DoRaw(ctx)
+			if err != nil {
+				klog.ErrorS(err, "Failed to get service account issuer document", "path", path)
+				return
+			}
+			documents[path] = data
+		}
+		if reflect.DeepEqual(documents, published) {
+			return
+		}
+		if err := publishServiceAccountIssuerDocuments(ctx, s.publish, documents); err != nil {
+			klog.ErrorS(err, "Failed to publish service account issuer documents")
+			return
+		}
+		published = documents
+		klog.Infof("Published service account issuer documents to %v", strings.TrimSpace(s.publish.Directory+" "+s.publish.URL))
+	}, serviceAccountIssuerPublishInterval)
+	return nil
+}
+
+func publishServiceAccountIssuerDocuments(ctx context.Context, publish config.ServiceAccountPublish, documents map[string][]byte) error {
+	if publish.Directory != "" {
+		for path, data := range documents {
+			if err := writeServiceAccountIssuerDocument(filepath.Join(publish.Directory, path), data); err != nil {
+				return err
+			}
+		}
+	}
+	if publish.URL != "" {
+		client, err := serviceAccountIssuerUploadClient(publish)
+		if err != nil {
+			return err
+		}
+		for path, data := range documents {
+			if err := uploadServiceAccountIssuerDocument(ctx, client, publish, path, data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeServiceAccountIssuerDocument replaces the document atomically so a
+// web server serving the directory never returns a partial document.
+func writeServiceAccountIssuerDocument(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to rename %q to %q: %w", tmpPath, path, err)
+	}
+	return nil
+}
+
+func serviceAccountIssuerUploadClient(publish config.ServiceAccountPublish) (*http.Client, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if publish.CAFile != "" {
+		ca, err := os.ReadFile(publish.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate found in %q", publish.CAFile)
+		}
+	}
+	return &http.Client{
+		Timeout:   serviceAccountIssuerUploadTimeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+	}, nil
+}
+
+func uploadServiceAccountIssuerDocument(ctx context.Context, client *http.Client, publish config.ServiceAccountPublish, path string, data []byte) error {
+	url := strings.TrimSuffix(publish.URL, "/") + "/" + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if publish.BearerTokenFile != "" {
+		token, err := os.ReadFile(publish.BearerTokenFile)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload %q: %w", url, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to upload %q: received http status %d", url, resp.StatusCode)
+	}
+	return nil
+}
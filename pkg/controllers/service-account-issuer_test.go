@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/microshift/pkg/config"
+)
+
+func TestPublishServiceAccountIssuerDocuments(t *testing.T) {
+	documents := map[string][]byte{
+		".well-known/openid-configuration": []byte(`{"issuer":"https://oidc.example.com"}`),
+		"openid/v1/jwks":                   []byte(`{"keys":[]}`),
+	}
+
+	var mu sync.Mutex
+	uploaded := map[string][]byte{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		uploaded[r.URL.Path] = data
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.crt")
+	assert.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600))
+	tokenFile := filepath.Join(dir, "token")
+	assert.NoError(t, os.WriteFile(tokenFile, []byte("secret\n"), 0600))
+
+	publish := config.ServiceAccountPublish{
+		Directory:       filepath.Join(dir, "www"),
+		URL:             server.URL + "/edge-1/",
+		CAFile:          caFile,
+		BearerTokenFile: tokenFile,
+	}
+	assert.NoError(t, publishServiceAccountIssuerDocuments(context.Background(), publish, documents))
+
+	for path, data := range documents {
+		written, err := os.ReadFile(filepath.Join(publish.Directory, path))
+		assert.NoError(t, err)
+		assert.Equal(t, data, written)
+		assert.Equal(t, data, uploaded["/edge-1/"+path])
+	}
+
+	publish.BearerTokenFile = ""
+	assert.ErrorContains(t, publishServiceAccountIssuerDocuments(context.Background(), publish, documents), "403")
+	publish.CAFile = ""
+	assert.Error(t, publishServiceAccountIssuerDocuments(context.Background(), publish, documents))
+}
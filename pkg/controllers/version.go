@@ -21,6 +21,7 @@ import (
 	"github.com/openshift/microshift/pkg/assets"
 	"github.com/openshift/microshift/pkg/config"
 	"github.com/openshift/microshift/pkg/version"
+	etcdversion "go.etcd.io/etcd/api/v3/version"
 	"k8s.io/klog/v2"
 )
 
@@ -39,6 +40,10 @@ func (s *VersionManager) Dependencies() []string {
 	return []string{"kube-apiserver"}
 }
 
+// Deferred publishes the version once MicroShift is ready, nothing waits for
+// the config map during the boot.
+func (s *VersionManager) Deferred() bool { return true }
+
 func (s *VersionManager) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
 	var cm = "version/microshift-version.yaml"
 
@@ -51,6 +56,7 @@ func (s *VersionManager) Run(ctx context.Context, ready chan<- struct{}, stopped
 		"minor":   versionInfo.Minor,
 		"patch":   versionInfo.Patch,
 		"version": versionInfo.String(),
+		"etcd":    etcdversion.APIVersion,
 	}
 
 	kubeConfigPath := s.cfg.KubeConfigPath(config.KubeAdmin)
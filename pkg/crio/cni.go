@@ -0,0 +1,120 @@
+package crio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+
+	"github.com/openshift/microshift/pkg/config"
+)
+
+var (
+	// bridgeNetworkFile is the CNI configuration of the bridge CNI. CRI-O
+	// uses the first configuration of the directory, and watches it for
+	// changes.
+	bridgeNetworkFile = "/etc/cni/net.d/05-microshift-bridge.conflist"
+	// multusDropInFile makes Multus the default network of CRI-O, which
+	// CRI-O only reads when starting.
+	multusDropInFile = "/etc/crio/crio.conf.d/92-microshift-multus.conf"
+)
+
+// ReconcileCNIConfig writes the CNI configuration of the bridge CNI when it
+// is the CNI plugin of the cluster, and removes it otherwise. It also makes
+// Multus the default network of CRI-O when Multus is managed by MicroShift,
+// restarting CRI-O when it changed.
+func ReconcileCNIConfig(ctx context.Context, cfg *config.Config) error {
+	var content []byte
+	if cfg.Network.CNIPlugin == config.CniPluginBridge {
+		var err error
+		if content, err = renderBridgeConfig(cfg.Network.ClusterNetwork); err != nil {
+			return err
+		}
+	}
+	if _, err := syncFile(bridgeNetworkFile, content); err != nil {
+		return fmt.Errorf("failed to update the CNI configuration: %w", err)
+	}
+
+	content = nil
+	if cfg.Network.Multus.Status == config.MultusStatusManaged {
+		content = []byte(header + multusConfig)
+	}
+	restart, err := syncFile(multusDropInFile, content)
+	if err != nil {
+		return fmt.Errorf("failed to update the CRI-O configuration: %w", err)
+	}
+	if restart {
+		return applyConfig(ctx, "restart")
+	}
+	return nil
+}
+
+// multusConfig makes CRI-O wait for and call Multus, and prefer the CNI
+// plugins Multus copies to /run/cni/bin over the ones of the host.
+const multusConfig = `[crio.network]
+cni_default_network = "multus-cni-network"
+plugin_dirs = [
+    "/run/cni/bin",
+    "/usr/libexec/cni",
+]
+`
+
+// DefaultNetworkFile returns the CNI configuration of the default network
+// of the CNI plugin, which Multus waits for and delegates to.
+func DefaultNetworkFile(plugin config.CNIPlugin) string {
+	switch plugin {
+	case config.CniPluginFlannel:
+		return "/etc/cni/net.d/10-flannel.conflist"
+	case config.CniPluginBridge:
+		return bridgeNetworkFile
+	default:
+		return "/etc/cni/net.d/10-ovn-kubernetes.conf"
+	}
+}
+
+// renderBridgeConfig renders a network connecting the pods to a bridge of
+// the host, which routes and masquerades their traffic, with their addresses
+// allocated from the cluster networks.
+func renderBridgeConfig(clusterNetwork []string) ([]byte, error) {
+	ranges := [][]map[string]string{}
+	routes := []map[string]string{}
+	for _, network := range clusterNetwork {
+		ip, _, err := net.ParseCIDR(network)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cluster network %q: %w", network, err)
+		}
+		ranges = append(ranges, []map[string]string{{"subnet": network}})
+		if ip.To4() != nil {
+			routes = append(routes, map[string]string{"dst": "0.0.0.0/0"})
+		} else {
+			routes = append(routes, map[string]string{"dst": "::/0"})
+		}
+	}
+	conflist := map[string]any{
+		"cniVersion": "0.4.0",
+		"name":       "microshift-bridge",
+		"plugins": []map[string]any{
+			{
+				"type":        "bridge",
+				"bridge":      "cni0",
+				"isGateway":   true,
+				"ipMasq":      true,
+				"hairpinMode": true,
+				"ipam": map[string]any{
+					"type":   "host-local",
+					"ranges": ranges,
+					"routes": routes,
+				},
+			},
+			{
+				"type":         "portmap",
+				"capabilities": map[string]bool{"portMappings": true},
+			},
+		},
+	}
+	data, err := json.MarshalIndent(conflist, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,72 @@
+package crio
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderBridgeConfig(t *testing.T) {
+	got, err := renderBridgeConfig([]string{"10.42.0.0/16", "fd01::/48"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"cniVersion": "0.4.0",
+		"name": "microshift-bridge",
+		"plugins": [
+			{
+				"type": "bridge",
+				"bridge": "cni0",
+				"isGateway": true,
+				"ipMasq": true,
+				"hairpinMode": true,
+				"ipam": {
+					"type": "host-local",
+					"ranges": [[{"subnet": "10.42.0.0/16"}], [{"subnet": "fd01::/48"}]],
+					"routes": [{"dst": "0.0.0.0/0"}, {"dst": "::/0"}]
+				}
+			},
+			{"type": "portmap", "capabilities": {"portMappings": true}}
+		]
+	}`, string(got))
+
+	_, err = renderBridgeConfig([]string{"10.42.0.0"})
+	assert.Error(t, err)
+}
+
+func TestReconcileCNIConfig(t *testing.T) {
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	fakeDropInFiles(t, dir)
+	bridge, multus := bridgeNetworkFile, multusDropInFile
+	t.Cleanup(func() { bridgeNetworkFile, multusDropInFile = bridge, multus })
+	bridgeNetworkFile = filepath.Join(dir, "net.d", "05-microshift-bridge.conflist")
+	multusDropInFile = filepath.Join(dir, "crio.conf.d", "92-microshift-multus.conf")
+
+	reconcile := func(cfg *config.Config) string {
+		require.NoError(t, os.RemoveAll(calls))
+		require.NoError(t, ReconcileCNIConfig(context.TODO(), cfg))
+		out, _ := os.ReadFile(calls)
+		return string(out)
+	}
+
+	cfg := config.NewDefault()
+	cfg.Network.CNIPlugin = config.CniPluginBridge
+	assert.Empty(t, reconcile(cfg))
+	assert.FileExists(t, bridgeNetworkFile)
+	assert.Equal(t, bridgeNetworkFile, DefaultNetworkFile(cfg.Network.CNIPlugin))
+
+	cfg.Network.Multus.Status = config.MultusStatusManaged
+	assert.Equal(t, "is-active --quiet crio.service\nrestart crio.service\n", reconcile(cfg))
+	assert.FileExists(t, multusDropInFile)
+	assert.Empty(t, reconcile(cfg))
+
+	cfg = config.NewDefault()
+	assert.Equal(t, "is-active --quiet crio.service\nrestart crio.service\n", reconcile(cfg))
+	assert.NoFileExists(t, bridgeNetworkFile)
+	assert.NoFileExists(t, multusDropInFile)
+}
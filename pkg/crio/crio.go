@@ -0,0 +1,181 @@
+package crio
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// Endpoint is the CRI socket of CRI-O.
+	Endpoint          = "unix:///var/run/crio/crio.sock"
+	ConnectionTimeout = 10 * time.Second
+
+	// header starts the files rendered from the MicroShift configuration.
+	header = "# Generated by MicroShift from /etc/microshift/config.yaml, do not edit.\n"
+)
+
+var (
+	// imagesDropInFile overrides the pull secret of the CRI-O configuration
+	// installed with MicroShift, which CRI-O only reads when starting.
+	imagesDropInFile = "/etc/crio/crio.conf.d/90-microshift-images.conf"
+	// registriesDropInFile configures the registry mirrors and the local
+	// registry, which CRI-O reads again when reloaded.
+	registriesDropInFile = "/etc/containers/registries.conf.d/90-microshift-registries.conf"
+	// pinnedImagesDropInFile pins the images protected from the garbage
+	// collection of the kubelet, which CRI-O reads again when reloaded.
+	pinnedImagesDropInFile = "/etc/crio/crio.conf.d/91-microshift-pinned-images.conf"
+
+	systemctlCommand = "systemctl"
+)
+
+// ReconcileImagesConfig renders the images configuration into the drop-in
+// configurations of CRI-O, and restarts or reloads CRI-O when they changed
+// so that the next pulls use them.
+func ReconcileImagesConfig(ctx context.Context, cfg *config.Config) error {
+	restart, err := syncFile(imagesDropInFile, renderImagesConfig(cfg.Images))
+	if err != nil {
+		return fmt.Errorf("failed to update the CRI-O configuration: %w", err)
+	}
+	reload, err := syncFile(registriesDropInFile, renderRegistriesConfig(cfg.Images))
+	if err != nil {
+		return fmt.Errorf("failed to update the registries configuration: %w", err)
+	}
+
+	// The protected images found by their labels are pinned by the image
+	// protector.
+	if len(cfg.Images.GarbageCollection.ProtectedLabels) == 0 {
+		changed, err := syncFile(pinnedImagesDropInFile, renderPinnedImagesConfig(cfg.Images.GarbageCollection.ProtectedImages))
+		if err != nil {
+			return fmt.Errorf("failed to update the pinned images: %w", err)
+		}
+		reload = reload || changed
+	}
+
+	switch {
+	case restart:
+		return applyConfig(ctx, "restart")
+	case reload:
+		return applyConfig(ctx, "reload")
+	}
+	return nil
+}
+
+// applyConfig restarts or reloads CRI-O, depending on action, so that it
+// uses the changed drop-in configurations.
+func applyConfig(ctx context.Context, action string) error {
+	// CRI-O applies its configuration when started, there is nothing to
+	// do before.
+	if err := exec.CommandContext(ctx, systemctlCommand, "is-active", "--quiet", "crio.service").Run(); err != nil {
+		return nil
+	}
+	klog.Infof("CRI-O configuration changed, running systemctl %s crio.service", action)
+	if out, err := exec.CommandContext(ctx, systemctlCommand, action, "crio.service").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to %s CRI-O: %w: %s", action, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// renderImagesConfig returns the CRI-O configuration of the pull secret, or
+// nil when the installed configuration already uses it.
+func renderImagesConfig(images config.Images) []byte {
+	if images.PullSecretFile == config.DefaultPullSecretFile {
+		return nil
+	}
+	b := &bytes.Buffer{}
+	b.WriteString(header)
+	b.WriteString("[crio.image]\n")
+	fmt.Fprintf(b, "global_auth_file = %s\n", strconv.Quote(images.PullSecretFile))
+	fmt.Fprintf(b, "pause_image_auth_file = %s\n", strconv.Quote(images.PullSecretFile))
+	return b.Bytes()
+}
+
+// renderPinnedImagesConfig returns the CRI-O configuration pinning the
+// images, or nil when there is none.
+func renderPinnedImagesConfig(refs []string) []byte {
+	if len(refs) == 0 {
+		return nil
+	}
+	quoted := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		quoted = append(quoted, strconv.Quote(ref))
+	}
+	b := &bytes.Buffer{}
+	b.WriteString(header)
+	b.WriteString("[crio.image]\n")
+	fmt.Fprintf(b, "pinned_images = [%s]\n", strings.Join(quoted, ", "))
+	return b.Bytes()
+}
+
+// renderRegistriesConfig returns the registries configuration of the
+// mirrors and of the local registry, or nil when there is none.
+func renderRegistriesConfig(images config.Images) []byte {
+	localRegistry := images.LocalRegistry.Status == config.LocalRegistryStatusEnabled
+	if len(images.Mirrors) == 0 && !localRegistry {
+		return nil
+	}
+	b := &bytes.Buffer{}
+	b.WriteString(header)
+	if localRegistry {
+		// The local registry is only served to the host, over HTTP.
+		b.WriteString("\n[[registry]]\n")
+		fmt.Fprintf(b, "location = %s\n", strconv.Quote(net.JoinHostPort("localhost", strconv.Itoa(images.LocalRegistry.Port))))
+		b.WriteString("insecure = true\n")
+	}
+	for _, m := range images.Mirrors {
+		b.WriteString("\n[[registry]]\n")
+		fmt.Fprintf(b, "prefix = %s\n", strconv.Quote(m.Source))
+		if !strings.HasPrefix(m.Source, "*.") {
+			fmt.Fprintf(b, "location = %s\n", strconv.Quote(m.Source))
+		}
+		fmt.Fprintf(b, "mirror-by-digest-only = %t\n", !m.MirrorTags)
+		if m.BlockSource {
+			b.WriteString("blocked = true\n")
+		}
+		for _, mirror := range m.Mirrors {
+			b.WriteString("\n[[registry.mirror]]\n")
+			fmt.Fprintf(b, "location = %s\n", strconv.Quote(mirror))
+		}
+	}
+	return b.Bytes()
+}
+
+// syncFile writes content to path, or removes path when content is nil, and
+// returns whether the file changed.
+func syncFile(path string, content []byte) (bool, error) {
+	current, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+	exists := err == nil
+	if content == nil {
+		if !exists {
+			return false, nil
+		}
+		klog.Infof("Removing %s", path)
+		return true, os.Remove(path)
+	}
+	if exists && bytes.Equal(current, content) {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return false, err
+	}
+	klog.Infof("Writing %s", path)
+	return true, os.Rename(tmp, path)
+}
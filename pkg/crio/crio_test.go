@@ -0,0 +1,102 @@
+package crio
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderRegistriesConfig(t *testing.T) {
+	images := config.NewDefault().Images
+	assert.Nil(t, renderRegistriesConfig(images))
+
+	images.Mirrors = []config.ImageMirror{
+		{Source: "quay.io/openshift-release-dev", Mirrors: []string{"mirror.example.com:5000/ocp", "backup.example.com/ocp"}},
+		{Source: "*.example.org", Mirrors: []string{"mirror.example.com:5000"}, MirrorTags: true, BlockSource: true},
+	}
+	images.LocalRegistry.Status = config.LocalRegistryStatusEnabled
+	got := renderRegistriesConfig(images)
+	assert.Equal(t, header+`
+[[registry]]
+location = "localhost:5000"
+insecure = true
+
+[[registry]]
+prefix = "quay.io/openshift-release-dev"
+location = "quay.io/openshift-release-dev"
+mirror-by-digest-only = true
+
+[[registry.mirror]]
+location = "mirror.example.com:5000/ocp"
+
+[[registry.mirror]]
+location = "backup.example.com/ocp"
+
+[[registry]]
+prefix = "*.example.org"
+mirror-by-digest-only = false
+blocked = true
+
+[[registry.mirror]]
+location = "mirror.example.com:5000"
+`, string(got))
+}
+
+// fakeDropInFiles writes the drop-in configurations in dir, and the calls
+// to systemctl to dir/calls.
+func fakeDropInFiles(t *testing.T, dir string) {
+	bin := filepath.Join(dir, "systemctl")
+	require.NoError(t, os.WriteFile(bin, []byte("#!/bin/sh\necho \"$@\" >> "+filepath.Join(dir, "calls")+"\n"), 0700))
+	images, mirrors, pinned, systemctl := imagesDropInFile, registriesDropInFile, pinnedImagesDropInFile, systemctlCommand
+	t.Cleanup(func() {
+		imagesDropInFile, registriesDropInFile, pinnedImagesDropInFile, systemctlCommand = images, mirrors, pinned, systemctl
+	})
+	imagesDropInFile = filepath.Join(dir, "crio.conf.d", "90-microshift-images.conf")
+	registriesDropInFile = filepath.Join(dir, "registries.conf.d", "90-microshift-registries.conf")
+	pinnedImagesDropInFile = filepath.Join(dir, "crio.conf.d", "91-microshift-pinned-images.conf")
+	systemctlCommand = bin
+}
+
+func TestReconcileImagesConfig(t *testing.T) {
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	fakeDropInFiles(t, dir)
+
+	reconcile := func(cfg *config.Config) string {
+		require.NoError(t, os.RemoveAll(calls))
+		require.NoError(t, ReconcileImagesConfig(context.TODO(), cfg))
+		out, _ := os.ReadFile(calls)
+		return string(out)
+	}
+
+	cfg := config.NewDefault()
+	assert.Empty(t, reconcile(cfg))
+	assert.NoFileExists(t, imagesDropInFile)
+	assert.NoFileExists(t, registriesDropInFile)
+
+	cfg.Images.Mirrors = []config.ImageMirror{{Source: "quay.io", Mirrors: []string{"mirror.example.com"}}}
+	assert.Equal(t, "is-active --quiet crio.service\nreload crio.service\n", reconcile(cfg))
+	assert.FileExists(t, registriesDropInFile)
+	assert.Empty(t, reconcile(cfg))
+
+	cfg.Images.PullSecretFile = "/etc/microshift/pull-secret.json"
+	assert.Equal(t, "is-active --quiet crio.service\nrestart crio.service\n", reconcile(cfg))
+	assert.FileExists(t, imagesDropInFile)
+
+	cfg.Images.GarbageCollection.ProtectedImages = []string{"registry.example.com/app:1.0", "quay.io/example/*"}
+	assert.Equal(t, "is-active --quiet crio.service\nreload crio.service\n", reconcile(cfg))
+	pinned, err := os.ReadFile(pinnedImagesDropInFile)
+	require.NoError(t, err)
+	assert.Equal(t, header+"[crio.image]\npinned_images = [\"registry.example.com/app:1.0\", \"quay.io/example/*\"]\n", string(pinned))
+
+	cfg = config.NewDefault()
+	assert.Equal(t, "is-active --quiet crio.service\nrestart crio.service\n", reconcile(cfg))
+	assert.NoFileExists(t, pinnedImagesDropInFile)
+	assert.NoFileExists(t, imagesDropInFile)
+	assert.NoFileExists(t, registriesDropInFile)
+}
@@ -0,0 +1,286 @@
+package crio
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+	remote "k8s.io/cri-client/pkg"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// ociRefNameAnnotation names the images of an OCI layout.
+	ociRefNameAnnotation = "org.opencontainers.image.ref.name"
+	ociImageIndexType    = "application/vnd.oci.image.index.v1+json"
+	dockerManifestList   = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// skopeoCommand copies the preloaded images to the storage of CRI-O.
+var skopeoCommand = "skopeo"
+
+// preloadImage is an image of the preload directory and where it is copied
+// from.
+type preloadImage struct {
+	// Ref is the name of the image in the storage of CRI-O.
+	Ref string
+	// Source is the skopeo reference of the image in the preload directory.
+	Source string
+	// ID is the digest of the configuration of the image, which identifies
+	// it in the storage of CRI-O. It is empty when not known, e.g. for
+	// multi-architecture images.
+	ID string
+}
+
+type ImagePreloader struct {
+	dir string
+}
+
+func NewImagePreloader(cfg *config.Config) *ImagePreloader {
+	return &ImagePreloader{dir: cfg.Images.Preload}
+}
+
+func (s *ImagePreloader) Name() string           { return "image-preloader" }
+func (s *ImagePreloader) Dependencies() []string { return []string{} }
+
+// Run copies the images of the preload directory missing from the storage of
+// CRI-O, so that the workloads start without pulling them. The images which
+// fail to be copied are pulled as usual.
+func (s *ImagePreloader) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	defer close(stopped)
+	defer close(ready)
+
+	start := time.Now()
+	images, err := listPreloadImages(s.dir)
+	if err != nil {
+		klog.Errorf("Failed to list the images to preload from %s: %v", s.dir, err)
+		return nil
+	}
+	if len(images) == 0 {
+		return nil
+	}
+	imageService, err := remote.NewRemoteImageService(Endpoint, ConnectionTimeout, nil, nil)
+	if err != nil {
+		klog.Errorf("Failed to connect to CRI-O to preload images: %v", err)
+		return nil
+	}
+
+	loaded := 0
+	for _, image := range images {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		status, err := imageService.ImageStatus(ctx, &runtimeapi.ImageSpec{Image: image.Ref}, false)
+		if err == nil && status.Image != nil && (image.ID == "" || strings.TrimPrefix(image.ID, "sha256:") == status.Image.Id) {
+			continue
+		}
+		if err := copyImage(ctx, image); err != nil {
+			klog.Errorf("Failed to preload image %s from %s: %v", image.Ref, image.Source, err)
+			continue
+		}
+		klog.Infof("Preloaded image %s from %s", image.Ref, image.Source)
+		loaded++
+	}
+	klog.Infof("Preloaded %d of %d images from %s in %s", loaded, len(images), s.dir, time.Since(start))
+	return nil
+}
+
+func copyImage(ctx context.Context, image preloadImage) error {
+	cmd := exec.CommandContext(ctx, skopeoCommand, "copy", "--quiet", image.Source, "containers-storage:"+image.Ref)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", skopeoCommand, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// listPreloadImages returns the images of the OCI layouts, OCI archives and
+// docker archives in dir. An empty list is returned when dir does not exist.
+func listPreloadImages(dir string) ([]preloadImage, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	images := []preloadImage{}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		var found []preloadImage
+		switch {
+		case entry.IsDir():
+			found, err = listOCILayoutImages(path)
+		case strings.HasSuffix(entry.Name(), ".tar"):
+			found, err = listArchiveImages(path)
+		default:
+			continue
+		}
+		if err != nil {
+			klog.Errorf("Skipping preloaded images of %s: %v", path, err)
+			continue
+		}
+		images = append(images, found...)
+	}
+	return images, nil
+}
+
+type ociIndex struct {
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type ociManifest struct {
+	Config ociDescriptor `json:"config"`
+}
+
+type dockerArchiveManifest struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+}
+
+// listOCILayoutImages returns the images of the OCI layout in dir, named by
+// their org.opencontainers.image.ref.name annotation.
+func listOCILayoutImages(dir string) ([]preloadImage, error) {
+	return listOCIImages("oci:"+dir, func(name string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(dir, name))
+	})
+}
+
+// listArchiveImages returns the images of the OCI or docker archive at path.
+func listArchiveImages(path string) ([]preloadImage, error) {
+	files, err := readTarFiles(path, func(name string) bool { return name == "index.json" || name == "manifest.json" })
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := files["index.json"]; ok {
+		return listOCIImages("oci-archive:"+path, func(name string) ([]byte, error) {
+			if data, ok := files[name]; ok {
+				return data, nil
+			}
+			blobs, err := readTarFiles(path, func(n string) bool { return n == name })
+			if err != nil {
+				return nil, err
+			}
+			if data, ok := blobs[name]; ok {
+				return data, nil
+			}
+			return nil, fmt.Errorf("%s not found", name)
+		})
+	}
+	data, ok := files["manifest.json"]
+	if !ok {
+		return nil, fmt.Errorf("neither an OCI archive nor a docker archive")
+	}
+	manifests := []dockerArchiveManifest{}
+	if err := json.Unmarshal(data, &manifests); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest.json: %w", err)
+	}
+	images := []preloadImage{}
+	for i, m := range manifests {
+		id := "sha256:" + strings.TrimSuffix(filepath.Base(m.Config), ".json")
+		for _, tag := range m.RepoTags {
+			images = append(images, preloadImage{
+				Ref:    tag,
+				Source: "docker-archive:" + path + ":@" + strconv.Itoa(i),
+				ID:     id,
+			})
+		}
+	}
+	return images, nil
+}
+
+// listOCIImages returns the images of the index of an OCI layout, read with
+// readFile, whose ref.name annotation is a full image reference.
+func listOCIImages(source string, readFile func(name string) ([]byte, error)) ([]preloadImage, error) {
+	data, err := readFile("index.json")
+	if err != nil {
+		return nil, err
+	}
+	index := ociIndex{}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse index.json: %w", err)
+	}
+	images := []preloadImage{}
+	for _, m := range index.Manifests {
+		ref := m.Annotations[ociRefNameAnnotation]
+		// Layouts written by podman or skopeo without a name only annotate
+		// the tag, the name of the image is unknown.
+		if !strings.Contains(ref, "/") {
+			klog.Warningf("Skipping image %s of %s without a full image reference in its %s annotation", m.Digest, source, ociRefNameAnnotation)
+			continue
+		}
+		image := preloadImage{Ref: ref, Source: source + ":" + ref}
+		if m.MediaType != ociImageIndexType && m.MediaType != dockerManifestList {
+			image.ID, err = ociConfigDigest(m.Digest, readFile)
+			if err != nil {
+				return nil, err
+			}
+		}
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+func ociConfigDigest(digest string, readFile func(name string) ([]byte, error)) (string, error) {
+	algorithm, hex, found := strings.Cut(digest, ":")
+	if !found {
+		return "", fmt.Errorf("invalid manifest digest %q", digest)
+	}
+	data, err := readFile(filepath.Join("blobs", algorithm, hex))
+	if err != nil {
+		return "", err
+	}
+	manifest := ociManifest{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return "", fmt.Errorf("failed to parse manifest %s: %w", digest, err)
+	}
+	return manifest.Config.Digest, nil
+}
+
+// readTarFiles returns the content of the files of the tarball at path
+// selected by match.
+func readTarFiles(path string, match func(name string) bool) (map[string][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		} else if err != nil {
+			return nil, err
+		}
+		name := strings.TrimPrefix(hdr.Name, "./")
+		if hdr.Typeflag != tar.TypeReg || !match(name) {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[name] = data
+	}
+}
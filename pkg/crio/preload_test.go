@@ -0,0 +1,61 @@
+package crio
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTar(t *testing.T, path string, files map[string]string) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+}
+
+func TestListPreloadImages(t *testing.T) {
+	images, err := listPreloadImages(filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, images)
+
+	dir := t.TempDir()
+	const index = `{"manifests": [
+		{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:aaaa", "annotations": {"org.opencontainers.image.ref.name": "registry.example.com/app:1.0"}},
+		{"mediaType": "application/vnd.oci.image.index.v1+json", "digest": "sha256:bbbb", "annotations": {"org.opencontainers.image.ref.name": "registry.example.com/multi:1.0"}},
+		{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:cccc", "annotations": {"org.opencontainers.image.ref.name": "latest"}}
+	]}`
+	const manifest = `{"config": {"digest": "sha256:1111"}}`
+
+	layout := filepath.Join(dir, "layout")
+	require.NoError(t, os.MkdirAll(filepath.Join(layout, "blobs", "sha256"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(layout, "index.json"), []byte(index), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(layout, "blobs", "sha256", "aaaa"), []byte(manifest), 0644))
+
+	writeTar(t, filepath.Join(dir, "oci.tar"), map[string]string{"index.json": index, "blobs/sha256/aaaa": manifest})
+	writeTar(t, filepath.Join(dir, "docker.tar"), map[string]string{
+		"manifest.json": `[{"Config": "2222.json", "RepoTags": ["busybox:latest", "registry.example.com/busybox:1"]}]`,
+	})
+	writeTar(t, filepath.Join(dir, "invalid.tar"), map[string]string{"other": ""})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), nil, 0644))
+
+	images, err = listPreloadImages(dir)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []preloadImage{
+		{Ref: "busybox:latest", Source: "docker-archive:" + filepath.Join(dir, "docker.tar") + ":@0", ID: "sha256:2222"},
+		{Ref: "registry.example.com/busybox:1", Source: "docker-archive:" + filepath.Join(dir, "docker.tar") + ":@0", ID: "sha256:2222"},
+		{Ref: "registry.example.com/app:1.0", Source: "oci:" + layout + ":registry.example.com/app:1.0", ID: "sha256:1111"},
+		{Ref: "registry.example.com/multi:1.0", Source: "oci:" + layout + ":registry.example.com/multi:1.0"},
+		{Ref: "registry.example.com/app:1.0", Source: "oci-archive:" + filepath.Join(dir, "oci.tar") + ":registry.example.com/app:1.0", ID: "sha256:1111"},
+		{Ref: "registry.example.com/multi:1.0", Source: "oci-archive:" + filepath.Join(dir, "oci.tar") + ":registry.example.com/multi:1.0"},
+	}, images)
+}
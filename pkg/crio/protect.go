@@ -0,0 +1,136 @@
+package crio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	"k8s.io/apimachinery/pkg/util/wait"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+	remote "k8s.io/cri-client/pkg"
+	"k8s.io/klog/v2"
+)
+
+// protectInterval is how often the images are checked for the protected
+// labels.
+const protectInterval = time.Minute
+
+// ImageProtector pins the images with the protected labels in CRI-O, so
+// that the kubelet never removes them, together with the protected images.
+type ImageProtector struct {
+	refs   []string
+	labels map[string]string
+	// labelsByID caches the labels of the images, which do not change.
+	labelsByID map[string]map[string]string
+}
+
+func NewImageProtector(cfg *config.Config) *ImageProtector {
+	return &ImageProtector{
+		refs:       cfg.Images.GarbageCollection.ProtectedImages,
+		labels:     cfg.Images.GarbageCollection.ProtectedLabels,
+		labelsByID: map[string]map[string]string{},
+	}
+}
+
+func (s *ImageProtector) Name() string           { return "image-protector" }
+func (s *ImageProtector) Dependencies() []string { return []string{"crio"} }
+
+func (s *ImageProtector) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	defer close(stopped)
+	close(ready)
+
+	imageService, err := remote.NewRemoteImageService(Endpoint, ConnectionTimeout, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to CRI-O: %w", err)
+	}
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := s.protect(ctx, imageService); err != nil {
+			klog.Errorf("Failed to pin the protected images: %v", err)
+		}
+	}, protectInterval)
+	return ctx.Err()
+}
+
+// protect pins the protected images and the images with the protected
+// labels, reloading CRI-O when they changed.
+func (s *ImageProtector) protect(ctx context.Context, imageService remoteImageService) error {
+	images, err := imageService.ListImages(ctx, nil)
+	if err != nil {
+		return err
+	}
+	refs := slices.Clone(s.refs)
+	seen := map[string]bool{}
+	for _, image := range images {
+		seen[image.Id] = true
+		labels, ok := s.labelsByID[image.Id]
+		if !ok {
+			if labels, err = imageLabels(ctx, imageService, image.Id); err != nil {
+				klog.Warningf("Failed to read the labels of image %s: %v", image.Id, err)
+				continue
+			}
+			s.labelsByID[image.Id] = labels
+		}
+		if matchLabels(labels, s.labels) {
+			refs = append(refs, image.RepoTags...)
+		}
+	}
+	for id := range s.labelsByID {
+		if !seen[id] {
+			delete(s.labelsByID, id)
+		}
+	}
+	slices.Sort(refs)
+	refs = slices.Compact(refs)
+
+	changed, err := syncFile(pinnedImagesDropInFile, renderPinnedImagesConfig(refs))
+	if err != nil || !changed {
+		return err
+	}
+	klog.Infof("Pinning the protected images %v", refs)
+	return applyConfig(ctx, "reload")
+}
+
+// remoteImageService is the part of the CRI image service the protector
+// uses.
+type remoteImageService interface {
+	ListImages(ctx context.Context, filter *runtimeapi.ImageFilter) ([]*runtimeapi.Image, error)
+	ImageStatus(ctx context.Context, image *runtimeapi.ImageSpec, verbose bool) (*runtimeapi.ImageStatusResponse, error)
+}
+
+// imageLabels returns the labels of the configuration of an image, from the
+// verbose status of CRI-O.
+func imageLabels(ctx context.Context, imageService remoteImageService, id string) (map[string]string, error) {
+	status, err := imageService.ImageStatus(ctx, &runtimeapi.ImageSpec{Image: id}, true)
+	if err != nil {
+		return nil, err
+	}
+	info := struct {
+		Labels    map[string]string `json:"labels"`
+		ImageSpec struct {
+			Config struct {
+				Labels map[string]string `json:"Labels"`
+			} `json:"config"`
+		} `json:"imageSpec"`
+	}{}
+	if data, ok := status.Info["info"]; ok {
+		if err := json.Unmarshal([]byte(data), &info); err != nil {
+			return nil, fmt.Errorf("failed to parse the image status: %w", err)
+		}
+	}
+	if len(info.Labels) != 0 {
+		return info.Labels, nil
+	}
+	return info.ImageSpec.Config.Labels, nil
+}
+
+func matchLabels(labels, selector map[string]string) bool {
+	for key, value := range selector {
+		if v, ok := labels[key]; !ok || v != value {
+			return false
+		}
+	}
+	return len(selector) != 0
+}
@@ -0,0 +1,69 @@
+package crio
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+type fakeImageService struct {
+	images []*runtimeapi.Image
+	info   map[string]string
+}
+
+func (f *fakeImageService) ListImages(ctx context.Context, filter *runtimeapi.ImageFilter) ([]*runtimeapi.Image, error) {
+	return f.images, nil
+}
+
+func (f *fakeImageService) ImageStatus(ctx context.Context, image *runtimeapi.ImageSpec, verbose bool) (*runtimeapi.ImageStatusResponse, error) {
+	return &runtimeapi.ImageStatusResponse{Info: map[string]string{"info": f.info[image.Image]}}, nil
+}
+
+func TestImageProtector(t *testing.T) {
+	dir := t.TempDir()
+	fakeDropInFiles(t, dir)
+
+	cfg := config.NewDefault()
+	cfg.Images.GarbageCollection.ProtectedImages = []string{"quay.io/example/*"}
+	cfg.Images.GarbageCollection.ProtectedLabels = map[string]string{"com.example.keep": "true"}
+	s := NewImageProtector(cfg)
+	images := &fakeImageService{
+		images: []*runtimeapi.Image{
+			{Id: "aaaa", RepoTags: []string{"registry.example.com/app:1.0", "registry.example.com/app:latest"}},
+			{Id: "bbbb", RepoTags: []string{"registry.example.com/other:1.0"}},
+			{Id: "cccc", RepoTags: []string{"registry.example.com/spec:1.0"}},
+		},
+		info: map[string]string{
+			"aaaa": `{"labels": {"com.example.keep": "true"}}`,
+			"bbbb": `{"labels": {"com.example.keep": "false"}}`,
+			"cccc": `{"imageSpec": {"config": {"Labels": {"com.example.keep": "true", "version": "1"}}}}`,
+		},
+	}
+
+	require.NoError(t, s.protect(context.TODO(), images))
+	pinned, err := os.ReadFile(pinnedImagesDropInFile)
+	require.NoError(t, err)
+	assert.Equal(t, header+`[crio.image]
+pinned_images = ["quay.io/example/*", "registry.example.com/app:1.0", "registry.example.com/app:latest", "registry.example.com/spec:1.0"]
+`, string(pinned))
+	calls, err := os.ReadFile(filepath.Join(dir, "calls"))
+	require.NoError(t, err)
+	assert.Equal(t, "is-active --quiet crio.service\nreload crio.service\n", string(calls))
+
+	// The pinned images are only written again when the images change.
+	require.NoError(t, os.Remove(filepath.Join(dir, "calls")))
+	images.images = images.images[1:]
+	require.NoError(t, s.protect(context.TODO(), images))
+	pinned, err = os.ReadFile(pinnedImagesDropInFile)
+	require.NoError(t, err)
+	assert.Equal(t, header+`[crio.image]
+pinned_images = ["quay.io/example/*", "registry.example.com/spec:1.0"]
+`, string(pinned))
+	assert.Len(t, s.labelsByID, 2)
+}
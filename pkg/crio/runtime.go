@@ -0,0 +1,87 @@
+package crio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/openshift/microshift/pkg/config"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var (
+	// pauseImageDropInFile overrides the pause image of the release, which
+	// CRI-O reads again when reloaded.
+	pauseImageDropInFile = "/etc/crio/crio.conf.d/92-microshift-pause-image.conf"
+	// runtimeDropInFile configures the limits of the containers and the
+	// additional runtimes, which CRI-O only reads when starting.
+	runtimeDropInFile = "/etc/crio/crio.conf.d/93-microshift-runtime.conf"
+)
+
+// ReconcileRuntimeConfig renders the crio configuration into the drop-in
+// configurations of CRI-O, and restarts or reloads CRI-O when they changed
+// so that the next containers use them.
+func ReconcileRuntimeConfig(ctx context.Context, cfg *config.Config) error {
+	reload, err := syncFile(pauseImageDropInFile, renderPauseImageConfig(cfg.CRIO))
+	if err != nil {
+		return fmt.Errorf("failed to update the pause image: %w", err)
+	}
+	restart, err := syncFile(runtimeDropInFile, renderRuntimeConfig(cfg.CRIO))
+	if err != nil {
+		return fmt.Errorf("failed to update the CRI-O configuration: %w", err)
+	}
+
+	switch {
+	case restart:
+		return applyConfig(ctx, "restart")
+	case reload:
+		return applyConfig(ctx, "reload")
+	}
+	return nil
+}
+
+// renderPauseImageConfig returns the CRI-O configuration of the pause image,
+// or nil to use the one of the release.
+func renderPauseImageConfig(c config.CRIO) []byte {
+	if c.PauseImage == "" {
+		return nil
+	}
+	b := &bytes.Buffer{}
+	b.WriteString(header)
+	b.WriteString("[crio.image]\n")
+	fmt.Fprintf(b, "pause_image = %s\n", strconv.Quote(c.PauseImage))
+	return b.Bytes()
+}
+
+// renderRuntimeConfig returns the CRI-O configuration of the limits of the
+// containers and of the runtimes other than crun, which the installed
+// configuration already has, or nil when there is none.
+func renderRuntimeConfig(c config.CRIO) []byte {
+	kata := slices.Contains(c.RuntimeClasses, config.RuntimeClassKata)
+	if c.PidsLimit == 0 && c.LogSizeMax == "" && !kata {
+		return nil
+	}
+	b := &bytes.Buffer{}
+	b.WriteString(header)
+	if c.PidsLimit != 0 || c.LogSizeMax != "" {
+		b.WriteString("[crio.runtime]\n")
+		if c.PidsLimit != 0 {
+			fmt.Fprintf(b, "pids_limit = %d\n", c.PidsLimit)
+		}
+		if c.LogSizeMax != "" {
+			size := resource.MustParse(c.LogSizeMax)
+			fmt.Fprintf(b, "log_size_max = %d\n", size.Value())
+		}
+	}
+	if kata {
+		b.WriteString("\n[crio.runtime.runtimes.kata]\n")
+		b.WriteString("runtime_path = \"/usr/bin/containerd-shim-kata-v2\"\n")
+		b.WriteString("runtime_type = \"vm\"\n")
+		b.WriteString("runtime_root = \"/run/vc\"\n")
+		b.WriteString("runtime_config_path = \"/usr/share/kata-containers/defaults/configuration.toml\"\n")
+		b.WriteString("privileged_without_host_devices = true\n")
+	}
+	return b.Bytes()
+}
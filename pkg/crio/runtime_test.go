@@ -0,0 +1,77 @@
+package crio
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReconcileRuntimeConfig(t *testing.T) {
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	fakeDropInFiles(t, dir)
+	pause, runtime := pauseImageDropInFile, runtimeDropInFile
+	t.Cleanup(func() { pauseImageDropInFile, runtimeDropInFile = pause, runtime })
+	pauseImageDropInFile = filepath.Join(dir, "crio.conf.d", "92-microshift-pause-image.conf")
+	runtimeDropInFile = filepath.Join(dir, "crio.conf.d", "93-microshift-runtime.conf")
+
+	reconcile := func(cfg *config.Config) string {
+		require.NoError(t, os.RemoveAll(calls))
+		require.NoError(t, ReconcileRuntimeConfig(context.TODO(), cfg))
+		out, _ := os.ReadFile(calls)
+		return string(out)
+	}
+
+	cfg := config.NewDefault()
+	assert.Empty(t, reconcile(cfg))
+	assert.NoFileExists(t, pauseImageDropInFile)
+	assert.NoFileExists(t, runtimeDropInFile)
+
+	cfg.CRIO.PauseImage = "registry.example.com/pause:3.9"
+	assert.Equal(t, "is-active --quiet crio.service\nreload crio.service\n", reconcile(cfg))
+	assert.Empty(t, reconcile(cfg))
+
+	cfg.CRIO.PidsLimit = 4096
+	cfg.CRIO.LogSizeMax = "1Mi"
+	cfg.CRIO.RuntimeClasses = []string{config.RuntimeClassCrun, config.RuntimeClassKata}
+	assert.Equal(t, "is-active --quiet crio.service\nrestart crio.service\n", reconcile(cfg))
+	rendered, err := os.ReadFile(runtimeDropInFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(rendered), "[crio.runtime]\npids_limit = 4096\nlog_size_max = 1048576\n")
+	assert.Contains(t, string(rendered), "[crio.runtime.runtimes.kata]\n")
+
+	cfg = config.NewDefault()
+	assert.Equal(t, "is-active --quiet crio.service\nrestart crio.service\n", reconcile(cfg))
+	assert.NoFileExists(t, pauseImageDropInFile)
+	assert.NoFileExists(t, runtimeDropInFile)
+}
+
+func TestReconcileRuntimeClasses(t *testing.T) {
+	ctx := context.TODO()
+	user := runtimeClass("user")
+	user.Labels = nil
+	client := fake.NewSimpleClientset(runtimeClass("removed"), user)
+
+	s := &RuntimeClassManager{names: []string{config.RuntimeClassKata, "user"}}
+	require.NoError(t, s.reconcile(ctx, client))
+
+	list, err := client.NodeV1().RuntimeClasses().List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	names := []string{}
+	for _, rc := range list.Items {
+		names = append(names, rc.Name)
+	}
+	assert.ElementsMatch(t, []string{config.RuntimeClassKata, "user"}, names)
+
+	kata, err := client.NodeV1().RuntimeClasses().Get(ctx, config.RuntimeClassKata, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, config.RuntimeClassKata, kata.Handler)
+	assert.NotNil(t, kata.Overhead)
+}
@@ -0,0 +1,120 @@
+package crio
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	nodev1 "k8s.io/api/node/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/microshift/pkg/config"
+)
+
+// managedByLabel marks the RuntimeClasses MicroShift creates, to delete the
+// ones removed from the configuration.
+const managedByLabel = "app.kubernetes.io/managed-by"
+
+// RuntimeClassManager creates a RuntimeClass for every runtime of
+// crio.runtimeClasses, and deletes the ones it created for the runtimes
+// removed from it.
+type RuntimeClassManager struct {
+	kubeconfig string
+	names      []string
+}
+
+func NewRuntimeClassManager(cfg *config.Config) *RuntimeClassManager {
+	return &RuntimeClassManager{
+		kubeconfig: cfg.KubeConfigPath(config.KubeAdmin),
+		names:      cfg.CRIO.RuntimeClasses,
+	}
+}
+
+func (s *RuntimeClassManager) Name() string           { return "runtime-class-manager" }
+func (s *RuntimeClassManager) Dependencies() []string { return []string{"kube-apiserver"} }
+
+func (s *RuntimeClassManager) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	defer close(stopped)
+
+	restCfg, err := clientcmd.BuildConfigFromFlags("", s.kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to load the kubeconfig: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(rest.AddUserAgent(restCfg, s.Name()))
+	if err != nil {
+		return err
+	}
+	if err := s.reconcile(ctx, client); err != nil {
+		return fmt.Errorf("failed to reconcile the runtime classes: %w", err)
+	}
+	close(ready)
+	return nil
+}
+
+func (s *RuntimeClassManager) reconcile(ctx context.Context, client kubernetes.Interface) error {
+	runtimeClasses := client.NodeV1().RuntimeClasses()
+	for _, name := range s.names {
+		rc := runtimeClass(name)
+		existing, err := runtimeClasses.Get(ctx, name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			if _, err := runtimeClasses.Create(ctx, rc, metav1.CreateOptions{}); err != nil {
+				return err
+			}
+			klog.Infof("Created RuntimeClass %s", name)
+			continue
+		case err != nil:
+			return err
+		}
+		if existing.Labels[managedByLabel] != "microshift" {
+			klog.Infof("Leaving RuntimeClass %s, not created by MicroShift, untouched", name)
+		}
+	}
+
+	managed, err := runtimeClasses.List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{managedByLabel: "microshift"}).String(),
+	})
+	if err != nil {
+		return err
+	}
+	for _, rc := range managed.Items {
+		if slices.Contains(s.names, rc.Name) {
+			continue
+		}
+		if err := runtimeClasses.Delete(ctx, rc.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		klog.Infof("Deleted RuntimeClass %s", rc.Name)
+	}
+	return nil
+}
+
+// runtimeClass returns the RuntimeClass of the runtime of CRI-O named name.
+func runtimeClass(name string) *nodev1.RuntimeClass {
+	rc := &nodev1.RuntimeClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{managedByLabel: "microshift"},
+		},
+		Handler: name,
+	}
+	if name == config.RuntimeClassKata {
+		// The memory and CPU of the virtual machine of the pod, accounted
+		// for when scheduling it.
+		rc.Overhead = &nodev1.Overhead{
+			PodFixed: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("350Mi"),
+				corev1.ResourceCPU:    resource.MustParse("250m"),
+			},
+		}
+	}
+	return rc
+}
@@ -0,0 +1,130 @@
+package crio
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/openshift/microshift/pkg/servicemanager"
+	"k8s.io/apimachinery/pkg/util/wait"
+	internalapi "k8s.io/cri-api/pkg/apis"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+	remote "k8s.io/cri-client/pkg"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// checkInterval is how often CRI-O is checked once ready.
+	checkInterval = 10 * time.Second
+	// maxFailedChecks is the number of consecutive failed checks after which
+	// CRI-O is started again.
+	maxFailedChecks = 3
+)
+
+// Service manages CRI-O as a dependency of MicroShift: it starts CRI-O when
+// it is not running, is ready once CRI-O serves the CRI, and fails when
+// CRI-O stops responding, to start it again.
+type Service struct {
+	runtimeService internalapi.RuntimeService
+}
+
+func NewService() *Service {
+	return &Service{}
+}
+
+func (s *Service) Name() string           { return "crio" }
+func (s *Service) Dependencies() []string { return []string{} }
+
+func (s *Service) RestartPolicy() servicemanager.RestartPolicy {
+	return servicemanager.RestartPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 5 * time.Second,
+		MaxBackoff:     time.Minute,
+		GiveUp:         servicemanager.GiveUpStopMicroShift,
+	}
+}
+
+func (s *Service) ReadinessTimeout() time.Duration { return 2 * time.Minute }
+func (s *Service) ReadinessHint() string {
+	return "check the output of 'systemctl status crio.service' and 'journalctl -u crio.service', " +
+		"and the drop-in configurations of /etc/crio/crio.conf.d"
+}
+
+func (s *Service) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	defer close(stopped)
+
+	if err := startIfInactive(ctx); err != nil {
+		return err
+	}
+	runtimeService, err := remote.NewRemoteRuntimeService(Endpoint, ConnectionTimeout, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to CRI-O: %w", err)
+	}
+	s.runtimeService = runtimeService
+
+	var lastErr error
+	if err := wait.PollUntilContextCancel(ctx, time.Second, true, func(ctx context.Context) (bool, error) {
+		lastErr = s.HealthCheck(ctx)
+		return lastErr == nil, nil
+	}); err != nil {
+		return fmt.Errorf("CRI-O is not ready: %w", lastErr)
+	}
+	version, err := runtimeService.Version(ctx, "")
+	if err != nil {
+		return err
+	}
+	klog.Infof("%s %s is ready", version.RuntimeName, version.RuntimeVersion)
+	close(ready)
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	failed := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if err := s.HealthCheck(ctx); err != nil {
+			failed++
+			klog.Warningf("CRI-O check %d of %d failed: %v", failed, maxFailedChecks, err)
+			if failed >= maxFailedChecks {
+				return fmt.Errorf("CRI-O stopped responding: %w", err)
+			}
+			continue
+		}
+		failed = 0
+	}
+}
+
+// HealthCheck returns an error unless CRI-O reports the runtime ready.
+func (s *Service) HealthCheck(ctx context.Context) error {
+	if s.runtimeService == nil {
+		return fmt.Errorf("not connected to CRI-O")
+	}
+	status, err := s.runtimeService.Status(ctx, false)
+	if err != nil {
+		return err
+	}
+	for _, condition := range status.GetStatus().GetConditions() {
+		if condition.Type == runtimeapi.RuntimeReady && !condition.Status {
+			return fmt.Errorf("runtime not ready: %s: %s", condition.Reason, condition.Message)
+		}
+	}
+	return nil
+}
+
+// startIfInactive starts crio.service when it is not running, e.g. after
+// it failed or was stopped, instead of waiting for it.
+func startIfInactive(ctx context.Context) error {
+	if err := exec.CommandContext(ctx, systemctlCommand, "is-active", "--quiet", "crio.service").Run(); err == nil {
+		return nil
+	}
+	klog.Infof("CRI-O is not running, running systemctl start crio.service")
+	if out, err := exec.CommandContext(ctx, systemctlCommand, "start", "crio.service").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to start CRI-O: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package diskpressure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+	remote "k8s.io/cri-client/pkg"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/microshift/pkg/admin/data"
+	"github.com/openshift/microshift/pkg/admin/prerun"
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/controllers"
+	"github.com/openshift/microshift/pkg/crio"
+	"github.com/openshift/microshift/pkg/util"
+)
+
+// defragmentEtcd compacts the history of etcd to its current revision and
+// defragments its database, releasing the space of the compacted keys to
+// the filesystem.
+func (m *Maintainer) defragmentEtcd(ctx context.Context, _ func() bool) (string, error) {
+	client, err := controllers.GetEtcdClient(ctx, m.cfg)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	status, err := client.Status(ctx, controllers.EtcdEndpoint)
+	if err != nil {
+		return "", err
+	}
+	revision := status.Header.Revision
+	if _, err := client.Compact(ctx, revision, clientv3.WithCompactPhysical()); err != nil && !errors.Is(err, rpctypes.ErrCompacted) {
+		return "", fmt.Errorf("failed to compact etcd to revision %d: %w", revision, err)
+	}
+	status, err = client.Status(ctx, controllers.EtcdEndpoint)
+	if err != nil {
+		return "", err
+	}
+	// The database is defragmented into a copy of the keys in use.
+	u, err := statFilesystem(config.DataDir)
+	if err != nil {
+		return "", err
+	}
+	if u.available < uint64(status.DbSizeInUse) {
+		return "", fmt.Errorf("not enough free space to defragment the etcd database of %s in use",
+			resource.NewQuantity(status.DbSizeInUse, resource.BinarySI))
+	}
+	if _, err := client.Defragment(ctx, controllers.EtcdEndpoint); err != nil {
+		return "", fmt.Errorf("failed to defragment etcd: %w", err)
+	}
+	after, err := client.Status(ctx, controllers.EtcdEndpoint)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Compacted etcd to revision %d and defragmented its database from %s to %s", revision,
+		resource.NewQuantity(status.DbSize, resource.BinarySI), resource.NewQuantity(after.DbSize, resource.BinarySI)), nil
+}
+
+// backup is a backup of the data directory, or a snapshot of etcd.
+type backup struct {
+	name    string
+	modTime time.Time
+}
+
+// prunableBackups returns the backups which can be pruned, from the oldest
+// to the newest, keeping the keep newest ones. The backups of the existing
+// deployments are never pruned, as MicroShift restores them when the host
+// rolls back to their deployment.
+func prunableBackups(backups []backup, keep int, deployments sets.Set[string]) []backup {
+	backups = slices.DeleteFunc(slices.Clone(backups), func(b backup) bool {
+		return deployments.Has(prerun.GetDeploymentIDForTheBackup(data.BackupName(b.name)))
+	})
+	slices.SortFunc(backups, func(a, b backup) int { return a.modTime.Compare(b.modTime) })
+	if keep >= len(backups) {
+		return nil
+	}
+	return backups[:len(backups)-keep]
+}
+
+// listBackups returns the backups of the data directory and the snapshots
+// of etcd in dir.
+func listBackups(dir string) ([]backup, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	backups := []backup{}
+	for _, entry := range entries {
+		if !entry.IsDir() && !strings.HasSuffix(entry.Name(), ".db") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, backup{name: entry.Name(), modTime: info.ModTime()})
+	}
+	return backups, nil
+}
+
+// existingDeployments returns the IDs of the deployments of the host, none
+// when it is not running on OSTree.
+func existingDeployments() (sets.Set[string], error) {
+	isOstree, err := util.PathExists("/run/ostree-booted")
+	if err != nil || !isOstree {
+		return sets.New[string](), err
+	}
+	ids, err := prerun.GetAllDeploymentIDs()
+	if err != nil {
+		return nil, err
+	}
+	return sets.New(ids...), nil
+}
+
+// pruneBackups removes the oldest backups, keeping the newest
+// diskMaintenance.keepBackups ones.
+func (m *Maintainer) pruneBackups(_ context.Context, pressured func() bool) (string, error) {
+	backups, err := listBackups(config.BackupsDir)
+	if err != nil {
+		return "", err
+	}
+	deployments, err := existingDeployments()
+	if err != nil {
+		return "", err
+	}
+	pruned := []string{}
+	for _, b := range prunableBackups(backups, *m.cfg.DiskMaintenance.KeepBackups, deployments) {
+		if !pressured() {
+			break
+		}
+		if err := os.RemoveAll(filepath.Join(config.BackupsDir, b.name)); err != nil {
+			return "", fmt.Errorf("failed to remove backup %q: %w", b.name, err)
+		}
+		klog.InfoS("Removed backup", "name", b.name)
+		pruned = append(pruned, b.name)
+	}
+	if len(pruned) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("Pruned the backups %s", strings.Join(pruned, ", ")), nil
+}
+
+// removeUnusedImages removes the images no container uses, from the
+// largest, leaving the pinned images to CRI-O.
+func (m *Maintainer) removeUnusedImages(ctx context.Context, pressured func() bool) (string, error) {
+	runtimeService, err := remote.NewRemoteRuntimeService(crio.Endpoint, crio.ConnectionTimeout, nil, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to CRI-O: %w", err)
+	}
+	imageService, err := remote.NewRemoteImageService(crio.Endpoint, crio.ConnectionTimeout, nil, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to CRI-O: %w", err)
+	}
+	containers, err := runtimeService.ListContainers(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	images, err := imageService.ListImages(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+
+	removed := []string{}
+	for _, image := range unusedImages(images, containers) {
+		if !pressured() {
+			break
+		}
+		if err := imageService.RemoveImage(ctx, &runtimeapi.ImageSpec{Image: image.Id}); err != nil {
+			klog.Warningf("Failed to remove image %s: %v", image.Id, err)
+			continue
+		}
+		klog.InfoS("Removed image", "id", image.Id, "tags", image.RepoTags)
+		removed = append(removed, image.Id)
+	}
+	if len(removed) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("Removed %d unused images", len(removed)), nil
+}
+
+// unusedImages returns the images which are neither pinned nor used by a
+// container, running or not, from the largest to the smallest.
+func unusedImages(images []*runtimeapi.Image, containers []*runtimeapi.Container) []*runtimeapi.Image {
+	used := map[string]bool{}
+	for _, c := range containers {
+		used[c.GetImageRef()] = true
+		used[c.GetImage().GetImage()] = true
+	}
+	unused := []*runtimeapi.Image{}
+	for _, image := range images {
+		if image.GetPinned() || used[image.GetId()] ||
+			slices.ContainsFunc(image.GetRepoDigests(), func(d string) bool { return used[d] }) ||
+			slices.ContainsFunc(image.GetRepoTags(), func(t string) bool { return used[t] }) {
+			continue
+		}
+		unused = append(unused, image)
+	}
+	slices.SortFunc(unused, func(a, b *runtimeapi.Image) int {
+		switch {
+		case a.GetSize_() > b.GetSize_():
+			return -1
+		case a.GetSize_() < b.GetSize_():
+			return 1
+		}
+		return 0
+	})
+	return unused
+}
@@ -0,0 +1,58 @@
+package diskpressure
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/sets"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+func TestPrunableBackups(t *testing.T) {
+	now := time.Now()
+	backups := []backup{
+		{name: "newest", modTime: now},
+		{name: "oldest", modTime: now.Add(-2 * time.Hour)},
+		{name: "older", modTime: now.Add(-time.Hour)},
+	}
+
+	none := sets.New[string]()
+	assert.Equal(t, []backup{backups[1], backups[2]}, prunableBackups(backups, 1, none))
+	assert.Equal(t, []backup{backups[1], backups[2], backups[0]}, prunableBackups(backups, 0, none))
+	assert.Empty(t, prunableBackups(backups, 3, none))
+}
+
+func TestPrunableBackupsOfExistingDeployments(t *testing.T) {
+	now := time.Now()
+	booted := "rhel-35d7b5c80f0f1378d6846f6dc1304bbf1dcdc5847198fcd4e6099364eaf99048.0"
+	rollback := "rhel-35d7b5c80f0f1378d6846f6dc1304bbf1dcdc5847198fcd4e6099364eaf99048.1"
+	removed := "rhel-35d7b5c80f0f1378d6846f6dc1304bbf1dcdc5847198fcd4e6099364eaf99048.2"
+	backups := []backup{
+		{name: booted + "_80364fcf3df54284a6902687e2cdd4c2", modTime: now},
+		{name: rollback + "_80364fcf3df54284a6902687e2cdd4c2", modTime: now.Add(-2 * time.Hour)},
+		{name: removed + "_80364fcf3df54284a6902687e2cdd4c2", modTime: now.Add(-3 * time.Hour)},
+		{name: "manual", modTime: now.Add(-time.Hour)},
+	}
+	deployments := sets.New(booted, rollback)
+
+	assert.Equal(t, []backup{backups[2], backups[3]}, prunableBackups(backups, 0, deployments))
+	assert.Equal(t, []backup{backups[2]}, prunableBackups(backups, 1, deployments))
+}
+
+func TestUnusedImages(t *testing.T) {
+	images := []*runtimeapi.Image{
+		{Id: "small", Size_: 1},
+		{Id: "pinned", Size_: 10, Pinned: true},
+		{Id: "large", Size_: 100},
+		{Id: "running", Size_: 100},
+		{Id: "by-digest", Size_: 100, RepoDigests: []string{"quay.io/app@sha256:1234"}},
+	}
+	containers := []*runtimeapi.Container{
+		{ImageRef: "running"},
+		{Image: &runtimeapi.ImageSpec{Image: "quay.io/app@sha256:1234"}},
+	}
+
+	unused := unusedImages(images, containers)
+	assert.Equal(t, []*runtimeapi.Image{images[2], images[0]}, unused)
+}
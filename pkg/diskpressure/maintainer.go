@@ -0,0 +1,274 @@
+// Package diskpressure reclaims disk space before the kubelet evicts pods
+// because the free space of the node or image filesystem runs low.
+package diskpressure
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/crio"
+	remote "k8s.io/cri-client/pkg"
+)
+
+// defaultImageStore is the image store of CRI-O, when it does not report
+// its filesystem.
+const defaultImageStore = "/var/lib/containers/storage"
+
+var (
+	availableBytes = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Name:           "microshift_disk_available_bytes",
+			Help:           "Free space of the filesystems watched for disk pressure.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"filesystem"},
+	)
+	thresholdBytes = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Name:           "microshift_disk_maintenance_threshold_bytes",
+			Help:           "Free space below which disk space is reclaimed on the filesystem.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"filesystem"},
+	)
+	actionsTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Name:           "microshift_disk_maintenance_actions_total",
+			Help:           "Actions run to reclaim disk space, by result.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"action", "result"},
+	)
+	reclaimedBytesTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Name:           "microshift_disk_maintenance_reclaimed_bytes_total",
+			Help:           "Disk space reclaimed by the actions.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"action"},
+	)
+)
+
+func init() {
+	legacyregistry.MustRegister(availableBytes, thresholdBytes, actionsTotal, reclaimedBytesTotal)
+}
+
+// filesystem is a filesystem the kubelet evicts pods for when its free
+// space runs low.
+type filesystem struct {
+	name   string
+	path   string
+	signal string
+}
+
+// usage is the space of a filesystem, and its device to tell the paths on
+// the same filesystem.
+type usage struct {
+	dev       uint64
+	capacity  uint64
+	available uint64
+}
+
+func statFilesystem(path string) (usage, error) {
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return usage{}, err
+	}
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return usage{}, err
+	}
+	return usage{
+		dev:       st.Dev,
+		capacity:  fs.Blocks * uint64(fs.Bsize),
+		available: fs.Bavail * uint64(fs.Bsize),
+	}, nil
+}
+
+// action reclaims disk space on the filesystem of path. run stops once
+// pressured returns false, and describes what it did.
+type action struct {
+	name   string
+	reason string
+	path   string
+	run    func(ctx context.Context, pressured func() bool) (string, error)
+}
+
+// Maintainer watches the free space of the data directory and of the image
+// store, and reclaims disk space once it gets close to the eviction
+// thresholds of the kubelet, recording an event on the node for every
+// action.
+type Maintainer struct {
+	cfg        *config.Config
+	kubeconfig string
+	nodeName   string
+	margin     int
+	interval   time.Duration
+
+	recorder    record.EventRecorder
+	filesystems []filesystem
+	actions     []action
+}
+
+func NewMaintainer(cfg *config.Config) *Maintainer {
+	return &Maintainer{
+		cfg:        cfg,
+		kubeconfig: cfg.KubeConfigPath(config.KubeAdmin),
+		nodeName:   cfg.CanonicalNodeName(),
+		margin:     *cfg.DiskMaintenance.MarginPercent,
+		interval:   time.Duration(*cfg.DiskMaintenance.CheckIntervalSeconds) * time.Second,
+	}
+}
+
+func (m *Maintainer) Name() string           { return "disk-pressure-maintainer" }
+func (m *Maintainer) Dependencies() []string { return []string{"kube-apiserver"} }
+
+// Deferred reclaims disk space once MicroShift is ready.
+func (m *Maintainer) Deferred() bool { return true }
+
+func (m *Maintainer) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	defer close(stopped)
+
+	restCfg, err := clientcmd.BuildConfigFromFlags("", m.kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to load the kubeconfig: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(rest.AddUserAgent(restCfg, m.Name()))
+	if err != nil {
+		return err
+	}
+	broadcaster := record.NewBroadcaster(record.WithContext(ctx))
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	defer broadcaster.Shutdown()
+	m.recorder = broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: m.Name(), Host: m.nodeName})
+
+	imageStore := m.imageStore(ctx)
+	m.filesystems = []filesystem{
+		{name: "nodefs", path: config.DataDir, signal: nodefsSignal},
+		{name: "imagefs", path: imageStore, signal: imagefsSignal},
+	}
+	// The actions are run from the cheapest to the most disruptive, until
+	// enough disk space is reclaimed.
+	if !m.cfg.Etcd.IsExternal() && !m.cfg.Etcd.Tmpfs.IsEnabled() {
+		m.actions = append(m.actions, action{
+			name: "etcd-defragmentation", reason: "EtcdDefragmented", path: config.DataDir, run: m.defragmentEtcd,
+		})
+	}
+	m.actions = append(m.actions,
+		action{name: "backup-pruning", reason: "BackupsPruned", path: config.BackupsDir, run: m.pruneBackups},
+		action{name: "image-garbage-collection", reason: "ImagesRemoved", path: imageStore, run: m.removeUnusedImages},
+	)
+
+	klog.Infof("%s watching the free space of %s and %s", m.Name(), config.DataDir, imageStore)
+	close(ready)
+
+	wait.UntilWithContext(ctx, m.check, m.interval)
+	return ctx.Err()
+}
+
+// imageStore returns the path of the image filesystem of CRI-O.
+func (m *Maintainer) imageStore(ctx context.Context) string {
+	imageService, err := remote.NewRemoteImageService(crio.Endpoint, crio.ConnectionTimeout, nil, nil)
+	if err != nil {
+		klog.Warningf("Failed to connect to CRI-O, assuming the image store is %s: %v", defaultImageStore, err)
+		return defaultImageStore
+	}
+	info, err := imageService.ImageFsInfo(ctx)
+	if err != nil || len(info.GetImageFilesystems()) == 0 {
+		klog.Warningf("Failed to get the image filesystem of CRI-O, assuming the image store is %s: %v", defaultImageStore, err)
+		return defaultImageStore
+	}
+	return info.GetImageFilesystems()[0].GetFsId().GetMountpoint()
+}
+
+// pressure returns the devices of the filesystems whose free space is
+// below their threshold.
+func (m *Maintainer) pressure() map[uint64]bool {
+	pressured := map[uint64]bool{}
+	for _, fs := range m.filesystems {
+		u, err := statFilesystem(fs.path)
+		if err != nil {
+			klog.V(2).Infof("Failed to get the free space of %s: %v", fs.path, err)
+			continue
+		}
+		threshold, err := evictionThreshold(m.cfg.Node.Eviction, fs.signal, u.capacity)
+		if err != nil {
+			klog.Warningf("Not reclaiming disk space on %s: %v", fs.path, err)
+			continue
+		}
+		threshold += u.capacity * uint64(m.margin) / 100
+		availableBytes.WithLabelValues(fs.name).Set(float64(u.available))
+		thresholdBytes.WithLabelValues(fs.name).Set(float64(threshold))
+		if u.available < threshold {
+			pressured[u.dev] = true
+		}
+	}
+	return pressured
+}
+
+// check runs the actions reclaiming disk space on the filesystems under
+// pressure, until they are not anymore.
+func (m *Maintainer) check(ctx context.Context) {
+	pressured := m.pressure()
+	if len(pressured) == 0 {
+		return
+	}
+	klog.Warningf("Free disk space is low, reclaiming disk space")
+	m.recorder.Event(m.nodeRef(), corev1.EventTypeWarning, "FreeDiskSpaceLow",
+		"Free disk space is close to the eviction thresholds of the kubelet, reclaiming disk space")
+
+	for _, a := range m.actions {
+		before, err := statFilesystem(a.path)
+		if err != nil || !pressured[before.dev] {
+			continue
+		}
+		stillPressured := func() bool { return m.pressure()[before.dev] }
+
+		message, err := a.run(ctx, stillPressured)
+		if err != nil {
+			klog.Errorf("Failed to reclaim disk space with %s: %v", a.name, err)
+			actionsTotal.WithLabelValues(a.name, "failure").Inc()
+			m.recorder.Eventf(m.nodeRef(), corev1.EventTypeWarning, "DiskMaintenanceFailed", "Failed to reclaim disk space with %s: %v", a.name, err)
+			continue
+		}
+		if message == "" {
+			continue
+		}
+		var reclaimed uint64
+		if after, err := statFilesystem(a.path); err == nil && after.available > before.available {
+			reclaimed = after.available - before.available
+		}
+		actionsTotal.WithLabelValues(a.name, "success").Inc()
+		reclaimedBytesTotal.WithLabelValues(a.name).Add(float64(reclaimed))
+		message = fmt.Sprintf("%s, reclaiming %s", message, resource.NewQuantity(int64(reclaimed), resource.BinarySI))
+		klog.Info(message)
+		m.recorder.Event(m.nodeRef(), corev1.EventTypeNormal, a.reason, message)
+
+		if pressured = m.pressure(); len(pressured) == 0 {
+			return
+		}
+	}
+	klog.Warningf("Free disk space is still low after reclaiming disk space")
+}
+
+// nodeRef refers to the node the way the kubelet does in its events.
+func (m *Maintainer) nodeRef() *corev1.ObjectReference {
+	return &corev1.ObjectReference{Kind: "Node", Name: m.nodeName, UID: types.UID(m.nodeName)}
+}
@@ -0,0 +1,66 @@
+package diskpressure
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/openshift/microshift/pkg/config"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	nodefsSignal  = "nodefs.available"
+	imagefsSignal = "imagefs.available"
+)
+
+// defaultEvictionHard are the hard eviction thresholds of the kubelet on
+// the free disk space, used when node.eviction.hard is empty. Once it is
+// set, the kubelet does not evict pods for the signals missing from it.
+var defaultEvictionHard = map[string]string{
+	nodefsSignal:  "10%",
+	imagefsSignal: "15%",
+}
+
+// evictionThreshold returns the free bytes, on a filesystem of capacity
+// bytes, below which the kubelet evicts pods for the signal: the highest
+// of its hard and soft thresholds.
+func evictionThreshold(eviction config.NodeEviction, signal string, capacity uint64) (uint64, error) {
+	hard := eviction.Hard
+	if len(hard) == 0 {
+		hard = defaultEvictionHard
+	}
+	var threshold uint64
+	for _, thresholds := range []map[string]string{hard, eviction.Soft} {
+		value, ok := thresholds[signal]
+		if !ok {
+			continue
+		}
+		bytes, err := parseThreshold(value, capacity)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s eviction threshold %q: %w", signal, value, err)
+		}
+		threshold = max(threshold, bytes)
+	}
+	return threshold, nil
+}
+
+// parseThreshold returns the bytes of a threshold, either a percentage of
+// capacity or a quantity.
+func parseThreshold(value string, capacity uint64) (uint64, error) {
+	if percent, ok := strings.CutSuffix(value, "%"); ok {
+		p, err := strconv.ParseFloat(percent, 64)
+		if err != nil || p < 0 || p > 100 {
+			return 0, fmt.Errorf("not a percentage")
+		}
+		return uint64(float64(capacity) * p / 100), nil
+	}
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0, err
+	}
+	if q.Sign() < 0 {
+		return 0, fmt.Errorf("negative quantity")
+	}
+	return uint64(q.Value()), nil
+}
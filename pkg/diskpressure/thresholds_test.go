@@ -0,0 +1,64 @@
+package diskpressure
+
+import (
+	"testing"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvictionThreshold(t *testing.T) {
+	const capacity = 100 << 30
+
+	tests := []struct {
+		name      string
+		eviction  config.NodeEviction
+		signal    string
+		expected  uint64
+		expectErr bool
+	}{
+		{
+			name:     "kubelet-defaults",
+			signal:   imagefsSignal,
+			expected: 15 << 30,
+		},
+		{
+			name:     "hard-quantity",
+			eviction: config.NodeEviction{Hard: map[string]string{nodefsSignal: "2Gi"}},
+			signal:   nodefsSignal,
+			expected: 2 << 30,
+		},
+		{
+			name:     "signal-missing-from-hard",
+			eviction: config.NodeEviction{Hard: map[string]string{nodefsSignal: "5%"}},
+			signal:   imagefsSignal,
+			expected: 0,
+		},
+		{
+			name: "soft-above-hard",
+			eviction: config.NodeEviction{
+				Hard: map[string]string{nodefsSignal: "5%"},
+				Soft: map[string]string{nodefsSignal: "12.5%"},
+			},
+			signal:   nodefsSignal,
+			expected: capacity / 8,
+		},
+		{
+			name:      "invalid",
+			eviction:  config.NodeEviction{Hard: map[string]string{nodefsSignal: "lots"}},
+			signal:    nodefsSignal,
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			threshold, err := evictionThreshold(tt.eviction, tt.signal, capacity)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, threshold)
+		})
+	}
+}
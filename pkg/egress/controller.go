@@ -0,0 +1,220 @@
+// Package egress translates the source address of the traffic the pods of a
+// namespace send out of the cluster to the address of the host selected by
+// the microshift.io/egress-ip annotation of the namespace, for equipment
+// expecting a fixed source address.
+package egress
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/microshift/pkg/config"
+)
+
+const (
+	// egressIPAnnotation selects the address of the host the traffic of
+	// the pods of the namespace leaving the cluster is translated to.
+	egressIPAnnotation = "microshift.io/egress-ip"
+
+	defaultInformerResyncPeriod = 10 * time.Minute
+	// syncKey is the single key of the queue, the rules being rendered
+	// for all the namespaces at once.
+	syncKey = "sync"
+)
+
+// Controller programs the translation of the source address of the pods of
+// the annotated namespaces running on the node.
+type Controller struct {
+	kubeconfig string
+	nodeName   string
+	addresses  []string
+	// internal are the networks the traffic to is not translated.
+	internal []string
+
+	recorder record.EventRecorder
+	// invalid holds the annotation of the namespaces last reported invalid,
+	// by name, to report it once.
+	invalid    map[string]string
+	namespaces cache.Indexer
+	pods       cache.Indexer
+	queue      workqueue.TypedRateLimitingInterface[string]
+	// applied is the last applied script.
+	applied []byte
+}
+
+func NewController(cfg *config.Config) *Controller {
+	return &Controller{
+		kubeconfig: cfg.KubeConfigPath(config.KubeAdmin),
+		nodeName:   cfg.CanonicalNodeName(),
+		addresses:  cfg.Network.Egress.Addresses,
+		internal:   append(slices.Clone(cfg.Network.ClusterNetwork), cfg.Network.ServiceNetwork...),
+		invalid:    map[string]string{},
+	}
+}
+
+func (c *Controller) Name() string { return "microshift-egress-controller" }
+func (c *Controller) Dependencies() []string {
+	return []string{"kube-apiserver", "infrastructure-services-manager"}
+}
+
+func (c *Controller) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	defer close(stopped)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	restCfg, err := clientcmd.BuildConfigFromFlags("", c.kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to load the kubeconfig: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(rest.AddUserAgent(restCfg, c.Name()))
+	if err != nil {
+		return err
+	}
+
+	broadcaster := record.NewBroadcaster(record.WithContext(ctx))
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	defer broadcaster.Shutdown()
+	c.recorder = broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: c.Name()})
+
+	c.queue = workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[string]())
+	defer c.queue.ShutDown()
+	enqueue := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(any) { c.queue.Add(syncKey) },
+		UpdateFunc: func(any, any) { c.queue.Add(syncKey) },
+		DeleteFunc: func(any) { c.queue.Add(syncKey) },
+	}
+
+	factory := informers.NewSharedInformerFactory(client, defaultInformerResyncPeriod)
+	namespaceInformer := factory.Core().V1().Namespaces().Informer()
+	// Only the pods of the node are translated by its rules.
+	podFactory := informers.NewSharedInformerFactoryWithOptions(client, defaultInformerResyncPeriod,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", c.nodeName).String()
+		}))
+	podInformer := podFactory.Core().V1().Pods().Informer()
+	for _, informer := range []cache.SharedIndexInformer{namespaceInformer, podInformer} {
+		if _, err := informer.AddEventHandler(enqueue); err != nil {
+			return fmt.Errorf("failed to initialize informer event handlers: %w", err)
+		}
+	}
+	c.namespaces = namespaceInformer.GetIndexer()
+	c.pods = podInformer.GetIndexer()
+
+	factory.Start(stopCh)
+	podFactory.Start(stopCh)
+	if !cache.WaitForCacheSync(stopCh, namespaceInformer.HasSynced, podInformer.HasSynced) {
+		return fmt.Errorf("timed out waiting for caches to sync")
+	}
+
+	go wait.Until(func() {
+		for c.processNextItem(ctx) {
+		}
+	}, time.Second, stopCh)
+
+	klog.Infof("%s started with egress addresses %v", c.Name(), c.addresses)
+	close(ready)
+
+	<-ctx.Done()
+	// The pods fall back to the translation of the CNI.
+	if err := applyNftables(context.Background(), []byte(nftablesReset)); err != nil {
+		klog.Errorf("Failed to delete the nftables table %s: %v", nftablesTable, err)
+	}
+	return ctx.Err()
+}
+
+func (c *Controller) processNextItem(ctx context.Context) bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	if err := c.sync(ctx); err != nil {
+		klog.Errorf("Failed to sync the egress rules: %v", err)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *Controller) sync(ctx context.Context) error {
+	egressIPs := map[string]net.IP{}
+	for _, obj := range c.namespaces.List() {
+		ns := obj.(*corev1.Namespace)
+		value, ok := ns.Annotations[egressIPAnnotation]
+		if !ok {
+			delete(c.invalid, ns.Name)
+			continue
+		}
+		ip, err := c.egressIP(value)
+		if err != nil {
+			if c.invalid[ns.Name] != value {
+				c.invalid[ns.Name] = value
+				c.recorder.Eventf(ns, corev1.EventTypeWarning, "InvalidEgressIP", "Not translating the egress traffic of the pods: %v", err)
+			}
+			continue
+		}
+		delete(c.invalid, ns.Name)
+		egressIPs[ns.Name] = ip
+	}
+
+	var translations []translation
+	for _, obj := range c.pods.List() {
+		pod := obj.(*corev1.Pod)
+		egressIP, ok := egressIPs[pod.Namespace]
+		if !ok || pod.Spec.HostNetwork || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, podIP := range pod.Status.PodIPs {
+			ip := net.ParseIP(podIP.IP)
+			if ip != nil && (ip.To4() != nil) == (egressIP.To4() != nil) {
+				translations = append(translations, translation{podIP: ip, egressIP: egressIP})
+			}
+		}
+	}
+
+	script := renderNftables(translations, c.internal)
+	if slices.Equal(script, c.applied) {
+		return nil
+	}
+	if err := applyNftables(ctx, script); err != nil {
+		return err
+	}
+	klog.Infof("Translating the egress traffic of %d pod addresses", len(translations))
+	c.applied = script
+	return nil
+}
+
+// egressIP parses the annotation, which must be one of the egress addresses
+// of the configuration.
+func (c *Controller) egressIP(value string) (net.IP, error) {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("%s %q is not an IP address", egressIPAnnotation, value)
+	}
+	for _, address := range c.addresses {
+		if ip.Equal(net.ParseIP(address)) {
+			return ip, nil
+		}
+	}
+	return nil, fmt.Errorf("%s %q is not one of network.egress.addresses %v", egressIPAnnotation, value, c.addresses)
+}
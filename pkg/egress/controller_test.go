@@ -0,0 +1,90 @@
+package egress
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRenderNftables(t *testing.T) {
+	got := renderNftables([]translation{
+		{podIP: net.ParseIP("10.42.0.9"), egressIP: net.ParseIP("192.168.1.50")},
+		{podIP: net.ParseIP("10.42.0.5"), egressIP: net.ParseIP("192.168.1.51")},
+		{podIP: net.ParseIP("fd01::5"), egressIP: net.ParseIP("2001:db8::50")},
+	}, []string{"10.42.0.0/16", "fd01::/48", "10.43.0.0/16", "fd02::/112"})
+	assert.Equal(t, `table inet microshift-egress
+delete table inet microshift-egress
+table inet microshift-egress {
+	chain postrouting {
+		type nat hook postrouting priority srcnat - 10; policy accept;
+		ip daddr { 10.42.0.0/16, 10.43.0.0/16 } return
+		snat ip to ip saddr map { 10.42.0.5 : 192.168.1.51, 10.42.0.9 : 192.168.1.50 }
+		ip6 daddr { fd01::/48, fd02::/112 } return
+		snat ip6 to ip6 saddr map { fd01::5 : 2001:db8::50 }
+	}
+}
+`, string(got))
+}
+
+func TestSync(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "script")
+	bin := filepath.Join(dir, "nft")
+	require.NoError(t, os.WriteFile(bin, []byte("#!/bin/sh\ncat > "+script+"\n"), 0700))
+	nft := nftCommand
+	t.Cleanup(func() { nftCommand = nft })
+	nftCommand = bin
+
+	recorder := record.NewFakeRecorder(10)
+	c := &Controller{
+		addresses:  []string{"192.168.1.50"},
+		internal:   []string{"10.42.0.0/16", "10.43.0.0/16"},
+		invalid:    map[string]string{},
+		recorder:   recorder,
+		namespaces: cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}),
+		pods:       cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}),
+	}
+	namespace := func(name, egressIP string) *corev1.Namespace {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if egressIP != "" {
+			ns.Annotations = map[string]string{egressIPAnnotation: egressIP}
+		}
+		return ns
+	}
+	pod := func(namespace, name, ip string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Status:     corev1.PodStatus{Phase: phase, PodIPs: []corev1.PodIP{{IP: ip}}},
+		}
+	}
+	require.NoError(t, c.namespaces.Add(namespace("plc", "192.168.1.50")))
+	require.NoError(t, c.namespaces.Add(namespace("other", "")))
+	require.NoError(t, c.namespaces.Add(namespace("wrong", "192.168.1.99")))
+	require.NoError(t, c.pods.Add(pod("plc", "gateway", "10.42.0.5", corev1.PodRunning)))
+	require.NoError(t, c.pods.Add(pod("plc", "job", "10.42.0.6", corev1.PodSucceeded)))
+	require.NoError(t, c.pods.Add(pod("other", "app", "10.42.0.7", corev1.PodRunning)))
+	require.NoError(t, c.pods.Add(pod("wrong", "app", "10.42.0.8", corev1.PodRunning)))
+
+	require.NoError(t, c.sync(context.TODO()))
+	out, err := os.ReadFile(script)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "snat ip to ip saddr map { 10.42.0.5 : 192.168.1.50 }\n")
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "InvalidEgressIP")
+
+	// Unchanged rules are not applied again, nor invalid annotations
+	// reported again.
+	require.NoError(t, os.Remove(script))
+	require.NoError(t, c.sync(context.TODO()))
+	assert.NoFileExists(t, script)
+	assert.Empty(t, recorder.Events)
+}
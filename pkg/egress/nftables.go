@@ -0,0 +1,75 @@
+package egress
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+const nftablesTable = "inet microshift-egress"
+
+// nftCommand is the nft binary, overridden in tests.
+var nftCommand = "nft"
+
+// nftablesReset declares the table before deleting it, for the script to
+// apply whether it exists or not.
+const nftablesReset = "table " + nftablesTable + "\ndelete table " + nftablesTable + "\n"
+
+// translation is the translation of the source address of a pod.
+type translation struct {
+	podIP    net.IP
+	egressIP net.IP
+}
+
+// renderNftables renders the script replacing the microshift-egress table,
+// whose postrouting chain translates the source address of the pods, but
+// for the traffic to the internal networks. It runs before the source NAT
+// of the CNI, which only translates the traffic not translated yet.
+func renderNftables(translations []translation, internal []string) []byte {
+	b := &bytes.Buffer{}
+	b.WriteString(nftablesReset)
+	fmt.Fprintf(b, "table %s {\n", nftablesTable)
+	b.WriteString("\tchain postrouting {\n")
+	b.WriteString("\t\ttype nat hook postrouting priority srcnat - 10; policy accept;\n")
+
+	for _, family := range []struct {
+		name string
+		ipv4 bool
+	}{{"ip", true}, {"ip6", false}} {
+		var networks, elements []string
+		for _, network := range internal {
+			if ip, _, err := net.ParseCIDR(network); err == nil && (ip.To4() != nil) == family.ipv4 {
+				networks = append(networks, network)
+			}
+		}
+		for _, t := range translations {
+			if (t.podIP.To4() != nil) == family.ipv4 {
+				elements = append(elements, t.podIP.String()+" : "+t.egressIP.String())
+			}
+		}
+		if len(elements) == 0 {
+			continue
+		}
+		sort.Strings(elements)
+		if len(networks) != 0 {
+			fmt.Fprintf(b, "\t\t%s daddr { %s } return\n", family.name, strings.Join(networks, ", "))
+		}
+		fmt.Fprintf(b, "\t\tsnat %s to %s saddr map { %s }\n", family.name, family.name, strings.Join(elements, ", "))
+	}
+	b.WriteString("\t}\n}\n")
+	return b.Bytes()
+}
+
+// applyNftables applies the nft script atomically.
+func applyNftables(ctx context.Context, script []byte) error {
+	cmd := exec.CommandContext(ctx, nftCommand, "-f", "-")
+	cmd.Stdin = bytes.NewReader(script)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to apply the nftables table %s: %w: %s", nftablesTable, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
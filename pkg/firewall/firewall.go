@@ -0,0 +1,152 @@
+// Package firewall opens the ports MicroShift serves in the host firewall
+// while it runs, and trusts the traffic of the pods, with firewalld or a
+// dedicated nftables table, instead of leaving them to be opened by hand.
+package firewall
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strconv"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/config/ovn"
+	"github.com/openshift/microshift/pkg/join"
+	"k8s.io/klog/v2"
+)
+
+const (
+	kubeletPort  = 10250
+	etcdPeerPort = 2380
+	mDNSPort     = 5353
+	genevePort   = 6081
+	vxlanPort    = 8472
+)
+
+// Reconciler opens the ports of MicroShift in the host firewall when
+// starting, and closes them when stopping.
+type Reconciler struct {
+	cfg *config.Config
+}
+
+func NewReconciler(cfg *config.Config) *Reconciler {
+	return &Reconciler{cfg: cfg}
+}
+
+func (r *Reconciler) Name() string           { return "firewall-reconciler" }
+func (r *Reconciler) Dependencies() []string { return []string{} }
+
+func (r *Reconciler) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	defer close(stopped)
+
+	ports := Ports(r.cfg)
+	sources := TrustedSources(r.cfg)
+	switch r.cfg.Firewall.Backend {
+	case config.FirewallBackendNftables:
+		if err := applyNftables(ctx, renderNftables(ports, sources)); err != nil {
+			return err
+		}
+		klog.Infof("%s opened ports %v and trusted %v in the nftables table %s", r.Name(), ports, sources, nftablesTable)
+		close(ready)
+
+		<-ctx.Done()
+		if err := deleteNftables(context.Background()); err != nil {
+			klog.Errorf("Failed to delete the nftables table %s: %v", nftablesTable, err)
+		}
+		return ctx.Err()
+
+	default:
+		// The connections to firewalld outlive ctx to close the ports.
+		fwCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		zone, err := NewFirewalld(fwCtx, r.cfg.Firewall.Zone)
+		if err != nil {
+			return err
+		}
+		trusted, err := NewFirewalld(fwCtx, r.cfg.Firewall.TrustedZone)
+		if err != nil {
+			return err
+		}
+		zone.SetPorts(ports)
+		trusted.SetSources(sources)
+		klog.Infof("%s opened ports %v in firewalld zone %q and trusted %v in zone %q", r.Name(), ports, r.cfg.Firewall.Zone, sources, r.cfg.Firewall.TrustedZone)
+		close(ready)
+
+		<-ctx.Done()
+		zone.SetPorts(nil)
+		trusted.SetSources(nil)
+		return ctx.Err()
+	}
+}
+
+// Ports returns the ports MicroShift serves on the node, and those of
+// firewall.allowedPorts, as port/protocol.
+func Ports(cfg *config.Config) []string {
+	port := func(number int, protocol string) string {
+		return strconv.Itoa(number) + "/" + protocol
+	}
+	ports := slices.Clone(cfg.Firewall.AllowedPorts)
+	switch {
+	case cfg.Etcd.Standby.Role == config.EtcdStandbyRoleStandby:
+		// Only etcd runs on the standby node.
+		ports = append(ports, port(etcdPeerPort, "tcp"))
+	case cfg.MultiNode.Worker:
+		ports = append(ports, port(kubeletPort, "tcp"))
+	default:
+		ports = append(ports, port(cfg.ApiServer.Port, "tcp"))
+		if cfg.Ingress.Status == config.StatusManaged {
+			ports = append(ports, port(*cfg.Ingress.Ports.Http, "tcp"), port(*cfg.Ingress.Ports.Https, "tcp"))
+		}
+		if cfg.MDNS.Status == config.MDNSStatusEnabled {
+			ports = append(ports, port(mDNSPort, "udp"))
+		}
+		if cfg.VirtualIP.Status == config.VirtualIPStatusEnabled {
+			ports = append(ports, port(cfg.VirtualIP.Port, "udp"))
+		}
+		if cfg.FleetAPI.Status == config.FleetAPIStatusEnabled {
+			ports = append(ports, port(cfg.FleetAPI.Port, "tcp"))
+		}
+		if cfg.Etcd.Standby.Role != "" && cfg.Etcd.Standby.Role != config.EtcdStandbyRoleNone {
+			ports = append(ports, port(etcdPeerPort, "tcp"))
+		}
+		if cfg.MultiNode.Enabled {
+			ports = append(ports, port(join.ServerPort, "tcp"))
+			if cfg.Network.IsOVNK() {
+				ports = append(ports, ovn.OVN_NB_PORT+"/tcp", ovn.OVN_SB_PORT+"/tcp")
+			}
+		}
+	}
+	// The overlay between the nodes.
+	if cfg.MultiNode.Enabled || cfg.MultiNode.Worker {
+		switch {
+		case cfg.Network.IsOVNK():
+			ports = append(ports, port(genevePort, "udp"))
+		case cfg.Network.CNIPlugin == config.CniPluginFlannel:
+			ports = append(ports, port(vxlanPort, "udp"))
+		}
+	}
+	slices.Sort(ports)
+	return slices.Compact(ports)
+}
+
+// TrustedSources returns the networks the traffic of the pods comes from:
+// the cluster networks, and the address OVN-Kubernetes masquerades the
+// traffic of the pods to the host with.
+func TrustedSources(cfg *config.Config) []string {
+	sources := slices.Clone(cfg.Network.ClusterNetwork)
+	if cfg.Network.IsOVNK() {
+		for _, network := range cfg.Network.ClusterNetwork {
+			ip, _, err := net.ParseCIDR(network)
+			if err != nil {
+				continue
+			}
+			if ip.To4() != nil {
+				sources = append(sources, "169.254.169.1")
+			} else {
+				sources = append(sources, "fd69::1")
+			}
+		}
+	}
+	slices.Sort(sources)
+	return slices.Compact(sources)
+}
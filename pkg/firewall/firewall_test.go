@@ -0,0 +1,84 @@
+package firewall
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPorts(t *testing.T) {
+	cfg := config.NewDefault()
+	assert.Equal(t, []string{"443/tcp", "5353/udp", "6443/tcp", "80/tcp"}, Ports(cfg))
+
+	cfg.Ingress.Status = config.StatusRemoved
+	cfg.MDNS.Status = config.MDNSStatusDisabled
+	cfg.Firewall.AllowedPorts = []string{"30080/tcp", "6443/tcp"}
+	cfg.FleetAPI.Status = config.FleetAPIStatusEnabled
+	cfg.MultiNode.Enabled = true
+	assert.Equal(t, []string{"30080/tcp", "6081/udp", "6443/tcp", "7443/tcp", "7445/tcp", "9641/tcp", "9642/tcp"}, Ports(cfg))
+
+	cfg = config.NewDefault()
+	cfg.MultiNode.Worker = true
+	cfg.Network.CNIPlugin = config.CniPluginFlannel
+	assert.Equal(t, []string{"10250/tcp", "8472/udp"}, Ports(cfg))
+
+	cfg = config.NewDefault()
+	cfg.Etcd.Standby.Role = config.EtcdStandbyRoleStandby
+	assert.Equal(t, []string{"2380/tcp"}, Ports(cfg))
+}
+
+func TestTrustedSources(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.Network.ClusterNetwork = []string{"10.42.0.0/16", "fd01::/48"}
+	assert.Equal(t, []string{"10.42.0.0/16", "169.254.169.1", "fd01::/48", "fd69::1"}, TrustedSources(cfg))
+
+	cfg.Network.CNIPlugin = config.CniPluginFlannel
+	assert.Equal(t, []string{"10.42.0.0/16", "fd01::/48"}, TrustedSources(cfg))
+}
+
+func TestRenderNftables(t *testing.T) {
+	got := renderNftables([]string{"443/tcp", "5353/udp", "6443/tcp"}, []string{"10.42.0.0/16", "169.254.169.1", "fd01::/48"})
+	assert.Equal(t, `table inet microshift
+delete table inet microshift
+table inet microshift {
+	chain input {
+		type filter hook input priority filter; policy drop;
+		ct state established,related accept
+		ct state invalid drop
+		iifname "lo" accept
+		meta l4proto { icmp, ipv6-icmp } accept
+		tcp dport 22 accept
+		ip6 saddr fe80::/10 udp dport 546 accept
+		ip saddr { 10.42.0.0/16, 169.254.169.1 } accept
+		ip6 saddr { fd01::/48 } accept
+		tcp dport { 443, 6443 } accept
+		udp dport { 5353 } accept
+	}
+}
+`, string(got))
+}
+
+func TestApplyNftables(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "script")
+	bin := filepath.Join(dir, "nft")
+	require.NoError(t, os.WriteFile(bin, []byte("#!/bin/sh\ncat >> "+script+"\n"), 0700))
+	nft := nftCommand
+	t.Cleanup(func() { nftCommand = nft })
+	nftCommand = bin
+
+	require.NoError(t, applyNftables(context.TODO(), renderNftables([]string{"6443/tcp"}, nil)))
+	require.NoError(t, deleteNftables(context.TODO()))
+	out, err := os.ReadFile(script)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "tcp dport { 6443 } accept\n")
+	assert.Contains(t, string(out), "}\n"+nftablesReset)
+
+	require.NoError(t, os.WriteFile(bin, []byte("#!/bin/sh\necho 'Error: syntax error' >&2\nexit 1\n"), 0700))
+	assert.ErrorContains(t, deleteNftables(context.TODO()), "syntax error")
+}
@@ -0,0 +1,133 @@
+package firewall
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+	"k8s.io/klog/v2"
+)
+
+const (
+	firewalldService       = "org.fedoraproject.FirewallD1"
+	firewalldPath          = dbus.ObjectPath("/org/fedoraproject/FirewallD1")
+	firewalldZoneInterface = "org.fedoraproject.FirewallD1.zone"
+)
+
+// Firewalld opens ports and adds sources in a firewalld zone, in its runtime
+// configuration, and opens and adds them again when firewalld reloads.
+type Firewalld struct {
+	sync.Mutex
+	zone string
+	conn *dbus.Conn
+	// wantedPorts are the ports to open, as port/protocol, and wantedSources
+	// the addresses or networks to add. opened holds those opened and added.
+	wantedPorts   []string
+	wantedSources []string
+	opened        map[string]bool
+}
+
+// NewFirewalld connects to firewalld, until ctx is done.
+func NewFirewalld(ctx context.Context, zone string) (*Firewalld, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the system bus: %w", err)
+	}
+	if err := conn.AddMatchSignal(
+		dbus.WithMatchSender(firewalldService),
+		dbus.WithMatchInterface(firewalldService),
+		dbus.WithMatchMember("Reloaded"),
+	); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to subscribe to firewalld signals: %w", err)
+	}
+	f := &Firewalld{zone: zone, conn: conn, opened: map[string]bool{}}
+
+	signals := make(chan *dbus.Signal, 4)
+	conn.Signal(signals)
+	go func() {
+		defer conn.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-signals:
+				if !ok {
+					return
+				}
+				klog.Infof("firewalld reloaded, opening the ports of zone %q again", zone)
+				f.Lock()
+				f.opened = map[string]bool{}
+				f.sync()
+				f.Unlock()
+			}
+		}
+	}()
+	return f, nil
+}
+
+// SetPorts opens the ports, and closes the others opened before.
+func (f *Firewalld) SetPorts(ports []string) {
+	f.Lock()
+	defer f.Unlock()
+	f.wantedPorts = ports
+	f.sync()
+}
+
+// SetSources adds the sources, and removes the others added before.
+func (f *Firewalld) SetSources(sources []string) {
+	f.Lock()
+	defer f.Unlock()
+	f.wantedSources = sources
+	f.sync()
+}
+
+// isPort returns whether an item of opened is a port, as port/protocol,
+// rather than a source.
+func isPort(item string) bool {
+	_, protocol, _ := strings.Cut(item, "/")
+	return protocol == "tcp" || protocol == "udp" || protocol == "sctp"
+}
+
+func (f *Firewalld) sync() {
+	zone := f.conn.Object(firewalldService, firewalldPath)
+	for _, item := range append(slices.Clone(f.wantedPorts), f.wantedSources...) {
+		if f.opened[item] {
+			continue
+		}
+		var err error
+		if isPort(item) {
+			number, protocol, _ := strings.Cut(item, "/")
+			err = zone.Call(firewalldZoneInterface+".addPort", 0, f.zone, number, protocol, int32(0)).Err
+		} else {
+			err = zone.Call(firewalldZoneInterface+".addSource", 0, f.zone, item).Err
+		}
+		if err != nil && !strings.Contains(err.Error(), "ALREADY_ENABLED") {
+			klog.Errorf("Failed to add %s to firewalld zone %q: %v", item, f.zone, err)
+			continue
+		}
+		klog.Infof("Added %s to firewalld zone %q", item, f.zone)
+		f.opened[item] = true
+	}
+	for item := range f.opened {
+		if slices.Contains(f.wantedPorts, item) || slices.Contains(f.wantedSources, item) {
+			continue
+		}
+		var err error
+		if isPort(item) {
+			number, protocol, _ := strings.Cut(item, "/")
+			err = zone.Call(firewalldZoneInterface+".removePort", 0, f.zone, number, protocol).Err
+		} else {
+			err = zone.Call(firewalldZoneInterface+".removeSource", 0, f.zone, item).Err
+		}
+		if err != nil && !strings.Contains(err.Error(), "NOT_ENABLED") {
+			klog.Errorf("Failed to remove %s from firewalld zone %q: %v", item, f.zone, err)
+			continue
+		}
+		klog.Infof("Removed %s from firewalld zone %q", item, f.zone)
+		delete(f.opened, item)
+	}
+}
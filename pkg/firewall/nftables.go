@@ -0,0 +1,84 @@
+package firewall
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+)
+
+const nftablesTable = "inet microshift"
+
+// nftCommand is the nft binary, overridden in tests.
+var nftCommand = "nft"
+
+// nftablesReset declares the table before deleting it, for the script to
+// apply whether it exists or not.
+const nftablesReset = "table " + nftablesTable + "\ndelete table " + nftablesTable + "\n"
+
+// renderNftables renders the script replacing the microshift table, whose
+// input chain drops the incoming traffic but the replies, the loopback and
+// ICMP traffic, SSH, DHCPv6, the traffic of the trusted sources and that to
+// the ports.
+func renderNftables(ports, sources []string) []byte {
+	b := &bytes.Buffer{}
+	b.WriteString(nftablesReset)
+	fmt.Fprintf(b, "table %s {\n", nftablesTable)
+	b.WriteString("\tchain input {\n")
+	b.WriteString("\t\ttype filter hook input priority filter; policy drop;\n")
+	b.WriteString("\t\tct state established,related accept\n")
+	b.WriteString("\t\tct state invalid drop\n")
+	b.WriteString("\t\tiifname \"lo\" accept\n")
+	b.WriteString("\t\tmeta l4proto { icmp, ipv6-icmp } accept\n")
+	b.WriteString("\t\ttcp dport 22 accept\n")
+	b.WriteString("\t\tip6 saddr fe80::/10 udp dport 546 accept\n")
+
+	var sources4, sources6 []string
+	for _, source := range sources {
+		ip := net.ParseIP(source)
+		if ip == nil {
+			ip, _, _ = net.ParseCIDR(source)
+		}
+		if ip.To4() != nil {
+			sources4 = append(sources4, source)
+		} else {
+			sources6 = append(sources6, source)
+		}
+	}
+	if len(sources4) != 0 {
+		fmt.Fprintf(b, "\t\tip saddr { %s } accept\n", strings.Join(sources4, ", "))
+	}
+	if len(sources6) != 0 {
+		fmt.Fprintf(b, "\t\tip6 saddr { %s } accept\n", strings.Join(sources6, ", "))
+	}
+
+	byProtocol := map[string][]string{}
+	for _, port := range ports {
+		number, protocol, _ := strings.Cut(port, "/")
+		byProtocol[protocol] = append(byProtocol[protocol], number)
+	}
+	for _, protocol := range []string{"tcp", "udp", "sctp"} {
+		if numbers := byProtocol[protocol]; len(numbers) != 0 {
+			fmt.Fprintf(b, "\t\t%s dport { %s } accept\n", protocol, strings.Join(numbers, ", "))
+		}
+	}
+	b.WriteString("\t}\n}\n")
+	return b.Bytes()
+}
+
+// applyNftables applies the nft script atomically.
+func applyNftables(ctx context.Context, script []byte) error {
+	cmd := exec.CommandContext(ctx, nftCommand, "-f", "-")
+	cmd.Stdin = bytes.NewReader(script)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to apply the nftables table %s: %w: %s", nftablesTable, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// deleteNftables deletes the microshift table, when it exists.
+func deleteNftables(ctx context.Context) error {
+	return applyNftables(ctx, []byte(nftablesReset))
+}
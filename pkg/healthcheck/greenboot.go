@@ -0,0 +1,96 @@
+package healthcheck
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	greenbootConfigFile = "/etc/greenboot/greenboot.conf"
+
+	defaultWaitTimeoutSecs = 300
+	minWaitTimeoutSecs     = 60
+	defaultMaxBootAttempts = 3
+)
+
+var (
+	waitTimeoutRegexp = regexp.MustCompile(`^[1-9][0-9]{0,3}$`)
+	maxBootsRegexp    = regexp.MustCompile(`^[1-9]$`)
+)
+
+// GreenbootWaitTimeout returns the time the health check may take on this
+// boot, like the greenboot scripts of MicroShift: MICROSHIFT_WAIT_TIMEOUT_SEC
+// of greenboot.conf, 300 by default, times the number of the boot attempt,
+// so that each attempt waits longer.
+func GreenbootWaitTimeout() time.Duration {
+	conf := readGreenbootConfig(greenbootConfigFile)
+	bootCounter := -1
+	if out, err := exec.Command("grub2-editenv", "-", "list").Output(); err == nil {
+		bootCounter = parseBootCounter(out)
+	}
+	return time.Duration(waitTimeoutSecs(conf["MICROSHIFT_WAIT_TIMEOUT_SEC"], conf["GREENBOOT_MAX_BOOT_ATTEMPTS"], bootCounter)) * time.Second
+}
+
+// waitTimeoutSecs computes the timeout from the greenboot settings and the
+// boot counter of GRUB, -1 when it is not set.
+func waitTimeoutSecs(baseSetting, maxBootsSetting string, bootCounter int) int {
+	base := defaultWaitTimeoutSecs
+	if waitTimeoutRegexp.MatchString(baseSetting) {
+		base, _ = strconv.Atoi(baseSetting)
+	}
+	base = max(base, minWaitTimeoutSecs)
+
+	maxBoots := defaultMaxBootAttempts
+	if maxBootsRegexp.MatchString(maxBootsSetting) {
+		maxBoots, _ = strconv.Atoi(maxBootsSetting)
+	}
+
+	if bootCounter < 0 {
+		bootCounter = maxBoots - 1
+	}
+	timeout := base * (maxBoots - bootCounter)
+	if timeout <= 0 {
+		timeout = base
+	}
+	return timeout
+}
+
+// readGreenbootConfig reads the KEY=VALUE settings of the shell file of
+// greenboot.
+func readGreenbootConfig(path string) map[string]string {
+	conf := map[string]string{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return conf
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		conf[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return conf
+}
+
+func parseBootCounter(grubEnv []byte) int {
+	for _, line := range strings.Split(string(grubEnv), "\n") {
+		if value, ok := strings.CutPrefix(line, "boot_counter="); ok {
+			if counter, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+				return counter
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,52 @@
+package healthcheck
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitTimeoutSecs(t *testing.T) {
+	tests := []struct {
+		name        string
+		base        string
+		maxBoots    string
+		bootCounter int
+		expected    int
+	}{
+		{name: "defaults without boot counter", bootCounter: -1, expected: 300},
+		{name: "first of three attempts", bootCounter: 2, expected: 300},
+		{name: "last of three attempts", bootCounter: 0, expected: 900},
+		{name: "custom base", base: "120", bootCounter: 1, expected: 240},
+		{name: "base below the minimum", base: "30", bootCounter: -1, expected: 60},
+		{name: "invalid base", base: "ten", bootCounter: -1, expected: 300},
+		{name: "custom attempts", maxBoots: "5", bootCounter: 0, expected: 1500},
+		{name: "invalid attempts", maxBoots: "10", bootCounter: 0, expected: 900},
+		{name: "counter over the attempts", bootCounter: 4, expected: 300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, waitTimeoutSecs(tt.base, tt.maxBoots, tt.bootCounter))
+		})
+	}
+}
+
+func TestReadGreenbootConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "greenboot.conf")
+	assert.NoError(t, os.WriteFile(path, []byte(`# Greenboot configuration
+GREENBOOT_MAX_BOOT_ATTEMPTS=2
+MICROSHIFT_WAIT_TIMEOUT_SEC="600"
+`), 0600))
+	assert.Equal(t, map[string]string{
+		"GREENBOOT_MAX_BOOT_ATTEMPTS": "2",
+		"MICROSHIFT_WAIT_TIMEOUT_SEC": "600",
+	}, readGreenbootConfig(path))
+	assert.Empty(t, readGreenbootConfig(filepath.Join(t.TempDir(), "missing.conf")))
+}
+
+func TestParseBootCounter(t *testing.T) {
+	assert.Equal(t, 1, parseBootCounter([]byte("saved_entry=ostree-1\nboot_counter=1\nboot_success=0\n")))
+	assert.Equal(t, -1, parseBootCounter([]byte("saved_entry=ostree-1\n")))
+}
@@ -0,0 +1,160 @@
+// Package healthcheck verifies that MicroShift and the workloads running on
+// it are healthy, for greenboot to roll back the system when they are not
+// after an upgrade.
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/openshift/microshift/pkg/admin/prerun"
+	"github.com/openshift/microshift/pkg/config"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// ExitCode tells why the health check failed, so that the greenboot scripts
+// and the logs of a rolled back device show what to look at.
+type ExitCode int
+
+const (
+	ExitHealthy ExitCode = 0
+	// ExitError is returned when the health check could not run, e.g.
+	// because of an invalid configuration.
+	ExitError ExitCode = 1
+	// ExitServiceFailed is returned when microshift.service failed or did
+	// not become active.
+	ExitServiceFailed ExitCode = 2
+	// ExitUpgradeRefused is returned when MicroShift refused to start on
+	// the data of the previous version, a rollback restores a compatible
+	// version.
+	ExitUpgradeRefused ExitCode = 3
+	// ExitAPIUnhealthy is returned when the readyz or livez endpoints of
+	// the kube-apiserver did not pass.
+	ExitAPIUnhealthy ExitCode = 4
+	// ExitCoreWorkloadsNotReady is returned when the workloads of
+	// MicroShift, e.g. the CNI or the router, did not become ready.
+	ExitCoreWorkloadsNotReady ExitCode = 5
+	// ExitUserWorkloadsNotReady is returned when the workloads listed in
+	// healthCheck.workloads did not become ready.
+	ExitUserWorkloadsNotReady ExitCode = 6
+)
+
+const pollInterval = 5 * time.Second
+
+// Options selects the checks run by Run.
+type Options struct {
+	// Timeout is the time all of the checks may take.
+	Timeout time.Duration
+	// Core checks the workloads of MicroShift.
+	Core bool
+	// User checks the workloads listed in healthCheck.workloads.
+	User bool
+}
+
+// Result is the outcome of Run. Message explains the failure.
+type Result struct {
+	Code    ExitCode
+	Message string
+}
+
+// Run waits for MicroShift and the workloads to be healthy, up to
+// opts.Timeout, and returns the first check that did not pass.
+func Run(ctx context.Context, cfg *config.Config, opts Options) Result {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	// There is nothing to wait for when the upgrade was refused.
+	reason, err := prerun.UpgradeRefusedOnCurrentBoot()
+	if err != nil {
+		return Result{ExitError, err.Error()}
+	}
+	if reason != "" {
+		return Result{ExitUpgradeRefused, fmt.Sprintf("MicroShift refused to start on the existing data: %s", reason)}
+	}
+
+	if err := waitForService(ctx); err != nil {
+		return Result{ExitServiceFailed, err.Error()}
+	}
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", cfg.KubeConfigPath(config.KubeAdmin))
+	if err != nil {
+		return Result{ExitError, fmt.Sprintf("failed to load the kubeconfig: %v", err)}
+	}
+	client, err := kubernetes.NewForConfig(rest.AddUserAgent(restConfig, "microshift-healthcheck"))
+	if err != nil {
+		return Result{ExitError, fmt.Sprintf("failed to create the client: %v", err)}
+	}
+
+	if err := WaitForAPI(ctx, client); err != nil {
+		return Result{ExitAPIUnhealthy, err.Error()}
+	}
+	if opts.Core {
+		if err := waitForWorkloads(ctx, client, CoreWorkloads(cfg)); err != nil {
+			return Result{ExitCoreWorkloadsNotReady, fmt.Sprintf("MicroShift workloads are not ready: %v", err)}
+		}
+	}
+	if opts.User {
+		workloads := make([]Workloads, 0, len(cfg.HealthCheck.Workloads))
+		for _, w := range cfg.HealthCheck.Workloads {
+			workloads = append(workloads, Workloads{HealthCheckWorkload: w, Required: true})
+		}
+		if err := waitForWorkloads(ctx, client, workloads); err != nil {
+			return Result{ExitUserWorkloadsNotReady, fmt.Sprintf("user workloads are not ready: %v", err)}
+		}
+	}
+	return Result{ExitHealthy, "MicroShift is healthy"}
+}
+
+// waitForService waits for microshift.service to be active, and fails right
+// away when it failed.
+func waitForService(ctx context.Context) error {
+	state := ""
+	err := wait.PollUntilContextCancel(ctx, time.Second, true, func(ctx context.Context) (bool, error) {
+		out, _ := exec.CommandContext(ctx, "systemctl", "show", "-p", "ActiveState", "--value", "microshift.service").Output()
+		state = strings.TrimSpace(string(out))
+		if state == "failed" {
+			return false, errors.New("microshift.service failed")
+		}
+		return state == "active", nil
+	})
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("timed out waiting for microshift.service to be active, it is %q", state)
+	}
+	return err
+}
+
+// WaitForAPI waits for the readyz and livez endpoints of the kube-apiserver
+// to pass.
+func WaitForAPI(ctx context.Context, client kubernetes.Interface) error {
+	var lastErr error
+	err := wait.PollUntilContextCancel(ctx, pollInterval, true, func(ctx context.Context) (bool, error) {
+		for _, path := range []string{"/readyz", "/livez"} {
+			if _, err := client.Discovery().RESTClient().Get().AbsPath(path).DoRaw(ctx); err != nil {
+				lastErr = fmt.Errorf("%s did not pass: %w", path, err)
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		if lastErr != nil {
+			return fmt.Errorf("timed out waiting for the API server to be healthy: %w", lastErr)
+		}
+		return fmt.Errorf("timed out waiting for the API server to be healthy: %w", err)
+	}
+	return nil
+}
+
+// ServiceEnabled returns whether microshift.service is enabled, the health
+// check passes on systems where MicroShift is not meant to run.
+func ServiceEnabled() bool {
+	out, _ := exec.Command("systemctl", "is-enabled", "microshift.service").Output()
+	return strings.TrimSpace(string(out)) == "enabled"
+}
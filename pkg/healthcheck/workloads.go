@@ -0,0 +1,198 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/openshift/microshift/pkg/config"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Workloads are workloads of a namespace to check.
+type Workloads struct {
+	config.HealthCheckWorkload
+	// Required is whether the namespace must have workloads, when none
+	// are named.
+	Required bool
+}
+
+// CoreWorkloads returns the workloads of MicroShift deployed with cfg.
+func CoreWorkloads(cfg *config.Config) []Workloads {
+	workloads := []Workloads{}
+	if cfg.Components.ServiceCA.Status == config.ServiceCAStatusManaged {
+		workloads = append(workloads, Workloads{HealthCheckWorkload: config.HealthCheckWorkload{Namespace: "openshift-service-ca"}, Required: true})
+	}
+	workloads = append(workloads, Workloads{HealthCheckWorkload: config.HealthCheckWorkload{Namespace: "openshift-dns"}, Required: true})
+	if cfg.Network.IsOVNK() {
+		workloads = append(workloads, Workloads{HealthCheckWorkload: config.HealthCheckWorkload{Namespace: "openshift-ovn-kubernetes"}, Required: true})
+	}
+	if cfg.Network.Multus.Status == config.MultusStatusManaged {
+		workloads = append(workloads, Workloads{HealthCheckWorkload: config.HealthCheckWorkload{Namespace: "openshift-multus"}, Required: true})
+	}
+	if cfg.Ingress.Status == config.StatusManaged {
+		workloads = append(workloads, Workloads{HealthCheckWorkload: config.HealthCheckWorkload{Namespace: "openshift-ingress"}, Required: true})
+	}
+	if cfg.Storage.IsEnabled() {
+		// LVMS is only deployed when the host has a volume group, and
+		// the CSI snapshot components may be disabled.
+		workloads = append(workloads,
+			Workloads{HealthCheckWorkload: config.HealthCheckWorkload{Namespace: "openshift-storage"}},
+			Workloads{HealthCheckWorkload: config.HealthCheckWorkload{Namespace: "kube-system"}})
+	}
+	return workloads
+}
+
+// waitForWorkloads waits for all of the workloads to be ready, and returns
+// the ones that are not when ctx is done.
+func waitForWorkloads(ctx context.Context, client kubernetes.Interface, workloads []Workloads) error {
+	var notReady []string
+	err := wait.PollUntilContextCancel(ctx, pollInterval, true, func(ctx context.Context) (bool, error) {
+		notReady = nil
+		for _, w := range workloads {
+			n, err := notReadyWorkloads(ctx, client, w)
+			if err != nil {
+				notReady = append(notReady, err.Error())
+				continue
+			}
+			notReady = append(notReady, n...)
+		}
+		return len(notReady) == 0, nil
+	})
+	if err != nil {
+		if len(notReady) > 0 {
+			return fmt.Errorf("%s", strings.Join(notReady, ", "))
+		}
+		return err
+	}
+	return nil
+}
+
+// notReadyWorkloads returns the workloads of w that are not ready. All of
+// the workloads of the namespace are checked when none are named.
+func notReadyWorkloads(ctx context.Context, client kubernetes.Interface, w Workloads) ([]string, error) {
+	apps := client.AppsV1()
+	ns := w.Namespace
+	var deployments []appsv1.Deployment
+	var daemonSets []appsv1.DaemonSet
+	var statefulSets []appsv1.StatefulSet
+	notReady := []string{}
+
+	if len(w.Deployments) == 0 && len(w.DaemonSets) == 0 && len(w.StatefulSets) == 0 {
+		deploymentList, err := apps.Deployments(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the deployments of %s: %w", ns, err)
+		}
+		daemonSetList, err := apps.DaemonSets(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the daemonsets of %s: %w", ns, err)
+		}
+		statefulSetList, err := apps.StatefulSets(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the statefulsets of %s: %w", ns, err)
+		}
+		deployments, daemonSets, statefulSets = deploymentList.Items, daemonSetList.Items, statefulSetList.Items
+		if w.Required && len(deployments)+len(daemonSets)+len(statefulSets) == 0 {
+			return []string{fmt.Sprintf("no workloads in namespace %s", ns)}, nil
+		}
+	}
+
+	for _, name := range w.Deployments {
+		d, err := apps.Deployments(ns).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			notReady = append(notReady, fmt.Sprintf("deployment %s/%s not found", ns, name))
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		deployments = append(deployments, *d)
+	}
+	for _, name := range w.DaemonSets {
+		ds, err := apps.DaemonSets(ns).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			notReady = append(notReady, fmt.Sprintf("daemonset %s/%s not found", ns, name))
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		daemonSets = append(daemonSets, *ds)
+	}
+	for _, name := range w.StatefulSets {
+		sts, err := apps.StatefulSets(ns).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			notReady = append(notReady, fmt.Sprintf("statefulset %s/%s not found", ns, name))
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		statefulSets = append(statefulSets, *sts)
+	}
+
+	for _, d := range deployments {
+		if msg := deploymentNotReady(&d); msg != "" {
+			notReady = append(notReady, fmt.Sprintf("deployment %s/%s %s", ns, d.Name, msg))
+		}
+	}
+	for _, ds := range daemonSets {
+		if msg := daemonSetNotReady(&ds); msg != "" {
+			notReady = append(notReady, fmt.Sprintf("daemonset %s/%s %s", ns, ds.Name, msg))
+		}
+	}
+	for _, sts := range statefulSets {
+		if msg := statefulSetNotReady(&sts); msg != "" {
+			notReady = append(notReady, fmt.Sprintf("statefulset %s/%s %s", ns, sts.Name, msg))
+		}
+	}
+	return notReady, nil
+}
+
+// deploymentNotReady returns why the rollout of d is not complete, like
+// kubectl rollout status, or an empty string if it is.
+func deploymentNotReady(d *appsv1.Deployment) string {
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	switch {
+	case d.Status.ObservedGeneration < d.Generation:
+		return "is not observed yet"
+	case d.Status.UpdatedReplicas < replicas:
+		return fmt.Sprintf("has %d/%d updated replicas", d.Status.UpdatedReplicas, replicas)
+	case d.Status.AvailableReplicas < replicas:
+		return fmt.Sprintf("has %d/%d available replicas", d.Status.AvailableReplicas, replicas)
+	}
+	return ""
+}
+
+func daemonSetNotReady(ds *appsv1.DaemonSet) string {
+	desired := ds.Status.DesiredNumberScheduled
+	switch {
+	case ds.Status.ObservedGeneration < ds.Generation:
+		return "is not observed yet"
+	case ds.Status.UpdatedNumberScheduled < desired:
+		return fmt.Sprintf("has %d/%d updated pods", ds.Status.UpdatedNumberScheduled, desired)
+	case ds.Status.NumberAvailable < desired:
+		return fmt.Sprintf("has %d/%d available pods", ds.Status.NumberAvailable, desired)
+	}
+	return ""
+}
+
+func statefulSetNotReady(sts *appsv1.StatefulSet) string {
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	switch {
+	case sts.Status.ObservedGeneration < sts.Generation:
+		return "is not observed yet"
+	case sts.Spec.UpdateStrategy.Type != appsv1.OnDeleteStatefulSetStrategyType && sts.Status.UpdatedReplicas < replicas:
+		return fmt.Sprintf("has %d/%d updated replicas", sts.Status.UpdatedReplicas, replicas)
+	case sts.Status.ReadyReplicas < replicas:
+		return fmt.Sprintf("has %d/%d ready replicas", sts.Status.ReadyReplicas, replicas)
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+package healthcheck
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+)
+
+func TestNotReadyWorkloads(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "my-app", Name: "frontend", Generation: 2},
+			Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](2)},
+			Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "my-app", Name: "backend", Generation: 1},
+			Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](3)},
+			Status:     appsv1.DeploymentStatus{ObservedGeneration: 1, UpdatedReplicas: 3, AvailableReplicas: 1},
+		},
+		&appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "my-app", Name: "agent", Generation: 3},
+			Status:     appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 1},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "my-db", Name: "db"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: ptr.To[int32](1)},
+			Status:     appsv1.StatefulSetStatus{UpdatedReplicas: 1, ReadyReplicas: 1},
+		},
+	)
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		workloads Workloads
+		expected  []string
+	}{
+		{
+			name:      "named ready deployment",
+			workloads: Workloads{HealthCheckWorkload: config.HealthCheckWorkload{Namespace: "my-app", Deployments: []string{"frontend"}}},
+			expected:  []string{},
+		},
+		{
+			name: "named missing workloads",
+			workloads: Workloads{HealthCheckWorkload: config.HealthCheckWorkload{
+				Namespace: "my-app", DaemonSets: []string{"missing"}, StatefulSets: []string{"db"},
+			}},
+			expected: []string{"daemonset my-app/missing not found", "statefulset my-app/db not found"},
+		},
+		{
+			name:      "whole namespace",
+			workloads: Workloads{HealthCheckWorkload: config.HealthCheckWorkload{Namespace: "my-app"}},
+			expected: []string{
+				"deployment my-app/backend has 1/3 available replicas",
+				"daemonset my-app/agent is not observed yet",
+			},
+		},
+		{
+			name:      "ready statefulset",
+			workloads: Workloads{HealthCheckWorkload: config.HealthCheckWorkload{Namespace: "my-db"}, Required: true},
+			expected:  []string{},
+		},
+		{
+			name:      "empty optional namespace",
+			workloads: Workloads{HealthCheckWorkload: config.HealthCheckWorkload{Namespace: "openshift-storage"}},
+			expected:  []string{},
+		},
+		{
+			name:      "empty required namespace",
+			workloads: Workloads{HealthCheckWorkload: config.HealthCheckWorkload{Namespace: "openshift-dns"}, Required: true},
+			expected:  []string{"no workloads in namespace openshift-dns"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notReady, err := notReadyWorkloads(ctx, client, tt.workloads)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, notReady)
+		})
+	}
+}
+
+func TestCoreWorkloads(t *testing.T) {
+	namespaces := func(workloads []Workloads) []string {
+		ns := []string{}
+		for _, w := range workloads {
+			ns = append(ns, w.Namespace)
+		}
+		return ns
+	}
+
+	cfg := config.NewDefault()
+	assert.Equal(t, []string{"openshift-service-ca", "openshift-dns", "openshift-ovn-kubernetes", "openshift-ingress", "openshift-storage", "kube-system"},
+		namespaces(CoreWorkloads(cfg)))
+
+	cfg.Network.CNIPlugin = config.CniPluginNone
+	cfg.Ingress.Status = config.StatusRemoved
+	cfg.Storage.Driver = config.CsiDriverNone
+	assert.Equal(t, []string{"openshift-service-ca", "openshift-dns"}, namespaces(CoreWorkloads(cfg)))
+
+	cfg.Components.ServiceCA.Status = config.ServiceCAStatusRemoved
+	assert.Equal(t, []string{"openshift-dns"}, namespaces(CoreWorkloads(cfg)))
+}
@@ -0,0 +1,220 @@
+package join
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/util"
+	"github.com/openshift/microshift/pkg/util/cryptomaterial"
+
+	"k8s.io/klog/v2"
+)
+
+const requestTimeout = 30 * time.Second
+
+// Joined returns whether the node already joined a control plane.
+func Joined() (bool, error) {
+	return util.PathExists(clusterPath())
+}
+
+// Join requests the certificates of the kubelet of the node from the join
+// API at joinURL, authenticated with token, and writes them and their keys
+// into the data directory with the settings of the cluster. The join API is
+// trusted once one of the CA bundles it serves holds the CA whose hash is
+// caCertHash, see CACertHash.
+func Join(ctx context.Context, cfg *config.Config, joinURL, token, caCertHash string) error {
+	u, err := url.Parse(joinURL)
+	if err != nil {
+		return fmt.Errorf("invalid join URL %q: %w", joinURL, err)
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("invalid join URL %q: the scheme must be https", joinURL)
+	}
+	if err := validateCACertHash(caCertHash); err != nil {
+		return err
+	}
+	baseURL := strings.TrimSuffix(joinURL, "/")
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	// The CA bundles are public, and fetched without verifying the join
+	// API: they are only trusted once they hold the pinned CA, and the
+	// join request itself is sent to the join API verified with it.
+	bundles := CABundles{}
+	insecure := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS12}}} //nolint:gosec
+	if err := do(ctx, insecure, http.MethodGet, baseURL+caBundlesPath, "", nil, &bundles); err != nil {
+		return fmt.Errorf("failed to get the CA bundles of the control plane: %w", err)
+	}
+	pool, err := pinnedCAs(bundles.APIServerCA, caCertHash)
+	if err != nil {
+		return err
+	}
+
+	clientKey, clientCSR, err := newCSR(cfg.CanonicalNodeName())
+	if err != nil {
+		return err
+	}
+	servingKey, servingCSR, err := newCSR(cfg.CanonicalNodeName())
+	if err != nil {
+		return err
+	}
+	req := Request{
+		NodeName:          cfg.CanonicalNodeName(),
+		NodeIP:            cfg.Node.NodeIP,
+		KubeletClientCSR:  clientCSR,
+		KubeletServingCSR: servingCSR,
+	}
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}}}
+	klog.InfoS("Joining the control plane", "url", joinURL, "node", cfg.CanonicalNodeName())
+	resp := Response{}
+	if err := do(ctx, client, http.MethodPost, baseURL+joinPath, token, req, &resp); err != nil {
+		return fmt.Errorf("failed to join the control plane: %w", err)
+	}
+
+	if err := writeNodeFiles(cfg, &resp, clientKey, servingKey); err != nil {
+		return fmt.Errorf("failed to write the certificates of the node: %w", err)
+	}
+	klog.InfoS("Joined the control plane", "apiServer", resp.Cluster.APIServerURL)
+	return nil
+}
+
+// do sends a request to the join API, with body encoded and the response
+// decoded into out.
+func do(ctx context.Context, client *http.Client, method, url, token string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxRequestSize))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// newCSR returns a new PEM private key and a certificate signing request of
+// it. The control plane sets the subject of the certificate.
+func newCSR(nodeName string) ([]byte, []byte, error) {
+	_, key, err := crypto.NewKeyPair()
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: nodeName}}, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err := crypto.EncodeKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return keyPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), nil
+}
+
+// writeNodeFiles writes the certificates and keys of the kubelet where the kubelet of
+// a control plane finds them, and then the settings of the cluster, which
+// mark the node as joined.
+func writeNodeFiles(cfg *config.Config, resp *Response, clientKey, servingKey []byte) error {
+	certsDir := cryptomaterial.CertsDirectory(config.DataDir)
+	servingDir := cryptomaterial.KubeletServingCertDir(certsDir)
+	files := []struct {
+		path string
+		data []byte
+	}{
+		{cryptomaterial.ServingCertPath(servingDir), resp.KubeletServingCert},
+		{cryptomaterial.ServingKeyPath(servingDir), servingKey},
+		{cryptomaterial.KubeletClientCAPath(certsDir), resp.KubeletClientCA},
+	}
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+			return err
+		}
+		if err := os.WriteFile(f.path, f.data, 0600); err != nil {
+			return err
+		}
+	}
+
+	if err := util.KubeConfigWithClientCerts(
+		cfg.KubeConfigPath(config.Kubelet),
+		resp.Cluster.APIServerURL,
+		resp.Cluster.APIServerCA,
+		resp.KubeletClientCert,
+		clientKey,
+	); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(resp.Cluster)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(clusterPath()), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(clusterPath(), data, 0600)
+}
+
+// LoadCluster returns the settings of the cluster the node joined.
+func LoadCluster() (*Cluster, error) {
+	data, err := os.ReadFile(clusterPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("the node did not join a control plane yet")
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &Cluster{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", clusterPath(), err)
+	}
+	return c, nil
+}
+
+// Apply sets the settings of the cluster in the configuration of the node,
+// for the kubelet to use the same networks as the control plane.
+func (c *Cluster) Apply(cfg *config.Config) {
+	cfg.ApiServer.URL = c.APIServerURL
+	cfg.Network.ClusterNetwork = c.ClusterNetwork
+	cfg.Network.ServiceNetwork = c.ServiceNetwork
+	cfg.Network.DNS = c.ClusterDNS
+}
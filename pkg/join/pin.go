@@ -0,0 +1,72 @@
+package join
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/util/cryptomaterial"
+
+	"k8s.io/client-go/util/cert"
+)
+
+const caCertHashPrefix = "sha256:"
+
+// CACertHash returns the hash of the public key of the CA certificate
+// caCert, in the format of the --discovery-token-ca-cert-hash of kubeadm.
+func CACertHash(caCert *x509.Certificate) string {
+	sum := sha256.Sum256(caCert.RawSubjectPublicKeyInfo)
+	return caCertHashPrefix + hex.EncodeToString(sum[:])
+}
+
+// ServerCACertHash returns the hash of the CA signing the serving
+// certificate of the join API, which the workers pin.
+func ServerCACertHash() (string, error) {
+	path := cryptomaterial.CACertPath(cryptomaterial.KubeAPIServerServiceNetworkSigner(cryptomaterial.CertsDirectory(config.DataDir)))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	certs, err := cert.ParseCertsPEM(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %q: %w", path, err)
+	}
+	return CACertHash(certs[0]), nil
+}
+
+// validateCACertHash checks the format of a CA certificate hash.
+func validateCACertHash(hash string) error {
+	h, ok := strings.CutPrefix(hash, caCertHashPrefix)
+	if !ok {
+		return fmt.Errorf("invalid CA certificate hash %q: must start with %q", hash, caCertHashPrefix)
+	}
+	if b, err := hex.DecodeString(h); err != nil || len(b) != sha256.Size {
+		return fmt.Errorf("invalid CA certificate hash %q: must be a hex encoded SHA-256", hash)
+	}
+	return nil
+}
+
+// pinnedCAs returns the certificates of the PEM bundle whose hash is
+// caCertHash.
+func pinnedCAs(bundle []byte, caCertHash string) (*x509.CertPool, error) {
+	certs, err := cert.ParseCertsPEM(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	found := false
+	for _, c := range certs {
+		if strings.EqualFold(CACertHash(c), caCertHash) {
+			pool.AddCert(c)
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no CA of the control plane matches the hash %s", caCertHash)
+	}
+	return pool, nil
+}
@@ -0,0 +1,354 @@
+package join
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/util"
+	"github.com/openshift/microshift/pkg/util/cryptomaterial"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+)
+
+const maxRequestSize = 64 * 1024
+
+// errForbidden is returned for the join requests of a node the token cannot
+// join as.
+var errForbidden = errors.New("forbidden")
+
+// Server serves the join API on the control plane.
+type Server struct {
+	cfg    *config.Config
+	tokens *TokenStore
+	// certsDir is where the signers and CA bundles are read from.
+	certsDir string
+	// signMu serializes the use of the serial file of the signer.
+	signMu sync.Mutex
+	// client looks up the registered nodes.
+	client kubernetes.Interface
+}
+
+func NewServer(cfg *config.Config) *Server {
+	return &Server{
+		cfg:      cfg,
+		tokens:   NewTokenStore(),
+		certsDir: cryptomaterial.CertsDirectory(config.DataDir),
+	}
+}
+
+func (s *Server) Name() string           { return "join-server" }
+func (s *Server) Dependencies() []string { return []string{"kube-apiserver"} }
+
+func (s *Server) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	defer close(stopped)
+
+	// The serving certificate of the API server for the service network
+	// holds the advertise address, which is the node IP in multi-node mode.
+	// It is loaded again on every handshake, for it to be rotated.
+	servingDir := cryptomaterial.KubeAPIServerServiceNetworkServingCertDir(s.certsDir)
+	getCertificate := util.ReloadingKeyPair(cryptomaterial.ServingCertPath(servingDir), cryptomaterial.ServingKeyPath(servingDir))
+	if _, err := getCertificate(nil); err != nil {
+		return fmt.Errorf("failed to load the serving certificate: %w", err)
+	}
+
+	restCfg, err := clientcmd.BuildConfigFromFlags("", s.cfg.KubeConfigPath(config.KubeAdmin))
+	if err != nil {
+		return fmt.Errorf("failed to load the kubeconfig: %w", err)
+	}
+	s.client, err = kubernetes.NewForConfig(rest.AddUserAgent(restCfg, s.Name()))
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort("", strconv.Itoa(ServerPort)))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", ServerPort, err)
+	}
+	srv := &http.Server{
+		Handler:           s.handler(),
+		TLSConfig:         &tls.Config{GetCertificate: getCertificate, MinVersion: tls.VersionTLS12},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	klog.Infof("%s listening on port %d", s.Name(), ServerPort)
+	close(ready)
+	if err := srv.ServeTLS(listener, "", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return ctx.Err()
+}
+
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+caBundlesPath, func(w http.ResponseWriter, r *http.Request) {
+		bundles, err := s.caBundles()
+		if err != nil {
+			klog.ErrorS(err, "Failed to read the CA bundles")
+			http.Error(w, "failed to read the CA bundles", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(bundles); err != nil {
+			klog.ErrorS(err, "Failed to write the CA bundles")
+		}
+	})
+	mux.HandleFunc("POST "+joinPath, func(w http.ResponseWriter, r *http.Request) {
+		token, err := s.authenticate(r)
+		if err != nil {
+			klog.Warningf("Refused join request from %s: %v", r.RemoteAddr, err)
+			http.Error(w, "invalid join token", http.StatusUnauthorized)
+			return
+		}
+
+		req := Request{}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid join request: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err := s.validate(req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.authorize(r.Context(), token, req.NodeName); errors.Is(err, errForbidden) {
+			klog.Warningf("Refused join request from %s: %v", r.RemoteAddr, err)
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		} else if err != nil {
+			klog.ErrorS(err, "Failed to authorize join request", "node", req.NodeName)
+			http.Error(w, "failed to authorize the join request", http.StatusInternalServerError)
+			return
+		}
+
+		resp, err := s.join(req)
+		var csrErr *csrError
+		if errors.As(err, &csrErr) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			klog.ErrorS(err, "Failed to join node", "node", req.NodeName)
+			http.Error(w, "failed to sign the certificates of the node", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			klog.ErrorS(err, "Failed to write join response", "node", req.NodeName)
+			return
+		}
+		klog.InfoS("Node joined", "node", req.NodeName, "ip", req.NodeIP, "token", token.ID, "remote", r.RemoteAddr)
+	})
+	return mux
+}
+
+func (s *Server) validate(req Request) error {
+	if errs := validation.IsDNS1123Subdomain(req.NodeName); len(errs) > 0 {
+		return fmt.Errorf("invalid node name %q: %s", req.NodeName, strings.Join(errs, ", "))
+	}
+	if req.NodeName == s.cfg.CanonicalNodeName() {
+		return fmt.Errorf("node name %q is the one of the control plane", req.NodeName)
+	}
+	if net.ParseIP(req.NodeIP) == nil {
+		return fmt.Errorf("invalid node IP %q", req.NodeIP)
+	}
+	return nil
+}
+
+// authorize binds the token to the node on its first use, for the token to
+// only ever join as that node, e.g. after the data of the worker is removed.
+// A token which is not bound yet cannot join as a node which is registered
+// already, for it to never impersonate another node.
+func (s *Server) authorize(ctx context.Context, token Token, nodeName string) error {
+	if token.NodeName == "" {
+		_, err := s.client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+		if err == nil {
+			return fmt.Errorf("%w: node %q is already registered", errForbidden, nodeName)
+		}
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to look up node %q: %w", nodeName, err)
+		}
+	}
+	return s.tokens.Bind(token.ID, nodeName)
+}
+
+// join signs the certificate signing requests of the kubelet of the node
+// with the signer of the kubelet certificates of the control plane. The
+// subjects of the requests are ignored, the ones of the certificates only
+// depend on the node name and IP.
+func (s *Server) join(req Request) (*Response, error) {
+	clientKey, err := csrPublicKey(req.KubeletClientCSR)
+	if err != nil {
+		return nil, &csrError{"client", err}
+	}
+	servingKey, err := csrPublicKey(req.KubeletServingCSR)
+	if err != nil {
+		return nil, &csrError{"serving", err}
+	}
+
+	signerDir := cryptomaterial.CSRSignerCertDir(s.certsDir)
+	lifetime := time.Duration(*s.cfg.Certificates.ShortLivedValidityDays) * 24 * time.Hour
+	backdate := s.cfg.Certificates.Backdate()
+
+	s.signMu.Lock()
+	defer s.signMu.Unlock()
+
+	ca, err := crypto.GetCA(cryptomaterial.CACertPath(signerDir), cryptomaterial.CAKeyPath(signerDir), cryptomaterial.CASerialsPath(signerDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the signer: %w", err)
+	}
+	// userinfo per https://kubernetes.io/docs/reference/access-authn-authz/node/#overview
+	clientSubject := crypto.UserToSubject(&user.DefaultInfo{Name: "system:node:" + req.NodeName, Groups: []string{"system:nodes"}})
+	clientTemplate := crypto.NewClientCertificateTemplateForDuration(clientSubject, lifetime, time.Now)
+	clientTemplate.NotBefore = clientTemplate.NotBefore.Add(-backdate)
+	client, err := ca.SignCertificate(clientTemplate, clientKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign the client certificate: %w", err)
+	}
+	serving, err := ca.SignCertificate(servingCertificateTemplate(req, lifetime, backdate), servingKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign the serving certificate: %w", err)
+	}
+
+	resp := &Response{}
+	if resp.KubeletClientCert, err = crypto.EncodeCertificates(client); err != nil {
+		return nil, err
+	}
+	if resp.KubeletServingCert, err = crypto.EncodeCertificates(serving); err != nil {
+		return nil, err
+	}
+	if resp.KubeletClientCA, err = os.ReadFile(cryptomaterial.KubeletClientCAPath(s.certsDir)); err != nil {
+		return nil, err
+	}
+	resp.Cluster, err = s.cluster()
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// csrError is returned by join for the invalid certificate signing requests.
+type csrError struct {
+	name string
+	err  error
+}
+
+func (e *csrError) Error() string {
+	return fmt.Sprintf("invalid %s certificate signing request: %v", e.name, e.err)
+}
+
+// csrPublicKey returns the public key of the PEM certificate signing request
+// csrPEM, once its signature is verified.
+func csrPublicKey(csrPEM []byte) (any, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("no PEM certificate request found")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, err
+	}
+	return csr.PublicKey, nil
+}
+
+func servingCertificateTemplate(req Request, lifetime, backdate time.Duration) *x509.Certificate {
+	now := time.Now()
+	t := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: req.NodeName},
+		NotBefore:             now.Add(-backdate - time.Second),
+		NotAfter:              now.Add(lifetime),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	t.IPAddresses, t.DNSNames = crypto.IPAddressesDNSNames([]string{req.NodeName, req.NodeIP})
+	return t
+}
+
+func (s *Server) caBundles() (*CABundles, error) {
+	apiServerCA, err := s.apiServerCA()
+	if err != nil {
+		return nil, err
+	}
+	kubeletClientCA, err := os.ReadFile(cryptomaterial.KubeletClientCAPath(s.certsDir))
+	if err != nil {
+		return nil, err
+	}
+	return &CABundles{APIServerCA: apiServerCA, KubeletClientCA: kubeletClientCA}, nil
+}
+
+// apiServerCA returns the bundle of the signers of the serving certificates
+// of the API server the workers connect to.
+func (s *Server) apiServerCA() ([]byte, error) {
+	apiServerCA := []byte{}
+	for _, signerDir := range []string{
+		cryptomaterial.KubeAPIServerServiceNetworkSigner(s.certsDir),
+		cryptomaterial.KubeAPIServerExternalSigner(s.certsDir),
+	} {
+		ca, err := os.ReadFile(cryptomaterial.CACertPath(signerDir))
+		if err != nil {
+			return nil, err
+		}
+		apiServerCA = append(apiServerCA, ca...)
+	}
+	return apiServerCA, nil
+}
+
+func (s *Server) cluster() (Cluster, error) {
+	apiServerCA, err := s.apiServerCA()
+	if err != nil {
+		return Cluster{}, err
+	}
+
+	// The workers follow the virtual IP of an active/passive pair of
+	// control planes.
+	host := s.cfg.Node.NodeIP
+	if s.cfg.VirtualIP.Status == config.VirtualIPStatusEnabled {
+		host = s.cfg.VirtualIP.Address
+	}
+	return Cluster{
+		APIServerURL:   "https://" + net.JoinHostPort(host, strconv.Itoa(s.cfg.ApiServer.Port)),
+		APIServerCA:    apiServerCA,
+		ClusterNetwork: s.cfg.Network.ClusterNetwork,
+		ServiceNetwork: s.cfg.Network.ServiceNetwork,
+		ClusterDNS:     s.cfg.Network.DNS,
+	}, nil
+}
+
+// authenticate returns the join token of the request.
+func (s *Server) authenticate(r *http.Request) (Token, error) {
+	t, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return Token{}, fmt.Errorf("no bearer token")
+	}
+	return s.tokens.Authenticate(t)
+}
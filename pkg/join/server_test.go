@@ -0,0 +1,192 @@
+package join
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/util/cryptomaterial"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+)
+
+func newTestServer(t *testing.T) *Server {
+	certsDir := t.TempDir()
+	for _, dir := range []string{
+		cryptomaterial.CSRSignerCertDir(certsDir),
+		cryptomaterial.KubeAPIServerServiceNetworkSigner(certsDir),
+		cryptomaterial.KubeAPIServerExternalSigner(certsDir),
+	} {
+		require.NoError(t, os.MkdirAll(dir, 0700))
+		_, err := crypto.MakeSelfSignedCA(cryptomaterial.CACertPath(dir), cryptomaterial.CAKeyPath(dir), cryptomaterial.CASerialsPath(dir), filepath.Base(dir), 1)
+		require.NoError(t, err)
+	}
+	clientCA := cryptomaterial.KubeletClientCAPath(certsDir)
+	require.NoError(t, os.MkdirAll(filepath.Dir(clientCA), 0700))
+	require.NoError(t, os.WriteFile(clientCA, []byte("client-ca"), 0600))
+
+	cfg := &config.Config{}
+	cfg.Node.HostnameOverride = "controlplane"
+	cfg.Node.NodeIP = "192.168.1.10"
+	cfg.ApiServer.Port = 6443
+	cfg.Certificates.ShortLivedValidityDays = ptr.To(365)
+	cfg.Certificates.BackdateMinutes = ptr.To(60)
+	tokens := &TokenStore{path: filepath.Join(t.TempDir(), "tokens.json"), now: time.Now}
+	client := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-0"}})
+	return &Server{cfg: cfg, tokens: tokens, certsDir: certsDir, client: client}
+}
+
+// newRequest returns a join request of a worker with its private keys.
+func newRequest(t *testing.T, name, ip string) Request {
+	_, clientCSR, err := newCSR(name)
+	require.NoError(t, err)
+	_, servingCSR, err := newCSR(name)
+	require.NoError(t, err)
+	return Request{NodeName: name, NodeIP: ip, KubeletClientCSR: clientCSR, KubeletServingCSR: servingCSR}
+}
+
+func post(t *testing.T, h http.Handler, token string, req Request) *httptest.ResponseRecorder {
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+	r := httptest.NewRequest(http.MethodPost, joinPath, bytes.NewReader(body))
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestJoinHandler(t *testing.T) {
+	s := newTestServer(t)
+	h := s.handler()
+	token, _, err := s.tokens.Create(time.Hour, "")
+	require.NoError(t, err)
+
+	valid := newRequest(t, "worker-1", "192.168.1.11")
+	noCSR := valid
+	noCSR.KubeletServingCSR = nil
+
+	tests := []struct {
+		name   string
+		token  string
+		req    Request
+		status int
+	}{
+		{"malformed token", "secret", valid, http.StatusUnauthorized},
+		{"unknown token", "abcdef.0123456789abcdef", valid, http.StatusUnauthorized},
+		{"invalid node name", token, newRequest(t, "Worker_1", "192.168.1.11"), http.StatusBadRequest},
+		{"name of the control plane", token, newRequest(t, "controlplane", "192.168.1.11"), http.StatusBadRequest},
+		{"invalid node IP", token, newRequest(t, "worker-1", "worker-1"), http.StatusBadRequest},
+		{"registered node", token, newRequest(t, "worker-0", "192.168.1.11"), http.StatusForbidden},
+		{"missing CSR", token, noCSR, http.StatusBadRequest},
+		{"joined", token, valid, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := post(t, h, tt.token, tt.req)
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestJoinBindsToken(t *testing.T) {
+	s := newTestServer(t)
+	h := s.handler()
+	token, t1, err := s.tokens.Create(time.Hour, "")
+	require.NoError(t, err)
+
+	w := post(t, h, token, newRequest(t, "worker-1", "192.168.1.11"))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	tokens, err := s.tokens.List()
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, t1.ID, tokens[0].ID)
+	assert.Equal(t, "worker-1", tokens[0].NodeName)
+
+	// The token is bound to the node, which can join again once registered.
+	_, err = s.client.CoreV1().Nodes().Create(context.TODO(), &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	w = post(t, h, token, newRequest(t, "worker-1", "192.168.1.11"))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = post(t, h, token, newRequest(t, "worker-2", "192.168.1.12"))
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	// Another token cannot join as the registered node.
+	other, _, err := s.tokens.Create(time.Hour, "")
+	require.NoError(t, err)
+	w = post(t, h, other, newRequest(t, "worker-1", "192.168.1.11"))
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+}
+
+func TestCABundles(t *testing.T) {
+	s := newTestServer(t)
+	w := httptest.NewRecorder()
+	s.handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, caBundlesPath, nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	bundles := CABundles{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&bundles))
+	assert.Equal(t, []byte("client-ca"), bundles.KubeletClientCA)
+
+	signer, err := os.ReadFile(cryptomaterial.CACertPath(cryptomaterial.KubeAPIServerServiceNetworkSigner(s.certsDir)))
+	require.NoError(t, err)
+	block, _ := pem.Decode(signer)
+	ca, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+
+	_, err = pinnedCAs(bundles.APIServerCA, CACertHash(ca))
+	assert.NoError(t, err)
+	_, err = pinnedCAs(bundles.APIServerCA, "sha256:"+strings.Repeat("0", 64))
+	assert.Error(t, err)
+}
+
+func TestJoinSignsKubeletCertificates(t *testing.T) {
+	s := newTestServer(t)
+	token, _, err := s.tokens.Create(0, "")
+	require.NoError(t, err)
+	w := post(t, s.handler(), token, newRequest(t, "worker-1", "192.168.1.11"))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	resp := Response{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+	parse := func(data []byte) *x509.Certificate {
+		block, _ := pem.Decode(data)
+		require.NotNil(t, block)
+		cert, err := x509.ParseCertificate(block.Bytes)
+		require.NoError(t, err)
+		return cert
+	}
+	client := parse(resp.KubeletClientCert)
+	assert.Equal(t, "system:node:worker-1", client.Subject.CommonName)
+	assert.Equal(t, []string{"system:nodes"}, client.Subject.Organization)
+	assert.Equal(t, "csr-signer", client.Issuer.CommonName)
+	assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, client.ExtKeyUsage)
+
+	serving := parse(resp.KubeletServingCert)
+	assert.NoError(t, serving.VerifyHostname("worker-1"))
+	assert.NoError(t, serving.VerifyHostname("192.168.1.11"))
+	assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, serving.ExtKeyUsage)
+	// The certificates are usable on workers whose clock is behind.
+	for _, cert := range []*x509.Certificate{client, serving} {
+		assert.True(t, cert.NotBefore.Before(time.Now().Add(-s.cfg.Certificates.Backdate())))
+	}
+
+	assert.Equal(t, []byte("client-ca"), resp.KubeletClientCA)
+	assert.Equal(t, "https://192.168.1.10:6443", resp.Cluster.APIServerURL)
+	assert.Equal(t, 2, bytes.Count(resp.Cluster.APIServerCA, []byte("BEGIN CERTIFICATE")))
+}
@@ -0,0 +1,209 @@
+package join
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	tokenIDLength     = 6
+	tokenSecretLength = 16
+	tokenAlphabet     = "abcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// tokenRegexp matches the tokens, "<id>.<secret>" like the bootstrap tokens
+// of kubeadm.
+var tokenRegexp = regexp.MustCompile(`^([a-z0-9]{6})\.([a-z0-9]{16})$`)
+
+// Token authorizes the workers to join until it expires.
+type Token struct {
+	ID string `json:"id"`
+	// SecretHash is the SHA-256 of the secret of the token, the secret
+	// itself is only shown on creation.
+	SecretHash  string    `json:"secretHash"`
+	Description string    `json:"description,omitempty"`
+	Created     time.Time `json:"created"`
+	// Expires is zero for the tokens that never expire.
+	Expires time.Time `json:"expires,omitempty"`
+	// NodeName is the name of the node which joined with the token, the only
+	// one the token can join as afterwards.
+	NodeName string `json:"nodeName,omitempty"`
+}
+
+// Expired returns whether the token expired at now.
+func (t Token) Expired(now time.Time) bool {
+	return !t.Expires.IsZero() && !now.Before(t.Expires)
+}
+
+// TokenStore keeps the join tokens in a file of the data directory. The join
+// API reads it on each request, for the tokens created or deleted with the
+// CLI to apply without a restart.
+type TokenStore struct {
+	path string
+	now  func() time.Time
+	// mu serializes the updates of the join API.
+	mu sync.Mutex
+}
+
+func NewTokenStore() *TokenStore {
+	return &TokenStore{path: tokensPath(), now: time.Now}
+}
+
+// Create adds a token expiring after ttl, or never with a ttl of 0, and
+// returns it with its secret. The expired tokens are removed meanwhile.
+func (s *TokenStore) Create(ttl time.Duration, description string) (string, Token, error) {
+	if ttl < 0 {
+		return "", Token{}, fmt.Errorf("invalid token TTL %s", ttl)
+	}
+	tokens, err := s.List()
+	if err != nil {
+		return "", Token{}, err
+	}
+	now := s.now()
+	tokens = slices.DeleteFunc(tokens, func(t Token) bool { return t.Expired(now) })
+
+	id, err := randomString(tokenIDLength)
+	if err != nil {
+		return "", Token{}, err
+	}
+	if slices.ContainsFunc(tokens, func(t Token) bool { return t.ID == id }) {
+		return "", Token{}, fmt.Errorf("token %q already exists, try again", id)
+	}
+	secret, err := randomString(tokenSecretLength)
+	if err != nil {
+		return "", Token{}, err
+	}
+	t := Token{ID: id, SecretHash: hashSecret(secret), Description: description, Created: now.UTC()}
+	if ttl > 0 {
+		t.Expires = now.Add(ttl).UTC()
+	}
+	if err := s.write(append(tokens, t)); err != nil {
+		return "", Token{}, err
+	}
+	return id + "." + secret, t, nil
+}
+
+// List returns the tokens, including the expired ones not removed yet.
+func (s *TokenStore) List() ([]Token, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Token{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	tokens := []Token{}
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", s.path, err)
+	}
+	return tokens, nil
+}
+
+// Delete removes the token with the ID id.
+func (s *TokenStore) Delete(id string) error {
+	tokens, err := s.List()
+	if err != nil {
+		return err
+	}
+	remaining := slices.DeleteFunc(slices.Clone(tokens), func(t Token) bool { return t.ID == id })
+	if len(remaining) == len(tokens) {
+		return fmt.Errorf("token %q not found", id)
+	}
+	return s.write(remaining)
+}
+
+// Bind binds the token with the ID id to the node nodeName, unless it is
+// bound to another node already.
+func (s *TokenStore) Bind(id, nodeName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.List()
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(tokens, func(t Token) bool { return t.ID == id })
+	if i < 0 {
+		return fmt.Errorf("token %q not found", id)
+	}
+	switch tokens[i].NodeName {
+	case nodeName:
+		return nil
+	case "":
+		tokens[i].NodeName = nodeName
+		return s.write(tokens)
+	default:
+		return fmt.Errorf("%w: token %q is bound to node %q", errForbidden, id, tokens[i].NodeName)
+	}
+}
+
+// Authenticate returns the token matching token, when it did not expire.
+func (s *TokenStore) Authenticate(token string) (Token, error) {
+	m := tokenRegexp.FindStringSubmatch(token)
+	if m == nil {
+		return Token{}, fmt.Errorf("malformed token")
+	}
+	tokens, err := s.List()
+	if err != nil {
+		return Token{}, err
+	}
+	for _, t := range tokens {
+		if t.ID != m[1] {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(t.SecretHash), []byte(hashSecret(m[2]))) != 1 {
+			return Token{}, fmt.Errorf("invalid secret for token %q", t.ID)
+		}
+		if t.Expired(s.now()) {
+			return Token{}, fmt.Errorf("token %q expired at %s", t.ID, t.Expires.Format(time.RFC3339))
+		}
+		return t, nil
+	}
+	return Token{}, fmt.Errorf("token %q not found", m[1])
+}
+
+// write replaces the file of the tokens atomically.
+func (s *TokenStore) write(tokens []Token) error {
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	max := big.NewInt(int64(len(tokenAlphabet)))
+	for i := range b {
+		r, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = tokenAlphabet[r.Int64()]
+	}
+	return string(b), nil
+}
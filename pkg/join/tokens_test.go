@@ -0,0 +1,54 @@
+package join
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenStore(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := &TokenStore{path: filepath.Join(t.TempDir(), "join", "tokens.json"), now: func() time.Time { return now }}
+
+	token, created, err := s.Create(time.Hour, "rack 1")
+	require.NoError(t, err)
+	assert.Regexp(t, tokenRegexp, token)
+	assert.Equal(t, now.Add(time.Hour), created.Expires)
+	assert.NotContains(t, created.SecretHash, token[7:])
+
+	forever, _, err := s.Create(0, "")
+	require.NoError(t, err)
+
+	authenticated, err := s.Authenticate(token)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, authenticated.ID)
+
+	_, err = s.Authenticate(created.ID + ".0000000000000000")
+	assert.Error(t, err, "wrong secret")
+	_, err = s.Authenticate("not-a-token")
+	assert.Error(t, err, "malformed token")
+
+	now = now.Add(2 * time.Hour)
+	_, err = s.Authenticate(token)
+	assert.Error(t, err, "expired token")
+	_, err = s.Authenticate(forever)
+	assert.NoError(t, err, "token without expiry")
+
+	// Expired tokens are listed until the next token is created.
+	tokens, err := s.List()
+	require.NoError(t, err)
+	assert.Len(t, tokens, 2)
+	_, _, err = s.Create(time.Hour, "")
+	require.NoError(t, err)
+	tokens, err = s.List()
+	require.NoError(t, err)
+	assert.Len(t, tokens, 2)
+
+	require.NoError(t, s.Delete(forever[:tokenIDLength]))
+	_, err = s.Authenticate(forever)
+	assert.Error(t, err, "deleted token")
+	assert.Error(t, s.Delete(forever[:tokenIDLength]))
+}
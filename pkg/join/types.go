@@ -0,0 +1,85 @@
+// Package join lets worker nodes join the control plane of a multi-node
+// MicroShift: the control plane serves the join API, which signs the
+// certificate signing requests of the kubelet of the worker in exchange for
+// a join token, and the worker pins the CA of the join API by its hash.
+package join
+
+import (
+	"path/filepath"
+
+	"github.com/openshift/microshift/pkg/config"
+)
+
+const (
+	// ServerPort is the port of the join API on the control plane.
+	ServerPort = 7443
+	// joinPath is the path of the join API.
+	joinPath = "/v1/join"
+	// caBundlesPath serves the CA bundles of the control plane, without
+	// authentication, for the workers to find the CA they pinned.
+	caBundlesPath = "/v1/ca-bundles"
+)
+
+// tokensPath holds the tokens authorizing the workers to join, managed with
+// `microshift join-token`.
+func tokensPath() string { return filepath.Join(config.DataDir, "resources", "join", "tokens.json") }
+
+// clusterPath holds the settings of the cluster a worker joined.
+func clusterPath() string { return filepath.Join(config.DataDir, "resources", "join", "cluster.json") }
+
+// Request is sent by a worker to join the control plane.
+type Request struct {
+	// NodeName is the name of the Node of the worker.
+	NodeName string `json:"nodeName"`
+	// NodeIP is the IP address of the worker, included with NodeName in
+	// the serving certificate of its kubelet.
+	NodeIP string `json:"nodeIP"`
+
+	// KubeletClientCSR and KubeletServingCSR are the PEM certificate
+	// signing requests of the keys of the kubelet, which never leave the
+	// worker. The subjects and SANs of the signed certificates are set by
+	// the control plane from NodeName and NodeIP.
+	KubeletClientCSR  []byte `json:"kubeletClientCSR"`
+	KubeletServingCSR []byte `json:"kubeletServingCSR"`
+}
+
+// Response holds the certificates of the kubelet of the worker and the
+// settings of the cluster it joins.
+type Response struct {
+	Cluster Cluster `json:"cluster"`
+
+	// KubeletClientCert authenticates the kubelet with the API server as
+	// system:node:<NodeName>.
+	KubeletClientCert []byte `json:"kubeletClientCert"`
+	// KubeletServingCert is the serving certificate of the kubelet,
+	// trusted by the API server.
+	KubeletServingCert []byte `json:"kubeletServingCert"`
+	// KubeletClientCA is the bundle of the signers of the clients the
+	// kubelet accepts, such as the API server.
+	KubeletClientCA []byte `json:"kubeletClientCA"`
+}
+
+// CABundles are the CA bundles of the control plane.
+type CABundles struct {
+	// APIServerCA is the bundle of the signers of the serving certificates
+	// of the API server, one of which signs the one of the join API.
+	APIServerCA []byte `json:"apiServerCA"`
+	// KubeletClientCA is the bundle of the signers of the clients the
+	// kubelets accept.
+	KubeletClientCA []byte `json:"kubeletClientCA"`
+}
+
+// Cluster is the part of the settings of the control plane the workers need,
+// kept by the workers across restarts.
+type Cluster struct {
+	// APIServerURL is the URL of the API server on the control plane.
+	APIServerURL string `json:"apiServerURL"`
+	// APIServerCA is the bundle of the signers of the serving certificates
+	// of the API server.
+	APIServerCA []byte `json:"apiServerCA"`
+
+	ClusterNetwork []string `json:"clusterNetwork"`
+	ServiceNetwork []string `json:"serviceNetwork"`
+	// ClusterDNS is the IP address of the DNS service of the cluster.
+	ClusterDNS string `json:"clusterDNS"`
+}
@@ -0,0 +1,145 @@
+package kustomize
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openshift/microshift/pkg/config"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/yaml"
+)
+
+// chartValuesFile holds the values of the chart of a chart directory.
+const chartValuesFile = "values.yaml"
+
+// chartNamespaceTransformer sets the release namespace on the rendered
+// resources which have no namespace, like Helm does when installing them.
+const chartNamespaceTransformer = `apiVersion: builtin
+kind: NamespaceTransformer
+metadata:
+  name: chart-namespace
+  namespace: %s
+unsetOnly: true
+setRoleBindingSubjects: none
+`
+
+// chartArchive returns the chart archive of the chart directory at path, a
+// manifests directory without a kustomization, or "" when path is not one.
+func chartArchive(path string) (string, error) {
+	k, err := readKustomization(path)
+	if err != nil || k != nil {
+		return "", err
+	}
+	archives, err := filepath.Glob(filepath.Join(path, config.ChartArchivePattern))
+	if err != nil {
+		return "", err
+	}
+	switch len(archives) {
+	case 0:
+		return "", nil
+	case 1:
+		return archives[0], nil
+	}
+	return "", fmt.Errorf("chart directory %q holds more than one chart archive: %v", path, archives)
+}
+
+// renderChart renders the chart archive of the chart directory at path with
+// the values.yaml file of the directory, like `helm template`. The release
+// is named after the chart and installed in the namespace of the
+// namespace.yaml file of the directory, or in the default namespace. Hooks
+// are left out, there is no release to run them for. It returns the
+// in-memory kustomization of the rendered resources.
+//
+//nolint:ireturn
+func renderChart(path, archive string) (filesys.FileSystem, string, error) {
+	chrt, err := loader.Load(archive)
+	if err != nil {
+		return nil, "", err
+	}
+	values := chartutil.Values{}
+	valuesFile := filepath.Join(path, chartValuesFile)
+	if _, err := os.Stat(valuesFile); err == nil {
+		if values, err = chartutil.ReadValuesFile(valuesFile); err != nil {
+			return nil, "", err
+		}
+	}
+	namespace, err := chartNamespace(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := chartutil.ProcessDependenciesWithMerge(chrt, values); err != nil {
+		return nil, "", err
+	}
+	options := chartutil.ReleaseOptions{Name: chrt.Name(), Namespace: namespace, Revision: 1, IsInstall: true}
+	renderValues, err := chartutil.ToRenderValues(chrt, values, options, chartutil.DefaultCapabilities)
+	if err != nil {
+		return nil, "", err
+	}
+	files, err := engine.Render(chrt, renderValues)
+	if err != nil {
+		return nil, "", err
+	}
+	for name := range files {
+		if strings.HasSuffix(name, "NOTES.txt") {
+			delete(files, name)
+		}
+	}
+	_, manifests, err := releaseutil.SortManifests(files, nil, releaseutil.InstallOrder)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var rendered bytes.Buffer
+	for _, crd := range chrt.CRDObjects() {
+		fmt.Fprintf(&rendered, "---\n%s\n", crd.File.Data)
+	}
+	for _, m := range manifests {
+		fmt.Fprintf(&rendered, "---\n%s\n", m.Content)
+	}
+
+	fSys := filesys.MakeFsInMemory()
+	dir := filepath.Join("/", chrt.Name())
+	for name, data := range map[string][]byte{
+		konfig.DefaultKustomizationFileName(): []byte("resources:\n- chart.yaml\ntransformers:\n- namespace.yaml\n"),
+		"namespace.yaml":                      []byte(fmt.Sprintf(chartNamespaceTransformer, namespace)),
+		"chart.yaml":                          rendered.Bytes(),
+	} {
+		if err := fSys.WriteFile(filepath.Join(dir, name), data); err != nil {
+			return nil, "", err
+		}
+	}
+	return fSys, dir, nil
+}
+
+// chartNamespace returns the namespace of the namespace.yaml file of the
+// chart directory at path, or the default namespace when there is none.
+func chartNamespace(path string) (string, error) {
+	file := filepath.Join(path, namespaceFileName)
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return metav1.NamespaceDefault, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var ns corev1.Namespace
+	if err := yaml.Unmarshal(data, &ns); err != nil {
+		return "", fmt.Errorf("failed to parse %q: %w", file, err)
+	}
+	if ns.Name == "" {
+		return "", fmt.Errorf("%q has no namespace name", file)
+	}
+	return ns.Name, nil
+}
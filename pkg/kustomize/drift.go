@@ -0,0 +1,137 @@
+package kustomize
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/openshift/microshift/pkg/config"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+)
+
+// ignoredDriftFields are changed by the API server on every apply.
+var ignoredDriftFields = []string{
+	"metadata.generation",
+	"metadata.managedFields",
+	"metadata.resourceVersion",
+	"status",
+}
+
+func (s *Kustomizer) forceConflicts() bool {
+	return s.cfg.Manifests.ConflictPolicy == config.ManifestsConflictPolicyForce
+}
+
+// logDrift logs the fields of the resources of the kustomization at path
+// which applying it changes, i.e. which were changed since it was last
+// applied, by dry-running the apply.
+func (s *Kustomizer) logDrift(ctx context.Context, path string) {
+	if err := s.checkDrift(ctx, path); err != nil {
+		klog.V(2).Infof("Not checking kustomization at %v for drift: %v", path, err)
+	}
+}
+
+func (s *Kustomizer) checkDrift(ctx context.Context, path string) error {
+	return s.forEachLiveResource(ctx, path, func(ref objectRef, resource dynamic.ResourceInterface, desired, live *unstructured.Unstructured) error {
+		force := s.forceConflicts()
+		applied, err := resource.Apply(ctx, ref.Name, desired, metav1.ApplyOptions{
+			FieldManager: fieldManager,
+			Force:        force,
+			DryRun:       []string{metav1.DryRunAll},
+		})
+		if apierrors.IsConflict(err) {
+			klog.Warningf("Kustomization at %v conflicts with the changes made to %s: %v", path, ref, err)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to dry-run the apply of %s: %w", ref, err)
+		}
+		if fields := driftedFields("", live.Object, applied.Object); len(fields) != 0 {
+			klog.Infof("Kustomization at %v reverts the changes made to %s: %s", path, ref, strings.Join(fields, ", "))
+		}
+		return nil
+	})
+}
+
+// forEachLiveResource calls fn with the resources of the kustomization at
+// path which exist, along with their live version.
+func (s *Kustomizer) forEachLiveResource(ctx context.Context, path string,
+	fn func(ref objectRef, resource dynamic.ResourceInterface, desired, live *unstructured.Unstructured) error) error {
+	resources, err := Render(path)
+	if err != nil {
+		return err
+	}
+	p, err := s.getPruner()
+	if err != nil {
+		return err
+	}
+	for _, r := range resources.Resources() {
+		object, err := r.Map()
+		if err != nil {
+			return err
+		}
+		desired := &unstructured.Unstructured{Object: object}
+		gvk := desired.GroupVersionKind()
+		mapping, err := p.mapper.RESTMapping(schema.GroupKind{Group: gvk.Group, Kind: gvk.Kind}, gvk.Version)
+		if err != nil {
+			// The CRD of the resource is not established yet.
+			continue
+		}
+		ref := objectRef{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind, Namespace: desired.GetNamespace(), Name: desired.GetName()}
+		resource := p.resource(mapping, ref.Namespace)
+
+		live, err := resource.Get(ctx, ref.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get %s: %w", ref, err)
+		}
+		if err := fn(ref, resource, desired, live); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// driftedFields returns the paths of the fields which differ between the
+// live and applied objects.
+func driftedFields(prefix string, live, applied map[string]interface{}) []string {
+	keys := make([]string, 0, len(live)+len(applied))
+	for k := range live {
+		keys = append(keys, k)
+	}
+	for k := range applied {
+		if _, ok := live[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	var fields []string
+	for _, k := range keys {
+		field := k
+		if prefix != "" {
+			field = prefix + "." + k
+		}
+		if slices.Contains(ignoredDriftFields, field) {
+			continue
+		}
+		liveMap, liveOK := live[k].(map[string]interface{})
+		appliedMap, appliedOK := applied[k].(map[string]interface{})
+		if liveOK && appliedOK {
+			fields = append(fields, driftedFields(field, liveMap, appliedMap)...)
+			continue
+		}
+		if !reflect.DeepEqual(live[k], applied[k]) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,37 @@
+package kustomize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDriftedFields(t *testing.T) {
+	live := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":            "app",
+			"resourceVersion": "10",
+			"labels":          map[string]interface{}{"app": "app", "edited": "true"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"template": map[string]interface{}{"containers": []interface{}{"a"}},
+		},
+		"status": map[string]interface{}{"replicas": int64(3)},
+	}
+	applied := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":            "app",
+			"resourceVersion": "11",
+			"labels":          map[string]interface{}{"app": "app"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(1),
+			"template": map[string]interface{}{"containers": []interface{}{"a"}},
+			"paused":   false,
+		},
+		"status": map[string]interface{}{"replicas": int64(1)},
+	}
+	assert.Equal(t, []string{"metadata.labels.edited", "spec.paused", "spec.replicas"}, driftedFields("", live, applied))
+	assert.Empty(t, driftedFields("", live, live))
+}
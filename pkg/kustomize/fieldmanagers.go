@@ -0,0 +1,70 @@
+package kustomize
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/csaupgrade"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// legacyApplyFieldManager applied the manifests with server-side apply
+	// before the kustomizer had its own field manager.
+	legacyApplyFieldManager = "kubectl"
+	// legacyUpdateFieldManager applied the manifests of the releases using
+	// client-side apply.
+	legacyUpdateFieldManager = "kubectl-client-side-apply"
+)
+
+// migrateFieldManagers moves the ownership of the fields of the resources of
+// the kustomization at path from the field managers of the earlier releases
+// to fieldManager. Without it, the manifests changed by an upgrade would
+// conflict with the values the earlier releases applied, and the fields
+// removed from them would never be removed from the resources.
+func (s *Kustomizer) migrateFieldManagers(ctx context.Context, path string) {
+	err := s.forEachLiveResource(ctx, path, func(ref objectRef, resource dynamic.ResourceInterface, _, live *unstructured.Unstructured) error {
+		patch, err := fieldManagersPatch(live)
+		if err != nil {
+			return fmt.Errorf("failed to migrate the field managers of %s: %w", ref, err)
+		}
+		if patch == nil {
+			return nil
+		}
+		if _, err := resource.Patch(ctx, ref.Name, types.JSONPatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("failed to migrate the field managers of %s: %w", ref, err)
+		}
+		klog.Infof("Migrated the fields of %s to the %s field manager", ref, fieldManager)
+		return nil
+	})
+	if err != nil {
+		klog.Warningf("Not migrating the field managers of kustomization at %v: %v", path, err)
+	}
+}
+
+// fieldManagersPatch returns the JSON patch merging the fields owned by the
+// legacy field managers into the ones of fieldManager, or nil when there is
+// none. csaupgrade only migrates the managers of Update operations, so the
+// Apply entries of the legacy manager are first turned into Update ones.
+func fieldManagersPatch(live *unstructured.Unstructured) ([]byte, error) {
+	obj := live.DeepCopy()
+	managedFields := obj.GetManagedFields()
+	for i := range managedFields {
+		if managedFields[i].Manager == legacyApplyFieldManager && managedFields[i].Operation == metav1.ManagedFieldsOperationApply {
+			managedFields[i].Operation = metav1.ManagedFieldsOperationUpdate
+		}
+	}
+	obj.SetManagedFields(managedFields)
+
+	patch, err := csaupgrade.UpgradeManagedFieldsPatch(obj,
+		sets.New(legacyApplyFieldManager, legacyUpdateFieldManager), fieldManager)
+	if err != nil || patch == nil {
+		return nil, err
+	}
+	return patch, nil
+}
@@ -0,0 +1,61 @@
+package kustomize
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestFieldManagersPatch(t *testing.T) {
+	entry := func(manager string, op metav1.ManagedFieldsOperationType, fields string) metav1.ManagedFieldsEntry {
+		return metav1.ManagedFieldsEntry{
+			Manager:    manager,
+			Operation:  op,
+			APIVersion: "apps/v1",
+			FieldsType: "FieldsV1",
+			FieldsV1:   &metav1.FieldsV1{Raw: []byte(fields)},
+		}
+	}
+	live := &unstructured.Unstructured{}
+	live.SetAPIVersion("apps/v1")
+	live.SetKind("Deployment")
+	live.SetName("app")
+	live.SetResourceVersion("10")
+
+	// Already migrated, or applied by this release only.
+	live.SetManagedFields([]metav1.ManagedFieldsEntry{
+		entry(fieldManager, metav1.ManagedFieldsOperationApply, `{"f:spec":{"f:replicas":{}}}`),
+	})
+	patch, err := fieldManagersPatch(live)
+	require.NoError(t, err)
+	assert.Nil(t, patch)
+
+	// Applied by an earlier release with server-side and client-side apply.
+	controller := entry("kube-controller-manager", metav1.ManagedFieldsOperationUpdate, `{"f:status":{}}`)
+	live.SetManagedFields([]metav1.ManagedFieldsEntry{
+		entry(legacyApplyFieldManager, metav1.ManagedFieldsOperationApply, `{"f:spec":{"f:replicas":{}}}`),
+		entry(legacyUpdateFieldManager, metav1.ManagedFieldsOperationUpdate, `{"f:spec":{"f:paused":{}}}`),
+		controller,
+	})
+	patch, err = fieldManagersPatch(live)
+	require.NoError(t, err)
+	require.NotNil(t, patch)
+
+	ops := []struct {
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value"`
+	}{}
+	require.NoError(t, json.Unmarshal(patch, &ops))
+	require.Equal(t, "/metadata/managedFields", ops[0].Path)
+	managedFields := []metav1.ManagedFieldsEntry{}
+	require.NoError(t, json.Unmarshal(ops[0].Value, &managedFields))
+	require.Len(t, managedFields, 2)
+	assert.Equal(t, fieldManager, managedFields[0].Manager)
+	assert.Equal(t, metav1.ManagedFieldsOperationApply, managedFields[0].Operation)
+	assert.JSONEq(t, `{"f:spec":{"f:paused":{},"f:replicas":{}}}`, string(managedFields[0].FieldsV1.Raw))
+	assert.Equal(t, controller, managedFields[1])
+}
@@ -4,13 +4,20 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"time"
 
+	"github.com/openshift/microshift/pkg/boottimings"
 	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/tracing"
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/kubernetes"
 	cliflag "k8s.io/component-base/cli/flag"
 	"k8s.io/klog/v2"
 	"k8s.io/kubectl/pkg/cmd/apply"
@@ -19,14 +26,26 @@ import (
 	"k8s.io/kubectl/pkg/util/templates"
 )
 
-const (
-	retryInterval = 10 * time.Second
-	retryTimeout  = 1 * time.Minute
-)
+// fieldManager owns the fields of the resources applied by the kustomizer.
+const fieldManager = "microshift-kustomizer"
+
+// applyBackoff retries the failed kustomizations, e.g. until the CRDs they
+// use are established or the webhooks validating them are ready, for about
+// 4 minutes.
+var applyBackoff = wait.Backoff{
+	Duration: 5 * time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    8,
+	Cap:      time.Minute,
+}
 
 type Kustomizer struct {
 	cfg        *config.Config
 	kubeconfig string
+	status     *applyStatus
+	pruner     *pruner
+	client     kubernetes.Interface
 }
 
 func NewKustomizer(cfg *config.Config) *Kustomizer {
@@ -36,12 +55,50 @@ func NewKustomizer(cfg *config.Config) *Kustomizer {
 	}
 }
 
-func (s *Kustomizer) Name() string           { return "kustomizer" }
-func (s *Kustomizer) Dependencies() []string { return []string{"kube-apiserver"} }
+func (s *Kustomizer) Name() string { return "kustomizer" }
+
+// Dependencies include the CRD manager, which is only ready once the default
+// and user CRDs are established, so that the manifests can use them.
+func (s *Kustomizer) Dependencies() []string {
+	return []string{"kube-apiserver", "openshift-crd-manager"}
+}
+
+// Optional allows disabling the kustomizer at runtime. Enabling it again
+// applies the manifests again.
+func (s *Kustomizer) Optional() bool { return true }
+
+// Deferred applies the manifests once MicroShift is ready, so they do not
+// compete with the control plane for the disk and memory during the boot.
+func (s *Kustomizer) Deferred() bool { return true }
 
 func (s *Kustomizer) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
 	defer close(stopped)
-	defer close(ready)
+
+	s.status = newApplyStatus(ctx, s.kubeconfig, s.cfg.CanonicalNodeName())
+	defer s.status.Shutdown()
+
+	// Applying the manifests is retried for minutes and may wait for their
+	// workloads to roll out, which must not hold the start of MicroShift.
+	close(ready)
+	if err := s.applyManifests(ctx); err != nil {
+		return err
+	}
+	boottimings.SetMilestone(boottimings.MilestoneManifestsApplied, time.Now())
+
+	interval := time.Duration(*s.cfg.Manifests.Reconcile.IntervalSeconds) * time.Second
+	if interval == 0 {
+		return ctx.Err()
+	}
+	return s.reconcile(ctx, interval)
+}
+
+// applyManifests pulls the kustomizations of OCI artifacts, deletes the
+// resources of the delete kustomizations, then applies the kustomizations.
+func (s *Kustomizer) applyManifests(ctx context.Context) error {
+	ctx, span := tracing.Tracer().Start(ctx, "apply manifests")
+	defer span.End()
+
+	s.pullOCIManifests(ctx)
 
 	kustomizationPaths, err := s.cfg.Manifests.GetKustomizationPaths()
 	if err != nil {
@@ -52,37 +109,110 @@ func (s *Kustomizer) Run(ctx context.Context, ready chan<- struct{}, stopped cha
 		return fmt.Errorf("failed to find any delete kustomization paths: %w", err)
 	}
 
+	kustomizationPaths = orderKustomizationPaths(kustomizationPaths)
+	// Deleting in reverse order removes the kustomizations before those
+	// they were applied after.
+	deletePaths = orderKustomizationPaths(deletePaths)
+	slices.Reverse(deletePaths)
+
+	var results []applyResult
 	for _, path := range deletePaths {
-		s.handleKustomizationPath(ctx, path, "Deleting", deleteKustomization)
+		err := s.handleKustomizationPath(ctx, path, "Deleting", deleteKustomization)
+		results = append(results, applyResult{path: path, verb: "Deleting", err: err})
 	}
 
+	inv, err := loadInventory(inventoryFile())
+	if err != nil {
+		klog.Errorf("Not pruning manifests: %v", err)
+	}
 	for _, path := range kustomizationPaths {
-		s.handleKustomizationPath(ctx, path, "Applying", applyKustomization)
+		s.migrateFieldManagers(ctx, path)
+		s.logDrift(ctx, path)
+		err := s.handleKustomizationPath(ctx, path, "Applying", s.applyWithNamespaces(ctx))
+		if err == nil {
+			if err = s.waitForRollout(ctx, path); err != nil {
+				klog.Errorf("Applying kustomization at %v failed: %v", path, err)
+				s.status.Report(path, "Applying", err)
+			}
+		}
+		if inv != nil && err == nil {
+			s.pruneKustomization(ctx, inv, path)
+		}
+		results = append(results, applyResult{path: path, verb: "Applying", err: err})
 	}
-
-	return ctx.Err()
+	if inv != nil {
+		s.pruneRemovedKustomizations(ctx, inv, kustomizationPaths)
+		if err := inv.save(inventoryFile()); err != nil {
+			klog.Errorf("Failed to save manifests inventory: %v", err)
+		}
+	}
+	logSummary(results)
+	return nil
 }
 
-func (s *Kustomizer) handleKustomizationPath(ctx context.Context, path string, verb string, actionFunc func(string, string) error) {
+func (s *Kustomizer) handleKustomizationPath(ctx context.Context, path string, verb string, actionFunc func(string, string) error) error {
 	klog.Infof("%s kustomization at %v ", verb, path)
-	err := wait.PollUntilContextTimeout(ctx, retryInterval, retryTimeout, true, func(_ context.Context) (done bool, err error) {
-		if err := actionFunc(path, s.kubeconfig); err != nil {
-			klog.Infof("%s kustomization failed: %s. Retrying in %s.", verb, err, retryInterval)
+	_, span := tracing.Tracer().Start(ctx, verb+" kustomization", trace.WithAttributes(
+		attribute.String("microshift.kustomization.path", path)))
+	var lastErr error
+	err := wait.ExponentialBackoffWithContext(ctx, applyBackoff, func(_ context.Context) (done bool, err error) {
+		if lastErr = actionFunc(path, s.kubeconfig); lastErr != nil {
+			klog.Infof("%s kustomization failed: %s. Retrying.", verb, lastErr)
 			return false, nil
 		}
 		return true, nil
 	})
+	// Report why the kustomization failed rather than the retries running out.
+	if err != nil && lastErr != nil {
+		err = lastErr
+	}
 	if err != nil {
 		klog.Errorf("%s kustomization at %v failed: %v. Giving up.", verb, path, err)
 	} else {
 		klog.Infof("%s kustomization at %v was successful.", verb, path)
 	}
+	s.status.Report(path, verb, err)
+	tracing.EndSpan(span, err)
+	return err
+}
+
+// applyKustomization applies the kustomization with server-side apply. When
+// force is set, the fields changed by other field managers are reverted and
+// taken over, otherwise such conflicts fail the apply.
+func applyKustomization(kustomization string, kubeconfig string, force bool) error {
+	// The namespace.yaml file of the kustomization is applied first, so
+	// that its resources can be created in the namespace.
+	namespaceFile := filepath.Join(kustomization, namespaceFileName)
+	if _, err := os.Stat(namespaceFile); err == nil {
+		if err := kubectlApply(kustomization, kubeconfig, force, nil, []string{namespaceFile}); err != nil {
+			return err
+		}
+	}
+
+	// kubectl cannot render Helm charts, apply them rendered.
+	archive, err := chartArchive(kustomization)
+	if err != nil {
+		return err
+	}
+	if archive != "" {
+		rendered, err := renderToFile(kustomization)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(rendered)
+		return kubectlApply(kustomization, kubeconfig, force, nil, []string{rendered})
+	}
+	return kubectlApply(kustomization, kubeconfig, force, &kustomization, nil)
 }
 
-func applyKustomization(kustomization string, kubeconfig string) error {
+// kubectlApply applies the kustomization at kustomize, or the files.
+func kubectlApply(kustomization, kubeconfig string, force bool, kustomize *string, files []string) error {
 	kubectlCmd := getKubectlCmd(kustomization, kubeconfig)
 	applyFlags := apply.NewApplyFlags(kubectlCmd.ioStreams)
-	applyFlags.DeleteFlags.FileNameFlags.Kustomize = &kustomization
+	applyFlags.DeleteFlags.FileNameFlags.Kustomize = kustomize
+	if files != nil {
+		applyFlags.DeleteFlags.FileNameFlags.Filenames = &files
+	}
 	applyFlags.AddFlags(kubectlCmd.cmds)
 
 	o, err := applyFlags.ToOptions(kubectlCmd.factory, kubectlCmd.cmds, "kubectl", nil)
@@ -92,8 +222,8 @@ func applyKustomization(kustomization string, kubeconfig string) error {
 
 	// Enable server-side apply to ensure big resources are applied successfully.
 	o.ServerSideApply = true
-	// Force conflicts to ensure that resources are applied even if they have changed on kube.
-	o.ForceConflicts = true
+	o.FieldManager = fieldManager
+	o.ForceConflicts = force
 
 	if err := o.Validate(); err != nil {
 		return err
@@ -107,6 +237,20 @@ func deleteKustomization(kustomization string, kubeconfig string) error {
 
 	deleteFlags := delete.NewDeleteFlags("")
 	deleteFlags.FileNameFlags.Kustomize = &kustomization
+	// kubectl cannot render Helm charts, delete them rendered.
+	archive, err := chartArchive(kustomization)
+	if err != nil {
+		return err
+	}
+	if archive != "" {
+		rendered, err := renderToFile(kustomization)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(rendered)
+		deleteFlags.FileNameFlags.Kustomize = nil
+		deleteFlags.FileNameFlags.Filenames = &[]string{rendered}
+	}
 	ignoreNotFound := true
 	deleteFlags.IgnoreNotFound = &ignoreNotFound
 	deleteFlags.AddFlags(kubectlCmd.cmds)
@@ -0,0 +1,209 @@
+package kustomize
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/openshift/microshift/pkg/config"
+	"k8s.io/klog/v2"
+)
+
+// skopeoCommand copies the OCI artifacts holding kustomizations, it must be
+// installed on the host to use them. It honors the registry mirrors of
+// /etc/containers/registries.conf, like CRI-O.
+var skopeoCommand = "skopeo"
+
+// ociTitleAnnotation names the file of a layer which is not a tarball, as
+// pushed by oras.
+const ociTitleAnnotation = "org.opencontainers.image.title"
+
+// ociDigestFile records the digest of the manifest of the artifact pulled
+// in a kustomization directory.
+const ociDigestFile = ".oci-digest"
+
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// pullOCIManifests pulls the kustomizations of the OCI references of the
+// manifests paths. The last pulled kustomization is kept when pulling fails.
+func (s *Kustomizer) pullOCIManifests(ctx context.Context) {
+	for _, path := range s.cfg.Manifests.KustomizePaths {
+		if !strings.HasPrefix(path, config.OCIScheme) {
+			continue
+		}
+		if err := pullOCIManifest(ctx, path, config.OCIManifestDir(path), s.cfg.Images.PullSecretFile); err != nil {
+			klog.Errorf("Failed to pull manifests %v, using the last pulled ones: %v", path, err)
+		}
+	}
+}
+
+// pullOCIManifest copies the OCI artifact of ref and unpacks its layers in
+// dir, unless dir already holds them. The pull is authenticated with the
+// pull secret of the images when it exists.
+func pullOCIManifest(ctx context.Context, ref, dir, pullSecretFile string) error {
+	if err := os.MkdirAll(filepath.Dir(dir), 0700); err != nil {
+		return err
+	}
+	pulled, err := os.MkdirTemp(filepath.Dir(dir), ".pull-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(pulled)
+
+	args := []string{"copy", "--quiet"}
+	if _, err := os.Stat(pullSecretFile); err == nil {
+		args = append(args, "--authfile", pullSecretFile)
+	}
+	args = append(args, "docker://"+strings.TrimPrefix(ref, config.OCIScheme), "dir:"+pulled)
+	cmd := exec.CommandContext(ctx, skopeoCommand, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", skopeoCommand, err, strings.TrimSpace(stderr.String()))
+	}
+
+	data, err := os.ReadFile(filepath.Join(pulled, "manifest.json"))
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	if current, err := os.ReadFile(filepath.Join(dir, ociDigestFile)); err == nil && string(current) == digest {
+		return nil
+	}
+
+	unpacked := pulled + ".unpacked"
+	defer os.RemoveAll(unpacked)
+	if err := unpackOCIArtifact(pulled, data, unpacked); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(unpacked, ociDigestFile), []byte(digest), 0600); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.Rename(unpacked, dir); err != nil {
+		return err
+	}
+	klog.Infof("Pulled manifests %v at %v", ref, digest)
+	return nil
+}
+
+// unpackOCIArtifact extracts the layers of the manifest, copied by skopeo in
+// the layout dir, to dest. Tarball layers, as in images, are extracted and
+// the others, as in artifacts pushed by oras, are written to the file named
+// by their title.
+func unpackOCIArtifact(layout string, manifestData []byte, dest string) error {
+	var manifest ociManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return fmt.Errorf("failed to parse the manifest: %w", err)
+	}
+	if err := os.MkdirAll(dest, 0700); err != nil {
+		return err
+	}
+	for _, layer := range manifest.Layers {
+		_, hexDigest, found := strings.Cut(layer.Digest, ":")
+		if !found {
+			return fmt.Errorf("invalid layer digest %q", layer.Digest)
+		}
+		blob := filepath.Join(layout, hexDigest)
+		if strings.Contains(layer.MediaType, "tar") {
+			if err := extractTarball(blob, dest); err != nil {
+				return fmt.Errorf("failed to extract layer %v: %w", layer.Digest, err)
+			}
+			continue
+		}
+		title := layer.Annotations[ociTitleAnnotation]
+		if !filepath.IsLocal(title) {
+			return fmt.Errorf("layer %v has an invalid %s annotation %q", layer.Digest, ociTitleAnnotation, title)
+		}
+		data, err := os.ReadFile(blob)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(filepath.Join(dest, title), bytes.NewReader(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractTarball extracts the directories and regular files of the, maybe
+// gzipped, tarball to dest.
+func extractTarball(path, dest string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = bufio.NewReader(f)
+	if magic, err := r.(*bufio.Reader).Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.Clean(header.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("invalid path %q", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(filepath.Join(dest, name), 0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeFile(filepath.Join(dest, name), tr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func writeFile(path string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
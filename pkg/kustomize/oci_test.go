@@ -0,0 +1,77 @@
+package kustomize
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnpackOCIArtifact(t *testing.T) {
+	layout := t.TempDir()
+
+	var tarball bytes.Buffer
+	gz := gzip.NewWriter(&tarball)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "base/", Typeflag: tar.TypeDir, Mode: 0755}))
+	content := []byte("kind: ConfigMap\n")
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "base/configmap.yaml", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}))
+	_, err := tw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, os.WriteFile(filepath.Join(layout, "aaaa"), tarball.Bytes(), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(layout, "bbbb"), []byte("resources:\n- base/configmap.yaml\n"), 0600))
+
+	manifest := []byte(`{"layers":[
+		{"mediaType":"application/vnd.oci.image.layer.v1.tar+gzip","digest":"sha256:aaaa"},
+		{"mediaType":"application/yaml","digest":"sha256:bbbb","annotations":{"org.opencontainers.image.title":"kustomization.yaml"}}
+	]}`)
+	dest := filepath.Join(t.TempDir(), "app")
+	require.NoError(t, unpackOCIArtifact(layout, manifest, dest))
+
+	data, err := os.ReadFile(filepath.Join(dest, "base/configmap.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+	data, err = os.ReadFile(filepath.Join(dest, "kustomization.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "resources:\n- base/configmap.yaml\n", string(data))
+}
+
+func TestUnpackOCIArtifactInvalidTitle(t *testing.T) {
+	layout := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(layout, "bbbb"), []byte("x"), 0600))
+	manifest := []byte(`{"layers":[{"mediaType":"application/yaml","digest":"sha256:bbbb","annotations":{"org.opencontainers.image.title":"../escape.yaml"}}]}`)
+	assert.Error(t, unpackOCIArtifact(layout, manifest, t.TempDir()))
+}
+
+func TestPullOCIManifest(t *testing.T) {
+	// A fake skopeo writing an artifact to its dir: destination.
+	bin := filepath.Join(t.TempDir(), "skopeo")
+	script := `#!/bin/sh
+for arg; do dest=$arg; done
+dest=${dest#dir:}
+printf 'resources: []\n' > "$dest/cccc"
+printf '{"layers":[{"mediaType":"application/yaml","digest":"sha256:cccc","annotations":{"org.opencontainers.image.title":"kustomization.yaml"}}]}' > "$dest/manifest.json"
+`
+	require.NoError(t, os.WriteFile(bin, []byte(script), 0700))
+	defer func(cmd string) { skopeoCommand = cmd }(skopeoCommand)
+	skopeoCommand = bin
+	pullSecretFile := filepath.Join(t.TempDir(), "missing")
+
+	dir := filepath.Join(t.TempDir(), "manifests-oci", "app")
+	require.NoError(t, pullOCIManifest(context.TODO(), "oci://registry.example.com/app:1.0", dir, pullSecretFile))
+	assert.FileExists(t, filepath.Join(dir, "kustomization.yaml"))
+	assert.FileExists(t, filepath.Join(dir, ociDigestFile))
+
+	// Pulling the same artifact again leaves the directory alone.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "marker"), nil, 0600))
+	require.NoError(t, pullOCIManifest(context.TODO(), "oci://registry.example.com/app:1.0", dir, pullSecretFile))
+	assert.FileExists(t, filepath.Join(dir, "marker"))
+}
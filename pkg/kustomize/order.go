@@ -0,0 +1,103 @@
+package kustomize
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// namespaceFileName is the file of a kustomization which is applied before
+// the kustomization itself.
+const namespaceFileName = "namespace.yaml"
+
+// orderIndex returns the ordering index of a kustomization directory named
+// NN-name, and whether it has one.
+func orderIndex(path string) (int, bool) {
+	prefix, _, found := strings.Cut(filepath.Base(path), "-")
+	if !found || prefix == "" {
+		return 0, false
+	}
+	index, err := strconv.Atoi(prefix)
+	if err != nil || index < 0 {
+		return 0, false
+	}
+	return index, true
+}
+
+// orderKustomizationPaths orders the kustomization paths by the index of
+// their NN-name directories. The paths without an index come first, in the
+// order they were found.
+func orderKustomizationPaths(paths []string) []string {
+	ordered := slices.Clone(paths)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, aOK := orderIndex(ordered[i])
+		b, bOK := orderIndex(ordered[j])
+		if aOK != bOK {
+			return bOK
+		}
+		return a < b
+	})
+	return ordered
+}
+
+// missingNamespaces returns the namespaces the objects are created in,
+// except those defined by the objects themselves.
+func missingNamespaces(objects []objectRef) []string {
+	var namespaces []string
+	for _, o := range objects {
+		if o.Namespace != "" && !slices.Contains(namespaces, o.Namespace) {
+			namespaces = append(namespaces, o.Namespace)
+		}
+	}
+	return slices.DeleteFunc(namespaces, func(ns string) bool {
+		return slices.ContainsFunc(objects, func(o objectRef) bool {
+			return o.Group == "" && o.Kind == "Namespace" && o.Name == ns
+		})
+	})
+}
+
+// applyWithNamespaces creates the namespaces the kustomization needs but
+// does not define, then applies it.
+func (s *Kustomizer) applyWithNamespaces(ctx context.Context) func(string, string) error {
+	return func(path, kubeconfig string) error {
+		objects, err := renderedObjects(path)
+		if err != nil {
+			return err
+		}
+		if err := s.createNamespaces(ctx, missingNamespaces(objects)); err != nil {
+			return err
+		}
+		return applyKustomization(path, kubeconfig, s.forceConflicts())
+	}
+}
+
+func (s *Kustomizer) createNamespaces(ctx context.Context, namespaces []string) error {
+	if len(namespaces) == 0 {
+		return nil
+	}
+	client, err := s.getClient()
+	if err != nil {
+		return err
+	}
+	for _, ns := range namespaces {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}}
+		_, err := client.CoreV1().Namespaces().Create(ctx, namespace, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create namespace %q: %w", ns, err)
+		}
+		klog.Infof("Created namespace %q", ns)
+	}
+	return nil
+}
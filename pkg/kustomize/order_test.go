@@ -0,0 +1,74 @@
+package kustomize
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestOrderKustomizationPaths(t *testing.T) {
+	paths := []string{
+		"/etc/microshift/manifests",
+		"/etc/microshift/manifests.d/20-app",
+		"/usr/lib/microshift/manifests.d/10-operator",
+		"/etc/microshift/manifests.d/app",
+		"/etc/microshift/manifests.d/2-crds",
+		"/usr/lib/microshift/manifests.d/001-namespaces",
+	}
+	assert.Equal(t, []string{
+		"/etc/microshift/manifests",
+		"/etc/microshift/manifests.d/app",
+		"/usr/lib/microshift/manifests.d/001-namespaces",
+		"/etc/microshift/manifests.d/2-crds",
+		"/usr/lib/microshift/manifests.d/10-operator",
+		"/etc/microshift/manifests.d/20-app",
+	}, orderKustomizationPaths(paths))
+}
+
+func TestOrderIndex(t *testing.T) {
+	for path, want := range map[string]int{
+		"/m/00-base": 0,
+		"/m/15-app":  15,
+	} {
+		index, ok := orderIndex(path)
+		assert.True(t, ok, path)
+		assert.Equal(t, want, index, path)
+	}
+	for _, path := range []string{"/m/app", "/m/-app", "/m/v1-app", "/m/10"} {
+		_, ok := orderIndex(path)
+		assert.False(t, ok, path)
+	}
+}
+
+func TestMissingNamespaces(t *testing.T) {
+	objects := []objectRef{
+		{Version: "v1", Kind: "Namespace", Name: "defined"},
+		{Version: "v1", Kind: "ConfigMap", Namespace: "defined", Name: "a"},
+		{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "app", Name: "a"},
+		{Version: "v1", Kind: "Service", Namespace: "app", Name: "a"},
+		{Version: "v1", Kind: "ConfigMap", Name: "default-namespace"},
+		{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole", Name: "a"},
+		{Version: "v1", Kind: "Secret", Namespace: "other", Name: "a"},
+	}
+	assert.Equal(t, []string{"app", "other"}, missingNamespaces(objects))
+}
+
+func TestCreateNamespaces(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "existing"}})
+	s := &Kustomizer{client: client}
+
+	require.NoError(t, s.createNamespaces(context.TODO(), []string{"existing", "app"}))
+
+	namespaces, err := client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	require.NoError(t, err)
+	var names []string
+	for _, ns := range namespaces.Items {
+		names = append(names, ns.Name)
+	}
+	assert.ElementsMatch(t, []string{"existing", "app"}, names)
+}
@@ -0,0 +1,247 @@
+package kustomize
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/openshift/microshift/pkg/config"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+)
+
+// pruneAnnotation, set to "true" in the metadata of a kustomization file,
+// deletes the resources the kustomization applied before and no longer
+// defines, and all of them once the kustomization is removed.
+const pruneAnnotation = "microshift.io/prune"
+
+// inventoryFile records the resources applied by the kustomizations which
+// opt in to pruning, by kustomization path.
+func inventoryFile() string { return filepath.Join(config.DataDir, "kustomize-inventory.json") }
+
+type objectRef struct {
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+func (r objectRef) String() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s %s", r.Kind, r.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", r.Kind, r.Namespace, r.Name)
+}
+
+type inventory map[string][]objectRef
+
+func loadInventory(path string) (inventory, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return inventory{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifests inventory: %w", err)
+	}
+	inv := inventory{}
+	if err := json.Unmarshal(data, &inv); err != nil {
+		return nil, fmt.Errorf("failed to parse manifests inventory %q: %w", path, err)
+	}
+	return inv, nil
+}
+
+func (inv inventory) save(path string) error {
+	data, err := json.Marshal(inv)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write manifests inventory: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// pruneEnabled reports whether the kustomization at path opts in to pruning.
+func pruneEnabled(path string) (bool, error) {
+	k, err := readKustomization(path)
+	if err != nil || k == nil {
+		return false, err
+	}
+	return k.MetaData != nil && k.MetaData.Annotations[pruneAnnotation] == "true", nil
+}
+
+// renderedObjects returns the resources defined by the kustomization at path.
+func renderedObjects(path string) ([]objectRef, error) {
+	resources, err := Render(path)
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]objectRef, 0, resources.Size())
+	for _, r := range resources.Resources() {
+		id := r.CurId()
+		refs = append(refs, objectRef{
+			Group:     id.Group,
+			Version:   id.Version,
+			Kind:      id.Kind,
+			Namespace: id.Namespace,
+			Name:      id.Name,
+		})
+	}
+	return refs, nil
+}
+
+// staleObjects returns the objects of previous which are not in current.
+// The version is ignored, so that moving a resource to a new API version
+// does not delete it.
+func staleObjects(previous, current []objectRef) []objectRef {
+	var stale []objectRef
+	for _, p := range previous {
+		if !slices.ContainsFunc(current, func(c objectRef) bool {
+			return c.Group == p.Group && c.Kind == p.Kind && c.Namespace == p.Namespace && c.Name == p.Name
+		}) {
+			stale = append(stale, p)
+		}
+	}
+	return stale
+}
+
+type pruner struct {
+	client dynamic.Interface
+	mapper meta.RESTMapper
+}
+
+func newPruner(kubeconfig string) (*pruner, error) {
+	restConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &pruner{
+		client: client,
+		mapper: restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient)),
+	}, nil
+}
+
+// delete deletes the objects, ignoring those already gone, including with
+// their CRD.
+func (p *pruner) delete(ctx context.Context, refs []objectRef) error {
+	var errs []error
+	for _, ref := range refs {
+		mapping, err := p.mapper.RESTMapping(schema.GroupKind{Group: ref.Group, Kind: ref.Kind}, ref.Version)
+		if meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to find the resource of %s: %w", ref, err))
+			continue
+		}
+
+		policy := metav1.DeletePropagationBackground
+		err = p.resource(mapping, ref.Namespace).Delete(ctx, ref.Name, metav1.DeleteOptions{PropagationPolicy: &policy})
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete %s: %w", ref, err))
+			continue
+		}
+		klog.Infof("Pruned %s no longer defined by the manifests", ref)
+	}
+	return errors.Join(errs...)
+}
+
+// resource returns the client of the resource of mapping, in namespace
+// when it is namespaced.
+func (p *pruner) resource(mapping *meta.RESTMapping, namespace string) dynamic.ResourceInterface {
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return p.client.Resource(mapping.Resource)
+	}
+	// kubectl applies the namespaced objects without a namespace in the
+	// default one.
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+	return p.client.Resource(mapping.Resource).Namespace(namespace)
+}
+
+func (s *Kustomizer) getPruner() (*pruner, error) {
+	if s.pruner == nil {
+		p, err := newPruner(s.kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create pruner: %w", err)
+		}
+		s.pruner = p
+	}
+	return s.pruner, nil
+}
+
+// pruneKustomization deletes the resources the kustomization at path, just
+// applied, no longer defines, and records the ones it does in inv.
+func (s *Kustomizer) pruneKustomization(ctx context.Context, inv inventory, path string) {
+	enabled, err := pruneEnabled(path)
+	if err != nil {
+		klog.Errorf("Not pruning kustomization at %v: %v", path, err)
+		return
+	}
+	if !enabled {
+		delete(inv, path)
+		return
+	}
+	current, err := renderedObjects(path)
+	if err != nil {
+		klog.Errorf("Not pruning kustomization at %v: %v", path, err)
+		return
+	}
+
+	stale := staleObjects(inv[path], current)
+	if len(stale) != 0 {
+		if err := s.deleteObjects(ctx, stale); err != nil {
+			klog.Errorf("Failed to prune kustomization at %v, retrying on the next apply: %v", path, err)
+			// Keep the stale objects to delete them next time.
+			current = append(current, stale...)
+		}
+	}
+	inv[path] = current
+}
+
+// pruneRemovedKustomizations deletes the resources of the kustomizations of
+// inv which no longer exist.
+func (s *Kustomizer) pruneRemovedKustomizations(ctx context.Context, inv inventory, kustomizationPaths []string) {
+	for path, refs := range inv {
+		if slices.Contains(kustomizationPaths, path) {
+			continue
+		}
+		klog.Infof("Kustomization at %v was removed, pruning its resources", path)
+		if err := s.deleteObjects(ctx, refs); err != nil {
+			klog.Errorf("Failed to prune removed kustomization at %v, retrying on the next apply: %v", path, err)
+			continue
+		}
+		delete(inv, path)
+	}
+}
+
+func (s *Kustomizer) deleteObjects(ctx context.Context, refs []objectRef) error {
+	p, err := s.getPruner()
+	if err != nil {
+		return err
+	}
+	return p.delete(ctx, refs)
+}
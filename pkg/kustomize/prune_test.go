@@ -0,0 +1,90 @@
+package kustomize
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneEnabled(t *testing.T) {
+	dir := t.TempDir()
+	enabled, err := pruneEnabled(dir)
+	require.NoError(t, err)
+	assert.False(t, enabled, "a directory without kustomization should not be pruned")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kustomization.yaml"), []byte(`resources:
+- cm.yaml
+`), 0600))
+	enabled, err = pruneEnabled(dir)
+	require.NoError(t, err)
+	assert.False(t, enabled, "pruning should be opt-in")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kustomization.yaml"), []byte(`metadata:
+  annotations:
+    microshift.io/prune: "true"
+resources:
+- cm.yaml
+`), 0600))
+	enabled, err = pruneEnabled(dir)
+	require.NoError(t, err)
+	assert.True(t, enabled)
+}
+
+func TestRenderedObjects(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kustomization.yaml"), []byte(`metadata:
+  annotations:
+    microshift.io/prune: "true"
+namespace: app
+resources:
+- resources.yaml
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "resources.yaml"), []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+`), 0600))
+
+	refs, err := renderedObjects(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []objectRef{
+		{Version: "v1", Kind: "ConfigMap", Namespace: "app", Name: "settings"},
+		{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "app", Name: "app"},
+	}, refs)
+}
+
+func TestStaleObjects(t *testing.T) {
+	previous := []objectRef{
+		{Version: "v1", Kind: "ConfigMap", Namespace: "app", Name: "settings"},
+		{Version: "v1", Kind: "ConfigMap", Namespace: "app", Name: "legacy"},
+		{Group: "example.com", Version: "v1alpha1", Kind: "Widget", Name: "widget"},
+	}
+	current := []objectRef{
+		{Version: "v1", Kind: "ConfigMap", Namespace: "app", Name: "settings"},
+		{Group: "example.com", Version: "v1", Kind: "Widget", Name: "widget"},
+	}
+	assert.Equal(t, []objectRef{
+		{Version: "v1", Kind: "ConfigMap", Namespace: "app", Name: "legacy"},
+	}, staleObjects(previous, current))
+}
+
+func TestInventory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory.json")
+	inv, err := loadInventory(path)
+	require.NoError(t, err)
+	assert.Empty(t, inv)
+
+	inv["/etc/microshift/manifests"] = []objectRef{{Version: "v1", Kind: "ConfigMap", Namespace: "app", Name: "settings"}}
+	require.NoError(t, inv.save(path))
+	loaded, err := loadInventory(path)
+	require.NoError(t, err)
+	assert.Equal(t, inv, loaded)
+}
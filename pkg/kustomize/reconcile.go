@@ -0,0 +1,129 @@
+package kustomize
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/klog/v2"
+)
+
+// manifestsSettleDelay lets the files of a manifests update, such as an
+// ostree deployment or a copy, all land before applying them.
+const manifestsSettleDelay = 2 * time.Second
+
+// reconcile applies the manifests again every interval, and as soon as the
+// files of the manifests directories change, until ctx is done.
+func (s *Kustomizer) reconcile(ctx context.Context, interval time.Duration) error {
+	klog.Infof("Reconciling manifests every %v and on changes", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var events <-chan fsnotify.Event
+	var errors <-chan error
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		klog.Warningf("Failed to watch the manifests directories, only reconciling every %v: %v", interval, err)
+	} else {
+		defer watcher.Close()
+		events, errors = watcher.Events, watcher.Errors
+		watchDirs(watcher, s.manifestDirs())
+	}
+	var settle <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-ticker.C:
+			s.reconcileManifests(ctx, "interval")
+
+		case event := <-events:
+			klog.V(2).Infof("Manifests changed: %v", event)
+			if settle == nil {
+				settle = time.After(manifestsSettleDelay)
+			}
+
+		case err := <-errors:
+			klog.Warningf("Error watching the manifests directories: %v", err)
+
+		case <-settle:
+			settle = nil
+			klog.Infof("Manifests changed, applying them again")
+			// Watch the directories created meanwhile.
+			watchDirs(watcher, s.manifestDirs())
+			s.reconcileManifests(ctx, "change")
+		}
+	}
+}
+
+// reconcileManifests applies the manifests again, in a trace of its own
+// rather than in the one of the start of MicroShift.
+func (s *Kustomizer) reconcileManifests(ctx context.Context, trigger string) {
+	ctx, span := tracing.Tracer().Start(ctx, "reconcile manifests", trace.WithNewRoot(),
+		trace.WithAttributes(attribute.String("microshift.reconcile.trigger", trigger)))
+	defer span.End()
+	if err := s.applyManifests(ctx); err != nil {
+		klog.Errorf("Failed to reconcile manifests: %v", err)
+	}
+}
+
+// manifestDirs returns the existing directories holding the manifests: the
+// configured paths, the parent directories of the glob patterns, where new
+// kustomizations appear, and the directories under them.
+func (s *Kustomizer) manifestDirs() []string {
+	var roots []string
+	for _, path := range s.cfg.Manifests.KustomizePaths {
+		// The OCI artifacts are pulled again on every reconciliation.
+		if strings.HasPrefix(path, config.OCIScheme) {
+			continue
+		}
+		if !strings.ContainsAny(path, "*?[") {
+			roots = append(roots, path)
+			continue
+		}
+		// e.g. /etc/microshift/manifests.d for /etc/microshift/manifests.d/*
+		dir := path
+		for strings.ContainsAny(dir, "*?[") {
+			dir = filepath.Dir(dir)
+		}
+		roots = append(roots, dir)
+	}
+
+	var dirs []string
+	for _, root := range roots {
+		_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// Missing directories are picked up by the periodic
+				// reconciliation once created.
+				return nil
+			}
+			if d.IsDir() {
+				dirs = append(dirs, path)
+			}
+			return nil
+		})
+	}
+	return dirs
+}
+
+func watchDirs(watcher *fsnotify.Watcher, dirs []string) {
+	if watcher == nil {
+		return
+	}
+	for _, dir := range dirs {
+		if err := watcher.Add(dir); err != nil && !os.IsNotExist(err) {
+			klog.Warningf("Failed to watch manifests directory %q: %v", dir, err)
+		}
+	}
+}
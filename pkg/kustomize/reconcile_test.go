@@ -0,0 +1,61 @@
+package kustomize
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestManifestDirs(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"manifests/base", "manifests.d/app1", "manifests.d/app2/delete"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, d), 0700))
+	}
+
+	s := &Kustomizer{cfg: &config.Config{Manifests: config.Manifests{KustomizePaths: []string{
+		filepath.Join(dir, "manifests"),
+		filepath.Join(dir, "manifests.d/*"),
+		filepath.Join(dir, "missing"),
+	}}}}
+	assert.Equal(t, []string{
+		filepath.Join(dir, "manifests"),
+		filepath.Join(dir, "manifests/base"),
+		filepath.Join(dir, "manifests.d"),
+		filepath.Join(dir, "manifests.d/app1"),
+		filepath.Join(dir, "manifests.d/app2"),
+		filepath.Join(dir, "manifests.d/app2/delete"),
+	}, s.manifestDirs())
+}
+
+func TestApplyStatusReport(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	s := &applyStatus{
+		recorder: recorder,
+		node:     &corev1.ObjectReference{Kind: "Node", Name: "node"},
+		failed:   map[string]bool{},
+	}
+
+	s.Report("/etc/microshift/manifests", "Applying", nil)
+	s.Report("/etc/microshift/manifests", "Applying", nil)
+	s.Report("/etc/microshift/manifests", "Applying", errors.New("boom"))
+	s.Report("/etc/microshift/manifests", "Applying", errors.New("boom"))
+	s.Report("/etc/microshift/manifests/delete", "Deleting", nil)
+	close(recorder.Events)
+
+	var events []string
+	for event := range recorder.Events {
+		events = append(events, event)
+	}
+	assert.Equal(t, []string{
+		"Normal KustomizationApplied Applying kustomization at /etc/microshift/manifests was successful",
+		"Warning KustomizationApplyFailed Applying kustomization at /etc/microshift/manifests failed: boom",
+		"Normal KustomizationDeleted Deleting kustomization at /etc/microshift/manifests/delete was successful",
+	}, events, "events should only be recorded when the outcome of a kustomization changes")
+}
@@ -0,0 +1,136 @@
+package kustomize
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/yaml"
+)
+
+// containerListFields are the fields of a pod spec holding containers.
+var containerListFields = []string{"containers", "initContainers", "ephemeralContainers"}
+
+// Render builds the kustomization at path without applying it to the cluster,
+// or renders the chart of the chart directory at path.
+func Render(path string) (resmap.ResMap, error) {
+	archive, err := chartArchive(path)
+	if err != nil {
+		return nil, err
+	}
+	fSys := filesys.MakeFsOnDisk()
+	if archive != "" {
+		if fSys, path, err = renderChart(path, archive); err != nil {
+			return nil, fmt.Errorf("failed to render chart %q: %w", archive, err)
+		}
+	}
+	k := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
+	resources, err := k.Run(fSys, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render kustomization %q: %w", path, err)
+	}
+	return resources, nil
+}
+
+// readKustomization reads the kustomization file at path, it returns nil
+// when there is none.
+func readKustomization(path string) (*types.Kustomization, error) {
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		data, err := os.ReadFile(filepath.Join(path, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var k types.Kustomization
+		if err := yaml.Unmarshal(data, &k); err != nil {
+			return nil, fmt.Errorf("failed to parse kustomization %q: %w", path, err)
+		}
+		return &k, nil
+	}
+	return nil, nil
+}
+
+// renderToFile renders the kustomization at path into a temporary file, to
+// be removed by the caller.
+func renderToFile(path string) (string, error) {
+	resources, err := Render(path)
+	if err != nil {
+		return "", err
+	}
+	data, err := resources.AsYaml()
+	if err != nil {
+		return "", fmt.Errorf("failed to render kustomization %q: %w", path, err)
+	}
+	f, err := os.CreateTemp("", "microshift-kustomization-*.yaml")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// ContainerImages returns the sorted list of container images referenced by
+// the workloads in the kustomization at path, after kustomize transformations
+// such as `images:` overrides are applied.
+func ContainerImages(path string) ([]string, error) {
+	resources, err := Render(path)
+	if err != nil {
+		return nil, err
+	}
+
+	images := sets.New[string]()
+	for _, r := range resources.Resources() {
+		obj, err := r.Map()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s in kustomization %q: %w", r.CurId(), path, err)
+		}
+		collectContainerImages(obj, images)
+	}
+
+	result := sets.List(images)
+	sort.Strings(result)
+	return result, nil
+}
+
+// collectContainerImages walks an arbitrary object looking for container
+// lists, so that every workload kind (including CRDs embedding pod templates)
+// is covered without knowing its schema.
+func collectContainerImages(obj any, images sets.Set[string]) {
+	switch o := obj.(type) {
+	case map[string]any:
+		for _, field := range containerListFields {
+			containers, ok := o[field].([]any)
+			if !ok {
+				continue
+			}
+			for _, c := range containers {
+				if container, ok := c.(map[string]any); ok {
+					if image, ok := container["image"].(string); ok && image != "" {
+						images.Insert(image)
+					}
+				}
+			}
+		}
+		for _, v := range o {
+			collectContainerImages(v, images)
+		}
+	case []any:
+		for _, v := range o {
+			collectContainerImages(v, images)
+		}
+	}
+}
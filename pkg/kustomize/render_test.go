@@ -0,0 +1,133 @@
+package kustomize
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+func TestContainerImages(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"kustomization.yaml": `resources:
+- deployment.yaml
+- cronjob.yaml
+images:
+- name: quay.io/example/app
+  newTag: v2
+`,
+		"deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: quay.io/example/init:v1
+      containers:
+      - name: app
+        image: quay.io/example/app:v1
+      - name: sidecar
+        image: quay.io/example/init:v1
+`,
+		"cronjob.yaml": `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: job
+spec:
+  schedule: "* * * * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: job
+            image: quay.io/example/job@sha256:0000000000000000000000000000000000000000000000000000000000000000
+`,
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+
+	images, err := ContainerImages(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"quay.io/example/app:v2",
+		"quay.io/example/init:v1",
+		"quay.io/example/job@sha256:0000000000000000000000000000000000000000000000000000000000000000",
+	}, images)
+}
+
+func TestContainerImagesMissingKustomization(t *testing.T) {
+	_, err := ContainerImages(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestRenderChart(t *testing.T) {
+	dir := t.TempDir()
+	_, err := chartutil.Save(&chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "app", Version: "1.0.0"},
+		Values:   map[string]any{"image": "quay.io/example/app:v1"},
+		Templates: []*chart.File{
+			{Name: "templates/deployment.yaml", Data: []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: {{ .Values.image }}
+`)},
+			{Name: "templates/clusterrole.yaml", Data: []byte(`apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: {{ .Release.Name }}
+`)},
+			{Name: "templates/hook.yaml", Data: []byte(`apiVersion: batch/v1
+kind: Job
+metadata:
+  name: {{ .Release.Name }}-test
+  annotations:
+    helm.sh/hook: test
+`)},
+			{Name: "templates/NOTES.txt", Data: []byte("Installed {{ .Release.Name }}.\n")},
+		},
+	}, dir)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, chartValuesFile), []byte("image: quay.io/example/app:v2\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, namespaceFileName), []byte(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: example
+`), 0600))
+
+	resources, err := Render(dir)
+	require.NoError(t, err)
+	var ids []string
+	for _, r := range resources.Resources() {
+		ids = append(ids, r.CurId().String())
+	}
+	assert.ElementsMatch(t, []string{
+		"ClusterRole.v1.rbac.authorization.k8s.io/app.[noNs]",
+		"Deployment.v1.apps/app.example",
+	}, ids)
+
+	images, err := ContainerImages(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"quay.io/example/app:v2"}, images)
+
+	// A kustomization takes precedence over the chart archive.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kustomization.yaml"), []byte("resources: []\n"), 0600))
+	archive, err := chartArchive(dir)
+	require.NoError(t, err)
+	assert.Empty(t, archive)
+}
@@ -0,0 +1,203 @@
+package kustomize
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// waitForRolloutAnnotation, set to "true" in the metadata of a
+	// kustomization file, waits for the Deployments, StatefulSets and
+	// DaemonSets of the kustomization to roll out once it is applied.
+	waitForRolloutAnnotation = "microshift.io/wait-for-rollout"
+	// rolloutTimeoutAnnotation overrides how long to wait for the rollout,
+	// as a duration, e.g. "10m".
+	rolloutTimeoutAnnotation = "microshift.io/rollout-timeout"
+
+	defaultRolloutTimeout = 5 * time.Minute
+	rolloutPollInterval   = 5 * time.Second
+)
+
+func (s *Kustomizer) getClient() (kubernetes.Interface, error) {
+	if s.client == nil {
+		restConfig, err := clientcmd.BuildConfigFromFlags("", s.kubeconfig)
+		if err != nil {
+			return nil, err
+		}
+		client, err := kubernetes.NewForConfig(restConfig)
+		if err != nil {
+			return nil, err
+		}
+		s.client = client
+	}
+	return s.client, nil
+}
+
+// waitForRollout waits for the workloads of the kustomization at path to
+// roll out, when it opts in to it.
+func (s *Kustomizer) waitForRollout(ctx context.Context, path string) error {
+	k, err := readKustomization(path)
+	if err != nil || k == nil || k.MetaData == nil || k.MetaData.Annotations[waitForRolloutAnnotation] != "true" {
+		return err
+	}
+	timeout := defaultRolloutTimeout
+	if value, ok := k.MetaData.Annotations[rolloutTimeoutAnnotation]; ok {
+		if timeout, err = time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid %s %q: %w", rolloutTimeoutAnnotation, value, err)
+		}
+	}
+	refs, err := renderedObjects(path)
+	if err != nil {
+		return err
+	}
+	client, err := s.getClient()
+	if err != nil {
+		return err
+	}
+
+	klog.Infof("Waiting up to %v for the workloads of kustomization at %v to roll out", timeout, path)
+	var pending string
+	err = wait.PollUntilContextTimeout(ctx, rolloutPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		for _, ref := range refs {
+			rolledOut, reason, err := rolloutStatus(ctx, client, ref)
+			if err != nil {
+				pending = err.Error()
+				return false, nil
+			}
+			if !rolledOut {
+				pending = fmt.Sprintf("%s: %s", ref, reason)
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("workloads did not roll out within %v: %s", timeout, pending)
+	}
+	klog.Infof("Workloads of kustomization at %v rolled out", path)
+	return nil
+}
+
+func rolloutStatus(ctx context.Context, client kubernetes.Interface, ref objectRef) (bool, string, error) {
+	if ref.Group != appsv1.GroupName {
+		return true, "", nil
+	}
+	// kubectl applies the namespaced objects without a namespace in the
+	// default one.
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+	switch ref.Kind {
+	case "Deployment":
+		d, err := client.AppsV1().Deployments(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, "", err
+		}
+		rolledOut, reason := deploymentRolledOut(d)
+		return rolledOut, reason, nil
+	case "StatefulSet":
+		ss, err := client.AppsV1().StatefulSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, "", err
+		}
+		rolledOut, reason := statefulSetRolledOut(ss)
+		return rolledOut, reason, nil
+	case "DaemonSet":
+		ds, err := client.AppsV1().DaemonSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, "", err
+		}
+		rolledOut, reason := daemonSetRolledOut(ds)
+		return rolledOut, reason, nil
+	}
+	return true, "", nil
+}
+
+// The rollout checks follow the ones of kubectl rollout status.
+
+func deploymentRolledOut(d *appsv1.Deployment) (bool, string) {
+	if d.Generation > d.Status.ObservedGeneration {
+		return false, "waiting for the spec update to be observed"
+	}
+	replicas := ptr.Deref(d.Spec.Replicas, 1)
+	switch {
+	case d.Status.UpdatedReplicas < replicas:
+		return false, fmt.Sprintf("%d of %d replicas updated", d.Status.UpdatedReplicas, replicas)
+	case d.Status.Replicas > d.Status.UpdatedReplicas:
+		return false, fmt.Sprintf("%d old replicas pending termination", d.Status.Replicas-d.Status.UpdatedReplicas)
+	case d.Status.AvailableReplicas < d.Status.UpdatedReplicas:
+		return false, fmt.Sprintf("%d of %d updated replicas available", d.Status.AvailableReplicas, d.Status.UpdatedReplicas)
+	}
+	return true, ""
+}
+
+func statefulSetRolledOut(ss *appsv1.StatefulSet) (bool, string) {
+	if ss.Generation > ss.Status.ObservedGeneration {
+		return false, "waiting for the spec update to be observed"
+	}
+	replicas := ptr.Deref(ss.Spec.Replicas, 1)
+	if ss.Status.ReadyReplicas < replicas {
+		return false, fmt.Sprintf("%d of %d replicas ready", ss.Status.ReadyReplicas, replicas)
+	}
+	if ss.Spec.UpdateStrategy.Type == appsv1.RollingUpdateStatefulSetStrategyType && ss.Status.UpdateRevision != ss.Status.CurrentRevision {
+		return false, fmt.Sprintf("%d of %d replicas updated", ss.Status.UpdatedReplicas, replicas)
+	}
+	return true, ""
+}
+
+func daemonSetRolledOut(ds *appsv1.DaemonSet) (bool, string) {
+	if ds.Generation > ds.Status.ObservedGeneration {
+		return false, "waiting for the spec update to be observed"
+	}
+	if ds.Status.UpdatedNumberScheduled < ds.Status.DesiredNumberScheduled {
+		return false, fmt.Sprintf("%d of %d pods updated", ds.Status.UpdatedNumberScheduled, ds.Status.DesiredNumberScheduled)
+	}
+	if ds.Status.NumberAvailable < ds.Status.DesiredNumberScheduled {
+		return false, fmt.Sprintf("%d of %d updated pods available", ds.Status.NumberAvailable, ds.Status.DesiredNumberScheduled)
+	}
+	return true, ""
+}
+
+type applyResult struct {
+	path string
+	verb string
+	err  error
+}
+
+// summarize describes the outcome of the kustomizations, one per line.
+func summarize(results []applyResult) (string, int) {
+	var b strings.Builder
+	failed := 0
+	for _, r := range results {
+		if r.err != nil {
+			failed++
+			fmt.Fprintf(&b, "\n  %s %s: failed: %v", r.verb, r.path, r.err)
+		} else {
+			fmt.Fprintf(&b, "\n  %s %s: succeeded", r.verb, r.path)
+		}
+	}
+	return fmt.Sprintf("%d of %d kustomizations succeeded:%s", len(results)-failed, len(results), b.String()), failed
+}
+
+func logSummary(results []applyResult) {
+	if len(results) == 0 {
+		return
+	}
+	summary, failed := summarize(results)
+	if failed != 0 {
+		klog.Errorf("Manifests: %s", summary)
+	} else {
+		klog.Infof("Manifests: %s", summary)
+	}
+}
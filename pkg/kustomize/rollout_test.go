@@ -0,0 +1,93 @@
+package kustomize
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestDeploymentRolledOut(t *testing.T) {
+	tests := []struct {
+		name   string
+		status appsv1.DeploymentStatus
+		want   bool
+	}{
+		{
+			name:   "spec update not observed",
+			status: appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+		},
+		{
+			name:   "replicas not updated",
+			status: appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 1, AvailableReplicas: 2},
+		},
+		{
+			name:   "old replicas pending termination",
+			status: appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 2, AvailableReplicas: 2},
+		},
+		{
+			name:   "updated replicas not available",
+			status: appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 1},
+		},
+		{
+			name:   "rolled out",
+			status: appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](2)},
+				Status:     tt.status,
+			}
+			got, reason := deploymentRolledOut(d)
+			assert.Equal(t, tt.want, got, reason)
+		})
+	}
+}
+
+func TestStatefulSetRolledOut(t *testing.T) {
+	ss := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Generation: 1},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:       ptr.To[int32](1),
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.RollingUpdateStatefulSetStrategyType},
+		},
+		Status: appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 1, CurrentRevision: "a", UpdateRevision: "b"},
+	}
+	rolledOut, _ := statefulSetRolledOut(ss)
+	assert.False(t, rolledOut, "a statefulset with pending revision update should not be rolled out")
+
+	ss.Status.CurrentRevision = "b"
+	rolledOut, _ = statefulSetRolledOut(ss)
+	assert.True(t, rolledOut)
+}
+
+func TestDaemonSetRolledOut(t *testing.T) {
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Generation: 1},
+		Status:     appsv1.DaemonSetStatus{ObservedGeneration: 1, DesiredNumberScheduled: 1, UpdatedNumberScheduled: 1},
+	}
+	rolledOut, _ := daemonSetRolledOut(ds)
+	assert.False(t, rolledOut, "a daemonset without available pods should not be rolled out")
+
+	ds.Status.NumberAvailable = 1
+	rolledOut, _ = daemonSetRolledOut(ds)
+	assert.True(t, rolledOut)
+}
+
+func TestSummarize(t *testing.T) {
+	summary, failed := summarize([]applyResult{
+		{path: "/etc/microshift/manifests/delete", verb: "Deleting"},
+		{path: "/etc/microshift/manifests", verb: "Applying", err: errors.New("boom")},
+	})
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, `1 of 2 kustomizations succeeded:
+  Deleting /etc/microshift/manifests/delete: succeeded
+  Applying /etc/microshift/manifests: failed: boom`, summary)
+}
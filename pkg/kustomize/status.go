@@ -0,0 +1,110 @@
+package kustomize
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+var (
+	kustomizationSuccess = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Name:           "microshift_kustomization_success",
+			Help:           "Whether the last apply, or delete, of the kustomization at path succeeded (1) or failed (0).",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"path"},
+	)
+	kustomizationTimestamp = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Name:           "microshift_kustomization_timestamp_seconds",
+			Help:           "Unix time of the last apply, or delete, of the kustomization at path.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"path"},
+	)
+)
+
+func init() {
+	legacyregistry.MustRegister(kustomizationSuccess, kustomizationTimestamp)
+}
+
+// applyStatus reports the outcome of the kustomizations with metrics, and
+// with Events on the node when it changes, so that reapplying them does not
+// flood the events.
+type applyStatus struct {
+	sync.Mutex
+	recorder    record.EventRecorder
+	broadcaster record.EventBroadcaster
+	node        *corev1.ObjectReference
+	// failed records whether the last outcome of each path was a failure.
+	failed map[string]bool
+}
+
+func newApplyStatus(ctx context.Context, kubeconfig, nodeName string) *applyStatus {
+	s := &applyStatus{
+		node:   &corev1.ObjectReference{Kind: "Node", Name: nodeName, UID: types.UID(nodeName)},
+		failed: map[string]bool{},
+	}
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		klog.Warningf("Not recording kustomization events: %v", err)
+		return s
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		klog.Warningf("Not recording kustomization events: %v", err)
+		return s
+	}
+	s.broadcaster = record.NewBroadcaster(record.WithContext(ctx))
+	s.broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	s.recorder = s.broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "microshift-kustomizer"})
+	return s
+}
+
+// Report records the outcome of verb, Applying or Deleting, on the
+// kustomization at path.
+func (s *applyStatus) Report(path, verb string, err error) {
+	kustomizationTimestamp.WithLabelValues(path).Set(float64(time.Now().Unix()))
+	if err != nil {
+		kustomizationSuccess.WithLabelValues(path).Set(0)
+	} else {
+		kustomizationSuccess.WithLabelValues(path).Set(1)
+	}
+
+	s.Lock()
+	failed, reported := s.failed[path]
+	s.failed[path] = err != nil
+	s.Unlock()
+	if s.recorder == nil || (reported && failed == (err != nil)) {
+		return
+	}
+
+	success, failure := "KustomizationApplied", "KustomizationApplyFailed"
+	if verb == "Deleting" {
+		success, failure = "KustomizationDeleted", "KustomizationDeleteFailed"
+	}
+	if err != nil {
+		s.recorder.Eventf(s.node, corev1.EventTypeWarning, failure, "%s kustomization at %s failed: %v", verb, path, err)
+	} else {
+		s.recorder.Eventf(s.node, corev1.EventTypeNormal, success, "%s kustomization at %s was successful", verb, path)
+	}
+}
+
+func (s *applyStatus) Shutdown() {
+	if s.broadcaster != nil {
+		s.broadcaster.Shutdown()
+	}
+}
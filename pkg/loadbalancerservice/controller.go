@@ -4,27 +4,38 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/netip"
 	"slices"
+	"sync/atomic"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/cloud-provider/service/helpers"
 	"k8s.io/klog/v2"
 
 	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/firewall"
 	"github.com/openshift/microshift/pkg/servicemanager"
+	"github.com/openshift/microshift/pkg/util"
 )
 
 const (
 	defaultInformerResyncPeriod = 10 * time.Minute
+	// workerStallTimeout is how long services may wait in the queue without
+	// any being processed before the controller is reported unhealthy.
+	workerStallTimeout = 5 * time.Minute
 )
 
 type LoadbalancerServiceController struct {
@@ -34,10 +45,29 @@ type LoadbalancerServiceController struct {
 	KubeConfig  string
 	Ipv4        bool
 	Ipv6        bool
-	client      *kubernetes.Clientset
-	indexer     cache.Indexer
-	queue       workqueue.TypedRateLimitingInterface[string]
-	informer    cache.SharedIndexInformer
+	pools       []addressPool
+	// assigned holds the addresses last assigned to the services, by key,
+	// ahead of the informer cache.
+	assigned map[string][]string
+	// pending holds the keys of the services without their addresses.
+	pending  map[string]bool
+	recorder record.EventRecorder
+	// l2 announces the pool addresses on the LAN, when enabled.
+	l2        *l2Announcer
+	l2Enabled bool
+	// firewall opens the ports of the services in firewalldZone, when set.
+	firewall      *firewall.Firewalld
+	firewalldZone string
+	// ports holds the ports of the services with addresses, by key.
+	ports    map[string][]string
+	client   *kubernetes.Clientset
+	indexer  cache.Indexer
+	queue    workqueue.TypedRateLimitingInterface[string]
+	informer cache.SharedIndexInformer
+	// lastProcessed is the unix time of the last processed queue item.
+	lastProcessed atomic.Int64
+	// cfg is the configuration the controller was created from, to renew it.
+	cfg *config.Config
 }
 
 var _ servicemanager.Service = &LoadbalancerServiceController{}
@@ -53,15 +83,56 @@ func NewLoadbalancerServiceController(cfg *config.Config) *LoadbalancerServiceCo
 		}
 	}
 	return &LoadbalancerServiceController{
-		IPAddresses: ipAddresses,
-		NICNames:    nicNames,
-		NodeIP:      cfg.Node.NodeIP,
-		KubeConfig:  cfg.KubeConfigPath(config.KubeAdmin),
-		Ipv4:        cfg.IsIPv4(),
-		Ipv6:        cfg.IsIPv6(),
+		IPAddresses:   ipAddresses,
+		NICNames:      nicNames,
+		NodeIP:        exposedAddress(cfg),
+		KubeConfig:    cfg.KubeConfigPath(config.KubeAdmin),
+		Ipv4:          cfg.IsIPv4(),
+		Ipv6:          cfg.IsIPv6(),
+		pools:         newAddressPools(cfg.LoadBalancer.AddressPools),
+		l2Enabled:     cfg.LoadBalancer.L2Announcement == config.L2AnnouncementEnabled,
+		firewalldZone: cfg.LoadBalancer.FirewalldZone,
+		ports:         map[string][]string{},
+		assigned:      map[string][]string{},
+		pending:       map[string]bool{},
+		cfg:           cfg,
 	}
 }
 
+// exposedAddress returns the address the services without an address pool
+// are assigned: the node IP, unless network.nodePortAddresses restrict the
+// services to other addresses of the host, of the same family.
+func exposedAddress(cfg *config.Config) string {
+	if len(cfg.Network.NodePortAddresses) == 0 {
+		return cfg.Node.NodeIP
+	}
+	exposed, err := util.ResolveAddresses(cfg.Network.NodePortAddresses)
+	if err != nil {
+		klog.Warningf("Assigning the node IP to the LoadBalancer services: %v", err)
+		return cfg.Node.NodeIP
+	}
+	nodeIP := net.ParseIP(cfg.Node.NodeIP)
+	for _, network := range exposed {
+		if network.Contains(nodeIP) {
+			return cfg.Node.NodeIP
+		}
+	}
+	addresses, err := config.AllowedListeningIPAddresses(nodeIP.To4() != nil, nodeIP.To4() == nil)
+	if err != nil {
+		klog.Warningf("Assigning the node IP to the LoadBalancer services: %v", err)
+		return cfg.Node.NodeIP
+	}
+	for _, network := range exposed {
+		for _, address := range addresses {
+			if network.Contains(net.ParseIP(address)) {
+				return address
+			}
+		}
+	}
+	klog.Warningf("No address of the host in network.nodePortAddresses %v, assigning the node IP to the LoadBalancer services", cfg.Network.NodePortAddresses)
+	return cfg.Node.NodeIP
+}
+
 func (c *LoadbalancerServiceController) Name() string {
 	return "microshift-loadbalancer-service-controller"
 }
@@ -74,6 +145,20 @@ func (c *LoadbalancerServiceController) Dependencies() []string {
 	}
 }
 
+// Optional allows disabling the controller at runtime. The status of the
+// LoadBalancer services is left as is while it is disabled.
+func (c *LoadbalancerServiceController) Optional() bool { return true }
+
+// Renew returns a new controller when it is enabled again, with its own
+// queue and informer for the health checks to read.
+func (c *LoadbalancerServiceController) Renew() servicemanager.Service {
+	return NewLoadbalancerServiceController(c.cfg)
+}
+
+// Deferred starts the controller once MicroShift is ready, the LoadBalancer
+// services are only reachable once their workloads run anyway.
+func (c *LoadbalancerServiceController) Deferred() bool { return true }
+
 func (c *LoadbalancerServiceController) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
 	defer close(stopped)
 	stopCh := make(chan struct{})
@@ -90,10 +175,25 @@ func (c *LoadbalancerServiceController) Run(ctx context.Context, ready chan<- st
 
 	klog.Infof("Starting service controller")
 
+	broadcaster := record.NewBroadcaster(record.WithContext(ctx))
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: c.client.CoreV1().Events("")})
+	defer broadcaster.Shutdown()
+	c.recorder = broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: c.Name()})
+	if c.l2Enabled {
+		c.l2 = newL2Announcer(ctx)
+	}
+	if c.firewalldZone != "" {
+		if c.firewall, err = firewall.NewFirewalld(ctx, c.firewalldZone); err != nil {
+			klog.Errorf("Not opening the ports of the LoadBalancer services: %v", err)
+		}
+	}
+
 	factory := informers.NewSharedInformerFactory(c.client, defaultInformerResyncPeriod)
 	serviceInformer := factory.Core().V1().Services()
 	c.informer = serviceInformer.Informer()
 	c.queue = workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[string]())
+	// Stops the worker, which waits for the next item of the queue.
+	defer c.queue.ShutDown()
 	c.indexer = c.informer.GetIndexer()
 	_, err = c.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
@@ -125,6 +225,7 @@ func (c *LoadbalancerServiceController) Run(ctx context.Context, ready chan<- st
 		return fmt.Errorf("timed out waiting for caches to sync")
 	}
 
+	c.lastProcessed.Store(time.Now().Unix())
 	go wait.Until(c.runWorker, time.Second, stopCh)
 
 	go defaultRouterWatch(c.IPAddresses, c.NICNames, c.Ipv4, c.Ipv6, c.updateDefaultRouterServiceStatus, stopCh)
@@ -136,6 +237,20 @@ func (c *LoadbalancerServiceController) Run(ctx context.Context, ready chan<- st
 	return ctx.Err()
 }
 
+// HealthCheck reports the controller unhealthy when the service informer
+// stopped, or when services are queued but the worker is not processing them.
+func (c *LoadbalancerServiceController) HealthCheck(ctx context.Context) error {
+	if c.informer.IsStopped() {
+		return fmt.Errorf("service informer is stopped")
+	}
+	if queued := c.queue.Len(); queued > 0 {
+		if idle := time.Since(time.Unix(c.lastProcessed.Load(), 0)); idle > workerStallTimeout {
+			return fmt.Errorf("%d services queued, none processed for %v", queued, idle.Round(time.Second))
+		}
+	}
+	return nil
+}
+
 func (c *LoadbalancerServiceController) runWorker() {
 	for c.processNextItem() {
 	}
@@ -147,6 +262,7 @@ func (c *LoadbalancerServiceController) processNextItem() bool {
 		return false
 	}
 	defer c.queue.Done(key)
+	defer c.lastProcessed.Store(time.Now().Unix())
 
 	err := c.updateServiceStatus(key)
 	c.handleErr(err, key)
@@ -174,23 +290,41 @@ func (c *LoadbalancerServiceController) updateServiceStatus(key string) error {
 		return err
 	}
 
+	defer c.announce()
+	defer c.openPorts()
+
 	if !exists {
 		klog.Infof("Service %s does not exist anymore", key)
+		delete(c.assigned, key)
+		delete(c.ports, key)
+		c.setPending(key, false)
 	} else {
 		svc := obj.(*corev1.Service)
 		if svc.Spec.Type != corev1.ServiceTypeLoadBalancer || svc.Spec.LoadBalancerClass != nil || isDefaultRouterService(svc) {
+			delete(c.assigned, key)
+			delete(c.ports, key)
+			c.setPending(key, false)
 			return nil
 		}
 		klog.Infof("Process service %s/%s", svc.Namespace, svc.Name)
 
 		newStatus, err := c.getNewStatus(svc)
+		c.setPending(key, err != nil)
 		if err != nil {
+			// Keep the addresses, but report why they cannot be assigned.
+			condition := newCondition(err)
+			if patchErr := c.patchStatus(svc, &svc.Status.LoadBalancer, &condition); patchErr != nil {
+				klog.Errorf("Failed to report the status of service %s: %v", key, patchErr)
+			}
 			return err
 		}
-		err = c.patchStatus(svc, newStatus)
+		condition := newCondition(nil)
+		err = c.patchStatus(svc, newStatus, &condition)
 		if err != nil {
 			return err
 		}
+		c.assigned[key] = ingressIPs(newStatus)
+		c.ports[key] = firewallPorts(svc)
 	}
 	return nil
 }
@@ -201,36 +335,105 @@ func (c *LoadbalancerServiceController) restConfig() (*rest.Config, error) {
 
 func (c *LoadbalancerServiceController) getNewStatus(svc *corev1.Service) (*corev1.LoadBalancerStatus, error) {
 	newStatus := &corev1.LoadBalancerStatus{}
-	objs := c.indexer.List()
-	for _, obj := range objs {
+	addresses, err := c.serviceAddresses(svc, c.otherAssignments(svc))
+	if err == nil {
+		err = checkHostPorts(svc, addresses)
+	}
+	if err != nil {
+		klog.Infof("Service %s/%s: %v", svc.Namespace, svc.Name, err)
+		return newStatus, err
+	}
+	for _, address := range addresses {
+		newStatus.Ingress = append(newStatus.Ingress, corev1.LoadBalancerIngress{
+			IP: address,
+		})
+	}
+	return newStatus, nil
+}
+
+// announce announces the pool addresses assigned to the services on the
+// LAN, when enabled.
+func (c *LoadbalancerServiceController) announce() {
+	if c.l2 == nil {
+		return
+	}
+	var addresses []netip.Addr
+	for _, assigned := range c.assigned {
+		for _, address := range assigned {
+			addr, err := netip.ParseAddr(address)
+			if err != nil || slices.Contains(addresses, addr) {
+				continue
+			}
+			if slices.ContainsFunc(c.pools, func(p addressPool) bool { return p.contains(addr) }) {
+				addresses = append(addresses, addr)
+			}
+		}
+	}
+	c.l2.Set(addresses)
+}
+
+// openPorts opens the ports of the services with addresses in the firewall,
+// when enabled.
+func (c *LoadbalancerServiceController) openPorts() {
+	if c.firewall == nil {
+		return
+	}
+	var ports []string
+	for _, servicePorts := range c.ports {
+		ports = append(ports, servicePorts...)
+	}
+	slices.Sort(ports)
+	c.firewall.SetPorts(slices.Compact(ports))
+}
+
+// otherAssignments returns the addresses assigned to the services but svc.
+func (c *LoadbalancerServiceController) otherAssignments(svc *corev1.Service) []assignment {
+	var others []assignment
+	for _, obj := range c.indexer.List() {
 		s := obj.(*corev1.Service)
-		if (s.Name == svc.Name && s.Namespace == svc.Namespace) || len(s.Status.LoadBalancer.Ingress) == 0 {
+		if s.Name == svc.Name && s.Namespace == svc.Namespace {
 			continue
 		}
-		for _, ep := range s.Spec.Ports {
-			for _, np := range svc.Spec.Ports {
-				if ep.Port == np.Port {
-					klog.Infof("Node port %d occupied", ep.Port)
-					return newStatus, fmt.Errorf("node port %d occupied", ep.Port)
-				}
-			}
+		addresses := ingressIPs(&s.Status.LoadBalancer)
+		if key, err := cache.MetaNamespaceKeyFunc(s); err == nil && c.assigned[key] != nil {
+			addresses = c.assigned[key]
+		}
+		if len(addresses) != 0 {
+			others = append(others, assignment{svc: s, addresses: addresses})
 		}
 	}
+	return others
+}
 
-	newStatus.Ingress = append(newStatus.Ingress, corev1.LoadBalancerIngress{
-		IP: c.NodeIP,
-	})
-	return newStatus, nil
+func ingressIPs(status *corev1.LoadBalancerStatus) []string {
+	ips := make([]string, 0, len(status.Ingress))
+	for _, ingress := range status.Ingress {
+		ips = append(ips, ingress.IP)
+	}
+	return ips
 }
 
-func (c *LoadbalancerServiceController) patchStatus(svc *corev1.Service, newStatus *corev1.LoadBalancerStatus) error {
-	if helpers.LoadBalancerStatusEqual(&svc.Status.LoadBalancer, newStatus) {
+// patchStatus updates the load balancer status of the service and, when not
+// nil, its assigned condition. Changes of the condition are recorded as
+// Events.
+func (c *LoadbalancerServiceController) patchStatus(svc *corev1.Service, newStatus *corev1.LoadBalancerStatus, condition *metav1.Condition) error {
+	changed := condition != nil && conditionChanged(svc, *condition)
+	if helpers.LoadBalancerStatusEqual(&svc.Status.LoadBalancer, newStatus) && !changed {
 		return nil
 	}
 	updated := svc.DeepCopy()
 	updated.Status.LoadBalancer = *newStatus
+	if condition != nil {
+		meta.SetStatusCondition(&updated.Status.Conditions, *condition)
+	}
 	_, err := helpers.PatchService(c.client.CoreV1(), svc, updated)
-
+	if err == nil && changed && c.recorder != nil {
+		eventType := corev1.EventTypeNormal
+		if condition.Status != metav1.ConditionTrue {
+			eventType = corev1.EventTypeWarning
+		}
+		c.recorder.Event(svc, eventType, condition.Reason, condition.Message)
+	}
 	return err
 }
 
@@ -254,7 +457,7 @@ func (c *LoadbalancerServiceController) updateDefaultRouterServiceStatus(ips []s
 			return true, nil
 		}
 		klog.Infof("Updating default router service status: %v", ips)
-		err = c.patchStatus(svc, newStatus)
+		err = c.patchStatus(svc, newStatus, nil)
 		if err != nil {
 			klog.ErrorS(err, "Unable to patch default router service")
 			return false, nil
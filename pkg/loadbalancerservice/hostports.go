@@ -0,0 +1,159 @@
+package loadbalancerservice
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// procNetFiles list the sockets of the host, by protocol.
+var procNetFiles = map[corev1.Protocol][]string{
+	corev1.ProtocolTCP:  {"/proc/net/tcp", "/proc/net/tcp6"},
+	corev1.ProtocolUDP:  {"/proc/net/udp", "/proc/net/udp6"},
+	corev1.ProtocolSCTP: {"/proc/net/sctp/eps"},
+}
+
+// listenStates are the states, in procNetFiles, of the TCP sockets
+// listening for connections and of the UDP sockets receiving datagrams.
+var listenStates = map[corev1.Protocol]string{
+	corev1.ProtocolTCP: "0A",
+	corev1.ProtocolUDP: "07",
+}
+
+type hostSocket struct {
+	addr netip.Addr
+	port int32
+}
+
+// listeningSockets returns the sockets of the host processes listening with
+// the protocol.
+var listeningSockets = func(protocol corev1.Protocol) ([]hostSocket, error) {
+	var sockets []hostSocket
+	for _, path := range procNetFiles[protocol] {
+		var s []hostSocket
+		var err error
+		if protocol == corev1.ProtocolSCTP {
+			s, err = readSCTPEndpoints(path)
+		} else {
+			s, err = readProcNet(path, listenStates[protocol])
+		}
+		// The sctp module may not be loaded.
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		sockets = append(sockets, s...)
+	}
+	return sockets, nil
+}
+
+// readProcNet returns the sockets in state of a /proc/net/tcp, or udp, file.
+func readProcNet(path, state string) ([]hostSocket, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var sockets []hostSocket
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[3] != state {
+			continue
+		}
+		socket, err := parseProcNetAddress(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		sockets = append(sockets, socket)
+	}
+	return sockets, scanner.Err()
+}
+
+// readSCTPEndpoints returns the sockets of the SCTP endpoints of a
+// /proc/net/sctp/eps file, one per local address.
+func readSCTPEndpoints(path string) ([]hostSocket, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var sockets []hostSocket
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		// ENDPT SOCK STY SST HBKT LPORT UID INODE LADDRS...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 9 {
+			continue
+		}
+		port, err := strconv.ParseUint(fields[5], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		for _, field := range fields[8:] {
+			if addr, err := netip.ParseAddr(field); err == nil {
+				sockets = append(sockets, hostSocket{addr: addr.Unmap(), port: int32(port)})
+			}
+		}
+	}
+	return sockets, scanner.Err()
+}
+
+// parseProcNetAddress parses an address such as 0100007F:1F90, made of the
+// 32 bits words of the IP address in host, little endian, order.
+func parseProcNetAddress(s string) (hostSocket, error) {
+	ipHex, portHex, found := strings.Cut(s, ":")
+	if !found {
+		return hostSocket{}, fmt.Errorf("invalid address %q", s)
+	}
+	ip, err := hex.DecodeString(ipHex)
+	if err != nil || (len(ip) != 4 && len(ip) != 16) {
+		return hostSocket{}, fmt.Errorf("invalid address %q", s)
+	}
+	for i := 0; i < len(ip); i += 4 {
+		ip[i], ip[i+1], ip[i+2], ip[i+3] = ip[i+3], ip[i+2], ip[i+1], ip[i]
+	}
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return hostSocket{}, fmt.Errorf("invalid address %q", s)
+	}
+	addr, _ := netip.AddrFromSlice(ip)
+	return hostSocket{addr: addr.Unmap(), port: int32(port)}, nil
+}
+
+// checkHostPorts returns an error when a host process listens on a port of
+// the service at one of its addresses, as the service would shadow it.
+func checkHostPorts(svc *corev1.Service, addresses []string) error {
+	for _, servicePort := range svc.Spec.Ports {
+		protocol := portProtocol(servicePort)
+		sockets, err := listeningSockets(protocol)
+		if err != nil {
+			return err
+		}
+		for _, address := range addresses {
+			addr, err := netip.ParseAddr(address)
+			if err != nil {
+				continue
+			}
+			for _, socket := range sockets {
+				if socket.port != servicePort.Port {
+					continue
+				}
+				// The IPv6 wildcard usually accepts IPv4 connections too.
+				if socket.addr == addr || (socket.addr.IsUnspecified() && (socket.addr.Is6() || addr.Is4())) {
+					return reasonf(reasonHostPortInUse, "%s port %d of address %s is used by a host process", protocol, servicePort.Port, address)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package loadbalancerservice
+
+import (
+	"errors"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestReadProcNet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tcp")
+	content := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1 1 0000000000000000 100 0 0 10 0
+   1: 0A01A8C0:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 2 1 0000000000000000 100 0 0 10 0
+   2: 0A01A8C0:0016 0B01A8C0:D431 01 00000000:00000000 00:00000000 00000000     0        0 3 1 0000000000000000 100 0 0 10 0
+   3: 00000000000000000000000000000000:0050 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 4 1 0000000000000000 100 0 0 10 0
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	sockets, err := readProcNet(path, listenStates[corev1.ProtocolTCP])
+	require.NoError(t, err)
+	assert.Equal(t, []hostSocket{
+		{addr: netip.MustParseAddr("127.0.0.1"), port: 8080},
+		{addr: netip.MustParseAddr("192.168.1.10"), port: 22},
+		{addr: netip.MustParseAddr("::"), port: 80},
+	}, sockets)
+}
+
+func TestCheckHostPorts(t *testing.T) {
+	defer func(f func(corev1.Protocol) ([]hostSocket, error)) { listeningSockets = f }(listeningSockets)
+	listeningSockets = func(corev1.Protocol) ([]hostSocket, error) {
+		return []hostSocket{
+			{addr: netip.MustParseAddr("127.0.0.1"), port: 8080},
+			{addr: netip.MustParseAddr("192.168.1.10"), port: 22},
+			{addr: netip.MustParseAddr("::"), port: 6443},
+		}, nil
+	}
+
+	assert.NoError(t, checkHostPorts(newService("a", nil, 8080, 80), []string{"192.168.1.10"}))
+	assert.NoError(t, checkHostPorts(newService("a", nil, 22), []string{"192.168.1.200"}))
+
+	err := checkHostPorts(newService("a", nil, 22), []string{"192.168.1.10"})
+	var re *reasonError
+	require.True(t, errors.As(err, &re))
+	assert.Equal(t, reasonHostPortInUse, re.reason)
+	assert.Error(t, checkHostPorts(newService("a", nil, 6443), []string{"192.168.1.200"}))
+}
+
+func TestReadSCTPEndpoints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "eps")
+	content := ` ENDPT     SOCK   STY SST HBKT LPORT   UID INODE LADDRS
+ffff8f0ec1e1c000 ffff8f0ec2d4a900 2   10  29   3868      0 31265 192.168.1.10 fd00::10
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	sockets, err := readSCTPEndpoints(path)
+	require.NoError(t, err)
+	assert.Equal(t, []hostSocket{
+		{addr: netip.MustParseAddr("192.168.1.10"), port: 3868},
+		{addr: netip.MustParseAddr("fd00::10"), port: 3868},
+	}, sockets)
+}
+
+func TestCheckHostPortsProtocol(t *testing.T) {
+	defer func(f func(corev1.Protocol) ([]hostSocket, error)) { listeningSockets = f }(listeningSockets)
+	listeningSockets = func(protocol corev1.Protocol) ([]hostSocket, error) {
+		if protocol == corev1.ProtocolUDP {
+			return []hostSocket{{addr: netip.MustParseAddr("0.0.0.0"), port: 53}}, nil
+		}
+		return nil, nil
+	}
+
+	svc := newService("dns", nil, 53)
+	assert.NoError(t, checkHostPorts(svc, []string{"192.168.1.10"}))
+	svc.Spec.Ports[0].Protocol = corev1.ProtocolUDP
+	assert.Error(t, checkHostPorts(svc, []string{"192.168.1.10"}))
+}
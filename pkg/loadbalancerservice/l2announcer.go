@@ -0,0 +1,382 @@
+package loadbalancerservice
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/net/ipv6"
+	"golang.org/x/sys/unix"
+	"k8s.io/klog/v2"
+)
+
+const (
+	etherTypeARP = 0x0806
+	arpRequest   = 1
+	arpReply     = 2
+	// arpFrameLen is the length of an ARP frame for IPv4 over Ethernet.
+	arpFrameLen = 42
+
+	icmpv6NeighborSolicitation  = 135
+	icmpv6NeighborAdvertisement = 136
+	ndpTargetLinkLayerOption    = 2
+
+	// l2ReadTimeout bounds the reads of the listeners, so they notice when
+	// they are stopped.
+	l2ReadTimeout = time.Second
+)
+
+// l2Announcer answers the ARP requests and IPv6 neighbor solicitations for
+// the addresses assigned to services which are not configured on the host,
+// and announces them with gratuitous ARP and unsolicited neighbor
+// advertisements once assigned, e.g. again after MicroShift restarts, so
+// that they are reachable on the LAN.
+type l2Announcer struct {
+	sync.Mutex
+	ctx context.Context
+	// addresses are the announced addresses, with their interface.
+	addresses map[netip.Addr]*l2Interface
+	// interfaces are listened on, by name, while they have addresses.
+	interfaces map[string]*l2Interface
+}
+
+type l2Interface struct {
+	iface  *net.Interface
+	cancel context.CancelFunc
+	ndp    *ipv6.PacketConn
+}
+
+func newL2Announcer(ctx context.Context) *l2Announcer {
+	return &l2Announcer{
+		ctx:        ctx,
+		addresses:  map[netip.Addr]*l2Interface{},
+		interfaces: map[string]*l2Interface{},
+	}
+}
+
+// Set announces the addresses, and stops announcing the others.
+func (a *l2Announcer) Set(addresses []netip.Addr) {
+	a.Lock()
+	defer a.Unlock()
+
+	for addr, ifc := range a.addresses {
+		if slices.Contains(addresses, addr) {
+			continue
+		}
+		klog.Infof("Stopping the announcement of %s on %s", addr, ifc.iface.Name)
+		delete(a.addresses, addr)
+		if addr.Is6() && ifc.ndp != nil {
+			_ = ifc.ndp.LeaveGroup(ifc.iface, &net.UDPAddr{IP: solicitedNodeAddress(addr).AsSlice()})
+		}
+	}
+	for _, addr := range addresses {
+		if _, ok := a.addresses[addr]; ok {
+			continue
+		}
+		ifc, err := a.interfaceFor(addr)
+		if err != nil {
+			klog.Warningf("Not announcing %s: %v", addr, err)
+			continue
+		}
+		if ifc == nil {
+			// The address is configured on the host, which answers for it.
+			continue
+		}
+		a.addresses[addr] = ifc
+		klog.Infof("Announcing %s on %s", addr, ifc.iface.Name)
+		if err := ifc.announce(addr); err != nil {
+			klog.Warningf("Failed to announce %s on %s: %v", addr, ifc.iface.Name, err)
+		}
+	}
+
+	for name, ifc := range a.interfaces {
+		if !slices.ContainsFunc(addresses, func(addr netip.Addr) bool { return a.addresses[addr] == ifc }) {
+			ifc.cancel()
+			delete(a.interfaces, name)
+		}
+	}
+}
+
+// interfaceFor returns the interface of the subnet of addr, listening on it,
+// or nil when addr is configured on the host.
+func (a *l2Announcer) interfaceFor(addr netip.Addr) (*l2Interface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	var link *net.Interface
+	for i := range ifaces {
+		addrs, err := ifaces[i].Addrs()
+		if err != nil {
+			return nil, err
+		}
+		for _, hostAddr := range addrs {
+			ipNet, ok := hostAddr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			if ipNet.IP.Equal(addr.AsSlice()) {
+				return nil, nil
+			}
+			if link == nil && ipNet.Contains(addr.AsSlice()) && !ipNet.IP.IsLinkLocalUnicast() {
+				link = &ifaces[i]
+			}
+		}
+	}
+	if link == nil {
+		return nil, fmt.Errorf("no interface is in the subnet of the address")
+	}
+
+	name := link.Name
+	ifc, ok := a.interfaces[name]
+	if !ok {
+		iface := link
+		if len(iface.HardwareAddr) != 6 {
+			return nil, fmt.Errorf("interface %s is not an Ethernet interface", name)
+		}
+		ctx, cancel := context.WithCancel(a.ctx)
+		ifc = &l2Interface{iface: iface, cancel: cancel}
+		a.interfaces[name] = ifc
+		go a.answerARP(ctx, ifc)
+		if ifc.ndp, err = listenNDP(ctx); err != nil {
+			klog.Warningf("Not answering IPv6 neighbor solicitations on %s: %v", name, err)
+		} else {
+			go a.answerNDP(ctx, ifc)
+		}
+	}
+	if addr.Is6() && ifc.ndp != nil {
+		// The group may already be joined for an address with the same
+		// last 24 bits.
+		err := ifc.ndp.JoinGroup(ifc.iface, &net.UDPAddr{IP: solicitedNodeAddress(addr).AsSlice()})
+		if err != nil && !errors.Is(err, unix.EADDRINUSE) {
+			return nil, err
+		}
+	}
+	return ifc, nil
+}
+
+// announced reports whether addr is announced on ifc.
+func (a *l2Announcer) announced(addr netip.Addr, ifc *l2Interface) bool {
+	a.Lock()
+	defer a.Unlock()
+	return a.addresses[addr] == ifc
+}
+
+// announce sends a gratuitous ARP, or an unsolicited neighbor advertisement,
+// for addr, so that the neighbors update their caches.
+func (ifc *l2Interface) announce(addr netip.Addr) error {
+	if addr.Is6() {
+		if ifc.ndp == nil {
+			return errors.New("IPv6 neighbor discovery is not available")
+		}
+		return ifc.sendNeighborAdvertisement(addr, netip.IPv6LinkLocalAllNodes(), false)
+	}
+	fd, err := openARPSocket(ifc.iface)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+	broadcast := net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	frame := arpFrame(arpRequest, ifc.iface.HardwareAddr, addr, broadcast, addr)
+	return sendFrame(fd, ifc.iface, broadcast, frame)
+}
+
+func openARPSocket(iface *net.Interface) (int, error) {
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, int(htons(etherTypeARP)))
+	if err != nil {
+		return -1, fmt.Errorf("failed to open ARP socket: %w", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrLinklayer{Protocol: htons(etherTypeARP), Ifindex: iface.Index}); err != nil {
+		unix.Close(fd)
+		return -1, fmt.Errorf("failed to bind ARP socket to %s: %w", iface.Name, err)
+	}
+	return fd, nil
+}
+
+func sendFrame(fd int, iface *net.Interface, dst net.HardwareAddr, frame []byte) error {
+	sa := &unix.SockaddrLinklayer{Protocol: htons(etherTypeARP), Ifindex: iface.Index, Halen: 6}
+	copy(sa.Addr[:], dst)
+	return unix.Sendto(fd, frame, 0, sa)
+}
+
+// answerARP answers the ARP requests for the addresses announced on ifc
+// until ctx is done.
+func (a *l2Announcer) answerARP(ctx context.Context, ifc *l2Interface) {
+	fd, err := openARPSocket(ifc.iface)
+	if err != nil {
+		klog.Errorf("Not answering ARP requests on %s: %v", ifc.iface.Name, err)
+		return
+	}
+	defer unix.Close(fd)
+	tv := unix.NsecToTimeval(l2ReadTimeout.Nanoseconds())
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		klog.Errorf("Not answering ARP requests on %s: %v", ifc.iface.Name, err)
+		return
+	}
+
+	buf := make([]byte, 1500)
+	for ctx.Err() == nil {
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			if !errors.Is(err, unix.EAGAIN) && !errors.Is(err, unix.EINTR) {
+				klog.Warningf("Failed to read ARP requests on %s: %v", ifc.iface.Name, err)
+				time.Sleep(l2ReadTimeout)
+			}
+			continue
+		}
+		senderMAC, senderIP, target, ok := parseARPRequest(buf[:n])
+		if !ok || !a.announced(target, ifc) {
+			continue
+		}
+		reply := arpFrame(arpReply, ifc.iface.HardwareAddr, target, senderMAC, senderIP)
+		if err := sendFrame(fd, ifc.iface, senderMAC, reply); err != nil {
+			klog.Warningf("Failed to answer ARP request for %s on %s: %v", target, ifc.iface.Name, err)
+		}
+	}
+}
+
+// arpFrame returns an Ethernet frame of an ARP message.
+func arpFrame(op uint16, senderMAC net.HardwareAddr, senderIP netip.Addr, targetMAC net.HardwareAddr, targetIP netip.Addr) []byte {
+	frame := make([]byte, arpFrameLen)
+	copy(frame[0:6], targetMAC)
+	copy(frame[6:12], senderMAC)
+	binary.BigEndian.PutUint16(frame[12:14], etherTypeARP)
+	binary.BigEndian.PutUint16(frame[14:16], 1) // Ethernet
+	binary.BigEndian.PutUint16(frame[16:18], unix.ETH_P_IP)
+	frame[18], frame[19] = 6, 4
+	binary.BigEndian.PutUint16(frame[20:22], op)
+	copy(frame[22:28], senderMAC)
+	copy(frame[28:32], senderIP.AsSlice())
+	if op == arpReply {
+		copy(frame[32:38], targetMAC)
+	}
+	copy(frame[38:42], targetIP.AsSlice())
+	return frame
+}
+
+// parseARPRequest returns the sender and the target address of an ARP
+// request frame.
+func parseARPRequest(frame []byte) (net.HardwareAddr, netip.Addr, netip.Addr, bool) {
+	if len(frame) < arpFrameLen ||
+		binary.BigEndian.Uint16(frame[12:14]) != etherTypeARP ||
+		binary.BigEndian.Uint16(frame[16:18]) != unix.ETH_P_IP ||
+		binary.BigEndian.Uint16(frame[20:22]) != arpRequest {
+		return nil, netip.Addr{}, netip.Addr{}, false
+	}
+	senderIP, _ := netip.AddrFromSlice(frame[28:32])
+	target, _ := netip.AddrFromSlice(frame[38:42])
+	return net.HardwareAddr(slices.Clone(frame[22:28])), senderIP, target, true
+}
+
+func listenNDP(ctx context.Context) (*ipv6.PacketConn, error) {
+	conn, err := (&net.ListenConfig{}).ListenPacket(ctx, "ip6:ipv6-icmp", "::")
+	if err != nil {
+		return nil, err
+	}
+	pc := ipv6.NewPacketConn(conn)
+	var filter ipv6.ICMPFilter
+	filter.SetAll(true)
+	filter.Accept(ipv6.ICMPTypeNeighborSolicitation)
+	if err := pc.SetICMPFilter(&filter); err != nil {
+		pc.Close()
+		return nil, err
+	}
+	if err := pc.SetControlMessage(ipv6.FlagInterface, true); err != nil {
+		pc.Close()
+		return nil, err
+	}
+	// Neighbor discovery messages are only valid with a hop limit of 255.
+	if err := pc.SetMulticastHopLimit(255); err != nil {
+		pc.Close()
+		return nil, err
+	}
+	if err := pc.SetHopLimit(255); err != nil {
+		pc.Close()
+		return nil, err
+	}
+	return pc, nil
+}
+
+// answerNDP answers the neighbor solicitations for the IPv6 addresses
+// announced on ifc until ctx is done.
+func (a *l2Announcer) answerNDP(ctx context.Context, ifc *l2Interface) {
+	defer ifc.ndp.Close()
+	buf := make([]byte, 1500)
+	for ctx.Err() == nil {
+		_ = ifc.ndp.SetReadDeadline(time.Now().Add(l2ReadTimeout))
+		n, cm, src, err := ifc.ndp.ReadFrom(buf)
+		if err != nil {
+			var netErr net.Error
+			if !errors.As(err, &netErr) || !netErr.Timeout() {
+				klog.Warningf("Failed to read neighbor solicitations on %s: %v", ifc.iface.Name, err)
+				time.Sleep(l2ReadTimeout)
+			}
+			continue
+		}
+		if cm != nil && cm.IfIndex != ifc.iface.Index {
+			continue
+		}
+		target, ok := parseNeighborSolicitation(buf[:n])
+		if !ok || !a.announced(target, ifc) {
+			continue
+		}
+		dst := netip.IPv6LinkLocalAllNodes()
+		if ip, ok := src.(*net.IPAddr); ok {
+			if addr, ok := netip.AddrFromSlice(ip.IP); ok && !addr.IsUnspecified() {
+				dst = addr.WithZone(ifc.iface.Name)
+			}
+		}
+		if err := ifc.sendNeighborAdvertisement(target, dst, true); err != nil {
+			klog.Warningf("Failed to answer neighbor solicitation for %s on %s: %v", target, ifc.iface.Name, err)
+		}
+	}
+}
+
+func (ifc *l2Interface) sendNeighborAdvertisement(target, dst netip.Addr, solicited bool) error {
+	msg := neighborAdvertisement(target, ifc.iface.HardwareAddr, solicited)
+	cm := &ipv6.ControlMessage{HopLimit: 255, IfIndex: ifc.iface.Index}
+	_, err := ifc.ndp.WriteTo(msg, cm, &net.IPAddr{IP: dst.AsSlice(), Zone: ifc.iface.Name})
+	return err
+}
+
+// neighborAdvertisement returns an ICMPv6 neighbor advertisement overriding
+// the link-layer address of target. The kernel computes the checksum.
+func neighborAdvertisement(target netip.Addr, mac net.HardwareAddr, solicited bool) []byte {
+	msg := make([]byte, 32)
+	msg[0] = icmpv6NeighborAdvertisement
+	msg[4] = 0x20 // Override
+	if solicited {
+		msg[4] |= 0x40
+	}
+	copy(msg[8:24], target.AsSlice())
+	msg[24], msg[25] = ndpTargetLinkLayerOption, 1
+	copy(msg[26:32], mac)
+	return msg
+}
+
+// parseNeighborSolicitation returns the target of an ICMPv6 neighbor
+// solicitation.
+func parseNeighborSolicitation(msg []byte) (netip.Addr, bool) {
+	if len(msg) < 24 || msg[0] != icmpv6NeighborSolicitation {
+		return netip.Addr{}, false
+	}
+	target, _ := netip.AddrFromSlice(msg[8:24])
+	return target, true
+}
+
+// solicitedNodeAddress returns the multicast address the neighbor
+// solicitations for addr are sent to.
+func solicitedNodeAddress(addr netip.Addr) netip.Addr {
+	a := addr.As16()
+	return netip.AddrFrom16([16]byte{0xff, 0x02, 10: 0, 11: 0x01, 12: 0xff, 13: a[13], 14: a[14], 15: a[15]})
+}
+
+func htons(v uint16) uint16 {
+	return v<<8 | v>>8
+}
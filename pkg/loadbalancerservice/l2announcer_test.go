@@ -0,0 +1,53 @@
+package loadbalancerservice
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestARPFrame(t *testing.T) {
+	requesterMAC := net.HardwareAddr{0x52, 0x54, 0x00, 0x00, 0x00, 0x01}
+	requester := netip.MustParseAddr("192.168.1.20")
+	vip := netip.MustParseAddr("192.168.1.200")
+
+	request := arpFrame(arpRequest, requesterMAC, requester, net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, vip)
+	senderMAC, senderIP, target, ok := parseARPRequest(request)
+	require.True(t, ok)
+	assert.Equal(t, requesterMAC, senderMAC)
+	assert.Equal(t, requester, senderIP)
+	assert.Equal(t, vip, target)
+
+	hostMAC := net.HardwareAddr{0x52, 0x54, 0x00, 0x00, 0x00, 0x02}
+	reply := arpFrame(arpReply, hostMAC, vip, senderMAC, senderIP)
+	assert.Equal(t, []byte(requesterMAC), reply[0:6])
+	assert.Equal(t, []byte(hostMAC), reply[22:28])
+	assert.Equal(t, vip.AsSlice(), reply[28:32])
+	_, _, _, ok = parseARPRequest(reply)
+	assert.False(t, ok, "replies are not requests")
+}
+
+func TestNeighborDiscovery(t *testing.T) {
+	vip := netip.MustParseAddr("fd00:1::c8")
+	mac := net.HardwareAddr{0x52, 0x54, 0x00, 0x00, 0x00, 0x02}
+
+	na := neighborAdvertisement(vip, mac, true)
+	assert.Equal(t, byte(icmpv6NeighborAdvertisement), na[0])
+	assert.Equal(t, byte(0x60), na[4], "solicited and override flags")
+	assert.Equal(t, vip.AsSlice(), na[8:24])
+	assert.Equal(t, []byte(mac), na[26:32])
+
+	ns := make([]byte, 24)
+	ns[0] = icmpv6NeighborSolicitation
+	copy(ns[8:], vip.AsSlice())
+	target, ok := parseNeighborSolicitation(ns)
+	require.True(t, ok)
+	assert.Equal(t, vip, target)
+	_, ok = parseNeighborSolicitation(na)
+	assert.False(t, ok)
+
+	assert.Equal(t, netip.MustParseAddr("ff02::1:ff00:c8"), solicitedNodeAddress(vip))
+}
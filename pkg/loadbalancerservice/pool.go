@@ -0,0 +1,234 @@
+package loadbalancerservice
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/microshift/pkg/config"
+)
+
+const (
+	// poolAnnotation selects the address pool the service is assigned an
+	// address from, instead of the node IP.
+	poolAnnotation = "microshift.io/loadbalancer-pool"
+	// ipsAnnotation requests the addresses of the service, comma separated,
+	// from the address pools or the node IP.
+	ipsAnnotation = "microshift.io/loadbalancer-ips"
+	// sharingAnnotation lets the services with the same value share their
+	// pool address, as long as their ports do not conflict.
+	sharingAnnotation = "microshift.io/allow-shared-ip"
+)
+
+type addressPool struct {
+	name   string
+	ranges []config.AddressRange
+}
+
+func (p addressPool) contains(addr netip.Addr) bool {
+	return slices.ContainsFunc(p.ranges, func(r config.AddressRange) bool { return r.Contains(addr) })
+}
+
+func newAddressPools(pools []config.LoadBalancerAddressPool) []addressPool {
+	result := make([]addressPool, 0, len(pools))
+	for _, pool := range pools {
+		// The pools are validated with the configuration.
+		ranges, err := pool.Ranges()
+		if err != nil {
+			klog.Errorf("Ignoring load balancer address pool: %v", err)
+			continue
+		}
+		result = append(result, addressPool{name: pool.Name, ranges: ranges})
+	}
+	return result
+}
+
+// assignment is the addresses assigned to a service.
+type assignment struct {
+	svc       *corev1.Service
+	addresses []string
+}
+
+// portsConflict reports whether the services expose the same port with the
+// same protocol.
+func portsConflict(a, b *corev1.Service) (corev1.ServicePort, bool) {
+	for _, ap := range a.Spec.Ports {
+		for _, bp := range b.Spec.Ports {
+			if ap.Port == bp.Port && portProtocol(ap) == portProtocol(bp) {
+				return ap, true
+			}
+		}
+	}
+	return corev1.ServicePort{}, false
+}
+
+// portProtocol returns the protocol of the port, TCP when unset.
+func portProtocol(port corev1.ServicePort) corev1.Protocol {
+	if port.Protocol == "" {
+		return corev1.ProtocolTCP
+	}
+	return port.Protocol
+}
+
+// firewallPorts returns the ports of the service, as port/protocol.
+func firewallPorts(svc *corev1.Service) []string {
+	ports := make([]string, 0, len(svc.Spec.Ports))
+	for _, port := range svc.Spec.Ports {
+		ports = append(ports, fmt.Sprintf("%d/%s", port.Port, strings.ToLower(string(portProtocol(port)))))
+	}
+	return ports
+}
+
+// checkAddress returns why the address cannot be assigned to svc, given the
+// assignments of the other services. The node IP is shared by all the
+// services, the pool addresses only by those allowed to share them.
+func (c *LoadbalancerServiceController) checkAddress(svc *corev1.Service, addr string, others []assignment) error {
+	sharingKey := svc.Annotations[sharingAnnotation]
+	for _, other := range others {
+		if !slices.Contains(other.addresses, addr) {
+			continue
+		}
+		if addr != c.NodeIP && (sharingKey == "" || other.svc.Annotations[sharingAnnotation] != sharingKey) {
+			return reasonf(reasonAddressUnavailable, "address %s is assigned to service %s/%s", addr, other.svc.Namespace, other.svc.Name)
+		}
+		if port, conflict := portsConflict(svc, other.svc); conflict {
+			return reasonf(reasonPortInUse, "%s port %d of address %s is used by service %s/%s", portProtocol(port), port.Port, addr, other.svc.Namespace, other.svc.Name)
+		}
+	}
+	return nil
+}
+
+// serviceAddresses returns the addresses of svc: the requested ones, one
+// from its address pool, or the node IP.
+func (c *LoadbalancerServiceController) serviceAddresses(svc *corev1.Service, others []assignment) ([]string, error) {
+	var pool *addressPool
+	if name, ok := svc.Annotations[poolAnnotation]; ok {
+		i := slices.IndexFunc(c.pools, func(p addressPool) bool { return p.name == name })
+		if i < 0 {
+			return nil, reasonf(reasonPoolNotFound, "address pool %q is not configured", name)
+		}
+		pool = &c.pools[i]
+	}
+
+	requested := requestedAddresses(svc)
+	if len(requested) != 0 {
+		for _, address := range requested {
+			addr, err := netip.ParseAddr(address)
+			if err != nil {
+				return nil, reasonf(reasonInvalidRequest, "invalid requested address %q: %v", address, err)
+			}
+			inPool := slices.ContainsFunc(c.pools, func(p addressPool) bool { return p.contains(addr) })
+			if pool != nil {
+				inPool = pool.contains(addr)
+			}
+			if err := checkFamily(svc, addr); err != nil {
+				return nil, err
+			}
+			if !inPool && address != c.NodeIP {
+				return nil, reasonf(reasonInvalidRequest, "requested address %s is neither in an address pool nor the node IP", address)
+			}
+			if err := c.checkAddress(svc, address, others); err != nil {
+				return nil, err
+			}
+		}
+		return requested, nil
+	}
+
+	if pool == nil {
+		if addr, err := netip.ParseAddr(c.NodeIP); err == nil {
+			if err := checkFamily(svc, addr); err != nil {
+				return nil, err
+			}
+		}
+		if err := c.checkAddress(svc, c.NodeIP, others); err != nil {
+			return nil, err
+		}
+		return []string{c.NodeIP}, nil
+	}
+
+	address, err := c.allocate(svc, pool, others)
+	if err != nil {
+		return nil, err
+	}
+	return []string{address}, nil
+}
+
+// requestedAddresses returns the addresses requested by the annotation or
+// the deprecated spec.loadBalancerIP field of the service.
+func requestedAddresses(svc *corev1.Service) []string {
+	var addresses []string
+	for _, address := range strings.Split(svc.Annotations[ipsAnnotation], ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	if len(addresses) == 0 && svc.Spec.LoadBalancerIP != "" {
+		addresses = append(addresses, svc.Spec.LoadBalancerIP)
+	}
+	return addresses
+}
+
+// allocate returns an address of the pool for svc, of its primary IP family.
+// The address it already has is kept, then the addresses of the services it
+// may share one with are preferred over the free ones.
+func (c *LoadbalancerServiceController) allocate(svc *corev1.Service, pool *addressPool, others []assignment) (string, error) {
+	ipv6 := len(svc.Spec.IPFamilies) != 0 && svc.Spec.IPFamilies[0] == corev1.IPv6Protocol
+	usable := func(addr netip.Addr) bool {
+		return addr.Is6() == ipv6 && pool.contains(addr) && c.checkAddress(svc, addr.String(), others) == nil
+	}
+
+	var candidates []string
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		candidates = append(candidates, ingress.IP)
+	}
+	if key := svc.Annotations[sharingAnnotation]; key != "" {
+		for _, other := range others {
+			if other.svc.Annotations[sharingAnnotation] == key {
+				candidates = append(candidates, other.addresses...)
+			}
+		}
+	}
+	for _, candidate := range candidates {
+		if addr, err := netip.ParseAddr(candidate); err == nil && usable(addr) {
+			return candidate, nil
+		}
+	}
+
+	// Every unusable address is assigned to another service, so this
+	// stops after as many addresses as there are assignments.
+	for _, r := range pool.ranges {
+		if r.First.Is6() != ipv6 {
+			continue
+		}
+		for addr := r.First; addr.IsValid() && addr.Compare(r.Last) <= 0; addr = addr.Next() {
+			if usable(addr) {
+				return addr.String(), nil
+			}
+		}
+	}
+	family := corev1.IPv4Protocol
+	if ipv6 {
+		family = corev1.IPv6Protocol
+	}
+	if !slices.ContainsFunc(pool.ranges, func(r config.AddressRange) bool { return r.First.Is6() == ipv6 }) {
+		return "", reasonf(reasonAddressFamilyMismatch, "address pool %q has no %s addresses", pool.name, family)
+	}
+	return "", reasonf(reasonAddressUnavailable, "no %s address available in address pool %q", family, pool.name)
+}
+
+// checkFamily returns an error when the service does not use the IP family
+// of the address.
+func checkFamily(svc *corev1.Service, addr netip.Addr) error {
+	family := corev1.IPv4Protocol
+	if addr.Is6() {
+		family = corev1.IPv6Protocol
+	}
+	if len(svc.Spec.IPFamilies) != 0 && !slices.Contains(svc.Spec.IPFamilies, family) {
+		return reasonf(reasonAddressFamilyMismatch, "address %s is %s, the service only uses %v", addr, family, svc.Spec.IPFamilies)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package loadbalancerservice
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/microshift/pkg/config"
+)
+
+func newService(name string, annotations map[string]string, ports ...int32) *corev1.Service {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: name, Annotations: annotations},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+	}
+	for _, port := range ports {
+		svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{Port: port, Protocol: corev1.ProtocolTCP})
+	}
+	return svc
+}
+
+func TestServiceAddresses(t *testing.T) {
+	c := &LoadbalancerServiceController{
+		NodeIP: "192.168.1.10",
+		pools: newAddressPools([]config.LoadBalancerAddressPool{
+			{Name: "lan", Addresses: []string{"192.168.1.200-192.168.1.201"}},
+			{Name: "v6", Addresses: []string{"fd00::/126"}},
+		}),
+	}
+	others := []assignment{
+		{svc: newService("node", nil, 80), addresses: []string{"192.168.1.10"}},
+		{svc: newService("web", map[string]string{sharingAnnotation: "web"}, 80), addresses: []string{"192.168.1.200"}},
+	}
+
+	tests := []struct {
+		name    string
+		svc     *corev1.Service
+		want    []string
+		wantErr bool
+	}{
+		{name: "node-ip", svc: newService("a", nil, 81), want: []string{"192.168.1.10"}},
+		{name: "node-ip-port-conflict", svc: newService("a", nil, 80), wantErr: true},
+		{name: "pool-free-address", svc: newService("a", map[string]string{poolAnnotation: "lan"}, 80), want: []string{"192.168.1.201"}},
+		{name: "pool-shared-address", svc: newService("a", map[string]string{poolAnnotation: "lan", sharingAnnotation: "web"}, 443), want: []string{"192.168.1.200"}},
+		{name: "pool-shared-port-conflict", svc: newService("a", map[string]string{poolAnnotation: "lan", sharingAnnotation: "web"}, 80), want: []string{"192.168.1.201"}},
+		{name: "pool-unknown", svc: newService("a", map[string]string{poolAnnotation: "dmz"}, 80), wantErr: true},
+		{name: "requested", svc: newService("a", map[string]string{ipsAnnotation: "192.168.1.201"}, 80), want: []string{"192.168.1.201"}},
+		{name: "requested-taken", svc: newService("a", map[string]string{ipsAnnotation: "192.168.1.200"}, 443), wantErr: true},
+		{name: "requested-outside-pools", svc: newService("a", map[string]string{ipsAnnotation: "192.168.1.50"}, 80), wantErr: true},
+		{name: "requested-family-mismatch", svc: func() *corev1.Service {
+			svc := newService("a", map[string]string{ipsAnnotation: "192.168.1.201"}, 80)
+			svc.Spec.IPFamilies = []corev1.IPFamily{corev1.IPv6Protocol}
+			return svc
+		}(), wantErr: true},
+		{name: "requested-other-pool", svc: newService("a", map[string]string{poolAnnotation: "v6", ipsAnnotation: "192.168.1.201"}, 80), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.serviceAddresses(tt.svc, others)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAllocateIPFamily(t *testing.T) {
+	c := &LoadbalancerServiceController{
+		pools: newAddressPools([]config.LoadBalancerAddressPool{{Name: "v6", Addresses: []string{"fd00::/127"}}}),
+	}
+	svc := newService("a", map[string]string{poolAnnotation: "v6"}, 80)
+	_, err := c.serviceAddresses(svc, nil)
+	assert.Error(t, err, "no IPv4 address in the pool")
+
+	svc.Spec.IPFamilies = []corev1.IPFamily{corev1.IPv6Protocol}
+	others := []assignment{{svc: newService("b", nil, 80), addresses: []string{"fd00::"}}}
+	got, err := c.serviceAddresses(svc, others)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fd00::1"}, got)
+
+	others = append(others, assignment{svc: newService("c", nil, 80), addresses: []string{"fd00::1"}})
+	_, err = c.serviceAddresses(svc, others)
+	assert.Error(t, err, "pool exhausted")
+}
+
+func TestPortsConflictProtocol(t *testing.T) {
+	tcp := newService("tcp", nil, 53)
+	udp := newService("udp", nil, 53)
+	udp.Spec.Ports[0].Protocol = corev1.ProtocolUDP
+	sctp := newService("sctp", nil, 53)
+	sctp.Spec.Ports[0].Protocol = corev1.ProtocolSCTP
+	unset := newService("unset", nil, 53)
+	unset.Spec.Ports[0].Protocol = ""
+
+	_, conflict := portsConflict(tcp, udp)
+	assert.False(t, conflict)
+	_, conflict = portsConflict(udp, sctp)
+	assert.False(t, conflict)
+	_, conflict = portsConflict(tcp, unset)
+	assert.True(t, conflict)
+	_, conflict = portsConflict(udp, udp)
+	assert.True(t, conflict)
+
+	assert.Equal(t, []string{"53/udp"}, firewallPorts(udp))
+	assert.Equal(t, []string{"53/tcp"}, firewallPorts(unset))
+}
@@ -0,0 +1,93 @@
+package loadbalancerservice
+
+import (
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+// assignedCondition reports on the services whether they were assigned
+// their load balancer addresses, and why not.
+const assignedCondition = "microshift.io/LoadBalancerAssigned"
+
+const (
+	reasonAssigned              = "AddressAssigned"
+	reasonAddressUnavailable    = "AddressUnavailable"
+	reasonAddressFamilyMismatch = "AddressFamilyMismatch"
+	reasonPortInUse             = "PortInUse"
+	reasonHostPortInUse         = "HostPortInUse"
+	reasonPoolNotFound          = "PoolNotFound"
+	reasonInvalidRequest        = "InvalidRequest"
+	reasonFailed                = "AssignmentFailed"
+)
+
+var pendingServices = metrics.NewGauge(
+	&metrics.GaugeOpts{
+		Name:           "microshift_loadbalancer_pending_services",
+		Help:           "Number of LoadBalancer services which could not be assigned their addresses.",
+		StabilityLevel: metrics.ALPHA,
+	},
+)
+
+func init() {
+	legacyregistry.MustRegister(pendingServices)
+}
+
+// reasonError is an error with the reason of the condition and Event
+// reporting it on the service.
+type reasonError struct {
+	reason  string
+	message string
+}
+
+func (e *reasonError) Error() string { return e.message }
+
+func reasonf(reason, format string, args ...interface{}) error {
+	return &reasonError{reason: reason, message: fmt.Sprintf(format, args...)}
+}
+
+// newCondition returns the assigned condition reporting err.
+func newCondition(err error) metav1.Condition {
+	if err == nil {
+		return metav1.Condition{
+			Type:    assignedCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  reasonAssigned,
+			Message: "The load balancer addresses are assigned",
+		}
+	}
+	reason := reasonFailed
+	var re *reasonError
+	if errors.As(err, &re) {
+		reason = re.reason
+	}
+	return metav1.Condition{
+		Type:    assignedCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  reason,
+		Message: err.Error(),
+	}
+}
+
+// conditionChanged reports whether setting the condition changes the
+// conditions of the service.
+func conditionChanged(svc *corev1.Service, condition metav1.Condition) bool {
+	current := meta.FindStatusCondition(svc.Status.Conditions, condition.Type)
+	return current == nil || current.Status != condition.Status || current.Reason != condition.Reason || current.Message != condition.Message
+}
+
+// setPending records whether the service of key is pending, and updates the
+// metric.
+func (c *LoadbalancerServiceController) setPending(key string, pending bool) {
+	if pending {
+		c.pending[key] = true
+	} else {
+		delete(c.pending, key)
+	}
+	pendingServices.Set(float64(len(c.pending)))
+}
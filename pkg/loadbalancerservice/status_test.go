@@ -0,0 +1,33 @@
+package loadbalancerservice
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewCondition(t *testing.T) {
+	condition := newCondition(nil)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, reasonAssigned, condition.Reason)
+
+	condition = newCondition(reasonf(reasonPortInUse, "port %d is used", 80))
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, reasonPortInUse, condition.Reason)
+	assert.Equal(t, "port 80 is used", condition.Message)
+
+	condition = newCondition(fmt.Errorf("unexpected"))
+	assert.Equal(t, reasonFailed, condition.Reason)
+}
+
+func TestConditionChanged(t *testing.T) {
+	svc := newService("a", nil, 80)
+	condition := newCondition(nil)
+	assert.True(t, conditionChanged(svc, condition))
+
+	svc.Status.Conditions = []metav1.Condition{condition}
+	assert.False(t, conditionChanged(svc, condition))
+	assert.True(t, conditionChanged(svc, newCondition(reasonf(reasonPortInUse, "port 80 is used"))))
+}
@@ -0,0 +1,150 @@
+package logforwarding
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// buffer keeps the records that could not be sent in files of a directory,
+// one per batch, as JSON objects one per line. The files are named after
+// the time they were written so they are sent in order.
+type buffer struct {
+	dir string
+	// maxBytes is the size of the files over which the oldest are removed.
+	maxBytes int64
+}
+
+func newBuffer(dir string, maxBytes int64) (*buffer, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create dir %q: %w", dir, err)
+	}
+	return &buffer{dir: dir, maxBytes: maxBytes}, nil
+}
+
+// store writes the records to a new file of the buffer, and removes the
+// oldest files when the buffer is full.
+func (b *buffer) store(records []Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	data := []byte{}
+	for _, r := range records {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		data = append(append(data, line...), '\n')
+	}
+
+	path := filepath.Join(b.dir, fmt.Sprintf("%020d.ndjson", time.Now().UnixNano()))
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %q: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to rename %q to %q: %w", tmp, path, err)
+	}
+	return b.prune()
+}
+
+// drain sends the buffered files, oldest first, and removes them once sent.
+// It stops at the first error of send.
+func (b *buffer) drain(send func([]Record) error) error {
+	files, err := b.files()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		records, err := readRecords(file)
+		if err != nil {
+			// A file that cannot be read cannot be sent later either.
+			klog.Warningf("Dropping buffered logs in %q: %v", file, err)
+		} else if err := send(records); err != nil {
+			return err
+		}
+		if err := os.Remove(file); err != nil {
+			return fmt.Errorf("failed to remove %q: %w", file, err)
+		}
+	}
+	return nil
+}
+
+func (b *buffer) prune() error {
+	files, err := b.files()
+	if err != nil {
+		return err
+	}
+	sizes := make([]int64, len(files))
+	total := int64(0)
+	for i, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		sizes[i] = info.Size()
+		total += sizes[i]
+	}
+	dropped := 0
+	for i := 0; total > b.maxBytes && i < len(files); i++ {
+		if err := os.Remove(files[i]); err != nil {
+			return fmt.Errorf("failed to remove %q: %w", files[i], err)
+		}
+		total -= sizes[i]
+		dropped++
+	}
+	if dropped > 0 {
+		klog.Warningf("Log forwarding buffer is full, dropped the %d oldest batches", dropped)
+	}
+	return nil
+}
+
+// files returns the paths of the buffered files, oldest first.
+func (b *buffer) files() ([]string, error) {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dir %q: %w", b.dir, err)
+	}
+	files := []string{}
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.HasSuffix(entry.Name(), ".ndjson") {
+			files = append(files, filepath.Join(b.dir, entry.Name()))
+		}
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+func readRecords(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records := []Record{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		r := Record{}
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("no records")
+	}
+	return records, nil
+}
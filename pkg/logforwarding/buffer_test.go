@@ -0,0 +1,54 @@
+package logforwarding
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuffer(t *testing.T) {
+	b, err := newBuffer(t.TempDir(), 1024*1024)
+	assert.NoError(t, err)
+
+	record := func(message string) Record {
+		return Record{Source: config.LogSourceAudit, Time: time.Unix(1700000000, 0).UTC(), Host: "edge", Message: message}
+	}
+	assert.NoError(t, b.store(nil))
+	assert.NoError(t, b.store([]Record{record("first"), record("second")}))
+	assert.NoError(t, b.store([]Record{record("third")}))
+
+	// A failed send keeps the files for the next drain.
+	assert.Error(t, b.drain(func([]Record) error { return errors.New("unreachable") }))
+
+	var sent []string
+	assert.NoError(t, b.drain(func(records []Record) error {
+		for _, r := range records {
+			sent = append(sent, r.Message)
+		}
+		return nil
+	}))
+	assert.Equal(t, []string{"first", "second", "third"}, sent)
+
+	files, err := b.files()
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestBufferPrune(t *testing.T) {
+	b, err := newBuffer(t.TempDir(), 200)
+	assert.NoError(t, err)
+
+	for _, message := range []string{"first", "second", "third"} {
+		assert.NoError(t, b.store([]Record{{Source: config.LogSourceKubelet, Message: message}}))
+	}
+
+	var sent []string
+	assert.NoError(t, b.drain(func(records []Record) error {
+		sent = append(sent, records[0].Message)
+		return nil
+	}))
+	assert.Equal(t, []string{"second", "third"}, sent, "the oldest batch is dropped when the buffer is full")
+}
@@ -0,0 +1,168 @@
+package logforwarding
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// batchSize is the maximum number of records sent at once.
+	batchSize = 500
+	// flushInterval is how often the records read so far are sent.
+	flushInterval = 5 * time.Second
+	// restartInterval is the delay before a failed source is read again.
+	restartInterval = 30 * time.Second
+)
+
+// StateDir keeps the position of the sources and the buffer of the records
+// that could not be sent, so that nothing is lost over restarts and offline
+// periods.
+func StateDir() string { return filepath.Join(config.DataDir, "log-forwarding") }
+
+// Record is a line of a log.
+type Record struct {
+	Source  config.LogSourceEnum `json:"source"`
+	Time    time.Time            `json:"time"`
+	Host    string               `json:"host"`
+	Message string               `json:"message"`
+}
+
+// LogForwarder ships the audit log of the kube-apiserver and the messages
+// of the kubelet to a central collector. Records are written to a buffer on
+// disk while the collector is unreachable and sent, oldest first, once it
+// is back.
+type LogForwarder struct {
+	endpoint   string
+	caFile     string
+	sources    []config.LogSourceEnum
+	host       string
+	bufferSize int64
+
+	sink   sink
+	buffer *buffer
+	// unreachable is whether the last send failed, to only log the
+	// transitions.
+	unreachable bool
+}
+
+func NewLogForwarder(cfg *config.Config) *LogForwarder {
+	return &LogForwarder{
+		endpoint:   cfg.Logging.Forwarding.Endpoint,
+		caFile:     cfg.Logging.Forwarding.CAFile,
+		sources:    cfg.Logging.Forwarding.Sources,
+		host:       cfg.Node.HostnameOverride,
+		bufferSize: int64(*cfg.Logging.Forwarding.BufferSizeMB) * 1024 * 1024,
+	}
+}
+
+func (s *LogForwarder) Name() string           { return "log-forwarder" }
+func (s *LogForwarder) Dependencies() []string { return []string{} }
+
+// Optional allows pausing the forwarding at runtime, the sources are read
+// from where they stopped once it is enabled again.
+func (s *LogForwarder) Optional() bool { return true }
+
+func (s *LogForwarder) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	defer close(stopped)
+
+	var err error
+	s.sink, err = newSink(s.endpoint, s.caFile)
+	if err != nil {
+		return fmt.Errorf("failed to configure log forwarding to %q: %w", s.endpoint, err)
+	}
+	defer s.sink.close()
+	s.buffer, err = newBuffer(filepath.Join(StateDir(), "buffer"), s.bufferSize)
+	if err != nil {
+		return err
+	}
+
+	records := make(chan Record, batchSize)
+	for _, source := range s.sources {
+		read := s.sourceReader(source)
+		go wait.UntilWithContext(ctx, func(ctx context.Context) {
+			if err := read(ctx, records); err != nil && ctx.Err() == nil {
+				klog.Warningf("Failed to read the %s log, retrying in %s: %v", source, restartInterval, err)
+			}
+		}, restartInterval)
+	}
+	klog.Infof("Forwarding the %v logs to %s", s.sources, s.endpoint)
+	close(ready)
+
+	s.ship(ctx, records)
+	return ctx.Err()
+}
+
+func (s *LogForwarder) sourceReader(source config.LogSourceEnum) func(context.Context, chan<- Record) error {
+	switch source {
+	case config.LogSourceAudit:
+		return func(ctx context.Context, records chan<- Record) error {
+			return tailAuditLog(ctx, AuditLogPath, filepath.Join(StateDir(), "audit.position"), s.host, records)
+		}
+	default:
+		return func(ctx context.Context, records chan<- Record) error {
+			return followKubeletJournal(ctx, filepath.Join(StateDir(), "kubelet.cursor"), s.host, records)
+		}
+	}
+}
+
+// ship sends the records in batches until ctx is done. The records that
+// are left when stopping are kept in the buffer.
+func (s *LogForwarder) ship(ctx context.Context, records <-chan Record) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Record, 0, batchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			for len(records) > 0 {
+				batch = append(batch, <-records)
+			}
+			if err := s.buffer.store(batch); err != nil {
+				klog.Warningf("Failed to buffer %d log records: %v", len(batch), err)
+			}
+			return
+		case r := <-records:
+			batch = append(batch, r)
+			if len(batch) >= batchSize {
+				s.flush(ctx, batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			s.flush(ctx, batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// flush sends the buffered records and then batch, or adds batch to the
+// buffer when the collector is unreachable so the order is kept.
+func (s *LogForwarder) flush(ctx context.Context, batch []Record) {
+	err := s.buffer.drain(func(buffered []Record) error {
+		return s.sink.send(ctx, buffered)
+	})
+	if err == nil && len(batch) > 0 {
+		err = s.sink.send(ctx, batch)
+	}
+	if err == nil {
+		if s.unreachable {
+			klog.Infof("Log collector %s is reachable again", s.endpoint)
+			s.unreachable = false
+		}
+		return
+	}
+
+	if !s.unreachable {
+		klog.Warningf("Failed to forward the logs to %s, buffering them on disk: %v", s.endpoint, err)
+		s.unreachable = true
+	}
+	if err := s.buffer.store(batch); err != nil {
+		klog.Warningf("Failed to buffer %d log records: %v", len(batch), err)
+	}
+}
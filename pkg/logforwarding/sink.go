@@ -0,0 +1,158 @@
+package logforwarding
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+)
+
+const sendTimeout = 10 * time.Second
+
+// sink sends records to a collector.
+type sink interface {
+	send(ctx context.Context, records []Record) error
+	close()
+}
+
+func newSink(endpoint, caFile string) (sink, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "tcp", "udp":
+		return &syslogSink{network: u.Scheme, address: u.Host}, nil
+	case "https":
+		return newHTTPSSink(u.String(), caFile)
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+}
+
+// syslogSink sends each record as an RFC 5424 syslog message. Over TCP the
+// messages are framed with their length, as in RFC 6587.
+type syslogSink struct {
+	network string
+	address string
+	conn    net.Conn
+}
+
+func (s *syslogSink) send(ctx context.Context, records []Record) error {
+	if s.conn == nil {
+		dialer := net.Dialer{Timeout: sendTimeout}
+		conn, err := dialer.DialContext(ctx, s.network, s.address)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	if err := s.conn.SetWriteDeadline(time.Now().Add(sendTimeout)); err != nil {
+		s.close()
+		return err
+	}
+
+	for _, r := range records {
+		msg := syslogMessage(r)
+		if s.network == "tcp" {
+			msg = fmt.Sprintf("%d %s", len(msg), msg)
+		}
+		if _, err := io.WriteString(s.conn, msg); err != nil {
+			// The collector may have received part of the records, they
+			// are sent again rather than lost.
+			s.close()
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *syslogSink) close() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// syslogMessage formats the record as an RFC 5424 message. The audit log is
+// sent with the security/authorization facility and the kubelet with the
+// system daemons one.
+func syslogMessage(r Record) string {
+	facility := 3 // daemon
+	if r.Source == config.LogSourceAudit {
+		facility = 13 // log audit
+	}
+	const severity = 6 // informational
+	host := r.Host
+	if host == "" {
+		host = "-"
+	}
+	return fmt.Sprintf("<%d>1 %s %s microshift-%s - - - %s",
+		facility*8+severity, r.Time.UTC().Format(time.RFC3339Nano), host, r.Source, r.Message)
+}
+
+// httpsSink POSTs the records as JSON objects, one per line.
+type httpsSink struct {
+	url    string
+	client *http.Client
+}
+
+func newHTTPSSink(url, caFile string) (*httpsSink, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %q: %w", caFile, err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %q", caFile)
+		}
+	}
+	return &httpsSink{
+		url: url,
+		client: &http.Client{
+			Timeout:   sendTimeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+		},
+	}, nil
+}
+
+func (s *httpsSink) send(ctx context.Context, records []Record) error {
+	body := &bytes.Buffer{}
+	encoder := json.NewEncoder(body)
+	for _, r := range records {
+		if err := encoder.Encode(r); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *httpsSink) close() {
+	s.client.CloseIdleConnections()
+}
@@ -0,0 +1,93 @@
+package logforwarding
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+var testRecord = Record{
+	Source:  config.LogSourceAudit,
+	Time:    time.Date(2024, 10, 16, 10, 12, 1, 0, time.UTC),
+	Host:    "edge",
+	Message: `{"kind":"Event"}`,
+}
+
+func TestSyslogSink(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	received := make(chan string)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		// Messages are prefixed with their length and a space.
+		prefix, err := reader.ReadString(' ')
+		if err != nil {
+			return
+		}
+		length, err := strconv.Atoi(strings.TrimSpace(prefix))
+		if err != nil {
+			return
+		}
+		msg := make([]byte, length)
+		if _, err := io.ReadFull(reader, msg); err == nil {
+			received <- string(msg)
+		}
+	}()
+
+	s, err := newSink("tcp://"+listener.Addr().String(), "")
+	assert.NoError(t, err)
+	defer s.close()
+	assert.NoError(t, s.send(context.Background(), []Record{testRecord}))
+	assert.Equal(t, `<110>1 2024-10-16T10:12:01Z edge microshift-audit - - - {"kind":"Event"}`, <-received)
+}
+
+func TestHTTPSSink(t *testing.T) {
+	var received []Record
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		decoder := json.NewDecoder(r.Body)
+		for decoder.More() {
+			record := Record{}
+			if err := decoder.Decode(&record); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			received = append(received, record)
+		}
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	assert.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600))
+
+	s, err := newSink(server.URL, caFile)
+	assert.NoError(t, err)
+	defer s.close()
+	assert.NoError(t, s.send(context.Background(), []Record{testRecord, testRecord}))
+	assert.Equal(t, []Record{testRecord, testRecord}, received)
+
+	// The server is not trusted without its CA.
+	s, err = newSink(server.URL, "")
+	assert.NoError(t, err)
+	defer s.close()
+	assert.Error(t, s.send(context.Background(), []Record{testRecord}))
+}
@@ -0,0 +1,258 @@
+package logforwarding
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+)
+
+const (
+	// AuditLogPath is the audit log of the kube-apiserver.
+	AuditLogPath = "/var/log/kube-apiserver/audit.log"
+
+	// pollInterval is how often the audit log is checked for new lines.
+	pollInterval = time.Second
+	// maxLineSize is the longest line read, audit events of large
+	// objects may be long.
+	maxLineSize = 4 * 1024 * 1024
+)
+
+// filePosition is where the reading of a file stopped.
+type filePosition struct {
+	Inode  uint64 `json:"inode"`
+	Offset int64  `json:"offset"`
+}
+
+// tailAuditLog follows the audit log from the position saved in stateFile,
+// across the rotations of the file, until ctx is done.
+func tailAuditLog(ctx context.Context, path, stateFile, host string, records chan<- Record) error {
+	pos := filePosition{}
+	if data, err := os.ReadFile(stateFile); err == nil {
+		_ = json.Unmarshal(data, &pos)
+	}
+	emit := func(line string) bool {
+		return sendRecord(ctx, records, Record{Source: config.LogSourceAudit, Time: time.Now(), Host: host, Message: line})
+	}
+	saved := pos
+	save := func() error {
+		if pos == saved {
+			return nil
+		}
+		saved = pos
+		return savePosition(stateFile, pos)
+	}
+
+	for {
+		f, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			// The kube-apiserver has not started yet.
+			if !sleep(ctx, pollInterval) {
+				return nil
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		err = tailFile(ctx, f, path, &pos, emit, save)
+		f.Close()
+		if err != nil || ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+// tailFile reads the complete lines of f from pos, and returns once the
+// file at path is another one, after reading the rest of f.
+func tailFile(ctx context.Context, f *os.File, path string, pos *filePosition, emit func(string) bool, save func() error) error {
+	inode, size, err := fileID(f)
+	if err != nil {
+		return err
+	}
+	if inode != pos.Inode || size < pos.Offset {
+		*pos = filePosition{Inode: inode}
+	}
+	if _, err := f.Seek(pos.Offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReaderSize(f, 64*1024)
+	for {
+		line, err := reader.ReadString('\n')
+		if err == nil {
+			if len(line) <= maxLineSize && !emit(strings.TrimSuffix(line, "\n")) {
+				return save()
+			}
+			pos.Offset += int64(len(line))
+			continue
+		}
+		if !errors.Is(err, io.EOF) {
+			return err
+		}
+		// The last line is still being written, it is read again once
+		// complete.
+		if len(line) > 0 {
+			if _, err := f.Seek(pos.Offset, io.SeekStart); err != nil {
+				return err
+			}
+			reader.Reset(f)
+		}
+		if err := save(); err != nil {
+			return err
+		}
+		if rotated(path, pos.Inode) {
+			// Lines may have been written between the last read and the
+			// rotation.
+			if drainErr := drainFile(reader, pos, emit); drainErr != nil {
+				return drainErr
+			}
+			*pos = filePosition{}
+			return save()
+		}
+		if !sleep(ctx, pollInterval) {
+			return nil
+		}
+	}
+}
+
+func drainFile(reader *bufio.Reader, pos *filePosition, emit func(string) bool) error {
+	for {
+		line, err := reader.ReadString('\n')
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(line) <= maxLineSize && !emit(strings.TrimSuffix(line, "\n")) {
+			return nil
+		}
+		pos.Offset += int64(len(line))
+	}
+}
+
+func rotated(path string, inode uint64) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return true
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return !ok || stat.Ino != inode
+}
+
+func fileID(f *os.File) (uint64, int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, fmt.Errorf("no inode for %q", f.Name())
+	}
+	return stat.Ino, info.Size(), nil
+}
+
+func savePosition(stateFile string, pos filePosition) error {
+	data, err := json.Marshal(pos)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(stateFile, data, 0600)
+}
+
+// journalEntry is the subset of the fields of journalctl --output=json
+// used.
+type journalEntry struct {
+	Cursor  string `json:"__CURSOR"`
+	Time    string `json:"__REALTIME_TIMESTAMP"`
+	Message any    `json:"MESSAGE"`
+}
+
+// followKubeletJournal follows the journal of MicroShift from the cursor
+// saved in cursorFile, and emits the messages of the kubelet.
+func followKubeletJournal(ctx context.Context, cursorFile, host string, records chan<- Record) error {
+	args := []string{"--unit=microshift.service", "--follow", "--output=json"}
+	if cursor, err := os.ReadFile(cursorFile); err == nil && len(cursor) > 0 {
+		args = append(args, "--after-cursor="+string(cursor))
+	} else {
+		// Start with the new messages on the first run.
+		args = append(args, "--lines=0")
+	}
+	cmd := exec.CommandContext(ctx, "journalctl", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run journalctl: %w", err)
+	}
+	defer func() { _ = cmd.Wait() }()
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		entry := journalEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		message, ok := entry.Message.(string)
+		if !ok || !isKubeletMessage(message) {
+			continue
+		}
+		r := Record{Source: config.LogSourceKubelet, Time: time.Now(), Host: host, Message: message}
+		if usec, err := strconv.ParseInt(entry.Time, 10, 64); err == nil {
+			r.Time = time.UnixMicro(usec)
+		}
+		if !sendRecord(ctx, records, r) {
+			return nil
+		}
+		if err := os.WriteFile(cursorFile, []byte(entry.Cursor), 0600); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	if ctx.Err() == nil {
+		return fmt.Errorf("journalctl stopped")
+	}
+	return nil
+}
+
+// isKubeletMessage returns whether a message of the journal of MicroShift
+// is logged by the kubelet, which is the first word of the text format and
+// the component field of the json one.
+func isKubeletMessage(message string) bool {
+	return strings.HasPrefix(message, "kubelet ") || strings.Contains(message, `"component":"kubelet"`)
+}
+
+// sendRecord returns false if ctx is done before r is sent.
+func sendRecord(ctx context.Context, records chan<- Record, r Record) bool {
+	select {
+	case records <- r:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// sleep returns false if ctx is done before d.
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,67 @@
+package logforwarding
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTailAuditLog(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+	stateFile := filepath.Join(dir, "audit.position")
+	records := make(chan Record, 10)
+
+	receive := func() string {
+		select {
+		case r := <-records:
+			return r.Message
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a record")
+			return ""
+		}
+	}
+	appendLines := func(path, lines string) {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		assert.NoError(t, err)
+		_, err = f.WriteString(lines)
+		assert.NoError(t, err)
+		assert.NoError(t, f.Close())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- tailAuditLog(ctx, path, stateFile, "edge", records) }()
+
+	appendLines(path, "one\ntw")
+	assert.Equal(t, "one", receive())
+	appendLines(path, "o\n")
+	assert.Equal(t, "two", receive(), "a partial line is read once complete")
+
+	// Rotate the file like the kube-apiserver does.
+	assert.NoError(t, os.Rename(path, path+".1"))
+	appendLines(path+".1", "three\n")
+	appendLines(path, "four\n")
+	assert.Equal(t, "three", receive(), "the rest of the rotated file is read")
+	assert.Equal(t, "four", receive())
+
+	cancel()
+	assert.NoError(t, <-done)
+
+	// The next run starts after the lines already read.
+	appendLines(path, "five\n")
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go func() { done <- tailAuditLog(ctx, path, stateFile, "edge", records) }()
+	assert.Equal(t, "five", receive())
+}
+
+func TestIsKubeletMessage(t *testing.T) {
+	assert.True(t, isKubeletMessage(`kubelet I1016 10:12:01.123456    1234 kubelet.go:2453] "SyncLoop ADD"`))
+	assert.True(t, isKubeletMessage(`{"ts":"2024-10-16T10:12:01.123456Z","component":"kubelet","msg":"SyncLoop ADD"}`))
+	assert.False(t, isKubeletMessage(`kube-apiserver I1016 10:12:01.123456    1234 handler.go:286] "Adding GroupVersion"`))
+}
@@ -0,0 +1,111 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	"github.com/openshift/microshift/pkg/config"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	logsAPIV1 "k8s.io/component-base/logs/api/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	timestampFormat = "2006-01-02T15:04:05.000000Z07:00"
+	// unknownComponent is the name of the component of the goroutines
+	// not labeled with klog.WithMicroshiftLoggerComponent.
+	unknownComponent = "???"
+)
+
+func init() {
+	utilruntime.Must(logsAPIV1.RegisterLogFormat(string(config.LogFormatJSON), jsonFactory{}, logsAPIV1.LoggingBetaOptions))
+}
+
+// NewLoggingConfiguration returns the logging configuration of MicroShift.
+// kube-apiserver and kube-controller-manager apply the same configuration
+// from their --logging-format and -v flags, a different one would make them
+// fail to start.
+func NewLoggingConfiguration(cfg *config.Config) *logsAPIV1.LoggingConfiguration {
+	c := logsAPIV1.NewLoggingConfiguration()
+	c.Format = string(cfg.Logging.Format)
+	c.Verbosity = logsAPIV1.VerbosityLevel(cfg.GetVerbosity())
+	return c
+}
+
+// Apply switches the logs of MicroShift, and of the embedded components
+// sharing klog with it, to the format of cfg.Logging.
+func Apply(cfg *config.Config) error {
+	if err := logsAPIV1.ValidateAndApply(NewLoggingConfiguration(cfg), utilfeature.DefaultFeatureGate); err != nil {
+		return fmt.Errorf("failed to apply the logging configuration: %w", err)
+	}
+	return nil
+}
+
+// jsonFactory creates loggers writing each message as a JSON object on a
+// single line, with the name of the MicroShift component that logged it.
+type jsonFactory struct{}
+
+func (jsonFactory) Create(c logsAPIV1.LoggingConfiguration, o logsAPIV1.LoggingOptions) (logr.Logger, logsAPIV1.RuntimeControl) {
+	return newJSONLogger(o.ErrorStream, int(c.Verbosity)), logsAPIV1.RuntimeControl{}
+}
+
+func newJSONLogger(out io.Writer, verbosity int) logr.Logger {
+	var mu sync.Mutex
+	write := func(obj string) {
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = io.WriteString(out, obj+"\n")
+	}
+	return funcr.NewJSON(write, funcr.Options{
+		LogCaller:          funcr.All,
+		LogTimestamp:       true,
+		TimestampFormat:    timestampFormat,
+		LogInfoLevel:       ptr.To("v"),
+		Verbosity:          verbosity,
+		RenderBuiltinsHook: renderBuiltins,
+	})
+}
+
+// renderBuiltins adds the component logging the message, which is only known
+// on the goroutine logging it, and renders the builtin fields the way the
+// JSON format of Kubernetes does: file:line callers and err for errors.
+func renderBuiltins(kvList []any) []any {
+	rendered := make([]any, 0, len(kvList)+2)
+	for i := 0; i < len(kvList); i += 2 {
+		key, value := kvList[i].(string), kvList[i+1]
+		switch key {
+		case "logger":
+			if value == "" {
+				continue
+			}
+		case "ts":
+			rendered = append(rendered, key, value, "component", component())
+			continue
+		case "caller":
+			if caller, ok := value.(funcr.Caller); ok {
+				value = fmt.Sprintf("%s:%d", caller.File, caller.Line)
+			}
+		case "error":
+			if value == nil {
+				continue
+			}
+			key = "err"
+		}
+		rendered = append(rendered, key, value)
+	}
+	return rendered
+}
+
+// component returns the name of the service running on the goroutine, or
+// microshift outside of the services.
+func component() string {
+	if c := klog.MicroshiftLoggerComponent(); c != unknownComponent {
+		return c
+	}
+	return "microshift"
+}
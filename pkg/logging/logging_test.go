@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestJSONLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger := newJSONLogger(&out, 2)
+
+	logger.Info("started", "port", 6443)
+	logger.V(4).Info("hidden")
+	logger.Error(errors.New("boom"), "failed", "attempt", 1)
+	logger.WithName("sub").V(2).Info("named")
+
+	var entries []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n")) {
+		entry := map[string]any{}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			t.Fatalf("line %q is not JSON: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 lines, got %d: %s", len(entries), out.String())
+	}
+
+	expected := []map[string]any{
+		{"component": "microshift", "msg": "started", "v": float64(0), "port": float64(6443)},
+		{"component": "microshift", "msg": "failed", "err": "boom", "attempt": float64(1)},
+		{"component": "microshift", "msg": "named", "v": float64(2), "logger": "sub"},
+	}
+	for i, fields := range expected {
+		for key, value := range fields {
+			if entries[i][key] != value {
+				t.Errorf("line %d: expected %s=%v, got %v", i, key, value, entries[i][key])
+			}
+		}
+		for _, key := range []string{"ts", "caller"} {
+			if _, ok := entries[i][key]; !ok {
+				t.Errorf("line %d: missing %s", i, key)
+			}
+		}
+	}
+	if _, ok := entries[0]["logger"]; ok {
+		t.Errorf("unexpected logger field for an unnamed logger")
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/openshift/microshift/pkg/config"
 	"github.com/openshift/microshift/pkg/config/ovn"
 	"github.com/openshift/microshift/pkg/mdns/server"
+	"github.com/openshift/microshift/pkg/servicemanager"
+	"github.com/openshift/microshift/pkg/util"
 	"k8s.io/klog/v2"
 )
 
@@ -18,23 +20,41 @@ type MicroShiftmDNSController struct {
 	sync.Mutex
 	NodeName   string
 	NodeIP     string
+	NodeIPv6   string
 	KubeConfig string
 	isIpv4     bool
 	isIpv6     bool
 	myIPs      []string
 	resolver   *server.Resolver
 	hostCount  map[string]int
-	stopCh     chan struct{}
+	// services maps the advertised services to their DNS-SD instance name.
+	services map[string]string
+	stopCh   chan struct{}
+	// domain is the suffix of the names announced, e.g. ".local".
+	domain    string
+	hostnames []string
+	// interfaces and excludeInterfaces select the interfaces answered on.
+	interfaces        []string
+	excludeInterfaces []string
+	// cfg is the configuration the controller was created from, to renew it.
+	cfg *config.Config
 }
 
 func NewMicroShiftmDNSController(cfg *config.Config) *MicroShiftmDNSController {
 	return &MicroShiftmDNSController{
-		NodeIP:     cfg.Node.NodeIP,
-		NodeName:   cfg.Node.HostnameOverride,
-		KubeConfig: cfg.KubeConfigPath(config.KubeAdmin),
-		isIpv4:     cfg.IsIPv4(),
-		isIpv6:     cfg.IsIPv6(),
-		hostCount:  make(map[string]int),
+		NodeIP:            cfg.Node.NodeIP,
+		NodeIPv6:          cfg.Node.NodeIPV6,
+		NodeName:          cfg.Node.HostnameOverride,
+		KubeConfig:        cfg.KubeConfigPath(config.KubeAdmin),
+		isIpv4:            cfg.IsIPv4(),
+		isIpv6:            cfg.IsIPv6(),
+		hostCount:         make(map[string]int),
+		services:          make(map[string]string),
+		domain:            "." + cfg.MDNS.Domain,
+		hostnames:         cfg.MDNS.Hostnames,
+		interfaces:        cfg.MDNS.Interfaces,
+		excludeInterfaces: cfg.MDNS.ExcludeInterfaces,
+		cfg:               cfg,
 	}
 }
 
@@ -43,6 +63,19 @@ func (c *MicroShiftmDNSController) Dependencies() []string {
 	return []string{"openshift-default-scc-manager"}
 }
 
+// Optional allows disabling mDNS at runtime, e.g. on untrusted networks.
+func (c *MicroShiftmDNSController) Optional() bool { return true }
+
+// Renew returns a new controller when mDNS is enabled again, the names
+// announced by the previous run are counted in its hostCount.
+func (c *MicroShiftmDNSController) Renew() servicemanager.Service {
+	return NewMicroShiftmDNSController(c.cfg)
+}
+
+// Deferred starts mDNS once MicroShift is ready, nothing it runs needs the
+// names to be published.
+func (c *MicroShiftmDNSController) Deferred() bool { return true }
+
 func (c *MicroShiftmDNSController) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
 	defer close(stopped)
 
@@ -59,18 +92,59 @@ func (c *MicroShiftmDNSController) Run(ctx context.Context, ready chan<- struct{
 		if ovn.IsOVNKubernetesInternalInterface(name) || name == ovn.OVNGatewayInterface {
 			continue
 		}
+		if !util.InterfaceSelected(name, c.interfaces, c.excludeInterfaces) {
+			continue
+		}
 		klog.Infof("mDNS: Starting server on interface %q, NodeIP %q, NodeName %q", name, c.NodeIP, c.NodeName)
 		if _, err := server.New(&ifs[n], c.resolver, c.stopCh); err != nil {
 			return fmt.Errorf("failed to start server: %w", err)
 		}
 	}
 
-	ips := []string{c.NodeIP}
+	c.myIPs = c.nodeIPs(ifs)
+	if strings.HasSuffix(c.NodeName, c.domain) {
+		klog.Infof("mDNS: Host FQDN %q will be announced via mDNS on IPs %q", c.NodeName, c.myIPs)
+		c.resolver.AddDomain(c.NodeName+".", c.myIPs)
+	}
+	for _, hostname := range c.hostnames {
+		klog.Infof("mDNS: Host name %q will be announced via mDNS on IPs %q", hostname, c.myIPs)
+		c.incHost(hostname)
+		c.resolver.AddDomain(hostname+".", c.myIPs)
+	}
+
+	close(ready)
+
+	go func() {
+		if err := c.startRouteInformer(c.stopCh); err != nil {
+			klog.Errorf("error running router: %v", err)
+		}
+	}()
+	go func() {
+		if err := c.startServiceInformer(c.stopCh); err != nil {
+			klog.Errorf("error running service watcher: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
 
-	// Discover additional IPs for the interface
+	return ctx.Err()
+}
+
+// nodeIPs returns the addresses of the interfaces holding the node IPs, of
+// the IP families MicroShift is configured with. IPv6 link-local addresses
+// are answered by the server of their interface only.
+func (c *MicroShiftmDNSController) nodeIPs(ifs []net.Interface) []string {
+	ips := []string{}
+	for _, nodeIP := range []string{c.NodeIP, c.NodeIPv6} {
+		if nodeIP != "" && !slices.Contains(ips, nodeIP) {
+			ips = append(ips, nodeIP)
+		}
+	}
+
+	// Discover additional IPs for the interfaces
 	for n := range ifs {
 		addrs, _ := ifs[n].Addrs()
-		if ipInAddrs(c.NodeIP, addrs) {
+		if ipInAddrs(c.NodeIP, addrs) || (c.NodeIPv6 != "" && ipInAddrs(c.NodeIPv6, addrs)) {
 			addrs = ovn.ExcludeOVNKubernetesMasqueradeIPs(addrs)
 			addrs = slices.DeleteFunc(
 				addrs,
@@ -92,27 +166,14 @@ func (c *MicroShiftmDNSController) Run(ctx context.Context, ready chan<- struct{
 					return !c.isIpv4
 				},
 			)
-			ips = addrsToStrings(addrs)
+			for _, ip := range addrsToStrings(addrs) {
+				if !slices.Contains(ips, ip) {
+					ips = append(ips, ip)
+				}
+			}
 		}
 	}
-
-	c.myIPs = ips
-	if strings.HasSuffix(c.NodeName, server.DefaultmDNSTLD) {
-		klog.Infof("mDNS: Host FQDN %q will be announced via mDNS on IPs %q", c.NodeName, ips)
-		c.resolver.AddDomain(c.NodeName+".", ips)
-	}
-
-	close(ready)
-
-	go func() {
-		if err := c.startRouteInformer(c.stopCh); err != nil {
-			klog.Errorf("error running router: %v", err)
-		}
-	}()
-
-	<-ctx.Done()
-
-	return ctx.Err()
+	return ips
 }
 
 func ipInAddrs(ip string, addrs []net.Addr) bool {
@@ -6,7 +6,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/openshift/microshift/pkg/mdns/server"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -128,7 +127,7 @@ func (c *MicroShiftmDNSController) deletedRoute(obj interface{}) {
 }
 
 func (c *MicroShiftmDNSController) exposeHost(host string) {
-	if !strings.HasSuffix(host, server.DefaultmDNSTLD) {
+	if !strings.HasSuffix(host, c.domain) {
 		klog.V(2).Infof("mDNS: Ignoring host %q without mDNS suffix", host)
 		return
 	}
@@ -20,7 +20,9 @@ func newTestController() *MicroShiftmDNSController {
 		NodeName:  testNodeName,
 		resolver:  server.NewResolver(),
 		hostCount: make(map[string]int),
+		services:  make(map[string]string),
 		myIPs:     []string{testIP, testIPv6},
+		domain:    server.DefaultmDNSTLD,
 	}
 }
 
@@ -98,3 +100,21 @@ func Test_updatedRouteDupHost(t *testing.T) {
 		t.Errorf("Old domain must have be gone after deleting the 2nd route")
 	}
 }
+
+func Test_addedRouteCustomDomain(t *testing.T) {
+	ctl := newTestController()
+	ctl.domain = ".lan"
+	route := &unstructured.Unstructured{Object: make(map[string]interface{})}
+	assert.NoError(t, unstructured.SetNestedField(route.Object, testRouteHost, "spec", "host"))
+
+	ctl.addedRoute(route)
+	if ctl.resolver.HasDomain(testRouteHost + ".") {
+		t.Errorf("Hosts outside of the mDNS domain should not be exposed")
+	}
+
+	assert.NoError(t, unstructured.SetNestedField(route.Object, "test-route-host.lan", "spec", "host"))
+	ctl.addedRoute(route)
+	if !ctl.resolver.HasDomain("test-route-host.lan.") {
+		t.Errorf("Hosts in the mDNS domain should be exposed")
+	}
+}
@@ -17,6 +17,7 @@ package server
 
 import (
 	"net"
+	"slices"
 	"sync"
 
 	"github.com/miekg/dns"
@@ -24,17 +25,69 @@ import (
 
 const defaultTTL = 120
 
+// serviceTypesName enumerates the service types advertised in a domain, see
+// RFC 6763 section 9.
+const serviceTypesName = "_services._dns-sd._udp"
+
+// Service is a DNS-SD service instance, see RFC 6763.
+type Service struct {
+	// Instance is the name of the instance, e.g. "web-default".
+	Instance string
+	// Type is the service type and protocol, e.g. "_http._tcp".
+	Type string
+	// Domain is the suffix of the names of the service, e.g. ".local".
+	Domain string
+	// Host is the name of the host providing the service, e.g. "node.local.".
+	Host string
+	Port uint16
+	// Txt are the key=value pairs describing the service.
+	Txt []string
+}
+
+func (s Service) typeDomain() string {
+	return s.Type + s.Domain + "."
+}
+
+// Name is the fully qualified name of the instance.
+func (s Service) Name() string {
+	return s.Instance + "." + s.typeDomain()
+}
+
 type Resolver struct {
 	sync.Mutex
-	domain map[string][]net.IP
+	domain   map[string][]net.IP
+	services map[string]Service
 }
 
 func NewResolver() *Resolver {
 	return &Resolver{
-		domain: map[string][]net.IP{},
+		domain:   map[string][]net.IP{},
+		services: map[string]Service{},
 	}
 }
 
+// AddService advertises the service, replacing the instance of the same name.
+func (r *Resolver) AddService(s Service) {
+	r.Lock()
+	defer r.Unlock()
+	r.services[s.Name()] = s
+}
+
+// DeleteService stops advertising the instance with the given fully
+// qualified name.
+func (r *Resolver) DeleteService(name string) {
+	r.Lock()
+	defer r.Unlock()
+	delete(r.services, name)
+}
+
+func (r *Resolver) HasService(name string) bool {
+	r.Lock()
+	defer r.Unlock()
+	_, ok := r.services[name]
+	return ok
+}
+
 func (r *Resolver) AddDomain(name string, ipStrs []string) {
 	r.Lock()
 	defer r.Unlock()
@@ -68,11 +121,67 @@ func (r *Resolver) Answer(q dns.Question) []dns.RR {
 		return r.answerARecord(q.Name)
 	case dns.TypeAAAA:
 		return r.answerAAAARecord(q.Name)
+	case dns.TypePTR:
+		return r.answerPTRRecord(q.Name)
+	case dns.TypeSRV:
+		return r.answerSRVRecord(q.Name)
+	case dns.TypeTXT:
+		return r.answerTXTRecord(q.Name)
 	}
 
 	return nil
 }
 
+func (r *Resolver) answerPTRRecord(name string) (rr []dns.RR) {
+	var targets []string
+	for _, s := range r.services {
+		switch name {
+		case serviceTypesName + s.Domain + ".":
+			if !slices.Contains(targets, s.typeDomain()) {
+				targets = append(targets, s.typeDomain())
+			}
+		case s.typeDomain():
+			targets = append(targets, s.Name())
+		}
+	}
+	slices.Sort(targets)
+	for _, target := range targets {
+		rr = append(rr, &dns.PTR{
+			Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypePTR, Class: dns.ClassINET, Ttl: defaultTTL},
+			Ptr: target,
+		})
+	}
+	return rr
+}
+
+func (r *Resolver) answerSRVRecord(name string) []dns.RR {
+	s, ok := r.services[name]
+	if !ok {
+		return nil
+	}
+	return []dns.RR{&dns.SRV{
+		Hdr:    dns.RR_Header{Name: name, Rrtype: dns.TypeSRV, Class: dns.ClassINET, Ttl: defaultTTL},
+		Target: s.Host,
+		Port:   s.Port,
+	}}
+}
+
+func (r *Resolver) answerTXTRecord(name string) []dns.RR {
+	s, ok := r.services[name]
+	if !ok {
+		return nil
+	}
+	// A TXT record is required even without any key, with an empty string.
+	txt := s.Txt
+	if len(txt) == 0 {
+		txt = []string{""}
+	}
+	return []dns.RR{&dns.TXT{
+		Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: defaultTTL},
+		Txt: txt,
+	}}
+}
+
 func (r *Resolver) answerARecord(name string) (rr []dns.RR) {
 	for _, ip4 := range r.getIPs(name, net.IPv4len) {
 		rr = append(rr, &dns.A{
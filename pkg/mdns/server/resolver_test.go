@@ -86,3 +86,34 @@ func testResolverDomainTypeAddress(t *testing.T, r *Resolver, name string, qtype
 		t.Errorf("%s didn't respond with address %s", res[0], addr)
 	}
 }
+
+func TestResolver_Services(t *testing.T) {
+	r := NewResolver()
+	r.AddService(Service{Instance: "web-demo", Type: "_http._tcp", Domain: DefaultmDNSTLD, Host: testDomain, Port: 8080, Txt: []string{"path=/"}})
+	r.AddService(Service{Instance: "printer-demo", Type: "_ipp._tcp", Domain: DefaultmDNSTLD, Host: testDomain, Port: 631})
+
+	res := r.Answer(dns.Question{Qtype: dns.TypePTR, Name: "_services._dns-sd._udp.local."})
+	if len(res) != 2 || res[0].(*dns.PTR).Ptr != "_http._tcp.local." || res[1].(*dns.PTR).Ptr != "_ipp._tcp.local." {
+		t.Errorf("The service types should be enumerated, but got %+v", res)
+	}
+
+	res = r.Answer(dns.Question{Qtype: dns.TypePTR, Name: "_http._tcp.local."})
+	if len(res) != 1 || res[0].(*dns.PTR).Ptr != "web-demo._http._tcp.local." {
+		t.Errorf("The instances of the service type should be answered, but got %+v", res)
+	}
+
+	res = r.Answer(dns.Question{Qtype: dns.TypeSRV, Name: "web-demo._http._tcp.local."})
+	if len(res) != 1 || res[0].(*dns.SRV).Target != testDomain || res[0].(*dns.SRV).Port != 8080 {
+		t.Errorf("The SRV record of the instance should be answered, but got %+v", res)
+	}
+
+	res = r.Answer(dns.Question{Qtype: dns.TypeTXT, Name: "printer-demo._ipp._tcp.local."})
+	if len(res) != 1 || len(res[0].(*dns.TXT).Txt) != 1 || res[0].(*dns.TXT).Txt[0] != "" {
+		t.Errorf("An empty TXT record should be answered for instances without keys, but got %+v", res)
+	}
+
+	r.DeleteService("web-demo._http._tcp.local.")
+	if res := r.Answer(dns.Question{Qtype: dns.TypeSRV, Name: "web-demo._http._tcp.local."}); len(res) != 0 {
+		t.Errorf("A deleted service should not be answered, but got %+v", res)
+	}
+}
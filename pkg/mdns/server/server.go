@@ -35,14 +35,27 @@ type Server struct {
 	responder Responder
 	listeners []*net.UDPConn
 	stopCh    chan struct{}
+	// linkLocal are the IPv6 link-local addresses of iface, only reachable
+	// from its link, so they are only answered on it.
+	linkLocal []net.IP
 }
 
 type Responder interface {
 	Answer(q dns.Question) []dns.RR
 }
 
+// domainResponder is implemented by the responders that can tell whether
+// they answer for a name, to add the link-local addresses of the interface
+// to their AAAA answers.
+type domainResponder interface {
+	HasDomain(name string) bool
+}
+
 func New(iface *net.Interface, responder Responder, stopCh chan struct{}) (*Server, error) {
 	srv := &Server{iface: iface, stopCh: stopCh, responder: responder}
+	if addrs, err := iface.Addrs(); err == nil {
+		srv.linkLocal = linkLocalIPv6(addrs)
+	}
 
 	for network, ipaddr := range map[string]string{"udp4": ipV4MDNSAddr, "udp6": ipV6MDNSAddr} {
 		listener, _ := net.ListenMulticastUDP(network, iface, &net.UDPAddr{
@@ -98,6 +111,7 @@ func (s *Server) handlemDNSPacket(conn *net.UDPConn, packet []byte, from net.Add
 	// Handle all the questions and construct the answers
 	for _, q := range query.Question {
 		answers = append(answers, s.responder.Answer(q)...)
+		answers = append(answers, s.answerLinkLocal(q)...)
 		unicast = unicast || q.Qclass&(1<<15) != 0
 	}
 
@@ -124,6 +138,31 @@ func (s *Server) handlemDNSPacket(conn *net.UDPConn, packet []byte, from net.Add
 	return nil
 }
 
+func (s *Server) answerLinkLocal(q dns.Question) (rr []dns.RR) {
+	r, ok := s.responder.(domainResponder)
+	if q.Qtype != dns.TypeAAAA || !ok || !r.HasDomain(q.Name) {
+		return nil
+	}
+	for _, ip := range s.linkLocal {
+		rr = append(rr, &dns.AAAA{
+			Hdr:  dns.RR_Header{Name: q.Name, Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: defaultTTL},
+			AAAA: ip,
+		})
+	}
+	return rr
+}
+
+func linkLocalIPv6(addrs []net.Addr) []net.IP {
+	var ips []net.IP
+	for _, a := range addrs {
+		ip, _, err := net.ParseCIDR(a.String())
+		if err == nil && ip.To4() == nil && ip.IsLinkLocalUnicast() {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
 func (s *Server) sendmDNSResponse(conn *net.UDPConn, resp *dns.Msg, from net.Addr, unicast bool) error {
 	destAddr := from.(*net.UDPAddr)
 
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestServer_answerLinkLocal(t *testing.T) {
+	r := NewResolver()
+	populateResolverForTests(r)
+	s := &Server{
+		responder: r,
+		linkLocal: linkLocalIPv6([]net.Addr{
+			&net.IPNet{IP: net.ParseIP("192.168.1.10"), Mask: net.CIDRMask(24, 32)},
+			&net.IPNet{IP: net.ParseIP("2001:db8::10"), Mask: net.CIDRMask(64, 128)},
+			&net.IPNet{IP: net.ParseIP("fe80::10"), Mask: net.CIDRMask(64, 128)},
+		}),
+	}
+
+	res := s.answerLinkLocal(dns.Question{Qtype: dns.TypeAAAA, Name: testDomain})
+	if len(res) != 1 || res[0].(*dns.AAAA).AAAA.String() != "fe80::10" {
+		t.Errorf("Expected the link-local address of the interface to be answered, got %+v", res)
+	}
+	if res := s.answerLinkLocal(dns.Question{Qtype: dns.TypeA, Name: testDomain}); len(res) != 0 {
+		t.Errorf("Link-local addresses should only be answered to AAAA queries, got %+v", res)
+	}
+	if res := s.answerLinkLocal(dns.Question{Qtype: dns.TypeAAAA, Name: "unknown.local."}); len(res) != 0 {
+		t.Errorf("Link-local addresses should only be answered for known domains, got %+v", res)
+	}
+}
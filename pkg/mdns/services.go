@@ -0,0 +1,185 @@
+package mdns
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/openshift/microshift/pkg/mdns/server"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// serviceTypeAnnotation opts a LoadBalancer or NodePort service in to be
+	// advertised with DNS-SD, with its service type, e.g. "_http._tcp".
+	serviceTypeAnnotation = "microshift.io/mdns-service-type"
+	// servicePortAnnotation selects the port advertised, by name or number.
+	// The first port of the service is advertised by default.
+	servicePortAnnotation = "microshift.io/mdns-service-port"
+	// serviceTxtAnnotation lists the comma separated key=value pairs of the
+	// TXT record of the service, e.g. "path=/api,version=2".
+	serviceTxtAnnotation = "microshift.io/mdns-service-txt"
+)
+
+var serviceTypeRegexp = regexp.MustCompile(`^_[a-z0-9]([a-z0-9-]{0,13}[a-z0-9])?\._(tcp|udp)$`)
+
+func (c *MicroShiftmDNSController) startServiceInformer(stopCh chan struct{}) error {
+	klog.Infof("Starting MicroShift mDNS service watcher")
+	cfg, err := c.restConfig()
+	if err != nil {
+		return fmt.Errorf("failed to create rest config for service informer: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client for service informer: %w", err)
+	}
+
+	informer := informers.NewSharedInformerFactory(client, defaultResyncTime).Core().V1().Services().Informer()
+	handlers := cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.updatedService,
+		UpdateFunc: func(_, newObj interface{}) { c.updatedService(newObj) },
+		DeleteFunc: c.deletedService,
+	}
+	if _, err := informer.AddEventHandler(handlers); err != nil {
+		return fmt.Errorf("failed to initialize event handler for service controller: %w", err)
+	}
+	informer.Run(stopCh)
+	return nil
+}
+
+// mDNSHostName is the target of the SRV records of the services: the node
+// name when it is in the mDNS domain, the first label of it under the domain
+// otherwise.
+func (c *MicroShiftmDNSController) mDNSHostName() string {
+	if strings.HasSuffix(c.NodeName, c.domain) {
+		return c.NodeName
+	}
+	return strings.Split(c.NodeName, ".")[0] + c.domain
+}
+
+func (c *MicroShiftmDNSController) updatedService(obj interface{}) {
+	svc, ok := obj.(*corev1.Service)
+	if !ok {
+		return
+	}
+	key := svc.Namespace + "/" + svc.Name
+
+	s, advertised, err := dnssdService(svc, c.domain, c.mDNSHostName()+".")
+	if err != nil {
+		klog.Warningf("mDNS: Not advertising service %s: %v", key, err)
+	}
+
+	c.Lock()
+	previous, wasAdvertised := c.services[key]
+	if advertised {
+		c.services[key] = s.Name()
+	} else {
+		delete(c.services, key)
+	}
+	c.Unlock()
+
+	if wasAdvertised && (!advertised || previous != s.Name()) {
+		c.resolver.DeleteService(previous)
+	}
+	if advertised {
+		if !wasAdvertised {
+			klog.Infof("mDNS: Advertising service %s as %q on port %d", key, s.Name(), s.Port)
+			c.incHost(c.mDNSHostName())
+			c.resolver.AddDomain(c.mDNSHostName()+".", c.myIPs)
+		}
+		c.resolver.AddService(s)
+	} else if wasAdvertised {
+		klog.Infof("mDNS: Removing service %s", key)
+		c.unexposeServiceHost()
+	}
+}
+
+func (c *MicroShiftmDNSController) deletedService(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	svc, ok := obj.(*corev1.Service)
+	if !ok {
+		return
+	}
+	key := svc.Namespace + "/" + svc.Name
+
+	c.Lock()
+	previous, wasAdvertised := c.services[key]
+	delete(c.services, key)
+	c.Unlock()
+
+	if wasAdvertised {
+		klog.Infof("mDNS: Removing service %s", key)
+		c.resolver.DeleteService(previous)
+		c.unexposeServiceHost()
+	}
+}
+
+// unexposeServiceHost stops announcing the host name once no route nor
+// service refers to it, unless it is the node name announced on start.
+func (c *MicroShiftmDNSController) unexposeServiceHost() {
+	host := c.mDNSHostName()
+	if c.decHost(host) == 0 && host != c.NodeName {
+		c.resolver.DeleteDomain(host + ".")
+	}
+}
+
+// dnssdService returns the DNS-SD service advertising svc on host in domain,
+// and whether svc is to be advertised at all.
+func dnssdService(svc *corev1.Service, domain, host string) (server.Service, bool, error) {
+	serviceType, ok := svc.Annotations[serviceTypeAnnotation]
+	if !ok {
+		return server.Service{}, false, nil
+	}
+	if svc.Spec.Type != corev1.ServiceTypeLoadBalancer && svc.Spec.Type != corev1.ServiceTypeNodePort {
+		return server.Service{}, false, fmt.Errorf("only LoadBalancer and NodePort services can be advertised, not %s", svc.Spec.Type)
+	}
+	if !serviceTypeRegexp.MatchString(serviceType) {
+		return server.Service{}, false, fmt.Errorf("invalid %s %q, expected e.g. _http._tcp", serviceTypeAnnotation, serviceType)
+	}
+	if len(svc.Spec.Ports) == 0 {
+		return server.Service{}, false, fmt.Errorf("service has no ports")
+	}
+
+	port := svc.Spec.Ports[0]
+	if name, ok := svc.Annotations[servicePortAnnotation]; ok {
+		found := false
+		for _, p := range svc.Spec.Ports {
+			if p.Name == name || strconv.Itoa(int(p.Port)) == name {
+				port, found = p, true
+				break
+			}
+		}
+		if !found {
+			return server.Service{}, false, fmt.Errorf("%s %q does not match any port", servicePortAnnotation, name)
+		}
+	}
+	// NodePort services are reachable on the node port, LoadBalancer ones on
+	// the port of the service on the node IPs.
+	number := port.Port
+	if svc.Spec.Type == corev1.ServiceTypeNodePort {
+		number = port.NodePort
+	}
+
+	var txt []string
+	if value := svc.Annotations[serviceTxtAnnotation]; value != "" {
+		for _, pair := range strings.Split(value, ",") {
+			txt = append(txt, strings.TrimSpace(pair))
+		}
+	}
+
+	return server.Service{
+		Instance: svc.Name + "-" + svc.Namespace,
+		Type:     serviceType,
+		Domain:   domain,
+		Host:     host,
+		Port:     uint16(number),
+		Txt:      txt,
+	}, true, nil
+}
@@ -0,0 +1,106 @@
+package mdns
+
+import (
+	"testing"
+
+	"github.com/openshift/microshift/pkg/mdns/server"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestService(svcType corev1.ServiceType, annotations map[string]string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "demo", Annotations: annotations},
+		Spec: corev1.ServiceSpec{
+			Type: svcType,
+			Ports: []corev1.ServicePort{
+				{Name: "metrics", Port: 9090, NodePort: 30090},
+				{Name: "http", Port: 8080, NodePort: 30080},
+			},
+		},
+	}
+}
+
+func Test_dnssdService(t *testing.T) {
+	tests := []struct {
+		name           string
+		svc            *corev1.Service
+		wantAdvertised bool
+		wantErr        bool
+		want           server.Service
+	}{
+		{
+			name: "not annotated",
+			svc:  newTestService(corev1.ServiceTypeLoadBalancer, nil),
+		},
+		{
+			name:           "load balancer on the first port",
+			svc:            newTestService(corev1.ServiceTypeLoadBalancer, map[string]string{serviceTypeAnnotation: "_http._tcp"}),
+			wantAdvertised: true,
+			want:           server.Service{Instance: "web-demo", Type: "_http._tcp", Domain: ".local", Host: "node.local.", Port: 9090},
+		},
+		{
+			name: "node port on a named port with TXT keys",
+			svc: newTestService(corev1.ServiceTypeNodePort, map[string]string{
+				serviceTypeAnnotation: "_http._tcp",
+				servicePortAnnotation: "http",
+				serviceTxtAnnotation:  "path=/api, version=2",
+			}),
+			wantAdvertised: true,
+			want:           server.Service{Instance: "web-demo", Type: "_http._tcp", Domain: ".local", Host: "node.local.", Port: 30080, Txt: []string{"path=/api", "version=2"}},
+		},
+		{
+			name:    "cluster IP",
+			svc:     newTestService(corev1.ServiceTypeClusterIP, map[string]string{serviceTypeAnnotation: "_http._tcp"}),
+			wantErr: true,
+		},
+		{
+			name:    "invalid service type",
+			svc:     newTestService(corev1.ServiceTypeLoadBalancer, map[string]string{serviceTypeAnnotation: "http"}),
+			wantErr: true,
+		},
+		{
+			name:    "unknown port",
+			svc:     newTestService(corev1.ServiceTypeLoadBalancer, map[string]string{serviceTypeAnnotation: "_http._tcp", servicePortAnnotation: "https"}),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, advertised, err := dnssdService(tt.svc, ".local", "node.local.")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("dnssdService() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.wantAdvertised, advertised)
+			if advertised {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_updatedService(t *testing.T) {
+	ctl := newTestController()
+	svc := newTestService(corev1.ServiceTypeLoadBalancer, map[string]string{serviceTypeAnnotation: "_http._tcp"})
+
+	ctl.updatedService(svc)
+	if !ctl.resolver.HasService("web-demo._http._tcp.local.") {
+		t.Errorf("An annotated service should be advertised")
+	}
+	if !ctl.resolver.HasDomain(testNodeName + ".") {
+		t.Errorf("The host of an advertised service should resolve")
+	}
+
+	svc = svc.DeepCopy()
+	svc.Annotations[serviceTypeAnnotation] = "_ipp._tcp"
+	ctl.updatedService(svc)
+	if ctl.resolver.HasService("web-demo._http._tcp.local.") || !ctl.resolver.HasService("web-demo._ipp._tcp.local.") {
+		t.Errorf("Changing the service type should replace the advertised instance")
+	}
+
+	ctl.deletedService(svc)
+	if ctl.resolver.HasService("web-demo._ipp._tcp.local.") {
+		t.Errorf("A deleted service should not be advertised")
+	}
+}
@@ -0,0 +1,181 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/servicemanager"
+	"github.com/openshift/microshift/pkg/util/cryptomaterial/certchains"
+
+	k8smetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+// Startup phases of MicroShift, reported by SetStartupPhaseDuration.
+const (
+	// PhaseInitialization is the management of the data directory and the
+	// version metadata, until the certificates are generated.
+	PhaseInitialization = "initialization"
+	// PhaseCertificates is the generation of the certificates and kubeconfigs.
+	PhaseCertificates = "certificates"
+	// PhaseServices is the start of the services until they are all ready.
+	PhaseServices = "services"
+)
+
+// configReloadsFile persists the number of reloads, which restart the
+// process.
+func configReloadsFile() string { return filepath.Join(config.DataDir, "config-reloads") }
+
+var (
+	serviceStates = []servicemanager.ServiceState{
+		servicemanager.StateWaiting,
+		servicemanager.StateStarting,
+		servicemanager.StateReady,
+		servicemanager.StateStopped,
+		servicemanager.StateFailed,
+		servicemanager.StateDisabled,
+	}
+
+	serviceState = k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Name:           "microshift_service_state",
+			Help:           "Whether a MicroShift service is in the state (1) or not (0).",
+			StabilityLevel: k8smetrics.ALPHA,
+		},
+		[]string{"service", "state"},
+	)
+	startupPhaseDuration = k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Name:           "microshift_startup_phase_duration_seconds",
+			Help:           "Duration of the phases of the last start of MicroShift.",
+			StabilityLevel: k8smetrics.ALPHA,
+		},
+		[]string{"phase"},
+	)
+	certificateExpiryDays = k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Name:           "microshift_certificate_expiry_days",
+			Help:           "Days until a MicroShift certificate expires, negative once expired.",
+			StabilityLevel: k8smetrics.ALPHA,
+		},
+		[]string{"name"},
+	)
+	certificateChainExpiryDays = k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Name:           "microshift_certificate_chain_expiry_days",
+			Help:           "Days until the first certificate of a MicroShift certificate chain expires, negative once expired.",
+			StabilityLevel: k8smetrics.ALPHA,
+		},
+		[]string{"chain"},
+	)
+	etcdDBSize = k8smetrics.NewGauge(
+		&k8smetrics.GaugeOpts{
+			Name:           "microshift_etcd_db_size_bytes",
+			Help:           "Size of the etcd database file.",
+			StabilityLevel: k8smetrics.ALPHA,
+		},
+	)
+	etcdDBSizeInUse = k8smetrics.NewGauge(
+		&k8smetrics.GaugeOpts{
+			Name:           "microshift_etcd_db_size_in_use_bytes",
+			Help:           "Size of the etcd database in use, the rest is reclaimed by defragmentation.",
+			StabilityLevel: k8smetrics.ALPHA,
+		},
+	)
+	configReloads = k8smetrics.NewCounter(
+		&k8smetrics.CounterOpts{
+			Name:           "microshift_config_reloads_total",
+			Help:           "Number of reloads of the configuration requested through the admin API.",
+			StabilityLevel: k8smetrics.ALPHA,
+		},
+	)
+)
+
+func init() {
+	// The legacy registry is served by the embedded kube-apiserver on /metrics.
+	legacyregistry.MustRegister(serviceState, startupPhaseDuration, certificateExpiryDays,
+		certificateChainExpiryDays, etcdDBSize, etcdDBSizeInUse, configReloads)
+}
+
+// SetStartupPhaseDuration reports how long a phase of the start of MicroShift took.
+func SetStartupPhaseDuration(phase string, d time.Duration) {
+	startupPhaseDuration.WithLabelValues(phase).Set(d.Seconds())
+}
+
+// RestoreConfigReloads reports the reloads of the previous runs of MicroShift.
+func RestoreConfigReloads() error {
+	count, err := readConfigReloads()
+	if err != nil {
+		return err
+	}
+	configReloads.Add(float64(count))
+	return nil
+}
+
+// RecordConfigReload counts a reload, before MicroShift stops to reload.
+func RecordConfigReload() error {
+	count, err := readConfigReloads()
+	if err != nil {
+		return err
+	}
+	configReloads.Inc()
+	if err := os.WriteFile(configReloadsFile(), []byte(strconv.Itoa(count+1)), 0600); err != nil {
+		return fmt.Errorf("failed to write %q: %w", configReloadsFile(), err)
+	}
+	return nil
+}
+
+func readConfigReloads() (int, error) {
+	data, err := os.ReadFile(configReloadsFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %q: %w", configReloadsFile(), err)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid number of reloads in %q: %w", configReloadsFile(), err)
+	}
+	return count, nil
+}
+
+// updateServiceStates reports the current state of every service.
+func updateServiceStates(statuses []servicemanager.ServiceStatus) {
+	serviceState.Reset()
+	for _, status := range statuses {
+		for _, state := range serviceStates {
+			value := 0.0
+			if status.State == state {
+				value = 1
+			}
+			serviceState.WithLabelValues(status.Name, string(state)).Set(value)
+		}
+	}
+}
+
+// updateCertificateExpiry reports the days until every certificate expires,
+// and until the first certificate of every chain, named after its root
+// signer, expires.
+func updateCertificateExpiry(certs []certchains.CertificateInfo, now time.Time) {
+	certificateExpiryDays.Reset()
+	certificateChainExpiryDays.Reset()
+	chainExpiry := make(map[string]time.Time)
+	for _, cert := range certs {
+		certificateExpiryDays.WithLabelValues(cert.Name).Set(cert.NotAfter.Sub(now).Hours() / 24)
+
+		chain, _, _ := strings.Cut(cert.Name, "/")
+		if expiry, ok := chainExpiry[chain]; !ok || cert.NotAfter.Before(expiry) {
+			chainExpiry[chain] = cert.NotAfter
+		}
+	}
+	for chain, expiry := range chainExpiry {
+		certificateChainExpiryDays.WithLabelValues(chain).Set(expiry.Sub(now).Hours() / 24)
+	}
+}
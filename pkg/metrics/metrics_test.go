@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/servicemanager"
+	"github.com/openshift/microshift/pkg/util/cryptomaterial/certchains"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func TestConfigReloads(t *testing.T) {
+	dataDir := config.DataDir
+	config.DataDir = t.TempDir()
+	defer func() { config.DataDir = dataDir }()
+	configReloads.Reset()
+
+	assert.NoError(t, RestoreConfigReloads())
+	assert.NoError(t, RecordConfigReload())
+	assert.NoError(t, RecordConfigReload())
+	data, err := os.ReadFile(configReloadsFile())
+	require.NoError(t, err)
+	assert.Equal(t, "2", string(data))
+
+	// A restart reports the reloads of the previous runs.
+	configReloads.Reset()
+	assert.NoError(t, RestoreConfigReloads())
+	value, err := testutil.GetCounterMetricValue(configReloads)
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, value)
+
+	require.NoError(t, os.WriteFile(configReloadsFile(), []byte("garbage"), 0600))
+	assert.Error(t, RestoreConfigReloads())
+}
+
+func TestUpdateServiceStates(t *testing.T) {
+	updateServiceStates([]servicemanager.ServiceStatus{
+		{Name: "etcd", State: servicemanager.StateReady},
+		{Name: "kubelet", State: servicemanager.StateFailed},
+	})
+
+	expected := `
+		# HELP microshift_service_state [ALPHA] Whether a MicroShift service is in the state (1) or not (0).
+		# TYPE microshift_service_state gauge
+		microshift_service_state{service="etcd",state="Disabled"} 0
+		microshift_service_state{service="etcd",state="Failed"} 0
+		microshift_service_state{service="etcd",state="Ready"} 1
+		microshift_service_state{service="etcd",state="Starting"} 0
+		microshift_service_state{service="etcd",state="Stopped"} 0
+		microshift_service_state{service="etcd",state="Waiting"} 0
+		microshift_service_state{service="kubelet",state="Disabled"} 0
+		microshift_service_state{service="kubelet",state="Failed"} 1
+		microshift_service_state{service="kubelet",state="Ready"} 0
+		microshift_service_state{service="kubelet",state="Starting"} 0
+		microshift_service_state{service="kubelet",state="Stopped"} 0
+		microshift_service_state{service="kubelet",state="Waiting"} 0
+	`
+	assert.NoError(t, testutil.CollectAndCompare(serviceState, strings.NewReader(expected)))
+}
+
+func TestUpdateCertificateExpiry(t *testing.T) {
+	now := time.Now()
+	updateCertificateExpiry([]certchains.CertificateInfo{
+		{Name: "admin-kubeconfig-signer", NotAfter: now.Add(10 * 24 * time.Hour)},
+		{Name: "admin-kubeconfig-signer/admin", NotAfter: now.Add(-12 * time.Hour)},
+	}, now)
+
+	for name, days := range map[string]float64{
+		"admin-kubeconfig-signer":       10,
+		"admin-kubeconfig-signer/admin": -0.5,
+	} {
+		value, err := testutil.GetGaugeMetricValue(certificateExpiryDays.WithLabelValues(name))
+		require.NoError(t, err)
+		assert.Equal(t, days, value, name)
+	}
+	// The chain expires with its first certificate.
+	value, err := testutil.GetGaugeMetricValue(certificateChainExpiryDays.WithLabelValues("admin-kubeconfig-signer"))
+	require.NoError(t, err)
+	assert.Equal(t, -0.5, value)
+}
+
+func TestHandler(t *testing.T) {
+	SetStartupPhaseDuration(PhaseServices, 90*time.Second)
+	s := NewServer(&config.Config{}, servicemanager.NewServiceManager(), nil)
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `microshift_startup_phase_duration_seconds{phase="services"} 90`)
+	// Only the metrics of MicroShift are served.
+	for _, line := range strings.Split(body, "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		assert.True(t, strings.HasPrefix(line, metricsPrefix), line)
+	}
+}
+
+func TestListenUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run", "metrics.sock")
+	// A stale socket is replaced.
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, nil, 0600))
+
+	listener, err := listen(config.MetricsUnixScheme + path)
+	require.NoError(t, err)
+	defer listener.Close()
+	assert.Equal(t, "unix", listener.Addr().Network())
+}
@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/controllers"
+	"github.com/openshift/microshift/pkg/servicemanager"
+	"github.com/openshift/microshift/pkg/util/cryptomaterial/certchains"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	k8smetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// metricsPrefix selects the metrics of MicroShift itself among the
+	// ones of the embedded components.
+	metricsPrefix = "microshift_"
+
+	etcdReportInterval = 30 * time.Second
+	etcdStatusTimeout  = 5 * time.Second
+)
+
+// Server serves the metrics of MicroShift itself, without the ones of the
+// embedded components, for scraping by the monitoring agents of the host.
+type Server struct {
+	cfg        *config.Config
+	services   *servicemanager.ServiceManager
+	certChains *certchains.CertificateChains
+}
+
+func NewServer(cfg *config.Config, services *servicemanager.ServiceManager, certChains *certchains.CertificateChains) *Server {
+	return &Server{
+		cfg:        cfg,
+		services:   services,
+		certChains: certChains,
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	handler := k8smetrics.HandlerFor(prometheus.GathererFunc(gatherMicroShift), k8smetrics.HandlerOpts{})
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.update()
+		handler.ServeHTTP(w, r)
+	}))
+	return mux
+}
+
+// Serve serves the metrics on cfg.Metrics.ListenAddress until ctx is done.
+func (s *Server) Serve(ctx context.Context) error {
+	listener, err := listen(s.cfg.Metrics.ListenAddress)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	go s.reportEtcdDBSize(ctx)
+
+	klog.Infof("Serving MicroShift metrics on %s", s.cfg.Metrics.ListenAddress)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, config.MetricsUnixScheme)
+	if !ok {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+		}
+		return listener, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create dir %q: %w", filepath.Dir(path), err)
+	}
+	// A stale socket is left behind when MicroShift is killed.
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove stale socket %q: %w", path, err)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %q: %w", path, err)
+	}
+	return listener, nil
+}
+
+// update refreshes the metrics that are cheap to compute before they are scraped.
+func (s *Server) update() {
+	updateServiceStates(s.services.Statuses())
+	if s.certChains == nil {
+		return
+	}
+	certs, err := certchains.ListCertificates(s.certChains)
+	if err != nil {
+		klog.V(2).Infof("Failed to list certificates: %v", err)
+		return
+	}
+	updateCertificateExpiry(certs, time.Now())
+}
+
+// reportEtcdDBSize periodically reports the size of the etcd database until
+// ctx is done. Failures are expected until etcd is started.
+func (s *Server) reportEtcdDBSize(ctx context.Context) {
+	ticker := time.NewTicker(etcdReportInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.updateEtcdDBSize(ctx); err != nil {
+			klog.V(2).Infof("Failed to report the etcd database size: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Server) updateEtcdDBSize(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, etcdStatusTimeout)
+	defer cancel()
+	client, err := controllers.GetEtcdClient(ctx, s.cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to etcd: %w", err)
+	}
+	defer client.Close()
+
+	endpoints := client.Endpoints()
+	if len(endpoints) == 0 {
+		return fmt.Errorf("no etcd endpoint")
+	}
+	status, err := client.Status(ctx, endpoints[0])
+	if err != nil {
+		return fmt.Errorf("failed to get the etcd status: %w", err)
+	}
+	etcdDBSize.Set(float64(status.DbSize))
+	etcdDBSizeInUse.Set(float64(status.DbSizeInUse))
+	return nil
+}
+
+// gatherMicroShift gathers the metrics of MicroShift from the legacy registry.
+func gatherMicroShift() ([]*dto.MetricFamily, error) {
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	microshift := make([]*dto.MetricFamily, 0, len(families))
+	for _, family := range families {
+		if strings.HasPrefix(family.GetName(), metricsPrefix) {
+			microshift = append(microshift, family)
+		}
+	}
+	return microshift, err
+}
@@ -0,0 +1,124 @@
+package node
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/util/cryptomaterial/certchains"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// CertificatesExpiringSoon is the node condition reporting the
+	// certificates of MicroShift past their rotation date.
+	CertificatesExpiringSoon corev1.NodeConditionType = "CertificatesExpiringSoon"
+
+	certificatesConditionInterval = time.Hour
+)
+
+// CertificatesCondition sets the CertificatesExpiringSoon condition of the
+// node, for the fleet monitoring to catch the certificates that were not
+// rotated in time, like the ones of the devices powered off past their
+// rotation date or the signers of an external CA expiring soon.
+type CertificatesCondition struct {
+	cfg        *config.Config
+	certChains *certchains.CertificateChains
+}
+
+func NewCertificatesCondition(cfg *config.Config, certChains *certchains.CertificateChains) *CertificatesCondition {
+	return &CertificatesCondition{cfg: cfg, certChains: certChains}
+}
+
+func (s *CertificatesCondition) Name() string { return "certificates-condition" }
+func (s *CertificatesCondition) Dependencies() []string {
+	return []string{"kube-apiserver", componentKubelet}
+}
+
+func (s *CertificatesCondition) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	defer close(stopped)
+	// MicroShift does not wait for the condition to be set.
+	close(ready)
+
+	client, err := newDrainClient(s.cfg)
+	if err != nil {
+		return err
+	}
+	nodeName := s.cfg.CanonicalNodeName()
+
+	// The kubelet registers the node after it is ready.
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := s.update(ctx, client, nodeName, time.Now()); err != nil {
+			klog.Warningf("Failed to set the %s condition of node %s: %v", CertificatesExpiringSoon, nodeName, err)
+		}
+	}, certificatesConditionInterval)
+	return ctx.Err()
+}
+
+func (s *CertificatesCondition) update(ctx context.Context, client kubernetes.Interface, nodeName string, now time.Time) error {
+	condition, err := certificatesCondition(s.certChains, now)
+	if err != nil {
+		return err
+	}
+
+	node, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	condition.LastTransitionTime = metav1.NewTime(now)
+	for _, c := range node.Status.Conditions {
+		if c.Type == condition.Type && c.Status == condition.Status {
+			condition.LastTransitionTime = c.LastTransitionTime
+		}
+	}
+
+	// The conditions are merged by type, leaving the ones of the kubelet.
+	patch, err := json.Marshal(map[string]any{
+		"status": map[string]any{
+			"conditions": []corev1.NodeCondition{condition},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.CoreV1().Nodes().PatchStatus(ctx, nodeName, patch)
+	return err
+}
+
+// certificatesCondition returns the CertificatesExpiringSoon condition,
+// which is true when any certificate is past its rotation date.
+func certificatesCondition(cs *certchains.CertificateChains, now time.Time) (corev1.NodeCondition, error) {
+	var expiring []string
+	err := cs.WalkChains(nil, func(certPath []string, c x509.Certificate) error {
+		if !now.Before(certchains.RotationDate(&c)) {
+			expiring = append(expiring, fmt.Sprintf("%s expires at %s", strings.Join(certPath, "/"), c.NotAfter.Format(time.RFC3339)))
+		}
+		return nil
+	})
+	if err != nil {
+		return corev1.NodeCondition{}, fmt.Errorf("failed to check the certificates: %w", err)
+	}
+
+	condition := corev1.NodeCondition{
+		Type:              CertificatesExpiringSoon,
+		Status:            corev1.ConditionFalse,
+		LastHeartbeatTime: metav1.NewTime(now),
+		Reason:            "CertificatesValid",
+		Message:           "No certificate is past its rotation date",
+	}
+	if len(expiring) != 0 {
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = "CertificatesNotRotated"
+		condition.Message = "Certificates past their rotation date: " + strings.Join(expiring, ", ")
+	}
+	return condition, nil
+}
@@ -0,0 +1,65 @@
+package node
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openshift/microshift/pkg/util/cryptomaterial/certchains"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCertificatesCondition_update(t *testing.T) {
+	tmpDir := t.TempDir()
+	cs, err := certchains.NewCertificateChains(
+		certchains.NewCertificateSigner("kube-control-plane-signer", filepath.Join(tmpDir, "control-plane"), 365).
+			WithClientCertificates(&certchains.ClientCertificateSigningRequestInfo{
+				CSRMeta:  certchains.CSRMeta{Name: "kube-scheduler", ValidityDays: 365},
+				UserInfo: &user.DefaultInfo{Name: "system:kube-scheduler"},
+			}),
+	).Complete()
+	require.NoError(t, err)
+
+	transition := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	client := fake.NewSimpleClientset(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node"},
+		Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			{Type: CertificatesExpiringSoon, Status: corev1.ConditionFalse, LastTransitionTime: transition},
+		}},
+	})
+	s := NewCertificatesCondition(nil, cs)
+	condition := func(t *testing.T) corev1.NodeCondition {
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), "node", metav1.GetOptions{})
+		require.NoError(t, err)
+		// The conditions of the kubelet are kept.
+		require.Len(t, node.Status.Conditions, 2)
+		for _, c := range node.Status.Conditions {
+			if c.Type == CertificatesExpiringSoon {
+				return c
+			}
+		}
+		t.Fatalf("no %s condition", CertificatesExpiringSoon)
+		return corev1.NodeCondition{}
+	}
+
+	require.NoError(t, s.update(context.TODO(), client, "node", time.Now()))
+	c := condition(t)
+	assert.Equal(t, corev1.ConditionFalse, c.Status)
+	assert.True(t, transition.Equal(&c.LastTransitionTime), "the transition time is kept")
+
+	// The certificates rotate 4 months before they expire.
+	require.NoError(t, s.update(context.TODO(), client, "node", time.Now().Add(300*24*time.Hour)))
+	c = condition(t)
+	assert.Equal(t, corev1.ConditionTrue, c.Status)
+	assert.Equal(t, "CertificatesNotRotated", c.Reason)
+	assert.Contains(t, c.Message, "kube-control-plane-signer/kube-scheduler expires at")
+	assert.False(t, transition.Equal(&c.LastTransitionTime))
+}
@@ -0,0 +1,230 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// drainedAnnotation marks a node cordoned by MicroShift on shutdown,
+	// so only those nodes are uncordoned on the next start and a node
+	// cordoned by the user stays cordoned.
+	drainedAnnotation = "microshift.openshift.io/drained-on-shutdown"
+
+	drainPollInterval = time.Second
+)
+
+// DrainNode cordons the node and evicts its pods, waiting for them to
+// terminate for at most the configured drain timeout. Pods owned by a
+// DaemonSet and static pods are left running, as the kubelet stops them.
+// Evictions refused because of a PodDisruptionBudget fall back to deleting
+// the pod: there is no other node to move the pod to, so the budget could
+// never be satisfied.
+func DrainNode(ctx context.Context, cfg *config.Config) error {
+	timeout := time.Duration(ptr.Deref(cfg.Node.Drain.TimeoutSeconds, 0)) * time.Second
+	if timeout == 0 {
+		klog.Info("Node drain is disabled")
+		return nil
+	}
+
+	client, err := newDrainClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	nodeName := cfg.CanonicalNodeName()
+	if err := cordonNode(ctx, client, nodeName); err != nil {
+		return err
+	}
+
+	pods, err := podsToEvict(ctx, client, nodeName)
+	if err != nil {
+		return err
+	}
+	klog.Infof("Evicting %d pods from node %s", len(pods), nodeName)
+
+	errs := []error{}
+	for i := range pods {
+		if err := evictPod(ctx, client, &pods[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	if err := waitForPodsDeleted(ctx, client, pods); err != nil {
+		return fmt.Errorf("pods did not terminate within %v: %w", timeout, err)
+	}
+	klog.Infof("Node %s drained", nodeName)
+	return nil
+}
+
+// UncordonNode makes the node schedulable again if it was cordoned by
+// DrainNode. It retries until it succeeds or ctx is done.
+func UncordonNode(ctx context.Context, cfg *config.Config) error {
+	client, err := newDrainClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	nodeName := cfg.CanonicalNodeName()
+	return wait.PollUntilContextCancel(ctx, 5*time.Second, true, func(ctx context.Context) (bool, error) {
+		if err := uncordonNode(ctx, client, nodeName); err != nil {
+			klog.Warningf("Failed to uncordon node %s: %v", nodeName, err)
+			return false, nil
+		}
+		return true, nil
+	})
+}
+
+// SetUnschedulable cordons or uncordons the node on request of the user,
+// so the node is not uncordoned on the next start.
+func SetUnschedulable(ctx context.Context, cfg *config.Config, unschedulable bool) error {
+	client, err := newDrainClient(cfg)
+	if err != nil {
+		return err
+	}
+	patch := map[string]any{
+		"metadata": map[string]any{"annotations": map[string]any{drainedAnnotation: nil}},
+		"spec":     map[string]any{"unschedulable": unschedulable},
+	}
+	return patchNode(ctx, client, cfg.CanonicalNodeName(), patch)
+}
+
+func newDrainClient(cfg *config.Config) (kubernetes.Interface, error) {
+	restConfig, err := clientcmd.BuildConfigFromFlags("", cfg.KubeConfigPath(config.KubeAdmin))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rest config: %w", err)
+	}
+	return kubernetes.NewForConfig(restConfig)
+}
+
+func cordonNode(ctx context.Context, client kubernetes.Interface, nodeName string) error {
+	node, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+	if node.Spec.Unschedulable {
+		klog.Infof("Node %s is already cordoned", nodeName)
+		return nil
+	}
+
+	patch := map[string]any{
+		"metadata": map[string]any{"annotations": map[string]any{drainedAnnotation: "true"}},
+		"spec":     map[string]any{"unschedulable": true},
+	}
+	return patchNode(ctx, client, nodeName, patch)
+}
+
+func uncordonNode(ctx context.Context, client kubernetes.Interface, nodeName string) error {
+	node, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+	if _, ok := node.Annotations[drainedAnnotation]; !ok {
+		return nil
+	}
+
+	klog.Infof("Uncordoning node %s drained on last shutdown", nodeName)
+	patch := map[string]any{
+		"metadata": map[string]any{"annotations": map[string]any{drainedAnnotation: nil}},
+		"spec":     map[string]any{"unschedulable": false},
+	}
+	return patchNode(ctx, client, nodeName, patch)
+}
+
+func patchNode(ctx context.Context, client kubernetes.Interface, nodeName string, patch map[string]any) error {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	if _, err := client.CoreV1().Nodes().Patch(ctx, nodeName, types.MergePatchType, data, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch node %s: %w", nodeName, err)
+	}
+	return nil
+}
+
+// podsToEvict returns the pods running on the node, except for the ones
+// that are not evicted when draining.
+func podsToEvict(ctx context.Context, client kubernetes.Interface, nodeName string) ([]corev1.Pod, error) {
+	podList, err := client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods of node %s: %w", nodeName, err)
+	}
+
+	pods := []corev1.Pod{}
+	for _, pod := range podList.Items {
+		if skipEviction(&pod) {
+			continue
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+func skipEviction(pod *corev1.Pod) bool {
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return true
+	}
+	if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+		return true
+	}
+	if owner := metav1.GetControllerOf(pod); owner != nil && owner.Kind == "DaemonSet" {
+		return true
+	}
+	return false
+}
+
+func evictPod(ctx context.Context, client kubernetes.Interface, pod *corev1.Pod) error {
+	eviction := &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+	}
+	err := client.PolicyV1().Evictions(pod.Namespace).Evict(ctx, eviction)
+	if apierrors.IsTooManyRequests(err) {
+		klog.Infof("Eviction of pod %s/%s refused, deleting it: %v", pod.Namespace, pod.Name, err)
+		err = client.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to evict pod %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+	return nil
+}
+
+func waitForPodsDeleted(ctx context.Context, client kubernetes.Interface, pods []corev1.Pod) error {
+	return wait.PollUntilContextCancel(ctx, drainPollInterval, true, func(ctx context.Context) (bool, error) {
+		for _, pod := range pods {
+			p, err := client.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) || (err == nil && p.UID != pod.UID) {
+				continue
+			}
+			if err != nil {
+				klog.Warningf("Failed to get pod %s/%s: %v", pod.Namespace, pod.Name, err)
+			}
+			return false, nil
+		}
+		return true, nil
+	})
+}
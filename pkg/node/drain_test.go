@@ -0,0 +1,90 @@
+package node
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+)
+
+func Test_skipEviction(t *testing.T) {
+	testData := []struct {
+		name string
+		pod  corev1.Pod
+		skip bool
+	}{
+		{
+			name: "Running pod",
+			pod:  corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		},
+		{
+			name: "Completed pod",
+			pod:  corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodSucceeded}},
+			skip: true,
+		},
+		{
+			name: "Static pod",
+			pod: corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{corev1.MirrorPodAnnotationKey: "hash"},
+			}},
+			skip: true,
+		},
+		{
+			name: "DaemonSet pod",
+			pod: corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				OwnerReferences: []metav1.OwnerReference{{Kind: "DaemonSet", Name: "ds", Controller: ptr.To(true)}},
+			}},
+			skip: true,
+		},
+		{
+			name: "ReplicaSet pod",
+			pod: corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "rs", Controller: ptr.To(true)}},
+			}},
+		},
+	}
+
+	for _, td := range testData {
+		t.Run(td.name, func(t *testing.T) {
+			assert.Equal(t, td.skip, skipEviction(&td.pod))
+		})
+	}
+}
+
+func Test_cordonAndUncordonNode(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Node cordoned on drain is uncordoned", func(t *testing.T) {
+		client := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}})
+
+		assert.NoError(t, cordonNode(ctx, client, "node"))
+		node, err := client.CoreV1().Nodes().Get(ctx, "node", metav1.GetOptions{})
+		assert.NoError(t, err)
+		assert.True(t, node.Spec.Unschedulable)
+		assert.Contains(t, node.Annotations, drainedAnnotation)
+
+		assert.NoError(t, uncordonNode(ctx, client, "node"))
+		node, err = client.CoreV1().Nodes().Get(ctx, "node", metav1.GetOptions{})
+		assert.NoError(t, err)
+		assert.False(t, node.Spec.Unschedulable)
+		assert.NotContains(t, node.Annotations, drainedAnnotation)
+	})
+
+	t.Run("Node cordoned by the user stays cordoned", func(t *testing.T) {
+		client := fake.NewSimpleClientset(&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node"},
+			Spec:       corev1.NodeSpec{Unschedulable: true},
+		})
+
+		assert.NoError(t, cordonNode(ctx, client, "node"))
+		assert.NoError(t, uncordonNode(ctx, client, "node"))
+		node, err := client.CoreV1().Nodes().Get(ctx, "node", metav1.GetOptions{})
+		assert.NoError(t, err)
+		assert.True(t, node.Spec.Unschedulable)
+	})
+}
@@ -19,6 +19,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -51,6 +52,12 @@ const (
 type KubeletServer struct {
 	kubeletflags *kubeletoptions.KubeletFlags
 	kubeconfig   *kubeletconfig.KubeletConfiguration
+	// worker is set on the nodes without the control plane, where the
+	// kubelet does not wait for a local kube-apiserver.
+	worker bool
+	// preload is set when images are preloaded, which the kubelet waits for
+	// so that the pods do not back off pulling them.
+	preload bool
 }
 
 func NewKubeletServer(cfg *config.Config) *KubeletServer {
@@ -59,8 +66,17 @@ func NewKubeletServer(cfg *config.Config) *KubeletServer {
 	return s
 }
 
-func (s *KubeletServer) Name() string           { return componentKubelet }
-func (s *KubeletServer) Dependencies() []string { return []string{"kube-apiserver"} }
+func (s *KubeletServer) Name() string { return componentKubelet }
+func (s *KubeletServer) Dependencies() []string {
+	deps := []string{"crio"}
+	if !s.worker {
+		deps = append(deps, "kube-apiserver")
+	}
+	if s.preload {
+		deps = append(deps, "image-preloader")
+	}
+	return deps
+}
 
 func (s *KubeletServer) configure(cfg *config.Config) {
 	if err := s.writeConfig(cfg); err != nil {
@@ -81,8 +97,12 @@ func (s *KubeletServer) configure(cfg *config.Config) {
 	kubeletFlags.RuntimeCgroups = "/system.slice/crio.service"
 	kubeletFlags.HostnameOverride = cfg.Node.HostnameOverride
 	kubeletFlags.NodeIP = nodeIP
-	kubeletFlags.NodeLabels["node-role.kubernetes.io/control-plane"] = ""
-	kubeletFlags.NodeLabels["node-role.kubernetes.io/master"] = ""
+	s.worker = cfg.MultiNode.Worker
+	s.preload = cfg.Images.Preload != ""
+	if !s.worker {
+		kubeletFlags.NodeLabels["node-role.kubernetes.io/control-plane"] = ""
+		kubeletFlags.NodeLabels["node-role.kubernetes.io/master"] = ""
+	}
 	kubeletFlags.NodeLabels["node-role.kubernetes.io/worker"] = ""
 	kubeletFlags.NodeLabels["node.openshift.io/os_id"] = osID
 
@@ -92,10 +112,41 @@ func (s *KubeletServer) configure(cfg *config.Config) {
 		klog.Fatalf("Failed to load Kubelet Configuration %v", err)
 	}
 
+	// The kubelet refuses to start when the policies of its resource managers
+	// differ from the ones recorded in their state.
+	for file, policy := range map[string]string{
+		"cpu_manager_state":    kubeletConfig.CPUManagerPolicy,
+		"memory_manager_state": kubeletConfig.MemoryManagerPolicy,
+	} {
+		if err := removeStaleManagerState(filepath.Join(kubeletFlags.RootDirectory, file), policy); err != nil {
+			klog.Warningf("Failed to remove stale kubelet state: %v", err)
+		}
+	}
+
 	s.kubeconfig = kubeletConfig
 	s.kubeletflags = kubeletFlags
 }
 
+// removeStaleManagerState removes the state file of a kubelet resource
+// manager if it was recorded with another policy than the configured one.
+func removeStaleManagerState(path, policy string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var state struct {
+		PolicyName string `json:"policyName"`
+	}
+	if err := json.Unmarshal(data, &state); err == nil && strings.EqualFold(state.PolicyName, policy) {
+		return nil
+	}
+	klog.Infof("Removing %s recorded with policy %q as the policy is now %q", path, state.PolicyName, policy)
+	return os.Remove(path)
+}
+
 func (s *KubeletServer) writeConfig(cfg *config.Config) error {
 	data, err := s.generateConfig(cfg)
 	if err != nil {
@@ -131,6 +182,43 @@ func (s *KubeletServer) generateConfig(cfg *config.Config) ([]byte, error) {
 		resolvConf = config.DefaultSystemdResolvedFile
 	}
 
+	evictionSettings, err := derivedConfig(evictionConfig(cfg.Node.Eviction), cfg.Kubelet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal kubelet eviction config: %w", err)
+	}
+	resourceManagersSettings, err := derivedConfig(resourceManagersConfig(cfg.Node), cfg.Kubelet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal kubelet resource managers config: %w", err)
+	}
+	reservation, err := reservationConfig(cfg.Node.ResourceReservation)
+	if err != nil {
+		return nil, err
+	}
+	reservationSettings, err := derivedConfig(reservation, cfg.Kubelet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal kubelet resource reservation config: %w", err)
+	}
+	shutdownSettings, err := derivedConfig(gracefulShutdownConfig(cfg.Node.GracefulShutdown), cfg.Kubelet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal kubelet graceful shutdown config: %w", err)
+	}
+	swapSettings, err := derivedConfig(map[string]any{
+		"memorySwap": map[string]any{"swapBehavior": string(cfg.Node.Swap.Behavior)},
+	}, cfg.Kubelet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal kubelet swap config: %w", err)
+	}
+
+	imageGCSettings, err := derivedConfig(imageGCConfig(cfg.Images.GarbageCollection), cfg.Kubelet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal kubelet image garbage collection config: %w", err)
+	}
+
+	staticPodPath := config.StaticPodsDir
+	if _, ok := cfg.Kubelet["staticPodPath"]; ok {
+		staticPodPath = ""
+	}
+
 	userProvidedConfig := ""
 	if cfg.Kubelet != nil {
 		b, err := yaml.Marshal(cfg.Kubelet)
@@ -147,6 +235,13 @@ func (s *KubeletServer) generateConfig(cfg *config.Config) ([]byte, error) {
 		"volumePluginDir":    config.DataDir + "/kubelet-plugins/volume/exec",
 		"clusterDNSIP":       cfg.Network.DNS,
 		"resolvConf":         resolvConf,
+		"staticPodPath":      staticPodPath,
+		"resourceManagers":   resourceManagersSettings,
+		"reservation":        reservationSettings,
+		"swapConfig":         swapSettings,
+		"gracefulShutdown":   shutdownSettings,
+		"evictionConfig":     evictionSettings,
+		"imageGCConfig":      imageGCSettings,
 		"userProvidedConfig": userProvidedConfig,
 	}
 
@@ -158,9 +253,97 @@ func (s *KubeletServer) generateConfig(cfg *config.Config) ([]byte, error) {
 	return data.Bytes(), nil
 }
 
+// derivedConfig marshals the kubelet settings derived from the MicroShift
+// config, except the ones passed as-is by the user, which take precedence.
+func derivedConfig(derived map[string]any, userProvided map[string]any) (string, error) {
+	for k := range userProvided {
+		delete(derived, k)
+	}
+	if len(derived) == 0 {
+		return "", nil
+	}
+	b, err := yaml.Marshal(derived)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func resourceManagersConfig(node config.Node) map[string]any {
+	c := map[string]any{
+		"topologyManagerPolicy": string(node.TopologyManager.Policy),
+		"topologyManagerScope":  string(node.TopologyManager.Scope),
+		"cpuManagerPolicy":      string(node.CPUManager.Policy),
+		"memoryManagerPolicy":   string(node.MemoryManager.Policy),
+	}
+	if len(node.CPUManager.PolicyOptions) != 0 {
+		c["cpuManagerPolicyOptions"] = node.CPUManager.PolicyOptions
+	}
+	if node.CPUManager.ReservedSystemCPUs != "" {
+		c["reservedSystemCPUs"] = node.CPUManager.ReservedSystemCPUs
+	}
+	if len(node.MemoryManager.ReservedMemory) != 0 {
+		reserved := []any{}
+		for _, r := range node.MemoryManager.ReservedMemory {
+			reserved = append(reserved, map[string]any{
+				"numaNode": r.NUMANode,
+				"limits":   map[string]any{"memory": r.Memory},
+			})
+		}
+		c["reservedMemory"] = reserved
+	}
+	return c
+}
+
+func gracefulShutdownConfig(shutdown config.NodeGracefulShutdown) map[string]any {
+	gracePeriod := *shutdown.GracePeriodSeconds
+	criticalPodsGracePeriod := *shutdown.CriticalPodsGracePeriodSeconds
+	if gracePeriod == 0 {
+		criticalPodsGracePeriod = 0
+	}
+	return map[string]any{
+		"shutdownGracePeriod":             fmt.Sprintf("%ds", gracePeriod),
+		"shutdownGracePeriodCriticalPods": fmt.Sprintf("%ds", criticalPodsGracePeriod),
+	}
+}
+
+func evictionConfig(eviction config.NodeEviction) map[string]any {
+	c := map[string]any{
+		"evictionPressureTransitionPeriod": fmt.Sprintf("%ds", *eviction.PressureTransitionPeriodSeconds),
+		"evictionMaxPodGracePeriod":        *eviction.MaxPodGracePeriodSeconds,
+	}
+	if len(eviction.Hard) != 0 {
+		c["evictionHard"] = eviction.Hard
+	}
+	if len(eviction.Soft) != 0 {
+		c["evictionSoft"] = eviction.Soft
+		c["evictionSoftGracePeriod"] = eviction.SoftGracePeriod
+	}
+	return c
+}
+
+// imageGCConfig returns the image garbage collection settings of the
+// kubelet. The protected images are pinned in CRI-O, which the kubelet
+// never removes.
+func imageGCConfig(gc config.ImagesGarbageCollection) map[string]any {
+	c := map[string]any{
+		"imageGCHighThresholdPercent": *gc.HighThresholdPercent,
+		"imageGCLowThresholdPercent":  *gc.LowThresholdPercent,
+		"imageMinimumGCAge":           fmt.Sprintf("%ds", *gc.MinimumAgeSeconds),
+	}
+	if *gc.MaximumAgeSeconds != 0 {
+		c["imageMaximumGCAge"] = fmt.Sprintf("%ds", *gc.MaximumAgeSeconds)
+	}
+	return c
+}
+
 func (s *KubeletServer) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
 	defer close(stopped)
 
+	if err := os.MkdirAll(config.StaticPodsDir, 0755); err != nil {
+		return fmt.Errorf("failed to create static pods dir: %w", err)
+	}
+
 	// construct a KubeletServer from kubeletFlags and kubeletConfig
 	kubeletServer := &kubeletoptions.KubeletServer{
 		KubeletFlags:         *s.kubeletflags,
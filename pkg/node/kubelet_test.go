@@ -1,10 +1,14 @@
 package node
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/openshift/microshift/pkg/config"
 	"github.com/stretchr/testify/assert"
+	"k8s.io/utils/ptr"
 )
 
 func Test_GenerateConfig(t *testing.T) {
@@ -48,3 +52,188 @@ reservedMemory:
 	assert.NoError(t, err)
 	assert.Contains(t, string(data), expectedConfigPart)
 }
+
+func Test_GenerateConfigEviction(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.Node.Eviction.Hard = map[string]string{"memory.available": "200Mi"}
+	cfg.Node.Eviction.Soft = map[string]string{"nodefs.available": "15%"}
+	cfg.Node.Eviction.SoftGracePeriod = map[string]string{"nodefs.available": "1m30s"}
+	cfg.Kubelet = map[string]any{
+		"evictionMaxPodGracePeriod": 30,
+	}
+
+	expectedConfigPart := `evictionHard:
+  memory.available: 200Mi
+evictionPressureTransitionPeriod: 300s
+evictionSoft:
+  nodefs.available: 15%
+evictionSoftGracePeriod:
+  nodefs.available: 1m30s
+evictionMaxPodGracePeriod: 30`
+
+	kubelet := &KubeletServer{}
+	data, err := kubelet.generateConfig(cfg)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), expectedConfigPart)
+	assert.Equal(t, 1, strings.Count(string(data), "evictionMaxPodGracePeriod"))
+}
+
+func Test_GenerateConfigImageGC(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.Images.GarbageCollection.MaximumAgeSeconds = ptr.To(604800)
+	cfg.Kubelet = map[string]any{
+		"imageGCHighThresholdPercent": 95,
+	}
+
+	expectedConfigPart := `imageGCLowThresholdPercent: 80
+imageMaximumGCAge: 604800s
+imageMinimumGCAge: 120s`
+
+	kubelet := &KubeletServer{}
+	data, err := kubelet.generateConfig(cfg)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), expectedConfigPart)
+	assert.Equal(t, 1, strings.Count(string(data), "imageGCHighThresholdPercent: 95"))
+}
+
+func Test_GenerateConfigStaticPods(t *testing.T) {
+	cfg := config.NewDefault()
+	kubelet := &KubeletServer{}
+	data, err := kubelet.generateConfig(cfg)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "staticPodPath: \"/etc/microshift/static-pods\"\n")
+
+	cfg.Kubelet = map[string]any{
+		"staticPodPath": "/opt/static-pods",
+	}
+	data, err = kubelet.generateConfig(cfg)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "staticPodPath: /opt/static-pods\n")
+	assert.Equal(t, 1, strings.Count(string(data), "staticPodPath"))
+}
+
+func Test_GenerateConfigTopologyManager(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.Node.TopologyManager.Policy = config.TopologyManagerPolicySingleNUMANode
+	cfg.Node.TopologyManager.Scope = config.TopologyManagerScopePod
+	kubelet := &KubeletServer{}
+	data, err := kubelet.generateConfig(cfg)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "topologyManagerPolicy: single-numa-node\ntopologyManagerScope: pod\n")
+
+	cfg.Kubelet = map[string]any{
+		"topologyManagerPolicy": "restricted",
+	}
+	data, err = kubelet.generateConfig(cfg)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "topologyManagerPolicy: restricted\n")
+	assert.Contains(t, string(data), "topologyManagerScope: pod\n")
+	assert.Equal(t, 1, strings.Count(string(data), "topologyManagerPolicy"))
+}
+
+func Test_GenerateConfigResourceManagers(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.Node.CPUManager.Policy = config.CPUManagerPolicyStatic
+	cfg.Node.CPUManager.PolicyOptions = map[string]string{"full-pcpus-only": "true"}
+	cfg.Node.CPUManager.ReservedSystemCPUs = "0-1"
+	cfg.Node.MemoryManager.Policy = config.MemoryManagerPolicyStatic
+	cfg.Node.MemoryManager.ReservedMemory = []config.NodeReservedMemory{{NUMANode: 0, Memory: "1100Mi"}}
+
+	expectedConfigPart := `cpuManagerPolicy: static
+cpuManagerPolicyOptions:
+  full-pcpus-only: "true"
+memoryManagerPolicy: Static
+reservedMemory:
+- limits:
+    memory: 1100Mi
+  numaNode: 0
+reservedSystemCPUs: 0-1
+topologyManagerPolicy: none
+topologyManagerScope: container
+`
+
+	kubelet := &KubeletServer{}
+	data, err := kubelet.generateConfig(cfg)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), expectedConfigPart)
+}
+
+func Test_removeStaleManagerState(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cpu_manager_state")
+
+	assert.NoError(t, removeStaleManagerState(path, "static"))
+
+	assert.NoError(t, os.WriteFile(path, []byte(`{"policyName":"static","defaultCpuSet":"0-3","checksum":1}`), 0600))
+	assert.NoError(t, removeStaleManagerState(path, "static"))
+	assert.FileExists(t, path)
+
+	assert.NoError(t, removeStaleManagerState(path, "none"))
+	assert.NoFileExists(t, path)
+
+	path = filepath.Join(dir, "memory_manager_state")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"policyName":"None","checksum":1}`), 0600))
+	assert.NoError(t, removeStaleManagerState(path, "None"))
+	assert.FileExists(t, path)
+}
+
+func Test_GenerateConfigSwap(t *testing.T) {
+	cfg := config.NewDefault()
+	kubelet := &KubeletServer{}
+	data, err := kubelet.generateConfig(cfg)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "failSwapOn: false\n")
+	assert.Contains(t, string(data), "memorySwap:\n  swapBehavior: NoSwap\n")
+
+	cfg.Node.Swap.Behavior = config.SwapBehaviorLimitedSwap
+	data, err = kubelet.generateConfig(cfg)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "memorySwap:\n  swapBehavior: LimitedSwap\n")
+
+	cfg.Kubelet = map[string]any{
+		"memorySwap": map[string]any{"swapBehavior": "NoSwap"},
+	}
+	data, err = kubelet.generateConfig(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "memorySwap"))
+	assert.Contains(t, string(data), "swapBehavior: NoSwap\n")
+}
+
+func Test_GenerateConfigGracefulShutdown(t *testing.T) {
+	cfg := config.NewDefault()
+	kubelet := &KubeletServer{}
+	data, err := kubelet.generateConfig(cfg)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "shutdownGracePeriod: 30s\nshutdownGracePeriodCriticalPods: 10s\n")
+
+	cfg.Node.GracefulShutdown.GracePeriodSeconds = ptr.To(0)
+	data, err = kubelet.generateConfig(cfg)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "shutdownGracePeriod: 0s\nshutdownGracePeriodCriticalPods: 0s\n")
+}
+
+func Test_GenerateConfigResourceReservation(t *testing.T) {
+	cfg := config.NewDefault()
+	kubelet := &KubeletServer{}
+	data, err := kubelet.generateConfig(cfg)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "systemReserved")
+	assert.NotContains(t, string(data), "{}")
+
+	cfg.Node.ResourceReservation.AutoSizing = config.AutoSizingEnabled
+	cfg.Node.ResourceReservation.SystemReserved = map[string]string{"memory": "1Gi", "ephemeral-storage": "1Gi"}
+	cfg.Node.ResourceReservation.KubeReserved = map[string]string{"cpu": "200m"}
+	data, err = kubelet.generateConfig(cfg)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "kubeReserved:\n  cpu: 200m\nsystemReserved:\n  cpu: ")
+	assert.Contains(t, string(data), "  ephemeral-storage: 1Gi\n  memory: 1Gi\n")
+
+	cfg.Kubelet = map[string]any{
+		"systemReserved": map[string]any{"memory": "2Gi"},
+		"kubeReserved":   map[string]any{"memory": "1Gi"},
+	}
+	data, err = kubelet.generateConfig(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "systemReserved"))
+	assert.NotContains(t, string(data), "{}")
+}
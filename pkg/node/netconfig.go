@@ -38,6 +38,13 @@ const (
 type NetworkConfiguration struct {
 	kasAdvertiseAddresses      []string
 	skipInterfaceConfiguration bool
+	// nodePortAddresses restrict the NodePort services to these addresses,
+	// when set.
+	nodePortAddresses []string
+	nodePortRange     string
+	clusterNetwork    []string
+	// nodePortRules is the last applied script.
+	nodePortRules []byte
 }
 
 func NewNetworkConfiguration(cfg *config.Config) *NetworkConfiguration {
@@ -52,6 +59,9 @@ func (n *NetworkConfiguration) Dependencies() []string { return []string{} }
 func (n *NetworkConfiguration) configure(cfg *config.Config) {
 	n.kasAdvertiseAddresses = cfg.ApiServer.AdvertiseAddresses
 	n.skipInterfaceConfiguration = cfg.ApiServer.SkipInterface
+	n.nodePortAddresses = cfg.Network.NodePortAddresses
+	n.nodePortRange = cfg.Network.ServiceNodePortRange
+	n.clusterNetwork = cfg.Network.ClusterNetwork
 }
 
 func (n *NetworkConfiguration) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
@@ -71,6 +81,13 @@ func (n *NetworkConfiguration) Run(ctx context.Context, ready chan<- struct{}, s
 			close(stopChan)
 		}()
 	}
+	if len(n.nodePortAddresses) != 0 {
+		if err := n.restrictNodePorts(ctx); err != nil {
+			return err
+		}
+	} else {
+		deleteNodePortRules(ctx)
+	}
 	klog.Infof("%q is ready", n.Name())
 	close(ready)
 	<-stopChan
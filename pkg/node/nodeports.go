@@ -0,0 +1,132 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/microshift/pkg/util"
+)
+
+const nodePortTable = "inet microshift-nodeport"
+
+// nftCommand is the nft binary, overridden in tests.
+var nftCommand = "nft"
+
+// nodePortReset declares the table before deleting it, for the script to
+// apply whether it exists or not.
+const nodePortReset = "table " + nodePortTable + "\ndelete table " + nodePortTable + "\n"
+
+// renderNodePortRules renders the script replacing the microshift-nodeport
+// table, whose prerouting chain drops the traffic to the NodePort range of
+// the addresses of the host but the exposed ones, before kube-proxy or
+// OVN-Kubernetes translate it to the services. The traffic of the pods is
+// left to the network policies.
+func renderNodePortRules(exposed []*net.IPNet, portRange string, clusterNetwork []string) []byte {
+	b := &bytes.Buffer{}
+	b.WriteString(nodePortReset)
+	fmt.Fprintf(b, "table %s {\n", nodePortTable)
+	b.WriteString("\tchain prerouting {\n")
+	b.WriteString("\t\ttype filter hook prerouting priority dstnat - 10; policy accept;\n")
+	for _, family := range []struct {
+		name, nfproto string
+		ipv4          bool
+	}{{"ip", "ipv4", true}, {"ip6", "ipv6", false}} {
+		var sources, destinations []string
+		for _, network := range clusterNetwork {
+			if ip, _, err := net.ParseCIDR(network); err == nil && (ip.To4() != nil) == family.ipv4 {
+				sources = append(sources, network)
+			}
+		}
+		for _, network := range exposed {
+			if (network.IP.To4() != nil) == family.ipv4 {
+				destinations = append(destinations, network.String())
+			}
+		}
+		slices.Sort(destinations)
+		rule := "fib daddr type local meta nfproto " + family.nfproto
+		if len(sources) != 0 {
+			rule += fmt.Sprintf(" %s saddr != { %s }", family.name, strings.Join(sources, ", "))
+		}
+		if len(destinations) != 0 {
+			rule += fmt.Sprintf(" %s daddr != { %s }", family.name, strings.Join(slices.Compact(destinations), ", "))
+		}
+		fmt.Fprintf(b, "\t\t%s meta l4proto { tcp, udp, sctp } th dport %s drop\n", rule, portRange)
+	}
+	b.WriteString("\t}\n}\n")
+	return b.Bytes()
+}
+
+// applyNftables applies the nft script atomically.
+func applyNftables(ctx context.Context, script []byte) error {
+	cmd := exec.CommandContext(ctx, nftCommand, "-f", "-")
+	cmd.Stdin = bytes.NewReader(script)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to apply the nftables table %s: %w: %s", nodePortTable, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// deleteNodePortRules deletes the table left by a previous configuration,
+// on the hosts with nft.
+func deleteNodePortRules(ctx context.Context) {
+	if _, err := exec.LookPath(nftCommand); err != nil {
+		return
+	}
+	if err := applyNftables(ctx, []byte(nodePortReset)); err != nil {
+		klog.Warningf("Failed to delete the nftables table %s: %v", nodePortTable, err)
+	}
+}
+
+// restrictNodePorts applies the NodePort rules, and again when the
+// addresses of the host change for those of the exposed interfaces to be
+// followed, until ctx is done.
+func (n *NetworkConfiguration) restrictNodePorts(ctx context.Context) error {
+	apply := func() error {
+		exposed, err := util.ResolveAddresses(n.nodePortAddresses)
+		if err != nil {
+			return fmt.Errorf("failed to resolve network.nodePortAddresses: %w", err)
+		}
+		script := renderNodePortRules(exposed, n.nodePortRange, n.clusterNetwork)
+		if bytes.Equal(script, n.nodePortRules) {
+			return nil
+		}
+		if err := applyNftables(ctx, script); err != nil {
+			return err
+		}
+		klog.Infof("Exposing the NodePort services on %v", exposed)
+		n.nodePortRules = script
+		return nil
+	}
+	if err := apply(); err != nil {
+		return err
+	}
+
+	updates := make(chan netlink.AddrUpdate)
+	done := make(chan struct{})
+	if err := netlink.AddrSubscribe(updates, done); err != nil {
+		klog.Warningf("Not following the addresses of network.nodePortAddresses: %v", err)
+		return nil
+	}
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-updates:
+				if err := apply(); err != nil {
+					klog.Errorf("Failed to update the NodePort rules: %v", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
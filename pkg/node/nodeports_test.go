@@ -0,0 +1,24 @@
+package node
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderNodePortRules(t *testing.T) {
+	_, ot, _ := net.ParseCIDR("10.10.0.0/24")
+	exposed := []*net.IPNet{ot, {IP: net.ParseIP("192.168.10.5"), Mask: net.CIDRMask(32, 32)}}
+	got := renderNodePortRules(exposed, "30000-32767", []string{"10.42.0.0/16"})
+	assert.Equal(t, `table inet microshift-nodeport
+delete table inet microshift-nodeport
+table inet microshift-nodeport {
+	chain prerouting {
+		type filter hook prerouting priority dstnat - 10; policy accept;
+		fib daddr type local meta nfproto ipv4 ip saddr != { 10.42.0.0/16 } ip daddr != { 10.10.0.0/24, 192.168.10.5/32 } meta l4proto { tcp, udp, sctp } th dport 30000-32767 drop
+		fib daddr type local meta nfproto ipv6 meta l4proto { tcp, udp, sctp } th dport 30000-32767 drop
+	}
+}
+`, string(got))
+}
@@ -0,0 +1,64 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// RemovePreviousNodes deletes the Node objects registered under the names
+// the node had before host name changes, so they do not linger NotReady.
+// The pods bound to them are then garbage collected. It retries until it
+// succeeds or ctx is done.
+func RemovePreviousNodes(ctx context.Context, cfg *config.Config) error {
+	previousNames, err := config.PreviousNodeNames(config.DataDir)
+	if err != nil || len(previousNames) == 0 {
+		return err
+	}
+
+	client, err := newDrainClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	nodeName := cfg.CanonicalNodeName()
+	err = wait.PollUntilContextCancel(ctx, 5*time.Second, true, func(ctx context.Context) (bool, error) {
+		if err := removeNodes(ctx, client, previousNames, nodeName); err != nil {
+			klog.Warningf("Failed to remove previous nodes: %v", err)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(config.DataDir, config.PreviousNodeNamesFile))
+}
+
+func removeNodes(ctx context.Context, client kubernetes.Interface, names []string, nodeName string) error {
+	for _, name := range names {
+		// The host may have been renamed back to a previous name.
+		if name == nodeName {
+			continue
+		}
+		err := client.CoreV1().Nodes().Delete(ctx, name, metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete node %s: %w", name, err)
+		}
+		klog.Infof("Deleted node %s registered before the host name changed", name)
+	}
+	return nil
+}
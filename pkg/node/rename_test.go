@@ -0,0 +1,26 @@
+package node
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_removeNodes(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "old"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "new"}},
+	)
+
+	assert.NoError(t, removeNodes(context.TODO(), client, []string{"old", "gone", "new"}, "new"))
+
+	_, err := client.CoreV1().Nodes().Get(context.TODO(), "old", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+	_, err = client.CoreV1().Nodes().Get(context.TODO(), "new", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
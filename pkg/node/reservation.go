@@ -0,0 +1,84 @@
+package node
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/openshift/microshift/pkg/config"
+)
+
+type reservationTier struct {
+	// size of the tier, the last one is unbounded.
+	size uint64
+	// share of the tier reserved, in basis points.
+	basisPoints uint64
+}
+
+var (
+	// memoryReservationTiers reserve 25% of the first 4Gi of memory, 20% of
+	// the next 4Gi, 10% of the next 8Gi, 6% of the next 112Gi and 2% of the
+	// rest, like the autoSizingReserved setting of OpenShift.
+	memoryReservationTiers = []reservationTier{
+		{size: 4 << 30, basisPoints: 2500},
+		{size: 4 << 30, basisPoints: 2000},
+		{size: 8 << 30, basisPoints: 1000},
+		{size: 112 << 30, basisPoints: 600},
+		{size: math.MaxUint64, basisPoints: 200},
+	}
+	// cpuReservationTiers reserve 6% of the first CPU, 1% of the second,
+	// 0.5% of the next two and 0.25% of the rest, in millicores.
+	cpuReservationTiers = []reservationTier{
+		{size: 1000, basisPoints: 600},
+		{size: 1000, basisPoints: 100},
+		{size: 2000, basisPoints: 50},
+		{size: math.MaxUint64, basisPoints: 25},
+	}
+)
+
+func reserve(total uint64, tiers []reservationTier) uint64 {
+	var reserved uint64
+	for _, tier := range tiers {
+		n := min(total, tier.size)
+		reserved += n * tier.basisPoints / 10000
+		total -= n
+	}
+	return reserved
+}
+
+// autoSizedReservation computes the memory and CPU reserved for the system
+// on a host with memoryBytes of memory and cpus CPUs.
+func autoSizedReservation(memoryBytes uint64, cpus int) map[string]string {
+	return map[string]string{
+		"memory": fmt.Sprintf("%dMi", reserve(memoryBytes, memoryReservationTiers)>>20),
+		"cpu":    fmt.Sprintf("%dm", reserve(uint64(cpus)*1000, cpuReservationTiers)),
+	}
+}
+
+// reservationConfig returns the systemReserved and kubeReserved settings of
+// the kubelet, auto-sizing the system reservation from the memory and CPUs
+// of the host when enabled.
+func reservationConfig(r config.NodeResourceReservation) (map[string]any, error) {
+	systemReserved := map[string]string{}
+	if r.AutoSizing == config.AutoSizingEnabled {
+		var info unix.Sysinfo_t
+		if err := unix.Sysinfo(&info); err != nil {
+			return nil, fmt.Errorf("failed to detect host memory: %w", err)
+		}
+		systemReserved = autoSizedReservation(uint64(info.Totalram)*uint64(info.Unit), runtime.NumCPU())
+	}
+	for name, quantity := range r.SystemReserved {
+		systemReserved[name] = quantity
+	}
+
+	c := map[string]any{}
+	if len(systemReserved) != 0 {
+		c["systemReserved"] = systemReserved
+	}
+	if len(r.KubeReserved) != 0 {
+		c["kubeReserved"] = r.KubeReserved
+	}
+	return c, nil
+}
@@ -0,0 +1,23 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_autoSizedReservation(t *testing.T) {
+	tests := []struct {
+		memory   uint64
+		cpus     int
+		expected map[string]string
+	}{
+		{memory: 2 << 30, cpus: 1, expected: map[string]string{"memory": "512Mi", "cpu": "60m"}},
+		{memory: 8 << 30, cpus: 4, expected: map[string]string{"memory": "1843Mi", "cpu": "80m"}},
+		{memory: 32 << 30, cpus: 8, expected: map[string]string{"memory": "3645Mi", "cpu": "90m"}},
+		{memory: 256 << 30, cpus: 64, expected: map[string]string{"memory": "12165Mi", "cpu": "230m"}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, autoSizedReservation(tt.memory, tt.cpus))
+	}
+}
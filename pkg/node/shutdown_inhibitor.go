@@ -0,0 +1,93 @@
+package node
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+
+	"github.com/godbus/dbus/v5"
+	"k8s.io/klog/v2"
+)
+
+const (
+	logindService   = "org.freedesktop.login1"
+	logindObject    = dbus.ObjectPath("/org/freedesktop/login1")
+	logindInterface = "org.freedesktop.login1.Manager"
+)
+
+// ShutdownInhibitor holds a systemd-logind delay inhibitor lock, so the
+// host waits for MicroShift to stop its workloads before shutting down.
+type ShutdownInhibitor struct {
+	conn     *dbus.Conn
+	lock     int
+	shutdown chan struct{}
+	release  sync.Once
+}
+
+// InhibitShutdown takes a delay inhibitor lock on host shutdowns and
+// watches for logind announcing one.
+func InhibitShutdown() (*ShutdownInhibitor, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the system bus: %w", err)
+	}
+
+	// Watch before taking the lock, so a shutdown requested in between is
+	// not missed.
+	if err := conn.AddMatchSignal(
+		dbus.WithMatchInterface(logindInterface),
+		dbus.WithMatchMember("PrepareForShutdown"),
+		dbus.WithMatchObjectPath(logindObject),
+	); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to watch for host shutdowns: %w", err)
+	}
+	signals := make(chan *dbus.Signal, 1)
+	conn.Signal(signals)
+
+	var lock uint32
+	call := conn.Object(logindService, logindObject).Call(logindInterface+".Inhibit", 0,
+		"shutdown", "microshift", "MicroShift needs time to stop the workloads of the node", "delay")
+	if err := call.Store(&lock); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to take systemd inhibitor lock: %w", err)
+	}
+
+	i := &ShutdownInhibitor{
+		conn:     conn,
+		lock:     int(lock),
+		shutdown: make(chan struct{}),
+	}
+	go watchShutdown(signals, i.shutdown)
+	return i, nil
+}
+
+// ShutdownRequested is closed once the host starts shutting down.
+func (i *ShutdownInhibitor) ShutdownRequested() <-chan struct{} {
+	return i.shutdown
+}
+
+// Release releases the inhibitor lock, letting a pending shutdown proceed.
+// It is safe to call more than once.
+func (i *ShutdownInhibitor) Release() {
+	i.release.Do(func() {
+		if err := syscall.Close(i.lock); err != nil {
+			klog.Warningf("Failed to release systemd inhibitor lock: %v", err)
+		}
+		i.conn.Close()
+	})
+}
+
+// watchShutdown closes shutdown on the first PrepareForShutdown signal
+// announcing a shutdown, or returns when signals is closed.
+func watchShutdown(signals <-chan *dbus.Signal, shutdown chan<- struct{}) {
+	for signal := range signals {
+		if signal == nil || len(signal.Body) == 0 {
+			continue
+		}
+		if active, ok := signal.Body[0].(bool); ok && active {
+			close(shutdown)
+			return
+		}
+	}
+}
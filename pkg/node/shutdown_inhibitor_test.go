@@ -0,0 +1,44 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func Test_watchShutdown(t *testing.T) {
+	signals := make(chan *dbus.Signal, 3)
+	shutdown := make(chan struct{})
+	go watchShutdown(signals, shutdown)
+
+	signals <- &dbus.Signal{}
+	signals <- &dbus.Signal{Body: []any{false}}
+	select {
+	case <-shutdown:
+		t.Fatal("shutdown reported without PrepareForShutdown(true)")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	signals <- &dbus.Signal{Body: []any{true}}
+	select {
+	case <-shutdown:
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown not reported")
+	}
+}
+
+func Test_watchShutdownClosed(t *testing.T) {
+	signals := make(chan *dbus.Signal)
+	done := make(chan struct{})
+	go func() {
+		watchShutdown(signals, make(chan struct{}))
+		close(done)
+	}()
+	close(signals)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchShutdown did not return")
+	}
+}
@@ -0,0 +1,286 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	"k8s.io/klog/v2"
+)
+
+const (
+	ociRefNameAnnotation = "org.opencontainers.image.ref.name"
+	ociManifestType      = "application/vnd.oci.image.manifest.v1+json"
+)
+
+var (
+	// repositoryName and digestRegexp are the names of repositories and
+	// the digests of the distribution spec, which keep the requests in the
+	// registry directory.
+	repositoryName = regexp.MustCompile(`^[a-z0-9]+(?:(?:\.|_|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:\.|_|__|-+)[a-z0-9]+)*)*$`)
+	digestRegexp   = regexp.MustCompile(`^(sha256:[a-f0-9]{64}|sha512:[a-f0-9]{128})$`)
+)
+
+// Dir is where the OCI layouts served by the local registry are sideloaded,
+// one per repository.
+func Dir() string {
+	return filepath.Join(config.DataDir, "registry")
+}
+
+// Server is a read-only registry serving the OCI layouts of a directory with
+// the pull endpoints of the OCI distribution spec. The OCI layout of the
+// <name> repository is <dir>/<name>, with the tags of the images in their
+// org.opencontainers.image.ref.name annotation.
+type Server struct {
+	dir  string
+	port int
+}
+
+func NewServer(cfg *config.Config) *Server {
+	return &Server{dir: Dir(), port: cfg.Images.LocalRegistry.Port}
+}
+
+func (s *Server) Name() string           { return "local-registry" }
+func (s *Server) Dependencies() []string { return []string{} }
+
+func (s *Server) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	defer close(stopped)
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", s.dir, err)
+	}
+	// The registry is served over HTTP, only to the host.
+	listener, err := net.Listen("tcp", net.JoinHostPort("localhost", strconv.Itoa(s.port)))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", s.port, err)
+	}
+	srv := &http.Server{
+		Handler:           s.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	klog.Infof("%s serving %s on port %d", s.Name(), s.dir, s.port)
+	close(ready)
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return ctx.Err()
+}
+
+type registryError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string][]registryError{"errors": {{Code: code, Message: message}}})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		klog.ErrorS(err, "Failed to write local registry response")
+	}
+}
+
+func (s *Server) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, http.StatusMethodNotAllowed, "UNSUPPORTED", "the local registry is read-only")
+			return
+		}
+		path := r.URL.Path
+		switch {
+		case path == "/v2/":
+			writeJSON(w, struct{}{})
+		case path == "/v2/_catalog":
+			s.catalog(w)
+		case strings.HasPrefix(path, "/v2/"):
+			s.repository(w, r, strings.TrimPrefix(path, "/v2/"))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// repository serves the tags, manifests and blobs of the repository of path,
+// which is <name>/tags/list, <name>/manifests/<reference> or
+// <name>/blobs/<digest>.
+func (s *Server) repository(w http.ResponseWriter, r *http.Request, path string) {
+	name, ref, kind := "", "", ""
+	if n, found := strings.CutSuffix(path, "/tags/list"); found {
+		name, kind = n, "tags"
+	} else if i := strings.LastIndex(path, "/manifests/"); i >= 0 {
+		name, ref, kind = path[:i], path[i+len("/manifests/"):], "manifests"
+	} else if i := strings.LastIndex(path, "/blobs/"); i >= 0 {
+		name, ref, kind = path[:i], path[i+len("/blobs/"):], "blobs"
+	}
+	if !repositoryName.MatchString(name) {
+		writeError(w, http.StatusNotFound, "NAME_UNKNOWN", "unknown repository")
+		return
+	}
+	index, err := s.readIndex(name)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "NAME_UNKNOWN", fmt.Sprintf("unknown repository %q", name))
+		return
+	}
+
+	switch kind {
+	case "tags":
+		tags := []string{}
+		for _, m := range index.Manifests {
+			if tag := tagOf(m); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		slices.Sort(tags)
+		writeJSON(w, map[string]any{"name": name, "tags": slices.Compact(tags)})
+	case "manifests":
+		mediaType := ""
+		if !digestRegexp.MatchString(ref) {
+			found := false
+			for _, m := range index.Manifests {
+				if tagOf(m) == ref {
+					ref, mediaType, found = m.Digest, m.MediaType, true
+					break
+				}
+			}
+			if !found {
+				writeError(w, http.StatusNotFound, "MANIFEST_UNKNOWN", fmt.Sprintf("unknown tag %q", ref))
+				return
+			}
+		}
+		s.serveBlob(w, r, name, ref, mediaType, "MANIFEST_UNKNOWN")
+	case "blobs":
+		if !digestRegexp.MatchString(ref) {
+			writeError(w, http.StatusBadRequest, "DIGEST_INVALID", fmt.Sprintf("invalid digest %q", ref))
+			return
+		}
+		s.serveBlob(w, r, name, ref, "application/octet-stream", "BLOB_UNKNOWN")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveBlob serves the blob of digest in the layout of the repository. The
+// media type of manifests is read from the manifest when not known.
+func (s *Server) serveBlob(w http.ResponseWriter, r *http.Request, name, digest, mediaType, unknownCode string) {
+	algorithm, hex, _ := strings.Cut(digest, ":")
+	f, err := os.Open(filepath.Join(s.dir, name, "blobs", algorithm, hex))
+	if err != nil {
+		writeError(w, http.StatusNotFound, unknownCode, fmt.Sprintf("unknown digest %q", digest))
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "UNKNOWN", err.Error())
+		return
+	}
+	if mediaType == "" {
+		mediaType = manifestMediaType(f)
+		if _, err := f.Seek(0, 0); err != nil {
+			writeError(w, http.StatusInternalServerError, "UNKNOWN", err.Error())
+			return
+		}
+	}
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Docker-Content-Digest", digest)
+	w.Header().Set("Etag", `"`+digest+`"`)
+	http.ServeContent(w, r, "", info.ModTime(), f)
+}
+
+// manifestMediaType returns the mediaType of a manifest, which defaults to
+// the one of OCI image manifests.
+func manifestMediaType(f *os.File) string {
+	manifest := struct {
+		MediaType string `json:"mediaType"`
+	}{}
+	if err := json.NewDecoder(f).Decode(&manifest); err != nil || manifest.MediaType == "" {
+		return ociManifestType
+	}
+	return manifest.MediaType
+}
+
+// catalog lists the repositories, which are the directories holding an OCI
+// layout.
+func (s *Server) catalog(w http.ResponseWriter) {
+	repositories := []string{}
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if d.Name() == "blobs" {
+			return filepath.SkipDir
+		}
+		name, err := filepath.Rel(s.dir, path)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(filepath.Join(path, "index.json")); err == nil && repositoryName.MatchString(filepath.ToSlash(name)) {
+			repositories = append(repositories, filepath.ToSlash(name))
+		}
+		return nil
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "UNKNOWN", err.Error())
+		return
+	}
+	writeJSON(w, map[string][]string{"repositories": repositories})
+}
+
+type ociIndex struct {
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+func (s *Server) readIndex(name string) (*ociIndex, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, name, "index.json"))
+	if err != nil {
+		return nil, err
+	}
+	index := &ociIndex{}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// tagOf returns the tag of an image of an OCI layout. The annotation may
+// hold a full reference, as written by skopeo copy to oci:<dir>:<image>.
+func tagOf(m ociDescriptor) string {
+	ref := m.Annotations[ociRefNameAnnotation]
+	if i := strings.LastIndexAny(ref, ":/"); i >= 0 {
+		if ref[i] == '/' {
+			return ""
+		}
+		ref = ref[i+1:]
+	}
+	return ref
+}
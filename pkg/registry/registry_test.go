@@ -0,0 +1,71 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeBlob(t *testing.T, layout, content string) string {
+	sum := sha256.Sum256([]byte(content))
+	digest := hex.EncodeToString(sum[:])
+	require.NoError(t, os.MkdirAll(filepath.Join(layout, "blobs", "sha256"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(layout, "blobs", "sha256", digest), []byte(content), 0644))
+	return "sha256:" + digest
+}
+
+func TestHandler(t *testing.T) {
+	dir := t.TempDir()
+	layout := filepath.Join(dir, "example", "app")
+	layer := writeBlob(t, layout, "layer")
+	manifest := `{"schemaVersion": 2, "mediaType": "application/vnd.oci.image.manifest.v1+json", "layers": [{"digest": "` + layer + `"}]}`
+	manifestDigest := writeBlob(t, layout, manifest)
+	index := `{"manifests": [
+		{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "` + manifestDigest + `", "annotations": {"org.opencontainers.image.ref.name": "1.0"}},
+		{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "` + manifestDigest + `", "annotations": {"org.opencontainers.image.ref.name": "quay.io/example/app:latest"}}
+	]}`
+	require.NoError(t, os.WriteFile(filepath.Join(layout, "index.json"), []byte(index), 0644))
+
+	handler := (&Server{dir: dir}).handler()
+	do := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/v2/").Code)
+
+	rec := do(http.MethodGet, "/v2/_catalog")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"repositories": ["example/app"]}`, rec.Body.String())
+
+	rec = do(http.MethodGet, "/v2/example/app/tags/list")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"name": "example/app", "tags": ["1.0", "latest"]}`, rec.Body.String())
+
+	for _, ref := range []string{"1.0", manifestDigest} {
+		rec = do(http.MethodGet, "/v2/example/app/manifests/"+ref)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, manifest, rec.Body.String())
+		assert.Equal(t, "application/vnd.oci.image.manifest.v1+json", rec.Header().Get("Content-Type"))
+		assert.Equal(t, manifestDigest, rec.Header().Get("Docker-Content-Digest"))
+	}
+
+	rec = do(http.MethodHead, "/v2/example/app/blobs/"+layer)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "5", rec.Header().Get("Content-Length"))
+	assert.Equal(t, "layer", do(http.MethodGet, "/v2/example/app/blobs/"+layer).Body.String())
+
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/v2/example/app/manifests/2.0").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/v2/example/other/tags/list").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/v2/../example/app/tags/list").Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/v2/example/app/blobs/sha256:..").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, do(http.MethodPut, "/v2/example/app/manifests/1.0").Code)
+}
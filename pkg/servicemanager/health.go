@@ -0,0 +1,189 @@
+package servicemanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// HealthEndpointAddress is where the health of the services is served.
+	// It only listens on localhost: the endpoint is not authenticated.
+	HealthEndpointAddress = "localhost:10261"
+
+	healthCheckInterval = 10 * time.Second
+	healthCheckTimeout  = 5 * time.Second
+)
+
+var (
+	serviceHealthy = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Name:           "microshift_service_healthy",
+			Help:           "Whether the last health check of a MicroShift service succeeded (1) or failed (0).",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"service"},
+	)
+	serviceHealthCheckFailures = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Name:           "microshift_service_health_check_failures_total",
+			Help:           "Number of failed health checks of a MicroShift service.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"service"},
+	)
+)
+
+func init() {
+	// The legacy registry is served by the embedded kube-apiserver on /metrics.
+	legacyregistry.MustRegister(serviceHealthy, serviceHealthCheckFailures)
+}
+
+// ServiceHealth is the result of the last health check of a service.
+type ServiceHealth struct {
+	Service   string    `json:"service"`
+	Healthy   bool      `json:"healthy"`
+	Error     string    `json:"error,omitempty"`
+	LastCheck time.Time `json:"lastCheck"`
+}
+
+// pollHealth periodically checks the health of the service, once it is
+// ready, until ctx is done or the service stops.
+func (m *ServiceManager) pollHealth(ctx context.Context, service Service, ready, stopped <-chan struct{}) {
+	checker, ok := service.(HealthChecker)
+	if !ok {
+		return
+	}
+
+	select {
+	case <-ready:
+	case <-stopped:
+		return
+	case <-ctx.Done():
+		return
+	}
+
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		m.checkHealth(ctx, service.Name(), checker)
+
+		select {
+		case <-ticker.C:
+		case <-stopped:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *ServiceManager) checkHealth(ctx context.Context, name string, checker HealthChecker) {
+	checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	err := checker.HealthCheck(checkCtx)
+	if ctx.Err() != nil {
+		// Shutting down, the result is meaningless.
+		return
+	}
+
+	h := ServiceHealth{Service: name, Healthy: err == nil, LastCheck: time.Now()}
+	if err != nil {
+		h.Error = err.Error()
+		serviceHealthy.WithLabelValues(name).Set(0)
+		serviceHealthCheckFailures.WithLabelValues(name).Inc()
+	} else {
+		serviceHealthy.WithLabelValues(name).Set(1)
+	}
+
+	m.healthMu.Lock()
+	previous, known := m.health[name]
+	m.health[name] = h
+	m.healthMu.Unlock()
+
+	switch {
+	case err != nil && (!known || previous.Healthy):
+		klog.ErrorS(err, "SERVICE UNHEALTHY", "service", name)
+	case err == nil && known && !previous.Healthy:
+		klog.InfoS("SERVICE HEALTHY", "service", name)
+	}
+}
+
+// Health returns the result of the last health check of every service
+// implementing HealthChecker that was checked at least once.
+func (m *ServiceManager) Health() []ServiceHealth {
+	m.healthMu.RLock()
+	defer m.healthMu.RUnlock()
+
+	result := make([]ServiceHealth, 0, len(m.health))
+	for _, h := range m.health {
+		result = append(result, h)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Service < result[j].Service })
+	return result
+}
+
+// ServeHTTP serves the health of all of the services on /health, or of a
+// single service on /health/<name>, with status 503 if any is unhealthy.
+func (m *ServiceManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	health := m.Health()
+	if name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/health"), "/"); name != "" {
+		filtered := []ServiceHealth{}
+		for _, h := range health {
+			if h.Service == name {
+				filtered = append(filtered, h)
+			}
+		}
+		if len(filtered) == 0 {
+			http.Error(w, fmt.Sprintf("no health information for service %q", name), http.StatusNotFound)
+			return
+		}
+		health = filtered
+	}
+
+	status := http.StatusOK
+	for _, h := range health {
+		if !h.Healthy {
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(health); err != nil {
+		klog.Warningf("Failed to write health response: %v", err)
+	}
+}
+
+// ServeHealth serves the health of the services on addr until ctx is done.
+func (m *ServiceManager) ServeHealth(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/health", m)
+	mux.Handle("/health/", m)
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+
+	klog.Infof("Serving services health on http://%s/health", addr)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
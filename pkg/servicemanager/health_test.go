@@ -0,0 +1,66 @@
+package servicemanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type healthTestService struct {
+	*GenericService
+	err error
+}
+
+func (s *healthTestService) HealthCheck(ctx context.Context) error { return s.err }
+
+func TestServiceHealth(t *testing.T) {
+	m := NewServiceManager()
+	healthy := &healthTestService{GenericService: NewGenericService("healthy", nil, nil)}
+	unhealthy := &healthTestService{GenericService: NewGenericService("unhealthy", nil, nil), err: errors.New("wedged")}
+
+	ctx := context.Background()
+	m.checkHealth(ctx, healthy.Name(), healthy)
+	m.checkHealth(ctx, unhealthy.Name(), unhealthy)
+
+	health := m.Health()
+	assert.Len(t, health, 2)
+	assert.Equal(t, "healthy", health[0].Service)
+	assert.True(t, health[0].Healthy)
+	assert.Equal(t, "unhealthy", health[1].Service)
+	assert.False(t, health[1].Healthy)
+	assert.Equal(t, "wedged", health[1].Error)
+
+	testData := []struct {
+		path     string
+		status   int
+		services int
+	}{
+		{path: "/health", status: http.StatusServiceUnavailable, services: 2},
+		{path: "/health/healthy", status: http.StatusOK, services: 1},
+		{path: "/health/unhealthy", status: http.StatusServiceUnavailable, services: 1},
+		{path: "/health/unknown", status: http.StatusNotFound},
+	}
+	for _, td := range testData {
+		t.Run(td.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, td.path, nil))
+			assert.Equal(t, td.status, rec.Code)
+			if td.services > 0 {
+				result := []ServiceHealth{}
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+				assert.Len(t, result, td.services)
+			}
+		})
+	}
+
+	unhealthy.err = nil
+	m.checkHealth(ctx, unhealthy.Name(), unhealthy)
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
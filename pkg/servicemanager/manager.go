@@ -4,10 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/openshift/microshift/pkg/boottimings"
+	"github.com/openshift/microshift/pkg/tracing"
 	"github.com/openshift/microshift/pkg/util/sigchannel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"k8s.io/klog/v2"
 )
 
@@ -17,6 +22,20 @@ type ServiceManager struct {
 
 	services   []Service
 	serviceMap map[string]Service
+
+	statesMu sync.RWMutex
+	states   map[string]ServiceStatus
+
+	healthMu sync.RWMutex
+	health   map[string]ServiceHealth
+
+	readinessTimeouts map[string]time.Duration
+
+	runMu   sync.Mutex
+	runCtx  context.Context
+	running map[string]*runningService
+	// toggleMu serializes enabling and disabling services.
+	toggleMu sync.Mutex
 }
 
 func NewServiceManager() *ServiceManager {
@@ -26,6 +45,11 @@ func NewServiceManager() *ServiceManager {
 
 		services:   []Service{},
 		serviceMap: make(map[string]Service),
+		states:     make(map[string]ServiceStatus),
+		health:     make(map[string]ServiceHealth),
+
+		readinessTimeouts: make(map[string]time.Duration),
+		running:           make(map[string]*runningService),
 	}
 }
 func (s *ServiceManager) Name() string           { return s.name }
@@ -45,6 +69,10 @@ func (m *ServiceManager) AddService(s Service) error {
 		if _, exists := m.serviceMap[dependency]; !exists {
 			return fmt.Errorf("dependecy '%s' of service '%s' not yet defined", dependency, s.Name())
 		}
+		// Services needed for readiness cannot wait for deferred ones.
+		if isDeferred(m.serviceMap[dependency]) && !isDeferred(s) {
+			return fmt.Errorf("service '%s' cannot depend on deferred service '%s'", s.Name(), dependency)
+		}
 	}
 
 	m.services = append(m.services, s)
@@ -65,75 +93,140 @@ func (m *ServiceManager) Run(ctx context.Context, ready chan<- struct{}, stopped
 	readyMap := make(map[string]<-chan struct{})
 	stoppedMap := make(map[string]<-chan struct{})
 
+	m.runMu.Lock()
+	m.runCtx = ctx
+	m.runMu.Unlock()
+
 	for _, service := range services {
-		// Compile a list of ready channels of the service's dependencies (if any).
-		depsReadyList := []<-chan struct{}{}
-		for _, dependency := range service.Dependencies() {
-			depsReadyList = append(depsReadyList, readyMap[dependency])
-		}
+		m.setState(service.Name(), StateWaiting)
+	}
 
-		// Wait until all of the service's dependencies signalled readiness.
-		// If the context gets canceled before, return immediately.
-		select {
-		case <-sigchannel.And(depsReadyList):
-		case <-ctx.Done():
+	// The deferred services are started once the other ones are ready.
+	var deferred []Service
+	for _, service := range services {
+		if isDeferred(service) {
+			deferred = append(deferred, service)
+			continue
+		}
+		if !m.startAfterDependencies(ctx, service, readyMap, stoppedMap) {
 			// Wait for all services to stop before returning
 			// so MicroShift doesn't quit abruptly
 			<-sigchannel.And(values(stoppedMap))
 			return ctx.Err()
 		}
-
-		// Start the service and store its ready and stopped channels
-		serviceReady, serviceStopped := m.asyncRun(ctx, service)
-		readyMap[service.Name()] = serviceReady
-		stoppedMap[service.Name()] = serviceStopped
 	}
 
 	// If we receive readiness signals from all services, signal readiness of manager
+	servicesReady := sigchannel.And(values(readyMap))
 	go func() {
-		<-sigchannel.And(values(readyMap))
+		<-servicesReady
 		close(ready)
 	}()
 
-	// Stop manager when all services stopped
+	if len(deferred) > 0 {
+		select {
+		case <-servicesReady:
+		case <-ctx.Done():
+			<-sigchannel.And(values(stoppedMap))
+			return ctx.Err()
+		}
+		klog.InfoS("STARTING DEFERRED SERVICES", "count", len(deferred))
+		for _, service := range deferred {
+			if !m.startAfterDependencies(ctx, service, readyMap, stoppedMap) {
+				<-sigchannel.And(values(stoppedMap))
+				return ctx.Err()
+			}
+		}
+	}
+
+	// Stop manager when all services stopped, including the ones enabled
+	// again after being disabled.
 	<-sigchannel.And(values(stoppedMap))
+	m.waitRunning()
 	return ctx.Err()
 }
 
+// startAfterDependencies starts service once its dependencies are ready, and
+// stores its ready and stopped channels. It returns false if the context is
+// canceled before.
+func (m *ServiceManager) startAfterDependencies(ctx context.Context, service Service, readyMap, stoppedMap map[string]<-chan struct{}) bool {
+	// Compile a list of ready channels of the service's dependencies (if any).
+	depsReadyList := []<-chan struct{}{}
+	for _, dependency := range service.Dependencies() {
+		depsReadyList = append(depsReadyList, readyMap[dependency])
+	}
+
+	// Wait until all of the service's dependencies signalled readiness.
+	select {
+	case <-sigchannel.And(depsReadyList):
+	case <-ctx.Done():
+		return false
+	}
+
+	serviceReady, serviceStopped := m.asyncRun(ctx, service)
+	readyMap[service.Name()] = serviceReady
+	stoppedMap[service.Name()] = serviceStopped
+	return true
+}
+
+func isDeferred(service Service) bool {
+	d, ok := service.(DeferredService)
+	return ok && d.Deferred()
+}
+
 func (m *ServiceManager) asyncRun(ctx context.Context, service Service) (<-chan struct{}, <-chan struct{}) {
 	ready, stopped := make(chan struct{}), make(chan struct{})
+	ctx, cancel := context.WithCancel(ctx)
+	run := &runningService{cancel: cancel, stopped: stopped, done: make(chan struct{})}
+	m.setRunning(service.Name(), run)
 	klog.WithMicroshiftLoggerComponent(service.Name(), func() {
 		go func() {
-			defer func() {
-				if r := recover(); r != nil {
-					klog.Errorf("%s panicked: %s", service.Name(), r)
-					klog.Error("Stopping MicroShift")
-					if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
-						klog.Warningf("error killing process: %v", err)
-					}
-					if !sigchannel.IsClosed(stopped) {
-						close(stopped)
-					}
-				}
-			}()
-
+			defer close(run.done)
+			defer cancel()
 			klog.InfoS("SERVICE STARTING", "service", service.Name())
+			m.setState(service.Name(), StateStarting)
 			svcStart := time.Now()
+			var span trace.Span
+			ctx, span = tracing.Tracer().Start(ctx, "service "+service.Name(),
+				trace.WithAttributes(attribute.String("microshift.service", service.Name())))
+			go traceStartup(ctx, span, ready, stopped)
 			go func() {
 				<-ready
+				m.transitionState(service.Name(), StateStarting, StateReady)
+				boottimings.SetServiceReady(service.Name(), svcStart, time.Now())
 				klog.InfoS("SERVICE READY", "service", service.Name(), "since-start", time.Since(svcStart))
 			}()
 			go func() {
 				<-stopped
 				klog.InfoS("SERVICE STOPPED", "service", service.Name(), "since-start", time.Since(svcStart))
 			}()
+			go m.pollHealth(ctx, service, ready, stopped)
+			go m.watchReadiness(ctx, service, ready, stopped)
 
-			if err := service.Run(ctx, ready, stopped); err != nil && !errors.Is(err, context.Canceled) {
-				klog.ErrorS(err, "SERVICE FAILED - stopping MicroShift", "service", service.Name(), "since-start", time.Since(svcStart))
-				if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
-					klog.Warningf("error killing process: %v", err)
+			err := runWithRestarts(ctx, service, ready, stopped)
+			if isPanic(err) && !sigchannel.IsClosed(stopped) {
+				close(stopped)
+			}
+			if m.isDisabled(run) {
+				m.setState(service.Name(), StateDisabled)
+				// Services waiting for MicroShift to be ready do not wait
+				// for disabled services.
+				if !sigchannel.IsClosed(ready) {
+					close(ready)
 				}
+				klog.InfoS("SERVICE DISABLED", "service", service.Name(), "since-start", time.Since(svcStart))
+				return
+			}
+			if err != nil && !errors.Is(err, context.Canceled) {
+				m.setFailed(service.Name(), err)
+				if giveUpAction(service) == GiveUpContinue {
+					klog.ErrorS(err, "SERVICE FAILED - continuing without it", "service", service.Name(), "since-start", time.Since(svcStart))
+					return
+				}
+				klog.ErrorS(err, "SERVICE FAILED - stopping MicroShift", "service", service.Name(), "since-start", time.Since(svcStart))
+				stopMicroShift()
 			} else {
+				m.setState(service.Name(), StateStopped)
 				klog.InfoS("SERVICE COMPLETED", "service", service.Name(), "since-start", time.Since(svcStart))
 			}
 		}()
@@ -141,6 +234,95 @@ func (m *ServiceManager) asyncRun(ctx context.Context, service Service) (<-chan
 	return ready, stopped
 }
 
+// traceStartup ends the span of the start of a service once it is ready, or
+// as failed if it stops before.
+func traceStartup(ctx context.Context, span trace.Span, ready, stopped <-chan struct{}) {
+	select {
+	case <-ready:
+		span.End()
+	case <-stopped:
+		err := ctx.Err()
+		if err == nil {
+			err = fmt.Errorf("stopped before being ready")
+		}
+		tracing.EndSpan(span, err)
+	}
+}
+
+// stopMicroShift makes MicroShift stop all of the services in an orderly way,
+// as if systemd stopped it.
+func stopMicroShift() {
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		klog.Warningf("error killing process: %v", err)
+	}
+}
+
+func giveUpAction(service Service) GiveUpAction {
+	if r, ok := service.(RestartableService); ok && r.RestartPolicy().GiveUp != "" {
+		return r.RestartPolicy().GiveUp
+	}
+	return GiveUpStopMicroShift
+}
+
+// runWithRestarts runs the service, running it again according to its
+// RestartPolicy when it fails. ready is closed when any of the runs becomes
+// ready, or when giving up with GiveUpContinue; stopped is closed once the
+// service is not restarted anymore.
+func runWithRestarts(ctx context.Context, service Service, ready chan<- struct{}, stopped chan<- struct{}) error {
+	r, ok := service.(RestartableService)
+	if !ok {
+		return runRecovered(ctx, service, ready, stopped)
+	}
+	policy := r.RestartPolicy()
+	defer close(stopped)
+
+	var readyOnce sync.Once
+	closeReady := func() { readyOnce.Do(func() { close(ready) }) }
+
+	attempt, backoff := 0, policy.InitialBackoff
+	for {
+		runReady, runStopped := make(chan struct{}), make(chan struct{})
+		go func() {
+			select {
+			case <-runReady:
+				closeReady()
+			case <-runStopped:
+			}
+		}()
+
+		runStart := time.Now()
+		err := runRecovered(ctx, service, runReady, runStopped)
+		if isPanic(err) && !sigchannel.IsClosed(runStopped) {
+			close(runStopped)
+		}
+		if sigchannel.IsClosed(runReady) {
+			closeReady()
+		}
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+
+		if time.Since(runStart) > policy.MaxBackoff {
+			attempt, backoff = 0, policy.InitialBackoff
+		}
+		if attempt >= policy.MaxAttempts {
+			if policy.GiveUp == GiveUpContinue {
+				closeReady()
+			}
+			return fmt.Errorf("giving up after %d restarts: %w", attempt, err)
+		}
+		attempt++
+
+		klog.ErrorS(err, "SERVICE FAILED - restarting", "service", service.Name(), "attempt", attempt, "max-attempts", policy.MaxAttempts, "backoff", backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff = min(2*backoff, policy.MaxBackoff)
+	}
+}
+
 func values(m map[string]<-chan struct{}) []<-chan struct{} {
 	values := make([]<-chan struct{}, 0, len(m))
 	for _, v := range m {
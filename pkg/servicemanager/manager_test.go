@@ -95,6 +95,49 @@ func TestRunToCompletion(t *testing.T) {
 	}
 }
 
+type deferredTestService struct {
+	*GenericService
+}
+
+func (s *deferredTestService) Deferred() bool { return true }
+
+func TestRunDeferred(t *testing.T) {
+	m := NewServiceManager()
+	assert.NoError(t, m.AddService(NewGenericService("foo", nil, func(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+		defer close(stopped)
+		close(ready)
+		<-ctx.Done()
+		return nil
+	})))
+	// The deferred service is never ready, MicroShift is ready without it.
+	deferredStarted := make(chan struct{})
+	assert.NoError(t, m.AddService(&deferredTestService{NewGenericService("bar", []string{"foo"}, func(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+		defer close(stopped)
+		close(deferredStarted)
+		<-ctx.Done()
+		return nil
+	})}))
+	assert.EqualError(t, m.AddService(NewGenericService("baz", []string{"bar"}, nil)), "service 'baz' cannot depend on deferred service 'bar'")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ready, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		assert.ErrorIs(t, m.Run(ctx, ready, stopped), context.Canceled)
+	}()
+	select {
+	case <-ready:
+	case <-time.After(5 * time.Second):
+		t.Fatal("service manager not ready")
+	}
+	select {
+	case <-deferredStarted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deferred service not started")
+	}
+	cancel()
+	<-stopped
+}
+
 func TestRunCancellation(t *testing.T) {
 	var wg sync.WaitGroup
 	defer wg.Wait()
@@ -227,3 +270,87 @@ func TestRunToServicePanic(t *testing.T) {
 		t.Errorf("stopped channel not closed after completing service manager")
 	}
 }
+
+type restartableTestService struct {
+	*GenericService
+	policy RestartPolicy
+}
+
+func (s *restartableTestService) RestartPolicy() RestartPolicy { return s.policy }
+
+func TestRunWithRestarts(t *testing.T) {
+	policy := RestartPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+	failTimes := func(n int, runs *int) RunFunc {
+		return func(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+			defer close(stopped)
+			*runs++
+			if *runs <= n {
+				return errors.New("failed")
+			}
+			close(ready)
+			return nil
+		}
+	}
+
+	t.Run("recovers within the attempts", func(t *testing.T) {
+		runs := 0
+		s := &restartableTestService{NewGenericService("foo", nil, failTimes(2, &runs)), policy}
+		ready, stopped := make(chan struct{}), make(chan struct{})
+		assert.NoError(t, runWithRestarts(context.Background(), s, ready, stopped))
+		assert.Equal(t, 3, runs)
+		assert.True(t, sigchannel.IsClosed(ready))
+		assert.True(t, sigchannel.IsClosed(stopped))
+	})
+
+	t.Run("gives up after the attempts", func(t *testing.T) {
+		runs := 0
+		s := &restartableTestService{NewGenericService("foo", nil, failTimes(3, &runs)), policy}
+		ready, stopped := make(chan struct{}), make(chan struct{})
+		assert.Error(t, runWithRestarts(context.Background(), s, ready, stopped))
+		assert.Equal(t, 3, runs)
+		assert.False(t, sigchannel.IsClosed(ready))
+		assert.True(t, sigchannel.IsClosed(stopped))
+		assert.Equal(t, GiveUpStopMicroShift, giveUpAction(s))
+	})
+
+	t.Run("giving up and continuing marks the service ready", func(t *testing.T) {
+		runs := 0
+		continuePolicy := policy
+		continuePolicy.GiveUp = GiveUpContinue
+		s := &restartableTestService{NewGenericService("foo", nil, failTimes(3, &runs)), continuePolicy}
+		ready, stopped := make(chan struct{}), make(chan struct{})
+		assert.Error(t, runWithRestarts(context.Background(), s, ready, stopped))
+		assert.True(t, sigchannel.IsClosed(ready))
+		assert.Equal(t, GiveUpContinue, giveUpAction(s))
+	})
+
+	t.Run("panics are restarted", func(t *testing.T) {
+		runs := 0
+		s := &restartableTestService{NewGenericService("foo", nil, func(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+			runs++
+			if runs == 1 {
+				panic("I'm in panic")
+			}
+			defer close(stopped)
+			close(ready)
+			return nil
+		}), policy}
+		ready, stopped := make(chan struct{}), make(chan struct{})
+		assert.NoError(t, runWithRestarts(context.Background(), s, ready, stopped))
+		assert.Equal(t, 2, runs)
+		assert.True(t, sigchannel.IsClosed(ready))
+		assert.True(t, sigchannel.IsClosed(stopped))
+	})
+}
+
+func TestRunRecovered(t *testing.T) {
+	s := NewGenericService("foo", nil, func(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+		panic("I'm in panic")
+	})
+	err := runRecovered(context.Background(), s, make(chan struct{}), make(chan struct{}))
+	var panicErr *PanicError
+	assert.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, "I'm in panic", panicErr.Value)
+	assert.Contains(t, panicErr.Stack, "TestRunRecovered")
+}
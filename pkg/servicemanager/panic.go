@@ -0,0 +1,40 @@
+package servicemanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"k8s.io/klog/v2"
+)
+
+// PanicError is the error of a service whose Run panicked.
+type PanicError struct {
+	Value any
+	Stack string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+func isPanic(err error) bool {
+	var panicErr *PanicError
+	return errors.As(err, &panicErr)
+}
+
+// runRecovered runs the service and turns a panic into a PanicError, so a
+// panicking service is restarted or stops MicroShift in an orderly way like
+// a failing one. Only panics of the Run goroutine can be recovered: a panic
+// in a goroutine started by the service still crashes the process.
+func runRecovered(ctx context.Context, service Service, ready chan<- struct{}, stopped chan<- struct{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicErr := &PanicError{Value: r, Stack: string(debug.Stack())}
+			klog.ErrorS(panicErr, "SERVICE PANICKED", "service", service.Name(), "stack", panicErr.Stack)
+			err = panicErr
+		}
+	}()
+	return service.Run(ctx, ready, stopped)
+}
@@ -0,0 +1,99 @@
+package servicemanager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// processStopTimeout is how long a child process may take to stop after
+// SIGTERM before it is killed.
+const processStopTimeout = 30 * time.Second
+
+// ProbeFunc returns once the process is ready, or an error if it never
+// becomes ready.
+type ProbeFunc func(ctx context.Context) error
+
+// ProcessService runs a component in a child process of MicroShift, so its
+// memory is accounted for and released separately from the MicroShift one.
+// The process is stopped with SIGTERM when the service is, and when
+// MicroShift dies.
+type ProcessService struct {
+	name  string
+	deps  []string
+	path  string
+	args  []string
+	probe ProbeFunc
+}
+
+func NewProcessService(name string, dependencies []string, path string, args []string, probe ProbeFunc) *ProcessService {
+	return &ProcessService{
+		name:  name,
+		deps:  dependencies,
+		path:  path,
+		args:  args,
+		probe: probe,
+	}
+}
+
+func (s *ProcessService) Name() string           { return s.name }
+func (s *ProcessService) Dependencies() []string { return s.deps }
+
+func (s *ProcessService) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	defer close(stopped)
+
+	processCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	cmd := exec.CommandContext(processCtx, s.path, s.args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Pdeathsig: syscall.SIGTERM}
+	cmd.Cancel = func() error { return cmd.Process.Signal(syscall.SIGTERM) }
+	cmd.WaitDelay = processStopTimeout
+
+	klog.Infof("Starting %s with args %v", s.path, s.args)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", s.path, err)
+	}
+	var exitErr error
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		exitErr = cmd.Wait()
+	}()
+	// The process is always stopped before the service is.
+	defer func() {
+		stop()
+		<-exited
+	}()
+
+	probed := make(chan error, 1)
+	go func() {
+		probed <- s.probe(processCtx)
+	}()
+
+	for {
+		select {
+		case err := <-probed:
+			if err != nil {
+				return fmt.Errorf("%s is not ready: %w", s.name, err)
+			}
+			klog.Infof("%s is ready", s.name)
+			close(ready)
+			probed = nil
+		case <-exited:
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if exitErr == nil {
+				return fmt.Errorf("%s process terminated prematurely", s.name)
+			}
+			return fmt.Errorf("%s process terminated: %w", s.name, exitErr)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package servicemanager
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openshift/microshift/pkg/util/sigchannel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessService(t *testing.T) {
+	probeOK := func(ctx context.Context) error { return nil }
+
+	// The process is stopped with the service.
+	s := NewProcessService("foo", nil, "sleep", []string{"60"}, probeOK)
+	ctx, cancel := context.WithCancel(context.Background())
+	ready, stopped := make(chan struct{}), make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Run(ctx, ready, stopped) }()
+	select {
+	case <-ready:
+	case <-time.After(5 * time.Second):
+		t.Fatal("process service not ready")
+	}
+	cancel()
+	select {
+	case err := <-errCh:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("process not stopped")
+	}
+	assert.True(t, sigchannel.IsClosed(stopped))
+
+	// A process exiting on its own fails the service.
+	s = NewProcessService("foo", nil, "false", nil, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	err := s.Run(context.Background(), make(chan struct{}), make(chan struct{}))
+	assert.ErrorContains(t, err, "foo process terminated")
+
+	// A process which is never ready is stopped.
+	s = NewProcessService("foo", nil, "sleep", []string{"60"}, func(ctx context.Context) error {
+		return errors.New("unhealthy")
+	})
+	err = s.Run(context.Background(), make(chan struct{}), make(chan struct{}))
+	assert.ErrorContains(t, err, "unhealthy")
+}
@@ -0,0 +1,72 @@
+package servicemanager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// SetReadinessTimeouts overrides the readiness timeouts of services, by
+// name. A timeout of 0 disables the timeout of the service. It must be
+// called after adding the services.
+func (m *ServiceManager) SetReadinessTimeouts(timeouts map[string]time.Duration) {
+	for name, timeout := range timeouts {
+		if _, exists := m.serviceMap[name]; !exists {
+			klog.Warningf("Ignoring readiness timeout of unknown service %q", name)
+			continue
+		}
+		m.readinessTimeouts[name] = timeout
+	}
+}
+
+func (m *ServiceManager) readinessTimeout(service Service) (time.Duration, string) {
+	var timeout time.Duration
+	var hint string
+	if r, ok := service.(ReadinessTimeoutService); ok {
+		timeout, hint = r.ReadinessTimeout(), r.ReadinessHint()
+	}
+	if override, ok := m.readinessTimeouts[service.Name()]; ok {
+		timeout = override
+	}
+	return timeout, hint
+}
+
+// watchReadiness stops MicroShift if the service is not ready within its
+// readiness timeout, instead of leaving MicroShift waiting forever.
+func (m *ServiceManager) watchReadiness(ctx context.Context, service Service, ready, stopped <-chan struct{}) {
+	timeout, hint := m.readinessTimeout(service)
+	if timeout <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ready:
+		return
+	case <-stopped:
+		return
+	case <-ctx.Done():
+		return
+	}
+
+	err := fmt.Errorf("not ready %s after starting", timeout)
+	m.setFailed(service.Name(), err)
+	klog.ErrorS(err, "SERVICE READINESS TIMEOUT - stopping MicroShift", "service", service.Name(), "hint", hint)
+	stopMicroShift()
+}
+
+// ReportNotReady logs the services that are not ready, with hints about
+// what to check, when MicroShift gives up waiting for them.
+func (m *ServiceManager) ReportNotReady() {
+	for _, status := range m.Statuses() {
+		if status.State == StateReady || status.State == StateStopped || status.State == StateDisabled {
+			continue
+		}
+		_, hint := m.readinessTimeout(m.serviceMap[status.Name])
+		klog.InfoS("SERVICE NOT READY", "service", status.Name, "state", status.State, "since", status.Since, "hint", hint)
+	}
+}
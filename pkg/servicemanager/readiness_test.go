@@ -0,0 +1,38 @@
+package servicemanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type readinessTestService struct {
+	*GenericService
+}
+
+func (s *readinessTestService) ReadinessTimeout() time.Duration { return time.Minute }
+func (s *readinessTestService) ReadinessHint() string           { return "check foo" }
+
+func TestReadinessTimeout(t *testing.T) {
+	m := NewServiceManager()
+	withDefault := &readinessTestService{NewGenericService("foo", nil, nil)}
+	withoutDefault := NewGenericService("bar", nil, nil)
+	assert.NoError(t, m.AddService(withDefault))
+	assert.NoError(t, m.AddService(withoutDefault))
+
+	timeout, hint := m.readinessTimeout(withDefault)
+	assert.Equal(t, time.Minute, timeout)
+	assert.Equal(t, "check foo", hint)
+	timeout, hint = m.readinessTimeout(withoutDefault)
+	assert.Zero(t, timeout)
+	assert.Empty(t, hint)
+
+	m.SetReadinessTimeouts(map[string]time.Duration{"foo": 0, "bar": time.Second, "unknown": time.Second})
+	timeout, hint = m.readinessTimeout(withDefault)
+	assert.Zero(t, timeout)
+	assert.Equal(t, "check foo", hint)
+	timeout, _ = m.readinessTimeout(withoutDefault)
+	assert.Equal(t, time.Second, timeout)
+	assert.NotContains(t, m.readinessTimeouts, "unknown")
+}
@@ -0,0 +1,82 @@
+package servicemanager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/openshift/microshift/pkg/config"
+)
+
+// ServiceFactory creates a service from the MicroShift configuration. It is
+// called once per run of MicroShift, with a context cancelled on shutdown.
+// Returning a nil service without an error skips the service, e.g. when it
+// is disabled in the configuration.
+type ServiceFactory func(ctx context.Context, cfg *config.Config) (Service, error)
+
+type registration struct {
+	name    string
+	factory ServiceFactory
+}
+
+// Registry holds services compiled into MicroShift in addition to the
+// built-in ones. Registered services take part in the same readiness and
+// shutdown lifecycle and may depend on any of the built-in services.
+type Registry struct {
+	mu            sync.Mutex
+	registrations []registration
+}
+
+// DefaultRegistry is the registry used by `microshift run`.
+var DefaultRegistry = &Registry{}
+
+// Register adds a service factory to DefaultRegistry. It is meant to be
+// called from the init function of a package that is imported for its side
+// effects, and panics if name is registered more than once.
+func Register(name string, factory ServiceFactory) {
+	if err := DefaultRegistry.Register(name, factory); err != nil {
+		panic(err)
+	}
+}
+
+func (r *Registry) Register(name string, factory ServiceFactory) error {
+	if factory == nil {
+		return fmt.Errorf("factory of service '%s' must not be <nil>", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, reg := range r.registrations {
+		if reg.name == name {
+			return fmt.Errorf("service '%s' registered more than once", name)
+		}
+	}
+	r.registrations = append(r.registrations, registration{name: name, factory: factory})
+	return nil
+}
+
+// AddServices creates the registered services and adds them to m, in the
+// order they were registered. It must be called after adding the built-in
+// services so the dependencies of the registered services are defined.
+func (r *Registry) AddServices(ctx context.Context, cfg *config.Config, m *ServiceManager) error {
+	r.mu.Lock()
+	registrations := append([]registration{}, r.registrations...)
+	r.mu.Unlock()
+
+	for _, reg := range registrations {
+		service, err := reg.factory(ctx, cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create registered service '%s': %w", reg.name, err)
+		}
+		if service == nil {
+			continue
+		}
+		if service.Name() != reg.name {
+			return fmt.Errorf("service '%s' was registered as '%s'", service.Name(), reg.name)
+		}
+		if err := m.AddService(service); err != nil {
+			return fmt.Errorf("failed to add registered service '%s': %w", reg.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package servicemanager
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	factory := func(name string, deps ...string) ServiceFactory {
+		return func(ctx context.Context, cfg *config.Config) (Service, error) {
+			return NewGenericService(name, deps, nil), nil
+		}
+	}
+
+	r := &Registry{}
+	assert.NoError(t, r.Register("extra", factory("extra", "etcd")))
+	assert.NoError(t, r.Register("disabled", func(ctx context.Context, cfg *config.Config) (Service, error) { return nil, nil }))
+	assert.NoError(t, r.Register("after-extra", factory("after-extra", "extra")))
+	assert.EqualError(t, r.Register("extra", factory("extra")), "service 'extra' registered more than once")
+	assert.Error(t, r.Register("nil", nil))
+
+	m := NewServiceManager()
+	assert.NoError(t, m.AddService(NewGenericService("etcd", nil, nil)))
+	assert.NoError(t, r.AddServices(context.Background(), config.NewDefault(), m))
+	assert.Len(t, m.services, 3)
+	assert.Equal(t, "extra", m.services[1].Name())
+	assert.Equal(t, "after-extra", m.services[2].Name())
+
+	missingDep := &Registry{}
+	assert.NoError(t, missingDep.Register("extra", factory("extra", "unknown")))
+	assert.Error(t, missingDep.AddServices(context.Background(), config.NewDefault(), NewServiceManager()))
+
+	misnamed := &Registry{}
+	assert.NoError(t, misnamed.Register("extra", factory("other")))
+	assert.Error(t, misnamed.AddServices(context.Background(), config.NewDefault(), NewServiceManager()))
+
+	failing := &Registry{}
+	assert.NoError(t, failing.Register("extra", func(ctx context.Context, cfg *config.Config) (Service, error) {
+		return nil, errors.New("boom")
+	}))
+	assert.ErrorContains(t, failing.AddServices(context.Background(), config.NewDefault(), NewServiceManager()), "boom")
+}
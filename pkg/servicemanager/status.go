@@ -0,0 +1,73 @@
+package servicemanager
+
+import (
+	"time"
+)
+
+// ServiceState is the lifecycle state of a service.
+type ServiceState string
+
+const (
+	// StateWaiting is the state of a service waiting for its dependencies.
+	StateWaiting  ServiceState = "Waiting"
+	StateStarting ServiceState = "Starting"
+	StateReady    ServiceState = "Ready"
+	StateStopped  ServiceState = "Stopped"
+	StateFailed   ServiceState = "Failed"
+	StateDisabled ServiceState = "Disabled"
+)
+
+// ServiceStatus describes the state of a service, and its health if the
+// service implements HealthChecker.
+type ServiceStatus struct {
+	Name  string       `json:"name"`
+	State ServiceState `json:"state"`
+	Since time.Time    `json:"since"`
+	// Error is why the service failed.
+	Error  string         `json:"error,omitempty"`
+	Health *ServiceHealth `json:"health,omitempty"`
+}
+
+func (m *ServiceManager) setState(name string, state ServiceState) {
+	m.statesMu.Lock()
+	defer m.statesMu.Unlock()
+	m.states[name] = ServiceStatus{Name: name, State: state, Since: time.Now()}
+}
+
+func (m *ServiceManager) setFailed(name string, err error) {
+	m.statesMu.Lock()
+	defer m.statesMu.Unlock()
+	m.states[name] = ServiceStatus{Name: name, State: StateFailed, Since: time.Now(), Error: err.Error()}
+}
+
+// transitionState changes the state of the service only if it is in state from.
+func (m *ServiceManager) transitionState(name string, from, to ServiceState) {
+	m.statesMu.Lock()
+	defer m.statesMu.Unlock()
+	if m.states[name].State == from {
+		m.states[name] = ServiceStatus{Name: name, State: to, Since: time.Now()}
+	}
+}
+
+// Statuses returns the status of every service, in the order they are started.
+func (m *ServiceManager) Statuses() []ServiceStatus {
+	health := map[string]ServiceHealth{}
+	for _, h := range m.Health() {
+		health[h.Service] = h
+	}
+
+	m.statesMu.RLock()
+	defer m.statesMu.RUnlock()
+	result := make([]ServiceStatus, 0, len(m.services))
+	for _, s := range m.services {
+		status, ok := m.states[s.Name()]
+		if !ok {
+			status = ServiceStatus{Name: s.Name(), State: StateWaiting}
+		}
+		if h, ok := health[s.Name()]; ok {
+			status.Health = &h
+		}
+		result = append(result, status)
+	}
+	return result
+}
@@ -0,0 +1,123 @@
+package servicemanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/klog/v2"
+)
+
+var (
+	ErrUnknownService = errors.New("unknown service")
+	ErrNotOptional    = errors.New("service is not optional")
+	ErrNotStarted     = errors.New("service has not been started yet")
+)
+
+type runningService struct {
+	cancel  context.CancelFunc
+	stopped <-chan struct{}
+	// done is closed once the end of the run has been handled.
+	done     chan struct{}
+	disabled bool
+}
+
+func (m *ServiceManager) setRunning(name string, r *runningService) {
+	m.runMu.Lock()
+	defer m.runMu.Unlock()
+	m.running[name] = r
+}
+
+func (m *ServiceManager) isDisabled(r *runningService) bool {
+	m.runMu.Lock()
+	defer m.runMu.Unlock()
+	return r.disabled
+}
+
+// waitRunning waits for the last run of every service to stop.
+func (m *ServiceManager) waitRunning() {
+	m.runMu.Lock()
+	stopped := make([]<-chan struct{}, 0, len(m.running))
+	for _, r := range m.running {
+		stopped = append(stopped, r.stopped)
+	}
+	m.runMu.Unlock()
+
+	for _, s := range stopped {
+		<-s
+	}
+}
+
+func (m *ServiceManager) optionalService(name string) (Service, error) {
+	service, ok := m.serviceMap[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownService, name)
+	}
+	if o, ok := service.(OptionalService); !ok || !o.Optional() {
+		return nil, fmt.Errorf("%w: %q", ErrNotOptional, name)
+	}
+	return service, nil
+}
+
+// DisableService stops an optional service until it is enabled again or
+// MicroShift restarts. It returns once the service stopped.
+func (m *ServiceManager) DisableService(name string) error {
+	m.toggleMu.Lock()
+	defer m.toggleMu.Unlock()
+
+	if _, err := m.optionalService(name); err != nil {
+		return err
+	}
+
+	m.runMu.Lock()
+	r, ok := m.running[name]
+	if !ok {
+		m.runMu.Unlock()
+		return fmt.Errorf("%w: %q", ErrNotStarted, name)
+	}
+	if r.disabled {
+		m.runMu.Unlock()
+		return nil
+	}
+	r.disabled = true
+	m.runMu.Unlock()
+
+	klog.InfoS("SERVICE DISABLING", "service", name)
+	r.cancel()
+	<-r.done
+	// The service may have completed before being disabled.
+	m.setState(name, StateDisabled)
+	return nil
+}
+
+// EnableService starts again an optional service that was disabled.
+func (m *ServiceManager) EnableService(name string) error {
+	m.toggleMu.Lock()
+	defer m.toggleMu.Unlock()
+
+	service, err := m.optionalService(name)
+	if err != nil {
+		return err
+	}
+
+	m.runMu.Lock()
+	r, ok := m.running[name]
+	ctx := m.runCtx
+	m.runMu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrNotStarted, name)
+	}
+	if !r.disabled {
+		return nil
+	}
+	if ctx == nil || ctx.Err() != nil {
+		return fmt.Errorf("cannot enable %q: MicroShift is stopping", name)
+	}
+
+	if r, ok := service.(RenewableService); ok {
+		service = r.Renew()
+	}
+	// The dependencies were ready when the service was first started.
+	m.asyncRun(ctx, service)
+	return nil
+}
@@ -0,0 +1,111 @@
+package servicemanager
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type optionalTestService struct {
+	*GenericService
+}
+
+func (s *optionalTestService) Optional() bool { return true }
+
+func TestDisableService(t *testing.T) {
+	m := NewServiceManager()
+	assert.NoError(t, m.AddService(NewGenericService("foo", nil, nil)))
+	assert.NoError(t, m.AddService(&optionalTestService{NewGenericService("bar", []string{"foo"}, nil)}))
+
+	assert.ErrorIs(t, m.DisableService("unknown"), ErrUnknownService)
+	assert.ErrorIs(t, m.DisableService("foo"), ErrNotOptional)
+	assert.ErrorIs(t, m.DisableService("bar"), ErrNotStarted)
+	assert.ErrorIs(t, m.EnableService("bar"), ErrNotStarted)
+
+	// Simulate the run of the service, which ends when its context is cancelled.
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &runningService{cancel: cancel, stopped: ctx.Done(), done: make(chan struct{})}
+	m.setRunning("bar", run)
+	go func() {
+		<-ctx.Done()
+		close(run.done)
+	}()
+
+	assert.NoError(t, m.DisableService("bar"))
+	assert.Error(t, ctx.Err())
+	assert.True(t, m.isDisabled(run))
+	assert.Equal(t, StateDisabled, m.Statuses()[1].State)
+	// Disabling twice is a no-op.
+	assert.NoError(t, m.DisableService("bar"))
+
+	// MicroShift is not running, so the service cannot be started again.
+	assert.Error(t, m.EnableService("bar"))
+}
+
+// renewableTestService counts its runs, which must be one per instance, and
+// sends the instances it renews to renewed.
+type renewableTestService struct {
+	runs    atomic.Int32
+	renewed chan *renewableTestService
+}
+
+func (s *renewableTestService) Name() string           { return "renewable" }
+func (s *renewableTestService) Dependencies() []string { return nil }
+func (s *renewableTestService) Optional() bool         { return true }
+
+func (s *renewableTestService) Renew() Service {
+	r := &renewableTestService{renewed: s.renewed}
+	s.renewed <- r
+	return r
+}
+
+func (s *renewableTestService) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	defer close(stopped)
+	s.runs.Add(1)
+	close(ready)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (s *renewableTestService) HealthCheck(ctx context.Context) error {
+	if runs := s.runs.Load(); runs != 1 {
+		return fmt.Errorf("run %d times", runs)
+	}
+	return nil
+}
+
+func TestEnableServiceRenews(t *testing.T) {
+	m := NewServiceManager()
+	first := &renewableTestService{renewed: make(chan *renewableTestService, 1)}
+	require.NoError(t, m.AddService(first))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.runMu.Lock()
+	m.runCtx = ctx
+	m.runMu.Unlock()
+	ready, _ := m.asyncRun(ctx, first)
+	<-ready
+
+	require.NoError(t, m.DisableService("renewable"))
+	require.NoError(t, m.EnableService("renewable"))
+	var renewed *renewableTestService
+	select {
+	case renewed = <-first.renewed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the service was enabled again without being renewed")
+	}
+	assert.Eventually(t, func() bool {
+		return m.Statuses()[0].State == StateReady
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, m.DisableService("renewable"))
+	assert.Equal(t, StateDisabled, m.Statuses()[0].State)
+
+	assert.Equal(t, int32(1), first.runs.Load())
+	assert.Equal(t, int32(1), renewed.runs.Load())
+}
@@ -2,6 +2,7 @@ package servicemanager
 
 import (
 	"context"
+	"time"
 )
 
 type Runner interface {
@@ -13,3 +14,80 @@ type Service interface {
 	Dependencies() []string
 	Runner
 }
+
+// ConfigurationReporter is implemented by services that prepare their
+// configuration when they are created but only fail when they are run,
+// so configuration problems can be reported without running them.
+type ConfigurationReporter interface {
+	ConfigurationError() error
+}
+
+// HealthChecker is implemented by services that can report their health
+// once they are ready. HealthCheck must return quickly, the context is
+// cancelled after a few seconds.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// GiveUpAction is what happens when a service keeps failing after all of the
+// restarts allowed by its RestartPolicy.
+type GiveUpAction string
+
+const (
+	// GiveUpStopMicroShift stops MicroShift, like for services without a
+	// restart policy.
+	GiveUpStopMicroShift GiveUpAction = "StopMicroShift"
+	// GiveUpContinue leaves the service stopped and keeps MicroShift running.
+	// The service is considered ready, so services depending on it still start.
+	GiveUpContinue GiveUpAction = "Continue"
+)
+
+// RestartPolicy describes how a failed service is restarted.
+type RestartPolicy struct {
+	// MaxAttempts is the number of consecutive restarts before giving up.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first restart. It doubles on
+	// every consecutive restart, up to MaxBackoff. A run lasting longer
+	// than MaxBackoff resets the attempts and the delay.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	GiveUp         GiveUpAction
+}
+
+// RestartableService is implemented by services whose Run may be called
+// again after it returned an error.
+type RestartableService interface {
+	RestartPolicy() RestartPolicy
+}
+
+// ReadinessTimeoutService is implemented by services with a default limit on
+// the time they may take to become ready once they are started.
+type ReadinessTimeoutService interface {
+	ReadinessTimeout() time.Duration
+	// ReadinessHint tells the user what to check when the service is not
+	// ready in time.
+	ReadinessHint() string
+}
+
+// OptionalService is implemented by services that can be disabled and
+// enabled again while MicroShift is running. Other services must not
+// depend on them.
+type OptionalService interface {
+	Optional() bool
+}
+
+// RenewableService is implemented by optional services whose Run must not be
+// called again once it returned, e.g. because it keeps the state of the run.
+// Renew returns a new instance of the service, which is run when the service
+// is enabled again after being disabled.
+type RenewableService interface {
+	Renew() Service
+}
+
+// DeferredService is implemented by services that MicroShift does not need
+// to be ready. They are started once all of the other services are ready,
+// sparing their start to the boot, and MicroShift does not wait for them.
+// Other services must not depend on them.
+type DeferredService interface {
+	Deferred() bool
+}
@@ -0,0 +1,62 @@
+package sysconfwatch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/openshift/microshift/pkg/version"
+	"k8s.io/klog/v2"
+)
+
+// timesyncClockFile is touched by systemd-timesyncd on every synchronization,
+// and its modification time restores the clock on boot.
+const timesyncClockFile = "/var/lib/systemd/timesync/clock"
+
+// EarliestSaneTime precedes the build of any MicroShift release, so a clock
+// behind it has not been set, like on devices without a real-time clock
+// booting at the epoch before NTP synchronizes.
+var EarliestSaneTime = time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+var saneClockPollInterval = time.Second
+
+// MinimumSaneTime returns the earliest time the clock can be at: the latest
+// of EarliestSaneTime, the build date of MicroShift and the last clock
+// synchronization recorded by systemd-timesyncd.
+func MinimumSaneTime() time.Time {
+	minTime := EarliestSaneTime
+	if buildDate, err := time.Parse(time.RFC3339, version.Get().BuildDate); err == nil && buildDate.After(minTime) {
+		minTime = buildDate
+	}
+	if fi, err := os.Stat(timesyncClockFile); err == nil && fi.ModTime().After(minTime) {
+		minTime = fi.ModTime()
+	}
+	return minTime
+}
+
+// WaitForSaneClock waits up to timeout for the clock to reach minTime, so
+// that certificates are not generated with validity dates in the past,
+// unusable once the clock is set.
+func WaitForSaneClock(ctx context.Context, minTime time.Time, timeout time.Duration) error {
+	if !time.Now().Before(minTime) {
+		return nil
+	}
+	klog.Warningf("clock is behind %s, waiting up to %v for it to synchronize", minTime.Format(time.RFC3339), timeout)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(saneClockPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if !time.Now().Before(minTime) {
+				klog.Infof("clock synchronized")
+				return nil
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("clock is still behind %s: %w", minTime.Format(time.RFC3339), ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package sysconfwatch
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitForSaneClock(t *testing.T) {
+	saneClockPollInterval = 10 * time.Millisecond
+
+	if err := WaitForSaneClock(context.Background(), time.Now().Add(-time.Hour), time.Millisecond); err != nil {
+		t.Errorf("expected no error for a clock past the minimum time, got %v", err)
+	}
+	if err := WaitForSaneClock(context.Background(), time.Now().Add(50*time.Millisecond), time.Second); err != nil {
+		t.Errorf("expected no error once the clock reaches the minimum time, got %v", err)
+	}
+	if err := WaitForSaneClock(context.Background(), time.Now().Add(time.Hour), 50*time.Millisecond); err == nil {
+		t.Errorf("expected an error for a clock behind the minimum time")
+	}
+}
+
+func TestMinimumSaneTime(t *testing.T) {
+	if minTime := MinimumSaneTime(); minTime.Before(EarliestSaneTime) {
+		t.Errorf("expected a minimum time not before %v, got %v", EarliestSaneTime, minTime)
+	}
+}
@@ -0,0 +1,65 @@
+package sysconfwatch
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	networkManagerService = "org.freedesktop.NetworkManager"
+	networkManagerPath    = dbus.ObjectPath("/org/freedesktop/NetworkManager")
+	hostnameService       = "org.freedesktop.hostname1"
+)
+
+// watchSystemBus notifies of the signals of NetworkManager, which cover the
+// changes of the addresses, links and DNS settings of the host, and of the
+// host name changes made with hostnamectl. The returned channel is closed
+// when the connection to the system bus is lost.
+func watchSystemBus(ctx context.Context) (<-chan struct{}, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the system bus: %w", err)
+	}
+
+	// Nothing is signaled on hosts not managed by NetworkManager.
+	var owner string
+	if err := conn.BusObject().Call("org.freedesktop.DBus.GetNameOwner", 0, networkManagerService).Store(&owner); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("NetworkManager is not running: %w", err)
+	}
+
+	for _, match := range [][]dbus.MatchOption{
+		{dbus.WithMatchSender(networkManagerService), dbus.WithMatchPathNamespace(networkManagerPath)},
+		{dbus.WithMatchSender(hostnameService), dbus.WithMatchInterface("org.freedesktop.DBus.Properties"), dbus.WithMatchMember("PropertiesChanged")},
+	} {
+		if err := conn.AddMatchSignal(match...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to subscribe to system bus signals: %w", err)
+		}
+	}
+	signals := make(chan *dbus.Signal, 16)
+	conn.Signal(signals)
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+		defer conn.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-signals:
+				if !ok {
+					return
+				}
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return changes, nil
+}
@@ -17,31 +17,56 @@ limitations under the License.
 package sysconfwatch
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"math"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/openshift/microshift/pkg/config"
 	"github.com/openshift/microshift/pkg/util"
 	"golang.org/x/sys/unix"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
 )
 
+// sysConfigCheckInterval is the polling interval when system bus signals
+// are not available, sysConfigSafetyCheckInterval the one catching the
+// changes not signaled, such as hand edits of resolv.conf.
 const sysConfigCheckInterval = time.Second * 5
+const sysConfigSafetyCheckInterval = time.Minute
+
+// sysConfigSettleDelay lets bursts of network changes, such as the
+// reconfiguration of a link, settle before checking them.
+const sysConfigSettleDelay = time.Second * 2
 const sysConfigAllowedTimeDrift = time.Second * 10
 
+// dnsPodsSelector selects the CoreDNS pods, which read the DNS settings of
+// the host when they start.
+const dnsPodsSelector = "dns.operator.openshift.io/daemonset-dns=default"
+
 type SysConfWatchController struct {
 	NodeIP       string
 	NodeIPv6     string
 	userNodeIP   string
 	userNodeIPv6 string
-	timerFd      int
+	nodeIPPolicy util.NodeIPPolicy
+	// hostname is watched when the NodeName follows the host name.
+	hostname       string
+	resolvConf     string
+	dnsConfig      string
+	kubeconfigPath string
+	timerFd        int
 }
 
 func NewSysConfWatchController(cfg *config.Config) *SysConfWatchController {
-	// Create a realtime clock timer with asynchronous read support
-	fd, err := unix.TimerfdCreate(unix.CLOCK_REALTIME, unix.TFD_CLOEXEC|unix.TFD_NONBLOCK)
+	// Create a realtime clock timer with blocking read support
+	fd, err := unix.TimerfdCreate(unix.CLOCK_REALTIME, unix.TFD_CLOEXEC)
 	if err != nil {
 		klog.Fatalf("failed to create a realtime clock timer %v", err)
 	}
@@ -56,12 +81,36 @@ func NewSysConfWatchController(cfg *config.Config) *SysConfWatchController {
 	if err != nil {
 		klog.Fatalf("failed to start a realtime clock timer %v", err)
 	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		klog.Fatalf("failed to get hostname %v", err)
+	}
+	hostname = strings.ToLower(hostname)
+	if cfg.CanonicalNodeName() != hostname {
+		hostname = ""
+	}
+
+	// The kubelet hands the same file to the CoreDNS pods.
+	resolvConf := "/etc/resolv.conf"
+	if _, err := os.Stat(config.DefaultSystemdResolvedFile); err == nil {
+		resolvConf = config.DefaultSystemdResolvedFile
+	}
+	dnsConfig, err := readDNSConfig(resolvConf)
+	if err != nil {
+		klog.Warningf("failed to read host DNS configuration: %v", err)
+	}
+
 	return &SysConfWatchController{
-		NodeIP:       cfg.Node.NodeIP,
-		NodeIPv6:     cfg.Node.NodeIPV6,
-		userNodeIP:   cfg.UserNodeIP(),
-		userNodeIPv6: cfg.UserNodeIPv6(),
-		timerFd:      fd,
+		NodeIP:         cfg.Node.NodeIP,
+		NodeIPv6:       cfg.Node.NodeIPV6,
+		userNodeIP:     cfg.UserNodeIP(),
+		userNodeIPv6:   cfg.UserNodeIPv6(),
+		nodeIPPolicy:   cfg.Node.NodeIPSelection.Policy(),
+		hostname:       hostname,
+		resolvConf:     resolvConf,
+		dnsConfig:      dnsConfig,
+		kubeconfigPath: cfg.KubeConfigPath(config.KubeAdmin),
+		timerFd:        fd,
 	}
 }
 
@@ -93,12 +142,22 @@ func getSysMonTimes() (int64, int64) {
 
 func (c *SysConfWatchController) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
 	defer close(stopped)
-	ticker := time.NewTicker(sysConfigCheckInterval)
-	defer ticker.Stop()
 
 	klog.Infof("starting sysconfwatch-controller with IP address %q", c.NodeIP)
 
-	var buf []byte = make([]byte, 8)
+	// Wait for the changes signaled by NetworkManager, and poll when they
+	// are not available.
+	interval := sysConfigSafetyCheckInterval
+	networkChanges, err := watchSystemBus(ctx)
+	if err != nil {
+		klog.Warningf("polling for network changes every %v: %v", sysConfigCheckInterval, err)
+		interval = sysConfigCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var settle <-chan time.Time
+
+	clockChanges := c.watchClock()
 	// Take a snapshot of the system and monototic clocks as a base reference
 	stimeRef, mtimeRef := getSysMonTimes()
 
@@ -107,57 +166,43 @@ func (c *SysConfWatchController) Run(ctx context.Context, ready chan<- struct{},
 	for {
 		select {
 		case <-ticker.C:
-			// Check the IP change
-			currentIP, err := util.GetHostIP(c.userNodeIP)
-			if err != nil {
-				klog.Warningf("cannot find an host IP: %v", err)
-				os.Exit(1)
-				return nil
-			}
-			if c.NodeIP != currentIP {
-				klog.Warningf("IP address has changed from %q to %q, restarting MicroShift", c.NodeIP, currentIP)
-				os.Exit(1)
-				return nil
+			c.checkNetwork(ctx)
+
+		case _, ok := <-networkChanges:
+			if !ok {
+				klog.Warningf("lost the system bus connection, polling for network changes every %v", sysConfigCheckInterval)
+				networkChanges = nil
+				ticker.Reset(sysConfigCheckInterval)
+				continue
 			}
-			// Dual stack case
-			if c.NodeIPv6 != "" {
-				currentIP, err = util.GetHostIPv6(c.userNodeIPv6)
-				if err != nil {
-					klog.Warningf("cannot find an host IP: %v", err)
-					os.Exit(1)
-					return nil
-				}
-				if c.NodeIPv6 != currentIP {
-					klog.Warningf("IP address has changed from %q to %q, restarting MicroShift", c.NodeIPv6, currentIP)
-					os.Exit(1)
-					return nil
-				}
+			if settle == nil {
+				settle = time.After(sysConfigSettleDelay)
 			}
 
-			// Check the clock change by initiating an asynchronous read operation on the timer object
-			// When the clock is reset, the read operation returns with the ECANCELED error code
-			_, err = unix.Read(c.timerFd, buf)
-			if err == unix.ECANCELED {
-				// Take a snapshot of the current system and monototic clocks
-				stimeCur, mtimeCur := getSysMonTimes()
-
-				// Compare the elapsed time for the current and base references
-				// Verify that the time drift is in the allowed range
-				var stimeDiff = stimeCur - stimeRef
-				var mtimeDiff = mtimeCur - mtimeRef
-				var smtDiffDrift = stimeDiff - mtimeDiff
-				if math.Abs(float64(smtDiffDrift)) < sysConfigAllowedTimeDrift.Seconds() {
-					// Allow time adjustments when the drift is the predefined range
-					// This comes to prevent restarts when small time adjustments are performed by NTP
-					klog.Warningf("realtime clock change detected, time drifted %v seconds within the allowed range", smtDiffDrift)
-					// Update the base references to allow cumulative time adjustments to remain in the allowed range
-					stimeRef = stimeCur
-					mtimeRef = mtimeCur
-				} else {
-					klog.Warningf("realtime clock change detected, time drifted %v seconds, restarting MicroShift", smtDiffDrift)
-					os.Exit(0)
-					return nil
-				}
+		case <-settle:
+			settle = nil
+			c.checkNetwork(ctx)
+
+		case <-clockChanges:
+			// Take a snapshot of the current system and monototic clocks
+			stimeCur, mtimeCur := getSysMonTimes()
+
+			// Compare the elapsed time for the current and base references
+			// Verify that the time drift is in the allowed range
+			var stimeDiff = stimeCur - stimeRef
+			var mtimeDiff = mtimeCur - mtimeRef
+			var smtDiffDrift = stimeDiff - mtimeDiff
+			if math.Abs(float64(smtDiffDrift)) < sysConfigAllowedTimeDrift.Seconds() {
+				// Allow time adjustments when the drift is the predefined range
+				// This comes to prevent restarts when small time adjustments are performed by NTP
+				klog.Warningf("realtime clock change detected, time drifted %v seconds within the allowed range", smtDiffDrift)
+				// Update the base references to allow cumulative time adjustments to remain in the allowed range
+				stimeRef = stimeCur
+				mtimeRef = mtimeCur
+			} else {
+				klog.Warningf("realtime clock change detected, time drifted %v seconds, restarting MicroShift", smtDiffDrift)
+				os.Exit(0)
+				return nil
 			}
 
 		case <-ctx.Done():
@@ -165,3 +210,117 @@ func (c *SysConfWatchController) Run(ctx context.Context, ready chan<- struct{},
 		}
 	}
 }
+
+// watchClock notifies of the changes of the realtime clock: a read of the
+// timer fails with ECANCELED when the clock is reset.
+func (c *SysConfWatchController) watchClock() <-chan struct{} {
+	changes := make(chan struct{}, 1)
+	go func() {
+		var buf []byte = make([]byte, 8)
+		for {
+			_, err := unix.Read(c.timerFd, buf)
+			switch {
+			case errors.Is(err, unix.ECANCELED):
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			case err != nil && !errors.Is(err, unix.EINTR):
+				klog.Errorf("failed to watch the realtime clock: %v", err)
+				return
+			}
+		}
+	}()
+	return changes
+}
+
+// checkNetwork restarts MicroShift when the node IP or the host name
+// changed, and restarts the CoreDNS pods when the DNS settings did.
+func (c *SysConfWatchController) checkNetwork(ctx context.Context) {
+	// Check the IP change
+	currentIP, err := util.GetHostIP(c.userNodeIP, c.nodeIPPolicy)
+	if err != nil {
+		klog.Warningf("cannot find an host IP: %v", err)
+		os.Exit(1)
+	}
+	// The kubelet and the kube-apiserver run in the MicroShift process and
+	// take the node IP when they start, and neither can be stopped and
+	// started again in process, so a node IP change restarts MicroShift.
+	// The certificates and kubeconfigs are regenerated for the new IP on
+	// start, while the workload containers keep running in CRI-O.
+	if c.NodeIP != currentIP {
+		klog.Warningf("IP address has changed from %q to %q, restarting MicroShift", c.NodeIP, currentIP)
+		os.Exit(1)
+	}
+	// Dual stack case
+	if c.NodeIPv6 != "" {
+		currentIP, err = util.GetHostIPv6(c.userNodeIPv6)
+		if err != nil {
+			klog.Warningf("cannot find an host IP: %v", err)
+			os.Exit(1)
+		}
+		if c.NodeIPv6 != currentIP {
+			klog.Warningf("IP address has changed from %q to %q, restarting MicroShift", c.NodeIPv6, currentIP)
+			os.Exit(1)
+		}
+	}
+
+	// Check the host name change, the NodeName follows it on restart
+	if c.hostname != "" {
+		if hostname, err := os.Hostname(); err == nil && strings.ToLower(hostname) != c.hostname {
+			klog.Warningf("host name has changed from %q to %q, restarting MicroShift", c.hostname, hostname)
+			os.Exit(0)
+		}
+	}
+
+	// Check the DNS settings change
+	dnsConfig, err := readDNSConfig(c.resolvConf)
+	if err != nil {
+		klog.Warningf("failed to read host DNS configuration: %v", err)
+		return
+	}
+	if dnsConfig != c.dnsConfig {
+		klog.Warningf("host DNS configuration in %s has changed, restarting DNS pods", c.resolvConf)
+		if err := c.restartDNSPods(ctx); err != nil {
+			klog.Warningf("failed to restart DNS pods, retrying: %v", err)
+			return
+		}
+		c.dnsConfig = dnsConfig
+	}
+}
+
+func (c *SysConfWatchController) restartDNSPods(ctx context.Context) error {
+	restConfig, err := clientcmd.BuildConfigFromFlags("", c.kubeconfigPath)
+	if err != nil {
+		return err
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return client.CoreV1().Pods("openshift-dns").DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{LabelSelector: dnsPodsSelector})
+}
+
+// readDNSConfig returns the nameserver, search and options lines of a
+// resolv.conf file, ignoring comments.
+func readDNSConfig(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "nameserver", "search", "domain", "options":
+			lines = append(lines, strings.Join(fields, " "))
+		}
+	}
+	return strings.Join(lines, "\n"), scanner.Err()
+}
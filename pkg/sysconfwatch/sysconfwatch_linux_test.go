@@ -0,0 +1,36 @@
+package sysconfwatch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadDNSConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resolv.conf")
+	assert.NoError(t, os.WriteFile(path, []byte(`# Generated by NetworkManager
+search example.com
+nameserver 192.168.1.1
+
+nameserver  192.168.1.2
+`), 0644))
+
+	dnsConfig, err := readDNSConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "search example.com\nnameserver 192.168.1.1\nnameserver 192.168.1.2", dnsConfig)
+
+	// Only comments changed.
+	assert.NoError(t, os.WriteFile(path, []byte(`# Generated by systemd-resolved
+search example.com
+nameserver 192.168.1.1
+nameserver 192.168.1.2
+`), 0644))
+	unchanged, err := readDNSConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, dnsConfig, unchanged)
+
+	_, err = readDNSConfig(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
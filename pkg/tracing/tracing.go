@@ -0,0 +1,69 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/openshift/microshift/pkg/version"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/klog/v2"
+)
+
+const instrumentationName = "github.com/openshift/microshift"
+
+// Tracer returns the tracer of MicroShift. Its spans are dropped unless
+// Setup configured an exporter.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Setup exports the traces of MicroShift to cfg.Tracing.Endpoint. The
+// returned function exports the pending spans and stops the exporter, it is
+// a no-op when tracing is not configured.
+func Setup(ctx context.Context, cfg *config.Config) (func(context.Context), error) {
+	if cfg.Tracing.Endpoint == "" {
+		return func(context.Context) {}, nil
+	}
+
+	// The connection is established in the background, the collector does
+	// not need to be up yet.
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(cfg.Tracing.Endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the OTLP exporter for %q: %w", cfg.Tracing.Endpoint, err)
+	}
+	res := resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName("microshift"),
+		semconv.ServiceVersion(version.Get().String()),
+		semconv.HostName(cfg.CanonicalNodeName()),
+	)
+	ratio := float64(*cfg.Tracing.SamplingRatePerMillion) / 1000000
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	klog.Infof("Exporting traces to %s", cfg.Tracing.Endpoint)
+
+	return func(ctx context.Context) {
+		if err := provider.Shutdown(ctx); err != nil {
+			klog.Warningf("Failed to export the pending traces: %v", err)
+		}
+	}, nil
+}
+
+// EndSpan ends the span, recording err as its status.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
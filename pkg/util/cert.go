@@ -18,6 +18,7 @@ package util
 import (
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
@@ -39,6 +40,19 @@ func EnsureKeyPair(pubKeyPath, privKeyPath string) error {
 	return GenKeys(pubKeyPath, privKeyPath)
 }
 
+// ReloadingKeyPair returns a tls.Config GetCertificate loading the key pair
+// on every handshake, for a server to serve its certificate once rotated
+// without being restarted.
+func ReloadingKeyPair(certFile, keyFile string) func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		return &cert, nil
+	}
+}
+
 // GenKeys generates and save rsa keys
 func GenKeys(pubPath, keyPath string) error {
 	rsaKey, err := rsa.GenerateKey(rand.Reader, keySize)
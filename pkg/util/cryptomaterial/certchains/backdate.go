@@ -0,0 +1,202 @@
+package certchains
+
+import (
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/crypto"
+)
+
+// The library-go counterparts of the functions below make the certificates
+// valid from a second before their generation. These ones make them valid
+// from backdate before it instead, keeping their expiry, for them to be
+// usable on the hosts whose clock is set behind by up to backdate.
+
+// ensureCA is crypto.EnsureCA with the certificate backdated.
+func ensureCA(certFile, keyFile, serialFile, name string, expireDays int, backdate time.Duration) (*crypto.CA, error) {
+	if ca, err := crypto.GetCA(certFile, keyFile, serialFile); err == nil {
+		// The CA is issued by an external CA no longer configured otherwise.
+		if ca.Config.Certs[0].CheckSignatureFrom(ca.Config.Certs[0]) == nil {
+			return ca, nil
+		}
+		klog.Infof("Replacing CA %s, which is not self-signed", name)
+	}
+	if err := removeSignedCertificates(certFile); err != nil {
+		return nil, err
+	}
+	klog.V(2).Infof("Generating new CA for %s cert, and key in %s, %s", name, certFile, keyFile)
+
+	caConfig, err := crypto.UnsafeMakeSelfSignedCAConfigForDurationAtTime(
+		name,
+		func() time.Time { return time.Now().Add(-backdate) },
+		time.Duration(expireDays)*24*time.Hour+backdate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return writeCA(caConfig, certFile, keyFile, serialFile)
+}
+
+// ensureSubCA is crypto.(*CA).EnsureSubCA with the certificate backdated.
+func ensureSubCA(ca *crypto.CA, certFile, keyFile, serialFile, name string, expireDays int, backdate time.Duration) (*crypto.CA, error) {
+	if subCA, err := crypto.GetCA(certFile, keyFile, serialFile); err == nil {
+		// The issuer is another one after the external CA is changed.
+		if subCA.Config.Certs[0].CheckSignatureFrom(ca.Config.Certs[0]) == nil {
+			return subCA, nil
+		}
+		klog.Infof("Replacing CA %s, which is not issued by %s", name, ca.Config.Certs[0].Subject.CommonName)
+	}
+	if err := removeSignedCertificates(certFile); err != nil {
+		return nil, err
+	}
+	return makeAndWriteSubCA(ca, certFile, keyFile, serialFile, name, expireDays, backdate)
+}
+
+// removeSignedCertificates removes the directory of the CA certificate
+// certFile before the CA is generated, the certificates it holds being
+// signed by the previous CA.
+func removeSignedCertificates(certFile string) error {
+	if err := os.RemoveAll(filepath.Dir(certFile)); err != nil {
+		return fmt.Errorf("failed to remove the certificates of the previous CA: %w", err)
+	}
+	return nil
+}
+
+// makeAndWriteSubCA is crypto.(*CA).MakeAndWriteSubCA with the certificate
+// backdated.
+func makeAndWriteSubCA(ca *crypto.CA, certFile, keyFile, serialFile, name string, expireDays int, backdate time.Duration) (*crypto.CA, error) {
+	klog.V(4).Infof("Generating sub-CA certificate in %s, key in %s, serial in %s", certFile, keyFile, serialFile)
+
+	publicKey, privateKey, err := crypto.NewKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	rsaPublicKey, ok := publicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unexpected public key type %T", publicKey)
+	}
+	hash := sha1.New() //nolint:gosec
+	hash.Write(rsaPublicKey.N.Bytes())
+
+	now := time.Now()
+	// The CA does not outlive its issuer.
+	notAfter := now.Add(time.Duration(expireDays) * 24 * time.Hour)
+	if issuerNotAfter := ca.Config.Certs[0].NotAfter; issuerNotAfter.Before(notAfter) {
+		notAfter = issuerNotAfter
+	}
+	template := &x509.Certificate{
+		Subject:            pkix.Name{CommonName: name},
+		SignatureAlgorithm: x509.SHA256WithRSA,
+		NotBefore:          now.Add(-backdate - time.Second),
+		NotAfter:           notAfter,
+		// Overwritten by the serial generator of the issuer.
+		SerialNumber: big.NewInt(1),
+
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+
+		AuthorityKeyId: ca.Config.Certs[0].SubjectKeyId,
+		SubjectKeyId:   hash.Sum(nil),
+	}
+	cert, err := ca.SignCertificate(template, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	subCAConfig := &crypto.TLSCertificateConfig{
+		Certs: append([]*x509.Certificate{cert}, ca.Config.Certs...),
+		Key:   privateKey,
+	}
+	return writeCA(subCAConfig, certFile, keyFile, serialFile)
+}
+
+// writeCA writes the CA certificate and key, and initializes its serial
+// file.
+func writeCA(caConfig *crypto.TLSCertificateConfig, certFile, keyFile, serialFile string) (*crypto.CA, error) {
+	if err := caConfig.WriteCertConfigFile(certFile, keyFile); err != nil {
+		return nil, err
+	}
+
+	var serialGenerator crypto.SerialGenerator
+	if len(serialFile) > 0 {
+		// create / overwrite the serial file with a zero padded hex value (ending in a newline to have a valid file)
+		if err := os.WriteFile(serialFile, []byte("00\n"), 0600); err != nil {
+			return nil, err
+		}
+		var err error
+		serialGenerator, err = crypto.NewSerialFileGenerator(serialFile)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		serialGenerator = &crypto.RandomSerialGenerator{}
+	}
+
+	return &crypto.CA{
+		SerialGenerator: serialGenerator,
+		Config:          caConfig,
+	}, nil
+}
+
+// ensureClientCertificate is crypto.(*CA).EnsureClientCertificate with the
+// certificate backdated.
+func ensureClientCertificate(ca *crypto.CA, certFile, keyFile string, u user.Info, expireDays int, backdate time.Duration) (*crypto.TLSCertificateConfig, error) {
+	if certConfig, err := crypto.GetClientCertificate(certFile, keyFile, u); err == nil {
+		return certConfig, nil
+	}
+	klog.V(4).Infof("Generating client cert in %s and key in %s", certFile, keyFile)
+
+	publicKey, privateKey, err := crypto.NewKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	template := crypto.NewClientCertificateTemplateForDuration(crypto.UserToSubject(u), time.Duration(expireDays)*24*time.Hour, time.Now)
+	template.NotBefore = template.NotBefore.Add(-backdate)
+	cert, err := ca.SignCertificate(template, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	certConfig := &crypto.TLSCertificateConfig{Certs: []*x509.Certificate{cert}, Key: privateKey}
+	if err := certConfig.WriteCertConfigFile(certFile, keyFile); err != nil {
+		return nil, err
+	}
+	return crypto.GetTLSCertificateConfig(certFile, keyFile)
+}
+
+// ensureServerCert is crypto.(*CA).EnsureServerCert with the certificate
+// backdated.
+func ensureServerCert(ca *crypto.CA, certFile, keyFile string, hostnames sets.Set[string], expireDays int, backdate time.Duration) (*crypto.TLSCertificateConfig, error) {
+	if certConfig, err := crypto.GetServerCert(certFile, keyFile, hostnames); err == nil {
+		return certConfig, nil
+	}
+	klog.V(4).Infof("Generating server certificate in %s, key in %s", certFile, keyFile)
+
+	certConfig, err := ca.MakeServerCert(hostnames, expireDays, backdated(backdate))
+	if err != nil {
+		return nil, err
+	}
+	if err := certConfig.WriteCertConfigFile(certFile, keyFile); err != nil {
+		return nil, err
+	}
+	return certConfig, nil
+}
+
+// backdated returns the certificate extension backdating the certificate.
+func backdated(backdate time.Duration) crypto.CertificateExtensionFunc {
+	return func(certTemplate *x509.Certificate) error {
+		certTemplate.NotBefore = certTemplate.NotBefore.Add(-backdate)
+		return nil
+	}
+}
@@ -0,0 +1,64 @@
+package certchains
+
+import (
+	"crypto/x509"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+func TestCertificateChains_WithBackdate(t *testing.T) {
+	tmpDir := t.TempDir()
+	backdate := 2 * time.Hour
+
+	start := time.Now()
+	chains, err := NewCertificateChains(
+		NewCertificateSigner("root-signer", filepath.Join(tmpDir, "root-signer"), 10).
+			WithSubCAs(
+				NewCertificateSigner("sub-signer", filepath.Join(tmpDir, "root-signer", "sub-signer"), 10).
+					WithClientCertificates(&ClientCertificateSigningRequestInfo{
+						CSRMeta:  CSRMeta{Name: "client", ValidityDays: 5},
+						UserInfo: &user.DefaultInfo{Name: "client"},
+					}),
+			).
+			WithServingCertificates(&ServingCertificateSigningRequestInfo{
+				CSRMeta:   CSRMeta{Name: "server", ValidityDays: 5},
+				Hostnames: []string{"localhost"},
+			}).
+			WithPeerCertificiates(&PeerCertificateSigningRequestInfo{
+				CSRMeta:   CSRMeta{Name: "peer", ValidityDays: 5},
+				UserInfo:  &user.DefaultInfo{Name: "peer"},
+				Hostnames: []string{"localhost"},
+			}),
+	).WithBackdate(backdate).Complete()
+	require.NoError(t, err)
+	end := time.Now()
+
+	var walked int
+	require.NoError(t, chains.WalkChains(nil, func(certPath []string, c x509.Certificate) error {
+		walked++
+		// The certificates are backdated, without shortening their validity.
+		assert.False(t, c.NotBefore.After(start.Add(-backdate)), "%v is valid from %v", certPath, c.NotBefore)
+		assert.True(t, c.NotBefore.After(end.Add(-backdate-time.Minute)), "%v is valid from %v", certPath, c.NotBefore)
+		days := 5
+		if len(certPath) == 1 || certPath[len(certPath)-1] == "sub-signer" {
+			days = 10
+		}
+		assert.WithinRange(t, c.NotAfter, start.Add(time.Duration(days)*24*time.Hour).Truncate(time.Second), end.Add(time.Duration(days)*24*time.Hour))
+		return nil
+	}))
+	assert.Equal(t, 5, walked)
+
+	// Regenerated certificates are backdated too.
+	require.NoError(t, chains.Regenerate("root-signer", "sub-signer", "client"))
+	certPEM, _, err := chains.GetCertKey("root-signer", "sub-signer", "client")
+	require.NoError(t, err)
+	certs, err := crypto.CertsFromPEM(certPEM)
+	require.NoError(t, err)
+	assert.True(t, certs[0].NotBefore.Before(time.Now().Add(-backdate)))
+}
@@ -3,14 +3,21 @@ package certchains
 import (
 	"crypto/x509"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 
 	"github.com/openshift/microshift/pkg/util/cryptomaterial"
 )
 
 type CertificateChains struct {
+	// mu guards the certificates against being regenerated while they are
+	// read, as they are rotated while MicroShift runs.
+	mu      sync.RWMutex
 	signers map[string]*CertificateSigner
 }
 
@@ -36,6 +43,9 @@ func (cs *CertificateChains) GetSigner(signerPath ...string) *CertificateSigner
 }
 
 func (cs *CertificateChains) GetCertKey(certPath ...string) ([]byte, []byte, error) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
 	if len(certPath) == 0 {
 		return nil, nil, fmt.Errorf("empty certificate path")
 	}
@@ -53,6 +63,9 @@ func (cs *CertificateChains) GetCertKey(certPath ...string) ([]byte, []byte, err
 }
 
 func (cs *CertificateChains) Regenerate(certPath ...string) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
 	if signer := cs.GetSigner(certPath[0]); signer != nil {
 		return signer.Regenerate(certPath[1:]...)
 	}
@@ -63,11 +76,19 @@ func (cs *CertificateChains) Regenerate(certPath ...string) error {
 type CertWalkFunc func(certPath []string, c x509.Certificate) error
 
 // WalkChains traverses through the trust chain starting at `rootPath` and applies
-// `fn` on all the certificates in the chain tree
+// `fn` on all the certificates in the chain tree. `fn` must not regenerate
+// the certificates.
 func (cs *CertificateChains) WalkChains(rootPath []string, fn CertWalkFunc) error {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	return cs.walkChains(rootPath, fn)
+}
+
+func (cs *CertificateChains) walkChains(rootPath []string, fn CertWalkFunc) error {
 	if len(rootPath) == 0 {
 		for _, signerName := range cs.GetSignerNames() {
-			if err := cs.WalkChains([]string{signerName}, fn); err != nil {
+			if err := cs.walkChains([]string{signerName}, fn); err != nil {
 				return err
 			}
 		}
@@ -82,7 +103,7 @@ func (cs *CertificateChains) WalkChains(rootPath []string, fn CertWalkFunc) erro
 
 		nextNames := append(signer.GetSubCANames(), signer.GetCertNames()...)
 		for _, name := range nextNames {
-			if err := cs.WalkChains(append(rootPath, name), fn); err != nil {
+			if err := cs.walkChains(append(rootPath, name), fn); err != nil {
 				return err
 			}
 		}
@@ -104,6 +125,17 @@ func (cs *CertificateChains) WalkChains(rootPath []string, fn CertWalkFunc) erro
 	return fmt.Errorf("a non-leaf fragment of the path '%v' either is not a signer or it doesn't exist", rootPath)
 }
 
+// RotationDate returns when the certificate is rotated while MicroShift runs:
+// 4 months before it expires when it is short-lived, 12 months otherwise.
+func RotationDate(c *x509.Certificate) time.Time {
+	const month = 30 * time.Hour * 24
+
+	if cryptomaterial.IsCertShortLived(c) {
+		return c.NotAfter.Add(-4 * month)
+	}
+	return c.NotAfter.Add(-12 * month)
+}
+
 func WhenToRotateAtEarliest(cs *CertificateChains) ([]string, time.Time, error) {
 	var (
 		certPath     []string
@@ -111,13 +143,8 @@ func WhenToRotateAtEarliest(cs *CertificateChains) ([]string, time.Time, error)
 	)
 
 	err := cs.WalkChains(nil, func(currentPath []string, c x509.Certificate) error {
-		const month = 30 * time.Hour * 24
-
-		rotateAt := c.NotAfter.Add(-4 * month)
-		if !cryptomaterial.IsCertShortLived(&c) {
-			rotateAt = c.NotAfter.Add(-12 * month)
-		}
-		klog.Errorf("%v rotate at: %s", currentPath, rotateAt.String())
+		rotateAt := RotationDate(&c)
+		klog.V(4).Infof("%v rotate at: %s", currentPath, rotateAt.String())
 
 		if rotationDate.IsZero() {
 			rotationDate = rotateAt
@@ -135,3 +162,24 @@ func WhenToRotateAtEarliest(cs *CertificateChains) ([]string, time.Time, error)
 
 	return certPath, rotationDate, err
 }
+
+// CertsToRotate returns the paths of the certificates due for rotation at
+// the given time, but the ones named in skip. The certificates signed by a
+// signer due for rotation are left out, as regenerating the signer
+// regenerates them too.
+func CertsToRotate(cs *CertificateChains, at time.Time, skip sets.Set[string]) ([][]string, error) {
+	var due [][]string
+	err := cs.WalkChains(nil, func(certPath []string, c x509.Certificate) error {
+		if at.Before(RotationDate(&c)) || skip.Has(strings.Join(certPath, "/")) {
+			return nil
+		}
+		for _, p := range due {
+			if len(p) < len(certPath) && slices.Equal(p, certPath[:len(p)]) {
+				return nil
+			}
+		}
+		due = append(due, slices.Clone(certPath))
+		return nil
+	})
+	return due, err
+}
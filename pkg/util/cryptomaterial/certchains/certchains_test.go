@@ -9,6 +9,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/util/diff"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/authentication/user"
 )
 
@@ -224,3 +225,32 @@ func TestWhenToRotateAtEarliest(t *testing.T) {
 
 	require.True(t, time.Now().Add(4*30*24*time.Hour).Before(rotationTime) && time.Now().Add(7*30*24*time.Hour).After(rotationTime), "the rotate time is at %s", rotationTime.String())
 }
+
+func TestCertsToRotate(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	testChain := testChains(t, tmpDir)
+
+	due, err := CertsToRotate(testChain, time.Now(), nil)
+	require.NoError(t, err)
+	require.Empty(t, due)
+
+	due, err = CertsToRotate(testChain, time.Now().Add(200*24*time.Hour), nil)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"test-signer1", "test-signer1-subca", "test-signer1-subca-too", "test-signer1-subca-too-too", "subca-too-too-test-client2"}}, due)
+
+	// The certificates of a signer due for rotation are regenerated with it.
+	due, err = CertsToRotate(testChain, time.Now().Add(4*365*24*time.Hour), nil)
+	require.NoError(t, err)
+	require.Contains(t, due, []string{"test-signer3"})
+	require.Contains(t, due, []string{"test-signer1", "test-signer1-subca", "test-signer1-subca-too", "test-signer1-subca-too-too2"})
+	for _, certPath := range due {
+		require.False(t, len(certPath) > 1 && certPath[0] == "test-signer3", "%v is rotated with its signer", certPath)
+	}
+
+	// The certificates of a skipped signer are rotated on their own.
+	due, err = CertsToRotate(testChain, time.Now().Add(4*365*24*time.Hour), sets.New("test-signer3"))
+	require.NoError(t, err)
+	require.NotContains(t, due, []string{"test-signer3"})
+	require.Contains(t, due, []string{"test-signer3", "test-signer3-server1"})
+}
@@ -0,0 +1,134 @@
+package certchains
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/crypto"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/microshift/pkg/util/cryptomaterial"
+)
+
+// CertificateInfo describes a certificate of the certificate chains.
+type CertificateInfo struct {
+	// Name is the path of the certificate in the certificate chains,
+	// e.g. "kube-control-plane-signer/kube-scheduler".
+	Name      string    `json:"name"`
+	Subject   string    `json:"subject"`
+	IsCA      bool      `json:"isCA"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+	SANs      []string  `json:"sans,omitempty"`
+}
+
+// ListCertificates returns all of the certificates of the chains, in the
+// order they are walked.
+func ListCertificates(cs *CertificateChains) ([]CertificateInfo, error) {
+	certs := []CertificateInfo{}
+	err := cs.WalkChains(nil, func(certPath []string, c x509.Certificate) error {
+		certs = append(certs, certificateInfo(certPath, &c))
+		return nil
+	})
+	return certs, err
+}
+
+// ListCertificateFiles returns the certificates of the chains of the builder
+// found on disk, in the same order as ListCertificates. Unlike completing the
+// chains, it neither generates nor modifies any file: the certificates that
+// do not exist yet are left out.
+func ListCertificateFiles(b CertificateChainsBuilder) ([]CertificateInfo, error) {
+	chains, ok := b.(*certificateChains)
+	if !ok {
+		return nil, fmt.Errorf("unsupported certificate chains builder %T", b)
+	}
+	certs := []CertificateInfo{}
+	for _, signer := range sortedSigners(chains.signers) {
+		if err := listSignerFiles(&certs, nil, signer); err != nil {
+			return nil, err
+		}
+	}
+	return certs, nil
+}
+
+func listSignerFiles(certs *[]CertificateInfo, parentPath []string, b CertificateSignerBuilder) error {
+	signer, ok := b.(*certificateSigner)
+	if !ok {
+		return fmt.Errorf("unsupported certificate signer builder %T", b)
+	}
+	signerPath := append(append([]string{}, parentPath...), signer.signerName)
+	if err := appendCertificateFile(certs, signerPath, cryptomaterial.CACertPath(signer.signerDir)); err != nil {
+		return err
+	}
+	for _, subCA := range sortedSigners(signer.subCAs) {
+		if err := listSignerFiles(certs, signerPath, subCA); err != nil {
+			return err
+		}
+	}
+
+	signInfos := append([]CSRInfo{}, signer.certificatesToSign...)
+	sort.Slice(signInfos, func(i, j int) bool { return signInfos[i].GetMeta().Name < signInfos[j].GetMeta().Name })
+	for _, signInfo := range signInfos {
+		certDir := filepath.Join(signer.signerDir, signInfo.GetMeta().Name)
+		var certFile string
+		switch signInfo.(type) {
+		case *ClientCertificateSigningRequestInfo:
+			certFile = cryptomaterial.ClientCertPath(certDir)
+		case *ServingCertificateSigningRequestInfo:
+			certFile = cryptomaterial.ServingCertPath(certDir)
+		case *PeerCertificateSigningRequestInfo:
+			certFile = cryptomaterial.PeerCertPath(certDir)
+		default:
+			return fmt.Errorf("unknown CSR info type: %T", signInfo)
+		}
+		if err := appendCertificateFile(certs, append(signerPath, signInfo.GetMeta().Name), certFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendCertificateFile appends the first certificate of certFile, if it
+// exists, to certs.
+func appendCertificateFile(certs *[]CertificateInfo, certPath []string, certFile string) error {
+	pemBytes, err := os.ReadFile(certFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	parsed, err := crypto.CertsFromPEM(pemBytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", certFile, err)
+	}
+	*certs = append(*certs, certificateInfo(certPath, parsed[0]))
+	return nil
+}
+
+func sortedSigners(signers []CertificateSignerBuilder) []CertificateSignerBuilder {
+	sorted := append([]CertificateSignerBuilder{}, signers...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name() < sorted[j].Name() })
+	return sorted
+}
+
+func certificateInfo(certPath []string, c *x509.Certificate) CertificateInfo {
+	// library-go stores IP SANs in both the DNS names and the IP
+	// addresses, drop the duplicates.
+	sans := sets.New(c.DNSNames...)
+	for _, ip := range c.IPAddresses {
+		sans.Insert(ip.String())
+	}
+	return CertificateInfo{
+		Name:      strings.Join(certPath, "/"),
+		Subject:   c.Subject.String(),
+		IsCA:      c.IsCA,
+		NotBefore: c.NotBefore,
+		NotAfter:  c.NotAfter,
+		SANs:      sets.List(sans),
+	}
+}
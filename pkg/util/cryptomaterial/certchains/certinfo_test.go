@@ -0,0 +1,61 @@
+package certchains
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+func testCertChains(dir string) CertificateChainsBuilder { //nolint:ireturn
+	return NewCertificateChains(
+		NewCertificateSigner("test-signer", filepath.Join(dir, "test-signer"), 10).
+			WithClientCertificates(&ClientCertificateSigningRequestInfo{
+				CSRMeta:  CSRMeta{Name: "test-client", ValidityDays: 1},
+				UserInfo: &user.DefaultInfo{Name: "test-user"},
+			}).
+			WithServingCertificates(&ServingCertificateSigningRequestInfo{
+				CSRMeta:   CSRMeta{Name: "test-server", ValidityDays: 1},
+				Hostnames: []string{"test.example.com", "10.0.0.1"},
+			}),
+	)
+}
+
+func TestListCertificates(t *testing.T) {
+	dir := t.TempDir()
+	chains, err := testCertChains(dir).Complete()
+	require.NoError(t, err)
+
+	certs, err := ListCertificates(chains)
+	require.NoError(t, err)
+
+	names := []string{}
+	for _, c := range certs {
+		names = append(names, c.Name)
+		if c.Name == "test-signer/test-server" {
+			assert.ElementsMatch(t, []string{"test.example.com", "10.0.0.1"}, c.SANs)
+		}
+	}
+	assert.Equal(t, []string{"test-signer", "test-signer/test-client", "test-signer/test-server"}, names)
+	assert.True(t, certs[0].IsCA)
+}
+
+func TestListCertificateFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	certs, err := ListCertificateFiles(testCertChains(dir))
+	require.NoError(t, err)
+	assert.Empty(t, certs)
+	assert.NoDirExists(t, filepath.Join(dir, "test-signer"), "listing must not generate certificates")
+
+	chains, err := testCertChains(dir).Complete()
+	require.NoError(t, err)
+	want, err := ListCertificates(chains)
+	require.NoError(t, err)
+
+	certs, err = ListCertificateFiles(testCertChains(dir))
+	require.NoError(t, err)
+	assert.Equal(t, want, certs)
+}
@@ -3,11 +3,16 @@ package certchains
 import (
 	"fmt"
 	"os"
+	"time"
+
+	"github.com/openshift/library-go/pkg/crypto"
 )
 
 type CertificateChainsBuilder interface {
 	WithSigners(signers ...CertificateSignerBuilder) CertificateChainsBuilder
 	WithCABundle(bundlePath string, signerNames ...[]string) CertificateChainsBuilder
+	WithBackdate(backdate time.Duration) CertificateChainsBuilder
+	WithIssuer(issuer *crypto.CA) CertificateChainsBuilder
 	Complete() (*CertificateChains, error)
 }
 
@@ -17,6 +22,13 @@ type certificateChains struct {
 	// fileBundles maps fileName -> signers, where fileName is the filename of a CA bundle
 	// where PEM certificates should be stored
 	fileBundles map[string][][]string
+
+	// backdate is how long the certificates of all the signers are valid
+	// from before they are generated.
+	backdate time.Duration
+	// issuer is the external CA issuing the root CAs of the signers, which
+	// are self-signed when nil.
+	issuer *crypto.CA
 }
 
 //nolint:ireturn
@@ -40,6 +52,25 @@ func (cs *certificateChains) WithCABundle(bundlePath string, signerNames ...[]st
 	return cs
 }
 
+// WithBackdate makes the certificates of all the signers valid from backdate
+// before they are generated.
+//
+//nolint:ireturn
+func (cs *certificateChains) WithBackdate(backdate time.Duration) CertificateChainsBuilder {
+	cs.backdate = backdate
+	return cs
+}
+
+// WithIssuer makes the external CA issuer issue the root CAs of the signers
+// instead of them being self-signed, for all of the certificates to chain to
+// it.
+//
+//nolint:ireturn
+func (cs *certificateChains) WithIssuer(issuer *crypto.CA) CertificateChainsBuilder {
+	cs.issuer = issuer
+	return cs
+}
+
 //nolint:ireturn
 func (cs *certificateChains) Complete() (*CertificateChains, error) {
 	completeChains := &CertificateChains{
@@ -68,7 +99,7 @@ func (cs *certificateChains) Complete() (*CertificateChains, error) {
 			return nil, fmt.Errorf("signer name clash: %s", signer.Name())
 		}
 
-		completedSigner, err := signer.Complete()
+		completedSigner, err := signer.WithBackdate(cs.backdate).WithIssuer(cs.issuer).Complete()
 		if err != nil {
 			return nil, fmt.Errorf("failed to complete signer %q: %w", signer.Name(), err)
 		}
@@ -3,6 +3,7 @@ package certchains
 import (
 	"crypto/x509"
 	"encoding/pem"
+	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
@@ -144,3 +145,86 @@ func pemToCert(t *testing.T, certPEM []byte) *x509.Certificate {
 func breakTestCertPath(testPath string) []string {
 	return strings.Split(testPath, "/")
 }
+
+func Test_certificateChains_WithIssuer(t *testing.T) {
+	tmpDir := t.TempDir()
+	bundlePath := filepath.Join(tmpDir, "bundle.crt")
+
+	newIssuer := func(name string) *crypto.CA {
+		dir := filepath.Join(t.TempDir(), name)
+		ca, err := crypto.MakeSelfSignedCA(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key"), "", name, 30)
+		require.NoError(t, err)
+		return ca
+	}
+	complete := func(issuer *crypto.CA) *CertificateChains {
+		chains, err := NewCertificateChains(
+			NewCertificateSigner("signer", filepath.Join(tmpDir, "signer"), 10).
+				WithServingCertificates(&ServingCertificateSigningRequestInfo{
+					CSRMeta:   CSRMeta{Name: "server", ValidityDays: 5},
+					Hostnames: []string{"localhost"},
+				}),
+		).WithCABundle(bundlePath, []string{"signer"}).WithIssuer(issuer).Complete()
+		require.NoError(t, err)
+		return chains
+	}
+	// verify verifies the serving certificate, with the chain it is stored
+	// with, against root.
+	verify := func(chains *CertificateChains, root *x509.Certificate) error {
+		certPEM, _, err := chains.GetCertKey("signer", "server")
+		require.NoError(t, err)
+		certs, err := crypto.CertsFromPEM(certPEM)
+		require.NoError(t, err)
+		roots, intermediates := x509.NewCertPool(), x509.NewCertPool()
+		roots.AddCert(root)
+		for _, c := range certs[1:] {
+			intermediates.AddCert(c)
+		}
+		_, err = certs[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates, DNSName: "localhost"})
+		return err
+	}
+	bundleSubjects := func() []string {
+		bundlePEM, err := os.ReadFile(bundlePath)
+		require.NoError(t, err)
+		certs, err := crypto.CertsFromPEM(bundlePEM)
+		require.NoError(t, err)
+		subjects := []string{}
+		for _, c := range certs {
+			subjects = append(subjects, c.Subject.CommonName+" by "+c.Issuer.CommonName)
+		}
+		return subjects
+	}
+
+	selfSigned := complete(nil)
+	selfSignedRoot := selfSigned.GetSigner("signer").signerConfig.Config.Certs[0]
+	require.NoError(t, verify(selfSigned, selfSignedRoot))
+
+	// The signer and its certificates are replaced to chain to the issuer.
+	issuer := newIssuer("external-ca")
+	issued := complete(issuer)
+	require.NoError(t, verify(issued, issuer.Config.Certs[0]))
+	require.Error(t, verify(issued, selfSignedRoot))
+	require.Equal(t, []string{"signer by external-ca"}, bundleSubjects())
+
+	// They are kept while the issuer is unchanged, and regenerated by it.
+	signerPEM, err := issued.GetSigner("signer").GetSignerCertPEM()
+	require.NoError(t, err)
+	issued = complete(issuer)
+	samePEM, err := issued.GetSigner("signer").GetSignerCertPEM()
+	require.NoError(t, err)
+	require.Equal(t, signerPEM, samePEM)
+	require.NoError(t, issued.Regenerate("signer"))
+	require.NoError(t, verify(issued, issuer.Config.Certs[0]))
+	regeneratedPEM, err := issued.GetSigner("signer").GetSignerCertPEM()
+	require.NoError(t, err)
+	require.NotEqual(t, signerPEM, regeneratedPEM)
+
+	// Another issuer replaces them again.
+	otherIssuer := newIssuer("other-external-ca")
+	require.NoError(t, verify(complete(otherIssuer), otherIssuer.Config.Certs[0]))
+	require.Equal(t, []string{"signer by other-external-ca"}, bundleSubjects())
+
+	// And they are self-signed again without issuer.
+	selfSigned = complete(nil)
+	require.NoError(t, verify(selfSigned, selfSigned.GetSigner("signer").signerConfig.Config.Certs[0]))
+	require.Equal(t, []string{"signer by signer"}, bundleSubjects())
+}
@@ -0,0 +1,98 @@
+package certchains
+
+import (
+	gocrypto "crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/library-go/pkg/crypto"
+)
+
+// EnsureKeyCA returns the self-signed CA of key, which is kept out of
+// MicroShift like in a PKCS#11 token, with its certificate in certFile. The
+// certificate is renewed with the same key and subject within renewBefore of
+// its expiry, for the certificates it issued to stay valid.
+func EnsureKeyCA(certFile string, key gocrypto.Signer, name string, validityDays int, renewBefore, backdate time.Duration) (*crypto.CA, error) {
+	rsaPublicKey, ok := key.Public().(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unexpected public key type %T", key.Public())
+	}
+
+	if certs, err := certsFromFile(certFile); err == nil {
+		cert := certs[0]
+		now := time.Now()
+		switch {
+		case !rsaPublicKey.Equal(cert.PublicKey):
+			klog.Infof("Replacing CA %s, which is not of the key", name)
+		case now.Before(cert.NotBefore) || now.Add(renewBefore).After(cert.NotAfter):
+			klog.Infof("Renewing CA %s, valid until %s", name, cert.NotAfter.Format(time.RFC3339))
+		default:
+			return keyCA(cert, key), nil
+		}
+	}
+	klog.V(2).Infof("Generating CA certificate for %s in %s", name, certFile)
+
+	hash := sha1.New() //nolint:gosec
+	hash.Write(rsaPublicKey.N.Bytes())
+	now := time.Now()
+	template := &x509.Certificate{
+		Subject:            pkix.Name{CommonName: name},
+		SignatureAlgorithm: x509.SHA256WithRSA,
+		NotBefore:          now.Add(-backdate - time.Second),
+		NotAfter:           now.Add(time.Duration(validityDays) * 24 * time.Hour),
+		SerialNumber:       big.NewInt(now.UnixNano()),
+
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+
+		SubjectKeyId: hash.Sum(nil),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, rsaPublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign CA %s: %w", name, err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	certPEM, err := crypto.EncodeCertificates(cert)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(certFile), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(certFile, certPEM, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write CA certificate %s: %w", certFile, err)
+	}
+	return keyCA(cert, key), nil
+}
+
+func certsFromFile(certFile string) ([]*x509.Certificate, error) {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.CertsFromPEM(certPEM)
+}
+
+// keyCA returns the CA of cert and key, whose serials are random not to
+// collide with the ones issued before its certificate was renewed.
+func keyCA(cert *x509.Certificate, key gocrypto.Signer) *crypto.CA {
+	return &crypto.CA{
+		Config:          &crypto.TLSCertificateConfig{Certs: []*x509.Certificate{cert}, Key: key},
+		SerialGenerator: &crypto.RandomSerialGenerator{},
+	}
+}
@@ -0,0 +1,47 @@
+package certchains
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureKeyCA(t *testing.T) {
+	certFile := filepath.Join(t.TempDir(), "root-ca", "ca.crt")
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ca, err := EnsureKeyCA(certFile, key, "root-ca", 10, 24*time.Hour, time.Hour)
+	require.NoError(t, err)
+	root := ca.Config.Certs[0]
+	assert.NoError(t, root.CheckSignatureFrom(root))
+	assert.True(t, root.NotBefore.Before(time.Now().Add(-time.Hour)))
+
+	subCA, err := makeAndWriteSubCA(ca, filepath.Join(t.TempDir(), "ca.crt"), filepath.Join(t.TempDir(), "ca.key"), "", "signer", 5, 0)
+	require.NoError(t, err)
+
+	// The certificate is kept while it is not due for renewal.
+	ca, err = EnsureKeyCA(certFile, key, "root-ca", 10, 24*time.Hour, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, root.SerialNumber, ca.Config.Certs[0].SerialNumber)
+
+	// The renewed certificate is of the same key, the certificates it issued
+	// stay valid.
+	ca, err = EnsureKeyCA(certFile, key, "root-ca", 10, 20*24*time.Hour, time.Hour)
+	require.NoError(t, err)
+	renewed := ca.Config.Certs[0]
+	assert.NotEqual(t, root.SerialNumber, renewed.SerialNumber)
+	assert.NoError(t, subCA.Config.Certs[0].CheckSignatureFrom(renewed))
+
+	// The certificate of another key is replaced.
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ca, err = EnsureKeyCA(certFile, otherKey, "root-ca", 10, 24*time.Hour, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, otherKey.PublicKey.Equal(ca.Config.Certs[0].PublicKey))
+}
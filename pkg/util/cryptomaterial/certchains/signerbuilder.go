@@ -2,6 +2,8 @@ package certchains
 
 import (
 	"fmt"
+	"time"
+
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/openshift/library-go/pkg/crypto"
@@ -23,6 +25,8 @@ type CertificateSignerBuilder interface {
 	WithServingCertificates(signInfos ...*ServingCertificateSigningRequestInfo) CertificateSignerBuilder
 	WithPeerCertificiates(signInfos ...*PeerCertificateSigningRequestInfo) CertificateSignerBuilder
 	WithCABundlePaths(bundlePath ...string) CertificateSignerBuilder
+	WithBackdate(backdate time.Duration) CertificateSignerBuilder
+	WithIssuer(issuer *crypto.CA) CertificateSignerBuilder
 	Complete() (*CertificateSigner, error)
 }
 
@@ -30,6 +34,12 @@ type certificateSigner struct {
 	signerName         string
 	signerDir          string
 	signerValidityDays int
+	// backdate is how long the certificates are valid from before they
+	// are generated.
+	backdate time.Duration
+	// issuer is the external CA issuing the root CA, which is self-signed
+	// when nil.
+	issuer *crypto.CA
 
 	// signerConfig should only be used in case this is a sub-ca signer
 	// It should be populated during CertificateSigner.SignSubCA()
@@ -72,6 +82,25 @@ func (s *certificateSigner) WithCABundlePaths(bundlePaths ...string) Certificate
 	return s
 }
 
+// WithBackdate makes the certificates valid from backdate before they are
+// generated, for them to be usable on the hosts whose clock is set behind.
+//
+//nolint:ireturn
+func (s *certificateSigner) WithBackdate(backdate time.Duration) CertificateSignerBuilder {
+	s.backdate = backdate
+	return s
+}
+
+// WithIssuer makes the external CA issuer issue the CA of the signer
+// instead of it being self-signed. It does not apply to sub-CAs, which are
+// issued by their signer.
+//
+//nolint:ireturn
+func (s *certificateSigner) WithIssuer(issuer *crypto.CA) CertificateSignerBuilder {
+	s.issuer = issuer
+	return s
+}
+
 //nolint:ireturn
 func (s *certificateSigner) WithClientCertificates(signInfos ...*ClientCertificateSigningRequestInfo) CertificateSignerBuilder {
 	for _, signInfo := range signInfos {
@@ -102,18 +131,28 @@ func (s *certificateSigner) WithSubCAs(subCAsInfo ...CertificateSignerBuilder) C
 	return s
 }
 
+// ensureSignerCA returns the root CA of signerDir, issued by issuer or
+// self-signed when nil.
+func ensureSignerCA(issuer *crypto.CA, signerDir, name string, validityDays int, backdate time.Duration) (*crypto.CA, error) {
+	if issuer != nil {
+		return ensureIssuedCA(issuer, signerDir, name, validityDays, backdate)
+	}
+	return ensureCA(
+		cryptomaterial.CACertPath(signerDir),
+		cryptomaterial.CAKeyPath(signerDir),
+		cryptomaterial.CASerialsPath(signerDir),
+		name,
+		validityDays,
+		backdate,
+	)
+}
+
 func (s *certificateSigner) Complete() (*CertificateSigner, error) {
 	// in case this is a sub-ca, it's already going to have the signer-config populated
 	signerConfig := s.signerConfig
 	if signerConfig == nil {
 		var err error
-		signerConfig, _, err = crypto.EnsureCA(
-			cryptomaterial.CACertPath(s.signerDir),
-			cryptomaterial.CAKeyPath(s.signerDir),
-			cryptomaterial.CASerialsPath(s.signerDir),
-			s.signerName,
-			s.signerValidityDays,
-		)
+		signerConfig, err = ensureSignerCA(s.issuer, s.signerDir, s.signerName, s.signerValidityDays, s.backdate)
 
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate %s CA certificate: %w", s.signerName, err)
@@ -124,6 +163,8 @@ func (s *certificateSigner) Complete() (*CertificateSigner, error) {
 		signerName:         s.signerName,
 		signerDir:          s.signerDir,
 		signerValidityDays: s.signerValidityDays,
+		backdate:           s.backdate,
+		issuer:             s.issuer,
 		signerConfig:       signerConfig,
 
 		subCAs:             make(map[string]*CertificateSigner),
@@ -6,12 +6,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"time"
 
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/authentication/user"
-	"k8s.io/klog/v2"
 
 	"github.com/openshift/library-go/pkg/crypto"
 	"github.com/openshift/microshift/pkg/util/cryptomaterial"
@@ -54,6 +54,12 @@ type CertificateSigner struct {
 	signerConfig       *crypto.CA
 	signerDir          string
 	signerValidityDays int
+	// backdate is how long the certificates are valid from before they
+	// are generated.
+	backdate time.Duration
+	// issuer is the external CA issuing the root CA, which is self-signed
+	// when nil.
+	issuer *crypto.CA
 
 	subCAs             map[string]*CertificateSigner
 	signedCertificates map[string]*signedCertificateInfo
@@ -74,7 +80,7 @@ func (s *CertificateSigner) GetSignerCertPEM() ([]byte, error) {
 func (s *CertificateSigner) Regenerate(certPath ...string) error {
 	switch len(certPath) {
 	case 0: // renew ourselves and all our sub-certs
-		if len(s.signerConfig.Config.Certs) == 1 {
+		if s.issuer != nil || len(s.signerConfig.Config.Certs) == 1 {
 			// this is a root CA, not an intermediary, regen the TLS config
 			if err := s.regenerateSelf(); err != nil {
 				return fmt.Errorf("failed to regenerate CA %q: %v", s.signerName, err)
@@ -114,14 +120,7 @@ func (s *CertificateSigner) regenerateSelf() error {
 		return fmt.Errorf("failed to regenerate CA %q: %v", s.signerName, err)
 	}
 
-	signerConfig, _, err := crypto.EnsureCA(
-		cryptomaterial.CACertPath(s.signerDir),
-		cryptomaterial.CAKeyPath(s.signerDir),
-		cryptomaterial.CASerialsPath(s.signerDir),
-		s.signerName,
-		s.signerValidityDays,
-	)
-
+	signerConfig, err := ensureSignerCA(s.issuer, s.signerDir, s.signerName, s.signerValidityDays, s.backdate)
 	if err != nil {
 		return fmt.Errorf("failed to regenerate %s CA certificate: %w", s.signerName, err)
 	}
@@ -167,69 +166,66 @@ func (s *CertificateSigner) regenerateCertificate(certName string) error {
 }
 
 func (s *CertificateSigner) AddToBundles(bundlePaths ...string) error {
-	cert := s.signerConfig.Config.Certs[0]
-
 	for _, bundlePath := range bundlePaths {
-		bundlePEMs, err := os.ReadFile(bundlePath)
-		if err != nil && !os.IsNotExist(err) {
+		if err := AddToBundle(bundlePath, s.signerConfig.Config.Certs[0]); err != nil {
 			return err
 		}
+		s.caBundlePaths.Insert(bundlePath)
+	}
 
-		certs := []*x509.Certificate{}
-		if len(bundlePEMs) > 0 {
-			certs, err = crypto.CertsFromPEM(bundlePEMs)
-			if err != nil {
-				return err
-			}
-		}
-
-		var certsChanged, certFound bool
-		for i, c := range certs {
-			if c.Subject.String() == cert.Subject.String() && c.Issuer.String() == cert.Issuer.String() {
-				certFound = true
-				if c.SerialNumber != cert.SerialNumber {
-					certs[i] = cert
-					certsChanged = true
-				}
-				break
-			}
-		}
-
-		if certFound {
-			if !certsChanged {
-				continue
-			}
-		} else {
-			certs = append(certs, cert)
-		}
+	return nil
+}
 
-		// make sure the parent directory exists
-		if err := os.MkdirAll(filepath.Dir(bundlePath), os.FileMode(0755)); err != nil {
-			return err
-		}
+// AddToBundle adds the certificates to the CA bundle, replacing the ones with
+// the same subject.
+func AddToBundle(bundlePath string, newCerts ...*x509.Certificate) error {
+	bundlePEMs, err := os.ReadFile(bundlePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
 
-		certFileWriter, err := os.OpenFile(bundlePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	certs := []*x509.Certificate{}
+	if len(bundlePEMs) > 0 {
+		certs, err = crypto.CertsFromPEM(bundlePEMs)
 		if err != nil {
 			return err
 		}
-		defer certFileWriter.Close()
+	}
 
-		bytes, err := crypto.EncodeCertificates(certs...)
-		if err != nil {
-			return err
-		}
-		if _, err := certFileWriter.Write(bytes); err != nil {
-			return err
+	for _, cert := range newCerts {
+		// The issuer is not compared, as it changes when the signers are
+		// issued by an external CA.
+		i := slices.IndexFunc(certs, func(c *x509.Certificate) bool { return c.Subject.String() == cert.Subject.String() })
+		if i >= 0 {
+			certs[i] = cert
+		} else {
+			certs = append(certs, cert)
 		}
+	}
 
-		s.caBundlePaths.Insert(bundlePath)
+	// make sure the parent directory exists
+	if err := os.MkdirAll(filepath.Dir(bundlePath), os.FileMode(0755)); err != nil {
+		return err
 	}
 
-	return nil
+	certFileWriter, err := os.OpenFile(bundlePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer certFileWriter.Close()
+
+	bytes, err := crypto.EncodeCertificates(certs...)
+	if err != nil {
+		return err
+	}
+	_, err = certFileWriter.Write(bytes)
+	return err
 }
 
 func (s *CertificateSigner) toBuilder() CertificateSignerBuilder { //nolint:ireturn
-	signer := NewCertificateSigner(s.signerName, s.signerDir, s.signerValidityDays)
+	signer := NewCertificateSigner(s.signerName, s.signerDir, s.signerValidityDays).
+		WithBackdate(s.backdate).
+		WithIssuer(s.issuer)
 
 	for _, subCA := range s.subCAs {
 		signer = signer.WithSubCAs(subCA.toBuilder())
@@ -270,34 +266,14 @@ func (s *CertificateSigner) SignSubCA(subSignerInfo CertificateSignerBuilder) er
 	subSignerName := subSignerInfo.Name()
 	subSignerDir := subSignerInfo.Directory()
 
-	subCA, _, err := libraryGoEnsureSubCA(
-		s.signerConfig,
-		cryptomaterial.CABundlePath(subSignerDir),
-		cryptomaterial.CAKeyPath(subSignerDir),
-		cryptomaterial.CASerialsPath(subSignerDir),
-		subSignerName,
-		subSignerInfo.ValidityDays(),
-	)
+	subCA, err := ensureIssuedCA(s.signerConfig, subSignerDir, subSignerName, subSignerInfo.ValidityDays(), s.backdate)
 	if err != nil {
 		return fmt.Errorf("failed to generate sub-CA %q: %w", subSignerName, err)
 	}
 
-	// the library code above writes the whole cert chain in files but some of
-	// the kube code requires a single cert per signer cert file
-	subCACertPath := cryptomaterial.CACertPath(subSignerDir)
-	if _, err := os.Stat(subCACertPath); err == nil || os.IsNotExist(err) {
-		certPEM, err := crypto.EncodeCertificates(subCA.Config.Certs[0])
-		if err != nil {
-			return fmt.Errorf("failed to encode sub-CA %q certs to pem: %w", subSignerName, err)
-		}
-
-		if err := os.WriteFile(subCACertPath, certPEM, os.FileMode(0644)); err != nil {
-			return fmt.Errorf("failed to write certificate for sub-CA %q: %w", subSignerName, err)
-		}
-	}
-
 	subCertSigner, err := subSignerInfo.
 		WithSignerConfig(subCA).
+		WithBackdate(s.backdate).
 		Complete()
 	if err != nil {
 		return err
@@ -307,14 +283,48 @@ func (s *CertificateSigner) SignSubCA(subSignerInfo CertificateSignerBuilder) er
 	return nil
 }
 
+// ensureIssuedCA returns the CA of signerDir issued by issuer, with its
+// chain in its CA bundle.
+func ensureIssuedCA(issuer *crypto.CA, signerDir, name string, validityDays int, backdate time.Duration) (*crypto.CA, error) {
+	ca, err := ensureSubCA(
+		issuer,
+		cryptomaterial.CABundlePath(signerDir),
+		cryptomaterial.CAKeyPath(signerDir),
+		cryptomaterial.CASerialsPath(signerDir),
+		name,
+		validityDays,
+		backdate,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// the library code above writes the whole cert chain in files but some of
+	// the kube code requires a single cert per signer cert file
+	caCertPath := cryptomaterial.CACertPath(signerDir)
+	if _, err := os.Stat(caCertPath); err == nil || os.IsNotExist(err) {
+		certPEM, err := crypto.EncodeCertificates(ca.Config.Certs[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode certs to pem: %w", err)
+		}
+
+		if err := os.WriteFile(caCertPath, certPEM, os.FileMode(0644)); err != nil {
+			return nil, fmt.Errorf("failed to write certificate: %w", err)
+		}
+	}
+	return ca, nil
+}
+
 func (s *CertificateSigner) SignClientCertificate(signInfo *ClientCertificateSigningRequestInfo) error {
 	certDir := filepath.Join(s.signerDir, signInfo.Name)
 
-	tlsConfig, _, err := s.signerConfig.EnsureClientCertificate(
+	tlsConfig, err := ensureClientCertificate(
+		s.signerConfig,
 		cryptomaterial.ClientCertPath(certDir),
 		cryptomaterial.ClientKeyPath(certDir),
 		signInfo.UserInfo,
 		signInfo.ValidityDays,
+		s.backdate,
 	)
 
 	if err != nil {
@@ -331,11 +341,13 @@ func (s *CertificateSigner) SignClientCertificate(signInfo *ClientCertificateSig
 func (s *CertificateSigner) SignServingCertificate(signInfo *ServingCertificateSigningRequestInfo) error {
 	certDir := filepath.Join(s.signerDir, signInfo.Name)
 
-	tlsConfig, _, err := s.signerConfig.EnsureServerCert(
+	tlsConfig, err := ensureServerCert(
+		s.signerConfig,
 		cryptomaterial.ServingCertPath(certDir),
 		cryptomaterial.ServingKeyPath(certDir),
 		sets.New[string](signInfo.Hostnames...),
 		signInfo.ValidityDays,
+		s.backdate,
 	)
 
 	if err != nil {
@@ -370,6 +382,7 @@ func (s *CertificateSigner) SignPeerCertificate(signInfo *PeerCertificateSigning
 
 			return nil
 		},
+		backdated(s.backdate),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to generate peer certificate for %q: %w", signInfo.Name, err)
@@ -432,51 +445,6 @@ func signedCertificateInfoMapKeysOrdered(stringMap map[string]*signedCertificate
 	return keys
 }
 
-// libraryGoEnsureSubCA comes from lib-go 4.12, use (ca *CA) EnsureSubCA from there once we get the updated lib-go
-func libraryGoEnsureSubCA(ca *crypto.CA, certFile, keyFile, serialFile, name string, expireDays int) (*crypto.CA, bool, error) {
-	if subCA, err := crypto.GetCA(certFile, keyFile, serialFile); err == nil {
-		return subCA, false, nil
-	}
-	subCA, err := libraryGoMakeAndWriteSubCA(ca, certFile, keyFile, serialFile, name, expireDays)
-	return subCA, true, err
-}
-
-// lilibraryGoMakeAndWriteSubCA comes from lib-go 4.12, use (ca *CA) MakeAndWriteSubCA from there once we get the updated lib-go
-func libraryGoMakeAndWriteSubCA(ca *crypto.CA, certFile, keyFile, serialFile, name string, expireDays int) (*crypto.CA, error) {
-	klog.V(4).Infof("Generating sub-CA certificate in %s, key in %s, serial in %s", certFile, keyFile, serialFile)
-
-	subCAConfig, err := crypto.MakeCAConfigForDuration(name, time.Duration(expireDays)*time.Hour*24, ca)
-	if err != nil {
-		return nil, err
-	}
-
-	if err := subCAConfig.WriteCertConfigFile(certFile, keyFile); err != nil {
-		return nil, err
-	}
-
-	var serialGenerator crypto.SerialGenerator
-	if len(serialFile) > 0 {
-		// create / overwrite the serial file with a zero padded hex value (ending in a newline to have a valid file)
-		if err := os.WriteFile(serialFile, []byte("00\n"), 0600); err != nil {
-			return nil, err
-		}
-
-		serialGenerator, err = crypto.NewSerialFileGenerator(serialFile)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		serialGenerator = &crypto.RandomSerialGenerator{}
-	}
-
-	subCA := &crypto.CA{
-		Config:          subCAConfig,
-		SerialGenerator: serialGenerator,
-	}
-
-	return subCA, nil
-}
-
 type sortedForDER []string
 
 func (s sortedForDER) Len() int {
@@ -74,6 +74,12 @@ func AdminKubeconfigClientCertDir(certsDir string) string {
 	return filepath.Join(AdminKubeconfigSignerDir(certsDir), "admin-kubeconfig-client")
 }
 
+// AdminKubeconfigIssuedDir returns the directory keeping the client
+// certificates minted by "microshift kubeconfig", to find the ones to revoke.
+func AdminKubeconfigIssuedDir(certsDir string) string {
+	return filepath.Join(AdminKubeconfigSignerDir(certsDir), "issued")
+}
+
 // KubeletCSRSignerSignerCertDir returns path to the signer that signs kubelet CSRs
 // and the signer that signs CSRs of the CSR API
 func KubeletCSRSignerSignerCertDir(certsDir string) string {
@@ -152,6 +158,12 @@ func KubeAPIServerServiceNetworkServingCertDir(certsDir string) string {
 	return filepath.Join(KubeAPIServerServiceNetworkSigner(certsDir), "kube-apiserver-service-network-serving")
 }
 
+// TokenRootCADir returns the directory of the certificate of the root CA
+// whose key is in a PKCS#11 token.
+func TokenRootCADir(certsDir string) string {
+	return filepath.Join(certsDir, "pkcs11-root-ca")
+}
+
 // TotalClientCABundlePath returns the path to the cert bundle with all client certificate signers
 func TotalClientCABundlePath(certsDir string) string {
 	return filepath.Join(certsDir, "ca-bundle", "client-ca.crt")
@@ -0,0 +1,146 @@
+// Package pkcs11 keeps private keys in a PKCS#11 token, like a TPM 2.0
+// through tpm2-pkcs11, using the pkcs11-tool command of OpenSC. The keys
+// never leave the token, which signs with them.
+package pkcs11
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	// pinEnv passes the PIN to pkcs11-tool, not to show it in the command
+	// line of the process.
+	pinEnv = "MICROSHIFT_PKCS11_PIN"
+
+	commandTimeout = time.Minute
+)
+
+// toolCommand is the pkcs11-tool binary, overridden in tests.
+var toolCommand = "pkcs11-tool"
+
+// The DER prefixes of the DigestInfo of the digests signed with the RSA-PKCS
+// mechanism, which pads the data but does not hash it, from RFC 8017.
+var digestInfoPrefixes = map[crypto.Hash][]byte{
+	crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
+
+// Token is a PKCS#11 token.
+type Token struct {
+	// Module is the path of the PKCS#11 module of the token.
+	Module string
+	// Label is the label of the token.
+	Label string
+	// PINFile is the path of the file holding the user PIN of the token.
+	PINFile string
+}
+
+// Signer is an RSA key of a token, signing with the PKCS #1 v1.5 scheme.
+type Signer struct {
+	token     Token
+	label     string
+	publicKey *rsa.PublicKey
+}
+
+var _ crypto.Signer = &Signer{}
+
+// EnsureKey returns the RSA key labelled label in the token, generating it
+// when generate is true and the token does not hold it.
+func (t Token) EnsureKey(label string, generate bool) (*Signer, error) {
+	publicKey, err := t.readPublicKey(label)
+	if err == nil {
+		return &Signer{token: t, label: label, publicKey: publicKey}, nil
+	}
+	if !generate {
+		return nil, err
+	}
+
+	if _, err := t.run(nil, "--keypairgen", "--key-type", "rsa:2048", "--usage-sign", "--label", label); err != nil {
+		return nil, fmt.Errorf("failed to generate key %q in token %q: %w", label, t.Label, err)
+	}
+	publicKey, err = t.readPublicKey(label)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{token: t, label: label, publicKey: publicKey}, nil
+}
+
+func (t Token) readPublicKey(label string) (*rsa.PublicKey, error) {
+	der, err := t.run(nil, "--read-object", "--type", "pubkey", "--label", label)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the public key %q of token %q: %w", label, t.Label, err)
+	}
+	// Older versions of pkcs11-tool write the PKCS #1 public key instead of
+	// the SubjectPublicKeyInfo.
+	if key, err := x509.ParsePKIXPublicKey(der); err == nil {
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("key %q of token %q is not an RSA key", label, t.Label)
+		}
+		return rsaKey, nil
+	}
+	key, err := x509.ParsePKCS1PublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the public key %q of token %q: %w", label, t.Label, err)
+	}
+	return key, nil
+}
+
+// run runs pkcs11-tool logged in the token, with stdin as its input, and
+// returns its output.
+func (t Token) run(stdin []byte, args ...string) ([]byte, error) {
+	pin, err := os.ReadFile(t.PINFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the PIN of token %q: %w", t.Label, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	args = append([]string{"--module", t.Module, "--token-label", t.Label, "--login", "--pin", "env:" + pinEnv}, args...)
+	cmd := exec.CommandContext(ctx, toolCommand, args...)
+	cmd.Env = append(os.Environ(), pinEnv+"="+strings.TrimSpace(string(pin)))
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w: %s", toolCommand, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// Public returns the public key of the key.
+func (s *Signer) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+// Sign signs the digest with the key in the token.
+func (s *Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if _, ok := opts.(*rsa.PSSOptions); ok {
+		return nil, errors.New("RSA-PSS signatures are not supported")
+	}
+	prefix, ok := digestInfoPrefixes[opts.HashFunc()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported hash function %v", opts.HashFunc())
+	}
+	if len(digest) != opts.HashFunc().Size() {
+		return nil, fmt.Errorf("digest of %d bytes for %v", len(digest), opts.HashFunc())
+	}
+
+	signature, err := s.token.run(append(bytes.Clone(prefix), digest...), "--sign", "--mechanism", "RSA-PKCS", "--label", s.label)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign with key %q of token %q: %w", s.label, s.token.Label, err)
+	}
+	return signature, nil
+}
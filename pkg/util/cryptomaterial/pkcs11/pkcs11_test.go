@@ -0,0 +1,81 @@
+package pkcs11
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeToken makes pkcs11-tool a script keeping the key in a file, using
+// openssl.
+func fakeToken(t *testing.T) Token {
+	if _, err := exec.LookPath("openssl"); err != nil {
+		t.Skip("openssl is not available")
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "pkcs11-tool")
+	script := `#!/bin/sh
+echo "$@" >> ` + dir + `/args
+[ "$` + pinEnv + `" = "1234" ] || { echo "wrong PIN" >&2; exit 1; }
+key=` + dir + `/key.pem
+case " $* " in
+*" --keypairgen "*) openssl genrsa -out "$key" 2048 2>/dev/null ;;
+*" --read-object "*) [ -f "$key" ] || { echo "object not found" >&2; exit 1; }; openssl rsa -in "$key" -pubout -outform DER 2>/dev/null ;;
+*" --sign "*) openssl pkeyutl -sign -inkey "$key" ;;
+esac
+`
+	require.NoError(t, os.WriteFile(bin, []byte(script), 0700))
+	tool := toolCommand
+	t.Cleanup(func() { toolCommand = tool })
+	toolCommand = bin
+
+	pinFile := filepath.Join(dir, "pin")
+	require.NoError(t, os.WriteFile(pinFile, []byte("1234\n"), 0600))
+	return Token{Module: "/usr/lib64/pkcs11/libtpm2_pkcs11.so", Label: "microshift", PINFile: pinFile}
+}
+
+func TestToken_EnsureKey(t *testing.T) {
+	token := fakeToken(t)
+
+	_, err := token.EnsureKey("root-ca", false)
+	assert.ErrorContains(t, err, "object not found")
+
+	signer, err := token.EnsureKey("root-ca", true)
+	require.NoError(t, err)
+
+	// The signatures of the key are valid for its public key.
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "root-ca"},
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, signer.Public(), signer)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	assert.NoError(t, cert.CheckSignatureFrom(cert))
+
+	// The key is generated once.
+	again, err := token.EnsureKey("root-ca", true)
+	require.NoError(t, err)
+	assert.True(t, signer.publicKey.Equal(again.Public()))
+
+	// The PIN is not on the command line.
+	args, err := os.ReadFile(filepath.Join(filepath.Dir(token.PINFile), "args"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(args), "1234")
+	assert.Contains(t, string(args), "--token-label microshift --login --pin env:"+pinEnv)
+}
@@ -29,6 +29,18 @@ func KubeConfigWithClientCerts(
 	clientCertPEM []byte,
 	clientKeyPEM []byte,
 ) error {
+	kubeConfig := NewKubeConfigWithClientCerts(clusterURL, clusterTrustBundle, clientCertPEM, clientKeyPEM)
+	return clientcmd.WriteToFile(kubeConfig, path)
+}
+
+// NewKubeConfigWithClientCerts returns an in-memory kubeconfig authenticating
+// with client cert/key against the cluster at `clusterURL`
+func NewKubeConfigWithClientCerts(
+	clusterURL string,
+	clusterTrustBundle []byte,
+	clientCertPEM []byte,
+	clientKeyPEM []byte,
+) clientcmdapi.Config {
 	const microshiftName = "microshift"
 
 	cluster := clientcmdapi.NewCluster()
@@ -47,12 +59,10 @@ func KubeConfigWithClientCerts(
 	msUser.ClientCertificateData = clientCertPEM
 	msUser.ClientKeyData = clientKeyPEM
 
-	kubeConfig := clientcmdapi.Config{
+	return clientcmdapi.Config{
 		CurrentContext: microshiftName,
 		Clusters:       map[string]*clientcmdapi.Cluster{microshiftName: cluster},
 		Contexts:       map[string]*clientcmdapi.Context{microshiftName: msContext},
 		AuthInfos:      map[string]*clientcmdapi.AuthInfo{"user": msUser},
 	}
-
-	return clientcmd.WriteToFile(kubeConfig, path)
 }
@@ -22,6 +22,7 @@ import (
 	tcpnet "net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"time"
@@ -38,10 +39,38 @@ var previousGatewayIP string = ""
 // on this host.
 var foundHardCodedNodeIP bool
 
-func GetHostIP(nodeIP string) (string, error) {
+// NodeIPPolicy filters and orders the addresses of the host the node IP is
+// chosen from when it is not configured.
+type NodeIPPolicy struct {
+	// Interfaces are shell patterns of the interface names allowed, all
+	// interfaces are allowed when empty.
+	Interfaces []string
+	// ExcludeInterfaces are shell patterns of the interface names denied.
+	ExcludeInterfaces []string
+	// PreferredSubnets are CIDRs whose addresses are preferred, in order.
+	PreferredSubnets []string
+	// PreferIPv6 prefers IPv6 addresses over IPv4 ones.
+	PreferIPv6 bool
+}
+
+// IsZero returns whether the policy is empty, in which case the address of
+// the interface of the default route is used.
+func (p NodeIPPolicy) IsZero() bool {
+	return len(p.Interfaces) == 0 && len(p.ExcludeInterfaces) == 0 && len(p.PreferredSubnets) == 0 && !p.PreferIPv6
+}
+
+func GetHostIP(nodeIP string, policy NodeIPPolicy) (string, error) {
 	var hostIP string
 	var err error
 
+	if nodeIP == "" && !policy.IsZero() {
+		hostIP, err = selectIPByPolicy(policy)
+		if err != nil {
+			return "", err
+		}
+		goto found
+	}
+
 	if nodeIP != "" {
 		if !foundHardCodedNodeIP {
 			foundHardCodedNodeIP = true
@@ -193,6 +222,174 @@ func selectIPFromHostInterface(nodeIP string) (string, error) {
 	return "", fmt.Errorf("no interface with valid address found on host")
 }
 
+type nodeIPCandidate struct {
+	ip        tcpnet.IP
+	iface     string
+	isDefault bool
+}
+
+// selectIPByPolicy chooses the best address of the host according to policy.
+func selectIPByPolicy(policy NodeIPPolicy) (string, error) {
+	ifaces, err := tcpnet.Interfaces()
+	if err != nil {
+		return "", err
+	}
+
+	// Addresses of the interfaces of the default routes are preferred when
+	// the policy does not tell the candidates apart.
+	defaultIPs := map[string]bool{}
+	if ip, err := net.ChooseHostInterface(); err == nil {
+		defaultIPs[ip.String()] = true
+	}
+	if ip, err := GetHostIPv6(""); err == nil {
+		defaultIPs[ip] = true
+	}
+
+	candidates := []nodeIPCandidate{}
+	for _, i := range ifaces {
+		if i.Flags&tcpnet.FlagUp == 0 || i.Flags&tcpnet.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := i.Addrs()
+		if err != nil {
+			klog.Warningf("failed to get IPs for interface %s: %v", i.Name, err)
+			continue
+		}
+		for _, addr := range addrs {
+			ip, _, err := tcpnet.ParseCIDR(addr.String())
+			if err != nil {
+				return "", fmt.Errorf("unable to parse CIDR for interface %q: %s", i.Name, err)
+			}
+			candidates = append(candidates, nodeIPCandidate{ip: ip, iface: i.Name, isDefault: defaultIPs[ip.String()]})
+		}
+	}
+
+	ip, err := chooseNodeIP(candidates, policy)
+	if err != nil {
+		return "", err
+	}
+	return ip.String(), nil
+}
+
+// chooseNodeIP returns the candidate allowed by policy with, in order, the
+// first preferred subnet, the preferred IP family and a default route.
+// Candidates ranking the same are kept in the order of the host.
+func chooseNodeIP(candidates []nodeIPCandidate, policy NodeIPPolicy) (tcpnet.IP, error) {
+	subnets := make([]*tcpnet.IPNet, 0, len(policy.PreferredSubnets))
+	for _, cidr := range policy.PreferredSubnets {
+		_, subnet, err := tcpnet.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid preferred subnet %q: %w", cidr, err)
+		}
+		subnets = append(subnets, subnet)
+	}
+	rank := func(c nodeIPCandidate) []int {
+		subnet := len(subnets)
+		for i, s := range subnets {
+			if s.Contains(c.ip) {
+				subnet = i
+				break
+			}
+		}
+		family := 0
+		if (c.ip.To4() == nil) != policy.PreferIPv6 {
+			family = 1
+		}
+		route := 1
+		if c.isDefault {
+			route = 0
+		}
+		return []int{subnet, family, route}
+	}
+
+	allowed := []nodeIPCandidate{}
+	for _, c := range candidates {
+		if c.ip.IsLoopback() || c.ip.IsLinkLocalUnicast() || c.ip.IsLinkLocalMulticast() {
+			continue
+		}
+		if !InterfaceSelected(c.iface, policy.Interfaces, policy.ExcludeInterfaces) {
+			continue
+		}
+		allowed = append(allowed, c)
+	}
+	if len(allowed) == 0 {
+		return nil, fmt.Errorf("no interface with a valid address allowed by the node IP selection policy found on host")
+	}
+	sort.SliceStable(allowed, func(i, j int) bool {
+		ri, rj := rank(allowed[i]), rank(allowed[j])
+		for k := range ri {
+			if ri[k] != rj[k] {
+				return ri[k] < rj[k]
+			}
+		}
+		return false
+	})
+	return allowed[0].ip, nil
+}
+
+// InterfaceSelected reports whether the interface name matches one of the
+// interfaces shell patterns, or any name when there are none, and none of
+// the excludeInterfaces patterns.
+func InterfaceSelected(name string, interfaces, excludeInterfaces []string) bool {
+	if len(interfaces) != 0 && !matchesAny(name, interfaces) {
+		return false
+	}
+	return !matchesAny(name, excludeInterfaces)
+}
+
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveAddresses returns the networks of entries holding IP addresses,
+// CIDRs or interface names, the addresses of the interfaces being resolved
+// at the time of the call. The interfaces missing are skipped.
+func ResolveAddresses(entries []string) ([]*tcpnet.IPNet, error) {
+	networks := make([]*tcpnet.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		if ip := tcpnet.ParseIP(entry); ip != nil {
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			networks = append(networks, &tcpnet.IPNet{IP: ip, Mask: tcpnet.CIDRMask(bits, bits)})
+			continue
+		}
+		if _, network, err := tcpnet.ParseCIDR(entry); err == nil {
+			networks = append(networks, network)
+			continue
+		}
+		link, err := netlink.LinkByName(entry)
+		if _, ok := err.(netlink.LinkNotFoundError); ok {
+			klog.Warningf("Interface %q not found, skipping its addresses", entry)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		addrs, err := netlink.AddrList(link, netlink.FAMILY_ALL)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			if addr.IP.IsLinkLocalUnicast() {
+				continue
+			}
+			bits := 32
+			if addr.IP.To4() == nil {
+				bits = 128
+			}
+			networks = append(networks, &tcpnet.IPNet{IP: addr.IP, Mask: tcpnet.CIDRMask(bits, bits)})
+		}
+	}
+	return networks, nil
+}
+
 // ContainIPANetwork - will check if given IP address contained within list of networks
 func ContainIPANetwork(ip tcpnet.IP, networks []string) bool {
 	for _, netStr := range networks {
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"net"
 	"os"
 	"testing"
 
@@ -49,3 +50,77 @@ func TestAddToNoProxyEnv_with_both(t *testing.T) {
 	assert.Equal(t, "", os.Getenv("no_proxy"), "no_proxy expected to be empty")
 	clearNoProxy()
 }
+
+func TestChooseNodeIP(t *testing.T) {
+	candidates := []nodeIPCandidate{
+		{ip: net.ParseIP("fe80::1"), iface: "eth0"},
+		{ip: net.ParseIP("192.168.1.10"), iface: "eth0", isDefault: true},
+		{ip: net.ParseIP("2001:db8::10"), iface: "eth0"},
+		{ip: net.ParseIP("10.0.0.10"), iface: "eth1"},
+		{ip: net.ParseIP("172.16.0.10"), iface: "wwan0"},
+	}
+
+	tests := []struct {
+		name      string
+		policy    NodeIPPolicy
+		expected  string
+		expectErr bool
+	}{
+		{
+			name:     "default-route",
+			policy:   NodeIPPolicy{},
+			expected: "192.168.1.10",
+		},
+		{
+			name:     "interface-allowlist",
+			policy:   NodeIPPolicy{Interfaces: []string{"eth1", "wwan*"}},
+			expected: "10.0.0.10",
+		},
+		{
+			name:     "interface-denylist",
+			policy:   NodeIPPolicy{ExcludeInterfaces: []string{"eth0"}},
+			expected: "10.0.0.10",
+		},
+		{
+			name:     "preferred-subnets",
+			policy:   NodeIPPolicy{PreferredSubnets: []string{"172.16.0.0/12", "10.0.0.0/8"}},
+			expected: "172.16.0.10",
+		},
+		{
+			name:     "preferred-subnets-filtered",
+			policy:   NodeIPPolicy{PreferredSubnets: []string{"172.16.0.0/12", "10.0.0.0/8"}, ExcludeInterfaces: []string{"wwan*"}},
+			expected: "10.0.0.10",
+		},
+		{
+			name:     "prefer-ipv6",
+			policy:   NodeIPPolicy{PreferIPv6: true},
+			expected: "2001:db8::10",
+		},
+		{
+			name:      "nothing-allowed",
+			policy:    NodeIPPolicy{Interfaces: []string{"br*"}},
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, err := chooseNodeIP(candidates, tt.policy)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, ip.String())
+		})
+	}
+}
+
+func TestResolveAddresses(t *testing.T) {
+	networks, err := ResolveAddresses([]string{"192.168.10.5", "10.10.0.0/24", "2001:db8::5", "does-not-exist0"})
+	assert.NoError(t, err)
+	got := []string{}
+	for _, network := range networks {
+		got = append(got, network.String())
+	}
+	assert.Equal(t, []string{"192.168.10.5/32", "10.10.0.0/24", "2001:db8::5/128"}, got)
+}
@@ -0,0 +1,74 @@
+package virtualip
+
+import (
+	"bytes"
+	"net"
+	"time"
+)
+
+const (
+	// advertInterval is the interval of the advertisements, as the default
+	// one of VRRP.
+	advertInterval = time.Second
+	// peerDownInterval is the time without advertisement after which the
+	// peer is considered down, three advertisements plus a skew.
+	peerDownInterval = 3*advertInterval + advertInterval/2
+)
+
+// advert is sent by each node of the pair on every advertInterval.
+type advert struct {
+	// Address is the virtual IP, for the nodes to ignore a peer
+	// configured for another one.
+	Address string `json:"address"`
+	// Priority is 0 when the node is unhealthy or stopping, for the
+	// peer to take over right away.
+	Priority int  `json:"priority"`
+	Master   bool `json:"master"`
+}
+
+// election decides which node of the pair holds the virtual IP, like VRRP
+// with preemption: the healthy node with the highest priority, or with the
+// highest IP address on a tie.
+type election struct {
+	priority int
+	ip       net.IP
+	peerIP   net.IP
+
+	peer     advert
+	peerSeen time.Time
+}
+
+// observe records an advertisement of the peer received at now.
+func (e *election) observe(a advert, now time.Time) {
+	e.peer = a
+	e.peerSeen = now
+}
+
+// peerAlive returns whether an advertisement of the peer was received
+// recently enough at now.
+func (e *election) peerAlive(now time.Time) bool {
+	return !e.peerSeen.IsZero() && now.Sub(e.peerSeen) < peerDownInterval
+}
+
+// effectivePriority is the priority advertised, 0 when unhealthy.
+func (e *election) effectivePriority(healthy bool) int {
+	if !healthy {
+		return 0
+	}
+	return e.priority
+}
+
+// master returns whether the node should hold the virtual IP at now.
+func (e *election) master(now time.Time, healthy bool) bool {
+	priority := e.effectivePriority(healthy)
+	if priority == 0 {
+		return false
+	}
+	if !e.peerAlive(now) || e.peer.Priority == 0 {
+		return true
+	}
+	if priority != e.peer.Priority {
+		return priority > e.peer.Priority
+	}
+	return bytes.Compare(e.ip.To16(), e.peerIP.To16()) > 0
+}
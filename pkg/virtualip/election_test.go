@@ -0,0 +1,42 @@
+package virtualip
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElection(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		priority int
+		ip       string
+		healthy  bool
+		peer     *advert
+		peerSeen time.Duration
+		want     bool
+	}{
+		{name: "alone and healthy", priority: 100, ip: "192.0.2.10", healthy: true, want: true},
+		{name: "alone and unhealthy", priority: 100, ip: "192.0.2.10", healthy: false, want: false},
+		{name: "higher priority", priority: 150, ip: "192.0.2.10", healthy: true, peer: &advert{Priority: 100, Master: true}, want: true},
+		{name: "lower priority", priority: 50, ip: "192.0.2.10", healthy: true, peer: &advert{Priority: 100}, want: false},
+		{name: "peer resigned", priority: 50, ip: "192.0.2.10", healthy: true, peer: &advert{Priority: 0, Master: true}, want: true},
+		{name: "peer down", priority: 50, ip: "192.0.2.10", healthy: true, peer: &advert{Priority: 100, Master: true}, peerSeen: 4 * time.Second, want: true},
+		{name: "peer alive within the down interval", priority: 50, ip: "192.0.2.10", healthy: true, peer: &advert{Priority: 100, Master: true}, peerSeen: 3 * time.Second, want: false},
+		{name: "tie won by the highest IP", priority: 100, ip: "192.0.2.12", healthy: true, peer: &advert{Priority: 100}, want: true},
+		{name: "tie lost by the lowest IP", priority: 100, ip: "192.0.2.10", healthy: true, peer: &advert{Priority: 100}, want: false},
+		{name: "unhealthy with a higher priority", priority: 150, ip: "192.0.2.10", healthy: false, peer: &advert{Priority: 100}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := election{priority: tt.priority, ip: net.ParseIP(tt.ip), peerIP: net.ParseIP("192.0.2.11")}
+			if tt.peer != nil {
+				e.observe(*tt.peer, now.Add(-tt.peerSeen))
+			}
+			assert.Equal(t, tt.want, e.master(now, tt.healthy))
+		})
+	}
+}
@@ -0,0 +1,252 @@
+// Package virtualip holds the virtual IP address of an active/passive pair of
+// MicroShift nodes on the healthy one. Both nodes advertise their priority to
+// each other over UDP, in the manner of VRRP, and the one elected adds the
+// virtual IP to its interface, where the API server and the router serve it.
+package virtualip
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"time"
+
+	"github.com/openshift/microshift/pkg/config"
+	"github.com/vishvananda/netlink"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// healthTimeout bounds the health check of the API server on each
+	// advertisement.
+	healthTimeout = advertInterval / 2
+	maxAdvertSize = 1024
+)
+
+// Manager takes part in the election of the holder of the virtual IP.
+type Manager struct {
+	cfg      *config.Config
+	address  net.IP
+	election election
+}
+
+func NewManager(cfg *config.Config) *Manager {
+	return &Manager{
+		cfg:     cfg,
+		address: net.ParseIP(cfg.VirtualIP.Address),
+		election: election{
+			priority: cfg.VirtualIP.Priority,
+			ip:       net.ParseIP(cfg.Node.NodeIP),
+			peerIP:   net.ParseIP(cfg.VirtualIP.Peer),
+		},
+	}
+}
+
+func (m *Manager) Name() string           { return "virtual-ip-manager" }
+func (m *Manager) Dependencies() []string { return []string{"kube-apiserver"} }
+
+func (m *Manager) Run(ctx context.Context, ready chan<- struct{}, stopped chan<- struct{}) error {
+	defer close(stopped)
+
+	link, err := m.link()
+	if err != nil {
+		return err
+	}
+	addr := &netlink.Addr{IPNet: &net.IPNet{IP: m.address, Mask: net.CIDRMask(hostPrefix(m.address), hostPrefix(m.address))}}
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", m.cfg.KubeConfigPath(config.KubeAdmin))
+	if err != nil {
+		return fmt.Errorf("failed to load the kubeconfig: %w", err)
+	}
+	restConfig.Timeout = healthTimeout
+	client, err := kubernetes.NewForConfig(rest.AddUserAgent(restConfig, m.Name()))
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{Port: m.cfg.VirtualIP.Port})
+	if err != nil {
+		return fmt.Errorf("failed to listen on UDP port %d: %w", m.cfg.VirtualIP.Port, err)
+	}
+	defer conn.Close()
+	peerAddr := &net.UDPAddr{IP: m.election.peerIP, Port: m.cfg.VirtualIP.Port}
+
+	adverts := make(chan advert)
+	go m.receive(ctx, conn, adverts)
+
+	// A virtual IP left over by a previous run is only kept once elected.
+	master := false
+	if err := m.release(link, addr); err != nil {
+		klog.Warningf("Failed to remove the virtual IP %s: %v", m.address, err)
+	}
+
+	klog.InfoS("Virtual IP manager started", "address", m.address, "interface", link.Attrs().Name, "peer", m.election.peerIP, "priority", m.election.priority)
+	close(ready)
+
+	ticker := time.NewTicker(advertInterval)
+	defer ticker.Stop()
+	healthy := false
+	for {
+		select {
+		case <-ctx.Done():
+			// Resign for the peer to take over without waiting for the
+			// advertisements to time out.
+			m.send(conn, peerAddr, advert{Address: m.address.String(), Priority: 0})
+			if master {
+				if err := m.release(link, addr); err != nil {
+					klog.Warningf("Failed to remove the virtual IP %s: %v", m.address, err)
+				}
+			}
+			return ctx.Err()
+		case a := <-adverts:
+			m.election.observe(a, time.Now())
+		case <-ticker.C:
+			healthy = apiServerHealthy(ctx, client)
+			m.send(conn, peerAddr, advert{Address: m.address.String(), Priority: m.election.effectivePriority(healthy), Master: master})
+		}
+
+		elected := m.election.master(time.Now(), healthy)
+		if elected == master {
+			continue
+		}
+		if elected {
+			klog.InfoS("Taking over the virtual IP", "address", m.address, "healthy", healthy, "peerAlive", m.election.peerAlive(time.Now()), "peerPriority", m.election.peer.Priority)
+			if err := m.acquire(link, addr); err != nil {
+				klog.Errorf("Failed to add the virtual IP %s: %v", m.address, err)
+				continue
+			}
+		} else {
+			klog.InfoS("Releasing the virtual IP", "address", m.address, "healthy", healthy, "peerPriority", m.election.peer.Priority)
+			if err := m.release(link, addr); err != nil {
+				klog.Errorf("Failed to remove the virtual IP %s: %v", m.address, err)
+				continue
+			}
+		}
+		master = elected
+	}
+}
+
+// receive forwards the advertisements of the peer to adverts.
+func (m *Manager) receive(ctx context.Context, conn *net.UDPConn, adverts chan<- advert) {
+	buf := make([]byte, maxAdvertSize)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+				klog.Errorf("Failed to receive virtual IP advertisement: %v", err)
+				continue
+			}
+			return
+		}
+		if !from.IP.Equal(m.election.peerIP) {
+			klog.V(2).Infof("Ignoring virtual IP advertisement from %s, which is not the peer", from)
+			continue
+		}
+		a := advert{}
+		if err := json.Unmarshal(buf[:n], &a); err != nil {
+			klog.Warningf("Invalid virtual IP advertisement from %s: %v", from, err)
+			continue
+		}
+		if a.Address != m.address.String() {
+			klog.Warningf("Ignoring advertisement of the peer %s for the virtual IP %s instead of %s", from, a.Address, m.address)
+			continue
+		}
+		select {
+		case adverts <- a:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Manager) send(conn *net.UDPConn, to *net.UDPAddr, a advert) {
+	data, err := json.Marshal(a)
+	if err != nil {
+		klog.Errorf("Failed to encode virtual IP advertisement: %v", err)
+		return
+	}
+	if _, err := conn.WriteToUDP(data, to); err != nil {
+		klog.V(2).Infof("Failed to send virtual IP advertisement to %s: %v", to, err)
+	}
+}
+
+// acquire adds the virtual IP to the interface and announces it, for the
+// neighbors to update the MAC address they send it to.
+func (m *Manager) acquire(link netlink.Link, addr *netlink.Addr) error {
+	if err := netlink.AddrReplace(link, addr); err != nil {
+		return err
+	}
+	if m.address.To4() != nil {
+		// The peer still holds the virtual IP in the ARP caches of the
+		// neighbors until a gratuitous ARP replaces it.
+		out, err := exec.Command("arping", "-U", "-c", "3", "-I", link.Attrs().Name, m.address.String()).CombinedOutput()
+		if err != nil {
+			klog.Warningf("Failed to send gratuitous ARP for the virtual IP %s: %v: %s", m.address, err, out)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) release(link netlink.Link, addr *netlink.Addr) error {
+	addrs, err := netlink.AddrList(link, netlink.FAMILY_ALL)
+	if err != nil {
+		return err
+	}
+	for _, a := range addrs {
+		if a.IP.Equal(m.address) {
+			return netlink.AddrDel(link, addr)
+		}
+	}
+	return nil
+}
+
+// link returns the interface of the virtual IP, the one of the node IP when
+// not configured.
+func (m *Manager) link() (netlink.Link, error) {
+	if name := m.cfg.VirtualIP.Interface; name != "" {
+		link, err := netlink.LinkByName(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find virtualIP.interface %q: %w", name, err)
+		}
+		return link, nil
+	}
+	links, err := netlink.LinkList()
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		addrs, err := netlink.AddrList(link, netlink.FAMILY_ALL)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range addrs {
+			if a.IP.Equal(m.election.ip) {
+				return link, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no interface holds the node IP %s, set virtualIP.interface", m.election.ip)
+}
+
+func apiServerHealthy(ctx context.Context, client kubernetes.Interface) bool {
+	ctx, cancel := context.WithTimeout(ctx, healthTimeout)
+	defer cancel()
+	_, err := client.Discovery().RESTClient().Get().AbsPath("/readyz").DoRaw(ctx)
+	if err != nil {
+		klog.V(2).Infof("API server is not healthy: %v", err)
+	}
+	return err == nil
+}
+
+func hostPrefix(ip net.IP) int {
+	if ip.To4() != nil {
+		return 32
+	}
+	return 128
+}